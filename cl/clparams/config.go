@@ -302,6 +302,10 @@ type BeaconChainConfig struct {
 	MaxDeposits          uint64 `yaml:"MAX_DEPOSITS" spec:"true"`           // MaxDeposits defines the maximum number of validator deposits in a block.
 	MaxVoluntaryExits    uint64 `yaml:"MAX_VOLUNTARY_EXITS" spec:"true"`    // MaxVoluntaryExits defines the maximum number of validator exits in a block.
 
+	// Capella withdrawals constants.
+	MaxWithdrawalsPerPayload         uint64 `yaml:"MAX_WITHDRAWALS_PER_PAYLOAD" spec:"true"`          // MaxWithdrawalsPerPayload defines the maximum number of withdrawals in an execution payload.
+	MaxValidatorsPerWithdrawalsSweep uint64 `yaml:"MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP" spec:"true"` // MaxValidatorsPerWithdrawalsSweep bounds the number of validators visited by the withdrawal sweep per payload.
+
 	// BLS domain values.
 	DomainBeaconProposer              [4]byte `yaml:"DOMAIN_BEACON_PROPOSER" spec:"true"`                // DomainBeaconProposer defines the BLS signature domain for beacon proposal verification.
 	DomainRandao                      [4]byte `yaml:"DOMAIN_RANDAO" spec:"true"`                         // DomainRandao defines the BLS signature domain for randao verification.
@@ -538,6 +542,10 @@ var MainnetBeaconConfig BeaconChainConfig = BeaconChainConfig{
 	MaxDeposits:          16,
 	MaxVoluntaryExits:    16,
 
+	// Capella withdrawals constants.
+	MaxWithdrawalsPerPayload:         16,
+	MaxValidatorsPerWithdrawalsSweep: 16384,
+
 	// BLS domain values.
 	DomainBeaconProposer:              utils.Uint32ToBytes4(0x00000000),
 	DomainBeaconAttester:              utils.Uint32ToBytes4(0x01000000),
@@ -49,20 +49,19 @@ const (
 	MaxExecutionChanges  = 16
 )
 
-func getBeaconBlockMinimumSize(v clparams.StateVersion) (size uint32) {
-	switch v {
-	case clparams.CapellaVersion:
-		size = 388
-	case clparams.BellatrixVersion:
-		size = 384
-	case clparams.AltairVersion:
-		size = 380
-	case clparams.Phase0Version:
-		size = 220
-	default:
-		panic("unimplemented version")
-	}
-	return
+// BeaconBodyContainer is the layout of BeaconBody
+var BeaconBodyContainer = VersionedContainer{
+	{Name: "RandaoReveal", Since: clparams.Phase0Version, Size: 96},
+	{Name: "Eth1Data", Since: clparams.Phase0Version, Size: 72},
+	{Name: "Graffiti", Since: clparams.Phase0Version, Size: 32},
+	{Name: "ProposerSlashings", Since: clparams.Phase0Version, Size: OffsetSize},
+	{Name: "AttesterSlashings", Since: clparams.Phase0Version, Size: OffsetSize},
+	{Name: "Attestations", Since: clparams.Phase0Version, Size: OffsetSize},
+	{Name: "Deposits", Since: clparams.Phase0Version, Size: OffsetSize},
+	{Name: "VoluntaryExits", Since: clparams.Phase0Version, Size: OffsetSize},
+	{Name: "SyncAggregate", Since: clparams.AltairVersion, Size: 160},
+	{Name: "ExecutionPayload", Since: clparams.BellatrixVersion, Size: OffsetSize},
+	{Name: "ExecutionChanges", Since: clparams.CapellaVersion, Size: OffsetSize},
 }
 
 type SignedBeaconBlock struct {
@@ -121,7 +120,7 @@ func (b *BeaconBody) EncodeSSZ(dst []byte) ([]byte, error) {
 	buf := dst
 	var err error
 	//start := len(buf)
-	offset := getBeaconBlockMinimumSize(b.Version)
+	offset := uint32(BeaconBodyContainer.FixedSize(b.Version))
 	// Write "easy" fields
 	buf = append(buf, b.RandaoReveal[:]...)
 	if buf, err = b.Eth1Data.EncodeSSZ(buf); err != nil {
@@ -151,14 +150,14 @@ func (b *BeaconBody) EncodeSSZ(dst []byte) ([]byte, error) {
 	buf = append(buf, ssz_utils.OffsetSSZ(offset)...)
 	offset += uint32(len(b.VoluntaryExits)) * 112
 	// Encode Sync Aggregate
-	if b.Version >= clparams.AltairVersion {
+	if BeaconBodyContainer.Has("SyncAggregate", b.Version) {
 		buf = b.SyncAggregate.EncodeSSZ(buf)
 	}
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		buf = append(buf, ssz_utils.OffsetSSZ(offset)...)
 		offset += uint32(b.ExecutionPayload.EncodingSizeSSZ(b.Version))
 	}
-	if b.Version >= clparams.CapellaVersion {
+	if BeaconBodyContainer.Has("ExecutionChanges", b.Version) {
 		buf = append(buf, ssz_utils.OffsetSSZ(offset)...)
 	}
 	// Now start encoding the rest of the fields.
@@ -220,14 +219,14 @@ func (b *BeaconBody) EncodeSSZ(dst []byte) ([]byte, error) {
 		buf = exit.EncodeSSZ(buf)
 	}
 
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		buf, err = b.ExecutionPayload.EncodeSSZ(buf, b.Version)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if b.Version >= clparams.CapellaVersion {
+	if BeaconBodyContainer.Has("ExecutionChanges", b.Version) {
 		for _, change := range b.ExecutionChanges {
 			if buf, err = change.EncodeSSZ(buf); err != nil {
 				return nil, err
@@ -238,7 +237,7 @@ func (b *BeaconBody) EncodeSSZ(dst []byte) ([]byte, error) {
 }
 
 func (b *BeaconBody) EncodingSizeSSZ() (size int) {
-	size = int(getBeaconBlockMinimumSize(b.Version))
+	size = BeaconBodyContainer.FixedSize(b.Version)
 
 	size += len(b.ProposerSlashings) * 416
 
@@ -255,14 +254,14 @@ func (b *BeaconBody) EncodingSizeSSZ() (size int) {
 	size += len(b.Deposits) * 1240
 	size += len(b.VoluntaryExits) * 112
 
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		if b.ExecutionPayload == nil {
 			b.ExecutionPayload = new(Eth1Block)
 		}
 		size += b.ExecutionPayload.EncodingSizeSSZ(b.Version)
 	}
 
-	if b.Version >= clparams.CapellaVersion {
+	if BeaconBodyContainer.Has("ExecutionChanges", b.Version) {
 		for _, change := range b.ExecutionChanges {
 			size += change.EncodingSizeSSZ()
 		}
@@ -282,38 +281,41 @@ func (b *BeaconBody) DecodeSSZ(buf []byte, version clparams.StateVersion) error
 	copy(b.RandaoReveal[:], buf)
 	// Decode ethereum 1 data.
 	b.Eth1Data = new(Eth1Data)
-	if err := b.Eth1Data.DecodeSSZ(buf[96:168]); err != nil {
+	eth1DataPos := BeaconBodyContainer.Position("Eth1Data", version)
+	if err := b.Eth1Data.DecodeSSZ(buf[eth1DataPos : eth1DataPos+72]); err != nil {
 		return err
 	}
 	// Decode graffiti.
-	b.Graffiti = libcommon.Copy(buf[168:200])
+	graffitiPos := BeaconBodyContainer.Position("Graffiti", version)
+	b.Graffiti = libcommon.Copy(buf[graffitiPos : graffitiPos+32])
 
 	// Decode offsets
-	offSetProposerSlashings := ssz_utils.DecodeOffset(buf[200:])
-	offsetAttesterSlashings := ssz_utils.DecodeOffset(buf[204:])
-	offsetAttestations := ssz_utils.DecodeOffset(buf[208:])
-	offsetDeposits := ssz_utils.DecodeOffset(buf[212:])
-	offsetExits := ssz_utils.DecodeOffset(buf[216:])
+	offset := func(name string) uint32 {
+		return ssz_utils.DecodeOffset(buf[BeaconBodyContainer.Position(name, version):])
+	}
+	offSetProposerSlashings := offset("ProposerSlashings")
+	offsetAttesterSlashings := offset("AttesterSlashings")
+	offsetAttestations := offset("Attestations")
+	offsetDeposits := offset("Deposits")
+	offsetExits := offset("VoluntaryExits")
 	// Decode sync aggregate if we are past altair.
-	if version >= clparams.AltairVersion {
-		if len(buf) < 380 {
-			return ssz_utils.ErrLowBufferSize
-		}
+	if BeaconBodyContainer.Has("SyncAggregate", version) {
+		pos := BeaconBodyContainer.Position("SyncAggregate", version)
 		b.SyncAggregate = new(SyncAggregate)
-		if err := b.SyncAggregate.DecodeSSZ(buf[220:380]); err != nil {
+		if err := b.SyncAggregate.DecodeSSZ(buf[pos : pos+160]); err != nil {
 			return err
 		}
 	}
 
 	// Execution Payload offset if past bellatrix.
 	var offsetExecution uint32
-	if version >= clparams.BellatrixVersion {
-		offsetExecution = ssz_utils.DecodeOffset(buf[380:])
+	if BeaconBodyContainer.Has("ExecutionPayload", version) {
+		offsetExecution = offset("ExecutionPayload")
 	}
 	// Execution to BLS changes
 	var blsChangesOffset uint32
-	if version >= clparams.CapellaVersion {
-		blsChangesOffset = ssz_utils.DecodeOffset(buf[384:])
+	if BeaconBodyContainer.Has("ExecutionChanges", version) {
+		blsChangesOffset = offset("ExecutionChanges")
 	}
 	// Decode Proposer slashings
 	proposerSlashingLength := 416
@@ -340,7 +342,7 @@ func (b *BeaconBody) DecodeSSZ(buf []byte, version clparams.StateVersion) error
 	// Decode exits
 	exitLength := 112
 	endOffset := len(buf)
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		endOffset = int(offsetExecution)
 	}
 	b.VoluntaryExits, err = ssz_utils.DecodeStaticList[*SignedVoluntaryExit](buf, offsetExits, uint32(endOffset), uint32(exitLength), MaxVoluntaryExits)
@@ -349,10 +351,10 @@ func (b *BeaconBody) DecodeSSZ(buf []byte, version clparams.StateVersion) error
 	}
 
 	endOffset = len(buf)
-	if b.Version >= clparams.CapellaVersion {
+	if BeaconBodyContainer.Has("ExecutionChanges", b.Version) {
 		endOffset = int(blsChangesOffset)
 	}
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		b.ExecutionPayload = new(Eth1Block)
 		if offsetExecution > uint32(endOffset) || len(buf) < endOffset {
 			return ssz_utils.ErrBadOffset
@@ -362,7 +364,7 @@ func (b *BeaconBody) DecodeSSZ(buf []byte, version clparams.StateVersion) error
 		}
 	}
 
-	if b.Version >= clparams.CapellaVersion {
+	if BeaconBodyContainer.Has("ExecutionChanges", b.Version) {
 		if b.ExecutionChanges, err = ssz_utils.DecodeStaticList[*SignedBLSToExecutionChange](buf, blsChangesOffset, uint32(len(buf)), 172, MaxExecutionChanges); err != nil {
 			return err
 		}
@@ -420,14 +422,14 @@ func (b *BeaconBody) HashSSZ() ([32]byte, error) {
 	}
 	leaves = append(leaves, exitLeaf)
 	// Sync aggreate leaf
-	if b.Version >= clparams.AltairVersion {
+	if BeaconBodyContainer.Has("SyncAggregate", b.Version) {
 		aggLeaf, err := b.SyncAggregate.HashSSZ()
 		if err != nil {
 			return [32]byte{}, err
 		}
 		leaves = append(leaves, aggLeaf)
 	}
-	if b.Version >= clparams.BellatrixVersion {
+	if BeaconBodyContainer.Has("ExecutionPayload", b.Version) {
 		payloadLeaf, err := b.ExecutionPayload.HashSSZ(b.Version)
 		if err != nil {
 			return [32]byte{}, err
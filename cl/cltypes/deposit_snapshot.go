@@ -0,0 +1,169 @@
+package cltypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strconv"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// DepositContractDepth is the depth of the merkle tree of the deposit contract.
+const DepositContractDepth = 32
+
+const depositSnapshotStaticSize = 4 + length.Hash*2 + 8*2
+
+// DepositSnapshot is the finalized part of the deposit tree (EIP-4881): the roots of the full subtrees over the
+// finalized deposits, from left to right. A node starting from it doesn't need the deposit logs before the
+// execution block of the snapshot.
+type DepositSnapshot struct {
+	Finalized            []libcommon.Hash
+	DepositRoot          libcommon.Hash
+	DepositCount         uint64
+	ExecutionBlockHash   libcommon.Hash
+	ExecutionBlockHeight uint64
+}
+
+// calculateRoot returns the root of the deposit tree of the snapshot, mixed in with the number of deposits like the
+// deposit contract does. There must be a finalized subtree per bit of the number of deposits.
+func (d *DepositSnapshot) calculateRoot() libcommon.Hash {
+	size := d.DepositCount
+	index := len(d.Finalized)
+	root := merkle_tree.ZeroHashes[0]
+	for level := 0; level < DepositContractDepth; level++ {
+		if size&1 == 1 {
+			index--
+			root = utils.Keccak256(d.Finalized[index][:], root[:])
+		} else {
+			root = utils.Keccak256(root[:], merkle_tree.ZeroHashes[level][:])
+		}
+		size >>= 1
+	}
+	count := merkle_tree.Uint64Root(d.DepositCount)
+	return utils.Keccak256(root[:], count[:])
+}
+
+// Verify checks that the finalized subtrees match the number of deposits and the deposit root.
+func (d *DepositSnapshot) Verify() error {
+	if d.DepositCount >= 1<<DepositContractDepth {
+		return fmt.Errorf("deposit snapshot: %d deposits over the capacity of the tree", d.DepositCount)
+	}
+	if len(d.Finalized) != bits.OnesCount64(d.DepositCount) {
+		return fmt.Errorf("deposit snapshot: %d finalized subtrees for %d deposits", len(d.Finalized), d.DepositCount)
+	}
+	if root := d.calculateRoot(); root != d.DepositRoot {
+		return fmt.Errorf("deposit snapshot: root %x, expected %x", root, d.DepositRoot)
+	}
+	return nil
+}
+
+func (d *DepositSnapshot) EncodeSSZ(buf []byte) ([]byte, error) {
+	dst := buf
+	dst = append(dst, ssz_utils.OffsetSSZ(depositSnapshotStaticSize)...)
+	dst = append(dst, d.DepositRoot[:]...)
+	dst = append(dst, ssz_utils.Uint64SSZ(d.DepositCount)...)
+	dst = append(dst, d.ExecutionBlockHash[:]...)
+	dst = append(dst, ssz_utils.Uint64SSZ(d.ExecutionBlockHeight)...)
+	for _, subtree := range d.Finalized {
+		dst = append(dst, subtree[:]...)
+	}
+	return dst, nil
+}
+
+func (d *DepositSnapshot) DecodeSSZ(buf []byte) error {
+	if len(buf) < depositSnapshotStaticSize {
+		return ssz_utils.ErrLowBufferSize
+	}
+	offset := ssz_utils.DecodeOffset(buf)
+	if offset != depositSnapshotStaticSize {
+		return ssz_utils.ErrBadOffset
+	}
+	copy(d.DepositRoot[:], buf[4:36])
+	d.DepositCount = ssz_utils.UnmarshalUint64SSZ(buf[36:44])
+	copy(d.ExecutionBlockHash[:], buf[44:76])
+	d.ExecutionBlockHeight = ssz_utils.UnmarshalUint64SSZ(buf[76:84])
+	var err error
+	d.Finalized, err = ssz_utils.DecodeHashList(buf, offset, uint32(len(buf)), DepositContractDepth)
+	return err
+}
+
+func (d *DepositSnapshot) DecodeSSZWithVersion(buf []byte, _ int) error {
+	return d.DecodeSSZ(buf)
+}
+
+func (d *DepositSnapshot) EncodingSizeSSZ() int {
+	return depositSnapshotStaticSize + len(d.Finalized)*length.Hash
+}
+
+func (d *DepositSnapshot) HashSSZ() ([32]byte, error) {
+	finalized := make([][32]byte, len(d.Finalized))
+	for i, subtree := range d.Finalized {
+		finalized[i] = subtree
+	}
+	finalizedRoot, err := merkle_tree.ArraysRootWithLimit(finalized, DepositContractDepth)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return merkle_tree.ArraysRoot([][32]byte{
+		finalizedRoot,
+		d.DepositRoot,
+		merkle_tree.Uint64Root(d.DepositCount),
+		d.ExecutionBlockHash,
+		merkle_tree.Uint64Root(d.ExecutionBlockHeight),
+	}, 8)
+}
+
+// depositSnapshotJSON is the encoding of the Beacon API, with the numbers as decimal strings.
+type depositSnapshotJSON struct {
+	Finalized            []libcommon.Hash `json:"finalized"`
+	DepositRoot          libcommon.Hash   `json:"deposit_root"`
+	DepositCount         string           `json:"deposit_count"`
+	ExecutionBlockHash   libcommon.Hash   `json:"execution_block_hash"`
+	ExecutionBlockHeight string           `json:"execution_block_height"`
+}
+
+func (d *DepositSnapshot) MarshalJSON() ([]byte, error) {
+	finalized := d.Finalized
+	if finalized == nil {
+		finalized = []libcommon.Hash{}
+	}
+	return json.Marshal(depositSnapshotJSON{
+		Finalized:            finalized,
+		DepositRoot:          d.DepositRoot,
+		DepositCount:         strconv.FormatUint(d.DepositCount, 10),
+		ExecutionBlockHash:   d.ExecutionBlockHash,
+		ExecutionBlockHeight: strconv.FormatUint(d.ExecutionBlockHeight, 10),
+	})
+}
+
+func (d *DepositSnapshot) UnmarshalJSON(input []byte) error {
+	var dec depositSnapshotJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	count, err := strconv.ParseUint(dec.DepositCount, 10, 64)
+	if err != nil {
+		return fmt.Errorf("deposit_count: %w", err)
+	}
+	height, err := strconv.ParseUint(dec.ExecutionBlockHeight, 10, 64)
+	if err != nil {
+		return fmt.Errorf("execution_block_height: %w", err)
+	}
+	if len(dec.Finalized) > DepositContractDepth {
+		return fmt.Errorf("finalized: %d subtrees, at most %d", len(dec.Finalized), DepositContractDepth)
+	}
+	*d = DepositSnapshot{
+		Finalized:            dec.Finalized,
+		DepositRoot:          dec.DepositRoot,
+		DepositCount:         count,
+		ExecutionBlockHash:   dec.ExecutionBlockHash,
+		ExecutionBlockHeight: height,
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cltypes_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// testDepositSnapshot returns the snapshot of a deposit tree of 5 leaves: the root of the first 4 and the 5th.
+func testDepositSnapshot(t *testing.T) *cltypes.DepositSnapshot {
+	leaves := make([][32]byte, 5)
+	for i := range leaves {
+		leaves[i][0] = byte(i + 1)
+	}
+	tree, err := merkle_tree.MerkleizeVector(append([][32]byte{}, leaves...), 1<<cltypes.DepositContractDepth)
+	require.NoError(t, err)
+	count := merkle_tree.Uint64Root(5)
+	firstFour, err := merkle_tree.MerkleizeVector(append([][32]byte{}, leaves[:4]...), 4)
+	require.NoError(t, err)
+	return &cltypes.DepositSnapshot{
+		Finalized:            []libcommon.Hash{firstFour, leaves[4]},
+		DepositRoot:          utils.Keccak256(tree[:], count[:]),
+		DepositCount:         5,
+		ExecutionBlockHash:   libcommon.HexToHash("0x3"),
+		ExecutionBlockHeight: 1234,
+	}
+}
+
+func TestDepositSnapshotVerify(t *testing.T) {
+	snapshot := testDepositSnapshot(t)
+	require.NoError(t, snapshot.Verify())
+
+	snapshot.DepositCount = 6
+	require.Error(t, snapshot.Verify())
+	snapshot.DepositCount = 5
+	snapshot.Finalized[1][0]++
+	require.Error(t, snapshot.Verify())
+
+	empty := &cltypes.DepositSnapshot{}
+	empty.DepositRoot = utils.Keccak256(merkle_tree.ZeroHashes[cltypes.DepositContractDepth][:], make([]byte, 32))
+	require.NoError(t, empty.Verify())
+}
+
+func TestDepositSnapshotEncoding(t *testing.T) {
+	snapshot := testDepositSnapshot(t)
+	encoded, err := snapshot.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, encoded, snapshot.EncodingSizeSSZ())
+	decoded := &cltypes.DepositSnapshot{}
+	require.NoError(t, decoded.DecodeSSZ(encoded))
+	require.Equal(t, snapshot, decoded)
+
+	encoded, err = json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), `"deposit_count":"5"`)
+	decoded = &cltypes.DepositSnapshot{}
+	require.NoError(t, json.Unmarshal(encoded, decoded))
+	require.Equal(t, snapshot, decoded)
+}
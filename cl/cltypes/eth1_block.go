@@ -28,8 +28,27 @@ func (b *Eth1Block) Withdrawals() types.Withdrawals {
 	return types.Withdrawals(b.Body.Withdrawals)
 }
 
+// ExecutionPayloadContainer is the layout of the SSZ encoding of Eth1Block
+var ExecutionPayloadContainer = VersionedContainer{
+	{Name: "ParentHash", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "FeeRecipient", Since: clparams.BellatrixVersion, Size: 20},
+	{Name: "StateRoot", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "ReceiptsRoot", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "LogsBloom", Since: clparams.BellatrixVersion, Size: 256},
+	{Name: "PrevRandao", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "BlockNumber", Since: clparams.BellatrixVersion, Size: 8},
+	{Name: "GasLimit", Since: clparams.BellatrixVersion, Size: 8},
+	{Name: "GasUsed", Since: clparams.BellatrixVersion, Size: 8},
+	{Name: "Timestamp", Since: clparams.BellatrixVersion, Size: 8},
+	{Name: "ExtraData", Since: clparams.BellatrixVersion, Size: OffsetSize},
+	{Name: "BaseFeePerGas", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "BlockHash", Since: clparams.BellatrixVersion, Size: 32},
+	{Name: "Transactions", Since: clparams.BellatrixVersion, Size: OffsetSize},
+	{Name: "Withdrawals", Since: clparams.CapellaVersion, Size: OffsetSize},
+}
+
 func (b *Eth1Block) EncodingSizeSSZ(version clparams.StateVersion) (size int) {
-	size = 508
+	size = ExecutionPayloadContainer.FixedSize(version)
 
 	if b.Header == nil {
 		return
@@ -42,26 +61,26 @@ func (b *Eth1Block) EncodingSizeSSZ(version clparams.StateVersion) (size int) {
 		size += len(tx)
 	}
 
-	if version >= clparams.CapellaVersion {
-		size += len(b.Body.Withdrawals)*44 + 4
+	if ExecutionPayloadContainer.Has("Withdrawals", version) {
+		size += len(b.Body.Withdrawals) * 44
 	}
 
 	return
 }
 
 func (b *Eth1Block) DecodeSSZ(buf []byte, version clparams.StateVersion) error {
-	if len(buf) < b.EncodingSizeSSZ(clparams.BellatrixVersion) {
+	if len(buf) < ExecutionPayloadContainer.FixedSize(version) {
 		return ssz_utils.ErrLowBufferSize
 	}
 	b.Header = new(types.Header)
 
 	pos := b.Header.DecodeHeaderMetadataForSSZ(buf)
 	// Compute block SSZ offsets.
-	extraDataOffset := ssz_utils.BaseExtraDataSSZOffsetBlock
+	extraDataOffset := ExecutionPayloadContainer.FixedSize(version)
 	transactionsOffset := ssz_utils.DecodeOffset(buf[pos:])
 	pos += 4
 	var withdrawalOffset *uint32
-	if version >= clparams.CapellaVersion {
+	if ExecutionPayloadContainer.Has("Withdrawals", version) {
 		withdrawalOffset = new(uint32)
 		*withdrawalOffset = ssz_utils.DecodeOffset(buf[pos:])
 	}
@@ -156,11 +175,7 @@ func (b *Eth1Block) DecodeSSZ(buf []byte, version clparams.StateVersion) error {
 func (b *Eth1Block) EncodeSSZ(dst []byte, version clparams.StateVersion) ([]byte, error) {
 	buf := dst
 	var err error
-	currentOffset := ssz_utils.BaseExtraDataSSZOffsetBlock
-
-	if version >= clparams.CapellaVersion {
-		currentOffset += 4
-	}
+	currentOffset := ExecutionPayloadContainer.FixedSize(version)
 	buf, err = b.Header.EncodeHeaderMetadataForSSZ(buf, currentOffset)
 	if err != nil {
 		return nil, err
@@ -175,7 +190,7 @@ func (b *Eth1Block) EncodeSSZ(dst []byte, version clparams.StateVersion) ([]byte
 		currentOffset += len(tx) + 4
 	}
 	// Write withdrawals offset if exist
-	if version >= clparams.CapellaVersion {
+	if ExecutionPayloadContainer.Has("Withdrawals", version) {
 		buf = append(buf, ssz_utils.OffsetSSZ(uint32(currentOffset))...)
 	}
 	// Sanity check for extra data then write it.
@@ -194,7 +209,7 @@ func (b *Eth1Block) EncodeSSZ(dst []byte, version clparams.StateVersion) ([]byte
 		buf = append(buf, tx...)
 	}
 
-	if version >= clparams.CapellaVersion {
+	if ExecutionPayloadContainer.Has("Withdrawals", version) {
 		// Append all withdrawals SSZ
 		for _, withdrawal := range body.Withdrawals {
 			buf = append(buf, withdrawal.EncodeSSZ()...)
@@ -208,7 +223,7 @@ func (b *Eth1Block) HashSSZ(version clparams.StateVersion) ([32]byte, error) {
 	if b.Header.TxHashSSZ, err = merkle_tree.TransactionsListRoot(b.Body.Transactions); err != nil {
 		return [32]byte{}, err
 	}
-	if version >= clparams.CapellaVersion {
+	if ExecutionPayloadContainer.Has("Withdrawals", version) {
 		b.Header.WithdrawalsHash = new(libcommon.Hash)
 		if *b.Header.WithdrawalsHash, err = types.Withdrawals(b.Body.Withdrawals).HashSSZ(16); err != nil {
 			return [32]byte{}, err
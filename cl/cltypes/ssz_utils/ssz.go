@@ -11,7 +11,6 @@ import (
 
 var (
 	BaseExtraDataSSZOffsetHeader = 536
-	BaseExtraDataSSZOffsetBlock  = 508
 )
 
 type HashableSSZ interface {
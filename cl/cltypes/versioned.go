@@ -0,0 +1,63 @@
+package cltypes
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+)
+
+// OffsetSize is the size in the fixed part of a container of a variable-size field: its offset
+const OffsetSize = 4
+
+// VersionedField is a field of a container which is part of it from fork Since on
+type VersionedField struct {
+	Name  string
+	Since clparams.StateVersion
+	// Size in the fixed part of the container, OffsetSize for variable-size fields
+	Size int
+}
+
+// VersionedContainer lists the fields of a container over all forks, in SSZ order. Sizes and positions in the
+// fixed part follow for each fork, so adding a fork only adds fields here.
+type VersionedContainer []VersionedField
+
+// Fields returns the fields of the container at version
+func (c VersionedContainer) Fields(version clparams.StateVersion) []VersionedField {
+	fields := make([]VersionedField, 0, len(c))
+	for _, field := range c {
+		if field.Since <= version {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Has returns whether the container has the field at version
+func (c VersionedContainer) Has(name string, version clparams.StateVersion) bool {
+	for _, field := range c {
+		if field.Name == name {
+			return field.Since <= version
+		}
+	}
+	panic(fmt.Sprintf("no field %s", name))
+}
+
+// FixedSize returns the size of the fixed part of the container at version, where the first variable-size
+// field starts.
+func (c VersionedContainer) FixedSize(version clparams.StateVersion) (size int) {
+	for _, field := range c.Fields(version) {
+		size += field.Size
+	}
+	return
+}
+
+// Position returns the position of the field (of its offset for variable-size fields) at version
+func (c VersionedContainer) Position(name string, version clparams.StateVersion) (pos int) {
+	for _, field := range c.Fields(version) {
+		if field.Name == name {
+			return pos
+		}
+		pos += field.Size
+	}
+	panic(fmt.Sprintf("no field %s at version %d", name, version))
+}
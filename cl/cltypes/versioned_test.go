@@ -0,0 +1,50 @@
+package cltypes_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestBeaconBodyContainer(t *testing.T) {
+	for version, size := range map[clparams.StateVersion]int{
+		clparams.Phase0Version:    220,
+		clparams.AltairVersion:    380,
+		clparams.BellatrixVersion: 384,
+		clparams.CapellaVersion:   388,
+	} {
+		require.Equal(t, size, cltypes.BeaconBodyContainer.FixedSize(version), version)
+	}
+	require.Equal(t, 220, cltypes.BeaconBodyContainer.Position("SyncAggregate", clparams.AltairVersion))
+	require.Equal(t, 384, cltypes.BeaconBodyContainer.Position("ExecutionChanges", clparams.CapellaVersion))
+	require.Len(t, cltypes.BeaconBodyContainer.Fields(clparams.Phase0Version), 8)
+	require.Len(t, cltypes.BeaconBodyContainer.Fields(clparams.CapellaVersion), 11)
+	require.False(t, cltypes.BeaconBodyContainer.Has("ExecutionPayload", clparams.AltairVersion))
+	require.True(t, cltypes.BeaconBodyContainer.Has("ExecutionPayload", clparams.BellatrixVersion))
+	require.Panics(t, func() { cltypes.BeaconBodyContainer.Position("ExecutionPayload", clparams.AltairVersion) })
+}
+
+func TestExecutionPayloadContainer(t *testing.T) {
+	require.Equal(t, 508, cltypes.ExecutionPayloadContainer.FixedSize(clparams.BellatrixVersion))
+	require.Equal(t, 512, cltypes.ExecutionPayloadContainer.FixedSize(clparams.CapellaVersion))
+	require.Equal(t, 436, cltypes.ExecutionPayloadContainer.Position("ExtraData", clparams.CapellaVersion))
+
+	// the variable-size fields start after the withdrawals offset
+	payload := &cltypes.Eth1Block{
+		Header: &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(7), Extra: []byte("extra")},
+		Body:   &types.RawBody{Transactions: [][]byte{{1, 2, 3}}, Withdrawals: []*types.Withdrawal{{Index: 9}}},
+	}
+	encoded, err := payload.EncodeSSZ(nil, clparams.CapellaVersion)
+	require.NoError(t, err)
+	require.Len(t, encoded, payload.EncodingSizeSSZ(clparams.CapellaVersion))
+	decoded := new(cltypes.Eth1Block)
+	require.NoError(t, decoded.DecodeSSZ(encoded, clparams.CapellaVersion))
+	require.Equal(t, []byte("extra"), decoded.Header.Extra)
+	require.Equal(t, payload.Body.Transactions, decoded.Body.Transactions)
+	require.Equal(t, uint64(9), decoded.Body.Withdrawals[0].Index)
+}
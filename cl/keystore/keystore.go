@@ -0,0 +1,176 @@
+// Package keystore decrypts the BLS keystores of validators (EIP-2335), as written by the deposit CLI.
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ledgerwatch/erigon/common/secret"
+)
+
+var ErrWrongPassword = errors.New("keystore: wrong password")
+
+type module struct {
+	Function string          `json:"function"`
+	Params   json.RawMessage `json:"params"`
+	Message  string          `json:"message"`
+}
+
+// Keystore is an EIP-2335 keystore
+type Keystore struct {
+	Crypto struct {
+		Kdf      module `json:"kdf"`
+		Checksum module `json:"checksum"`
+		Cipher   module `json:"cipher"`
+	} `json:"crypto"`
+	Description string `json:"description"`
+	Pubkey      string `json:"pubkey"`
+	Path        string `json:"path"`
+	UUID        string `json:"uuid"`
+	Version     int    `json:"version"`
+}
+
+func Parse(data []byte) (*Keystore, error) {
+	k := &Keystore{}
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	if k.Version != 4 {
+		return nil, fmt.Errorf("keystore: unsupported version %d", k.Version)
+	}
+	return k, nil
+}
+
+// PublicKey returns the public key the keystore declares, it is not checked against the secret key.
+func (k *Keystore) PublicKey() (pubkey [48]byte, err error) {
+	b, err := hex.DecodeString(k.Pubkey)
+	if err != nil {
+		return pubkey, fmt.Errorf("keystore: pubkey: %w", err)
+	}
+	if len(b) != len(pubkey) {
+		return pubkey, fmt.Errorf("keystore: pubkey of %d bytes", len(b))
+	}
+	copy(pubkey[:], b)
+	return pubkey, nil
+}
+
+// Decrypt returns the secret key held by the keystore.
+func (k *Keystore) Decrypt(password []byte) (*secret.Secret, error) {
+	decryptionKey, err := k.decryptionKey(processPassword(password))
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Zero(decryptionKey)
+	cipherMessage, err := hex.DecodeString(k.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: cipher message: %w", err)
+	}
+
+	if k.Crypto.Checksum.Function != "sha256" {
+		return nil, fmt.Errorf("keystore: unsupported checksum function %s", k.Crypto.Checksum.Function)
+	}
+	expected, err := hex.DecodeString(k.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: checksum message: %w", err)
+	}
+	checksum := sha256.Sum256(append(append([]byte{}, decryptionKey[16:32]...), cipherMessage...))
+	if !bytes.Equal(checksum[:], expected) {
+		return nil, ErrWrongPassword
+	}
+
+	if k.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, fmt.Errorf("keystore: unsupported cipher function %s", k.Crypto.Cipher.Function)
+	}
+	var cipherParams struct {
+		IV string `json:"iv"`
+	}
+	if err := json.Unmarshal(k.Crypto.Cipher.Params, &cipherParams); err != nil {
+		return nil, fmt.Errorf("keystore: cipher params: %w", err)
+	}
+	iv, err := hex.DecodeString(cipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: cipher iv: %w", err)
+	}
+	block, err := aes.NewCipher(decryptionKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("keystore: cipher iv of %d bytes", len(iv))
+	}
+	secretKey := make([]byte, len(cipherMessage))
+	cipher.NewCTR(block, iv).XORKeyStream(secretKey, cipherMessage)
+	return secret.New(secretKey), nil
+}
+
+func (k *Keystore) decryptionKey(password []byte) ([]byte, error) {
+	defer secret.Zero(password)
+	switch k.Crypto.Kdf.Function {
+	case "scrypt":
+		var params struct {
+			Dklen int    `json:"dklen"`
+			N     int    `json:"n"`
+			P     int    `json:"p"`
+			R     int    `json:"r"`
+			Salt  string `json:"salt"`
+		}
+		if err := json.Unmarshal(k.Crypto.Kdf.Params, &params); err != nil {
+			return nil, fmt.Errorf("keystore: kdf params: %w", err)
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("keystore: kdf salt: %w", err)
+		}
+		if params.Dklen < 32 {
+			return nil, fmt.Errorf("keystore: kdf dklen %d", params.Dklen)
+		}
+		return scrypt.Key(password, salt, params.N, params.R, params.P, params.Dklen)
+	case "pbkdf2":
+		var params struct {
+			Dklen int    `json:"dklen"`
+			C     int    `json:"c"`
+			Prf   string `json:"prf"`
+			Salt  string `json:"salt"`
+		}
+		if err := json.Unmarshal(k.Crypto.Kdf.Params, &params); err != nil {
+			return nil, fmt.Errorf("keystore: kdf params: %w", err)
+		}
+		if params.Prf != "hmac-sha256" {
+			return nil, fmt.Errorf("keystore: unsupported kdf prf %s", params.Prf)
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("keystore: kdf salt: %w", err)
+		}
+		if params.Dklen < 32 {
+			return nil, fmt.Errorf("keystore: kdf dklen %d", params.Dklen)
+		}
+		return pbkdf2.Key(password, salt, params.C, params.Dklen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("keystore: unsupported kdf function %s", k.Crypto.Kdf.Function)
+	}
+}
+
+// processPassword normalizes password to NFKD and strips its control codes, as EIP-2335 requires.
+func processPassword(password []byte) []byte {
+	normalized := norm.NFKD.String(string(password))
+	processed := make([]byte, 0, len(normalized))
+	for _, r := range normalized {
+		if unicode.IsControl(r) {
+			continue
+		}
+		processed = append(processed, string(r)...)
+	}
+	return processed
+}
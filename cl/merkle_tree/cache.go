@@ -0,0 +1,184 @@
+package merkle_tree
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/prysmaticlabs/gohashtree"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// Cache keeps the nodes of the merkle tree over the chunks of a list or a vector, so that its root is recomputed by
+// hashing only the branches of the chunks which changed since the previous computation instead of the whole tree.
+type Cache struct {
+	depth  uint8        // depth of the tree holding the limit of chunks
+	layers [][][32]byte // layers[0] are the chunks, layers[l+1] the parents of layers[l], up to layers[depth]
+	stale  []uint64     // chunks changed since the last Root
+	built  bool
+}
+
+// NewCache returns an empty cache for a tree of limit chunks: the length of a vector, or the chunk limit of a list.
+func NewCache(limit uint64) *Cache {
+	return &Cache{depth: getDepth(limit)}
+}
+
+// Invalidate marks chunk i as changed, it is computed again by the next Root.
+func (c *Cache) Invalidate(i uint64) {
+	if c.built {
+		c.stale = append(c.stale, i)
+	}
+}
+
+// Reset drops the cached nodes, the next Root computes all the chunks.
+func (c *Cache) Reset() {
+	c.layers, c.stale, c.built = nil, nil, false
+}
+
+// Copy returns a cache with the same nodes, which is not affected by changes to c.
+func (c *Cache) Copy() *Cache {
+	cpy := &Cache{depth: c.depth, built: c.built, stale: append([]uint64{}, c.stale...)}
+	cpy.layers = make([][][32]byte, len(c.layers))
+	for l, layer := range c.layers {
+		cpy.layers[l] = append([][32]byte{}, layer...)
+	}
+	return cpy
+}
+
+// Clean returns whether Root over count chunks returns the cached root, without changing the cache: it can then be
+// called concurrently.
+func (c *Cache) Clean(count uint64) bool {
+	return c.built && len(c.stale) == 0 && uint64(len(c.layers[0])) == count
+}
+
+// Root returns the root of the tree over count chunks padded with zero chunks. chunk computes the chunks invalidated
+// since the previous call and the chunks added since, all of them the first time.
+func (c *Cache) Root(count uint64, chunk func(i uint64) ([32]byte, error)) ([32]byte, error) {
+	if !c.built {
+		return c.build(count, chunk)
+	}
+	if c.Clean(count) {
+		return c.root(count), nil
+	}
+	leaves := c.layers[0]
+	dirty := c.stale
+	c.stale = nil
+	if prev := uint64(len(leaves)); count < prev {
+		leaves = leaves[:count]
+		if count > 0 {
+			// the branch of the new last chunk gets zero hashes on its right
+			dirty = append(dirty, count-1)
+		}
+	} else {
+		for i := prev; i < count; i++ {
+			leaves = append(leaves, [32]byte{})
+			dirty = append(dirty, i)
+		}
+	}
+	c.layers[0] = leaves
+	dirty = sortedUnique(dirty, count)
+	var err error
+	for _, i := range dirty {
+		if leaves[i], err = chunk(i); err != nil {
+			c.Reset()
+			return [32]byte{}, err
+		}
+	}
+
+	for l := uint8(0); l < c.depth; l++ {
+		layer := c.layers[l]
+		parentLen := (len(layer) + 1) / 2
+		parent := c.layers[l+1]
+		if len(parent) > parentLen {
+			parent = parent[:parentLen]
+		}
+		for len(parent) < parentLen {
+			parent = append(parent, [32]byte{})
+		}
+		parents := make([]uint64, 0, len(dirty))
+		for _, i := range dirty {
+			if p := i / 2; len(parents) == 0 || parents[len(parents)-1] != p {
+				parents = append(parents, p)
+			}
+		}
+		for _, p := range parents {
+			right := ZeroHashes[l]
+			if 2*p+1 < uint64(len(layer)) {
+				right = layer[2*p+1]
+			}
+			parent[p] = utils.Keccak256(layer[2*p][:], right[:])
+		}
+		c.layers[l+1] = parent
+		dirty = parents
+	}
+	return c.root(count), nil
+}
+
+func (c *Cache) build(count uint64, chunk func(i uint64) ([32]byte, error)) ([32]byte, error) {
+	c.layers = make([][][32]byte, c.depth+1)
+	leaves := make([][32]byte, count)
+	var err error
+	for i := range leaves {
+		if leaves[i], err = chunk(uint64(i)); err != nil {
+			c.Reset()
+			return [32]byte{}, err
+		}
+	}
+	c.layers[0] = leaves
+	for l := uint8(0); l < c.depth; l++ {
+		layer := c.layers[l]
+		parent := make([][32]byte, (len(layer)+1)/2)
+		even := len(layer) &^ 1
+		if even > 0 {
+			if err := gohashtree.Hash(parent[:even/2], layer[:even]); err != nil {
+				c.Reset()
+				return [32]byte{}, err
+			}
+		}
+		if even < len(layer) {
+			parent[len(parent)-1] = utils.Keccak256(layer[even][:], ZeroHashes[l][:])
+		}
+		c.layers[l+1] = parent
+	}
+	c.stale = nil
+	c.built = true
+	return c.root(count), nil
+}
+
+func (c *Cache) root(count uint64) [32]byte {
+	if count == 0 {
+		return ZeroHashes[c.depth]
+	}
+	return c.layers[c.depth][0]
+}
+
+// Node returns the node at generalized index of the tree as of the last Root, 1 being its root. The nodes of the
+// padding are zero hashes.
+func (c *Cache) Node(index uint64) ([32]byte, error) {
+	if !c.built {
+		return [32]byte{}, fmt.Errorf("tree is not computed")
+	}
+	level := bits.Len64(index) - 1
+	if level < 0 || level > int(c.depth) {
+		return [32]byte{}, fmt.Errorf("generalized index %d is out of the tree of depth %d", index, c.depth)
+	}
+	l := c.depth - uint8(level)
+	if i := index - 1<<level; i < uint64(len(c.layers[l])) {
+		return c.layers[l][i], nil
+	}
+	return ZeroHashes[l], nil
+}
+
+// sortedUnique sorts indices and drops the duplicates and those out of count, in place.
+func sortedUnique(indices []uint64, count uint64) []uint64 {
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	out := indices[:0]
+	for _, i := range indices {
+		if i >= count || (len(out) > 0 && out[len(out)-1] == i) {
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package merkle_tree_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+)
+
+func TestCache(t *testing.T) {
+	const limit = 1 << 10
+	rnd := rand.New(rand.NewSource(1))
+	var chunks [][32]byte
+	cache := merkle_tree.NewCache(limit)
+	computed := 0
+	check := func() {
+		computed = 0
+		root, err := cache.Root(uint64(len(chunks)), func(i uint64) ([32]byte, error) {
+			computed++
+			return chunks[i], nil
+		})
+		require.NoError(t, err)
+		expected, err := merkle_tree.MerkleizeVector(append([][32]byte{}, chunks...), limit)
+		require.NoError(t, err)
+		require.Equal(t, expected, root)
+	}
+	check()
+
+	for _, count := range []int{1, 2, 7, 300, 301, 33} {
+		for len(chunks) < count {
+			var chunk [32]byte
+			rnd.Read(chunk[:])
+			chunks = append(chunks, chunk)
+		}
+		chunks = chunks[:count]
+		check()
+	}
+	require.Equal(t, 1, computed) // shrinking only recomputes the new last chunk
+
+	rnd.Read(chunks[5][:])
+	rnd.Read(chunks[20][:])
+	cache.Invalidate(5)
+	cache.Invalidate(20)
+	cache.Invalidate(20)
+	cpy := cache.Copy()
+	check()
+	require.Equal(t, 2, computed)
+
+	// the copy recomputes the same chunks, the original is unaffected
+	root, err := cpy.Root(uint64(len(chunks)), func(i uint64) ([32]byte, error) { return chunks[i], nil })
+	require.NoError(t, err)
+	expected, err := merkle_tree.MerkleizeVector(append([][32]byte{}, chunks...), limit)
+	require.NoError(t, err)
+	require.Equal(t, expected, root)
+
+	chunks = nil
+	check()
+}
@@ -0,0 +1,119 @@
+package merkle_tree
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// Multiproofs over generalized indices, as in https://github.com/ethereum/consensus-specs/blob/dev/ssz/merkle-proofs.md.
+// A generalized index addresses a node of the tree: 1 is the root, the children of node i are 2i and 2i+1.
+
+// MultiProofHelperIndices returns the generalized indices of the nodes needed to prove indices, sorted descending.
+func MultiProofHelperIndices(indices []uint64) []uint64 {
+	branch := map[uint64]struct{}{}
+	path := map[uint64]struct{}{}
+	for _, index := range indices {
+		for i := index; i > 1; i /= 2 {
+			branch[i^1] = struct{}{}
+			path[i] = struct{}{}
+		}
+	}
+	helpers := make([]uint64, 0, len(branch))
+	for i := range branch {
+		if _, ok := path[i]; !ok {
+			helpers = append(helpers, i)
+		}
+	}
+	sort.Slice(helpers, func(i, j int) bool { return helpers[i] > helpers[j] })
+	return helpers
+}
+
+// MerkleTreeNodes returns all the nodes of the tree over leaves, indexed by generalized index.
+func MerkleTreeNodes(leaves [][32]byte) ([][32]byte, error) {
+	if !utils.IsPowerOf2(uint64(len(leaves))) {
+		return nil, fmt.Errorf("number of leaves is not a power of 2: %d", len(leaves))
+	}
+	nodes := make([][32]byte, 2*len(leaves))
+	copy(nodes[len(leaves):], leaves)
+	for i := len(leaves) - 1; i > 0; i-- {
+		nodes[i] = utils.Keccak256(nodes[2*i][:], nodes[2*i+1][:])
+	}
+	return nodes, nil
+}
+
+// MultiProof returns the nodes at indices and the proof (nodes at MultiProofHelperIndices) for the tree over leaves.
+func MultiProof(leaves [][32]byte, indices []uint64) (values, proof [][32]byte, err error) {
+	nodes, err := MerkleTreeNodes(leaves)
+	if err != nil {
+		return nil, nil, err
+	}
+	return MultiProofFromNodes(indices, func(index uint64) ([32]byte, error) {
+		if index == 0 || index >= uint64(len(nodes)) {
+			return [32]byte{}, fmt.Errorf("generalized index %d is out of the tree of %d leaves", index, len(leaves))
+		}
+		return nodes[index], nil
+	})
+}
+
+// MultiProofFromNodes returns the nodes at indices and their proof, node returns the node of the tree at a generalized
+// index. It proves nodes of trees which are not kept in full, such as lists padded to their limit.
+func MultiProofFromNodes(indices []uint64, node func(index uint64) ([32]byte, error)) (values, proof [][32]byte, err error) {
+	for _, index := range indices {
+		value, err := node(index)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, value)
+	}
+	for _, index := range MultiProofHelperIndices(indices) {
+		value, err := node(index)
+		if err != nil {
+			return nil, nil, err
+		}
+		proof = append(proof, value)
+	}
+	return values, proof, nil
+}
+
+// MultiProofRoot computes the root from the nodes at indices and their proof, see MultiProof.
+func MultiProofRoot(values [][32]byte, proof [][32]byte, indices []uint64) ([32]byte, error) {
+	helpers := MultiProofHelperIndices(indices)
+	if len(values) != len(indices) {
+		return [32]byte{}, fmt.Errorf("got %d values for %d indices", len(values), len(indices))
+	}
+	if len(proof) != len(helpers) {
+		return [32]byte{}, fmt.Errorf("got %d proof nodes, expected %d", len(proof), len(helpers))
+	}
+	objects := make(map[uint64][32]byte, len(indices)+len(helpers))
+	for i, index := range indices {
+		objects[index] = values[i]
+	}
+	for i, index := range helpers {
+		objects[index] = proof[i]
+	}
+	keys := make([]uint64, 0, len(objects))
+	for k := range objects {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] > keys[j] })
+	for pos := 0; pos < len(keys); pos++ {
+		k := keys[pos]
+		if k <= 1 {
+			continue
+		}
+		_, hasSibling := objects[k^1]
+		_, hasParent := objects[k/2]
+		if hasSibling && !hasParent {
+			left, right := objects[k&^1], objects[k|1]
+			objects[k/2] = utils.Keccak256(left[:], right[:])
+			keys = append(keys, k/2)
+		}
+	}
+	root, ok := objects[1]
+	if !ok {
+		return [32]byte{}, fmt.Errorf("proof does not reach the root")
+	}
+	return root, nil
+}
@@ -0,0 +1,40 @@
+package merkle_tree_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+)
+
+func TestMultiProofHelperIndices(t *testing.T) {
+	// 8 and 9 are siblings, their parent 4 needs 5; 14 needs 15, then 6 for 7
+	require.Equal(t, []uint64{15, 6, 5}, merkle_tree.MultiProofHelperIndices([]uint64{8, 9, 14}))
+	require.Empty(t, merkle_tree.MultiProofHelperIndices([]uint64{1}))
+	require.Equal(t, []uint64{5, 3}, merkle_tree.MultiProofHelperIndices([]uint64{4}))
+}
+
+func TestMultiProof(t *testing.T) {
+	leaves := make([][32]byte, 8)
+	for i := range leaves {
+		leaves[i][0] = byte(i + 1)
+	}
+	root, err := merkle_tree.MerkleRootFromLeaves(leaves)
+	require.NoError(t, err)
+
+	for _, indices := range [][]uint64{{8}, {8, 9, 14}, {2, 15}, {1}, {3, 4}} {
+		values, proof, err := merkle_tree.MultiProof(leaves, indices)
+		require.NoError(t, err)
+		computed, err := merkle_tree.MultiProofRoot(values, proof, indices)
+		require.NoError(t, err)
+		require.Equal(t, root, computed, "indices %v", indices)
+	}
+
+	_, _, err = merkle_tree.MultiProof(leaves[:3], []uint64{4})
+	require.Error(t, err)
+	_, _, err = merkle_tree.MultiProof(leaves, []uint64{16})
+	require.Error(t, err)
+	_, err = merkle_tree.MultiProofRoot(nil, nil, []uint64{8})
+	require.Error(t, err)
+}
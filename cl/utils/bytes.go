@@ -15,6 +15,7 @@ package utils
 
 import (
 	"encoding/binary"
+	"math/bits"
 
 	"github.com/golang/snappy"
 	"github.com/klauspost/compress/zstd"
@@ -98,3 +99,17 @@ func DecompressZstd(b []byte) ([]byte, error) {
 	}
 	return r.DecodeAll(b, nil)
 }
+
+// IsBitOn returns whether the bit at index of the SSZ bitfield b is set, bits are little-endian within the bytes.
+func IsBitOn(b []byte, index int) bool {
+	return b[index/8]&(1<<(index%8)) != 0
+}
+
+// BitlistLength returns the number of bits of the SSZ bitlist b, whose last byte holds a length bit after its last
+// bit. It returns 0 for an empty list or a missing length bit.
+func BitlistLength(b []byte) int {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return 0
+	}
+	return 8*(len(b)-1) + bits.Len8(b[len(b)-1]) - 1
+}
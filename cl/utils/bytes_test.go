@@ -35,3 +35,16 @@ func TestLiteralConverters(t *testing.T) {
 	require.Equal(t, utils.BytesToBytes4([]byte{10, 23, 56, 7, 8, 5}), [4]byte{10, 23, 56, 7})
 	require.Equal(t, utils.Uint64ToLE(600), []byte{0x58, 0x2, 0x0, 0x0, 0x0, 0x0, 0x00, 0x00})
 }
+
+func TestBitlist(t *testing.T) {
+	bits := []byte{0b00000101, 0b00000110} // bits 0, 2 and 9 set, then the length bit at 10
+	require.Equal(t, 10, utils.BitlistLength(bits))
+	require.True(t, utils.IsBitOn(bits, 0))
+	require.False(t, utils.IsBitOn(bits, 1))
+	require.True(t, utils.IsBitOn(bits, 2))
+	require.True(t, utils.IsBitOn(bits, 9))
+	require.Equal(t, 0, utils.BitlistLength([]byte{0b1}))
+	require.Equal(t, 8, utils.BitlistLength([]byte{0xff, 0b1}))
+	require.Equal(t, 0, utils.BitlistLength(nil))
+	require.Equal(t, 0, utils.BitlistLength([]byte{0xff, 0}))
+}
@@ -6,6 +6,9 @@ import (
 
 // Check if leaf at index verifies against the Merkle root and branch
 func IsValidMerkleBranch(leaf libcommon.Hash, branch []libcommon.Hash, depth uint64, index uint64, root [32]byte) bool {
+	if uint64(len(branch)) < depth {
+		return false
+	}
 	value := leaf
 	for i := uint64(0); i < depth; i++ {
 		if (index / PowerOf2(i) % 2) == 1 {
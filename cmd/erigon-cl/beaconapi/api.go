@@ -0,0 +1,147 @@
+// Package beaconapi serves the endpoints of the Beacon API which the node answers from its database.
+package beaconapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// NewHandler returns the handler of the endpoints served from db.
+func NewHandler(db kv.RoDB, cfg *clparams.BeaconChainConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/deposit_snapshot", depositSnapshot(db))
+	mux.HandleFunc(stateProofPath, stateProof(db, cfg))
+	return mux
+}
+
+// depositSnapshot serves the deposit tree snapshot (EIP-4881), in JSON or in SSZ when it's accepted.
+func depositSnapshot(db kv.RoDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		tx, err := db.BeginRo(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer tx.Rollback()
+		snapshot, err := rawdb.ReadDepositSnapshot(tx)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if snapshot == nil {
+			writeError(w, http.StatusNotFound, "no deposit snapshot")
+			return
+		}
+		if r.Header.Get("Accept") == "application/octet-stream" {
+			encoded, err := snapshot.EncodeSSZ(nil)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			if _, err := w.Write(encoded); err != nil {
+				log.Debug("[Beacon API] Write failed", "err", err)
+			}
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": snapshot})
+	}
+}
+
+const stateProofPath = "/eth/v0/beacon/proof/state/"
+
+// stateProof serves the multiproof of the nodes at the gindex parameters of the state tree of a state, which is
+// "head", a slot or a state root.
+func stateProof(db kv.RoDB, cfg *clparams.BeaconChainConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		var indices []uint64
+		for _, param := range r.URL.Query()["gindex"] {
+			index, err := strconv.ParseUint(param, 10, 64)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid gindex %q", param))
+				return
+			}
+			indices = append(indices, index)
+		}
+		if len(indices) == 0 {
+			writeError(w, http.StatusBadRequest, "no gindex")
+			return
+		}
+		tx, err := db.BeginRo(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer tx.Rollback()
+		var beaconState *state.BeaconState
+		switch stateId := strings.TrimPrefix(r.URL.Path, stateProofPath); {
+		case stateId == "head":
+			beaconState, err = rawdb.ReadLatestBeaconState(tx, cfg)
+		case strings.HasPrefix(stateId, "0x"):
+			beaconState, err = rawdb.ReadBeaconStateByRoot(tx, cfg, libcommon.HexToHash(stateId))
+		default:
+			slot, parseErr := strconv.ParseUint(stateId, 10, 64)
+			if parseErr != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid state id %q", stateId))
+				return
+			}
+			beaconState, err = rawdb.ReadBeaconState(tx, cfg, slot)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if beaconState == nil {
+			writeError(w, http.StatusNotFound, "state not found")
+			return
+		}
+		values, proof, err := beaconState.MultiProof(indices)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+			"leaves": hashes(values),
+			"proof":  hashes(proof),
+		}})
+	}
+}
+
+func hashes(nodes [][32]byte) []libcommon.Hash {
+	out := make([]libcommon.Hash, len(nodes))
+	for i := range nodes {
+		out[i] = nodes[i]
+	}
+	return out
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]interface{}{"code": code, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug("[Beacon API] Write failed", "err", err)
+	}
+}
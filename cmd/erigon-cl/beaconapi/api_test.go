@@ -0,0 +1,122 @@
+package beaconapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/beaconapi"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+func TestDepositSnapshot(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	server := httptest.NewServer(beaconapi.NewHandler(db, &clparams.MainnetBeaconConfig))
+	defer server.Close()
+	uri := server.URL + "/eth/v1/beacon/deposit_snapshot"
+
+	r, err := http.Get(uri)
+	require.NoError(t, err)
+	r.Body.Close()
+	require.Equal(t, http.StatusNotFound, r.StatusCode)
+
+	snapshot := &cltypes.DepositSnapshot{
+		Finalized:            []libcommon.Hash{{1}},
+		DepositRoot:          libcommon.Hash{2},
+		DepositCount:         1,
+		ExecutionBlockHash:   libcommon.Hash{3},
+		ExecutionBlockHeight: 4,
+	}
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, rawdb.WriteDepositSnapshot(tx, snapshot))
+	require.NoError(t, tx.Commit())
+
+	r, err = http.Get(uri)
+	require.NoError(t, err)
+	var response struct {
+		Data *cltypes.DepositSnapshot `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&response))
+	r.Body.Close()
+	require.Equal(t, snapshot, response.Data)
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/octet-stream")
+	r, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	encoded, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	require.NoError(t, err)
+	decoded := &cltypes.DepositSnapshot{}
+	require.NoError(t, decoded.DecodeSSZ(encoded))
+	require.Equal(t, snapshot, decoded)
+}
+
+func TestStateProof(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	server := httptest.NewServer(beaconapi.NewHandler(db, &clparams.MainnetBeaconConfig))
+	defer server.Close()
+
+	beaconState := state.GetEmptyBeaconState()
+	beaconState.SetSlot(42)
+	beaconState.AddValidator(&cltypes.Validator{EffectiveBalance: 32e9})
+	beaconState.AddBalance(32e9)
+	stateRoot, err := beaconState.HashSSZ()
+	require.NoError(t, err)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, rawdb.WriteBeaconState(tx, beaconState))
+	require.NoError(t, tx.Commit())
+
+	indices := []uint64{state.ValidatorGeneralizedIndex(0), state.BalanceGeneralizedIndex(0)}
+	query := fmt.Sprintf("?gindex=%d&gindex=%d", indices[0], indices[1])
+	for _, stateId := range []string{"head", "42", libcommon.Hash(stateRoot).String()} {
+		r, err := http.Get(server.URL + "/eth/v0/beacon/proof/state/" + stateId + query)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+		var response struct {
+			Data struct {
+				Leaves []libcommon.Hash `json:"leaves"`
+				Proof  []libcommon.Hash `json:"proof"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&response))
+		r.Body.Close()
+		values := make([][32]byte, len(response.Data.Leaves))
+		for i := range values {
+			values[i] = response.Data.Leaves[i]
+		}
+		proof := make([][32]byte, len(response.Data.Proof))
+		for i := range proof {
+			proof[i] = response.Data.Proof[i]
+		}
+		computed, err := merkle_tree.MultiProofRoot(values, proof, indices)
+		require.NoError(t, err)
+		require.Equal(t, stateRoot, computed, stateId)
+	}
+
+	for uri, code := range map[string]int{
+		"/eth/v0/beacon/proof/state/41" + query: http.StatusNotFound,
+		"/eth/v0/beacon/proof/state/head":       http.StatusBadRequest,
+		"/eth/v0/beacon/proof/state/head?gindex=" + fmt.Sprint((32+uint64(state.SlotLeafIndex))*2): http.StatusBadRequest,
+	} {
+		r, err := http.Get(server.URL + uri)
+		require.NoError(t, err)
+		r.Body.Close()
+		require.Equal(t, code, r.StatusCode, uri)
+	}
+}
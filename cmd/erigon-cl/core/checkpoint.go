@@ -2,11 +2,14 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/log/v3"
@@ -48,3 +51,53 @@ func RetrieveBeaconState(ctx context.Context, beaconConfig *clparams.BeaconChain
 	}
 	return beaconState, nil
 }
+
+// DepositSnapshotEndpoint returns the deposit snapshot endpoint of the Beacon API of the node serving checkpointUri.
+func DepositSnapshotEndpoint(checkpointUri string) (string, error) {
+	u, err := url.Parse(checkpointUri)
+	if err != nil {
+		return "", err
+	}
+	u.Path, u.RawQuery = "/eth/v1/beacon/deposit_snapshot", ""
+	return u.String(), nil
+}
+
+// RetrieveDepositSnapshot requests the deposit tree snapshot (EIP-4881) and checks it against the checkpoint state:
+// it can't have more deposits than the eth1 data of the state, and has its root when it has all of them.
+func RetrieveDepositSnapshot(ctx context.Context, uri string, checkpoint *state.BeaconState) (*cltypes.DepositSnapshot, error) {
+	log.Info("[Checkpoint Sync] Requesting deposit snapshot", "uri", uri)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("deposit snapshot request failed, bad status code %d", r.StatusCode)
+	}
+	var response struct {
+		Data *cltypes.DepositSnapshot `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("deposit snapshot request failed %s", err)
+	}
+	snapshot := response.Data
+	if snapshot == nil {
+		return nil, fmt.Errorf("deposit snapshot request failed, no data")
+	}
+	if err := snapshot.Verify(); err != nil {
+		return nil, err
+	}
+	eth1Data := checkpoint.Eth1Data()
+	if snapshot.DepositCount > eth1Data.DepositCount {
+		return nil, fmt.Errorf("deposit snapshot of %d deposits ahead of the checkpoint state, %d deposits", snapshot.DepositCount, eth1Data.DepositCount)
+	}
+	if snapshot.DepositCount == eth1Data.DepositCount && snapshot.DepositRoot != eth1Data.Root {
+		return nil, fmt.Errorf("deposit snapshot root %x, checkpoint state deposit root %x", snapshot.DepositRoot, eth1Data.Root)
+	}
+	return snapshot, nil
+}
@@ -1,11 +1,14 @@
 package rawdb
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
+	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
@@ -17,33 +20,256 @@ func EncodeNumber(n uint64) []byte {
 	return ret
 }
 
-// WriteBeaconState writes beacon state for specific block to database.
-func WriteBeaconState(tx kv.Putter, state *state.BeaconState) error {
-	data, err := utils.EncodeSSZSnappy(state)
+// The beacon states are stored at their slot either in full, as anchors, or as a diff to the state at a lower slot.
+// An anchor is its version followed by its root and its snappy compressed SSZ encoding, a diff is its version with
+// beaconStateDiffFlag set, its root, the root of its base and the snappy compressed state.EncodeSSZDiff from the base.
+// The base is found by its root, so that a diff is never replayed onto a state which replaced its base at that slot.
+const beaconStateDiffFlag = 0x80
+
+func writeBeaconState(tx kv.Putter, slot uint64, stateRoot libcommon.Hash, data []byte) error {
+	key := EncodeNumber(slot)
+	if err := tx.Put(kv.BeaconState, key, data); err != nil {
+		return err
+	}
+	return tx.Put(kv.RootSlotIndex, stateRoot[:], key)
+}
+
+// WriteBeaconState writes the beacon state at its slot in full, and indexes its root to its slot so that it can be
+// read by root as well.
+func WriteBeaconState(tx kv.Putter, beaconState *state.BeaconState) error {
+	stateRoot, err := beaconState.HashSSZ()
 	if err != nil {
 		return err
 	}
+	encoded, err := beaconState.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	data := append([]byte{byte(beaconState.Version())}, stateRoot[:]...)
+	return writeBeaconState(tx, beaconState.Slot(), stateRoot, append(data, utils.CompressSnappy(encoded)...))
+}
 
-	return tx.Put(kv.BeaconState, EncodeNumber(state.Slot()), data)
+// BeaconStateWriter writes the beacon states of increasing slots as diffs to the previous one, with an anchor in full
+// at least every anchorInterval slots and at the forks. Reading a state replays the diffs from its anchor.
+type BeaconStateWriter struct {
+	anchorInterval uint64
+	anchorSlot     uint64
+	// the previous state written
+	baseSlot    uint64
+	baseRoot    libcommon.Hash
+	base        []byte
+	baseVersion clparams.StateVersion
 }
 
-// ReadBeaconState reads beacon state for specific block from database.
-func ReadBeaconState(tx kv.Getter, slot uint64) (*state.BeaconState, error) {
-	data, err := tx.GetOne(kv.BeaconState, EncodeNumber(slot))
+func NewBeaconStateWriter(anchorInterval uint64) *BeaconStateWriter {
+	return &BeaconStateWriter{anchorInterval: anchorInterval}
+}
+
+// Write writes the beacon state at its slot, and indexes its root to its slot. The state it replaces at its slot and
+// the states of higher slots are of a fork the new state is not on, they are deleted.
+//
+// The previous state written is the base of the diff only while tx still holds it: the transaction which wrote it may
+// have been rolled back, or the state replaced since. An anchor is written otherwise.
+func (w *BeaconStateWriter) Write(tx kv.RwTx, beaconState *state.BeaconState) error {
+	stateRoot, err := beaconState.HashSSZ()
+	if err != nil {
+		return err
+	}
+	if w.base != nil {
+		data, err := readBeaconStateData(tx, w.baseRoot[:])
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			w.base = nil
+		}
+	}
+	if err := deleteBeaconStatesFrom(tx, beaconState.Slot()); err != nil {
+		return err
+	}
+	encoded, err := beaconState.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	slot, version := beaconState.Slot(), beaconState.Version()
+	if w.base == nil || version != w.baseVersion || slot <= w.baseSlot || slot >= w.anchorSlot+w.anchorInterval {
+		data := append([]byte{byte(version)}, stateRoot[:]...)
+		if err := writeBeaconState(tx, slot, stateRoot, append(data, utils.CompressSnappy(encoded)...)); err != nil {
+			return err
+		}
+		w.anchorSlot = slot
+	} else {
+		diff, err := state.EncodeSSZDiff(version, w.base, encoded)
+		if err != nil {
+			return err
+		}
+		data := append([]byte{byte(version) | beaconStateDiffFlag}, stateRoot[:]...)
+		data = append(data, w.baseRoot[:]...)
+		if err := writeBeaconState(tx, slot, stateRoot, append(data, utils.CompressSnappy(diff)...)); err != nil {
+			return err
+		}
+	}
+	w.baseSlot, w.baseRoot, w.base, w.baseVersion = slot, stateRoot, encoded, version
+	return nil
+}
+
+// deleteBeaconStatesFrom deletes the beacon states from slot on, and the index of their roots.
+func deleteBeaconStatesFrom(tx kv.RwTx, slot uint64) error {
+	c, err := tx.RwCursor(kv.BeaconState)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, v, err := c.Seek(EncodeNumber(slot)); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if len(v) >= 33 {
+			indexed, err := tx.GetOne(kv.RootSlotIndex, v[1:33])
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(indexed, k) {
+				if err := tx.Delete(kv.RootSlotIndex, v[1:33]); err != nil {
+					return err
+				}
+			}
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readBeaconStateData returns the stored state of root stateRoot, nil if there is none, it was pruned or replaced by
+// another state of the same slot.
+func readBeaconStateData(tx kv.Getter, stateRoot []byte) ([]byte, error) {
+	slot, err := tx.GetOne(kv.RootSlotIndex, stateRoot)
+	if err != nil || len(slot) == 0 {
+		return nil, err
+	}
+	data, err := tx.GetOne(kv.BeaconState, slot)
+	if err != nil || len(data) < 33 || !bytes.Equal(data[1:33], stateRoot) {
+		return nil, err
+	}
+	return data, nil
+}
+
+// regenerateBeaconState decodes the beacon state stored as data, replaying the diffs from its anchor.
+func regenerateBeaconState(tx kv.Getter, cfg *clparams.BeaconChainConfig, data []byte) (*state.BeaconState, error) {
+	var diffs [][]byte
+	for data[0]&beaconStateDiffFlag != 0 {
+		diffs = append(diffs, data)
+		base := data[33:65]
+		var err error
+		if data, err = readBeaconStateData(tx, base); err != nil {
+			return nil, err
+		}
+		if data == nil {
+			return nil, fmt.Errorf("base state %x is missing", base)
+		}
+	}
+	version := clparams.StateVersion(data[0])
+	encoded, err := utils.DecompressSnappy(data[33:])
 	if err != nil {
 		return nil, err
 	}
-	state := &state.BeaconState{}
+	for i := len(diffs) - 1; i >= 0; i-- {
+		diff, err := utils.DecompressSnappy(diffs[i][65:])
+		if err != nil {
+			return nil, err
+		}
+		version = clparams.StateVersion(diffs[i][0] &^ beaconStateDiffFlag)
+		if encoded, err = state.ApplySSZDiff(version, encoded, diff); err != nil {
+			return nil, err
+		}
+	}
+	beaconState := state.New(cfg)
+	if err := beaconState.DecodeSSZWithVersion(encoded, int(version)); err != nil {
+		return nil, err
+	}
+	return beaconState, nil
+}
 
+// ReadBeaconState reads the beacon state at slot, nil if there is none.
+func ReadBeaconState(tx kv.Getter, cfg *clparams.BeaconChainConfig, slot uint64) (*state.BeaconState, error) {
+	data, err := tx.GetOne(kv.BeaconState, EncodeNumber(slot))
+	if err != nil {
+		return nil, err
+	}
 	if len(data) == 0 {
 		return nil, nil
 	}
+	return regenerateBeaconState(tx, cfg, data)
+}
 
-	if err := utils.DecodeSSZSnappy(state, data); err != nil {
+// ReadBeaconStateByRoot reads the beacon state of root stateRoot, nil if there is none, it was pruned or replaced by
+// another state of the same slot.
+func ReadBeaconStateByRoot(tx kv.Getter, cfg *clparams.BeaconChainConfig, stateRoot libcommon.Hash) (*state.BeaconState, error) {
+	data, err := readBeaconStateData(tx, stateRoot[:])
+	if err != nil || data == nil {
 		return nil, err
 	}
+	return regenerateBeaconState(tx, cfg, data)
+}
 
-	return state, nil
+// ReadLatestBeaconState reads the beacon state of the highest slot, which the node resumes from after a restart, nil
+// if there is none.
+func ReadLatestBeaconState(tx kv.Tx, cfg *clparams.BeaconChainConfig) (*state.BeaconState, error) {
+	c, err := tx.Cursor(kv.BeaconState)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	_, data, err := c.Last()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return regenerateBeaconState(tx, cfg, data)
+}
+
+// PruneBeaconStates deletes the beacon states before slot pruneTo, except the anchor and the diffs which the states
+// from pruneTo on are replayed from. Their roots stay indexed, like the roots of the blocks, and read no state.
+func PruneBeaconStates(tx kv.RwTx, pruneTo uint64) error {
+	c, err := tx.RwCursor(kv.BeaconState)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	_, data, err := c.Seek(EncodeNumber(pruneTo))
+	if err != nil {
+		return err
+	}
+	for len(data) > 0 && data[0]&beaconStateDiffFlag != 0 {
+		base := data[33:65]
+		slot, err := tx.GetOne(kv.RootSlotIndex, base)
+		if err != nil {
+			return err
+		}
+		if len(slot) == 0 {
+			break
+		}
+		pruneTo = uint64(binary.BigEndian.Uint32(slot))
+		if data, err = readBeaconStateData(tx, base); err != nil {
+			return err
+		}
+	}
+	for k, _, err := c.First(); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if uint64(binary.BigEndian.Uint32(k)) >= pruneTo {
+			break
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func WriteLightClientUpdate(tx kv.RwTx, update *cltypes.LightClientUpdate) error {
@@ -73,7 +299,7 @@ func WriteLightClientOptimisticUpdate(tx kv.RwTx, update *cltypes.LightClientOpt
 	return tx.Put(kv.LightClient, kv.LightClientOptimisticUpdate, encoded)
 }
 
-func ReadLightClientUpdate(tx kv.RwTx, period uint32) (*cltypes.LightClientUpdate, error) {
+func ReadLightClientUpdate(tx kv.Getter, period uint32) (*cltypes.LightClientUpdate, error) {
 	key := make([]byte, 4)
 	binary.BigEndian.PutUint32(key, period)
 
@@ -81,6 +307,9 @@ func ReadLightClientUpdate(tx kv.RwTx, period uint32) (*cltypes.LightClientUpdat
 	if err != nil {
 		return nil, err
 	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
 	update := &cltypes.LightClientUpdate{}
 	if err = update.DecodeSSZ(encoded); err != nil {
 		return nil, err
@@ -118,6 +347,65 @@ func ReadLightClientOptimisticUpdate(tx kv.Tx) (*cltypes.LightClientOptimisticUp
 	return update, nil
 }
 
+// lightClientBootstrapPrefix + block root => state version + LightClientBootstrap
+var lightClientBootstrapPrefix = []byte("LightClientBootstrap")
+
+func lightClientBootstrapKey(blockRoot libcommon.Hash) []byte {
+	return append(libcommon.Copy(lightClientBootstrapPrefix), blockRoot[:]...)
+}
+
+// WriteLightClientBootstrap writes the light client bootstrap for the block of root blockRoot.
+func WriteLightClientBootstrap(tx kv.Putter, blockRoot libcommon.Hash, bootstrap *cltypes.LightClientBootstrap, version clparams.StateVersion) error {
+	encoded, err := bootstrap.WithVersion(version).EncodeSSZ([]byte{byte(version)})
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.LightClient, lightClientBootstrapKey(blockRoot), encoded)
+}
+
+// ReadLightClientBootstrap reads the light client bootstrap for the block of root blockRoot, nil if there is none.
+func ReadLightClientBootstrap(tx kv.Getter, blockRoot libcommon.Hash) (*cltypes.LightClientBootstrap, error) {
+	encoded, err := tx.GetOne(kv.LightClient, lightClientBootstrapKey(blockRoot))
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	bootstrap := &cltypes.LightClientBootstrap{}
+	if err = bootstrap.DecodeSSZWithVersion(encoded[1:], int(encoded[0])); err != nil {
+		return nil, err
+	}
+	return bootstrap, nil
+}
+
+var depositSnapshotKey = []byte("depositSnapshot")
+
+// WriteDepositSnapshot replaces the deposit tree snapshot (EIP-4881).
+func WriteDepositSnapshot(tx kv.Putter, snapshot *cltypes.DepositSnapshot) error {
+	encoded, err := snapshot.EncodeSSZ(nil)
+	if err != nil {
+		return err
+	}
+	return tx.Put(kv.DatabaseInfo, depositSnapshotKey, encoded)
+}
+
+// ReadDepositSnapshot reads the deposit tree snapshot, nil if there is none.
+func ReadDepositSnapshot(tx kv.Getter) (*cltypes.DepositSnapshot, error) {
+	encoded, err := tx.GetOne(kv.DatabaseInfo, depositSnapshotKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	snapshot := &cltypes.DepositSnapshot{}
+	if err = snapshot.DecodeSSZ(encoded); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
 // Bytes2FromLength convert length to 2 bytes repressentation
 func Bytes2FromLength(size int) []byte {
 	return []byte{
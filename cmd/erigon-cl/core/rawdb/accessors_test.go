@@ -1,14 +1,19 @@
 package rawdb_test
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"testing"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +57,221 @@ func TestBeaconBlock(t *testing.T) {
 	require.Equal(t, root, newRoot)
 }
 
+func TestBeaconState(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	cfg := &clparams.MainnetBeaconConfig
+	read, err := rawdb.ReadLatestBeaconState(tx, cfg)
+	require.NoError(t, err)
+	require.Nil(t, read)
+
+	var roots []libcommon.Hash
+	for slot := uint64(1); slot <= 3; slot++ {
+		beaconState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+		beaconState.SetSlot(slot)
+		root, err := beaconState.HashSSZ()
+		require.NoError(t, err)
+		roots = append(roots, root)
+		require.NoError(t, rawdb.WriteBeaconState(tx, beaconState))
+	}
+
+	read, err = rawdb.ReadBeaconState(tx, cfg, 2)
+	require.NoError(t, err)
+	require.Equal(t, clparams.AltairVersion, read.Version())
+	root, err := read.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, roots[1], libcommon.Hash(root))
+
+	read, err = rawdb.ReadBeaconStateByRoot(tx, cfg, roots[0])
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), read.Slot())
+
+	read, err = rawdb.ReadLatestBeaconState(tx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), read.Slot())
+
+	require.NoError(t, rawdb.PruneBeaconStates(tx, 3))
+	for _, slot := range []uint64{1, 2} {
+		read, err = rawdb.ReadBeaconState(tx, cfg, slot)
+		require.NoError(t, err)
+		require.Nil(t, read)
+	}
+	read, err = rawdb.ReadBeaconStateByRoot(tx, cfg, roots[0])
+	require.NoError(t, err)
+	require.Nil(t, read)
+	read, err = rawdb.ReadBeaconState(tx, cfg, 3)
+	require.NoError(t, err)
+	require.NotNil(t, read)
+}
+
+func TestBeaconStateDiffs(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	cfg := &clparams.MainnetBeaconConfig
+	writer := rawdb.NewBeaconStateWriter(4)
+
+	beaconState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	roots := map[uint64][32]byte{}
+	for slot := uint64(1); slot <= 10; slot++ {
+		beaconState.SetSlot(slot)
+		beaconState.SetBlockRootAt(int(slot), libcommon.Hash{byte(slot)})
+		beaconState.AddValidator(&cltypes.Validator{EffectiveBalance: slot})
+		beaconState.AddBalance(slot)
+		beaconState.AddInactivityScore(0)
+		beaconState.AddCurrentEpochParticipationFlags(0)
+		beaconState.AddPreviousEpochParticipationFlags(0)
+		if slot > 1 {
+			beaconState.SetValidatorBalance(0, 32e9+slot)
+		}
+		root, err := beaconState.HashSSZ()
+		require.NoError(t, err)
+		roots[slot] = root
+		require.NoError(t, writer.Write(tx, beaconState))
+	}
+
+	for slot, expected := range roots {
+		read, err := rawdb.ReadBeaconState(tx, cfg, slot)
+		require.NoError(t, err)
+		root, err := read.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, expected, root, "slot %d", slot)
+	}
+	read, err := rawdb.ReadLatestBeaconState(tx, cfg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), read.Slot())
+	require.Len(t, read.Validators(), 10)
+
+	// the states from slot 7 on replay the diffs from the anchor at slot 5
+	require.NoError(t, rawdb.PruneBeaconStates(tx, 7))
+	read, err = rawdb.ReadBeaconState(tx, cfg, 4)
+	require.NoError(t, err)
+	require.Nil(t, read)
+	for slot := uint64(5); slot <= 10; slot++ {
+		read, err := rawdb.ReadBeaconState(tx, cfg, slot)
+		require.NoError(t, err)
+		root, err := read.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, roots[slot], root, "slot %d", slot)
+	}
+}
+
+func TestBeaconStateDiffsReorg(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	cfg := &clparams.MainnetBeaconConfig
+	writer := rawdb.NewBeaconStateWriter(8)
+
+	beaconState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	roots := map[uint64][32]byte{}
+	for slot := uint64(1); slot <= 3; slot++ {
+		beaconState.SetSlot(slot)
+		root, err := beaconState.HashSSZ()
+		require.NoError(t, err)
+		roots[slot] = root
+		require.NoError(t, writer.Write(tx, beaconState))
+	}
+
+	// a reorg replaces the state of slot 2, the state of slot 3 is of the abandoned fork
+	beaconState.SetSlot(2)
+	beaconState.SetBlockRootAt(2, libcommon.Hash{2})
+	reorgRoot, err := beaconState.HashSSZ()
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(tx, beaconState))
+
+	for _, root := range []libcommon.Hash{roots[2], roots[3]} {
+		read, err := rawdb.ReadBeaconStateByRoot(tx, cfg, root)
+		require.NoError(t, err)
+		require.Nil(t, read)
+		indexed, err := tx.GetOne(kv.RootSlotIndex, root[:])
+		require.NoError(t, err)
+		require.Empty(t, indexed)
+	}
+	read, err := rawdb.ReadLatestBeaconState(tx, cfg)
+	require.NoError(t, err)
+	root, err := read.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, reorgRoot, root)
+
+	// a diff is not replayed onto a state which replaced its base
+	beaconState.SetSlot(3)
+	require.NoError(t, writer.Write(tx, beaconState))
+	replaced := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	replaced.SetSlot(2)
+	require.NoError(t, rawdb.WriteBeaconState(tx, replaced))
+	_, err = rawdb.ReadBeaconState(tx, cfg, 3)
+	require.ErrorContains(t, err, "missing")
+}
+
+func TestBeaconStateDiffsRollback(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	cfg := &clparams.MainnetBeaconConfig
+	writer := rawdb.NewBeaconStateWriter(8)
+
+	beaconState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	write := func(slot uint64, commit bool) libcommon.Hash {
+		tx, err := db.BeginRw(context.Background())
+		require.NoError(t, err)
+		defer tx.Rollback()
+		beaconState.SetSlot(slot)
+		root, err := beaconState.HashSSZ()
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(tx, beaconState))
+		if commit {
+			require.NoError(t, tx.Commit())
+		}
+		return root
+	}
+	write(1, true)
+	// the stage fails after writing the state of slot 2, its transaction is rolled back
+	write(2, false)
+	root := write(3, true)
+
+	// the state of slot 3 is not a diff to the state of slot 2, which was never committed
+	tx, err := db.BeginRo(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	read, err := rawdb.ReadBeaconStateByRoot(tx, cfg, root)
+	require.NoError(t, err)
+	require.NotNil(t, read)
+	readRoot, err := read.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, [32]byte(root), readRoot)
+}
+
+func TestLightClientBootstrap(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	beaconState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	blockRoot, bootstrap, err := beaconState.LightClientBootstrap()
+	require.NoError(t, err)
+
+	require.NoError(t, rawdb.WriteLightClientBootstrap(tx, blockRoot, bootstrap, clparams.AltairVersion))
+	read, err := rawdb.ReadLightClientBootstrap(tx, blockRoot)
+	require.NoError(t, err)
+	require.Equal(t, bootstrap.Header.HeaderEth2, read.Header.HeaderEth2)
+	require.Equal(t, bootstrap.CurrentSyncCommitteeBranch, read.CurrentSyncCommitteeBranch)
+	require.True(t, bootstrap.CurrentSyncCommittee.Equal(read.CurrentSyncCommittee))
+
+	read, err = rawdb.ReadLightClientBootstrap(tx, libcommon.Hash{})
+	require.NoError(t, err)
+	require.Nil(t, read)
+}
+
+func TestDepositSnapshot(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	read, err := rawdb.ReadDepositSnapshot(tx)
+	require.NoError(t, err)
+	require.Nil(t, read)
+
+	snapshot := &cltypes.DepositSnapshot{
+		Finalized:            []libcommon.Hash{{1}, {2}},
+		DepositRoot:          libcommon.Hash{3},
+		DepositCount:         3,
+		ExecutionBlockHash:   libcommon.Hash{4},
+		ExecutionBlockHeight: 5,
+	}
+	require.NoError(t, rawdb.WriteDepositSnapshot(tx, snapshot))
+	read, err = rawdb.ReadDepositSnapshot(tx)
+	require.NoError(t, err)
+	require.Equal(t, snapshot, read)
+}
+
 // Benchmarks
 func BenchmarkSnappyBeaconBlock(b *testing.B) {
 	uncompressed := rawdb.SSZTestBeaconBlock
@@ -8,8 +8,10 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
 	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/core/types"
 )
 
 // GetActiveValidatorsIndices returns the list of validator indices active for the given epoch.
@@ -115,17 +117,19 @@ func (b *BeaconState) GetBlockRootAtSlot(slot uint64) (libcommon.Hash, error) {
 	return b.blockRoots[slot%b.beaconConfig.SlotsPerHistoricalRoot], nil
 }
 
+// GetDomain returns the signature domain of domainType at epoch (get_domain): from the fork version of the state at
+// epoch, the previous one before the fork epoch, and the genesis validators root.
 func (b *BeaconState) GetDomain(domainType [4]byte, epoch uint64) ([]byte, error) {
-	if epoch == 0 {
-		epoch = b.Epoch()
-	}
-	var forkVersion [4]byte
-	if epoch < b.fork.Epoch {
-		forkVersion = b.fork.PreviousVersion
-	} else {
-		forkVersion = b.fork.CurrentVersion
+	return fork.Domain(b.fork, epoch, domainType, b.genesisValidatorsRoot)
+}
+
+// ComputeSigningRoot returns the root which is signed for obj in the domain of domainType at epoch, see GetDomain.
+func (b *BeaconState) ComputeSigningRoot(obj ssz_utils.HashableSSZ, domainType [4]byte, epoch uint64) (libcommon.Hash, error) {
+	domain, err := b.GetDomain(domainType, epoch)
+	if err != nil {
+		return libcommon.Hash{}, err
 	}
-	return fork.ComputeDomain(domainType[:], forkVersion, b.genesisValidatorsRoot)
+	return fork.ComputeSigningRoot(obj, domain)
 }
 
 func (b *BeaconState) ComputeShuffledIndex(ind, ind_count uint64, seed [32]byte) (uint64, error) {
@@ -196,33 +200,139 @@ func (b *BeaconState) ComputeProposerIndex(indices []uint64, seed [32]byte) (uin
 	}
 }
 
+// IsMergeTransitionComplete returns whether the state has seen an execution payload, the latest header is the
+// default one before the merge: its block hash is zero.
+func (b *BeaconState) IsMergeTransitionComplete() bool {
+	return b.latestExecutionPayloadHeader != nil && b.latestExecutionPayloadHeader.BlockHashCL != (libcommon.Hash{})
+}
+
 func (b *BeaconState) GetRandaoMixes(epoch uint64) [32]byte {
 	return b.randaoMixes[epoch%b.beaconConfig.EpochsPerHistoricalVector]
 }
 
+// GetBeaconProposerIndex returns the proposer of the slot of the state, from the proposers of the epoch.
 func (b *BeaconState) GetBeaconProposerIndex() (uint64, error) {
-	epoch := b.Epoch()
+	proposers, err := b.EpochProposers(b.Epoch())
+	if err != nil {
+		return 0, err
+	}
+	return proposers[b.slot%b.beaconConfig.SlotsPerEpoch], nil
+}
 
-	hash := sha256.New()
-	// Input for the seed hash.
-	input := b.GetSeed(epoch, clparams.MainnetBeaconConfig.DomainBeaconProposer)
+// ComputeBeaconProposerIndexAtSlot returns the proposer of slot, assuming the effective balances of the state
+// still hold at slot. It is exact for the slots of the current epoch.
+func (b *BeaconState) ComputeBeaconProposerIndexAtSlot(slot uint64) (uint64, error) {
+	return b.ComputeProposerIndex(b.GetActiveValidatorsIndices(b.GetEpochAtSlot(slot)), b.proposerSeed(slot))
+}
+
+// proposerSeed returns the seed of the proposer of slot, the proposer seed of its epoch hashed with slot.
+func (b *BeaconState) proposerSeed(slot uint64) [32]byte {
+	input := b.GetSeed(b.GetEpochAtSlot(slot), b.beaconConfig.DomainBeaconProposer)
 	slotByteArray := make([]byte, 8)
-	binary.LittleEndian.PutUint64(slotByteArray, b.Slot())
+	binary.LittleEndian.PutUint64(slotByteArray, slot)
+	return sha256.Sum256(append(input, slotByteArray...))
+}
 
-	// Add slot to the end of the input.
-	inputWithSlot := append(input, slotByteArray...)
+// CommitteeCount returns the number of committees per slot in epoch (get_committee_count_per_slot in the spec).
+func (b *BeaconState) CommitteeCount(epoch uint64) uint64 {
+	committeesPerSlot := uint64(len(b.GetActiveValidatorsIndices(epoch))) / b.beaconConfig.SlotsPerEpoch / b.beaconConfig.TargetCommitteeSize
+	if committeesPerSlot > b.beaconConfig.MaxCommitteesPerSlot {
+		return b.beaconConfig.MaxCommitteesPerSlot
+	}
+	if committeesPerSlot == 0 {
+		return 1
+	}
+	return committeesPerSlot
+}
 
-	// Calculate the hash.
-	hash.Write(inputWithSlot)
-	seed := hash.Sum(nil)
+// ComputeCommittee returns the index-th of count committees the shuffled indices are split into.
+func (b *BeaconState) ComputeCommittee(indices []uint64, seed [32]byte, index, count uint64) ([]uint64, error) {
+	total := uint64(len(indices))
+	start := total * index / count
+	end := total * (index + 1) / count
+	committee := make([]uint64, 0, end-start)
+	for i := start; i < end; i++ {
+		shuffled, err := b.ComputeShuffledIndex(i, total, seed)
+		if err != nil {
+			return nil, err
+		}
+		committee = append(committee, indices[shuffled])
+	}
+	return committee, nil
+}
 
-	indices := b.GetActiveValidatorsIndices(epoch)
+// GetBeaconCommittee returns the attesters of committee index at slot. The shuffling of the epoch is computed once
+// (see ShuffledActiveIndices), the committee is shared and must not be modified.
+func (b *BeaconState) GetBeaconCommittee(slot, index uint64) ([]uint64, error) {
+	epoch := b.GetEpochAtSlot(slot)
+	committeesPerSlot := b.CommitteeCount(epoch)
+	if index >= committeesPerSlot {
+		return nil, fmt.Errorf("committee index %d out of the %d committees per slot", index, committeesPerSlot)
+	}
+	shuffled := b.ShuffledActiveIndices(epoch)
+	total := uint64(len(shuffled))
+	count := committeesPerSlot * b.beaconConfig.SlotsPerEpoch
+	i := (slot%b.beaconConfig.SlotsPerEpoch)*committeesPerSlot + index
+	start, end := total*i/count, total*(i+1)/count
+	return shuffled[start:end:end], nil
+}
 
-	// Write the seed to an array.
-	seedArray := [32]byte{}
-	copy(seedArray[:], seed)
+// GetAttestingIndices returns the members of the committee of data whose bit is set in aggregationBits, in the
+// committee order. With checkBitsLength, the bitlist must have a bit per member.
+func (b *BeaconState) GetAttestingIndices(data *cltypes.AttestationData, aggregationBits []byte, checkBitsLength bool) ([]uint64, error) {
+	committee, err := b.GetBeaconCommittee(data.Slot, data.Index)
+	if err != nil {
+		return nil, err
+	}
+	bitsLength := utils.BitlistLength(aggregationBits)
+	if checkBitsLength && bitsLength != len(committee) {
+		return nil, fmt.Errorf("GetAttestingIndices: %d aggregation bits for a committee of %d", bitsLength, len(committee))
+	}
+	if bitsLength < len(committee) {
+		return nil, fmt.Errorf("GetAttestingIndices: %d aggregation bits, too few for a committee of %d", bitsLength, len(committee))
+	}
+	attestingIndices := make([]uint64, 0, len(committee))
+	for i, index := range committee {
+		if utils.IsBitOn(aggregationBits, i) {
+			attestingIndices = append(attestingIndices, index)
+		}
+	}
+	return attestingIndices, nil
+}
 
-	return b.ComputeProposerIndex(indices, seedArray)
+// GetAttestationParticipationFlagIndices returns the participation flags earned by an attestation of data included
+// inclusionDelay slots after its slot. The source of data must be the justified checkpoint of its target epoch.
+func (b *BeaconState) GetAttestationParticipationFlagIndices(data *cltypes.AttestationData, inclusionDelay uint64) ([]uint8, error) {
+	justifiedCheckpoint := b.previousJustifiedCheckpoint
+	if data.Target.Epoch == b.Epoch() {
+		justifiedCheckpoint = b.currentJustifiedCheckpoint
+	}
+	if *data.Source != *justifiedCheckpoint {
+		return nil, fmt.Errorf("GetAttestationParticipationFlagIndices: source %d/%x is not the justified checkpoint %d/%x",
+			data.Source.Epoch, data.Source.Root, justifiedCheckpoint.Epoch, justifiedCheckpoint.Root)
+	}
+	targetRoot, err := b.GetBlockRoot(data.Target.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	headRoot, err := b.GetBlockRootAtSlot(data.Slot)
+	if err != nil {
+		return nil, err
+	}
+	matchingTarget := data.Target.Root == targetRoot
+	matchingHead := matchingTarget && data.BeaconBlockHash == headRoot
+
+	var flags []uint8
+	if inclusionDelay <= utils.IntegerSquareRoot(b.beaconConfig.SlotsPerEpoch) {
+		flags = append(flags, b.beaconConfig.TimelySourceFlagIndex)
+	}
+	if matchingTarget && inclusionDelay <= b.beaconConfig.SlotsPerEpoch {
+		flags = append(flags, b.beaconConfig.TimelyTargetFlagIndex)
+	}
+	if matchingHead && inclusionDelay == b.beaconConfig.MinAttestationInclusionDelay {
+		flags = append(flags, b.beaconConfig.TimelyHeadFlagIndex)
+	}
+	return flags, nil
 }
 
 func (b *BeaconState) GetSeed(epoch uint64, domain [4]byte) []byte {
@@ -241,6 +351,24 @@ func (b *BeaconState) baseRewardPerIncrement(totalActiveBalance uint64) uint64 {
 	return b.beaconConfig.EffectiveBalanceIncrement * b.beaconConfig.BaseRewardFactor / utils.IntegerSquareRoot(totalActiveBalance)
 }
 
+// BaseReward return the base reward of a validator of effective balance effectiveBalance.
+func (b *BeaconState) BaseReward(effectiveBalance, totalActiveBalance uint64) uint64 {
+	return effectiveBalance / b.beaconConfig.EffectiveBalanceIncrement * b.baseRewardPerIncrement(totalActiveBalance)
+}
+
+// InactivityLeaking returns whether the chain has not finalized for long enough to penalize the inactive validators.
+func (b *BeaconState) InactivityLeaking() bool {
+	return b.PreviousEpoch()-b.finalizedCheckpoint.Epoch > b.beaconConfig.MinEpochsToInactivityPenalty
+}
+
+// IsValidatorEligible returns whether the validator at index gets rewards and penalties for the previous epoch: it
+// was active, or it is slashed and not yet withdrawable.
+func (b *BeaconState) IsValidatorEligible(index uint64) bool {
+	validator := b.validators[index]
+	previousEpoch := b.PreviousEpoch()
+	return validator.Active(previousEpoch) || (validator.Slashed && previousEpoch+1 < validator.WithdrawableEpoch)
+}
+
 // SyncRewards returns the proposer reward and the sync participant reward given the total active balance in state.
 func (b *BeaconState) SyncRewards() (proposerReward, participantReward uint64, err error) {
 	activeBalance, err := b.GetTotalActiveBalance()
@@ -259,8 +387,8 @@ func (b *BeaconState) SyncRewards() (proposerReward, participantReward uint64, e
 func (b *BeaconState) ValidatorFromDeposit(deposit *cltypes.Deposit) *cltypes.Validator {
 	amount := deposit.Data.Amount
 	effectiveBalance := amount - amount%b.beaconConfig.EffectiveBalanceIncrement
-	if effectiveBalance > b.beaconConfig.EffectiveBalanceIncrement {
-		effectiveBalance = b.beaconConfig.EffectiveBalanceIncrement
+	if effectiveBalance > b.beaconConfig.MaxEffectiveBalance {
+		effectiveBalance = b.beaconConfig.MaxEffectiveBalance
 	}
 
 	return &cltypes.Validator{
@@ -273,3 +401,69 @@ func (b *BeaconState) ValidatorFromDeposit(deposit *cltypes.Deposit) *cltypes.Va
 		EffectiveBalance:           effectiveBalance,
 	}
 }
+
+// hasEth1WithdrawalCredential checks whether the withdrawal credentials of a validator point to an execution address.
+func (b *BeaconState) hasEth1WithdrawalCredential(validator *cltypes.Validator) bool {
+	return validator.WithdrawalCredentials[0] == b.beaconConfig.ETH1AddressWithdrawalPrefixByte
+}
+
+// isFullyWithdrawableValidator checks whether the whole balance of a validator can be withdrawn at epoch.
+func (b *BeaconState) isFullyWithdrawableValidator(validator *cltypes.Validator, balance uint64, epoch uint64) bool {
+	return b.hasEth1WithdrawalCredential(validator) && validator.WithdrawableEpoch <= epoch && balance > 0
+}
+
+// isPartiallyWithdrawableValidator checks whether a validator has a balance above the maximum effective balance to withdraw.
+func (b *BeaconState) isPartiallyWithdrawableValidator(validator *cltypes.Validator, balance uint64) bool {
+	maxEffectiveBalance := b.beaconConfig.MaxEffectiveBalance
+	return b.hasEth1WithdrawalCredential(validator) && validator.EffectiveBalance == maxEffectiveBalance && balance > maxEffectiveBalance
+}
+
+// sweepWithdrawals runs the withdrawal sweep over at most bound validators from the current cursor, stopping at max withdrawals.
+func (b *BeaconState) sweepWithdrawals(bound uint64, max int) (withdrawals types.Withdrawals) {
+	if len(b.validators) == 0 {
+		return nil
+	}
+	epoch := b.Epoch()
+	withdrawalIndex := b.nextWithdrawalIndex
+	validatorIndex := b.nextWithdrawalValidatorIndex % uint64(len(b.validators))
+	for i := uint64(0); i < bound && len(withdrawals) < max; i++ {
+		validator, balance := b.validators[validatorIndex], b.balances.Get(int(validatorIndex))
+		address := libcommon.BytesToAddress(validator.WithdrawalCredentials[12:])
+		if b.isFullyWithdrawableValidator(validator, balance, epoch) {
+			withdrawals = append(withdrawals, &types.Withdrawal{Index: withdrawalIndex, Validator: validatorIndex, Address: address, Amount: balance})
+			withdrawalIndex++
+		} else if b.isPartiallyWithdrawableValidator(validator, balance) {
+			withdrawals = append(withdrawals, &types.Withdrawal{Index: withdrawalIndex, Validator: validatorIndex, Address: address, Amount: balance - b.beaconConfig.MaxEffectiveBalance})
+			withdrawalIndex++
+		}
+		validatorIndex = (validatorIndex + 1) % uint64(len(b.validators))
+	}
+	return
+}
+
+// ExpectedWithdrawals returns the withdrawals of the next execution payload (get_expected_withdrawals in the spec).
+func (b *BeaconState) ExpectedWithdrawals() types.Withdrawals {
+	bound := uint64(len(b.validators))
+	if bound > b.beaconConfig.MaxValidatorsPerWithdrawalsSweep {
+		bound = b.beaconConfig.MaxValidatorsPerWithdrawalsSweep
+	}
+	return b.sweepWithdrawals(bound, int(b.beaconConfig.MaxWithdrawalsPerPayload))
+}
+
+// NextWithdrawals simulates the withdrawal sweep over all validators from the current cursor and returns the next n
+// withdrawals, across as many payloads as needed, assuming balances don't change meanwhile.
+func (b *BeaconState) NextWithdrawals(n int) types.Withdrawals {
+	return b.sweepWithdrawals(uint64(len(b.validators)), n)
+}
+
+// HistoricalSummary returns the roots of the block roots and of the state roots of the state, which summarize the
+// last SlotsPerHistoricalRoot slots. Its root is the one of the historical batch of the roots before Capella.
+func (b *BeaconState) HistoricalSummary() (*cltypes.HistoricalSummary, error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, err
+	}
+	return &cltypes.HistoricalSummary{
+		BlockSummaryRoot: b.leaves[BlockRootsLeafIndex],
+		StateSummaryRoot: b.leaves[StateRootsLeafIndex],
+	}, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/stretchr/testify/require"
 )
@@ -127,6 +128,37 @@ func TestGetBeaconProposerIndex(t *testing.T) {
 	}
 }
 
+func TestEpochProposers(t *testing.T) {
+	b := getTestState(t)
+	slotsPerEpoch := clparams.MainnetBeaconConfig.SlotsPerEpoch
+	for _, epoch := range []uint64{0, 1} {
+		proposers, err := b.EpochProposers(epoch)
+		require.NoError(t, err)
+		for i, proposer := range proposers {
+			expected, err := b.ComputeBeaconProposerIndexAtSlot(epoch*slotsPerEpoch + uint64(i))
+			require.NoError(t, err)
+			require.Equal(t, expected, proposer)
+		}
+		// computed once for the state and its copies
+		again, err := b.Copy().EpochProposers(epoch)
+		require.NoError(t, err)
+		require.Same(t, &proposers[0], &again[0])
+	}
+	_, err := b.EpochProposers(2)
+	require.Error(t, err)
+
+	// at the next epoch the proposers are computed again, the effective balances may have changed
+	predicted, err := b.EpochProposers(1)
+	require.NoError(t, err)
+	b.SetSlot(slotsPerEpoch)
+	proposers, err := b.EpochProposers(1)
+	require.NoError(t, err)
+	require.NotSame(t, &predicted[0], &proposers[0])
+	proposer, err := b.GetBeaconProposerIndex()
+	require.NoError(t, err)
+	require.Equal(t, proposers[0], proposer)
+}
+
 func TestComputeShuffledIndex(t *testing.T) {
 	testCases := []struct {
 		description  string
@@ -241,3 +273,125 @@ func TestSyncReward(t *testing.T) {
 	require.Equal(t, propReward, uint64(30))
 	require.Equal(t, partRew, uint64(214))
 }
+
+func TestNextWithdrawals(t *testing.T) {
+	eth1Credentials := func(b byte) (credentials common.Hash) {
+		credentials[0] = clparams.MainnetBeaconConfig.ETH1AddressWithdrawalPrefixByte
+		credentials[31] = b
+		return
+	}
+	maxBalance := clparams.MainnetBeaconConfig.MaxEffectiveBalance
+	testState := state.GetEmptyBeaconState()
+	testState.SetSlot(10 * 32)
+	// 0: fully withdrawable
+	testState.AddValidator(&cltypes.Validator{WithdrawalCredentials: eth1Credentials(0), WithdrawableEpoch: 5, EffectiveBalance: maxBalance})
+	testState.AddBalance(maxBalance)
+	// 1: BLS credentials, never withdrawable
+	testState.AddValidator(&cltypes.Validator{WithdrawableEpoch: 5, EffectiveBalance: maxBalance})
+	testState.AddBalance(maxBalance + 1)
+	// 2: partially withdrawable
+	testState.AddValidator(&cltypes.Validator{WithdrawalCredentials: eth1Credentials(2), WithdrawableEpoch: 20, EffectiveBalance: maxBalance})
+	testState.AddBalance(maxBalance + 7)
+	// 3: not withdrawable yet
+	testState.AddValidator(&cltypes.Validator{WithdrawalCredentials: eth1Credentials(3), WithdrawableEpoch: 20, EffectiveBalance: maxBalance})
+	testState.AddBalance(maxBalance)
+	testState.SetNextWithdrawalIndex(100)
+	testState.SetNextWithdrawalValidatorIndex(2)
+
+	withdrawals := testState.NextWithdrawals(10)
+	require.Len(t, withdrawals, 2)
+	// the sweep starts at the cursor and wraps around
+	require.Equal(t, uint64(2), withdrawals[0].Validator)
+	require.Equal(t, uint64(100), withdrawals[0].Index)
+	require.Equal(t, uint64(7), withdrawals[0].Amount)
+	require.Equal(t, common.BytesToAddress([]byte{2}), withdrawals[0].Address)
+	require.Equal(t, uint64(0), withdrawals[1].Validator)
+	require.Equal(t, uint64(101), withdrawals[1].Index)
+	require.Equal(t, maxBalance, withdrawals[1].Amount)
+
+	require.Len(t, testState.NextWithdrawals(1), 1)
+	require.Equal(t, withdrawals, testState.ExpectedWithdrawals())
+}
+
+func TestGetDomain(t *testing.T) {
+	testState := getTestState(t)
+	testState.SetFork(&cltypes.Fork{
+		Epoch:           5,
+		PreviousVersion: [4]byte{0, 1, 2, 3},
+		CurrentVersion:  [4]byte{3, 2, 1, 0},
+	})
+	testState.SetGenesisValidatorsRoot(common.Hash{1})
+	domainType := clparams.MainnetBeaconConfig.DomainVoluntaryExit
+
+	// epoch 0 is before the fork, like any other epoch
+	for epoch, version := range map[uint64][4]byte{0: {0, 1, 2, 3}, 4: {0, 1, 2, 3}, 5: {3, 2, 1, 0}, 100: {3, 2, 1, 0}} {
+		expected, err := fork.ComputeDomain(domainType[:], version, common.Hash{1})
+		require.NoError(t, err)
+		domain, err := testState.GetDomain(domainType, epoch)
+		require.NoError(t, err)
+		require.Equal(t, expected, domain, epoch)
+
+		exit := &cltypes.VoluntaryExit{Epoch: epoch, ValidatorIndex: 1}
+		expectedRoot, err := fork.ComputeSigningRoot(exit, expected)
+		require.NoError(t, err)
+		root, err := testState.ComputeSigningRoot(exit, domainType, epoch)
+		require.NoError(t, err)
+		require.Equal(t, common.Hash(expectedRoot), root, epoch)
+	}
+}
+
+func TestGetAttestationParticipationFlagIndices(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	s := state.GetEmptyBeaconState()
+	s.SetSlot(70)
+	justified := &cltypes.Checkpoint{Epoch: 1, Root: common.Hash{1}}
+	s.SetCurrentJustifiedCheckpoint(justified)
+	s.SetPreviousJustifiedCheckpoint(&cltypes.Checkpoint{})
+	targetRoot, headRoot := common.Hash{2}, common.Hash{3}
+	s.SetBlockRootAt(64, targetRoot)
+	s.SetBlockRootAt(65, headRoot)
+	data := &cltypes.AttestationData{
+		Slot:            65,
+		BeaconBlockHash: headRoot,
+		Source:          justified,
+		Target:          &cltypes.Checkpoint{Epoch: 2, Root: targetRoot},
+	}
+	source, target, head := cfg.TimelySourceFlagIndex, cfg.TimelyTargetFlagIndex, cfg.TimelyHeadFlagIndex
+
+	for _, tc := range []struct {
+		inclusionDelay uint64
+		expected       []uint8
+	}{
+		// the head flag only at the minimum delay
+		{cfg.MinAttestationInclusionDelay, []uint8{source, target, head}},
+		{cfg.MinAttestationInclusionDelay + 1, []uint8{source, target}},
+		// the source flag up to the square root of the slots of an epoch
+		{5, []uint8{source, target}},
+		{6, []uint8{target}},
+		// the target flag up to an epoch
+		{cfg.SlotsPerEpoch, []uint8{target}},
+		{cfg.SlotsPerEpoch + 1, nil},
+	} {
+		flags, err := s.GetAttestationParticipationFlagIndices(data, tc.inclusionDelay)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, flags, "inclusion delay %d", tc.inclusionDelay)
+	}
+
+	// a wrong head doesn't earn the head flag, a wrong target neither the target nor the head flags
+	wrongHead := *data
+	wrongHead.BeaconBlockHash = common.Hash{4}
+	flags, err := s.GetAttestationParticipationFlagIndices(&wrongHead, cfg.MinAttestationInclusionDelay)
+	require.NoError(t, err)
+	require.Equal(t, []uint8{source, target}, flags)
+	wrongTarget := *data
+	wrongTarget.Target = &cltypes.Checkpoint{Epoch: 2, Root: common.Hash{4}}
+	flags, err = s.GetAttestationParticipationFlagIndices(&wrongTarget, cfg.MinAttestationInclusionDelay)
+	require.NoError(t, err)
+	require.Equal(t, []uint8{source}, flags)
+
+	// the source must be the justified checkpoint of the target epoch
+	wrongSource := *data
+	wrongSource.Source = &cltypes.Checkpoint{Epoch: 1, Root: common.Hash{4}}
+	_, err = s.GetAttestationParticipationFlagIndices(&wrongSource, cfg.MinAttestationInclusionDelay)
+	require.Error(t, err)
+}
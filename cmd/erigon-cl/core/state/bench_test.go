@@ -0,0 +1,150 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// benchValidators is the size of the validator set of the benchmarks.
+const benchValidators = 1 << 16
+
+// getBenchState returns a hashed Altair state of validators active validators, all of which attested in the previous
+// epoch.
+func getBenchState(tb testing.TB, validators int) *state.BeaconState {
+	cfg := &clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	b.SetSlot(10 * cfg.SlotsPerEpoch)
+	all := cltypes.ParticipationFlags(0).Add(0).Add(1).Add(2)
+	for i := 0; i < validators; i++ {
+		var pubKey [48]byte
+		pubKey[0], pubKey[1], pubKey[2] = byte(i), byte(i>>8), byte(i>>16)
+		b.AddValidator(&cltypes.Validator{
+			PublicKey:         pubKey,
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance + uint64(i))
+		b.AddInactivityScore(0)
+		b.AddPreviousEpochParticipationFlags(all)
+		b.AddCurrentEpochParticipationFlags(0)
+	}
+	_, err := b.HashSSZ()
+	require.NoError(tb, err)
+	return b
+}
+
+func BenchmarkHashTreeRoot(b *testing.B) {
+	s := getBenchState(b, benchValidators)
+	encoded, err := s.EncodeSSZ(nil)
+	require.NoError(b, err)
+
+	// the first root of a decoded state hashes every field
+	b.Run("cold", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			decoded := state.New(&clparams.MainnetBeaconConfig)
+			require.NoError(b, decoded.DecodeSSZWithVersion(encoded, int(clparams.AltairVersion)))
+			b.StartTimer()
+			if _, err := decoded.HashSSZ(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// a block changes a few balances, only their branches are hashed again
+	b.Run("balance", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.SetValidatorBalance(i%benchValidators, uint64(i))
+			if _, err := s.HashSSZ(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("slot", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.SetSlot(uint64(i))
+			if _, err := s.HashSSZ(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCopy(b *testing.B) {
+	s := getBenchState(b, benchValidators)
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.Copy()
+		}
+	})
+	// the first write to the balances of a copy copies them, and their tree
+	b.Run("write", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.Copy().SetValidatorBalance(0, uint64(i))
+		}
+	})
+	dst := s.Copy()
+	b.Run("into", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s.CopyInto(dst)
+		}
+	})
+}
+
+func BenchmarkEncodeSSZ(b *testing.B) {
+	s := getBenchState(b, benchValidators)
+	buf := make([]byte, 0, s.EncodingSizeSSZ())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.EncodeSSZ(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeSSZ(b *testing.B) {
+	encoded, err := getBenchState(b, benchValidators).EncodeSSZ(nil)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoded := state.New(&clparams.MainnetBeaconConfig)
+		if err := decoded.DecodeSSZWithVersion(encoded, int(clparams.AltairVersion)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestAllocations checks that the operations of every block don't allocate by validator: copying a state and hashing
+// it again after a balance changed allocate as much for a large validator set as for a small one. A field added
+// without copy-on-write or a cached tree fails it.
+func TestAllocations(t *testing.T) {
+	small, large := getBenchState(t, 64), getBenchState(t, 4096)
+	for name, operation := range map[string]func(s *state.BeaconState){
+		"copy": func(s *state.BeaconState) {
+			s.Copy()
+		},
+		"hash": func(s *state.BeaconState) {
+			s.SetValidatorBalance(1, s.ValidatorBalance(1)+1)
+			if _, err := s.HashSSZ(); err != nil {
+				t.Fatal(err)
+			}
+		},
+	} {
+		smallAllocs := testing.AllocsPerRun(10, func() { operation(small) })
+		largeAllocs := testing.AllocsPerRun(10, func() { operation(large) })
+		require.LessOrEqual(t, largeAllocs, smallAllocs, name)
+	}
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"sync"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// shareLock guards the copy-on-write flags of the states being copied, see CopyInto.
+var shareLock sync.Mutex
+
+// Copy returns a copy of the state which is unaffected by later changes to b, and doesn't affect b.
+//
+// The validators and the balances, which grow with the validator set, and the merkle trees cached for hashing are
+// shared until one of the states writes to them (copy-on-write), so fork choice can hold many candidate states of the
+// same lineage for the price of the small fields. Validators themselves are shared for good: they are replaced with
+// SetValidatorAt, never modified in place. The index of the public keys is shared for good too, it only grows.
+func (b *BeaconState) Copy() *BeaconState {
+	cpy := &BeaconState{}
+	b.CopyInto(cpy)
+	return cpy
+}
+
+// CopyInto makes dst a copy of b, like Copy, reusing the memory of the lists and maps of dst. dst must not be used
+// by anyone else.
+func (b *BeaconState) CopyInto(dst *BeaconState) {
+	historicalRoots, eth1DataVotes, historicalSummaries := dst.historicalRoots[:0], dst.eth1DataVotes[:0], dst.historicalSummaries[:0]
+	previousEpochParticipation, currentEpochParticipation := dst.previousEpochParticipation[:0], dst.currentEpochParticipation[:0]
+	inactivityScores, touchedLeaves := dst.inactivityScores[:0], dst.touchedLeaves
+
+	// copies of the same state, like the head state copied by the duties, can run concurrently: marking b as shared
+	// and reading the flags for dst is serialized
+	shareLock.Lock()
+	b.sharedValidators = true
+	b.sharedBalances = true
+	b.sharedRandaoMixesTree = true
+	b.sharedBlockRootsTree = true
+	*dst = *b
+	shareLock.Unlock()
+
+	dst.fork = copyPtr(b.fork)
+	dst.latestBlockHeader = copyPtr(b.latestBlockHeader)
+	dst.historicalRoots = append(historicalRoots, b.historicalRoots...)
+	dst.eth1Data = copyPtr(b.eth1Data)
+	dst.eth1DataVotes = eth1DataVotes
+	for _, vote := range b.eth1DataVotes {
+		dst.eth1DataVotes = append(dst.eth1DataVotes, copyPtr(vote))
+	}
+	dst.previousEpochParticipation = append(previousEpochParticipation, b.previousEpochParticipation...)
+	dst.currentEpochParticipation = append(currentEpochParticipation, b.currentEpochParticipation...)
+	dst.previousJustifiedCheckpoint = copyPtr(b.previousJustifiedCheckpoint)
+	dst.currentJustifiedCheckpoint = copyPtr(b.currentJustifiedCheckpoint)
+	dst.finalizedCheckpoint = copyPtr(b.finalizedCheckpoint)
+	dst.inactivityScores = append(inactivityScores, b.inactivityScores...)
+	dst.currentSyncCommittee = copySyncCommittee(b.currentSyncCommittee)
+	dst.nextSyncCommittee = copySyncCommittee(b.nextSyncCommittee)
+	if b.latestExecutionPayloadHeader != nil {
+		dst.latestExecutionPayloadHeader = types.CopyHeader(b.latestExecutionPayloadHeader)
+	}
+	dst.historicalSummaries = historicalSummaries
+	for _, summary := range b.historicalSummaries {
+		dst.historicalSummaries = append(dst.historicalSummaries, copyPtr(summary))
+	}
+	if touchedLeaves == nil {
+		touchedLeaves = make(map[StateLeafIndex]bool, len(b.touchedLeaves))
+	}
+	for idx := range touchedLeaves {
+		delete(touchedLeaves, idx)
+	}
+	for idx, touched := range b.touchedLeaves {
+		touchedLeaves[idx] = touched
+	}
+	dst.touchedLeaves = touchedLeaves
+}
+
+// ownValidators gives b its own validators slice before it is written to.
+func (b *BeaconState) ownValidators() {
+	if b.sharedValidators {
+		b.validators = append(make([]*cltypes.Validator, 0, len(b.validators)+1), b.validators...)
+		b.validatorsTree = b.validatorsTree.Copy()
+		b.sharedValidators = false
+	}
+}
+
+// ownBalances gives b its own balances slice before it is written to.
+func (b *BeaconState) ownBalances() {
+	if b.sharedBalances {
+		b.balances = append(make(packedUint64s, 0, len(b.balances)+8), b.balances...)
+		b.balancesTree = b.balancesTree.Copy()
+		b.sharedBalances = false
+	}
+}
+
+// ownTree returns tree, copied first when it is shared with copies of the state.
+func ownTree(tree *merkle_tree.Cache, shared *bool) *merkle_tree.Cache {
+	if *shared {
+		*shared = false
+		return tree.Copy()
+	}
+	return tree
+}
+
+func copyPtr[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	cpy := *v
+	return &cpy
+}
+
+func copySyncCommittee(c *cltypes.SyncCommittee) *cltypes.SyncCommittee {
+	if c == nil {
+		return nil
+	}
+	return &cltypes.SyncCommittee{PubKeys: append([][48]byte{}, c.PubKeys...), AggregatePublicKey: c.AggregatePublicKey}
+}
@@ -0,0 +1,166 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
+)
+
+// diffChunkSize is the size of the elements the fixed part of the state and the execution payload header are diffed
+// by.
+const diffChunkSize = 32
+
+// diffElementSizes are the sizes of the elements of the variable-size fields of BeaconStateContainer.
+var diffElementSizes = map[string]int{
+	"HistoricalRoots":              32,
+	"Eth1DataVotes":                72,
+	"Validators":                   121,
+	"Balances":                     8,
+	"PreviousEpochParticipation":   1,
+	"CurrentEpochParticipation":    1,
+	"InactivityScores":             8,
+	"LatestExecutionPayloadHeader": diffChunkSize,
+	"HistoricalSummaries":          64,
+}
+
+// diffSection is a part of the SSZ encoding of a state which is diffed element by element: the fixed part, then each
+// variable-size field.
+type diffSection struct {
+	buf         []byte
+	elementSize int
+}
+
+// diffSections splits buf, the SSZ encoding of a state at version, into its sections.
+func diffSections(buf []byte, version clparams.StateVersion) ([]diffSection, error) {
+	if version == clparams.Phase0Version {
+		return nil, fmt.Errorf("phase0 states are not supported")
+	}
+	fixedSize := BeaconStateContainer.FixedSize(version)
+	if len(buf) < fixedSize {
+		return nil, ssz_utils.ErrLowBufferSize
+	}
+	sections := []diffSection{{buf: buf[:fixedSize], elementSize: diffChunkSize}}
+	var names []string
+	var offsets []int
+	for _, field := range BeaconStateContainer.Fields(version) {
+		if _, ok := diffElementSizes[field.Name]; ok {
+			names = append(names, field.Name)
+			offsets = append(offsets, int(ssz_utils.DecodeOffset(buf[BeaconStateContainer.Position(field.Name, version):])))
+		}
+	}
+	offsets = append(offsets, len(buf))
+	for i, name := range names {
+		if offsets[i] < fixedSize || offsets[i] > offsets[i+1] || offsets[i+1] > len(buf) {
+			return nil, fmt.Errorf("bad offset of %s", name)
+		}
+		sections = append(sections, diffSection{buf: buf[offsets[i]:offsets[i+1]], elementSize: diffElementSizes[name]})
+	}
+	return sections, nil
+}
+
+// EncodeSSZDiff returns the diff from base to target, the SSZ encodings of two states at version. Each section of the
+// encoding is diffed by its elements: its new length, then the changed elements with the gap from the previous one,
+// XORed with the elements of base. From one slot to the next few elements change, and the XOR of a balance which
+// changes a little is mostly zeroes, so the diff is small and compresses well.
+func EncodeSSZDiff(version clparams.StateVersion, base, target []byte) ([]byte, error) {
+	baseSections, err := diffSections(base, version)
+	if err != nil {
+		return nil, fmt.Errorf("EncodeSSZDiff: base: %v", err)
+	}
+	targetSections, err := diffSections(target, version)
+	if err != nil {
+		return nil, fmt.Errorf("EncodeSSZDiff: target: %v", err)
+	}
+	var (
+		diff     []byte
+		baseElem []byte
+	)
+	for i, section := range targetSections {
+		size := section.elementSize
+		baseBuf := baseSections[i].buf
+		diff = appendUvarint(diff, uint64(len(section.buf)))
+
+		var changes []byte
+		count, previous := 0, -1
+		for index := 0; index*size < len(section.buf); index++ {
+			elem := section.buf[index*size : minInt((index+1)*size, len(section.buf))]
+			baseElem = append(baseElem[:0], make([]byte, len(elem))...)
+			if index*size < len(baseBuf) {
+				copy(baseElem, baseBuf[index*size:])
+			}
+			if bytes.Equal(elem, baseElem) {
+				continue
+			}
+			changes = appendUvarint(changes, uint64(index-previous-1))
+			for j := range elem {
+				changes = append(changes, elem[j]^baseElem[j])
+			}
+			count, previous = count+1, index
+		}
+		diff = appendUvarint(diff, uint64(count))
+		diff = append(diff, changes...)
+	}
+	return diff, nil
+}
+
+// ApplySSZDiff returns the SSZ encoding of the state which diff was encoded to, from base at version.
+func ApplySSZDiff(version clparams.StateVersion, base, diff []byte) ([]byte, error) {
+	baseSections, err := diffSections(base, version)
+	if err != nil {
+		return nil, fmt.Errorf("ApplySSZDiff: base: %v", err)
+	}
+	target := make([]byte, 0, len(base))
+	for _, section := range baseSections {
+		length, n := binary.Uvarint(diff)
+		if n <= 0 {
+			return nil, fmt.Errorf("ApplySSZDiff: bad section length")
+		}
+		diff = diff[n:]
+		count, n := binary.Uvarint(diff)
+		if n <= 0 {
+			return nil, fmt.Errorf("ApplySSZDiff: bad changes count")
+		}
+		diff = diff[n:]
+
+		start := len(target)
+		target = append(target, make([]byte, length)...)
+		copy(target[start:], section.buf)
+		size, index := section.elementSize, -1
+		for ; count > 0; count-- {
+			gap, n := binary.Uvarint(diff)
+			if n <= 0 {
+				return nil, fmt.Errorf("ApplySSZDiff: bad change index")
+			}
+			diff = diff[n:]
+			index += int(gap) + 1
+			from := start + index*size
+			to := minInt(from+size, len(target))
+			if from >= len(target) || len(diff) < to-from {
+				return nil, fmt.Errorf("ApplySSZDiff: change out of range")
+			}
+			for j := from; j < to; j++ {
+				target[j] ^= diff[j-from]
+			}
+			diff = diff[to-from:]
+		}
+	}
+	if len(diff) != 0 {
+		return nil, fmt.Errorf("ApplySSZDiff: %d trailing bytes", len(diff))
+	}
+	return target, nil
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var varint [binary.MaxVarintLen64]byte
+	return append(buf, varint[:binary.PutUvarint(varint[:], x)]...)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
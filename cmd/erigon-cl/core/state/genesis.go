@@ -0,0 +1,110 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// NewGenesis returns the genesis state of version before the genesis deposits are applied: it starts GenesisDelay
+// after eth1Block, whose hash seeds the randao mixes, at the fork version of version. From Bellatrix on the merge is
+// at genesis, eth1Block is the latest execution payload.
+func NewGenesis(cfg *clparams.BeaconChainConfig, version clparams.StateVersion, eth1Block *types.Header) (*BeaconState, error) {
+	forkVersion := cfg.GenesisForkVersion
+	switch version {
+	case clparams.AltairVersion:
+		forkVersion = cfg.AltairForkVersion
+	case clparams.BellatrixVersion:
+		forkVersion = cfg.BellatrixForkVersion
+	case clparams.CapellaVersion:
+		forkVersion = cfg.CapellaForkVersion
+	}
+	b := &BeaconState{
+		beaconConfig: cfg,
+		version:      version,
+		genesisTime:  eth1Block.Time + cfg.GenesisDelay,
+		fork: &cltypes.Fork{
+			PreviousVersion: utils.Uint32ToBytes4(forkVersion),
+			CurrentVersion:  utils.Uint32ToBytes4(forkVersion),
+			Epoch:           cfg.GenesisEpoch,
+		},
+		eth1Data:                    &cltypes.Eth1Data{BlockHash: eth1Block.Hash()},
+		previousJustifiedCheckpoint: &cltypes.Checkpoint{},
+		currentJustifiedCheckpoint:  &cltypes.Checkpoint{},
+		finalizedCheckpoint:         &cltypes.Checkpoint{},
+		currentSyncCommittee:        &cltypes.SyncCommittee{PubKeys: make([][48]byte, cfg.SyncCommitteeSize)},
+		nextSyncCommittee:           &cltypes.SyncCommittee{PubKeys: make([][48]byte, cfg.SyncCommitteeSize)},
+		latestExecutionPayloadHeader: &types.Header{
+			BaseFee: new(big.Int),
+			Number:  new(big.Int),
+		},
+	}
+	b.initBeaconState()
+	for i := range b.randaoMixes {
+		b.randaoMixes[i] = b.eth1Data.BlockHash
+	}
+
+	// the header of the genesis block, with an empty body
+	body := &cltypes.BeaconBody{
+		Eth1Data:         &cltypes.Eth1Data{},
+		SyncAggregate:    &cltypes.SyncAggregate{},
+		ExecutionPayload: &cltypes.Eth1Block{Header: &types.Header{BaseFee: new(big.Int), Number: new(big.Int)}, Body: &types.RawBody{}},
+		Version:          version,
+	}
+	bodyRoot, err := body.HashSSZ()
+	if err != nil {
+		return nil, fmt.Errorf("NewGenesis: %v", err)
+	}
+	b.latestBlockHeader = &cltypes.BeaconBlockHeader{BodyRoot: bodyRoot}
+
+	if version >= clparams.BellatrixVersion {
+		payload := &cltypes.Eth1Block{Header: types.CopyHeader(eth1Block), Body: &types.RawBody{}}
+		payload.Header.BlockHashCL = eth1Block.Hash()
+		// hashing fills the transactions and withdrawals roots of the header
+		if _, err := payload.HashSSZ(version); err != nil {
+			return nil, fmt.Errorf("NewGenesis: %v", err)
+		}
+		b.latestExecutionPayloadHeader = payload.Header
+	}
+	return b, nil
+}
+
+// ActivateGenesisValidators completes the genesis state once its deposits are applied: the validators of a full
+// effective balance are active from genesis, the genesis validators root commits to the validator set and the sync
+// committees are drawn from it.
+func (b *BeaconState) ActivateGenesisValidators() error {
+	for index, validator := range b.validators {
+		balance := b.balances.Get(index)
+		// validators are shared with the copies of the state
+		activated := *validator
+		activated.EffectiveBalance = balance - balance%b.beaconConfig.EffectiveBalanceIncrement
+		if activated.EffectiveBalance > b.beaconConfig.MaxEffectiveBalance {
+			activated.EffectiveBalance = b.beaconConfig.MaxEffectiveBalance
+		}
+		if activated.EffectiveBalance == b.beaconConfig.MaxEffectiveBalance {
+			activated.ActivationEligibilityEpoch = b.beaconConfig.GenesisEpoch
+			activated.ActivationEpoch = b.beaconConfig.GenesisEpoch
+		}
+		b.SetValidatorAt(index, &activated)
+	}
+	if err := b.computeDirtyLeaves(); err != nil {
+		return fmt.Errorf("ActivateGenesisValidators: %v", err)
+	}
+	b.SetGenesisValidatorsRoot(b.leaves[ValidatorsLeafIndex])
+	if b.version < clparams.AltairVersion {
+		return nil
+	}
+	committee, err := b.ComputeNextSyncCommittee()
+	if err != nil {
+		return fmt.Errorf("ActivateGenesisValidators: %v", err)
+	}
+	b.SetCurrentSyncCommittee(committee)
+	next := *committee
+	next.PubKeys = append([][48]byte{}, committee.PubKeys...)
+	b.SetNextSyncCommittee(&next)
+	return nil
+}
@@ -61,6 +61,7 @@ func (b *BeaconState) Eth1DepositIndex() uint64 {
 	return b.eth1DepositIndex
 }
 
+// Validators returns the validators, which may be shared with copies of the state: use the setters to change them.
 func (b *BeaconState) Validators() []*cltypes.Validator {
 	return b.validators
 }
@@ -69,12 +70,13 @@ func (b *BeaconState) ValidatorAt(index int) *cltypes.Validator {
 	return b.validators[index]
 }
 
+// Balances returns a copy of the balances, ValidatorBalance reads one without copying.
 func (b *BeaconState) Balances() []uint64 {
-	return b.balances
+	return b.balances.Unpack()
 }
 
 func (b *BeaconState) ValidatorBalance(index int) uint64 {
-	return b.balances[index]
+	return b.balances.Get(index)
 }
 
 func (b *BeaconState) RandaoMixes() [randoMixesLength]libcommon.Hash {
@@ -109,6 +111,15 @@ func (b *BeaconState) CurrentJustifiedCheckpoint() *cltypes.Checkpoint {
 	return b.currentJustifiedCheckpoint
 }
 
+// InactivityScores returns a copy of the inactivity scores, InactivityScoreAt reads one without copying.
+func (b *BeaconState) InactivityScores() []uint64 {
+	return b.inactivityScores.Unpack()
+}
+
+func (b *BeaconState) InactivityScoreAt(index int) uint64 {
+	return b.inactivityScores.Get(index)
+}
+
 func (b *BeaconState) FinalizedCheckpoint() *cltypes.Checkpoint {
 	return b.finalizedCheckpoint
 }
@@ -141,7 +152,11 @@ func (b *BeaconState) Version() clparams.StateVersion {
 	return b.version
 }
 
+// ValidatorIndexByPubkey returns the index of the validator with the serialized public key key.
 func (b *BeaconState) ValidatorIndexByPubkey(key [48]byte) (uint64, bool) {
-	val, ok := b.publicKeyIndicies[key]
-	return val, ok
+	return b.publicKeyIndex.lookup(key, b.validators)
+}
+
+func (b *BeaconState) BeaconConfig() *clparams.BeaconChainConfig {
+	return b.beaconConfig
 }
@@ -2,14 +2,17 @@ package state
 
 import (
 	"fmt"
+	"sync"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
 )
 
 func (b *BeaconState) IncreaseBalance(index int, delta uint64) {
-	b.SetValidatorBalance(index, b.balances[index]+delta)
+	b.SetValidatorBalance(index, b.balances.Get(index)+delta)
 }
 
 func (b *BeaconState) DecreaseBalance(index, delta uint64) {
-	curAmount := b.balances[index]
+	curAmount := b.balances.Get(int(index))
 	var newBalance uint64
 	if curAmount >= delta {
 		newBalance = curAmount - delta
@@ -17,6 +20,80 @@ func (b *BeaconState) DecreaseBalance(index, delta uint64) {
 	b.SetValidatorBalance(int(index), newBalance)
 }
 
+// ApplyDeltas adds deltas[i] to the balance of the validator i, a balance does not go below zero. The balances are
+// written in one pass and only the hashes of the chunks which changed are computed again, the epoch processing
+// changes most of them at once.
+func (b *BeaconState) ApplyDeltas(deltas []int64) error {
+	if len(deltas) != b.balances.Len() {
+		return fmt.Errorf("ApplyDeltas: %d deltas for %d balances", len(deltas), b.balances.Len())
+	}
+	b.touchedLeaves[BalancesLeafIndex] = true
+	b.ownBalances()
+	for _, chunk := range applyDeltas(b.balances, deltas, 0) {
+		b.balancesTree.Invalidate(chunk)
+	}
+	return nil
+}
+
+// ApplyDeltasParallel is ApplyDeltas over workers goroutines, for large validator sets.
+func (b *BeaconState) ApplyDeltasParallel(deltas []int64, workers int) error {
+	if workers < 2 {
+		return b.ApplyDeltas(deltas)
+	}
+	if len(deltas) != b.balances.Len() {
+		return fmt.Errorf("ApplyDeltasParallel: %d deltas for %d balances", len(deltas), b.balances.Len())
+	}
+	b.touchedLeaves[BalancesLeafIndex] = true
+	b.ownBalances()
+	// the ranges are aligned to the chunks of 4 balances: a chunk is changed by a single worker
+	size := ((len(deltas)+workers-1)/workers + 3) &^ 3
+	changed := make([][]uint64, workers)
+	var wg sync.WaitGroup
+	for worker, first := 0, 0; first < len(deltas); worker, first = worker+1, first+size {
+		last := first + size
+		if last > len(deltas) {
+			last = len(deltas)
+		}
+		wg.Add(1)
+		go func(worker, first, last int) {
+			defer wg.Done()
+			changed[worker] = applyDeltas(b.balances[8*first:8*last], deltas[first:last], uint64(first))
+		}(worker, first, last)
+	}
+	wg.Wait()
+	for _, chunks := range changed {
+		for _, chunk := range chunks {
+			b.balancesTree.Invalidate(chunk)
+		}
+	}
+	return nil
+}
+
+// applyDeltas adds deltas to balances, which start at the index first of the balances of the state, and returns
+// the chunks which changed.
+func applyDeltas(balances packedUint64s, deltas []int64, first uint64) (changed []uint64) {
+	lastChunk := uint64(1<<64 - 1)
+	for i, delta := range deltas {
+		balance := balances.Get(i)
+		switch {
+		case delta == 0:
+			continue
+		case delta > 0:
+			balance += uint64(delta)
+		case uint64(-delta) < balance:
+			balance -= uint64(-delta)
+		default:
+			balance = 0
+		}
+		balances.Set(i, balance)
+		if chunk := (first + uint64(i)) / 4; chunk != lastChunk {
+			changed = append(changed, chunk)
+			lastChunk = chunk
+		}
+	}
+	return changed
+}
+
 func (b *BeaconState) ComputeActivationExitEpoch(epoch uint64) uint64 {
 	return epoch + 1 + b.beaconConfig.MaxSeedLookahead
 }
@@ -31,20 +108,17 @@ func (b *BeaconState) GetValidatorChurnLimit() uint64 {
 }
 
 func (b *BeaconState) InitiateValidatorExit(index uint64) {
-	validator := b.validators[index]
+	// Copied: validators are shared with the copies of the state
+	validator := copyPtr(b.validators[index])
 	if validator.ExitEpoch != b.beaconConfig.FarFutureEpoch {
 		return
 	}
 
-	currentEpoch := b.Epoch()
-	exitQueueEpoch := currentEpoch
-	activationExitEpoch := b.ComputeActivationExitEpoch(currentEpoch)
+	// the exit queue is at the latest exit, not before the activation exit epoch
+	exitQueueEpoch := b.ComputeActivationExitEpoch(b.Epoch())
 	for _, v := range b.validators {
-		if v.ExitEpoch != b.beaconConfig.FarFutureEpoch {
-			potentialExit := v.ExitEpoch + activationExitEpoch
-			if potentialExit > exitQueueEpoch {
-				exitQueueEpoch = potentialExit
-			}
+		if v.ExitEpoch != b.beaconConfig.FarFutureEpoch && v.ExitEpoch > exitQueueEpoch {
+			exitQueueEpoch = v.ExitEpoch
 		}
 	}
 
@@ -63,10 +137,13 @@ func (b *BeaconState) InitiateValidatorExit(index uint64) {
 	b.SetValidatorAt(int(index), validator)
 }
 
+// SlashValidator slashes the validator at slashedInd: it exits, is penalized and the slashed balance is recorded for
+// the proportional penalty of the epoch processing. The whistleblower, the proposer when whistleblowerInd is 0, and
+// the proposer share the whistleblower reward.
 func (b *BeaconState) SlashValidator(slashedInd, whistleblowerInd uint64) error {
 	epoch := b.Epoch()
 	b.InitiateValidatorExit(slashedInd)
-	newValidator := b.validators[slashedInd]
+	newValidator := copyPtr(b.validators[slashedInd])
 	newValidator.Slashed = true
 	withdrawEpoch := epoch + b.beaconConfig.EpochsPerSlashingsVector
 	if newValidator.WithdrawableEpoch < withdrawEpoch {
@@ -76,7 +153,7 @@ func (b *BeaconState) SlashValidator(slashedInd, whistleblowerInd uint64) error
 	segmentIndex := int(epoch % b.beaconConfig.EpochsPerSlashingsVector)
 	currentSlashing := b.SlashingSegmentAt(segmentIndex)
 	b.SetSlashingSegmentAt(segmentIndex, currentSlashing+newValidator.EffectiveBalance)
-	b.DecreaseBalance(slashedInd, newValidator.EffectiveBalance/b.beaconConfig.MinSlashingPenaltyQuotient)
+	b.DecreaseBalance(slashedInd, newValidator.EffectiveBalance/b.minSlashingPenaltyQuotient())
 
 	proposerInd, err := b.GetBeaconProposerIndex()
 	if err != nil {
@@ -86,8 +163,24 @@ func (b *BeaconState) SlashValidator(slashedInd, whistleblowerInd uint64) error
 		whistleblowerInd = proposerInd
 	}
 	whistleBlowerReward := newValidator.EffectiveBalance / b.beaconConfig.WhistleBlowerRewardQuotient
-	proposerReward := whistleBlowerReward / b.beaconConfig.ProposerRewardQuotient
+	var proposerReward uint64
+	if b.version >= clparams.AltairVersion {
+		proposerReward = whistleBlowerReward * b.beaconConfig.ProposerWeight / b.beaconConfig.WeightDenominator
+	} else {
+		proposerReward = whistleBlowerReward / b.beaconConfig.ProposerRewardQuotient
+	}
 	b.IncreaseBalance(int(proposerInd), proposerReward)
-	b.IncreaseBalance(int(whistleblowerInd), whistleBlowerReward)
+	b.IncreaseBalance(int(whistleblowerInd), whistleBlowerReward-proposerReward)
 	return nil
 }
+
+func (b *BeaconState) minSlashingPenaltyQuotient() uint64 {
+	switch {
+	case b.version >= clparams.BellatrixVersion:
+		return b.beaconConfig.MinSlashingPenaltyQuotientBellatrix
+	case b.version >= clparams.AltairVersion:
+		return b.beaconConfig.MinSlashingPenaltyQuotientAltair
+	default:
+		return b.beaconConfig.MinSlashingPenaltyQuotient
+	}
+}
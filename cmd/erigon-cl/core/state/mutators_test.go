@@ -3,6 +3,8 @@ package state_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
@@ -88,8 +90,63 @@ func TestDecreaseBalance(t *testing.T) {
 	}
 }
 
+func TestApplyDeltas(t *testing.T) {
+	deltas := make([]int64, 2048)
+	deltas[5], deltas[7], deltas[2000] = 10, -100, -3
+	expected := getTestStateBalances(t).Balances()
+	expected[5], expected[7], expected[2000] = 15, 0, 1997
+	wantState := state.GetEmptyBeaconState()
+	wantState.SetBalances(expected)
+	want, err := wantState.HashSSZ()
+	require.NoError(t, err)
+
+	for _, workers := range []int{1, 3, 16} {
+		b := getTestStateBalances(t)
+		// the hashes of the balances are cached, and shared with the copy
+		_, err := b.HashSSZ()
+		require.NoError(t, err)
+		before := b.Copy()
+
+		require.Error(t, b.ApplyDeltasParallel(deltas[1:], workers))
+		require.NoError(t, b.ApplyDeltasParallel(deltas, workers))
+		require.Equal(t, expected, b.Balances())
+		root, err := b.HashSSZ()
+		require.NoError(t, err)
+		require.Equal(t, want, root)
+		require.Equal(t, uint64(7), before.Balances()[7])
+	}
+}
+
+// BenchmarkApplyDeltas applies the deltas of an epoch to a million balances and hashes them again, like the rewards
+// and penalties do.
+func BenchmarkApplyDeltas(b *testing.B) {
+	const validators = 1 << 20
+	balances := make([]uint64, validators)
+	deltas := make([]int64, validators)
+	for i := range balances {
+		balances[i] = clparams.MainnetBeaconConfig.MaxEffectiveBalance
+		deltas[i] = int64(i%7) - 3
+	}
+	s := state.GetEmptyBeaconState()
+	s.SetBalances(balances)
+	if _, err := s.HashSSZ(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.ApplyDeltas(deltas); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := s.HashSSZ(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestInitiatieValidatorExit(t *testing.T) {
-	exitDelay := testExitEpoch + clparams.MainnetBeaconConfig.MaxSeedLookahead + 1
+	// the other validators exit before the activation exit epoch, the exit queue starts at it
+	activationExitEpoch := testExitEpoch + clparams.MainnetBeaconConfig.MaxSeedLookahead + 1
 	testCases := []struct {
 		description                string
 		numValidators              uint64
@@ -100,8 +157,8 @@ func TestInitiatieValidatorExit(t *testing.T) {
 		{
 			description:                "success",
 			numValidators:              3,
-			expectedExitEpoch:          testExitEpoch + exitDelay,
-			expectedWithdrawlableEpoch: testExitEpoch + exitDelay + clparams.MainnetBeaconConfig.MinValidatorWithdrawabilityDelay,
+			expectedExitEpoch:          activationExitEpoch,
+			expectedWithdrawlableEpoch: activationExitEpoch + clparams.MainnetBeaconConfig.MinValidatorWithdrawabilityDelay,
 			validator: &cltypes.Validator{
 				ExitEpoch:       clparams.MainnetBeaconConfig.FarFutureEpoch,
 				ActivationEpoch: 0,
@@ -148,22 +205,24 @@ func TestSlashValidator(t *testing.T) {
 		successBalances = append(successBalances, uint64(i+1))
 		wantBalances = append(wantBalances, uint64(i+1))
 	}
-	successState.SetBalances(successBalances)
 
 	// Set up slashed balance.
 	preSlashBalance := uint64(1 << 20)
-	successState.Balances()[slashedInd] = preSlashBalance
+	successBalances[slashedInd] = preSlashBalance
+	successState.SetBalances(successBalances)
 	successState.ValidatorAt(slashedInd).EffectiveBalance = preSlashBalance
-	wantBalances[slashedInd] = preSlashBalance - (preSlashBalance / clparams.MainnetBeaconConfig.MinSlashingPenaltyQuotient)
+	// The test state is a bellatrix one.
+	wantBalances[slashedInd] = preSlashBalance - (preSlashBalance / clparams.MainnetBeaconConfig.MinSlashingPenaltyQuotientBellatrix)
 
 	// Set up whistleblower & validator balances.
 	wbReward := preSlashBalance / clparams.MainnetBeaconConfig.WhistleBlowerRewardQuotient
-	wantBalances[whistleblowerInd] += wbReward
+	proposerReward := wbReward * clparams.MainnetBeaconConfig.ProposerWeight / clparams.MainnetBeaconConfig.WeightDenominator
+	wantBalances[whistleblowerInd] += wbReward - proposerReward
 	valInd, err := successState.GetBeaconProposerIndex()
 	if err != nil {
 		t.Fatalf("unable to get proposer index for test state: %v", err)
 	}
-	wantBalances[valInd] += wbReward / clparams.MainnetBeaconConfig.ProposerRewardQuotient
+	wantBalances[valInd] += proposerReward
 
 	failState := getTestState(t)
 	for _, v := range failState.Validators() {
@@ -216,3 +275,36 @@ func TestSlashValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestSlashValidatorAltair(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for i := 0; i < 64; i++ {
+		b.AddValidator(&cltypes.Validator{ExitEpoch: cfg.FarFutureEpoch, WithdrawableEpoch: cfg.FarFutureEpoch, EffectiveBalance: cfg.MaxEffectiveBalance})
+		b.AddBalance(cfg.MaxEffectiveBalance)
+	}
+	b.SetSlot(19)
+	proposer, err := b.GetBeaconProposerIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slashed, whistleblower := (proposer+1)%64, (proposer+2)%64
+	if err := b.SlashValidator(slashed, whistleblower); err != nil {
+		t.Fatal(err)
+	}
+
+	reward := cfg.MaxEffectiveBalance / cfg.WhistleBlowerRewardQuotient
+	proposerReward := reward * cfg.ProposerWeight / cfg.WeightDenominator
+	if got, want := b.Balances()[slashed], cfg.MaxEffectiveBalance-cfg.MaxEffectiveBalance/cfg.MinSlashingPenaltyQuotientAltair; got != want {
+		t.Errorf("unexpected slashed balance: got %d, want %d", got, want)
+	}
+	if got, want := b.Balances()[proposer], cfg.MaxEffectiveBalance+proposerReward; got != want {
+		t.Errorf("unexpected proposer balance: got %d, want %d", got, want)
+	}
+	if got, want := b.Balances()[whistleblower], cfg.MaxEffectiveBalance+reward-proposerReward; got != want {
+		t.Errorf("unexpected whistleblower balance: got %d, want %d", got, want)
+	}
+	if got, want := b.SlashingSegmentAt(0), cfg.MaxEffectiveBalance; got != want {
+		t.Errorf("unexpected slashings: got %d, want %d", got, want)
+	}
+}
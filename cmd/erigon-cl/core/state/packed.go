@@ -0,0 +1,68 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// packedUint64s is a list of uint64 laid out as its SSZ encoding, 8 bytes little endian per value. The chunks of 32
+// bytes are the leaves of its merkle tree and the list encodes and decodes by a copy, a state of a million
+// validators doesn't convert millions of values whenever it is hashed or stored.
+type packedUint64s []byte
+
+func packUint64s(values []uint64) packedUint64s {
+	p := make(packedUint64s, 8*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint64(p[8*i:], value)
+	}
+	return p
+}
+
+// decodePackedUint64s copies the list encoded in buf, of at most limit values.
+func decodePackedUint64s(buf []byte, limit int) (packedUint64s, error) {
+	if len(buf)%8 != 0 {
+		return nil, fmt.Errorf("list of %d bytes is not a list of uint64", len(buf))
+	}
+	if len(buf)/8 > limit {
+		return nil, fmt.Errorf("list of %d values is over the limit %d", len(buf)/8, limit)
+	}
+	return append(packedUint64s{}, buf...), nil
+}
+
+func (p packedUint64s) Len() int {
+	return len(p) / 8
+}
+
+func (p packedUint64s) Get(index int) uint64 {
+	return binary.LittleEndian.Uint64(p[8*index:])
+}
+
+func (p packedUint64s) Set(index int, value uint64) {
+	binary.LittleEndian.PutUint64(p[8*index:], value)
+}
+
+func (p packedUint64s) Append(value uint64) packedUint64s {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	return append(p, buf[:]...)
+}
+
+// Unpack returns the values in a new slice.
+func (p packedUint64s) Unpack() []uint64 {
+	values := make([]uint64, p.Len())
+	for i := range values {
+		values[i] = p.Get(i)
+	}
+	return values
+}
+
+// Chunks returns the number of chunks of 4 values, the last one may be partial.
+func (p packedUint64s) Chunks() uint64 {
+	return uint64(len(p)+31) / 32
+}
+
+// Chunk returns the chunk of the values 4*i to 4*i+3, padded with zeroes.
+func (p packedUint64s) Chunk(i uint64) (chunk [32]byte) {
+	copy(chunk[:], p[32*i:])
+	return chunk
+}
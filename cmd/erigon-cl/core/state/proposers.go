@@ -0,0 +1,68 @@
+package state
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// proposersCacheSize is the number of epochs whose proposers are kept: the current and next epochs of a few forks.
+const proposersCacheSize = 8
+
+// proposersKey identifies the proposers of epoch computed at the state epoch atEpoch of the lineage of the block
+// dependentRoot, the last block before atEpoch. The effective balances, the active set and the seeds are fixed at the
+// start of an epoch, so are the proposers of the epoch and the prediction of the next one.
+type proposersKey struct {
+	epoch         uint64
+	atEpoch       uint64
+	dependentRoot libcommon.Hash
+}
+
+// proposersCache keeps the proposers of the slots of the epochs, it is shared by the copies of a state.
+type proposersCache struct {
+	proposers *lru.Cache // proposersKey -> []uint64
+}
+
+func newProposersCache() *proposersCache {
+	proposers, err := lru.New(proposersCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &proposersCache{proposers: proposers}
+}
+
+// EpochProposers returns the proposers of the slots of epoch, the current or the next one. The proposers of the next
+// epoch are predicted from the effective balances of the current one, which the epoch transition may still change.
+// They are computed once for the states of a lineage at an epoch, the result is shared, it must not be modified.
+func (b *BeaconState) EpochProposers(epoch uint64) ([]uint64, error) {
+	atEpoch := b.Epoch()
+	if epoch != atEpoch && epoch != atEpoch+1 {
+		return nil, fmt.Errorf("EpochProposers: proposers of epoch %d cannot be computed at epoch %d", epoch, atEpoch)
+	}
+	key := proposersKey{epoch: epoch, atEpoch: atEpoch}
+	if atEpoch > b.beaconConfig.GenesisEpoch {
+		var err error
+		if key.dependentRoot, err = b.GetBlockRootAtSlot(atEpoch*b.beaconConfig.SlotsPerEpoch - 1); err != nil {
+			return nil, fmt.Errorf("EpochProposers: %v", err)
+		}
+	}
+	if b.proposers != nil {
+		if proposers, ok := b.proposers.proposers.Get(key); ok {
+			return proposers.([]uint64), nil
+		}
+	}
+	proposers := make([]uint64, b.beaconConfig.SlotsPerEpoch)
+	indices := b.GetActiveValidatorsIndices(epoch)
+	for i := range proposers {
+		proposer, err := b.ComputeProposerIndex(indices, b.proposerSeed(epoch*b.beaconConfig.SlotsPerEpoch+uint64(i)))
+		if err != nil {
+			return nil, fmt.Errorf("EpochProposers: %v", err)
+		}
+		proposers[i] = proposer
+	}
+	if b.proposers != nil {
+		b.proposers.proposers.Add(key, proposers)
+	}
+	return proposers, nil
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Giulio2002/bls"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+// publicKeyCache keeps deserialized BLS public keys by validator index. Decompressing a key and running the
+// subgroup check is a significant share of signature verification, and it is the same work for every block.
+// Entries remember the serialized key, so a validator replaced at the same index is never served a stale key.
+type publicKeyCache struct {
+	mu   sync.RWMutex
+	keys []publicKeyCacheEntry
+}
+
+type publicKeyCacheEntry struct {
+	raw [48]byte
+	key *bls.PublicKey
+}
+
+func (c *publicKeyCache) get(index uint64, raw [48]byte) *bls.PublicKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if index >= uint64(len(c.keys)) || c.keys[index].key == nil || c.keys[index].raw != raw {
+		return nil
+	}
+	return c.keys[index].key
+}
+
+func (c *publicKeyCache) put(index uint64, raw [48]byte, key *bls.PublicKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if index >= uint64(len(c.keys)) {
+		keys := make([]publicKeyCacheEntry, index+1, 2*(index+1))
+		copy(keys, c.keys)
+		c.keys = keys
+	}
+	c.keys[index] = publicKeyCacheEntry{raw: raw, key: key}
+}
+
+// publicKeyIndex maps the serialized public keys of the validators to their indices. Validators are only appended
+// and their keys never change, so a state and all its copies, on every fork, share one index which only grows: an
+// entry is only trusted by a state whose validator at that index has the key.
+type publicKeyIndex struct {
+	mu      sync.RWMutex
+	indices map[[48]byte][]uint64 // usually one index, more when forks added the key at different indices
+}
+
+func newPublicKeyIndex() *publicKeyIndex {
+	return &publicKeyIndex{indices: make(map[[48]byte][]uint64)}
+}
+
+func (p *publicKeyIndex) add(key [48]byte, index uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, i := range p.indices[key] {
+		if i == index {
+			return
+		}
+	}
+	p.indices[key] = append(p.indices[key], index)
+}
+
+// lookup returns the index of key among validators.
+func (p *publicKeyIndex) lookup(key [48]byte, validators []*cltypes.Validator) (uint64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, i := range p.indices[key] {
+		if i < uint64(len(validators)) && validators[i].PublicKey == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ValidatorPublicKey returns the deserialized and validated public key of the validator at index.
+func (b *BeaconState) ValidatorPublicKey(index uint64) (*bls.PublicKey, error) {
+	if index >= uint64(len(b.validators)) {
+		return nil, fmt.Errorf("validator index %d out of range, validators: %d", index, len(b.validators))
+	}
+	raw := b.validators[index].PublicKey
+	if key := b.publicKeys.get(index, raw); key != nil {
+		return key, nil
+	}
+	key, err := bls.NewPublicKeyFromBytes(raw[:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of validator %d: %v", index, err)
+	}
+	b.publicKeys.put(index, raw, key)
+	return key, nil
+}
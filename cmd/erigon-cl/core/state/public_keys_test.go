@@ -0,0 +1,80 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// Interop validators public keys.
+var testPublicKeys = []string{
+	"a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c",
+	"b89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b",
+	"a3a32b0f8b4ddb83f1a0a853d81dd725dfe577d4f4c3db8ece52ce2b026eca84815c1a7e8e92a4de3d755733bf7e4a9b",
+}
+
+func testValidatorWithKey(t *testing.T, i int) *cltypes.Validator {
+	v := &cltypes.Validator{}
+	copy(v.PublicKey[:], common.Hex2Bytes(testPublicKeys[i]))
+	return v
+}
+
+func TestValidatorPublicKey(t *testing.T) {
+	b := state.GetEmptyBeaconState()
+	b.SetValidators([]*cltypes.Validator{testValidatorWithKey(t, 0), testValidatorWithKey(t, 1)})
+
+	key, err := b.ValidatorPublicKey(1)
+	require.NoError(t, err)
+	require.Equal(t, b.ValidatorAt(1).PublicKey[:], key.Bytes(nil))
+	cached, err := b.ValidatorPublicKey(1)
+	require.NoError(t, err)
+	require.True(t, key == cached, "key should be served from cache")
+
+	// a different validator at the same index must not get the cached key
+	b.SetValidatorAt(1, testValidatorWithKey(t, 2))
+	replaced, err := b.ValidatorPublicKey(1)
+	require.NoError(t, err)
+	require.Equal(t, b.ValidatorAt(1).PublicKey[:], replaced.Bytes(nil))
+
+	b.AddValidator(&cltypes.Validator{}) // zero key is not a valid point
+	_, err = b.ValidatorPublicKey(2)
+	require.Error(t, err)
+	_, err = b.ValidatorPublicKey(3)
+	require.Error(t, err)
+}
+
+func TestValidatorIndexByPubkey(t *testing.T) {
+	b := state.GetEmptyBeaconState()
+	b.SetValidators([]*cltypes.Validator{testValidatorWithKey(t, 0)})
+	index, ok := b.ValidatorIndexByPubkey(b.ValidatorAt(0).PublicKey)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), index)
+
+	// two forks add different validators at the same index, and the same one at different indices
+	fork1, fork2 := b.Copy(), b.Copy()
+	fork1.AddValidator(testValidatorWithKey(t, 1))
+	fork2.AddValidator(testValidatorWithKey(t, 2))
+	fork2.AddValidator(testValidatorWithKey(t, 1))
+	for _, tt := range []struct {
+		state *state.BeaconState
+		key   int
+		index uint64
+		ok    bool
+	}{
+		{b, 1, 0, false},
+		{b, 2, 0, false},
+		{fork1, 0, 0, true},
+		{fork1, 1, 1, true},
+		{fork1, 2, 0, false},
+		{fork2, 1, 2, true},
+		{fork2, 2, 1, true},
+	} {
+		index, ok := tt.state.ValidatorIndexByPubkey(testValidatorWithKey(t, tt.key).PublicKey)
+		require.Equal(t, tt.ok, ok, "key %d", tt.key)
+		require.Equal(t, tt.index, index, "key %d", tt.key)
+	}
+}
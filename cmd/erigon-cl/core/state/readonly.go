@@ -0,0 +1,58 @@
+package state
+
+import (
+	"github.com/Giulio2002/bls"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// ReadOnlyBeaconState is the view of a state for the readers running concurrently with block processing: fork
+// choice, the Beacon API, gossip validation. Its methods don't write to the state, the caches they fill are
+// synchronized, so a state which nobody modifies (see Snapshot) is read without locks. The lists and the pointers it
+// returns are shared with the state, they must not be modified.
+type ReadOnlyBeaconState interface {
+	BeaconConfig() *clparams.BeaconChainConfig
+	Version() clparams.StateVersion
+	GenesisTime() uint64
+	GenesisValidatorsRoot() libcommon.Hash
+	Slot() uint64
+	Epoch() uint64
+	PreviousEpoch() uint64
+	Fork() *cltypes.Fork
+	LatestBlockHeader() *cltypes.BeaconBlockHeader
+	GetBlockRootAtSlot(slot uint64) (libcommon.Hash, error)
+	GetRandaoMixes(epoch uint64) [32]byte
+	Eth1Data() *cltypes.Eth1Data
+	Eth1DepositIndex() uint64
+	HistoricalRoots() []libcommon.Hash
+	HistoricalSummaries() []*cltypes.HistoricalSummary
+
+	Validators() []*cltypes.Validator
+	ValidatorAt(index int) *cltypes.Validator
+	ValidatorIndexByPubkey(key [48]byte) (uint64, bool)
+	ValidatorPublicKey(index uint64) (*bls.PublicKey, error)
+	Balances() []uint64
+	ValidatorBalance(index int) uint64
+	GetActiveValidatorsIndices(epoch uint64) []uint64
+	GetTotalActiveBalance() (uint64, error)
+
+	CommitteeCount(epoch uint64) uint64
+	ShuffledActiveIndices(epoch uint64) []uint64
+	GetBeaconProposerIndex() (uint64, error)
+	EpochProposers(epoch uint64) ([]uint64, error)
+	ComputeBeaconProposerIndexAtSlot(slot uint64) (uint64, error)
+	CurrentSyncCommittee() *cltypes.SyncCommittee
+	NextSyncCommittee() *cltypes.SyncCommittee
+
+	PreviousJustifiedCheckpoint() *cltypes.Checkpoint
+	CurrentJustifiedCheckpoint() *cltypes.Checkpoint
+	FinalizedCheckpoint() *cltypes.Checkpoint
+
+	LatestExecutionPayloadHeader() *types.Header
+	IsMergeTransitionComplete() bool
+}
+
+var _ ReadOnlyBeaconState = (*BeaconState)(nil)
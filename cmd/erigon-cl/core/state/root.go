@@ -1,10 +1,15 @@
 package state
 
 import (
+	"fmt"
+	"math/bits"
+
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state/state_encoding"
 )
 
@@ -16,7 +21,25 @@ func (b *BeaconState) HashSSZ() ([32]byte, error) {
 	return merkle_tree.MerkleRootFromLeaves(b.leaves[:])
 }
 
+// ownDirtyTrees copies the trees shared with copies of the state which Root would change, the changes are pending
+// since before the copy and the other states may be hashing the same tree.
+func (b *BeaconState) ownDirtyTrees() {
+	if b.sharedValidators && !b.validatorsTree.Clean(uint64(len(b.validators))) {
+		b.validatorsTree = b.validatorsTree.Copy()
+	}
+	if b.sharedBalances && !b.balancesTree.Clean(b.balances.Chunks()) {
+		b.balancesTree = b.balancesTree.Copy()
+	}
+	if !b.randaoMixesTree.Clean(randoMixesLength) {
+		b.randaoMixesTree = ownTree(b.randaoMixesTree, &b.sharedRandaoMixesTree)
+	}
+	if !b.blockRootsTree.Clean(blockRootsLength) {
+		b.blockRootsTree = ownTree(b.blockRootsTree, &b.sharedBlockRootsTree)
+	}
+}
+
 func (b *BeaconState) computeDirtyLeaves() error {
+	b.ownDirtyTrees()
 	// Update all dirty leafs
 	// ----
 
@@ -55,7 +78,9 @@ func (b *BeaconState) computeDirtyLeaves() error {
 
 	// Field(5): BlockRoots
 	if b.isLeafDirty(BlockRootsLeafIndex) {
-		blockRootsRoot, err := merkle_tree.ArraysRoot(preparateRootsForHashing(b.blockRoots[:]), state_encoding.BlockRootsLength)
+		blockRootsRoot, err := b.blockRootsTree.Root(blockRootsLength, func(i uint64) ([32]byte, error) {
+			return b.blockRoots[i], nil
+		})
 		if err != nil {
 			return err
 		}
@@ -105,25 +130,33 @@ func (b *BeaconState) computeDirtyLeaves() error {
 
 	// Field(11): Validators
 	if b.isLeafDirty(ValidatorsLeafIndex) {
-		vRoot, err := state_encoding.ValidatorsVectorRoot(b.validators)
+		vRoot, err := b.validatorsTree.Root(uint64(len(b.validators)), func(i uint64) ([32]byte, error) {
+			return b.validators[i].HashSSZ()
+		})
 		if err != nil {
 			return err
 		}
-		b.updateLeaf(ValidatorsLeafIndex, vRoot)
+		lengthRoot := merkle_tree.Uint64Root(uint64(len(b.validators)))
+		b.updateLeaf(ValidatorsLeafIndex, utils.Keccak256(vRoot[:], lengthRoot[:]))
 	}
 
 	// Field(12): Balances
 	if b.isLeafDirty(BalancesLeafIndex) {
-		balancesRoot, err := merkle_tree.Uint64ListRootWithLimit(b.balances, state_encoding.ValidatorLimitForBalancesChunks())
+		balancesRoot, err := b.balancesTree.Root(b.balances.Chunks(), func(i uint64) ([32]byte, error) {
+			return b.balances.Chunk(i), nil
+		})
 		if err != nil {
 			return err
 		}
-		b.updateLeaf(BalancesLeafIndex, balancesRoot)
+		lengthRoot := merkle_tree.Uint64Root(uint64(b.balances.Len()))
+		b.updateLeaf(BalancesLeafIndex, utils.Keccak256(balancesRoot[:], lengthRoot[:]))
 	}
 
 	// Field(13): RandaoMixes
 	if b.isLeafDirty(RandaoMixesLeafIndex) {
-		randaoRootsRoot, err := merkle_tree.ArraysRoot(preparateRootsForHashing(b.randaoMixes[:]), state_encoding.RandaoMixesLength)
+		randaoRootsRoot, err := b.randaoMixesTree.Root(randoMixesLength, func(i uint64) ([32]byte, error) {
+			return b.randaoMixes[i], nil
+		})
 		if err != nil {
 			return err
 		}
@@ -192,11 +225,16 @@ func (b *BeaconState) computeDirtyLeaves() error {
 
 	// Field(21): Inactivity Scores
 	if b.isLeafDirty(InactivityScoresLeafIndex) {
-		scoresRoot, err := merkle_tree.Uint64ListRootWithLimit(b.inactivityScores, state_encoding.ValidatorLimitForBalancesChunks())
+		chunks := make([][32]byte, b.inactivityScores.Chunks())
+		for i := range chunks {
+			chunks[i] = b.inactivityScores.Chunk(uint64(i))
+		}
+		scoresRoot, err := merkle_tree.MerkleizeVector(chunks, state_encoding.ValidatorLimitForBalancesChunks())
 		if err != nil {
 			return err
 		}
-		b.updateLeaf(InactivityScoresLeafIndex, scoresRoot)
+		lengthRoot := merkle_tree.Uint64Root(uint64(b.inactivityScores.Len()))
+		b.updateLeaf(InactivityScoresLeafIndex, utils.Keccak256(scoresRoot[:], lengthRoot[:]))
 	}
 
 	// Field(22): CurrentSyncCommitte
@@ -266,3 +304,107 @@ func (b *BeaconState) isLeafDirty(idx StateLeafIndex) bool {
 	touched, isInitialized := b.touchedLeaves[idx]
 	return !isInitialized || touched // change only if the leaf was touched or root is non-initialized.
 }
+
+// The state has 32 leaves: field i is at generalized index 32+i. A list field is the root of its data tree, the left
+// child, mixed in with its length, the right child. The data trees of the validators and of the balances hold
+// 2^validatorsTreeDepth validators and 2^balancesTreeDepth chunks of 4 balances.
+const (
+	stateTreeDepth      = 5
+	validatorsTreeDepth = 40
+	balancesTreeDepth   = 38
+)
+
+// ValidatorGeneralizedIndex returns the generalized index of the root of validator index in the state tree.
+func ValidatorGeneralizedIndex(index uint64) uint64 {
+	return (1<<stateTreeDepth+uint64(ValidatorsLeafIndex))<<(validatorsTreeDepth+1) | index
+}
+
+// BalanceGeneralizedIndex returns the generalized index of the chunk holding the balance of validator index in the
+// state tree.
+func BalanceGeneralizedIndex(index uint64) uint64 {
+	return (1<<stateTreeDepth+uint64(BalancesLeafIndex))<<(balancesTreeDepth+1) | index/4
+}
+
+// MultiProof returns the nodes at the given generalized indices of the state tree together with their multiproof
+// against the state root. The nodes are the field roots, the nodes above them, and the nodes of the validators and
+// balances lists down to the validator roots and the balance chunks.
+func (b *BeaconState) MultiProof(indices []uint64) (values, proof [][32]byte, err error) {
+	if err := b.computeDirtyLeaves(); err != nil {
+		return nil, nil, err
+	}
+	nodes, err := merkle_tree.MerkleTreeNodes(b.leaves[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return merkle_tree.MultiProofFromNodes(indices, func(index uint64) ([32]byte, error) {
+		if index == 0 {
+			return [32]byte{}, fmt.Errorf("generalized index 0 is not in the state tree")
+		}
+		if index < uint64(len(nodes)) {
+			return nodes[index], nil
+		}
+		// below the field roots, the first stateTreeDepth levels under the root select the field
+		below := bits.Len64(index) - 1 - stateTreeDepth
+		field := StateLeafIndex(index>>below - 1<<stateTreeDepth)
+		var (
+			tree   *merkle_tree.Cache
+			length uint64
+		)
+		switch field {
+		case ValidatorsLeafIndex:
+			tree, length = b.validatorsTree, uint64(len(b.validators))
+		case BalancesLeafIndex:
+			tree, length = b.balancesTree, uint64(b.balances.Len())
+		default:
+			return [32]byte{}, fmt.Errorf("generalized index %d is below the root of field %d, which can't be proven", index, field)
+		}
+		// the index relative to the field root, whose left child is the data root and right child the length
+		relative := index&(1<<below-1) | 1<<below
+		if relative == 3 {
+			return merkle_tree.Uint64Root(length), nil
+		}
+		if relative>>(below-1) != 2 {
+			return [32]byte{}, fmt.Errorf("generalized index %d is below the length of field %d", index, field)
+		}
+		// drop the step to the data root
+		return tree.Node(relative&(1<<(below-1)-1) | 1<<(below-1))
+	})
+}
+
+// LightClientBootstrap returns the light client bootstrap for the latest block header of the state, together with the
+// root of that block. The state must be the post-state of the block and at least Altair; Capella headers would also
+// need the execution branch of the block body, so they are not supported.
+func (b *BeaconState) LightClientBootstrap() (blockRoot libcommon.Hash, bootstrap *cltypes.LightClientBootstrap, err error) {
+	if b.version < clparams.AltairVersion || b.version >= clparams.CapellaVersion {
+		return libcommon.Hash{}, nil, fmt.Errorf("light client bootstrap is not supported for state version %d", b.version)
+	}
+	stateRoot, err := b.HashSSZ()
+	if err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	header := *b.latestBlockHeader
+	if header.Root == (libcommon.Hash{}) {
+		header.Root = stateRoot
+	}
+	if header.Root != stateRoot {
+		return libcommon.Hash{}, nil, fmt.Errorf("state at slot %d is not the post-state of its latest block", b.slot)
+	}
+	if blockRoot, err = header.HashSSZ(); err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	// state has 32 leaves: field i is at generalized index 32+i
+	_, proof, err := b.MultiProof([]uint64{32 + uint64(CurrentSyncCommitteeLeafIndex)})
+	if err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	branch := make([]libcommon.Hash, len(proof))
+	for i := range proof {
+		branch[i] = proof[i]
+	}
+	bootstrap = (&cltypes.LightClientBootstrap{
+		Header:                     (&cltypes.LightClientHeader{HeaderEth2: &header}).WithVersion(b.version),
+		CurrentSyncCommittee:       b.currentSyncCommittee,
+		CurrentSyncCommitteeBranch: branch,
+	}).WithVersion(b.version)
+	return blockRoot, bootstrap, nil
+}
@@ -3,6 +3,13 @@ package state_test
 import (
 	"testing"
 
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 )
 
@@ -24,3 +31,94 @@ func BenchmarkStateRootCached(b *testing.B) {
 		base.HashSSZ()
 	}
 }
+
+func TestStateMultiProof(t *testing.T) {
+	base := state.GetEmptyBeaconState()
+	base.SetSlot(42)
+	root, err := base.HashSSZ()
+	require.NoError(t, err)
+
+	// state has 32 leaves: field i is at generalized index 32+i
+	indices := []uint64{32 + uint64(state.SlotLeafIndex), 32 + uint64(state.ValidatorsLeafIndex), 32 + uint64(state.BalancesLeafIndex)}
+	values, proof, err := base.MultiProof(indices)
+	require.NoError(t, err)
+	require.Equal(t, [32]byte(merkle_tree.Uint64Root(42)), values[0])
+	require.Len(t, proof, len(merkle_tree.MultiProofHelperIndices(indices)))
+
+	computed, err := merkle_tree.MultiProofRoot(values, proof, indices)
+	require.NoError(t, err)
+	require.Equal(t, root, computed)
+
+	values[0] = utils.Keccak256([]byte("forged"))
+	computed, err = merkle_tree.MultiProofRoot(values, proof, indices)
+	require.NoError(t, err)
+	require.NotEqual(t, root, computed)
+
+	_, _, err = base.MultiProof([]uint64{64})
+	require.Error(t, err)
+}
+
+func TestStateMultiProofBelowFields(t *testing.T) {
+	base := state.GetEmptyBeaconState()
+	for i := 0; i < 5; i++ {
+		base.AddValidator(&cltypes.Validator{EffectiveBalance: uint64(i) * 1e9})
+		base.AddBalance(uint64(i) * 1e9)
+	}
+	root, err := base.HashSSZ()
+	require.NoError(t, err)
+	validatorRoot, err := base.Validators()[3].HashSSZ()
+	require.NoError(t, err)
+
+	// validator 3, the chunk of balances 4 to 7, and the length of the validators
+	indices := []uint64{state.ValidatorGeneralizedIndex(3), state.BalanceGeneralizedIndex(4), (32+uint64(state.ValidatorsLeafIndex))*2 + 1}
+	values, proof, err := base.MultiProof(indices)
+	require.NoError(t, err)
+	require.Equal(t, validatorRoot, values[0])
+	require.Equal(t, [32]byte(merkle_tree.Uint64Root(4e9)), values[1])
+	require.Equal(t, [32]byte(merkle_tree.Uint64Root(5)), values[2])
+
+	computed, err := merkle_tree.MultiProofRoot(values, proof, indices)
+	require.NoError(t, err)
+	require.Equal(t, root, computed)
+
+	// the padding of the lists is proven too
+	indices = []uint64{state.ValidatorGeneralizedIndex(1 << 39)}
+	values, proof, err = base.MultiProof(indices)
+	require.NoError(t, err)
+	require.Equal(t, [32]byte{}, values[0])
+	computed, err = merkle_tree.MultiProofRoot(values, proof, indices)
+	require.NoError(t, err)
+	require.Equal(t, root, computed)
+
+	_, _, err = base.MultiProof([]uint64{(32 + uint64(state.SlotLeafIndex)) * 2})
+	require.Error(t, err)
+	_, _, err = base.MultiProof([]uint64{((32+uint64(state.ValidatorsLeafIndex))*2 + 1) * 2})
+	require.Error(t, err)
+}
+
+func TestLightClientBootstrap(t *testing.T) {
+	base := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	base.SetSlot(42)
+	base.SetLatestBlockHeader(&cltypes.BeaconBlockHeader{Slot: 42, ProposerIndex: 7})
+	stateRoot, err := base.HashSSZ()
+	require.NoError(t, err)
+
+	blockRoot, bootstrap, err := base.LightClientBootstrap()
+	require.NoError(t, err)
+	headerRoot, err := bootstrap.Header.HeaderEth2.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, [32]byte(blockRoot), headerRoot)
+	require.Equal(t, [32]byte(bootstrap.Header.HeaderEth2.Root), stateRoot)
+
+	committeeRoot, err := bootstrap.CurrentSyncCommittee.HashSSZ()
+	require.NoError(t, err)
+	require.True(t, utils.IsValidMerkleBranch(committeeRoot, bootstrap.CurrentSyncCommitteeBranch, 5, uint64(state.CurrentSyncCommitteeLeafIndex), stateRoot))
+
+	// the state is not the post-state of its latest block anymore
+	base.SetLatestBlockHeader(&cltypes.BeaconBlockHeader{Slot: 41, Root: libcommon.HexToHash("0x01")})
+	_, _, err = base.LightClientBootstrap()
+	require.Error(t, err)
+
+	_, _, err = state.GetEmptyBeaconStateWithVersion(clparams.Phase0Version).LightClientBootstrap()
+	require.Error(t, err)
+}
@@ -4,6 +4,8 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state/state_encoding"
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
@@ -44,6 +46,8 @@ func (b *BeaconState) SetHistoricalRoots(historicalRoots []libcommon.Hash) {
 func (b *BeaconState) SetBlockRootAt(index int, root libcommon.Hash) {
 	b.touchedLeaves[BlockRootsLeafIndex] = true
 	b.blockRoots[index] = root
+	b.blockRootsTree = ownTree(b.blockRootsTree, &b.sharedBlockRootsTree)
+	b.blockRootsTree.Invalidate(uint64(index))
 }
 
 func (b *BeaconState) SetStateRootAt(index int, root libcommon.Hash) {
@@ -51,13 +55,24 @@ func (b *BeaconState) SetStateRootAt(index int, root libcommon.Hash) {
 	b.stateRoots[index] = root
 }
 
+func (b *BeaconState) AddHistoricalRoot(root libcommon.Hash) {
+	b.touchedLeaves[HistoricalRootsLeafIndex] = true
+	b.historicalRoots = append(b.historicalRoots, root)
+}
+
 func (b *BeaconState) SetHistoricalRootAt(index int, root [32]byte) {
 	b.touchedLeaves[HistoricalRootsLeafIndex] = true
 	b.historicalRoots[index] = root
 }
 
+// SetValidatorAt replaces the validator at index. Validators are shared with copies of the state (see Copy), so
+// they are never modified in place.
 func (b *BeaconState) SetValidatorAt(index int, validator *cltypes.Validator) {
+	b.touchedLeaves[ValidatorsLeafIndex] = true
+	b.ownValidators()
 	b.validators[index] = validator
+	b.validatorsTree.Invalidate(uint64(index))
+	b.publicKeyIndex.add(validator.PublicKey, uint64(index))
 }
 
 func (b *BeaconState) SetEth1Data(eth1Data *cltypes.Eth1Data) {
@@ -84,33 +99,44 @@ func (b *BeaconState) SetEth1DepositIndex(eth1DepositIndex uint64) {
 func (b *BeaconState) SetValidators(validators []*cltypes.Validator) {
 	b.touchedLeaves[ValidatorsLeafIndex] = true
 	b.validators = validators
+	b.sharedValidators = false
 	b.initBeaconState()
 }
 
 func (b *BeaconState) AddValidator(validator *cltypes.Validator) {
 	b.touchedLeaves[ValidatorsLeafIndex] = true
+	b.ownValidators()
 	b.validators = append(b.validators, validator)
-	b.publicKeyIndicies[validator.PublicKey] = uint64(len(b.validators)) - 1
+	b.publicKeyIndex.add(validator.PublicKey, uint64(len(b.validators))-1)
 }
 
 func (b *BeaconState) SetBalances(balances []uint64) {
 	b.touchedLeaves[BalancesLeafIndex] = true
-	b.balances = balances
+	b.balances = packUint64s(balances)
+	b.sharedBalances = false
+	b.balancesTree = merkle_tree.NewCache(state_encoding.ValidatorLimitForBalancesChunks())
 }
 
 func (b *BeaconState) AddBalance(balance uint64) {
 	b.touchedLeaves[BalancesLeafIndex] = true
-	b.balances = append(b.balances, balance)
+	b.ownBalances()
+	b.balances = b.balances.Append(balance)
+	// the last chunk may be partially filled
+	b.balancesTree.Invalidate(b.balances.Chunks() - 1)
 }
 
 func (b *BeaconState) SetValidatorBalance(index int, balance uint64) {
 	b.touchedLeaves[BalancesLeafIndex] = true
-	b.balances[index] = balance
+	b.ownBalances()
+	b.balances.Set(index, balance)
+	b.balancesTree.Invalidate(uint64(index) / 4)
 }
 
 func (b *BeaconState) SetRandaoMixAt(index int, mix libcommon.Hash) {
 	b.touchedLeaves[RandaoMixesLeafIndex] = true
 	b.randaoMixes[index] = mix
+	b.randaoMixesTree = ownTree(b.randaoMixesTree, &b.sharedRandaoMixesTree)
+	b.randaoMixesTree.Invalidate(uint64(index))
 }
 
 func (b *BeaconState) SetSlashingSegmentAt(index int, segment uint64) {
@@ -128,6 +154,16 @@ func (b *BeaconState) SetCurrentEpochParticipation(currentEpochParticipation []c
 	b.currentEpochParticipation = currentEpochParticipation
 }
 
+func (b *BeaconState) SetPreviousEpochParticipationFlagsAt(index int, flags cltypes.ParticipationFlags) {
+	b.touchedLeaves[PreviousEpochParticipationLeafIndex] = true
+	b.previousEpochParticipation[index] = flags
+}
+
+func (b *BeaconState) SetCurrentEpochParticipationFlagsAt(index int, flags cltypes.ParticipationFlags) {
+	b.touchedLeaves[CurrentEpochParticipationLeafIndex] = true
+	b.currentEpochParticipation[index] = flags
+}
+
 func (b *BeaconState) SetJustificationBits(justificationBits cltypes.JustificationBits) {
 	b.touchedLeaves[JustificationBitsLeafIndex] = true
 	b.justificationBits = justificationBits
@@ -164,25 +200,41 @@ func (b *BeaconState) SetLatestExecutionPayloadHeader(header *types.Header) {
 }
 
 func (b *BeaconState) SetNextWithdrawalIndex(index uint64) {
+	b.touchedLeaves[NextWithdrawalIndexLeafIndex] = true
 	b.nextWithdrawalIndex = index
 }
 
 func (b *BeaconState) SetNextWithdrawalValidatorIndex(index uint64) {
+	b.touchedLeaves[NextWithdrawalValidatorIndexLeafIndex] = true
 	b.nextWithdrawalValidatorIndex = index
 }
 
 func (b *BeaconState) AddHistoricalSummary(summary *cltypes.HistoricalSummary) {
+	b.touchedLeaves[HistoricalSummariesLeafIndex] = true
 	b.historicalSummaries = append(b.historicalSummaries, summary)
 }
 
 func (b *BeaconState) AddInactivityScore(score uint64) {
-	b.inactivityScores = append(b.inactivityScores, score)
+	b.touchedLeaves[InactivityScoresLeafIndex] = true
+	b.inactivityScores = b.inactivityScores.Append(score)
+}
+
+func (b *BeaconState) SetInactivityScores(scores []uint64) {
+	b.touchedLeaves[InactivityScoresLeafIndex] = true
+	b.inactivityScores = packUint64s(scores)
+}
+
+func (b *BeaconState) SetInactivityScoreAt(index int, score uint64) {
+	b.touchedLeaves[InactivityScoresLeafIndex] = true
+	b.inactivityScores.Set(index, score)
 }
 
 func (b *BeaconState) AddCurrentEpochParticipationFlags(flags cltypes.ParticipationFlags) {
+	b.touchedLeaves[CurrentEpochParticipationLeafIndex] = true
 	b.currentEpochParticipation = append(b.currentEpochParticipation, flags)
 }
 
 func (b *BeaconState) AddPreviousEpochParticipationFlags(flags cltypes.ParticipationFlags) {
+	b.touchedLeaves[PreviousEpochParticipationLeafIndex] = true
 	b.previousEpochParticipation = append(b.previousEpochParticipation, flags)
 }
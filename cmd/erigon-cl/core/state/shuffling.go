@@ -0,0 +1,83 @@
+package state
+
+import (
+	"encoding/binary"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// shufflingCacheSize is the number of epochs whose shuffling is kept: the previous, current and next epochs of a few
+// forks.
+const shufflingCacheSize = 8
+
+// shufflingCache keeps the shuffled active validators of the epochs by attester seed. The seed of an epoch is fixed
+// before the epoch, as is its active set, so the states of a lineage share the shuffling of an epoch whatever their
+// slot: the cache is shared by the copies of a state.
+type shufflingCache struct {
+	shuffled *lru.Cache // [32]byte seed -> []uint64
+}
+
+func newShufflingCache() *shufflingCache {
+	shuffled, err := lru.New(shufflingCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return &shufflingCache{shuffled: shuffled}
+}
+
+// ShuffledActiveIndices returns the active validators of epoch in the order of the attester shuffling, the beacon
+// committees of the epoch are its consecutive slices. The result is shared, it must not be modified.
+func (b *BeaconState) ShuffledActiveIndices(epoch uint64) []uint64 {
+	var seed [32]byte
+	copy(seed[:], b.GetSeed(epoch, b.beaconConfig.DomainBeaconAttester))
+	if b.shuffling != nil {
+		if shuffled, ok := b.shuffling.shuffled.Get(seed); ok {
+			return shuffled.([]uint64)
+		}
+	}
+	shuffled := b.ComputeShuffledIndices(b.GetActiveValidatorsIndices(epoch), seed)
+	if b.shuffling != nil {
+		b.shuffling.shuffled.Add(seed, shuffled)
+	}
+	return shuffled
+}
+
+// ComputeShuffledIndices returns indices permuted like ComputeShuffledIndex permutes each of them, at the i-th
+// position the indices[ComputeShuffledIndex(i)]. The rounds of the swap-or-not shuffle swap pairs of positions, so
+// they are applied to the whole list at once, in the reverse order, with a hash per 256 positions instead of two
+// hashes per position.
+func (b *BeaconState) ComputeShuffledIndices(indices []uint64, seed [32]byte) []uint64 {
+	shuffled := append([]uint64{}, indices...)
+	count := uint64(len(shuffled))
+	if count <= 1 {
+		return shuffled
+	}
+	input := make([]byte, 32+1+4)
+	copy(input, seed[:])
+	for round := b.beaconConfig.ShuffleRoundCount; round > 0; round-- {
+		input[32] = byte(round - 1)
+		pivotHash := utils.Keccak256(input[:33])
+		pivot := binary.LittleEndian.Uint64(pivotHash[:8]) % count
+
+		// the pairs are {i, (pivot - i) mod count}, the swap is decided by the bit of the largest of the two
+		var source [32]byte
+		sourceChunk := uint64(1<<64 - 1)
+		for i := uint64(0); i < count; i++ {
+			flip := (pivot + count - i) % count
+			if flip <= i {
+				continue
+			}
+			if chunk := flip / 256; chunk != sourceChunk {
+				binary.LittleEndian.PutUint32(input[33:], uint32(chunk))
+				source = utils.Keccak256(input)
+				sourceChunk = chunk
+			}
+			if (source[(flip%256)/8]>>(flip%8))&1 == 1 {
+				shuffled[i], shuffled[flip] = shuffled[flip], shuffled[i]
+			}
+		}
+	}
+	return shuffled
+}
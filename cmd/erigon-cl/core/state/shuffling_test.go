@@ -0,0 +1,63 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+func TestComputeShuffledIndices(t *testing.T) {
+	b := state.GetEmptyBeaconState()
+	seed := [32]byte{1, 2, 3}
+	// sizes across the 256 positions of a hash
+	for _, count := range []int{0, 1, 2, 3, 255, 256, 257, 1000} {
+		indices := make([]uint64, count)
+		for i := range indices {
+			indices[i] = uint64(i * 3)
+		}
+		shuffled := b.ComputeShuffledIndices(indices, seed)
+		require.Len(t, shuffled, count)
+		for i := range shuffled {
+			position, err := b.ComputeShuffledIndex(uint64(i), uint64(count), seed)
+			require.NoError(t, err)
+			require.Equal(t, indices[position], shuffled[i], "count %d position %d", count, i)
+		}
+	}
+}
+
+func TestGetBeaconCommittee(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	validators := make([]*cltypes.Validator, 300)
+	for i := range validators {
+		validators[i] = &cltypes.Validator{ExitEpoch: cfg.FarFutureEpoch, EffectiveBalance: cfg.MaxEffectiveBalance}
+	}
+	// an inactive validator
+	validators[7].ActivationEpoch = cfg.FarFutureEpoch
+	b := state.GetEmptyBeaconState()
+	b.SetValidators(validators)
+	b.SetSlot(3 * cfg.SlotsPerEpoch)
+	b.SetRandaoMixAt(1, [32]byte{9})
+
+	epoch := b.Epoch()
+	var seed [32]byte
+	copy(seed[:], b.GetSeed(epoch, cfg.DomainBeaconAttester))
+	indices := b.GetActiveValidatorsIndices(epoch)
+	count := b.CommitteeCount(epoch) * cfg.SlotsPerEpoch
+	for slot := epoch * cfg.SlotsPerEpoch; slot < (epoch+1)*cfg.SlotsPerEpoch; slot++ {
+		committee, err := b.GetBeaconCommittee(slot, 0)
+		require.NoError(t, err)
+		expected, err := b.ComputeCommittee(indices, seed, slot%cfg.SlotsPerEpoch, count)
+		require.NoError(t, err)
+		require.Equal(t, expected, committee)
+	}
+	_, err := b.GetBeaconCommittee(epoch*cfg.SlotsPerEpoch, b.CommitteeCount(epoch))
+	require.Error(t, err)
+
+	// the shuffling is computed once for the state and its copies
+	shuffled := b.ShuffledActiveIndices(epoch)
+	require.Same(t, &shuffled[0], &b.Copy().ShuffledActiveIndices(epoch)[0])
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"sync"
+	"sync/atomic"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// snapshotGenerations is the number of epochs Snapshots keeps a state of
+const snapshotGenerations = 2
+
+// Snapshot is the state as of the end of a slot, stamped with its epoch. Its state is only handed out read-only: it
+// is shared by all the readers, which need neither locks nor copies to use it.
+type Snapshot struct {
+	epoch uint64
+	root  libcommon.Hash
+	state *BeaconState
+}
+
+func (s *Snapshot) Epoch() uint64              { return s.epoch }
+func (s *Snapshot) Slot() uint64               { return s.state.Slot() }
+func (s *Snapshot) Root() libcommon.Hash       { return s.root }
+func (s *Snapshot) State() ReadOnlyBeaconState { return s.state }
+
+// Snapshots hands out read-only views of the head state to readers (fork choice, APIs, gossip validation, metrics)
+// while block processing keeps mutating it: the head is copied each time it is published and changed, the first
+// copy of each epoch is kept for the last snapshotGenerations epochs.
+type Snapshots struct {
+	mu          sync.Mutex   // serializes Publish
+	head        atomic.Value // *Snapshot
+	generations atomic.Value // []*Snapshot, newest last, replaced and never modified
+}
+
+func NewSnapshots() *Snapshots {
+	s := &Snapshots{}
+	s.generations.Store([]*Snapshot{})
+	return s
+}
+
+// Publish makes a snapshot of b the head unless b did not change since, and keeps it if it is the first of its
+// epoch. It returns the snapshot of the epoch of b.
+func (s *Snapshots) Publish(b *BeaconState) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Hashing cleans all the leaves before the copy, so that readers hashing the snapshot again only read the cache
+	root, err := b.HashSSZ()
+	if err != nil {
+		return nil, err
+	}
+	head := s.Head()
+	if head == nil || head.root != root {
+		head = &Snapshot{epoch: b.Epoch(), root: root, state: b.Copy()}
+		s.head.Store(head)
+	}
+	generations := s.generations.Load().([]*Snapshot)
+	if len(generations) > 0 && generations[len(generations)-1].epoch >= head.epoch {
+		return generations[len(generations)-1], nil
+	}
+	if len(generations) >= snapshotGenerations {
+		generations = generations[len(generations)-snapshotGenerations+1:]
+	}
+	s.generations.Store(append(append([]*Snapshot{}, generations...), head))
+	return head, nil
+}
+
+// Head returns the snapshot of the state last published, nil if none was published yet.
+func (s *Snapshots) Head() *Snapshot {
+	head, _ := s.head.Load().(*Snapshot)
+	return head
+}
+
+// Latest returns the snapshot of the newest epoch, nil if none was published yet.
+func (s *Snapshots) Latest() *Snapshot {
+	generations := s.generations.Load().([]*Snapshot)
+	if len(generations) == 0 {
+		return nil
+	}
+	return generations[len(generations)-1]
+}
+
+// AtEpoch returns the snapshot of epoch, nil if it is not kept.
+func (s *Snapshots) AtEpoch(epoch uint64) *Snapshot {
+	for _, snapshot := range s.generations.Load().([]*Snapshot) {
+		if snapshot.epoch == epoch {
+			return snapshot
+		}
+	}
+	return nil
+}
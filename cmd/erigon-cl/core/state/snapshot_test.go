@@ -0,0 +1,169 @@
+package state_test
+
+import (
+	"sync"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+func TestCopy(t *testing.T) {
+	b := getTestStateValidators(t, 8)
+	b.SetBalances([]uint64{1, 2, 3, 4, 5, 6, 7, 8})
+	root, err := b.HashSSZ()
+	require.NoError(t, err)
+
+	cpy := b.Copy()
+	b.SetSlot(b.Slot() + 1)
+	b.SetValidatorBalance(0, 100)
+	b.InitiateValidatorExit(1)
+	header := b.LatestBlockHeader()
+	header.Slot = 1000
+
+	require.Equal(t, uint64(1), cpy.ValidatorBalance(0))
+	require.Equal(t, uint64(testExitEpoch), cpy.ValidatorAt(1).ExitEpoch)
+	require.Zero(t, cpy.LatestBlockHeader().Slot)
+	cpyRoot, err := cpy.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, root, cpyRoot)
+}
+
+func TestSnapshots(t *testing.T) {
+	slotsPerEpoch := clparams.MainnetBeaconConfig.SlotsPerEpoch
+	b := state.GetEmptyBeaconState()
+	b.SetValidators([]*cltypes.Validator{{}})
+	snapshots := state.NewSnapshots()
+	require.Nil(t, snapshots.Latest())
+
+	first, err := snapshots.Publish(b)
+	require.NoError(t, err)
+	root, err := b.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, libcommon.Hash(root), first.Root())
+
+	// once per epoch
+	b.SetSlot(slotsPerEpoch - 1)
+	again, err := snapshots.Publish(b)
+	require.NoError(t, err)
+	require.Same(t, first, again)
+	require.Zero(t, again.Slot())
+	// the head follows the state
+	head := snapshots.Head()
+	require.Equal(t, slotsPerEpoch-1, head.Slot())
+	_, err = snapshots.Publish(b)
+	require.NoError(t, err)
+	require.Same(t, head, snapshots.Head())
+
+	for epoch := uint64(1); epoch <= 3; epoch++ {
+		b.SetSlot(epoch * slotsPerEpoch)
+		snapshot, err := snapshots.Publish(b)
+		require.NoError(t, err)
+		require.Equal(t, epoch, snapshot.Epoch())
+		require.Same(t, snapshot, snapshots.Latest())
+	}
+	require.Nil(t, snapshots.AtEpoch(1))
+	require.Equal(t, 2*slotsPerEpoch, snapshots.AtEpoch(2).Slot())
+	require.Equal(t, 3*slotsPerEpoch, snapshots.Latest().State().Slot())
+	require.Same(t, snapshots.Latest(), snapshots.Head())
+}
+
+func TestSnapshotsConcurrentReads(t *testing.T) {
+	b := getTestStateValidators(t, 64)
+	b.SetBalances(make([]uint64, 64))
+	snapshots := state.NewSnapshots()
+	_, err := snapshots.Publish(b)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				head := snapshots.Head().State()
+				// the balances of a snapshot are all the same, whatever the writer does meanwhile
+				balance := head.ValidatorBalance(0)
+				for index := range head.Balances() {
+					if head.ValidatorBalance(index) != balance {
+						t.Errorf("balance %d of a snapshot changed", index)
+						return
+					}
+				}
+				head.ShuffledActiveIndices(head.Epoch())
+			}
+		}()
+	}
+	for round := 0; round < 100; round++ {
+		for index := 0; index < 64; index++ {
+			b.IncreaseBalance(index, 1)
+		}
+		_, err := snapshots.Publish(b)
+		require.NoError(t, err)
+	}
+	close(stop)
+	wg.Wait()
+	require.Equal(t, uint64(100), snapshots.Head().State().ValidatorBalance(63))
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	b := getTestStateValidators(t, 4)
+	b.SetBalances([]uint64{1, 2, 3, 4})
+	cpy := b.Copy()
+	require.Same(t, &b.Validators()[0], &cpy.Validators()[0])
+
+	// the writer gets its own slices, the other state keeps the shared ones
+	cpy.IncreaseBalance(0, 10)
+	slashed := *cpy.ValidatorAt(1)
+	slashed.Slashed = true
+	cpy.SetValidatorAt(1, &slashed)
+	require.Equal(t, uint64(1), b.ValidatorBalance(0))
+	require.Equal(t, uint64(11), cpy.ValidatorBalance(0))
+	require.False(t, b.ValidatorAt(1).Slashed)
+	require.True(t, cpy.ValidatorAt(1).Slashed)
+
+	// appending to one doesn't write to the other either
+	b.AddValidator(&cltypes.Validator{PublicKey: [48]byte{1}})
+	b.AddBalance(5)
+	require.Len(t, b.Validators(), 5)
+	require.Len(t, cpy.Validators(), 4)
+	require.Len(t, cpy.Balances(), 4)
+	_, ok := cpy.ValidatorIndexByPubkey([48]byte{1})
+	require.False(t, ok)
+
+	// CopyInto reuses the destination
+	b.CopyInto(cpy)
+	require.Equal(t, b.Balances(), cpy.Balances())
+	cpy.SetValidatorBalance(4, 50)
+	require.Equal(t, uint64(5), b.ValidatorBalance(4))
+}
+
+func TestConcurrentCopy(t *testing.T) {
+	b := state.GetEmptyBeaconState()
+	// never shared before: the copies mark it as shared concurrently
+	var wg sync.WaitGroup
+	copies := make([]*state.BeaconState, 4)
+	for i := range copies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			copies[i] = b.Copy()
+		}(i)
+	}
+	wg.Wait()
+	for i, cpy := range copies {
+		cpy.AddBalance(uint64(i))
+		require.Equal(t, uint64(i), cpy.ValidatorBalance(0))
+	}
+	require.Empty(t, b.Balances())
+}
@@ -12,20 +12,44 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
+// BeaconStateContainer is the layout of the SSZ encoding of BeaconState
+var BeaconStateContainer = cltypes.VersionedContainer{
+	{Name: "GenesisTime", Since: clparams.Phase0Version, Size: 8},
+	{Name: "GenesisValidatorsRoot", Since: clparams.Phase0Version, Size: 32},
+	{Name: "Slot", Since: clparams.Phase0Version, Size: 8},
+	{Name: "Fork", Since: clparams.Phase0Version, Size: 16},
+	{Name: "LatestBlockHeader", Since: clparams.Phase0Version, Size: 112},
+	{Name: "BlockRoots", Since: clparams.Phase0Version, Size: blockRootsLength * 32},
+	{Name: "StateRoots", Since: clparams.Phase0Version, Size: stateRootsLength * 32},
+	{Name: "HistoricalRoots", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "Eth1Data", Since: clparams.Phase0Version, Size: 72},
+	{Name: "Eth1DataVotes", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "Eth1DepositIndex", Since: clparams.Phase0Version, Size: 8},
+	{Name: "Validators", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "Balances", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "RandaoMixes", Since: clparams.Phase0Version, Size: randoMixesLength * 32},
+	{Name: "Slashings", Since: clparams.Phase0Version, Size: slashingsLength * 8},
+	// Epoch attestations in phase0
+	{Name: "PreviousEpochParticipation", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "CurrentEpochParticipation", Since: clparams.Phase0Version, Size: cltypes.OffsetSize},
+	{Name: "JustificationBits", Since: clparams.Phase0Version, Size: 1},
+	{Name: "PreviousJustifiedCheckpoint", Since: clparams.Phase0Version, Size: 40},
+	{Name: "CurrentJustifiedCheckpoint", Since: clparams.Phase0Version, Size: 40},
+	{Name: "FinalizedCheckpoint", Since: clparams.Phase0Version, Size: 40},
+	{Name: "InactivityScores", Since: clparams.AltairVersion, Size: cltypes.OffsetSize},
+	{Name: "CurrentSyncCommittee", Since: clparams.AltairVersion, Size: 24624},
+	{Name: "NextSyncCommittee", Since: clparams.AltairVersion, Size: 24624},
+	{Name: "LatestExecutionPayloadHeader", Since: clparams.BellatrixVersion, Size: cltypes.OffsetSize},
+	{Name: "NextWithdrawalIndex", Since: clparams.CapellaVersion, Size: 8},
+	{Name: "NextWithdrawalValidatorIndex", Since: clparams.CapellaVersion, Size: 8},
+	{Name: "HistoricalSummaries", Since: clparams.CapellaVersion, Size: cltypes.OffsetSize},
+}
+
 func (b *BeaconState) baseOffsetSSZ() uint32 {
-	switch b.version {
-	case clparams.Phase0Version:
+	if b.version == clparams.Phase0Version {
 		panic("not implemented")
-	case clparams.AltairVersion:
-		return 2736629
-	case clparams.BellatrixVersion:
-		return 2736633
-	case clparams.CapellaVersion:
-		return 2736653
-	default:
-		// ?????
-		panic("tf is that")
 	}
+	return uint32(BeaconStateContainer.FixedSize(b.version))
 }
 
 func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
@@ -50,7 +74,7 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 		return nil, fmt.Errorf("too many validators")
 	}
 
-	if len(b.balances) > state_encoding.ValidatorRegistryLimit {
+	if b.balances.Len() > state_encoding.ValidatorRegistryLimit {
 		return nil, fmt.Errorf("too many balances")
 	}
 
@@ -58,7 +82,7 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 		return nil, fmt.Errorf("too many participations")
 	}
 
-	if len(b.inactivityScores) > state_encoding.ValidatorRegistryLimit {
+	if b.inactivityScores.Len() > state_encoding.ValidatorRegistryLimit {
 		return nil, fmt.Errorf("too many inactivities scores")
 	}
 	// Start encoding
@@ -103,7 +127,7 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 
 	// balances offset
 	dst = append(dst, ssz_utils.OffsetSSZ(offset)...)
-	offset += uint32(len(b.balances)) * 8
+	offset += uint32(len(b.balances))
 
 	for _, mix := range &b.randaoMixes {
 		dst = append(dst, mix[:]...)
@@ -136,7 +160,7 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 	}
 	// Inactivity scores offset
 	dst = append(dst, ssz_utils.OffsetSSZ(offset)...)
-	offset += uint32(len(b.inactivityScores)) * 8
+	offset += uint32(len(b.inactivityScores))
 
 	// Sync commitees
 	if dst, err = b.currentSyncCommittee.EncodeSSZ(dst); err != nil {
@@ -147,12 +171,12 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 	}
 
 	// Offset (24) 'LatestExecutionPayloadHeader'
-	dst = append(dst, ssz_utils.OffsetSSZ(offset)...)
-	if b.version >= clparams.BellatrixVersion {
+	if BeaconStateContainer.Has("LatestExecutionPayloadHeader", b.version) {
+		dst = append(dst, ssz_utils.OffsetSSZ(offset)...)
 		offset += uint32(b.latestExecutionPayloadHeader.EncodingSizeSSZ(b.version))
 	}
 
-	if b.version >= clparams.CapellaVersion {
+	if BeaconStateContainer.Has("HistoricalSummaries", b.version) {
 		dst = append(dst, ssz_utils.Uint64SSZ(b.nextWithdrawalIndex)...)
 		dst = append(dst, ssz_utils.Uint64SSZ(b.nextWithdrawalValidatorIndex)...)
 		dst = append(dst, ssz_utils.OffsetSSZ(offset)...)
@@ -174,26 +198,22 @@ func (b *BeaconState) EncodeSSZ(buf []byte) ([]byte, error) {
 		}
 	}
 	// Write balances (offset 4)
-	for _, balance := range b.balances {
-		dst = append(dst, ssz_utils.Uint64SSZ(balance)...)
-	}
+	dst = append(dst, b.balances...)
 
 	// Write participations (offset 4 & 5)
 	dst = append(dst, b.previousEpochParticipation.Bytes()...)
 	dst = append(dst, b.currentEpochParticipation.Bytes()...)
 
 	// write inactivity scores (offset 6)
-	for _, score := range b.inactivityScores {
-		dst = append(dst, ssz_utils.Uint64SSZ(score)...)
-	}
+	dst = append(dst, b.inactivityScores...)
 	// write execution header (offset 7)
-	if b.version >= clparams.BellatrixVersion {
+	if BeaconStateContainer.Has("LatestExecutionPayloadHeader", b.version) {
 		if dst, err = b.latestExecutionPayloadHeader.EncodeSSZ(dst); err != nil {
 			return nil, err
 		}
 	}
 
-	if b.version >= clparams.CapellaVersion {
+	if BeaconStateContainer.Has("HistoricalSummaries", b.version) {
 		for _, summary := range b.historicalSummaries {
 			if dst, err = summary.EncodeSSZ(dst); err != nil {
 				return nil, err
@@ -311,12 +331,12 @@ func (b *BeaconState) DecodeSSZWithVersion(buf []byte, version int) error {
 	pos += b.nextSyncCommittee.EncodingSizeSSZ()
 	var executionPayloadOffset uint32
 	// Execution Payload header offset
-	if b.version >= clparams.BellatrixVersion {
+	if BeaconStateContainer.Has("LatestExecutionPayloadHeader", b.version) {
 		executionPayloadOffset = ssz_utils.DecodeOffset(buf[pos:])
 		pos += 4
 	}
 	var historicalSummariesOffset uint32
-	if b.version >= clparams.CapellaVersion {
+	if BeaconStateContainer.Has("HistoricalSummaries", b.version) {
 		b.nextWithdrawalIndex = ssz_utils.UnmarshalUint64SSZ(buf[pos:])
 		pos += 8
 		b.nextWithdrawalValidatorIndex = ssz_utils.UnmarshalUint64SSZ(buf[pos:])
@@ -335,9 +355,13 @@ func (b *BeaconState) DecodeSSZWithVersion(buf []byte, version int) error {
 	if b.validators, err = ssz_utils.DecodeStaticList[*cltypes.Validator](buf, validatorsOffset, balancesOffset, 121, state_encoding.ValidatorRegistryLimit); err != nil {
 		return err
 	}
-	if b.balances, err = ssz_utils.DecodeNumbersList(buf, balancesOffset, previousEpochParticipationOffset, state_encoding.ValidatorRegistryLimit); err != nil {
+	if balancesOffset > previousEpochParticipationOffset || uint32(len(buf)) < previousEpochParticipationOffset {
+		return ssz_utils.ErrBadOffset
+	}
+	if b.balances, err = decodePackedUint64s(buf[balancesOffset:previousEpochParticipationOffset], state_encoding.ValidatorRegistryLimit); err != nil {
 		return err
 	}
+	b.sharedValidators, b.sharedBalances = false, false
 	var previousEpochParticipation, currentEpochParticipation []byte
 	if previousEpochParticipation, err = ssz_utils.DecodeString(buf, uint64(previousEpochParticipationOffset), uint64(currentEpochParticipationOffset), state_encoding.ValidatorRegistryLimit); err != nil {
 		return err
@@ -350,7 +374,10 @@ func (b *BeaconState) DecodeSSZWithVersion(buf []byte, version int) error {
 	if executionPayloadOffset != 0 {
 		endOffset = executionPayloadOffset
 	}
-	if b.inactivityScores, err = ssz_utils.DecodeNumbersList(buf, inactivityScoresOffset, endOffset, state_encoding.ValidatorRegistryLimit); err != nil {
+	if inactivityScoresOffset > endOffset || uint32(len(buf)) < endOffset {
+		return ssz_utils.ErrBadOffset
+	}
+	if b.inactivityScores, err = decodePackedUint64s(buf[inactivityScoresOffset:endOffset], state_encoding.ValidatorRegistryLimit); err != nil {
 		return err
 	}
 	if b.version <= clparams.AltairVersion {
@@ -383,10 +410,10 @@ func (b *BeaconState) EncodingSizeSSZ() (size int) {
 	size = int(b.baseOffsetSSZ()) + (len(b.historicalRoots) * 32)
 	size += len(b.eth1DataVotes) * 72
 	size += len(b.validators) * 121
-	size += len(b.balances) * 8
+	size += len(b.balances)
 	size += len(b.previousEpochParticipation)
 	size += len(b.currentEpochParticipation)
-	size += len(b.inactivityScores) * 8
+	size += len(b.inactivityScores)
 	size += len(b.historicalSummaries) * 64
 	return
 }
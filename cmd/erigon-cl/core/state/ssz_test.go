@@ -0,0 +1,34 @@
+package state_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+func TestBeaconStateContainer(t *testing.T) {
+	for version, size := range map[clparams.StateVersion]int{
+		clparams.AltairVersion:    2736629,
+		clparams.BellatrixVersion: 2736633,
+		clparams.CapellaVersion:   2736653,
+	} {
+		require.Equal(t, size, state.BeaconStateContainer.FixedSize(version), version)
+	}
+}
+
+func TestEncodeDecodeAltair(t *testing.T) {
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	b.SetSlot(42)
+	b.SetBalances([]uint64{1, 2, 3})
+	encoded, err := b.EncodeSSZ(nil)
+	require.NoError(t, err)
+	require.Len(t, encoded, b.EncodingSizeSSZ())
+
+	decoded := state.New(&clparams.MainnetBeaconConfig)
+	require.NoError(t, decoded.DecodeSSZWithVersion(encoded, int(clparams.AltairVersion)))
+	require.Equal(t, uint64(42), decoded.Slot())
+	require.Equal(t, []uint64{1, 2, 3}, decoded.Balances())
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state/state_encoding"
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
@@ -31,7 +33,7 @@ type BeaconState struct {
 	eth1DataVotes              []*cltypes.Eth1Data
 	eth1DepositIndex           uint64
 	validators                 []*cltypes.Validator
-	balances                   []uint64
+	balances                   packedUint64s
 	randaoMixes                [randoMixesLength]libcommon.Hash
 	slashings                  [slashingsLength]uint64
 	previousEpochParticipation cltypes.ParticipationFlagsList
@@ -41,7 +43,7 @@ type BeaconState struct {
 	previousJustifiedCheckpoint *cltypes.Checkpoint
 	currentJustifiedCheckpoint  *cltypes.Checkpoint
 	finalizedCheckpoint         *cltypes.Checkpoint
-	inactivityScores            []uint64
+	inactivityScores            packedUint64s
 	currentSyncCommittee        *cltypes.SyncCommittee
 	nextSyncCommittee           *cltypes.SyncCommittee
 	// Bellatrix
@@ -51,10 +53,24 @@ type BeaconState struct {
 	nextWithdrawalValidatorIndex uint64
 	historicalSummaries          []*cltypes.HistoricalSummary
 	// Internals
-	version           clparams.StateVersion   // State version
-	leaves            [32][32]byte            // Pre-computed leaves.
-	touchedLeaves     map[StateLeafIndex]bool // Maps each leaf to whether they were touched or not.
-	publicKeyIndicies map[[48]byte]uint64
+	version        clparams.StateVersion   // State version
+	leaves         [32][32]byte            // Pre-computed leaves.
+	touchedLeaves  map[StateLeafIndex]bool // Maps each leaf to whether they were touched or not.
+	publicKeyIndex *publicKeyIndex         // Validator indices by public key, shared with the copies, survives re-initialization.
+	publicKeys     *publicKeyCache         // Deserialized public keys by validator index, survives re-initialization.
+	shuffling      *shufflingCache         // Shuffled active validators by epoch, shared with the copies.
+	proposers      *proposersCache         // Proposers of the slots by epoch, shared with the copies.
+	// Merkle trees of the largest fields, only the branches which changed are hashed again (see root.go).
+	validatorsTree  *merkle_tree.Cache
+	balancesTree    *merkle_tree.Cache
+	randaoMixesTree *merkle_tree.Cache
+	blockRootsTree  *merkle_tree.Cache
+	// Copy-on-write: shared with copies of the state until written to (see Copy). The trees of validators and
+	// balances follow their lists.
+	sharedValidators      bool
+	sharedBalances        bool
+	sharedRandaoMixesTree bool
+	sharedBlockRootsTree  bool
 	// Configs
 	beaconConfig *clparams.BeaconChainConfig
 }
@@ -97,8 +113,20 @@ func (b *BeaconState) BlockRoot() ([32]byte, error) {
 
 func (b *BeaconState) initBeaconState() {
 	b.touchedLeaves = make(map[StateLeafIndex]bool)
-	b.publicKeyIndicies = make(map[[48]byte]uint64)
+	b.validatorsTree = merkle_tree.NewCache(state_encoding.ValidatorRegistryLimit)
+	b.balancesTree = merkle_tree.NewCache(state_encoding.ValidatorLimitForBalancesChunks())
+	b.randaoMixesTree = merkle_tree.NewCache(randoMixesLength)
+	b.blockRootsTree = merkle_tree.NewCache(blockRootsLength)
+	b.sharedRandaoMixesTree, b.sharedBlockRootsTree = false, false
+	b.shuffling = newShufflingCache()
+	b.proposers = newProposersCache()
+	if b.publicKeys == nil {
+		b.publicKeys = &publicKeyCache{}
+	}
+	if b.publicKeyIndex == nil {
+		b.publicKeyIndex = newPublicKeyIndex()
+	}
 	for i, validator := range b.validators {
-		b.publicKeyIndicies[validator.PublicKey] = uint64(i)
+		b.publicKeyIndex.add(validator.PublicKey, uint64(i))
 	}
 }
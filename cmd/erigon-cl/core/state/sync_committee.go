@@ -0,0 +1,68 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+)
+
+// ComputeNextSyncCommitteeIndices samples the validators of the sync committee of the next period from the active
+// validators of the next epoch, with a probability proportional to their effective balance. A validator may be
+// sampled more than once.
+func (b *BeaconState) ComputeNextSyncCommitteeIndices() ([]uint64, error) {
+	const maxRandomByte = 1<<8 - 1
+	epoch := b.Epoch() + 1
+	activeValidatorIndices := b.GetActiveValidatorsIndices(epoch)
+	activeValidatorCount := uint64(len(activeValidatorIndices))
+	if activeValidatorCount == 0 {
+		return nil, fmt.Errorf("no active validators at epoch %d", epoch)
+	}
+	var seed [32]byte
+	copy(seed[:], b.GetSeed(epoch, b.beaconConfig.DomainSyncCommittee))
+
+	input := make([]byte, 32+8)
+	copy(input, seed[:])
+	var random [32]byte
+	indices := make([]uint64, 0, b.beaconConfig.SyncCommitteeSize)
+	for i := uint64(0); uint64(len(indices)) < b.beaconConfig.SyncCommitteeSize; i++ {
+		shuffledIndex, err := b.ComputeShuffledIndex(i%activeValidatorCount, activeValidatorCount, seed)
+		if err != nil {
+			return nil, err
+		}
+		candidateIndex := activeValidatorIndices[shuffledIndex]
+		if i%32 == 0 {
+			binary.LittleEndian.PutUint64(input[32:], i/32)
+			random = utils.Keccak256(input)
+		}
+		effectiveBalance := b.validators[candidateIndex].EffectiveBalance
+		if effectiveBalance*maxRandomByte >= b.beaconConfig.MaxEffectiveBalance*uint64(random[i%32]) {
+			indices = append(indices, candidateIndex)
+		}
+	}
+	return indices, nil
+}
+
+// ComputeNextSyncCommittee returns the sync committee of the next period, with the aggregate of its public keys.
+func (b *BeaconState) ComputeNextSyncCommittee() (*cltypes.SyncCommittee, error) {
+	indices, err := b.ComputeNextSyncCommitteeIndices()
+	if err != nil {
+		return nil, err
+	}
+	committee := &cltypes.SyncCommittee{PubKeys: make([][48]byte, len(indices))}
+	keys := make([][]byte, len(indices))
+	for i, index := range indices {
+		committee.PubKeys[i] = b.validators[index].PublicKey
+		keys[i] = committee.PubKeys[i][:]
+	}
+	// the keys of the validators were checked by their deposits
+	aggregate := new(blst.P1Aggregate)
+	if !aggregate.AggregateCompressed(keys, false) {
+		return nil, fmt.Errorf("unable to aggregate the public keys of the sync committee")
+	}
+	copy(committee.AggregatePublicKey[:], aggregate.ToAffine().Compress())
+	return committee, nil
+}
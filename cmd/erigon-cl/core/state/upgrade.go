@@ -0,0 +1,79 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// upgradeFork moves the fork of the state to version at the current epoch, the fields of the new fork are set by the
+// caller.
+func (b *BeaconState) upgradeFork(to clparams.StateVersion, forkVersion uint32) error {
+	if b.version+1 != to {
+		return fmt.Errorf("can't upgrade a state of version %d to version %d", b.version, to)
+	}
+	b.SetFork(&cltypes.Fork{
+		PreviousVersion: b.fork.CurrentVersion,
+		CurrentVersion:  utils.Uint32ToBytes4(forkVersion),
+		Epoch:           b.Epoch(),
+	})
+	b.version = to
+	return nil
+}
+
+// UpgradeToAltair upgrades a phase0 state to Altair in place, at the first slot of the Altair fork epoch. The epoch
+// participation and the inactivity scores start at zero: the state does not keep the pending attestations of phase0,
+// so there is no participation to translate. The current and the next sync committees are the same.
+func (b *BeaconState) UpgradeToAltair() error {
+	if err := b.upgradeFork(clparams.AltairVersion, b.beaconConfig.AltairForkVersion); err != nil {
+		return err
+	}
+	b.SetPreviousEpochParticipation(make(cltypes.ParticipationFlagsList, len(b.validators)))
+	b.SetCurrentEpochParticipation(make(cltypes.ParticipationFlagsList, len(b.validators)))
+	b.SetInactivityScores(make([]uint64, len(b.validators)))
+	committee, err := b.ComputeNextSyncCommittee()
+	if err != nil {
+		return fmt.Errorf("UpgradeToAltair: %v", err)
+	}
+	b.SetCurrentSyncCommittee(committee)
+	next := *committee
+	next.PubKeys = append([][48]byte{}, committee.PubKeys...)
+	b.SetNextSyncCommittee(&next)
+	return nil
+}
+
+// UpgradeToBellatrix upgrades an Altair state to Bellatrix in place, at the first slot of the Bellatrix fork epoch.
+// The latest execution payload header is the default one until the merge.
+func (b *BeaconState) UpgradeToBellatrix() error {
+	if err := b.upgradeFork(clparams.BellatrixVersion, b.beaconConfig.BellatrixForkVersion); err != nil {
+		return err
+	}
+	b.SetLatestExecutionPayloadHeader(&types.Header{
+		BaseFee: new(big.Int),
+		Number:  new(big.Int),
+	})
+	return nil
+}
+
+// UpgradeToCapella upgrades a Bellatrix state to Capella in place, at the first slot of the Capella fork epoch. The
+// latest execution payload header gets an empty withdrawals root, the withdrawal sweep starts from the first
+// validator and there are no historical summaries yet.
+func (b *BeaconState) UpgradeToCapella() error {
+	if err := b.upgradeFork(clparams.CapellaVersion, b.beaconConfig.CapellaForkVersion); err != nil {
+		return err
+	}
+	header := types.CopyHeader(b.latestExecutionPayloadHeader)
+	header.WithdrawalsHash = new(libcommon.Hash)
+	b.SetLatestExecutionPayloadHeader(header)
+	b.SetNextWithdrawalIndex(0)
+	b.SetNextWithdrawalValidatorIndex(0)
+	b.touchedLeaves[HistoricalSummariesLeafIndex] = true
+	b.historicalSummaries = nil
+	return nil
+}
@@ -0,0 +1,92 @@
+package state_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// getUpgradeState returns a state of version with 16 validators of valid public keys, at the first slot of epoch 10
+// and with the fork before version.
+func getUpgradeState(version clparams.StateVersion, previousForkVersion uint32) *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(version)
+	for i := 0; i < 16; i++ {
+		ikm := make([]byte, 32)
+		ikm[0] = byte(i + 1)
+		validator := &cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		}
+		copy(validator.PublicKey[:], new(blst.P1Affine).From(blst.KeyGen(ikm)).Compress())
+		b.AddValidator(validator)
+		b.AddBalance(cfg.MaxEffectiveBalance)
+	}
+	b.SetSlot(10 * cfg.SlotsPerEpoch)
+	b.SetFork(&cltypes.Fork{CurrentVersion: utils.Uint32ToBytes4(previousForkVersion)})
+	return b
+}
+
+func TestUpgradeToAltair(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getUpgradeState(clparams.Phase0Version, cfg.GenesisForkVersion)
+	require.NoError(t, b.UpgradeToAltair())
+	require.Equal(t, clparams.AltairVersion, b.Version())
+	require.Equal(t, &cltypes.Fork{
+		PreviousVersion: utils.Uint32ToBytes4(cfg.GenesisForkVersion),
+		CurrentVersion:  utils.Uint32ToBytes4(cfg.AltairForkVersion),
+		Epoch:           10,
+	}, b.Fork())
+	require.Len(t, b.PreviousEpochParticipation(), 16)
+	require.Len(t, b.CurrentEpochParticipation(), 16)
+	require.Equal(t, make([]uint64, 16), b.InactivityScores())
+	require.Len(t, b.CurrentSyncCommittee().PubKeys, int(cfg.SyncCommitteeSize))
+	require.NotEqual(t, [48]byte{}, b.CurrentSyncCommittee().AggregatePublicKey)
+	require.Equal(t, b.CurrentSyncCommittee(), b.NextSyncCommittee())
+	_, err := b.HashSSZ()
+	require.NoError(t, err)
+
+	// a state upgrades to the next fork only
+	require.Error(t, b.UpgradeToAltair())
+	require.Error(t, b.UpgradeToCapella())
+}
+
+func TestUpgradeToBellatrix(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getUpgradeState(clparams.AltairVersion, cfg.AltairForkVersion)
+	require.NoError(t, b.UpgradeToBellatrix())
+	require.Equal(t, clparams.BellatrixVersion, b.Version())
+	require.Equal(t, utils.Uint32ToBytes4(cfg.AltairForkVersion), b.Fork().PreviousVersion)
+	require.Equal(t, utils.Uint32ToBytes4(cfg.BellatrixForkVersion), b.Fork().CurrentVersion)
+	require.False(t, b.IsMergeTransitionComplete())
+	_, err := b.HashSSZ()
+	require.NoError(t, err)
+}
+
+func TestUpgradeToCapella(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getUpgradeState(clparams.BellatrixVersion, cfg.BellatrixForkVersion)
+	b.LatestExecutionPayloadHeader().BlockHashCL = libcommon.Hash{1}
+	root, err := b.HashSSZ()
+	require.NoError(t, err)
+
+	require.NoError(t, b.UpgradeToCapella())
+	require.Equal(t, clparams.CapellaVersion, b.Version())
+	require.Equal(t, utils.Uint32ToBytes4(cfg.CapellaForkVersion), b.Fork().CurrentVersion)
+	require.Equal(t, libcommon.Hash{1}, b.LatestExecutionPayloadHeader().BlockHashCL)
+	require.Equal(t, &libcommon.Hash{}, b.LatestExecutionPayloadHeader().WithdrawalsHash)
+	require.Zero(t, b.NextWithdrawalIndex())
+	require.Zero(t, b.NextWithdrawalValidatorIndex())
+	require.Empty(t, b.HistoricalSummaries())
+	upgraded, err := b.HashSSZ()
+	require.NoError(t, err)
+	require.NotEqual(t, root, upgraded)
+}
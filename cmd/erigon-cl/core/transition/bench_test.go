@@ -0,0 +1,54 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+// BenchmarkEpochTransition processes the end of an epoch of an Altair state of 65536 validators, all of which
+// attested in the previous epoch, and hashes the result.
+func BenchmarkEpochTransition(b *testing.B) {
+	const validators = 1 << 16
+	cfg := clparams.MainnetBeaconConfig
+	base := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	base.SetSlot(11*cfg.SlotsPerEpoch - 1)
+	all := cltypes.ParticipationFlags(0).Add(0).Add(1).Add(2)
+	for i := 0; i < validators; i++ {
+		var pubKey [48]byte
+		pubKey[0], pubKey[1], pubKey[2] = byte(i), byte(i>>8), byte(i>>16)
+		base.AddValidator(&cltypes.Validator{
+			PublicKey:         pubKey,
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+		base.AddBalance(cfg.MaxEffectiveBalance)
+		base.AddInactivityScore(0)
+		base.AddPreviousEpochParticipationFlags(all)
+		base.AddCurrentEpochParticipationFlags(all)
+	}
+	_, err := base.HashSSZ()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := base.Copy()
+		st := transition.New(s, &cfg, nil, true)
+		b.StartTimer()
+
+		if err := st.ProcessEpoch(); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := s.HashSSZ(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package transition
 
 import (
+	"fmt"
+
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 )
@@ -59,18 +61,20 @@ func (s *StateTransistor) weighJustificationAndFinalization(totalActiveBalance,
 	return nil
 }
 
-func (s *StateTransistor) ProcessJustificationBitsAndFinality() error {
+// ProcessJustificationAndFinalization justifies the previous and the current epochs which got the votes of two thirds of
+// the active balance as their target, and finalizes the checkpoints which these justifications confirm.
+func (s *StateTransistor) ProcessJustificationAndFinalization() error {
 	if s.state.Version() == clparams.Phase0Version {
-		return s.processJustificationBitsAndFinalityPreAltair()
+		return s.processJustificationAndFinalizationPhase0()
 	}
-	return s.processJustificationBitsAndFinalityAltair()
+	return s.processJustificationAndFinalizationAltair()
 }
 
-func (s *StateTransistor) processJustificationBitsAndFinalityPreAltair() error {
-	panic("NOT IMPLEMENTED. STOOOOOP")
+func (s *StateTransistor) processJustificationAndFinalizationPhase0() error {
+	return fmt.Errorf("process_justification_and_finalization: phase0 is not supported")
 }
 
-func (s *StateTransistor) processJustificationBitsAndFinalityAltair() error {
+func (s *StateTransistor) processJustificationAndFinalizationAltair() error {
 	currentEpoch := s.state.Epoch()
 	previousEpoch := s.state.PreviousEpoch()
 	// Skip for first 2 epochs
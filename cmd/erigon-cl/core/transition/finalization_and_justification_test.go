@@ -4,46 +4,178 @@ import (
 	"testing"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
-	"github.com/stretchr/testify/require"
 )
 
-func getJustificationAndFinalizationState() *state.BeaconState {
+const justificationTestValidators = 4
+
+// getJustificationAndFinalizationState returns a state at the first slot of epoch+1, whose first previousTargets and
+// currentTargets validators attested to the target of the previous and current epochs. The block root of the slot i
+// starts with byte i.
+func getJustificationAndFinalizationState(epoch uint64, previousTargets, currentTargets int, bits byte, previousJustified, currentJustified cltypes.Checkpoint) *state.BeaconState {
 	cfg := clparams.MainnetBeaconConfig
-	epoch := cfg.FarFutureEpoch
-	bal := cfg.MaxEffectiveBalance
-	state := state.GetEmptyBeaconState()
-	blockRoots := make([][32]byte, cfg.SlotsPerEpoch*2+1)
-	for i := 0; i < len(blockRoots); i++ {
-		blockRoots[i][0] = byte(i)
-		state.SetBlockRootAt(i, blockRoots[i])
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	slot := epoch*cfg.SlotsPerEpoch + 1
+	for i := uint64(0); i < slot; i++ {
+		b.SetBlockRootAt(int(i), [32]byte{byte(i)})
 	}
-	state.SetSlot(cfg.SlotsPerEpoch*2 + 1)
-	bits := cltypes.JustificationBits{}
-	bits.FromByte(0x3)
-	state.SetJustificationBits(bits)
-	state.SetValidators([]*cltypes.Validator{
-		{ExitEpoch: epoch}, {ExitEpoch: epoch}, {ExitEpoch: epoch}, {ExitEpoch: epoch},
-	})
-	state.SetBalances([]uint64{bal, bal, bal, bal})
-	state.SetCurrentEpochParticipation(cltypes.ParticipationFlagsList{0b01, 0b01, 0b01, 0b01})
-	state.SetPreviousEpochParticipation(cltypes.ParticipationFlagsList{0b01, 0b01, 0b01, 0b01})
-
-	return state
+	b.SetSlot(slot)
+	justificationBits := cltypes.JustificationBits{}
+	justificationBits.FromByte(bits)
+	b.SetJustificationBits(justificationBits)
+	b.SetPreviousJustifiedCheckpoint(&previousJustified)
+	b.SetCurrentJustifiedCheckpoint(&currentJustified)
+
+	target := cltypes.ParticipationFlags(0).Add(int(cfg.TimelyTargetFlagIndex))
+	previous := make(cltypes.ParticipationFlagsList, justificationTestValidators)
+	current := make(cltypes.ParticipationFlagsList, justificationTestValidators)
+	for i := 0; i < justificationTestValidators; i++ {
+		b.AddValidator(&cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance)
+		if i < previousTargets {
+			previous[i] = target
+		}
+		if i < currentTargets {
+			current[i] = target
+		}
+	}
+	b.SetPreviousEpochParticipation(previous)
+	b.SetCurrentEpochParticipation(current)
+	return b
 }
 
-func TestProcessJustificationAndFinalizationJustifyCurrentEpoch(t *testing.T) {
-	cfg := clparams.MainnetBeaconConfig
-	testState := getJustificationAndFinalizationState()
-	transitioner := transition.New(testState, &cfg, nil, false)
-	transitioner.ProcessJustificationBitsAndFinality()
-	rt := libcommon.Hash{byte(64)}
-	require.Equal(t, rt, testState.CurrentJustifiedCheckpoint().Root, "Unexpected current justified root")
-	require.Equal(t, uint64(2), testState.CurrentJustifiedCheckpoint().Epoch, "Unexpected justified epoch")
-	require.Equal(t, uint64(0), testState.PreviousJustifiedCheckpoint().Epoch, "Unexpected previous justified epoch")
-	require.Equal(t, libcommon.Hash{}, testState.FinalizedCheckpoint().Root)
-	require.Equal(t, uint64(0), testState.FinalizedCheckpoint().Epoch, "Unexpected finalized epoch")
+func TestProcessJustificationAndFinalization(t *testing.T) {
+	// the root of the first slot of epoch e, with 32 slots per epoch
+	epochRoot := func(e uint64) libcommon.Hash { return libcommon.Hash{byte(e * 32)} }
+	testCases := []struct {
+		description       string
+		epoch             uint64
+		previousTargets   int
+		currentTargets    int
+		bits              byte
+		previousJustified cltypes.Checkpoint
+		currentJustified  cltypes.Checkpoint
+
+		expectedBits              byte
+		expectedPreviousJustified cltypes.Checkpoint
+		expectedCurrentJustified  cltypes.Checkpoint
+		expectedFinalized         cltypes.Checkpoint
+	}{
+		{
+			description:       "first epochs are skipped",
+			epoch:             1,
+			previousTargets:   4,
+			currentTargets:    4,
+			bits:              0b0011,
+			expectedBits:      0b0011,
+			expectedFinalized: cltypes.Checkpoint{},
+		},
+		{
+			description:               "no supermajority",
+			epoch:                     4,
+			previousTargets:           2,
+			currentTargets:            2,
+			bits:                      0b0001,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0010,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+		},
+		{
+			description:               "justify previous epoch",
+			epoch:                     4,
+			previousTargets:           3,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0010,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 3, Root: epochRoot(3)},
+		},
+		{
+			description:               "justify current epoch",
+			epoch:                     4,
+			currentTargets:            3,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0001,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 4, Root: epochRoot(4)},
+		},
+		{
+			description:               "finalize 2nd epoch with 4th as source",
+			epoch:                     4,
+			previousTargets:           3,
+			bits:                      0b0110,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b1110,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 3, Root: epochRoot(3)},
+			expectedFinalized:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+		},
+		{
+			description:               "finalize 2nd epoch with 3rd as source",
+			epoch:                     4,
+			previousTargets:           3,
+			bits:                      0b0010,
+			previousJustified:         cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0110,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 3, Root: epochRoot(3)},
+			expectedFinalized:         cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xaa}},
+		},
+		{
+			description:               "finalize 1st epoch with 3rd as source",
+			epoch:                     4,
+			previousTargets:           3,
+			currentTargets:            4,
+			bits:                      0b0010,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0111,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 4, Root: epochRoot(4)},
+			expectedFinalized:         cltypes.Checkpoint{Epoch: 2, Root: libcommon.Hash{0xbb}},
+		},
+		{
+			description:               "finalize 1st epoch with 2nd as source",
+			epoch:                     4,
+			currentTargets:            3,
+			bits:                      0b0001,
+			previousJustified:         cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{0xaa}},
+			currentJustified:          cltypes.Checkpoint{Epoch: 3, Root: libcommon.Hash{0xbb}},
+			expectedBits:              0b0011,
+			expectedPreviousJustified: cltypes.Checkpoint{Epoch: 3, Root: libcommon.Hash{0xbb}},
+			expectedCurrentJustified:  cltypes.Checkpoint{Epoch: 4, Root: epochRoot(4)},
+			expectedFinalized:         cltypes.Checkpoint{Epoch: 3, Root: libcommon.Hash{0xbb}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg := clparams.MainnetBeaconConfig
+			testState := getJustificationAndFinalizationState(tc.epoch, tc.previousTargets, tc.currentTargets, tc.bits, tc.previousJustified, tc.currentJustified)
+			require.NoError(t, transition.New(testState, &cfg, nil, false).ProcessJustificationAndFinalization())
+			require.Equal(t, tc.expectedBits, testState.JustificationBits().Byte(), "Unexpected justification bits")
+			require.Equal(t, tc.expectedPreviousJustified, *testState.PreviousJustifiedCheckpoint(), "Unexpected previous justified checkpoint")
+			require.Equal(t, tc.expectedCurrentJustified, *testState.CurrentJustifiedCheckpoint(), "Unexpected current justified checkpoint")
+			require.Equal(t, tc.expectedFinalized, *testState.FinalizedCheckpoint(), "Unexpected finalized checkpoint")
+		})
+	}
+}
+
+func TestProcessJustificationAndFinalizationPhase0(t *testing.T) {
+	testState := state.GetEmptyBeaconStateWithVersion(clparams.Phase0Version)
+	require.Error(t, transition.New(testState, &clparams.MainnetBeaconConfig, nil, false).ProcessJustificationAndFinalization())
 }
@@ -0,0 +1,84 @@
+package transition
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// GenesisStateFromDeposits builds the genesis state of a chain from the deposits of the deposit contract up to
+// eth1Block, for devnets which don't start from a downloaded state. The state is at the fork of the genesis epoch,
+// the deposits carry their proof against the deposit root which includes them, like the deposits of blocks. The
+// validators of a full balance are active from genesis.
+func GenesisStateFromDeposits(cfg *clparams.BeaconChainConfig, deposits []*cltypes.Deposit, eth1Block *types.Header) (*state.BeaconState, error) {
+	version := cfg.GetCurrentStateVersion(cfg.GenesisEpoch)
+	if version == clparams.Phase0Version {
+		return nil, fmt.Errorf("GenesisStateFromDeposits: phase0 genesis is not supported, the Altair fork must be at genesis")
+	}
+	b, err := state.NewGenesis(cfg, version, eth1Block)
+	if err != nil {
+		return nil, err
+	}
+	s := New(b, cfg, nil, false)
+	var tree depositTree
+	for i, deposit := range deposits {
+		leaf, err := deposit.Data.HashSSZ()
+		if err != nil {
+			return nil, fmt.Errorf("GenesisStateFromDeposits: deposit %d: %v", i, err)
+		}
+		tree.push(leaf)
+		b.SetEth1Data(&cltypes.Eth1Data{
+			Root:         tree.root(),
+			BlockHash:    b.Eth1Data().BlockHash,
+			DepositCount: uint64(len(deposits)),
+		})
+		if err := s.ProcessDeposit(deposit); err != nil {
+			return nil, fmt.Errorf("GenesisStateFromDeposits: deposit %d: %v", i, err)
+		}
+	}
+	if err := b.ActivateGenesisValidators(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// depositTree is the incremental merkle tree of the deposit contract: the left branch of the next leaf is enough to
+// append leaves and compute the root, with the count mixed in like the list of the deposits.
+type depositTree struct {
+	branch [cltypes.DepositContractDepth]libcommon.Hash
+	count  uint64
+}
+
+func (t *depositTree) push(leaf libcommon.Hash) {
+	t.count++
+	node := leaf
+	for height, size := 0, t.count; height < cltypes.DepositContractDepth; height, size = height+1, size/2 {
+		if size&1 == 1 {
+			t.branch[height] = node
+			return
+		}
+		node = utils.Keccak256(t.branch[height][:], node[:])
+	}
+}
+
+func (t *depositTree) root() libcommon.Hash {
+	var node libcommon.Hash
+	for height, size := 0, t.count; height < cltypes.DepositContractDepth; height, size = height+1, size/2 {
+		if size&1 == 1 {
+			node = utils.Keccak256(t.branch[height][:], node[:])
+		} else {
+			node = utils.Keccak256(node[:], merkle_tree.ZeroHashes[height][:])
+		}
+	}
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:], t.count)
+	return utils.Keccak256(node[:], count[:])
+}
@@ -0,0 +1,130 @@
+package transition_test
+
+import (
+	"math/big"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/fork"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// depositProof returns the branch of the leaf index in the deposit tree of leaves, followed by the count of leaves.
+func depositProof(leaves [][32]byte, index int) []libcommon.Hash {
+	var proof []libcommon.Hash
+	layer := append([][32]byte{}, leaves...)
+	for height := 0; height < cltypes.DepositContractDepth; height++ {
+		if sibling := index ^ 1; sibling < len(layer) {
+			proof = append(proof, layer[sibling])
+		} else {
+			proof = append(proof, merkle_tree.ZeroHashes[height])
+		}
+		if len(layer)%2 == 1 {
+			layer = append(layer, merkle_tree.ZeroHashes[height])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = utils.Keccak256(layer[2*i][:], layer[2*i+1][:])
+		}
+		layer, index = next, index/2
+	}
+	return append(proof, merkle_tree.Uint64Root(uint64(len(leaves))))
+}
+
+// getGenesisDeposits returns signed deposits of amounts, each with its proof against the deposit root which includes
+// it.
+func getGenesisDeposits(t *testing.T, cfg *clparams.BeaconChainConfig, amounts []uint64) []*cltypes.Deposit {
+	domain, err := fork.ComputeDomain(cfg.DomainDeposit[:], utils.Uint32ToBytes4(cfg.GenesisForkVersion), [32]byte{})
+	require.NoError(t, err)
+	var leaves [][32]byte
+	var deposits []*cltypes.Deposit
+	for i, amount := range amounts {
+		ikm := make([]byte, 32)
+		ikm[0] = byte(i + 1)
+		secretKey := blst.KeyGen(ikm)
+		data := &cltypes.DepositData{Amount: amount, WithdrawalCredentials: libcommon.Hash{byte(i + 1)}}
+		copy(data.PubKey[:], new(blst.P1Affine).From(secretKey).Compress())
+		messageRoot, err := data.MessageHash()
+		require.NoError(t, err)
+		signingRoot := utils.Keccak256(messageRoot[:], domain)
+		copy(data.Signature[:], new(blst.P2Affine).Sign(secretKey, signingRoot[:], []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")).Compress())
+		leaf, err := data.HashSSZ()
+		require.NoError(t, err)
+		leaves = append(leaves, leaf)
+		deposits = append(deposits, &cltypes.Deposit{Data: data, Proof: depositProof(leaves, i)})
+	}
+	return deposits
+}
+
+func TestGenesisStateFromDeposits(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	cfg.AltairForkEpoch = 0
+	eth1Block := &types.Header{Number: big.NewInt(10), BaseFee: big.NewInt(7), Difficulty: big.NewInt(0), Time: 1000}
+	// a full deposit, a partial one and one over the maximum effective balance
+	deposits := getGenesisDeposits(t, &cfg, []uint64{cfg.MaxEffectiveBalance, cfg.EjectionBalance, cfg.MaxEffectiveBalance + 1e9})
+
+	b, err := transition.GenesisStateFromDeposits(&cfg, deposits, eth1Block)
+	require.NoError(t, err)
+	require.Equal(t, clparams.AltairVersion, b.Version())
+	require.Equal(t, eth1Block.Time+cfg.GenesisDelay, b.GenesisTime())
+	require.Equal(t, utils.Uint32ToBytes4(cfg.AltairForkVersion), b.Fork().CurrentVersion)
+	require.Equal(t, eth1Block.Hash(), b.Eth1Data().BlockHash)
+	require.Equal(t, uint64(3), b.Eth1Data().DepositCount)
+	require.Equal(t, uint64(3), b.Eth1DepositIndex())
+	require.Equal(t, [32]byte(eth1Block.Hash()), b.GetRandaoMixes(5))
+
+	require.Len(t, b.Validators(), 3)
+	require.True(t, b.ValidatorAt(0).Active(0))
+	require.False(t, b.ValidatorAt(1).Active(0))
+	require.Equal(t, cfg.EjectionBalance, b.ValidatorAt(1).EffectiveBalance)
+	require.True(t, b.ValidatorAt(2).Active(0))
+	require.Equal(t, cfg.MaxEffectiveBalance, b.ValidatorAt(2).EffectiveBalance)
+	require.Equal(t, cfg.MaxEffectiveBalance+1e9, b.ValidatorBalance(2))
+
+	require.NotEqual(t, libcommon.Hash{}, b.GenesisValidatorsRoot())
+	require.Equal(t, b.CurrentSyncCommittee().PubKeys, b.NextSyncCommittee().PubKeys)
+	require.Contains(t, b.CurrentSyncCommittee().PubKeys, b.ValidatorAt(0).PublicKey)
+	_, err = b.HashSSZ()
+	require.NoError(t, err)
+
+	// the same deposits give the same genesis
+	again, err := transition.GenesisStateFromDeposits(&cfg, getGenesisDeposits(t, &cfg, []uint64{cfg.MaxEffectiveBalance, cfg.EjectionBalance, cfg.MaxEffectiveBalance + 1e9}), eth1Block)
+	require.NoError(t, err)
+	root, err := b.HashSSZ()
+	require.NoError(t, err)
+	againRoot, err := again.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, root, againRoot)
+
+	// a deposit whose proof isn't against the root of the deposits up to it
+	deposits[1].Proof[0][0]++
+	_, err = transition.GenesisStateFromDeposits(&cfg, deposits, eth1Block)
+	require.Error(t, err)
+}
+
+func TestGenesisStateFromDepositsMerged(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	cfg.AltairForkEpoch, cfg.BellatrixForkEpoch, cfg.CapellaForkEpoch = 0, 0, 0
+	eth1Block := &types.Header{Number: big.NewInt(0), BaseFee: big.NewInt(7), Difficulty: big.NewInt(0), GasLimit: 30_000_000, Time: 1000}
+	b, err := transition.GenesisStateFromDeposits(&cfg, getGenesisDeposits(t, &cfg, []uint64{cfg.MaxEffectiveBalance}), eth1Block)
+	require.NoError(t, err)
+	require.Equal(t, clparams.CapellaVersion, b.Version())
+	require.True(t, b.IsMergeTransitionComplete())
+	require.Equal(t, eth1Block.Hash(), b.LatestExecutionPayloadHeader().BlockHashCL)
+	require.NotNil(t, b.LatestExecutionPayloadHeader().WithdrawalsHash)
+	_, err = b.HashSSZ()
+	require.NoError(t, err)
+
+	// phase0 genesis is not supported
+	cfg = clparams.MainnetBeaconConfig
+	_, err = transition.GenesisStateFromDeposits(&cfg, nil, eth1Block)
+	require.Error(t, err)
+}
@@ -1,9 +1,11 @@
 package transition
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/Giulio2002/bls"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/fork"
@@ -60,23 +62,12 @@ func IsValidIndexedAttestation(state *state.BeaconState, att *cltypes.IndexedAtt
 		return false, fmt.Errorf("invalid attesting indices")
 	}
 
-	pks := [][]byte{}
-	for _, v := range inds {
-		val := state.ValidatorAt(int(v))
-		pks = append(pks, val.PublicKey[:])
-	}
-
-	domain, err := state.GetDomain(clparams.MainnetBeaconConfig.DomainBeaconAttester, att.Data.Target.Epoch)
-	if err != nil {
-		return false, fmt.Errorf("unable to get the domain: %v", err)
-	}
-
-	signingRoot, err := fork.ComputeSigningRoot(att.Data, domain)
+	signingRoot, err := state.ComputeSigningRoot(att.Data, state.BeaconConfig().DomainBeaconAttester, att.Data.Target.Epoch)
 	if err != nil {
 		return false, fmt.Errorf("unable to get signing root: %v", err)
 	}
 
-	valid, err := bls.VerifyAggregate(att.Signature[:], signingRoot[:], pks)
+	valid, err := verifyAggregateSignature(state, att.Signature[:], signingRoot[:], inds)
 	if err != nil {
 		return false, fmt.Errorf("error while validating signature: %v", err)
 	}
@@ -110,21 +101,23 @@ func (s *StateTransistor) ProcessProposerSlashing(propSlashing *cltypes.Proposer
 		return fmt.Errorf("propose slashing headers are the same: %v == %v", h1Root, h2Root)
 	}
 
+	if h1.ProposerIndex >= uint64(len(s.state.Validators())) {
+		return fmt.Errorf("proposer index %d out of range", h1.ProposerIndex)
+	}
 	proposer := s.state.ValidatorAt(int(h1.ProposerIndex))
 	if !IsSlashableValidator(proposer, s.state.Epoch()) {
 		return fmt.Errorf("proposer is not slashable: %v", proposer)
 	}
 
 	for _, signedHeader := range []*cltypes.SignedBeaconBlockHeader{propSlashing.Header1, propSlashing.Header2} {
-		domain, err := s.state.GetDomain(s.beaconConfig.DomainBeaconProposer, s.state.GetEpochAtSlot(signedHeader.Header.Slot))
-		if err != nil {
-			return fmt.Errorf("unable to get domain: %v", err)
+		if s.noValidate {
+			break
 		}
-		signingRoot, err := fork.ComputeSigningRoot(signedHeader.Header, domain)
+		signingRoot, err := s.state.ComputeSigningRoot(signedHeader.Header, s.beaconConfig.DomainBeaconProposer, s.state.GetEpochAtSlot(signedHeader.Header.Slot))
 		if err != nil {
 			return fmt.Errorf("unable to compute signing root: %v", err)
 		}
-		valid, err := bls.Verify(signedHeader.Signature[:], signingRoot[:], proposer.PublicKey[:])
+		valid, err := verifyValidatorSignature(s.state, signedHeader.Signature[:], signingRoot[:], h1.ProposerIndex)
 		if err != nil {
 			return fmt.Errorf("unable to verify signature: %v", err)
 		}
@@ -134,7 +127,9 @@ func (s *StateTransistor) ProcessProposerSlashing(propSlashing *cltypes.Proposer
 	}
 
 	// Set whistleblower index to 0 so current proposer gets reward.
-	s.state.SlashValidator(h1.ProposerIndex, 0)
+	if err := s.state.SlashValidator(h1.ProposerIndex, 0); err != nil {
+		return fmt.Errorf("unable to slash proposer %d: %v", h1.ProposerIndex, err)
+	}
 	return nil
 }
 
@@ -150,25 +145,29 @@ func (s *StateTransistor) ProcessAttesterSlashing(attSlashing *cltypes.AttesterS
 		return fmt.Errorf("attestation data not slashable: %+v; %+v", att1.Data, att2.Data)
 	}
 
-	valid, err := IsValidIndexedAttestation(s.state, att1)
-	if err != nil {
-		return fmt.Errorf("error calculating indexed attestation 1 validity: %v", err)
-	}
-	if !valid {
-		return fmt.Errorf("invalid indexed attestation 1")
-	}
-
-	valid, err = IsValidIndexedAttestation(s.state, att2)
-	if err != nil {
-		return fmt.Errorf("error calculating indexed attestation 2 validity: %v", err)
-	}
-	if !valid {
-		return fmt.Errorf("invalid indexed attestation 2")
+	for i, att := range []*cltypes.IndexedAttestation{att1, att2} {
+		if s.noValidate {
+			// the signatures are not checked, the indices are
+			if len(att.AttestingIndices) == 0 || !IsSortedSet(att.AttestingIndices) {
+				return fmt.Errorf("invalid attesting indices of indexed attestation %d", i+1)
+			}
+			continue
+		}
+		valid, err := IsValidIndexedAttestation(s.state, att)
+		if err != nil {
+			return fmt.Errorf("error calculating indexed attestation %d validity: %v", i+1, err)
+		}
+		if !valid {
+			return fmt.Errorf("invalid indexed attestation %d", i+1)
+		}
 	}
 
 	slashedAny := false
 	indices := GetSetIntersection(att1.AttestingIndices, att2.AttestingIndices)
 	for _, ind := range indices {
+		if ind >= uint64(len(s.state.Validators())) {
+			return fmt.Errorf("attesting index %d out of range", ind)
+		}
 		if IsSlashableValidator(s.state.ValidatorAt(int(ind)), s.state.GetEpochAtSlot(s.state.Slot())) {
 			err := s.state.SlashValidator(ind, 0)
 			if err != nil {
@@ -184,6 +183,8 @@ func (s *StateTransistor) ProcessAttesterSlashing(attSlashing *cltypes.AttesterS
 	return nil
 }
 
+// ProcessDeposit applies the next deposit of the Eth1 deposit contract: it tops up the validator of the public key, or
+// adds a validator when its deposit is signed.
 func (s *StateTransistor) ProcessDeposit(deposit *cltypes.Deposit) error {
 	if deposit == nil {
 		return nil
@@ -195,7 +196,7 @@ func (s *StateTransistor) ProcessDeposit(deposit *cltypes.Deposit) error {
 	depositIndex := s.state.Eth1DepositIndex()
 	eth1Data := s.state.Eth1Data()
 	// Validate merkle proof for deposit leaf.
-	if !s.noValidate && utils.IsValidMerkleBranch(
+	if !s.noValidate && !utils.IsValidMerkleBranch(
 		depositLeaf,
 		deposit.Proof,
 		s.beaconConfig.DepositContractTreeDepth+1,
@@ -209,36 +210,115 @@ func (s *StateTransistor) ProcessDeposit(deposit *cltypes.Deposit) error {
 	s.state.SetEth1DepositIndex(depositIndex + 1)
 	publicKey := deposit.Data.PubKey
 	amount := deposit.Data.Amount
-	// Check if pub key is in validator set
+	// Check if pub key is in validator set, the index also holds the validators added by the previous deposits.
 	validatorIndex, has := s.state.ValidatorIndexByPubkey(publicKey)
-	if !has {
-		// Agnostic domain.
-		domain, err := fork.ComputeDomain(s.beaconConfig.DomainDeposit[:], utils.Uint32ToBytes4(s.beaconConfig.GenesisForkVersion), [32]byte{})
+	if has {
+		// Increase the balance if exists already, a top-up is not signed.
+		s.state.IncreaseBalance(int(validatorIndex), amount)
+		return nil
+	}
+	// Agnostic domain.
+	domain, err := fork.ComputeDomain(s.beaconConfig.DomainDeposit[:], utils.Uint32ToBytes4(s.beaconConfig.GenesisForkVersion), [32]byte{})
+	if err != nil {
+		return err
+	}
+	depositMessageRoot, err := deposit.Data.MessageHash()
+	if err != nil {
+		return err
+	}
+	signedRoot := utils.Keccak256(depositMessageRoot[:], domain)
+	// Perform BLS verification, a deposit with an invalid signature (or public key) is skipped, the block stays valid.
+	valid, err := bls.Verify(deposit.Data.Signature[:], signedRoot[:], publicKey[:])
+	if err != nil || !valid {
+		return nil
+	}
+	// Append validator
+	s.state.AddValidator(s.state.ValidatorFromDeposit(deposit))
+	s.state.AddBalance(amount)
+	if s.state.Version() >= clparams.AltairVersion {
+		s.state.AddCurrentEpochParticipationFlags(cltypes.ParticipationFlags(0))
+		s.state.AddPreviousEpochParticipationFlags(cltypes.ParticipationFlags(0))
+		s.state.AddInactivityScore(0)
+	}
+	return nil
+}
+
+// ProcessVoluntaryExit initiates the exit of a validator which signed it, once it has been active for long enough.
+func (s *StateTransistor) ProcessVoluntaryExit(signedVoluntaryExit *cltypes.SignedVoluntaryExit) error {
+	voluntaryExit := signedVoluntaryExit.VolunaryExit
+	if voluntaryExit.ValidatorIndex >= uint64(len(s.state.Validators())) {
+		return fmt.Errorf("ProcessVoluntaryExit: validator index %d out of range", voluntaryExit.ValidatorIndex)
+	}
+	validator := s.state.ValidatorAt(int(voluntaryExit.ValidatorIndex))
+	currentEpoch := s.state.Epoch()
+	if !validator.Active(currentEpoch) {
+		return fmt.Errorf("ProcessVoluntaryExit: validator %d is not active", voluntaryExit.ValidatorIndex)
+	}
+	if validator.ExitEpoch != s.beaconConfig.FarFutureEpoch {
+		return fmt.Errorf("ProcessVoluntaryExit: validator %d is already exiting, at epoch %d", voluntaryExit.ValidatorIndex, validator.ExitEpoch)
+	}
+	if currentEpoch < voluntaryExit.Epoch {
+		return fmt.Errorf("ProcessVoluntaryExit: exit epoch %d is in the future", voluntaryExit.Epoch)
+	}
+	if currentEpoch < validator.ActivationEpoch+s.beaconConfig.ShardCommitteePeriod {
+		return fmt.Errorf("ProcessVoluntaryExit: validator %d has not been active long enough", voluntaryExit.ValidatorIndex)
+	}
+	if !s.noValidate {
+		// the domain is the one of the exit epoch, exits stay valid after a fork
+		signingRoot, err := s.state.ComputeSigningRoot(voluntaryExit, s.beaconConfig.DomainVoluntaryExit, voluntaryExit.Epoch)
+		if err != nil {
+			return fmt.Errorf("ProcessVoluntaryExit: unable to compute signing root: %v", err)
+		}
+		valid, err := verifyValidatorSignature(s.state, signedVoluntaryExit.Signature[:], signingRoot[:], voluntaryExit.ValidatorIndex)
 		if err != nil {
-			return err
+			return fmt.Errorf("ProcessVoluntaryExit: unable to verify signature: %v", err)
 		}
-		depositMessageRoot, err := deposit.Data.MessageHash()
+		if !valid {
+			return fmt.Errorf("ProcessVoluntaryExit: invalid signature of validator %d", voluntaryExit.ValidatorIndex)
+		}
+	}
+	s.state.InitiateValidatorExit(voluntaryExit.ValidatorIndex)
+	return nil
+}
+
+// ProcessBlsToExecutionChange replaces the BLS withdrawal credentials of a validator with an execution address
+// (Capella), signed by the key the credentials commit to.
+func (s *StateTransistor) ProcessBlsToExecutionChange(signedChange *cltypes.SignedBLSToExecutionChange) error {
+	change := signedChange.Message
+	if change.ValidatorIndex >= uint64(len(s.state.Validators())) {
+		return fmt.Errorf("ProcessBlsToExecutionChange: validator index %d out of range", change.ValidatorIndex)
+	}
+	// Copied: validators are shared with the copies of the state
+	validator := *s.state.ValidatorAt(int(change.ValidatorIndex))
+	if validator.WithdrawalCredentials[0] != s.beaconConfig.BLSWithdrawalPrefixByte {
+		return fmt.Errorf("ProcessBlsToExecutionChange: validator %d has no BLS withdrawal credentials", change.ValidatorIndex)
+	}
+	hashedFrom := utils.Keccak256(change.From[:])
+	if !bytes.Equal(hashedFrom[1:], validator.WithdrawalCredentials[1:]) {
+		return fmt.Errorf("ProcessBlsToExecutionChange: withdrawal credentials of validator %d don't match the public key", change.ValidatorIndex)
+	}
+	if !s.noValidate {
+		// Fork-agnostic domain, like deposits.
+		domain, err := fork.ComputeDomain(s.beaconConfig.DomainBLSToExecutionChange[:], utils.Uint32ToBytes4(s.beaconConfig.GenesisForkVersion), s.state.GenesisValidatorsRoot())
 		if err != nil {
-			return err
+			return fmt.Errorf("ProcessBlsToExecutionChange: unable to get domain: %v", err)
 		}
-		signedRoot := utils.Keccak256(depositMessageRoot[:], domain)
-		// Perform BLS verification and if successful noice.
-		valid, err := bls.Verify(deposit.Data.Signature[:], signedRoot[:], publicKey[:])
+		signingRoot, err := fork.ComputeSigningRoot(change, domain)
 		if err != nil {
-			return err
-		}
-		if valid {
-			// Append validator
-			s.state.AddValidator(s.state.ValidatorFromDeposit(deposit))
-			s.state.AddBalance(amount)
-			// Altair only
-			s.state.AddCurrentEpochParticipationFlags(cltypes.ParticipationFlags(0))
-			s.state.AddPreviousEpochParticipationFlags(cltypes.ParticipationFlags(0))
-			s.state.AddInactivityScore(0)
-		}
-	} else {
-		// Increase the balance if exists already
-		s.state.IncreaseBalance(int(validatorIndex), amount)
+			return fmt.Errorf("ProcessBlsToExecutionChange: unable to compute signing root: %v", err)
+		}
+		valid, err := bls.Verify(signedChange.Signature[:], signingRoot[:], change.From[:])
+		if err != nil {
+			return fmt.Errorf("ProcessBlsToExecutionChange: unable to verify signature: %v", err)
+		}
+		if !valid {
+			return fmt.Errorf("ProcessBlsToExecutionChange: invalid signature of validator %d", change.ValidatorIndex)
+		}
 	}
+	var credentials libcommon.Hash
+	credentials[0] = s.beaconConfig.ETH1AddressWithdrawalPrefixByte
+	copy(credentials[12:], change.To[:])
+	validator.WithdrawalCredentials = credentials
+	s.state.SetValidatorAt(int(change.ValidatorIndex), &validator)
 	return nil
 }
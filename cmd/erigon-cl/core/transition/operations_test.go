@@ -8,6 +8,8 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/common"
 )
@@ -340,6 +342,55 @@ func TestProcessDeposit(t *testing.T) {
 			testState.Balances()[1],
 		)
 	}
+	require.Equal(t, clparams.MainnetBeaconConfig.MaxEffectiveBalance, testState.ValidatorAt(1).EffectiveBalance)
+	index, ok := testState.ValidatorIndexByPubkey(deposit.Data.PubKey)
+	require.True(t, ok)
+	require.Equal(t, uint64(1), index)
+
+	// A second deposit of the key tops the validator up, it's not signed.
+	topUp := &cltypes.Deposit{Data: &cltypes.DepositData{PubKey: deposit.Data.PubKey, Amount: 1000000000}}
+	require.NoError(t, s.ProcessDeposit(topUp))
+	require.Len(t, testState.Validators(), 2)
+	require.Equal(t, deposit.Data.Amount+topUp.Data.Amount, testState.Balances()[1])
+
+	// A new validator with an invalid signature is skipped, its deposit is still consumed.
+	invalid := &cltypes.Deposit{Data: &cltypes.DepositData{}}
+	*invalid.Data = *deposit.Data
+	invalid.Data.PubKey[47]++
+	require.NoError(t, s.ProcessDeposit(invalid))
+	require.Len(t, testState.Validators(), 2)
+	require.Equal(t, uint64(3), testState.Eth1DepositIndex())
+}
+
+func TestProcessDepositProof(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	testState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	testState.AddValidator(&cltypes.Validator{PublicKey: [48]byte{1}})
+	testState.AddBalance(cfg.MaxEffectiveBalance)
+	deposit := &cltypes.Deposit{Data: &cltypes.DepositData{PubKey: [48]byte{1}, Amount: 1000000000}}
+
+	// The deposit is the only leaf of the tree, its branch holds the roots of empty subtrees and the deposit count.
+	leaf, err := deposit.Data.HashSSZ()
+	require.NoError(t, err)
+	tree, err := merkle_tree.MerkleizeVector([][32]byte{leaf}, 1<<cfg.DepositContractTreeDepth)
+	require.NoError(t, err)
+	count := merkle_tree.Uint64Root(1)
+	for i := uint64(0); i < cfg.DepositContractTreeDepth; i++ {
+		deposit.Proof = append(deposit.Proof, merkle_tree.ZeroHashes[i])
+	}
+	deposit.Proof = append(deposit.Proof, count)
+	testState.SetEth1Data(&cltypes.Eth1Data{Root: utils.Keccak256(tree[:], count[:]), DepositCount: 1})
+
+	s := New(testState, cfg, nil, false)
+	require.NoError(t, s.ProcessDeposit(deposit))
+	require.Equal(t, uint64(1), testState.Eth1DepositIndex())
+	require.Equal(t, cfg.MaxEffectiveBalance+deposit.Data.Amount, testState.Balances()[0])
+
+	// The next deposit index doesn't match the branch.
+	require.Error(t, s.ProcessDeposit(deposit))
+	testState.SetEth1DepositIndex(0)
+	deposit.Proof = deposit.Proof[:cfg.DepositContractTreeDepth]
+	require.Error(t, s.ProcessDeposit(deposit))
 	/*
 		beaconState, err := state_native.InitializeFromProtoAltair(&ethpb.BeaconStateAltair{
 			Validators: registry,
@@ -352,3 +403,87 @@ func TestProcessDeposit(t *testing.T) {
 		})*/
 	//s := New()
 }
+
+func getExitState(t *testing.T) *state.BeaconState {
+	cfg := &clparams.MainnetBeaconConfig
+	testState := state.GetEmptyBeaconState()
+	for i := 0; i < 4; i++ {
+		testState.AddValidator(&cltypes.Validator{
+			PublicKey:         [48]byte{byte(i)},
+			ActivationEpoch:   uint64(i) * cfg.ShardCommitteePeriod,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+	}
+	testState.SetSlot(cfg.ShardCommitteePeriod * cfg.SlotsPerEpoch)
+	return testState
+}
+
+func TestProcessVoluntaryExit(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	tests := []struct {
+		name    string
+		exit    cltypes.VoluntaryExit
+		prepare func(b *state.BeaconState)
+		wantErr bool
+	}{
+		{name: "valid", exit: cltypes.VoluntaryExit{Epoch: 0, ValidatorIndex: 0}},
+		{name: "out of range", exit: cltypes.VoluntaryExit{ValidatorIndex: 4}, wantErr: true},
+		{name: "not active yet", exit: cltypes.VoluntaryExit{ValidatorIndex: 2}, wantErr: true},
+		{name: "active for too short", exit: cltypes.VoluntaryExit{ValidatorIndex: 1}, wantErr: true},
+		{name: "future epoch", exit: cltypes.VoluntaryExit{Epoch: cfg.ShardCommitteePeriod + 1}, wantErr: true},
+		{
+			name: "already exiting",
+			exit: cltypes.VoluntaryExit{ValidatorIndex: 0},
+			prepare: func(b *state.BeaconState) {
+				b.InitiateValidatorExit(0)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testState := getExitState(t)
+			if tt.prepare != nil {
+				tt.prepare(testState)
+			}
+			exit := tt.exit
+			err := New(testState, cfg, nil, true).ProcessVoluntaryExit(&cltypes.SignedVoluntaryExit{VolunaryExit: &exit})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			exitEpoch := testState.ComputeActivationExitEpoch(testState.Epoch())
+			require.Equal(t, exitEpoch, testState.ValidatorAt(0).ExitEpoch)
+			require.Equal(t, exitEpoch+cfg.MinValidatorWithdrawabilityDelay, testState.ValidatorAt(0).WithdrawableEpoch)
+		})
+	}
+}
+
+func TestProcessBlsToExecutionChange(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	from := [48]byte{7}
+	credentials := utils.Keccak256(from[:])
+	credentials[0] = cfg.BLSWithdrawalPrefixByte
+	testState := getExitState(t)
+	validator := *testState.ValidatorAt(1)
+	validator.WithdrawalCredentials = credentials
+	testState.SetValidatorAt(1, &validator)
+	copied := testState.Copy()
+	s := New(testState, cfg, nil, true)
+
+	change := &cltypes.BLSToExecutionChange{ValidatorIndex: 1, From: [48]byte{8}, To: libcommon.HexToAddress("0x1234")}
+	require.Error(t, s.ProcessBlsToExecutionChange(&cltypes.SignedBLSToExecutionChange{Message: change}))
+
+	change.From = from
+	require.NoError(t, s.ProcessBlsToExecutionChange(&cltypes.SignedBLSToExecutionChange{Message: change}))
+	want := libcommon.HexToHash("0x010000000000000000000000" + "0000000000000000000000000000000000001234")
+	require.Equal(t, want, testState.ValidatorAt(1).WithdrawalCredentials)
+	require.Equal(t, credentials, [32]byte(copied.ValidatorAt(1).WithdrawalCredentials))
+
+	// The credentials are not BLS anymore.
+	require.Error(t, s.ProcessBlsToExecutionChange(&cltypes.SignedBLSToExecutionChange{Message: change}))
+	change.ValidatorIndex = 4
+	require.Error(t, s.ProcessBlsToExecutionChange(&cltypes.SignedBLSToExecutionChange{Message: change}))
+}
@@ -0,0 +1,105 @@
+package transition
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+// ProcessAttestations applies the attestations of a block: the attesters earn the participation flags of the
+// attestation, the proposer a reward for each flag newly earned.
+func (s *StateTransistor) ProcessAttestations(attestations []*cltypes.Attestation) error {
+	if s.state.Version() == clparams.Phase0Version {
+		return fmt.Errorf("ProcessAttestations: phase0 attestations are not supported")
+	}
+	// the effective balances don't change while processing the attestations
+	totalActiveBalance, err := s.state.GetTotalActiveBalance()
+	if err != nil {
+		return err
+	}
+	for i, attestation := range attestations {
+		if err := s.processAttestation(attestation, totalActiveBalance); err != nil {
+			return fmt.Errorf("ProcessAttestations: attestation %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (s *StateTransistor) processAttestation(attestation *cltypes.Attestation, totalActiveBalance uint64) error {
+	data := attestation.Data
+	stateSlot := s.state.Slot()
+	currentEpoch := s.state.Epoch()
+	if data.Target.Epoch != currentEpoch && data.Target.Epoch != s.state.PreviousEpoch() {
+		return fmt.Errorf("target epoch %d is neither the current nor the previous epoch", data.Target.Epoch)
+	}
+	if data.Target.Epoch != s.state.GetEpochAtSlot(data.Slot) {
+		return fmt.Errorf("target epoch %d is not the epoch of slot %d", data.Target.Epoch, data.Slot)
+	}
+	if data.Slot+s.beaconConfig.MinAttestationInclusionDelay > stateSlot || stateSlot > data.Slot+s.beaconConfig.SlotsPerEpoch {
+		return fmt.Errorf("attestation of slot %d cannot be included at slot %d", data.Slot, stateSlot)
+	}
+	participationFlagIndices, err := s.state.GetAttestationParticipationFlagIndices(data, stateSlot-data.Slot)
+	if err != nil {
+		return err
+	}
+	attestingIndices, err := s.state.GetAttestingIndices(data, attestation.AggregationBits, true)
+	if err != nil {
+		return err
+	}
+	if !s.noValidate {
+		indexedAttestation := &cltypes.IndexedAttestation{
+			AttestingIndices: append([]uint64{}, attestingIndices...),
+			Data:             data,
+			Signature:        attestation.Signature,
+		}
+		sort.Slice(indexedAttestation.AttestingIndices, func(i, j int) bool {
+			return indexedAttestation.AttestingIndices[i] < indexedAttestation.AttestingIndices[j]
+		})
+		valid, err := IsValidIndexedAttestation(s.state, indexedAttestation)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return fmt.Errorf("invalid indexed attestation")
+		}
+	}
+
+	epochParticipation := s.state.PreviousEpochParticipation()
+	setParticipationFlags := s.state.SetPreviousEpochParticipationFlagsAt
+	if data.Target.Epoch == currentEpoch {
+		epochParticipation = s.state.CurrentEpochParticipation()
+		setParticipationFlags = s.state.SetCurrentEpochParticipationFlagsAt
+	}
+	weights := []uint64{s.beaconConfig.TimelySourceWeight, s.beaconConfig.TimelyTargetWeight, s.beaconConfig.TimelyHeadWeight}
+	flagIndices := []uint8{s.beaconConfig.TimelySourceFlagIndex, s.beaconConfig.TimelyTargetFlagIndex, s.beaconConfig.TimelyHeadFlagIndex}
+	earned := make([]bool, len(flagIndices))
+	for i, flagIndex := range flagIndices {
+		for _, participationFlagIndex := range participationFlagIndices {
+			if participationFlagIndex == flagIndex {
+				earned[i] = true
+			}
+		}
+	}
+
+	proposerRewardNumerator := uint64(0)
+	for _, index := range attestingIndices {
+		flags := epochParticipation[index]
+		baseReward := s.state.BaseReward(s.state.ValidatorAt(int(index)).EffectiveBalance, totalActiveBalance)
+		for i, flagIndex := range flagIndices {
+			if earned[i] && !flags.HasFlag(int(flagIndex)) {
+				flags = flags.Add(int(flagIndex))
+				proposerRewardNumerator += baseReward * weights[i]
+			}
+		}
+		setParticipationFlags(int(index), flags)
+	}
+	proposerRewardDenominator := (s.beaconConfig.WeightDenominator - s.beaconConfig.ProposerWeight) * s.beaconConfig.WeightDenominator / s.beaconConfig.ProposerWeight
+	proposerIndex, err := s.state.GetBeaconProposerIndex()
+	if err != nil {
+		return err
+	}
+	s.state.IncreaseBalance(int(proposerIndex), proposerRewardNumerator/proposerRewardDenominator)
+	return nil
+}
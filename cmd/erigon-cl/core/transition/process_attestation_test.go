@@ -0,0 +1,226 @@
+package transition_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+// attestationTestValidators make a committee of 8 per slot.
+const attestationTestValidators = 256
+
+// attestationTestSlot is the slot of the attestation tests, in epoch 2.
+const attestationTestSlot = 74
+
+// getAttestationState returns an altair state at attestationTestSlot, where the block root of the slot i starts with
+// byte i+1, and the justified checkpoints are the first slots of the epochs 0 and 1.
+func getAttestationState() *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for i := 0; i < attestationTestSlot; i++ {
+		b.SetBlockRootAt(i, libcommon.Hash{byte(i + 1)})
+	}
+	b.SetSlot(attestationTestSlot)
+	b.SetPreviousJustifiedCheckpoint(&cltypes.Checkpoint{Epoch: 0, Root: libcommon.Hash{1}})
+	b.SetCurrentJustifiedCheckpoint(&cltypes.Checkpoint{Epoch: 1, Root: libcommon.Hash{33}})
+	for i := 0; i < attestationTestValidators; i++ {
+		b.AddValidator(&cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance)
+	}
+	b.SetPreviousEpochParticipation(make(cltypes.ParticipationFlagsList, attestationTestValidators))
+	b.SetCurrentEpochParticipation(make(cltypes.ParticipationFlagsList, attestationTestValidators))
+	return b
+}
+
+// getAttestation returns an attestation of the committee 0 at slot, voting for the head, target and source of the state.
+func getAttestation(b *state.BeaconState, slot uint64, bits []byte) *cltypes.Attestation {
+	epoch := b.GetEpochAtSlot(slot)
+	source := *b.CurrentJustifiedCheckpoint()
+	if epoch != b.Epoch() {
+		source = *b.PreviousJustifiedCheckpoint()
+	}
+	targetRoot, err := b.GetBlockRoot(epoch)
+	if err != nil {
+		panic(err)
+	}
+	headRoot, err := b.GetBlockRootAtSlot(slot)
+	if err != nil {
+		panic(err)
+	}
+	return &cltypes.Attestation{
+		AggregationBits: bits,
+		Data: &cltypes.AttestationData{
+			Slot:            slot,
+			BeaconBlockHash: headRoot,
+			Source:          &source,
+			Target:          &cltypes.Checkpoint{Epoch: epoch, Root: targetRoot},
+		},
+	}
+}
+
+func TestProcessAttestation(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	source := cltypes.ParticipationFlags(0).Add(int(cfg.TimelySourceFlagIndex))
+	target := cltypes.ParticipationFlags(0).Add(int(cfg.TimelyTargetFlagIndex))
+	head := cltypes.ParticipationFlags(0).Add(int(cfg.TimelyHeadFlagIndex))
+	full := []byte{0xff, 0b1}
+
+	testCases := []struct {
+		description string
+		slot        uint64
+		bits        []byte
+		modify      func(data *cltypes.AttestationData)
+
+		expectedErr   bool
+		expectedFlags cltypes.ParticipationFlags
+		attesters     int
+	}{
+		{
+			description:   "timely",
+			slot:          attestationTestSlot - 1,
+			bits:          full,
+			expectedFlags: source | target | head,
+			attesters:     8,
+		},
+		{
+			description:   "part of the committee",
+			slot:          attestationTestSlot - 1,
+			bits:          []byte{0b111, 0b1},
+			expectedFlags: source | target | head,
+			attesters:     3,
+		},
+		{
+			description:   "wrong head",
+			slot:          attestationTestSlot - 1,
+			bits:          full,
+			modify:        func(data *cltypes.AttestationData) { data.BeaconBlockHash = libcommon.Hash{0xff} },
+			expectedFlags: source | target,
+			attesters:     8,
+		},
+		{
+			description:   "wrong target",
+			slot:          attestationTestSlot - 1,
+			bits:          full,
+			modify:        func(data *cltypes.AttestationData) { data.Target.Root = libcommon.Hash{0xff} },
+			expectedFlags: source,
+			attesters:     8,
+		},
+		{
+			description:   "late for the source",
+			slot:          attestationTestSlot - 6,
+			bits:          full,
+			expectedFlags: target,
+			attesters:     8,
+		},
+		{
+			description:   "previous epoch",
+			slot:          60,
+			bits:          full,
+			expectedFlags: target,
+			attesters:     8,
+		},
+		{
+			description: "wrong source",
+			slot:        attestationTestSlot - 1,
+			bits:        full,
+			modify:      func(data *cltypes.AttestationData) { data.Source.Epoch = 0 },
+			expectedErr: true,
+		},
+		{
+			description: "bits longer than the committee",
+			slot:        attestationTestSlot - 1,
+			bits:        []byte{0xff, 0b11},
+			expectedErr: true,
+		},
+		{
+			description: "before the inclusion delay",
+			slot:        attestationTestSlot - 1,
+			bits:        full,
+			modify:      func(data *cltypes.AttestationData) { data.Slot = attestationTestSlot },
+			expectedErr: true,
+		},
+		{
+			description: "target epoch not of the slot",
+			slot:        attestationTestSlot - 1,
+			bits:        full,
+			modify:      func(data *cltypes.AttestationData) { data.Target.Epoch = 1 },
+			expectedErr: true,
+		},
+		{
+			description: "too old",
+			slot:        attestationTestSlot - cfg.SlotsPerEpoch - 1,
+			bits:        full,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			b := getAttestationState()
+			attestation := getAttestation(b, tc.slot, tc.bits)
+			if tc.modify != nil {
+				tc.modify(attestation.Data)
+			}
+			s := transition.New(b, &cfg, nil, true)
+			proposer, err := b.GetBeaconProposerIndex()
+			require.NoError(t, err)
+			proposerBalance := b.Balances()[proposer]
+
+			err = s.ProcessAttestations([]*cltypes.Attestation{attestation})
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			participation := b.CurrentEpochParticipation()
+			if attestation.Data.Target.Epoch != b.Epoch() {
+				participation = b.PreviousEpochParticipation()
+			}
+			committee, err := b.GetBeaconCommittee(tc.slot, 0)
+			require.NoError(t, err)
+			attesting := map[uint64]bool{}
+			for _, index := range committee[:tc.attesters] {
+				attesting[index] = true
+			}
+			for index, flags := range participation {
+				if attesting[uint64(index)] {
+					require.Equal(t, tc.expectedFlags, flags, "attester %d", index)
+				} else {
+					require.Zero(t, flags, "validator %d", index)
+				}
+			}
+
+			// the proposer is rewarded for the flags earned by the attesters
+			totalActiveBalance, err := b.GetTotalActiveBalance()
+			require.NoError(t, err)
+			weight := uint64(0)
+			for flagIndex, flagWeight := range map[uint8]uint64{
+				cfg.TimelySourceFlagIndex: cfg.TimelySourceWeight,
+				cfg.TimelyTargetFlagIndex: cfg.TimelyTargetWeight,
+				cfg.TimelyHeadFlagIndex:   cfg.TimelyHeadWeight,
+			} {
+				if tc.expectedFlags.HasFlag(int(flagIndex)) {
+					weight += flagWeight
+				}
+			}
+			numerator := uint64(tc.attesters) * b.BaseReward(cfg.MaxEffectiveBalance, totalActiveBalance) * weight
+			denominator := (cfg.WeightDenominator - cfg.ProposerWeight) * cfg.WeightDenominator / cfg.ProposerWeight
+			require.Equal(t, proposerBalance+numerator/denominator, b.Balances()[proposer])
+
+			// the flags are earned once
+			require.NoError(t, s.ProcessAttestations([]*cltypes.Attestation{attestation}))
+			require.Equal(t, proposerBalance+numerator/denominator, b.Balances()[proposer])
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+)
+
+// ProcessBlock applies block to the state, which must be at the slot of the block (see processSlots). The execution
+// payload is validated by the execution engine, when one is set (see SetExecutionEngine).
+func (s *StateTransistor) ProcessBlock(block *cltypes.BeaconBlock) error {
+	if err := s.ProcessBlockHeader(block); err != nil {
+		return fmt.Errorf("ProcessBlock: %v", err)
+	}
+	if s.state.Version() >= clparams.CapellaVersion {
+		if block.Body.ExecutionPayload == nil {
+			return fmt.Errorf("ProcessBlock: no execution payload")
+		}
+		if err := s.ProcessWithdrawals(block.Body.ExecutionPayload.Withdrawals()); err != nil {
+			return fmt.Errorf("ProcessBlock: %v", err)
+		}
+	}
+	if s.state.Version() >= clparams.CapellaVersion || (s.state.Version() >= clparams.BellatrixVersion && s.isExecutionEnabled(block.Body.ExecutionPayload)) {
+		if block.Body.ExecutionPayload == nil {
+			return fmt.Errorf("ProcessBlock: no execution payload")
+		}
+		if err := s.ProcessExecutionPayload(block.Body.ExecutionPayload); err != nil {
+			return fmt.Errorf("ProcessBlock: %v", err)
+		}
+	}
+	if err := s.ProcessRandao(block.Body.RandaoReveal); err != nil {
+		return fmt.Errorf("ProcessBlock: %v", err)
+	}
+	if err := s.ProcessEth1Data(block.Body.Eth1Data); err != nil {
+		return fmt.Errorf("ProcessBlock: %v", err)
+	}
+	if err := s.processOperations(block.Body); err != nil {
+		return fmt.Errorf("ProcessBlock: %v", err)
+	}
+	if s.state.Version() >= clparams.AltairVersion {
+		if err := s.ProcessSyncAggregate(block.Body.SyncAggregate); err != nil {
+			return fmt.Errorf("ProcessBlock: %v", err)
+		}
+	}
+	return nil
+}
+
+// processOperations applies the operations of a block body, in the order of the spec.
+func (s *StateTransistor) processOperations(body *cltypes.BeaconBody) error {
+	maxDeposits := s.state.Eth1Data().DepositCount - s.state.Eth1DepositIndex()
+	if maxDeposits > s.beaconConfig.MaxDeposits {
+		maxDeposits = s.beaconConfig.MaxDeposits
+	}
+	if uint64(len(body.Deposits)) != maxDeposits {
+		return fmt.Errorf("%d deposits, expected %d", len(body.Deposits), maxDeposits)
+	}
+	for _, slashing := range body.ProposerSlashings {
+		if err := s.ProcessProposerSlashing(slashing); err != nil {
+			return err
+		}
+	}
+	for _, slashing := range body.AttesterSlashings {
+		if err := s.ProcessAttesterSlashing(slashing); err != nil {
+			return err
+		}
+	}
+	if err := s.ProcessAttestations(body.Attestations); err != nil {
+		return err
+	}
+	for _, deposit := range body.Deposits {
+		if err := s.ProcessDeposit(deposit); err != nil {
+			return err
+		}
+	}
+	for _, exit := range body.VoluntaryExits {
+		if err := s.ProcessVoluntaryExit(exit); err != nil {
+			return err
+		}
+	}
+	if s.state.Version() < clparams.CapellaVersion {
+		return nil
+	}
+	for _, change := range body.ExecutionChanges {
+		if err := s.ProcessBlsToExecutionChange(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
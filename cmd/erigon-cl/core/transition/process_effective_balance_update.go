@@ -0,0 +1,23 @@
+package transition
+
+// ProcessEffectiveBalanceUpdates moves the effective balances to the balances rounded down to an increment, only when
+// they are past the hysteresis thresholds so that small changes of a balance don't change its effective balance.
+func (s *StateTransistor) ProcessEffectiveBalanceUpdates() {
+	hysteresisIncrement := s.beaconConfig.EffectiveBalanceIncrement / s.beaconConfig.HysteresisQuotient
+	downwardThreshold := hysteresisIncrement * s.beaconConfig.HysteresisDownwardMultiplier
+	upwardThreshold := hysteresisIncrement * s.beaconConfig.HysteresisUpwardMultiplier
+	for index, validator := range s.state.Validators() {
+		balance := s.state.ValidatorBalance(index)
+		if balance+downwardThreshold >= validator.EffectiveBalance && validator.EffectiveBalance+upwardThreshold >= balance {
+			continue
+		}
+		effectiveBalance := balance - balance%s.beaconConfig.EffectiveBalanceIncrement
+		if effectiveBalance > s.beaconConfig.MaxEffectiveBalance {
+			effectiveBalance = s.beaconConfig.MaxEffectiveBalance
+		}
+		// validators are shared with the copies of the state
+		updated := *validator
+		updated.EffectiveBalance = effectiveBalance
+		s.state.SetValidatorAt(index, &updated)
+	}
+}
@@ -0,0 +1,43 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func TestProcessEffectiveBalanceUpdates(t *testing.T) {
+	const gwei = 1_000_000_000
+	testCases := []struct {
+		description       string
+		balance           uint64
+		effectiveBalance  uint64
+		expectedEffective uint64
+	}{
+		{"unchanged", 32 * gwei, 32 * gwei, 32 * gwei},
+		{"below the upward threshold", 33 * gwei, 32 * gwei, 32 * gwei},
+		{"capped to the maximum", 34 * gwei, 32 * gwei, 32 * gwei},
+		{"above the downward threshold", 31_800_000_000, 32 * gwei, 32 * gwei},
+		{"below the downward threshold", 31_700_000_000, 32 * gwei, 31 * gwei},
+		{"above the upward threshold", 20_500_000_000, 19 * gwei, 20 * gwei},
+		{"within the upward threshold", 20_200_000_000, 19 * gwei, 19 * gwei},
+	}
+
+	testState := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for _, tc := range testCases {
+		testState.AddValidator(&cltypes.Validator{EffectiveBalance: tc.effectiveBalance})
+		testState.AddBalance(tc.balance)
+	}
+	before := testState.Copy()
+	transition.New(testState, &clparams.MainnetBeaconConfig, nil, false).ProcessEffectiveBalanceUpdates()
+	for i, tc := range testCases {
+		require.Equal(t, tc.expectedEffective, testState.ValidatorAt(i).EffectiveBalance, tc.description)
+		// the copy keeps its validators
+		require.Equal(t, tc.effectiveBalance, before.ValidatorAt(i).EffectiveBalance, tc.description)
+	}
+}
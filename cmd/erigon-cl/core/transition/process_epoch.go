@@ -0,0 +1,39 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+)
+
+// ProcessEpoch applies the end of epoch processing to the state at the last slot of an epoch, in the order of the
+// spec (process_epoch). Phase0 states are not supported.
+func (s *StateTransistor) ProcessEpoch() error {
+	for _, process := range []func() error{
+		s.ProcessJustificationAndFinalization,
+		s.ProcessInactivityUpdates,
+		s.ProcessRewardsAndPenalties,
+		s.ProcessRegistryUpdates,
+		s.ProcessSlashings,
+	} {
+		if err := process(); err != nil {
+			return fmt.Errorf("ProcessEpoch: %v", err)
+		}
+	}
+	s.ProcessEth1DataReset()
+	s.ProcessEffectiveBalanceUpdates()
+	s.ProcessSlashingsReset()
+	s.ProcessRandaoMixesReset()
+	processHistoricalUpdate := s.ProcessHistoricalRootsUpdate
+	if s.state.Version() >= clparams.CapellaVersion {
+		processHistoricalUpdate = s.ProcessHistoricalSummariesUpdate
+	}
+	if err := processHistoricalUpdate(); err != nil {
+		return fmt.Errorf("ProcessEpoch: %v", err)
+	}
+	s.ProcessParticipationFlagUpdates()
+	if err := s.ProcessSyncCommitteeUpdate(); err != nil {
+		return fmt.Errorf("ProcessEpoch: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package transition
+
+import (
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// ExecutionEngine validates the execution payloads for the state transition (notify_new_payload in the spec), it is
+// the local Erigon-EL or a remote one through the Engine API.
+type ExecutionEngine interface {
+	// NewPayload returns whether payload is valid. An engine which can't tell yet, e.g. while syncing, accepts it.
+	NewPayload(payload *cltypes.Eth1Block) (bool, error)
+}
+
+// SetExecutionEngine sets the engine which validates the execution payloads, without one they are accepted as long
+// as they are consistent with the state.
+func (s *StateTransistor) SetExecutionEngine(engine ExecutionEngine) {
+	s.executionEngine = engine
+}
+
+// isExecutionEnabled returns whether the payload of the block must be processed (Bellatrix): once the merge is
+// complete, or for the first payload which completes it.
+func (s *StateTransistor) isExecutionEnabled(payload *cltypes.Eth1Block) bool {
+	return s.state.IsMergeTransitionComplete() || (payload != nil && payload.Header.BlockHashCL != (libcommon.Hash{}))
+}
+
+// ProcessExecutionPayload checks that payload builds on the latest execution payload of the state, with its randao
+// and at the time of its slot, has the execution engine validate it, and makes its header the latest one.
+func (s *StateTransistor) ProcessExecutionPayload(payload *cltypes.Eth1Block) error {
+	if s.state.IsMergeTransitionComplete() {
+		if latest := s.state.LatestExecutionPayloadHeader(); payload.Header.ParentHash != latest.BlockHashCL {
+			return fmt.Errorf("ProcessExecutionPayload: parent hash %x, expected %x", payload.Header.ParentHash, latest.BlockHashCL)
+		}
+	}
+	if randao := s.state.GetRandaoMixes(s.state.Epoch()); payload.Header.MixDigest != randao {
+		return fmt.Errorf("ProcessExecutionPayload: prev randao %x, expected %x", payload.Header.MixDigest, randao)
+	}
+	if timestamp := s.state.GenesisTime() + s.state.Slot()*s.beaconConfig.SecondsPerSlot; payload.Header.Time != timestamp {
+		return fmt.Errorf("ProcessExecutionPayload: timestamp %d, expected %d", payload.Header.Time, timestamp)
+	}
+	if s.executionEngine != nil {
+		valid, err := s.executionEngine.NewPayload(payload)
+		if err != nil {
+			return fmt.Errorf("ProcessExecutionPayload: %v", err)
+		}
+		if !valid {
+			return fmt.Errorf("ProcessExecutionPayload: payload %x is invalid", payload.Header.BlockHashCL)
+		}
+	}
+	// hashing fills the transactions and withdrawals roots of the header
+	if _, err := payload.HashSSZ(s.state.Version()); err != nil {
+		return fmt.Errorf("ProcessExecutionPayload: %v", err)
+	}
+	s.state.SetLatestExecutionPayloadHeader(types.CopyHeader(payload.Header))
+	return nil
+}
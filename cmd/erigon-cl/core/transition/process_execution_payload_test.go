@@ -0,0 +1,83 @@
+package transition_test
+
+import (
+	"math/big"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// testEngine is an ExecutionEngine which answers valid, and records the payloads.
+type testEngine struct {
+	valid    bool
+	payloads []*cltypes.Eth1Block
+}
+
+func (e *testEngine) NewPayload(payload *cltypes.Eth1Block) (bool, error) {
+	e.payloads = append(e.payloads, payload)
+	return e.valid, nil
+}
+
+// getPayloadState returns a bellatrix state at slot 10 and a payload with the time and randao of the slot.
+func getPayloadState() (*state.BeaconState, *cltypes.Eth1Block) {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.BellatrixVersion)
+	b.SetGenesisTime(1000)
+	b.SetSlot(10)
+	b.SetRandaoMixAt(0, libcommon.Hash{7})
+	payload := &cltypes.Eth1Block{
+		Header: &types.Header{
+			Number:      big.NewInt(1),
+			BaseFee:     big.NewInt(0),
+			Time:        1000 + 10*cfg.SecondsPerSlot,
+			MixDigest:   libcommon.Hash{7},
+			BlockHashCL: libcommon.Hash{1},
+		},
+		Body: &types.RawBody{},
+	}
+	return b, payload
+}
+
+func TestProcessExecutionPayload(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b, payload := getPayloadState()
+	require.False(t, b.IsMergeTransitionComplete())
+
+	payload.Header.Time++
+	require.Error(t, transition.New(b, &cfg, nil, true).ProcessExecutionPayload(payload))
+	payload.Header.Time--
+	payload.Header.MixDigest = libcommon.Hash{8}
+	require.Error(t, transition.New(b, &cfg, nil, true).ProcessExecutionPayload(payload))
+	payload.Header.MixDigest = libcommon.Hash{7}
+
+	engine := &testEngine{}
+	s := transition.New(b, &cfg, nil, true)
+	s.SetExecutionEngine(engine)
+	require.Error(t, s.ProcessExecutionPayload(payload))
+	require.False(t, b.IsMergeTransitionComplete())
+
+	// the first payload completes the merge, whatever its parent
+	engine.valid = true
+	require.NoError(t, s.ProcessExecutionPayload(payload))
+	require.Equal(t, []*cltypes.Eth1Block{payload, payload}, engine.payloads)
+	require.True(t, b.IsMergeTransitionComplete())
+	require.Equal(t, libcommon.Hash{1}, b.LatestExecutionPayloadHeader().BlockHashCL)
+
+	// the next payloads must build on it
+	b.SetSlot(11)
+	next := &cltypes.Eth1Block{Header: types.CopyHeader(payload.Header), Body: &types.RawBody{}}
+	next.Header.Time += cfg.SecondsPerSlot
+	next.Header.BlockHashCL = libcommon.Hash{2}
+	next.Header.ParentHash = libcommon.Hash{3}
+	require.Error(t, s.ProcessExecutionPayload(next))
+	next.Header.ParentHash = libcommon.Hash{1}
+	require.NoError(t, s.ProcessExecutionPayload(next))
+	require.Equal(t, libcommon.Hash{2}, b.LatestExecutionPayloadHeader().BlockHashCL)
+}
@@ -0,0 +1,41 @@
+package transition
+
+import "fmt"
+
+// isHistoricalUpdateEpoch returns whether the epoch processing fills a period of SlotsPerHistoricalRoot slots, whose
+// block and state roots are accumulated before they get overwritten.
+func (s *StateTransistor) isHistoricalUpdateEpoch() bool {
+	return (s.state.Epoch()+1)%(s.beaconConfig.SlotsPerHistoricalRoot/s.beaconConfig.SlotsPerEpoch) == 0
+}
+
+// ProcessHistoricalRootsUpdate appends the root of the historical batch of the period to the historical roots, until
+// Bellatrix.
+func (s *StateTransistor) ProcessHistoricalRootsUpdate() error {
+	if !s.isHistoricalUpdateEpoch() {
+		return nil
+	}
+	summary, err := s.state.HistoricalSummary()
+	if err != nil {
+		return fmt.Errorf("ProcessHistoricalRootsUpdate: %v", err)
+	}
+	root, err := summary.HashSSZ()
+	if err != nil {
+		return fmt.Errorf("ProcessHistoricalRootsUpdate: %v", err)
+	}
+	s.state.AddHistoricalRoot(root)
+	return nil
+}
+
+// ProcessHistoricalSummariesUpdate appends the summary of the period to the historical summaries, from Capella on:
+// the historical roots are frozen.
+func (s *StateTransistor) ProcessHistoricalSummariesUpdate() error {
+	if !s.isHistoricalUpdateEpoch() {
+		return nil
+	}
+	summary, err := s.state.HistoricalSummary()
+	if err != nil {
+		return fmt.Errorf("ProcessHistoricalSummariesUpdate: %v", err)
+	}
+	s.state.AddHistoricalSummary(summary)
+	return nil
+}
@@ -0,0 +1,67 @@
+package transition_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/merkle_tree"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state/state_encoding"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+// getHistoricalState returns a state at epoch with a few block and state roots.
+func getHistoricalState(version clparams.StateVersion, epoch uint64) *state.BeaconState {
+	b := state.GetEmptyBeaconStateWithVersion(version)
+	b.SetSlot(epoch * clparams.MainnetBeaconConfig.SlotsPerEpoch)
+	for i := 0; i < 3; i++ {
+		b.SetBlockRootAt(i, libcommon.Hash{byte(i + 1)})
+		b.SetStateRootAt(i, libcommon.Hash{byte(i + 10)})
+	}
+	return b
+}
+
+func rootsRoot(t *testing.T, roots [state_encoding.BlockRootsLength]libcommon.Hash) libcommon.Hash {
+	leaves := make([][32]byte, len(roots))
+	for i := range roots {
+		leaves[i] = roots[i]
+	}
+	root, err := merkle_tree.ArraysRoot(leaves, state_encoding.BlockRootsLength)
+	require.NoError(t, err)
+	return root
+}
+
+func TestProcessHistoricalSummariesUpdate(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	// the period ends with the epoch 255
+	b := getHistoricalState(clparams.CapellaVersion, 254)
+	require.NoError(t, transition.New(b, &cfg, nil, false).ProcessHistoricalSummariesUpdate())
+	require.Empty(t, b.HistoricalSummaries())
+
+	b = getHistoricalState(clparams.CapellaVersion, 255)
+	require.NoError(t, transition.New(b, &cfg, nil, false).ProcessHistoricalSummariesUpdate())
+	require.Len(t, b.HistoricalSummaries(), 1)
+	summary := b.HistoricalSummaries()[0]
+	require.Equal(t, rootsRoot(t, b.BlockRoots()), summary.BlockSummaryRoot)
+	require.Equal(t, rootsRoot(t, b.StateRoots()), summary.StateSummaryRoot)
+	require.Empty(t, b.HistoricalRoots())
+}
+
+func TestProcessHistoricalRootsUpdate(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getHistoricalState(clparams.BellatrixVersion, 254)
+	require.NoError(t, transition.New(b, &cfg, nil, false).ProcessHistoricalRootsUpdate())
+	require.Empty(t, b.HistoricalRoots())
+
+	b = getHistoricalState(clparams.BellatrixVersion, 511)
+	require.NoError(t, transition.New(b, &cfg, nil, false).ProcessHistoricalRootsUpdate())
+	require.Len(t, b.HistoricalRoots(), 1)
+	// the root of the historical batch of the block and state roots
+	blockRoots, stateRoots := rootsRoot(t, b.BlockRoots()), rootsRoot(t, b.StateRoots())
+	batchRoot, err := merkle_tree.ArraysRoot([][32]byte{blockRoots, stateRoots}, 2)
+	require.NoError(t, err)
+	require.Equal(t, libcommon.Hash(batchRoot), b.HistoricalRoots()[0])
+}
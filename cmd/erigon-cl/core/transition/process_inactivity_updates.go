@@ -0,0 +1,39 @@
+package transition
+
+// ProcessInactivityUpdates updates the inactivity scores of the eligible validators from their target participation
+// in the previous epoch: the score of a validator which missed the target grows by the bias, and shrinks back by one
+// otherwise. Outside of an inactivity leak all the scores also recover.
+func (s *StateTransistor) ProcessInactivityUpdates() error {
+	if s.state.Epoch() == s.beaconConfig.GenesisEpoch {
+		return nil
+	}
+	indices, err := s.state.GetUnslashedParticipatingIndices(int(s.beaconConfig.TimelyTargetFlagIndex), s.state.PreviousEpoch())
+	if err != nil {
+		return err
+	}
+	targetParticipating := make([]bool, len(s.state.Validators()))
+	for _, index := range indices {
+		targetParticipating[index] = true
+	}
+	leaking := s.state.InactivityLeaking()
+	for index := range s.state.Validators() {
+		if !s.state.IsValidatorEligible(uint64(index)) {
+			continue
+		}
+		score := s.state.InactivityScoreAt(index)
+		if !targetParticipating[index] {
+			score += s.beaconConfig.InactivityScoreBias
+		} else if score > 0 {
+			score--
+		}
+		if !leaking {
+			if score > s.beaconConfig.InactivityScoreRecoveryRate {
+				score -= s.beaconConfig.InactivityScoreRecoveryRate
+			} else {
+				score = 0
+			}
+		}
+		s.state.SetInactivityScoreAt(index, score)
+	}
+	return nil
+}
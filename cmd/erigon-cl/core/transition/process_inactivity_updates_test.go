@@ -0,0 +1,41 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func TestProcessInactivityUpdates(t *testing.T) {
+	b := getTestStateRewards(0)
+	b.SetInactivityScores([]uint64{20, 20, 20, 20})
+	require.False(t, b.InactivityLeaking())
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessInactivityUpdates())
+	// the target is hit (-1) or missed (+4), then the scores recover by 16
+	require.Equal(t, []uint64{3, 3, 8, 8}, b.InactivityScores())
+
+	b.SetInactivityScores([]uint64{0, 1, 0, 0})
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessInactivityUpdates())
+	require.Equal(t, []uint64{0, 0, 0, 0}, b.InactivityScores())
+}
+
+func TestProcessInactivityUpdatesLeak(t *testing.T) {
+	b := getTestStateRewards(0)
+	b.SetSlot(10 * clparams.MainnetBeaconConfig.SlotsPerEpoch)
+	b.SetInactivityScores([]uint64{20, 20, 20, 20})
+	require.True(t, b.InactivityLeaking())
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessInactivityUpdates())
+	// no recovery while leaking, slashed validators miss the target
+	require.Equal(t, []uint64{19, 19, 24, 24}, b.InactivityScores())
+}
+
+func TestProcessInactivityUpdatesGenesis(t *testing.T) {
+	b := getTestStateRewards(0)
+	b.SetSlot(0)
+	b.SetInactivityScores([]uint64{20, 20, 20, 20})
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessInactivityUpdates())
+	require.Equal(t, []uint64{20, 20, 20, 20}, b.InactivityScores())
+}
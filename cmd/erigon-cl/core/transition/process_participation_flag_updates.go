@@ -0,0 +1,10 @@
+package transition
+
+import "github.com/ledgerwatch/erigon/cl/cltypes"
+
+// ProcessParticipationFlagUpdates rotates the participation flags at the end of an epoch: the flags of the current
+// epoch become the ones of the previous epoch, and the validators start the next epoch without any.
+func (s *StateTransistor) ProcessParticipationFlagUpdates() {
+	s.state.SetPreviousEpochParticipation(s.state.CurrentEpochParticipation())
+	s.state.SetCurrentEpochParticipation(make(cltypes.ParticipationFlagsList, len(s.state.Validators())))
+}
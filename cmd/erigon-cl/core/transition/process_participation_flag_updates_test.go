@@ -0,0 +1,25 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func TestProcessParticipationFlagUpdates(t *testing.T) {
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for i := 0; i < 3; i++ {
+		b.AddValidator(&cltypes.Validator{})
+		b.AddBalance(0)
+	}
+	b.SetPreviousEpochParticipation(cltypes.ParticipationFlagsList{1, 1, 1})
+	b.SetCurrentEpochParticipation(cltypes.ParticipationFlagsList{0, 3, 7})
+	transition.New(b, &clparams.MainnetBeaconConfig, nil, true).ProcessParticipationFlagUpdates()
+	require.Equal(t, cltypes.ParticipationFlagsList{0, 3, 7}, b.PreviousEpochParticipation())
+	require.Equal(t, cltypes.ParticipationFlagsList{0, 0, 0}, b.CurrentEpochParticipation())
+}
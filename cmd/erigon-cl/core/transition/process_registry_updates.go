@@ -0,0 +1,48 @@
+package transition
+
+import (
+	"sort"
+)
+
+// ProcessRegistryUpdates moves the validators through the registry: the validators with a full effective balance
+// become eligible for activation, the active ones whose effective balance went down to the ejection balance exit, and
+// the eligible validators whose eligibility is finalized are activated in the order of the queue, up to the churn
+// limit.
+func (s *StateTransistor) ProcessRegistryUpdates() error {
+	currentEpoch := s.state.Epoch()
+	for index := range s.state.Validators() {
+		validator := s.state.ValidatorAt(index)
+		if validator.ActivationEligibilityEpoch == s.beaconConfig.FarFutureEpoch && validator.EffectiveBalance == s.beaconConfig.MaxEffectiveBalance {
+			// validators are shared with the copies of the state
+			updated := *validator
+			updated.ActivationEligibilityEpoch = currentEpoch + 1
+			s.state.SetValidatorAt(index, &updated)
+		}
+		if validator.Active(currentEpoch) && validator.EffectiveBalance <= s.beaconConfig.EjectionBalance {
+			s.state.InitiateValidatorExit(uint64(index))
+		}
+	}
+
+	finalizedEpoch := s.state.FinalizedCheckpoint().Epoch
+	var activationQueue []int
+	for index, validator := range s.state.Validators() {
+		if validator.ActivationEligibilityEpoch <= finalizedEpoch && validator.ActivationEpoch == s.beaconConfig.FarFutureEpoch {
+			activationQueue = append(activationQueue, index)
+		}
+	}
+	// the queue is ordered by eligibility, then by index
+	sort.SliceStable(activationQueue, func(i, j int) bool {
+		return s.state.ValidatorAt(activationQueue[i]).ActivationEligibilityEpoch < s.state.ValidatorAt(activationQueue[j]).ActivationEligibilityEpoch
+	})
+	churnLimit := s.state.GetValidatorChurnLimit()
+	if uint64(len(activationQueue)) > churnLimit {
+		activationQueue = activationQueue[:churnLimit]
+	}
+	activationEpoch := s.state.ComputeActivationExitEpoch(currentEpoch)
+	for _, index := range activationQueue {
+		updated := *s.state.ValidatorAt(index)
+		updated.ActivationEpoch = activationEpoch
+		s.state.SetValidatorAt(index, &updated)
+	}
+	return nil
+}
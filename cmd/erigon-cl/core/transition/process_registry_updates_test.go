@@ -0,0 +1,61 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func TestProcessRegistryUpdates(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	far := cfg.FarFutureEpoch
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	b.SetSlot(10 * cfg.SlotsPerEpoch)
+	b.SetFinalizedCheckpoint(&cltypes.Checkpoint{Epoch: 8})
+	validators := []*cltypes.Validator{
+		// active, 4 of them keep the churn limit at its minimum
+		{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: far, WithdrawableEpoch: far},
+		{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: far, WithdrawableEpoch: far},
+		{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: far, WithdrawableEpoch: far},
+		// ejected
+		{EffectiveBalance: cfg.EjectionBalance, ActivationEligibilityEpoch: 0, ActivationEpoch: 0, ExitEpoch: far, WithdrawableEpoch: far},
+		// becomes eligible
+		{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: far, ActivationEpoch: far, ExitEpoch: far, WithdrawableEpoch: far},
+		// not eligible without a full balance
+		{EffectiveBalance: cfg.EjectionBalance, ActivationEligibilityEpoch: far, ActivationEpoch: far, ExitEpoch: far, WithdrawableEpoch: far},
+		// eligibility not finalized
+		{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: 9, ActivationEpoch: far, ExitEpoch: far, WithdrawableEpoch: far},
+	}
+	// the queue, by eligibility then index: 8 7 9 10 11, the churn limit activates the first 4
+	for _, eligibility := range []uint64{5, 4, 5, 5, 6} {
+		validators = append(validators, &cltypes.Validator{EffectiveBalance: cfg.MaxEffectiveBalance, ActivationEligibilityEpoch: eligibility, ActivationEpoch: far, ExitEpoch: far, WithdrawableEpoch: far})
+	}
+	for _, validator := range validators {
+		b.AddValidator(validator)
+		b.AddBalance(validator.EffectiveBalance)
+	}
+	require.Equal(t, cfg.MinPerEpochChurnLimit, b.GetValidatorChurnLimit())
+
+	require.NoError(t, transition.New(b, &cfg, nil, false).ProcessRegistryUpdates())
+	activationEpoch := b.ComputeActivationExitEpoch(10)
+	require.Equal(t, activationEpoch, b.ValidatorAt(3).ExitEpoch)
+	require.Equal(t, far, b.ValidatorAt(0).ExitEpoch)
+	require.Equal(t, uint64(11), b.ValidatorAt(4).ActivationEligibilityEpoch)
+	require.Equal(t, far, b.ValidatorAt(4).ActivationEpoch)
+	require.Equal(t, far, b.ValidatorAt(5).ActivationEligibilityEpoch)
+	require.Equal(t, far, b.ValidatorAt(6).ActivationEpoch)
+	for index, activated := range map[int]bool{7: true, 8: true, 9: true, 10: true, 11: false} {
+		if activated {
+			require.Equal(t, activationEpoch, b.ValidatorAt(index).ActivationEpoch, index)
+		} else {
+			require.Equal(t, far, b.ValidatorAt(index).ActivationEpoch, index)
+		}
+	}
+	// the input validators are untouched
+	require.Equal(t, far, validators[7].ActivationEpoch)
+}
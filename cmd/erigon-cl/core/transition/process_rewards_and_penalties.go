@@ -0,0 +1,88 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+)
+
+// ProcessRewardsAndPenalties applies the rewards and penalties of the attestations of the previous epoch, from the
+// participation flags, and the inactivity penalties, from the inactivity scores.
+func (s *StateTransistor) ProcessRewardsAndPenalties() error {
+	if s.state.Version() == clparams.Phase0Version {
+		return s.processRewardsAndPenaltiesPhase0()
+	}
+	// no rewards at the end of the genesis epoch, they are for the work done in the previous epoch
+	if s.state.Epoch() == s.beaconConfig.GenesisEpoch {
+		return nil
+	}
+	previousEpoch := s.state.PreviousEpoch()
+	validators := s.state.Validators()
+	totalActiveBalance, err := s.state.GetTotalActiveBalance()
+	if err != nil {
+		return err
+	}
+	activeIncrements := totalActiveBalance / s.beaconConfig.EffectiveBalanceIncrement
+	leaking := s.state.InactivityLeaking()
+
+	weights := []uint64{s.beaconConfig.TimelySourceWeight, s.beaconConfig.TimelyTargetWeight, s.beaconConfig.TimelyHeadWeight}
+	flagIndices := []uint8{s.beaconConfig.TimelySourceFlagIndex, s.beaconConfig.TimelyTargetFlagIndex, s.beaconConfig.TimelyHeadFlagIndex}
+	participating := make([][]bool, len(flagIndices))
+	participatingIncrements := make([]uint64, len(flagIndices))
+	for i, flagIndex := range flagIndices {
+		indices, err := s.state.GetUnslashedParticipatingIndices(int(flagIndex), previousEpoch)
+		if err != nil {
+			return err
+		}
+		participatingBalance, err := s.state.GetTotalBalance(indices)
+		if err != nil {
+			return err
+		}
+		participatingIncrements[i] = participatingBalance / s.beaconConfig.EffectiveBalanceIncrement
+		participating[i] = make([]bool, len(validators))
+		for _, index := range indices {
+			participating[i][index] = true
+		}
+	}
+
+	inactivityPenaltyQuotient := s.beaconConfig.InactivityPenaltyQuotientBellatrix
+	if s.state.Version() == clparams.AltairVersion {
+		inactivityPenaltyQuotient = s.beaconConfig.InactivityPenaltyQuotientAltair
+	}
+	targetParticipating := participating[1] // flagIndices[1] is the target
+	// the deltas are applied one flag after the other, like the spec does, as penalties stop at a zero balance. The
+	// last ones are the inactivity penalties.
+	deltas := make([][]int64, len(flagIndices)+1)
+	for i := range deltas {
+		deltas[i] = make([]int64, len(validators))
+	}
+	inactivityDeltas := deltas[len(flagIndices)]
+	for index, validator := range validators {
+		if !s.state.IsValidatorEligible(uint64(index)) {
+			continue
+		}
+		baseReward := s.state.BaseReward(validator.EffectiveBalance, totalActiveBalance)
+		for i, flagIndex := range flagIndices {
+			if participating[i][index] {
+				if !leaking {
+					deltas[i][index] = int64(baseReward * weights[i] * participatingIncrements[i] / (activeIncrements * s.beaconConfig.WeightDenominator))
+				}
+			} else if flagIndex != s.beaconConfig.TimelyHeadFlagIndex {
+				deltas[i][index] = -int64(baseReward * weights[i] / s.beaconConfig.WeightDenominator)
+			}
+		}
+		if !targetParticipating[index] {
+			inactivityDeltas[index] = -int64(validator.EffectiveBalance * s.state.InactivityScoreAt(index) / (s.beaconConfig.InactivityScoreBias * inactivityPenaltyQuotient))
+		}
+	}
+	for _, flagDeltas := range deltas {
+		if err := s.state.ApplyDeltas(flagDeltas); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StateTransistor) processRewardsAndPenaltiesPhase0() error {
+	return fmt.Errorf("process_rewards_and_penalties: phase0 is not supported")
+}
@@ -0,0 +1,67 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func getTestStateRewards(finalizedEpoch uint64) *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	b.SetSlot(2 * cfg.SlotsPerEpoch)
+	b.SetFinalizedCheckpoint(&cltypes.Checkpoint{Epoch: finalizedEpoch})
+	all := cltypes.ParticipationFlags(0).Add(0).Add(1).Add(2)
+	// all the flags, source and target, none, and all the flags but slashed
+	participation := []cltypes.ParticipationFlags{all, cltypes.ParticipationFlags(0).Add(0).Add(1), 0, all}
+	for i := range participation {
+		b.AddValidator(&cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+			Slashed:           i == 3,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance)
+		b.AddInactivityScore(0)
+	}
+	b.SetPreviousEpochParticipation(participation)
+	return b
+}
+
+func TestProcessRewardsAndPenalties(t *testing.T) {
+	b := getTestStateRewards(0)
+	// base reward is 32 * (1e9 * 64 / isqrt(128e9)) = 5724320, 1/64 of it per unit of weight
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessRewardsAndPenalties())
+	require.Equal(t, []uint64{
+		32e9 + 626097 + 1162752 + 313048, // rewards of source, target and head, weighted by their participation
+		32e9 + 626097 + 1162752,          // no penalty for a missed head
+		32e9 - 1252195 - 2325505,         // penalties of source and target
+		32e9 - 1252195 - 2325505,         // slashed validators don't participate
+	}, b.Balances())
+}
+
+func TestProcessRewardsAndPenaltiesInactivityLeak(t *testing.T) {
+	b := getTestStateRewards(0)
+	b.SetSlot(10 * clparams.MainnetBeaconConfig.SlotsPerEpoch)
+	b.SetInactivityScoreAt(2, 10)
+	require.True(t, b.InactivityLeaking())
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessRewardsAndPenalties())
+	require.Equal(t, []uint64{
+		32e9,                            // no rewards while leaking
+		32e9,                            // and no penalty for a missed head
+		32e9 - 1252195 - 2325505 - 1589, // 32e9 * 10 / (4 * 3 * 2^24) of inactivity penalty
+		32e9 - 1252195 - 2325505,
+	}, b.Balances())
+}
+
+func TestProcessRewardsAndPenaltiesGenesis(t *testing.T) {
+	b := getTestStateRewards(0)
+	b.SetSlot(0)
+	require.NoError(t, transition.New(b, &clparams.MainnetBeaconConfig, nil, false).ProcessRewardsAndPenalties())
+	require.Equal(t, []uint64{32e9, 32e9, 32e9, 32e9}, b.Balances())
+}
@@ -3,13 +3,17 @@ package transition
 import (
 	"fmt"
 
-	"github.com/Giulio2002/bls"
+	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 )
 
+// transitionState applies a signed block to the state (state_transition): the slots up to the block are processed,
+// then the proposer signature is verified, the block is processed and the resulting state root is checked.
 func (s *StateTransistor) transitionState(block *cltypes.SignedBeaconBlock) error {
 	currentBlock := block.Block
-	s.processSlots(currentBlock.Slot)
+	if err := s.processSlots(currentBlock.Slot); err != nil {
+		return err
+	}
 	if !s.noValidate {
 		valid, err := s.verifyBlockSignature(block)
 		if err != nil {
@@ -19,7 +23,9 @@ func (s *StateTransistor) transitionState(block *cltypes.SignedBeaconBlock) erro
 			return fmt.Errorf("block not valid")
 		}
 	}
-	// TODO add logic to process block and update state.
+	if err := s.ProcessBlock(currentBlock); err != nil {
+		return err
+	}
 	if !s.noValidate {
 		expectedStateRoot, err := s.state.HashSSZ()
 		if err != nil {
@@ -66,19 +72,57 @@ func (s *StateTransistor) processSlots(slot uint64) error {
 		if err != nil {
 			return fmt.Errorf("unable to process slot transition: %v", err)
 		}
-		// TODO: add logic to process epoch updates.
+		if (stateSlot+1)%s.beaconConfig.SlotsPerEpoch == 0 {
+			if err := s.ProcessEpoch(); err != nil {
+				return fmt.Errorf("unable to process the epoch: %v", err)
+			}
+		}
 		stateSlot += 1
 		s.state.SetSlot(stateSlot)
+		if stateSlot%s.beaconConfig.SlotsPerEpoch == 0 {
+			if err := s.upgradeState(stateSlot / s.beaconConfig.SlotsPerEpoch); err != nil {
+				return fmt.Errorf("unable to upgrade the state: %v", err)
+			}
+			// the proposers of the epoch are looked up by the blocks and the duties from now on
+			if _, err := s.state.EpochProposers(s.state.Epoch()); err != nil {
+				return fmt.Errorf("unable to compute the proposers: %v", err)
+			}
+			if _, err := s.state.EpochProposers(s.state.Epoch() + 1); err != nil {
+				return fmt.Errorf("unable to compute the proposers: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// upgradeState upgrades the state at the first slot of a fork epoch, forks scheduled at the same epoch are applied in
+// order.
+func (s *StateTransistor) upgradeState(epoch uint64) error {
+	if s.state.Version() == clparams.Phase0Version && epoch == s.beaconConfig.AltairForkEpoch {
+		if err := s.state.UpgradeToAltair(); err != nil {
+			return err
+		}
+	}
+	if s.state.Version() == clparams.AltairVersion && epoch == s.beaconConfig.BellatrixForkEpoch {
+		if err := s.state.UpgradeToBellatrix(); err != nil {
+			return err
+		}
+	}
+	if s.state.Version() == clparams.BellatrixVersion && epoch == s.beaconConfig.CapellaForkEpoch {
+		if err := s.state.UpgradeToCapella(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// verifyBlockSignature verifies the signature of the proposer, over the block in the proposer domain at the epoch of the
+// block.
 func (s *StateTransistor) verifyBlockSignature(block *cltypes.SignedBeaconBlock) (bool, error) {
-	proposer := s.state.ValidatorAt(int(block.Block.ProposerIndex))
-	sigRoot, err := block.Block.Body.HashSSZ()
+	sigRoot, err := s.state.ComputeSigningRoot(block.Block, s.beaconConfig.DomainBeaconProposer, s.state.GetEpochAtSlot(block.Block.Slot))
 	if err != nil {
 		return false, err
 	}
 	sig := block.Signature
-	return bls.Verify(sig[:], sigRoot[:], proposer.PublicKey[:])
+	return verifyValidatorSignature(s.state, sig[:], sigRoot[:], block.Block.ProposerIndex)
 }
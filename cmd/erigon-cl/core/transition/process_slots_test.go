@@ -6,6 +6,8 @@ import (
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blst "github.com/supranational/blst/bindings/go"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
@@ -15,6 +17,8 @@ import (
 var (
 	testBeaconConfig = &clparams.BeaconChainConfig{
 		SlotsPerHistoricalRoot: 8192,
+		SlotsPerEpoch:          32,
+		DomainBeaconProposer:   clparams.MainnetBeaconConfig.DomainBeaconProposer,
 	}
 	stateHash0 = "0617561534e6a3ff7fed7f007ae993035b81110f7b7def36e14ff8cbb8034581"
 	blockHash0 = "ea9052349d8c9107c4fa04f9a5c5033f6afc7f02e857359c25b426d9948aaaca"
@@ -28,18 +32,17 @@ var (
 	stateHash44 = "81954d95a6452e516c076f3254424cac99ae3e8c757f33d8aacb97fd8ef02864"
 	blockHash44 = "3ff92b54cba8067044f6b6ca0a69c7a6344154de2a38742e7a89b1057877fffa"
 
-	testPubKey    = [48]byte{152, 250, 212, 202, 139, 152, 8, 45, 224, 234, 128, 186, 142, 67, 172, 78, 124, 69, 173, 39, 48, 98, 75, 146, 244, 131, 55, 15, 138, 170, 246, 81, 77, 88, 244, 114, 1, 47, 123, 123, 55, 174, 58, 139, 90, 249, 237, 19}
-	testSignature = [96]byte{141, 154, 97, 227, 33, 202, 245, 163, 252, 75, 124, 240, 197, 188, 117, 88, 146, 35, 171, 19, 247, 222, 208, 78, 160, 135, 37, 246, 251, 1, 170, 160, 121, 83, 11, 146, 207, 100, 82, 101, 243, 131, 17, 142, 201, 231, 170, 116, 5, 62, 23, 250, 166, 178, 120, 64, 214, 70, 122, 203, 30, 156, 153, 12, 69, 247, 193, 208, 73, 4, 245, 70, 97, 67, 42, 217, 30, 98, 191, 21, 190, 47, 168, 218, 36, 52, 59, 238, 88, 14, 100, 105, 16, 231, 157, 172}
+	// testSecretKey is the key of testValidator, which proposes the test blocks (see signTestBlock).
+	testSecretKey = blst.KeyGen(make([]byte, 32))
+	testPubKey    = func() (pubKey [48]byte) {
+		copy(pubKey[:], new(blst.P1Affine).From(testSecretKey).Compress())
+		return
+	}()
 	badSignature  = [96]byte{182, 82, 244, 116, 233, 59, 56, 251, 52, 194, 122, 255, 161, 96, 204, 165, 43, 97, 19, 48, 130, 187, 17, 200, 223, 62, 114, 194, 225, 19, 242, 174, 224, 24, 188, 83, 118, 45, 23, 192, 205, 200, 47, 165, 212, 35, 193, 189, 10, 165, 161, 72, 81, 250, 195, 186, 174, 197, 26, 208, 165, 254, 31, 214, 135, 140, 129, 47, 211, 59, 87, 136, 55, 242, 93, 149, 128, 30, 84, 126, 182, 157, 70, 90, 68, 113, 7, 92, 70, 230, 164, 54, 120, 16, 180, 151}
 	testValidator = &cltypes.Validator{
 		PublicKey: testPubKey,
 	}
-	testStateRoot = [32]byte{243, 188, 193, 154, 58, 176, 139, 235, 38, 219, 21, 196, 194, 30, 119, 102, 233, 246, 197, 228, 242, 75, 89, 204, 102, 150, 82, 251, 101, 124, 98, 78}
-
-	stateHashValidator0 = "8545b3e58b298e4b847eba8d6bbc7264df21e36f75924e970aea914bbee82cd9"
-	blockHashValidator0 = "f5b74f03650fb65362badf85660ab2f6e92e8df10af9a981a2b5a4df1d9f2479"
-	stateHashValidator1 = "5e8ab9f10c2afd511b3fd1079b40686716c449b7195e27ecdf393db2d36467e8"
-	blockHashValidator1 = "f5b74f03650fb65362badf85660ab2f6e92e8df10af9a981a2b5a4df1d9f2479"
+	testStateRoot = [32]byte{145, 206, 231, 208, 130, 132, 9, 196, 200, 40, 19, 102, 191, 61, 36, 10, 22, 70, 160, 236, 2, 117, 192, 111, 156, 36, 80, 3, 142, 244, 236, 34}
 )
 
 func getEmptyBlock() *cltypes.SignedBeaconBlock {
@@ -55,8 +58,19 @@ func getEmptyBlock() *cltypes.SignedBeaconBlock {
 	}
 }
 
+// signTestBlock signs block with testSecretKey, in the proposer domain of the test states.
+func signTestBlock(block *cltypes.SignedBeaconBlock) *cltypes.SignedBeaconBlock {
+	b := getTestBeaconState()
+	signingRoot, err := b.ComputeSigningRoot(block.Block, testBeaconConfig.DomainBeaconProposer, b.GetEpochAtSlot(block.Block.Slot))
+	if err != nil {
+		panic(err)
+	}
+	copy(block.Signature[:], new(blst.P2Affine).Sign(testSecretKey, signingRoot[:], []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")).Compress())
+	return block
+}
+
 func getTestBeaconBlock() *cltypes.SignedBeaconBlock {
-	return &cltypes.SignedBeaconBlock{
+	return signTestBlock(&cltypes.SignedBeaconBlock{
 		Block: &cltypes.BeaconBlock{
 			ProposerIndex: 0,
 			Body: &cltypes.BeaconBody{
@@ -68,8 +82,7 @@ func getTestBeaconBlock() *cltypes.SignedBeaconBlock {
 			},
 			StateRoot: testStateRoot,
 		},
-		Signature: testSignature,
-	}
+	})
 }
 
 func getTestBeaconState() *state.BeaconState {
@@ -261,6 +274,11 @@ func TestProcessSlots(t *testing.T) {
 func TestVerifyBlockSignature(t *testing.T) {
 	badSigBlock := getTestBeaconBlock()
 	badSigBlock.Signature = badSignature
+	// signed over the root of the body, without the proposer domain
+	bodySigBlock := getTestBeaconBlock()
+	bodyRoot, err := bodySigBlock.Block.Body.HashSSZ()
+	require.NoError(t, err)
+	copy(bodySigBlock.Signature[:], new(blst.P2Affine).Sign(testSecretKey, bodyRoot[:], []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")).Compress())
 	testCases := []struct {
 		description string
 		state       *state.BeaconState
@@ -288,6 +306,13 @@ func TestVerifyBlockSignature(t *testing.T) {
 			wantErr:     false,
 			wantValid:   false,
 		},
+		{
+			description: "failure_body_signature",
+			state:       getTestBeaconStateWithValidator(),
+			block:       bodySigBlock,
+			wantErr:     false,
+			wantValid:   false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -313,56 +338,171 @@ func TestVerifyBlockSignature(t *testing.T) {
 	}
 }
 
+// transitionTestValidators is the number of validators of the transitionState tests, they make committees of 2.
+const transitionTestValidators = 64
+
+// transitionTestKeys are the secret keys of the validators of getTransitionTestState.
+var transitionTestKeys = func() []*blst.SecretKey {
+	keys := make([]*blst.SecretKey, transitionTestValidators)
+	for i := range keys {
+		ikm := make([]byte, 32)
+		ikm[0] = byte(i + 1)
+		keys[i] = blst.KeyGen(ikm)
+	}
+	return keys
+}()
+
+// signTransitionTest returns the signature of root by the validator at index of getTransitionTestState.
+func signTransitionTest(index uint64, root libcommon.Hash) (signature [96]byte) {
+	copy(signature[:], new(blst.P2Affine).Sign(transitionTestKeys[index], root[:], []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")).Compress())
+	return
+}
+
+// getTransitionTestState returns an altair state at genesis, of transitionTestValidators validators which also sit in
+// the sync committees.
+func getTransitionTestState() *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for _, key := range transitionTestKeys {
+		validator := &cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		}
+		copy(validator.PublicKey[:], new(blst.P1Affine).From(key).Compress())
+		b.AddValidator(validator)
+		b.AddBalance(cfg.MaxEffectiveBalance)
+		b.AddInactivityScore(0)
+		b.AddPreviousEpochParticipationFlags(0)
+		b.AddCurrentEpochParticipationFlags(0)
+	}
+	syncCommittee := &cltypes.SyncCommittee{PubKeys: make([][48]byte, cfg.SyncCommitteeSize)}
+	for i := range syncCommittee.PubKeys {
+		syncCommittee.PubKeys[i] = b.ValidatorAt(i % transitionTestValidators).PublicKey
+	}
+	b.SetCurrentSyncCommittee(syncCommittee)
+	b.SetNextSyncCommittee(syncCommittee)
+	return b
+}
+
+// signTransitionTestBlock signs block on behalf of its proposer, b is the state before the block.
+func signTransitionTestBlock(t *testing.T, b *state.BeaconState, block *cltypes.BeaconBlock) *cltypes.SignedBeaconBlock {
+	signingRoot, err := b.ComputeSigningRoot(block, clparams.MainnetBeaconConfig.DomainBeaconProposer, b.GetEpochAtSlot(block.Slot))
+	require.NoError(t, err)
+	return &cltypes.SignedBeaconBlock{Block: block, Signature: signTransitionTest(block.ProposerIndex, signingRoot)}
+}
+
+// getTransitionTestBlock returns the block of slot on top of b, whose attestation is voted by the committee of the
+// previous slot but its last member. The attesters are returned with it.
+func getTransitionTestBlock(t *testing.T, b *state.BeaconState, slot uint64) (*cltypes.SignedBeaconBlock, []uint64) {
+	cfg := clparams.MainnetBeaconConfig
+	pre := b.Copy()
+	require.NoError(t, New(pre, &cfg, nil, true).processSlots(slot))
+	epoch := pre.Epoch()
+	proposer, err := pre.GetBeaconProposerIndex()
+	require.NoError(t, err)
+	parentRoot, err := pre.LatestBlockHeader().HashSSZ()
+	require.NoError(t, err)
+	domain, err := pre.GetDomain(cfg.DomainRandao, epoch)
+	require.NoError(t, err)
+	randaoRoot, err := computeSigningRootEpoch(epoch, domain)
+	require.NoError(t, err)
+
+	headRoot, err := pre.GetBlockRootAtSlot(slot - 1)
+	require.NoError(t, err)
+	targetRoot, err := pre.GetBlockRoot(epoch)
+	require.NoError(t, err)
+	data := &cltypes.AttestationData{
+		Slot:            slot - 1,
+		BeaconBlockHash: headRoot,
+		Source:          pre.CurrentJustifiedCheckpoint(),
+		Target:          &cltypes.Checkpoint{Epoch: epoch, Root: targetRoot},
+	}
+	committee, err := pre.GetBeaconCommittee(data.Slot, data.Index)
+	require.NoError(t, err)
+	attesters := committee[:len(committee)-1]
+	bits := make([]byte, len(committee)/8+1)
+	for i := range attesters {
+		bits[i/8] |= 1 << (i % 8)
+	}
+	bits[len(committee)/8] |= 1 << (len(committee) % 8)
+	attestationRoot, err := pre.ComputeSigningRoot(data, cfg.DomainBeaconAttester, epoch)
+	require.NoError(t, err)
+	aggregate := new(blst.P2Aggregate)
+	for _, index := range attesters {
+		signature := signTransitionTest(index, attestationRoot)
+		require.True(t, aggregate.AggregateCompressed([][]byte{signature[:]}, false))
+	}
+	attestation := &cltypes.Attestation{AggregationBits: bits, Data: data}
+	copy(attestation.Signature[:], aggregate.ToAffine().Compress())
+
+	block := &cltypes.BeaconBlock{
+		Slot:          slot,
+		ProposerIndex: proposer,
+		ParentRoot:    parentRoot,
+		Body: &cltypes.BeaconBody{
+			RandaoReveal:  signTransitionTest(proposer, randaoRoot),
+			Eth1Data:      &cltypes.Eth1Data{},
+			Graffiti:      make([]byte, 32),
+			Attestations:  []*cltypes.Attestation{attestation},
+			SyncAggregate: &cltypes.SyncAggregate{SyncCommiteeSignature: infiniteSignature},
+			Version:       clparams.AltairVersion,
+		},
+	}
+	post := b.Copy()
+	require.NoError(t, New(post, &cfg, nil, true).transitionState(&cltypes.SignedBeaconBlock{Block: block}))
+	block.StateRoot, err = post.HashSSZ()
+	require.NoError(t, err)
+	return signTransitionTestBlock(t, b, block), attesters
+}
+
 func TestTransitionState(t *testing.T) {
-	slot2 := getTestBeaconBlock()
-	slot2.Block.Slot = 2
-	badSigBlock := getTestBeaconBlock()
+	cfg := clparams.MainnetBeaconConfig
+	genesis := getTransitionTestState()
+	block, attesters := getTransitionTestBlock(t, genesis, 2)
+	pastBlock := *block.Block
+	pastBlock.Slot = 0
+	emptyBlock := getEmptyBlock()
+	emptyBlock.Block.Slot = 2
+	badSigBlock := *block
 	badSigBlock.Signature = badSignature
-	badStateRootBlock := getTestBeaconBlock()
-	badStateRootBlock.Block.StateRoot = libcommon.Hash{}
+	badStateRootBlock := *block.Block
+	badStateRootBlock.StateRoot = libcommon.Hash{}
 	testCases := []struct {
-		description   string
-		prevState     *state.BeaconState
-		block         *cltypes.SignedBeaconBlock
-		expectedState *state.BeaconState
-		wantErr       bool
+		description string
+		block       *cltypes.SignedBeaconBlock
+		wantErr     bool
 	}{
 		{
-			description: "success_2_slots",
-			prevState:   getTestBeaconStateWithValidator(),
-			block:       slot2,
-			expectedState: prepareNextBeaconState(
-				t,
-				[]uint64{0, 1},
-				[]string{stateHashValidator0, stateHashValidator1},
-				[]string{blockHashValidator0, blockHashValidator1},
-				getTestBeaconStateWithValidator(),
-			),
-			wantErr: false,
+			description: "success",
+			block:       block,
+			wantErr:     false,
+		},
+		{
+			description: "error_slot_not_after_state",
+			block:       signTransitionTestBlock(t, genesis, &pastBlock),
+			wantErr:     true,
 		},
 		{
 			description: "error_empty_block_body",
-			prevState:   getTestBeaconStateWithValidator(),
-			block:       getEmptyBlock(),
+			block:       emptyBlock,
 			wantErr:     true,
 		},
 		{
 			description: "error_bad_signature",
-			prevState:   getTestBeaconStateWithValidator(),
-			block:       badSigBlock,
+			block:       &badSigBlock,
 			wantErr:     true,
 		},
 		{
 			description: "error_bad_state_root",
-			prevState:   getTestBeaconStateWithValidator(),
-			block:       badStateRootBlock,
+			block:       signTransitionTestBlock(t, genesis, &badStateRootBlock),
 			wantErr:     true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			s := New(tc.prevState, testBeaconConfig, nil, false)
+			s := New(genesis.Copy(), &cfg, nil, false)
 			err := s.transitionState(tc.block)
 			if tc.wantErr {
 				if err == nil {
@@ -372,10 +512,41 @@ func TestTransitionState(t *testing.T) {
 			}
 
 			// Non-failure case.
-			if err != nil {
-				t.Errorf("unexpected error: %v", err)
+			require.NoError(t, err)
+			require.Equal(t, tc.block.Block.Slot, s.state.Slot())
+			// the attesters of the block earned the timely source, target and head flags, the others none
+			flags := cltypes.ParticipationFlags(0).Add(int(cfg.TimelySourceFlagIndex)).Add(int(cfg.TimelyTargetFlagIndex)).Add(int(cfg.TimelyHeadFlagIndex))
+			want := make(cltypes.ParticipationFlagsList, transitionTestValidators)
+			for _, index := range attesters {
+				want[index] = flags
 			}
-			assertStateEq(t, tc.prevState, tc.expectedState)
+			require.Equal(t, want, s.state.CurrentEpochParticipation())
 		})
 	}
 }
+
+func TestProcessSlotsEpoch(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for i := 0; i < 4; i++ {
+		b.AddValidator(&cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance)
+		b.AddInactivityScore(0)
+		b.AddPreviousEpochParticipationFlags(0)
+		b.AddCurrentEpochParticipationFlags(cltypes.ParticipationFlags(0).Add(int(cfg.TimelyTargetFlagIndex)))
+	}
+	b.SetSlot(cfg.SlotsPerEpoch - 2)
+
+	// the epoch is processed at its last slot, before the state moves to the next one
+	s := New(b, &cfg, nil, false)
+	require.NoError(t, s.processSlots(cfg.SlotsPerEpoch-1))
+	require.Equal(t, cltypes.ParticipationFlagsList{0, 0, 0, 0}, b.PreviousEpochParticipation())
+	require.NoError(t, s.processSlots(cfg.SlotsPerEpoch))
+	target := cltypes.ParticipationFlags(0).Add(int(cfg.TimelyTargetFlagIndex))
+	require.Equal(t, cltypes.ParticipationFlagsList{target, target, target, target}, b.PreviousEpochParticipation())
+	require.Equal(t, cltypes.ParticipationFlagsList{0, 0, 0, 0}, b.CurrentEpochParticipation())
+}
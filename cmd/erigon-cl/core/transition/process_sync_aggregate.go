@@ -3,16 +3,14 @@ package transition
 import (
 	"errors"
 
-	"github.com/Giulio2002/bls"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
-	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cl/utils"
 )
 
 // processSyncAggregate applies all the logic in the spec function `process_sync_aggregate` except
-// verifying the BLS signatures. It returns the modified beacons state and the list of validators'
-// public keys that voted, for future signature verification.
-func (s *StateTransistor) processSyncAggregate(sync *cltypes.SyncAggregate) ([][]byte, error) {
+// verifying the BLS signatures. It returns the modified beacons state and the list of indices of the
+// validators that voted, for future signature verification.
+func (s *StateTransistor) processSyncAggregate(sync *cltypes.SyncAggregate) ([]uint64, error) {
 	currentSyncCommittee := s.state.CurrentSyncCommittee()
 
 	if currentSyncCommittee == nil {
@@ -22,7 +20,7 @@ func (s *StateTransistor) processSyncAggregate(sync *cltypes.SyncAggregate) ([][
 	if len(sync.SyncCommiteeBits)*8 > len(committeeKeys) {
 		return nil, errors.New("bits length exceeds committee length")
 	}
-	votedKeys := make([][]byte, 0, len(committeeKeys))
+	votedIndices := make([]uint64, 0, len(committeeKeys))
 
 	proposerReward, participantReward, err := s.state.SyncRewards()
 	if err != nil {
@@ -44,7 +42,7 @@ func (s *StateTransistor) processSyncAggregate(sync *cltypes.SyncAggregate) ([][
 		bit := i % 8
 		currByte := sync.SyncCommiteeBits[i/8]
 		if (currByte & (1 << bit)) > 0 {
-			votedKeys = append(votedKeys, committeeKeys[i][:])
+			votedIndices = append(votedIndices, vIdx)
 			s.state.IncreaseBalance(int(vIdx), participantReward)
 			earnedProposerReward += proposerReward
 		} else {
@@ -52,27 +50,39 @@ func (s *StateTransistor) processSyncAggregate(sync *cltypes.SyncAggregate) ([][
 		}
 	}
 	s.state.IncreaseBalance(int(proposerIndex), earnedProposerReward)
-	return votedKeys, err
+	return votedIndices, err
 }
 
+// infiniteSignature is the compressed point at infinity of G2.
+var infiniteSignature = [96]byte{0xc0}
+
+// ProcessSyncAggregate rewards the participants of the sync aggregate of the block and its proposer, penalizes the
+// absent members of the sync committee, and verifies the aggregate signature of the participants.
 func (s *StateTransistor) ProcessSyncAggregate(sync *cltypes.SyncAggregate) error {
-	votedKeys, err := s.processSyncAggregate(sync)
+	votedIndices, err := s.processSyncAggregate(sync)
 	if err != nil {
 		return err
 	}
 	if !s.noValidate {
 		previousSlot := s.state.PreviousSlot()
 
-		domain, err := fork.Domain(s.state.Fork(), previousSlot/s.beaconConfig.SlotsPerEpoch, s.beaconConfig.DomainSyncCommittee, s.state.GenesisValidatorsRoot())
+		domain, err := s.state.GetDomain(s.beaconConfig.DomainSyncCommittee, s.state.GetEpochAtSlot(previousSlot))
 		if err != nil {
-			return nil
+			return err
 		}
 		blockRoot, err := s.state.GetBlockRootAtSlot(previousSlot)
 		if err != nil {
 			return err
 		}
 		msg := utils.Keccak256(blockRoot[:], domain)
-		isValid, err := bls.VerifyAggregate(sync.SyncCommiteeSignature[:], msg[:], votedKeys)
+		// Without participants, the signature is the point at infinity (eth_fast_aggregate_verify).
+		if len(votedIndices) == 0 {
+			if sync.SyncCommiteeSignature != infiniteSignature {
+				return errors.New("ProcessSyncAggregate: expected the infinite signature without participants")
+			}
+			return nil
+		}
+		isValid, err := verifyAggregateSignature(s.state, sync.SyncCommiteeSignature[:], msg[:], votedIndices)
 		if err != nil {
 			return err
 		}
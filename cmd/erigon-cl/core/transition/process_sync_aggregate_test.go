@@ -0,0 +1,39 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+func TestProcessSyncAggregate(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getSyncState(t)
+	b.SetCurrentSyncCommittee(b.NextSyncCommittee())
+	proposerReward, participantReward, err := b.SyncRewards()
+	require.NoError(t, err)
+	require.NotZero(t, participantReward)
+	proposer, err := b.GetBeaconProposerIndex()
+	require.NoError(t, err)
+
+	// The first half of the committee participates.
+	var bits [64]byte
+	for i := range bits[:len(bits)/2] {
+		bits[i] = 0xff
+	}
+	want := append([]uint64{}, b.Balances()...)
+	for i := 0; i < int(cfg.SyncCommitteeSize); i++ {
+		if i < int(cfg.SyncCommitteeSize)/2 {
+			want[i%syncTestValidators] += participantReward
+			want[proposer] += proposerReward
+		} else {
+			want[i%syncTestValidators] -= participantReward
+		}
+	}
+	require.NoError(t, transition.New(b, &cfg, nil, true).ProcessSyncAggregate(&cltypes.SyncAggregate{SyncCommiteeBits: bits}))
+	require.Equal(t, want, b.Balances())
+}
@@ -0,0 +1,16 @@
+package transition
+
+// ProcessSyncCommitteeUpdate rotates the sync committees at the end of a sync committee period: the next committee
+// becomes the current one and the committee of the following period is sampled.
+func (s *StateTransistor) ProcessSyncCommitteeUpdate() error {
+	if (s.state.Epoch()+1)%s.beaconConfig.EpochsPerSyncCommitteePeriod != 0 {
+		return nil
+	}
+	nextSyncCommittee, err := s.state.ComputeNextSyncCommittee()
+	if err != nil {
+		return err
+	}
+	s.state.SetCurrentSyncCommittee(s.state.NextSyncCommittee())
+	s.state.SetNextSyncCommittee(nextSyncCommittee)
+	return nil
+}
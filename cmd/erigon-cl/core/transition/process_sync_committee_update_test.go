@@ -0,0 +1,80 @@
+package transition_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+)
+
+// syncTestValidators is the number of validators of the sync committee tests, each sits several times in a committee.
+const syncTestValidators = 64
+
+// getSyncState returns an altair state with syncTestValidators validators of valid public keys, at the last epoch of
+// the first sync committee period.
+func getSyncState(t *testing.T) *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.AltairVersion)
+	for i := 0; i < syncTestValidators; i++ {
+		ikm := make([]byte, 32)
+		ikm[0] = byte(i + 1)
+		validator := &cltypes.Validator{
+			EffectiveBalance:  cfg.MaxEffectiveBalance,
+			ExitEpoch:         cfg.FarFutureEpoch,
+			WithdrawableEpoch: cfg.FarFutureEpoch,
+		}
+		copy(validator.PublicKey[:], new(blst.P1Affine).From(blst.KeyGen(ikm)).Compress())
+		b.AddValidator(validator)
+		b.AddBalance(cfg.MaxEffectiveBalance)
+	}
+	b.SetSlot((cfg.EpochsPerSyncCommitteePeriod - 1) * cfg.SlotsPerEpoch)
+	next := &cltypes.SyncCommittee{PubKeys: make([][48]byte, cfg.SyncCommitteeSize)}
+	for i := range next.PubKeys {
+		next.PubKeys[i] = b.ValidatorAt(i % syncTestValidators).PublicKey
+	}
+	b.SetCurrentSyncCommittee(&cltypes.SyncCommittee{PubKeys: make([][48]byte, cfg.SyncCommitteeSize)})
+	b.SetNextSyncCommittee(next)
+	return b
+}
+
+func TestProcessSyncCommitteeUpdate(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getSyncState(t)
+	next := b.NextSyncCommittee()
+
+	// Not the last epoch of the period.
+	b.SetSlot(b.Slot() - cfg.SlotsPerEpoch)
+	require.NoError(t, transition.New(b, &cfg, nil, true).ProcessSyncCommitteeUpdate())
+	require.Same(t, next, b.NextSyncCommittee())
+
+	// Only the validators active at the next epoch are sampled: exit the even ones. (Validators without effective
+	// balance could still be sampled, by a zero random byte.)
+	for i := 0; i < syncTestValidators; i += 2 {
+		validator := *b.ValidatorAt(i)
+		validator.ExitEpoch = 0
+		b.SetValidatorAt(i, &validator)
+	}
+	b.SetSlot(b.Slot() + cfg.SlotsPerEpoch)
+	require.NoError(t, transition.New(b, &cfg, nil, true).ProcessSyncCommitteeUpdate())
+	require.Same(t, next, b.CurrentSyncCommittee())
+	computed := b.NextSyncCommittee()
+	require.Len(t, computed.PubKeys, int(cfg.SyncCommitteeSize))
+
+	indices, err := b.ComputeNextSyncCommitteeIndices()
+	require.NoError(t, err)
+	require.Len(t, indices, int(cfg.SyncCommitteeSize))
+	keys := make([][]byte, len(indices))
+	for i, index := range indices {
+		require.Equal(t, uint64(1), index%2)
+		require.Equal(t, b.ValidatorAt(int(index)).PublicKey, computed.PubKeys[i])
+		keys[i] = computed.PubKeys[i][:]
+	}
+	aggregate := new(blst.P1Aggregate)
+	require.True(t, aggregate.AggregateCompressed(keys, true))
+	require.Equal(t, aggregate.ToAffine().Compress(), computed.AggregatePublicKey[:])
+}
@@ -0,0 +1,38 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// ProcessWithdrawals checks that the withdrawals of the execution payload are the expected ones (Capella), debits
+// them, and moves the withdrawal sweep forward. The state is left untouched when a withdrawal is not the expected one.
+func (s *StateTransistor) ProcessWithdrawals(withdrawals types.Withdrawals) error {
+	expected := s.state.ExpectedWithdrawals()
+	if len(withdrawals) != len(expected) {
+		return fmt.Errorf("ProcessWithdrawals: %d withdrawals, expected %d", len(withdrawals), len(expected))
+	}
+	for i, withdrawal := range withdrawals {
+		if withdrawal == nil || *withdrawal != *expected[i] {
+			return fmt.Errorf("ProcessWithdrawals: withdrawal %d is %+v, expected %+v", i, withdrawal, expected[i])
+		}
+	}
+	for _, withdrawal := range expected {
+		s.state.DecreaseBalance(withdrawal.Validator, withdrawal.Amount)
+	}
+	validatorCount := uint64(len(s.state.Validators()))
+	if validatorCount == 0 {
+		return nil
+	}
+	if len(expected) > 0 {
+		s.state.SetNextWithdrawalIndex(expected[len(expected)-1].Index + 1)
+	}
+	// a full payload stops the sweep after its last withdrawal, otherwise the sweep went over its whole bound
+	if uint64(len(expected)) == s.beaconConfig.MaxWithdrawalsPerPayload {
+		s.state.SetNextWithdrawalValidatorIndex((expected[len(expected)-1].Validator + 1) % validatorCount)
+	} else {
+		s.state.SetNextWithdrawalValidatorIndex((s.state.NextWithdrawalValidatorIndex() + s.beaconConfig.MaxValidatorsPerWithdrawalsSweep) % validatorCount)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package transition_test
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/transition"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// getWithdrawalState returns a capella state of n validators with execution credentials, each with 1 gwei above the
+// maximum effective balance to withdraw.
+func getWithdrawalState(n int) *state.BeaconState {
+	cfg := clparams.MainnetBeaconConfig
+	b := state.GetEmptyBeaconStateWithVersion(clparams.CapellaVersion)
+	for i := 0; i < n; i++ {
+		var credentials libcommon.Hash
+		credentials[0] = cfg.ETH1AddressWithdrawalPrefixByte
+		credentials[31] = byte(i)
+		b.AddValidator(&cltypes.Validator{
+			WithdrawalCredentials: credentials,
+			EffectiveBalance:      cfg.MaxEffectiveBalance,
+			ExitEpoch:             cfg.FarFutureEpoch,
+			WithdrawableEpoch:     cfg.FarFutureEpoch,
+		})
+		b.AddBalance(cfg.MaxEffectiveBalance + 1)
+	}
+	return b
+}
+
+func TestProcessWithdrawals(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getWithdrawalState(4)
+	b.SetNextWithdrawalIndex(10)
+	b.SetNextWithdrawalValidatorIndex(2)
+	expected := b.ExpectedWithdrawals()
+	require.Len(t, expected, 4)
+
+	require.Error(t, transition.New(b, &cfg, nil, true).ProcessWithdrawals(expected[:3]))
+	wrong := make(types.Withdrawals, len(expected))
+	for i := range expected {
+		withdrawal := *expected[i]
+		wrong[i] = &withdrawal
+	}
+	wrong[1].Amount++
+	require.Error(t, transition.New(b, &cfg, nil, true).ProcessWithdrawals(wrong))
+	// nothing was debited before the wrong withdrawal was found
+	for _, balance := range b.Balances() {
+		require.Equal(t, cfg.MaxEffectiveBalance+1, balance)
+	}
+	require.Equal(t, uint64(10), b.NextWithdrawalIndex())
+
+	require.NoError(t, transition.New(b, &cfg, nil, true).ProcessWithdrawals(expected))
+	for _, balance := range b.Balances() {
+		require.Equal(t, cfg.MaxEffectiveBalance, balance)
+	}
+	require.Equal(t, uint64(14), b.NextWithdrawalIndex())
+	// the sweep went over all the validators, from the cursor
+	require.Equal(t, (2+cfg.MaxValidatorsPerWithdrawalsSweep)%4, b.NextWithdrawalValidatorIndex())
+	require.Empty(t, b.ExpectedWithdrawals())
+}
+
+func TestProcessWithdrawalsFullPayload(t *testing.T) {
+	cfg := clparams.MainnetBeaconConfig
+	b := getWithdrawalState(int(cfg.MaxWithdrawalsPerPayload) + 4)
+	b.SetNextWithdrawalValidatorIndex(2)
+	expected := b.ExpectedWithdrawals()
+	require.Len(t, expected, int(cfg.MaxWithdrawalsPerPayload))
+
+	require.NoError(t, transition.New(b, &cfg, nil, true).ProcessWithdrawals(expected))
+	// the next sweep starts after the last withdrawal
+	require.Equal(t, uint64(2+cfg.MaxWithdrawalsPerPayload), b.NextWithdrawalValidatorIndex())
+	require.Equal(t, cfg.MaxWithdrawalsPerPayload, b.NextWithdrawalIndex())
+	require.Len(t, b.ExpectedWithdrawals(), 4)
+}
@@ -6,8 +6,6 @@ import (
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
-	"github.com/Giulio2002/bls"
-	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/utils"
 )
@@ -65,7 +63,7 @@ func (s *StateTransistor) ProcessRandao(randao [96]byte) error {
 		return fmt.Errorf("unable to get proposer index: %v", err)
 	}
 	proposer := s.state.ValidatorAt(int(propInd))
-	domain, err := s.state.GetDomain(clparams.MainnetBeaconConfig.DomainRandao, epoch)
+	domain, err := s.state.GetDomain(s.beaconConfig.DomainRandao, epoch)
 	if err != nil {
 		return fmt.Errorf("unable to get domain: %v", err)
 	}
@@ -73,7 +71,7 @@ func (s *StateTransistor) ProcessRandao(randao [96]byte) error {
 	if err != nil {
 		return fmt.Errorf("unable to compute signing root: %v", err)
 	}
-	valid, err := bls.Verify(randao[:], signingRoot[:], proposer.PublicKey[:])
+	valid, err := verifyValidatorSignature(s.state, randao[:], signingRoot[:], propInd)
 	if err != nil {
 		return fmt.Errorf("unable to verify public key: %x, with signing root: %x, and signature: %x, %v", proposer.PublicKey[:], signingRoot[:], randao[:], err)
 	}
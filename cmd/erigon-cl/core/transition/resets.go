@@ -1,5 +1,6 @@
 package transition
 
+// ProcessEth1DataReset clears the eth1 data votes at the end of a voting period.
 func (s *StateTransistor) ProcessEth1DataReset() {
 	nextEpoch := s.state.Epoch() + 1
 	if nextEpoch%s.beaconConfig.EpochsPerEth1VotingPeriod == 0 {
@@ -7,11 +8,12 @@ func (s *StateTransistor) ProcessEth1DataReset() {
 	}
 }
 
+// ProcessSlashingsReset clears the slashed balances of the next epoch in the slashings vector.
 func (s *StateTransistor) ProcessSlashingsReset() {
-	s.state.SetSlashingSegmentAt(int(s.state.Epoch()+1)%int(s.beaconConfig.EpochsPerSlashingsVector), 0)
-
+	s.state.SetSlashingSegmentAt(int((s.state.Epoch()+1)%s.beaconConfig.EpochsPerSlashingsVector), 0)
 }
 
+// ProcessRandaoMixesReset carries the randao mix of the current epoch over to the next one.
 func (s *StateTransistor) ProcessRandaoMixesReset() {
 	currentEpoch := s.state.Epoch()
 	nextEpoch := s.state.Epoch() + 1
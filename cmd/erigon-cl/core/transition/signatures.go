@@ -0,0 +1,38 @@
+package transition
+
+import (
+	"github.com/Giulio2002/bls"
+
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// verifyValidatorSignature verifies signature of msg by the validator at index, using the public key
+// cached in the state instead of decompressing it again.
+func verifyValidatorSignature(st *state.BeaconState, signature, msg []byte, index uint64) (bool, error) {
+	sig, err := bls.NewSignatureFromBytes(signature)
+	if err != nil {
+		return false, err
+	}
+	publicKey, err := st.ValidatorPublicKey(index)
+	if err != nil {
+		return false, err
+	}
+	return sig.Verify(msg, publicKey), nil
+}
+
+// verifyAggregateSignature verifies an aggregate signature of msg by the validators at indices.
+func verifyAggregateSignature(st *state.BeaconState, signature, msg []byte, indices []uint64) (bool, error) {
+	sig, err := bls.NewSignatureFromBytes(signature)
+	if err != nil {
+		return false, err
+	}
+	publicKeys := make([]*bls.PublicKey, 0, len(indices))
+	for _, index := range indices {
+		publicKey, err := st.ValidatorPublicKey(index)
+		if err != nil {
+			return false, err
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+	return sig.VerifyAggregate(msg, publicKeys), nil
+}
@@ -0,0 +1,273 @@
+package transition
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/spectest"
+)
+
+// runSpecTests runs the cases of runner, the test is skipped when the vectors weren't downloaded.
+func runSpecTests(t *testing.T, runner string, run func(t *testing.T, c spectest.Case)) {
+	cases, err := spectest.Cases(runner)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skip("no consensus-spec-tests, did you run make cl-spec-tests?")
+	}
+	require.NoError(t, err)
+	for _, c := range cases {
+		c := c
+		t.Run(c.String(), func(t *testing.T) {
+			if c.Preset != "mainnet" {
+				t.Skip("only the mainnet preset is configured")
+			}
+			version, ok := c.Version()
+			if !ok {
+				t.Skip("unsupported fork")
+			}
+			if version == clparams.Phase0Version {
+				t.Skip("phase0 states don't decode")
+			}
+			run(t, c)
+		})
+	}
+}
+
+// runSpecTestCase applies apply to the pre state of c, and checks it results in the post state, or fails when the
+// case has none.
+func runSpecTestCase(t *testing.T, c spectest.Case, apply func(s *StateTransistor) error) {
+	cfg := clparams.MainnetBeaconConfig
+	meta, err := c.Meta()
+	require.NoError(t, err)
+	pre, err := c.ReadState("pre", &cfg)
+	require.NoError(t, err)
+	s := New(pre, &cfg, nil, meta.BLSSetting == spectest.BLSIgnored)
+
+	err = apply(s)
+	if !c.Exists("post") {
+		require.Error(t, err)
+		return
+	}
+	require.NoError(t, err)
+	post, err := c.ReadState("post", &cfg)
+	require.NoError(t, err)
+	expected, err := post.HashSSZ()
+	require.NoError(t, err)
+	root, err := s.state.HashSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expected, root, "state root")
+}
+
+// specEngine is the execution engine of the execution_payload cases, it answers execution.yaml.
+type specEngine struct {
+	valid bool
+}
+
+func (e specEngine) NewPayload(*cltypes.Eth1Block) (bool, error) {
+	return e.valid, nil
+}
+
+var specOperations = map[string]func(c spectest.Case, s *StateTransistor) error{
+	"attestation": func(c spectest.Case, s *StateTransistor) error {
+		attestation := &cltypes.Attestation{}
+		if err := c.DecodeSSZ("attestation", attestation); err != nil {
+			return err
+		}
+		return s.ProcessAttestations([]*cltypes.Attestation{attestation})
+	},
+	"attester_slashing": func(c spectest.Case, s *StateTransistor) error {
+		slashing := &cltypes.AttesterSlashing{}
+		if err := c.DecodeSSZ("attester_slashing", slashing); err != nil {
+			return err
+		}
+		return s.ProcessAttesterSlashing(slashing)
+	},
+	"proposer_slashing": func(c spectest.Case, s *StateTransistor) error {
+		slashing := &cltypes.ProposerSlashing{}
+		if err := c.DecodeSSZ("proposer_slashing", slashing); err != nil {
+			return err
+		}
+		return s.ProcessProposerSlashing(slashing)
+	},
+	"block_header": func(c spectest.Case, s *StateTransistor) error {
+		buf, err := c.ReadSSZ("block")
+		if err != nil {
+			return err
+		}
+		block := &cltypes.BeaconBlock{}
+		if err := block.DecodeSSZ(buf, s.state.Version()); err != nil {
+			return err
+		}
+		return s.ProcessBlockHeader(block)
+	},
+	"deposit": func(c spectest.Case, s *StateTransistor) error {
+		deposit := &cltypes.Deposit{}
+		if err := c.DecodeSSZ("deposit", deposit); err != nil {
+			return err
+		}
+		return s.ProcessDeposit(deposit)
+	},
+	"voluntary_exit": func(c spectest.Case, s *StateTransistor) error {
+		exit := &cltypes.SignedVoluntaryExit{}
+		if err := c.DecodeSSZ("voluntary_exit", exit); err != nil {
+			return err
+		}
+		return s.ProcessVoluntaryExit(exit)
+	},
+	"sync_aggregate": func(c spectest.Case, s *StateTransistor) error {
+		aggregate := &cltypes.SyncAggregate{}
+		if err := c.DecodeSSZ("sync_aggregate", aggregate); err != nil {
+			return err
+		}
+		return s.ProcessSyncAggregate(aggregate)
+	},
+	"execution_payload": func(c spectest.Case, s *StateTransistor) error {
+		var execution struct {
+			Valid bool `yaml:"execution_valid"`
+		}
+		if err := c.ReadYAML("execution", &execution); err != nil {
+			return err
+		}
+		payload, err := readSpecPayload(c, s)
+		if err != nil {
+			return err
+		}
+		s.SetExecutionEngine(specEngine{valid: execution.Valid})
+		return s.ProcessExecutionPayload(payload)
+	},
+	"withdrawals": func(c spectest.Case, s *StateTransistor) error {
+		payload, err := readSpecPayload(c, s)
+		if err != nil {
+			return err
+		}
+		return s.ProcessWithdrawals(payload.Withdrawals())
+	},
+	"bls_to_execution_change": func(c spectest.Case, s *StateTransistor) error {
+		change := &cltypes.SignedBLSToExecutionChange{}
+		if err := c.DecodeSSZ("address_change", change); err != nil {
+			return err
+		}
+		return s.ProcessBlsToExecutionChange(change)
+	},
+}
+
+func readSpecPayload(c spectest.Case, s *StateTransistor) (*cltypes.Eth1Block, error) {
+	buf, err := c.ReadSSZ("execution_payload")
+	if err != nil {
+		return nil, err
+	}
+	payload := &cltypes.Eth1Block{}
+	if err := payload.DecodeSSZ(buf, s.state.Version()); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func TestSpecOperations(t *testing.T) {
+	runSpecTests(t, "operations", func(t *testing.T, c spectest.Case) {
+		operation, ok := specOperations[c.Handler]
+		if !ok {
+			t.Skipf("unknown operation %s", c.Handler)
+		}
+		runSpecTestCase(t, c, func(s *StateTransistor) error {
+			return operation(c, s)
+		})
+	})
+}
+
+var specEpochProcessing = map[string]func(s *StateTransistor) error{
+	"justification_and_finalization": func(s *StateTransistor) error { return s.ProcessJustificationAndFinalization() },
+	"inactivity_updates":             func(s *StateTransistor) error { return s.ProcessInactivityUpdates() },
+	"rewards_and_penalties":          func(s *StateTransistor) error { return s.ProcessRewardsAndPenalties() },
+	"registry_updates":               func(s *StateTransistor) error { return s.ProcessRegistryUpdates() },
+	"historical_roots_update":        func(s *StateTransistor) error { return s.ProcessHistoricalRootsUpdate() },
+	"historical_summaries_update":    func(s *StateTransistor) error { return s.ProcessHistoricalSummariesUpdate() },
+	"slashings":                      func(s *StateTransistor) error { return s.ProcessSlashings() },
+	"sync_committee_updates":         func(s *StateTransistor) error { return s.ProcessSyncCommitteeUpdate() },
+	"eth1_data_reset": func(s *StateTransistor) error {
+		s.ProcessEth1DataReset()
+		return nil
+	},
+	"effective_balance_updates": func(s *StateTransistor) error {
+		s.ProcessEffectiveBalanceUpdates()
+		return nil
+	},
+	"slashings_reset": func(s *StateTransistor) error {
+		s.ProcessSlashingsReset()
+		return nil
+	},
+	"randao_mixes_reset": func(s *StateTransistor) error {
+		s.ProcessRandaoMixesReset()
+		return nil
+	},
+	"participation_flag_updates": func(s *StateTransistor) error {
+		s.ProcessParticipationFlagUpdates()
+		return nil
+	},
+}
+
+func TestSpecEpochProcessing(t *testing.T) {
+	runSpecTests(t, "epoch_processing", func(t *testing.T, c spectest.Case) {
+		process, ok := specEpochProcessing[c.Handler]
+		if !ok {
+			t.Skipf("unknown epoch processing %s", c.Handler)
+		}
+		runSpecTestCase(t, c, process)
+	})
+}
+
+// applySpecBlocks applies the blocks of c with the state transition function.
+func applySpecBlocks(c spectest.Case, s *StateTransistor) error {
+	meta, err := c.Meta()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < meta.BlocksCount; i++ {
+		buf, err := c.ReadSSZ(fmt.Sprintf("blocks_%d", i))
+		if err != nil {
+			return err
+		}
+		block := &cltypes.SignedBeaconBlock{}
+		if err := block.DecodeSSZWithVersion(buf, int(s.state.Version())); err != nil {
+			return err
+		}
+		if err := s.transitionState(block); err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func TestSpecSanity(t *testing.T) {
+	runSpecTests(t, "sanity", func(t *testing.T, c spectest.Case) {
+		switch c.Handler {
+		case "blocks":
+			runSpecTestCase(t, c, func(s *StateTransistor) error {
+				return applySpecBlocks(c, s)
+			})
+		case "slots":
+			runSpecTestCase(t, c, func(s *StateTransistor) error {
+				var slots uint64
+				if err := c.ReadYAML("slots", &slots); err != nil {
+					return err
+				}
+				return s.processSlots(s.state.Slot() + slots)
+			})
+		default:
+			t.Skipf("unknown sanity handler %s", c.Handler)
+		}
+	})
+}
+
+func TestSpecFinality(t *testing.T) {
+	runSpecTests(t, "finality", func(t *testing.T, c spectest.Case) {
+		runSpecTestCase(t, c, func(s *StateTransistor) error {
+			return applySpecBlocks(c, s)
+		})
+	})
+}
@@ -11,6 +11,8 @@ type StateTransistor struct {
 	beaconConfig  *clparams.BeaconChainConfig
 	genesisConfig *clparams.GenesisConfig
 	noValidate    bool // Whether we want to do cryptography checks.
+
+	executionEngine ExecutionEngine // nil - the execution payloads are not validated
 }
 
 func New(state *state.BeaconState, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, noValidate bool) *StateTransistor {
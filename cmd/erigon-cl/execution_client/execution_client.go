@@ -9,9 +9,12 @@ import (
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/execution"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cmd/erigon-el/eth1"
@@ -21,6 +24,7 @@ import (
 // ExecutionClient interfaces with the Erigon-EL component consensus side.
 type ExecutionClient struct {
 	client execution.ExecutionClient
+	direct *eth1.Eth1Execution // set when Erigon-EL runs in the same process, client is nil then
 	ctx    context.Context
 }
 
@@ -54,8 +58,20 @@ func NewExecutionClient(ctx context.Context, addr string) (*ExecutionClient, err
 	}, nil
 }
 
-// InsertHeaders will send block bodies to execution client
+// NewExecutionClientDirect calls Erigon-EL running in the same process: payloads are handed over as they are, without
+// going through gRPC and its serialization. Erigon-EL in another process is reached with NewExecutionClient instead.
+func NewExecutionClientDirect(ctx context.Context, direct *eth1.Eth1Execution) *ExecutionClient {
+	return &ExecutionClient{
+		direct: direct,
+		ctx:    ctx,
+	}
+}
+
+// InsertHeaders will send block headers to execution client
 func (ec *ExecutionClient) InsertHeaders(headers []*types.Header) error {
+	if ec.direct != nil {
+		return ec.direct.WriteHeaders(ec.ctx, headers)
+	}
 	grpcHeaders := make([]*execution.Header, 0, len(headers))
 	for _, header := range headers {
 		grpcHeaders = append(grpcHeaders, eth1.HeaderToHeaderRPC(header))
@@ -69,12 +85,25 @@ func (ec *ExecutionClient) InsertBodies(bodies []*types.RawBody, blockHashes []l
 	if len(bodies) != len(blockHashes) || len(bodies) != len(blockNumbers) {
 		return fmt.Errorf("unbalanced inputs")
 	}
+	if ec.direct != nil {
+		return ec.direct.WriteBodies(ec.ctx, bodies, blockHashes, blockNumbers)
+	}
 	grpcBodies := make([]*execution.BlockBody, 0, len(bodies))
 	for i, body := range bodies {
+		withdrawals := make([]*types2.Withdrawal, 0, len(body.Withdrawals))
+		for _, withdrawal := range body.Withdrawals {
+			withdrawals = append(withdrawals, &types2.Withdrawal{
+				Index:          withdrawal.Index,
+				ValidatorIndex: withdrawal.Validator,
+				Address:        gointerfaces.ConvertAddressToH160(withdrawal.Address),
+				Amount:         withdrawal.Amount,
+			})
+		}
 		grpcBodies = append(grpcBodies, &execution.BlockBody{
 			BlockHash:    gointerfaces.ConvertHashToH256(blockHashes[i]),
 			BlockNumber:  blockNumbers[i],
 			Transactions: body.Transactions,
+			Withdrawals:  withdrawals,
 		})
 	}
 	_, err := ec.client.InsertBodies(ec.ctx, &execution.InsertBodiesRequest{Bodies: grpcBodies})
@@ -102,13 +131,45 @@ func (ec *ExecutionClient) InsertExecutionPayloads(payloads []*cltypes.Eth1Block
 }
 
 func (ec *ExecutionClient) ForkChoiceUpdate(headHash libcommon.Hash) (*execution.ForkChoiceReceipt, error) {
+	if ec.direct != nil {
+		return ec.direct.UpdateForkChoice(ec.ctx, gointerfaces.ConvertHashToH256(headHash))
+	}
 	return ec.client.UpdateForkChoice(ec.ctx, gointerfaces.ConvertHashToH256(headHash))
 }
 
 func (ec *ExecutionClient) IsCanonical(hash libcommon.Hash) (bool, error) {
-	resp, err := ec.client.IsCanonicalHash(ec.ctx, gointerfaces.ConvertHashToH256(hash))
+	var resp *execution.IsCanonicalResponse
+	var err error
+	if ec.direct != nil {
+		resp, err = ec.direct.IsCanonicalHash(ec.ctx, gointerfaces.ConvertHashToH256(hash))
+	} else {
+		resp, err = ec.client.IsCanonicalHash(ec.ctx, gointerfaces.ConvertHashToH256(hash))
+	}
 	if err != nil {
 		return false, err
 	}
 	return resp.Canonical, nil
 }
+
+// NewPayload inserts payload and has the execution client validate the chain up to it, it is the ExecutionEngine of
+// the state transition. The payload is accepted optimistically when the execution client can't tell yet: the chain is
+// missing segments, or is too far from its head, or the execution client does not validate chains.
+func (ec *ExecutionClient) NewPayload(payload *cltypes.Eth1Block) (bool, error) {
+	if err := ec.InsertExecutionPayloads([]*cltypes.Eth1Block{payload}); err != nil {
+		return false, err
+	}
+	var receipt *execution.ValidationReceipt
+	var err error
+	if ec.direct != nil {
+		receipt, err = ec.direct.ValidateChain(ec.ctx, gointerfaces.ConvertHashToH256(payload.Header.BlockHashCL))
+	} else {
+		receipt, err = ec.client.ValidateChain(ec.ctx, gointerfaces.ConvertHashToH256(payload.Header.BlockHashCL))
+	}
+	if status.Code(err) == codes.Unimplemented {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return receipt.ValidationStatus != execution.ValidationStatus_InvalidChain, nil
+}
@@ -0,0 +1,42 @@
+package execution_client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-el/eth1"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestInsertExecutionPayloadsDirect(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	ec := NewExecutionClientDirect(ctx, eth1.NewEth1Execution(db, nil, nil))
+
+	header := &types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(0), GasLimit: 30_000_000}
+	header.BlockHashCL = header.Hash()
+	withdrawal := &types.Withdrawal{Index: 1, Validator: 2, Address: libcommon.HexToAddress("0x03"), Amount: 4}
+	payload := &cltypes.Eth1Block{
+		Header: header,
+		Body:   &types.RawBody{Transactions: [][]byte{}, Withdrawals: []*types.Withdrawal{withdrawal}},
+	}
+	batch := NewInsertBatch(ec)
+	require.NoError(t, batch.WriteExecutionPayload(payload))
+	require.NoError(t, batch.Flush())
+
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		require.Equal(t, header.Hash(), rawdb.ReadHeader(tx, header.Hash(), 10).Hash())
+		body, _, _ := rawdb.ReadBody(tx, header.Hash(), 10)
+		require.NotNil(t, body)
+		require.Equal(t, []*types.Withdrawal{withdrawal}, body.Withdrawals)
+		return nil
+	}))
+}
@@ -0,0 +1,190 @@
+package lookahead
+
+import (
+	"fmt"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/common/memlimit"
+)
+
+// dutiesCacheSize is the number of (epoch, dependent root) pairs kept, enough for a couple of forks per epoch.
+const dutiesCacheSize = 16
+
+// dutiesMemory is the memory of the cached duties: the committees of an epoch hold its whole active set.
+var dutiesMemory = memlimit.Default.Account("cl_duties")
+
+// Duties are the proposers and the attestation committees of an epoch.
+type Duties struct {
+	Epoch             uint64
+	DependentRoot     libcommon.Hash // Root of the last block the shuffling depends on.
+	CommitteesPerSlot uint64
+	Proposers         []uint64   // By slot in the epoch.
+	Committees        [][]uint64 // By slot in the epoch * CommitteesPerSlot + committee index.
+	// Whether the proposers were predicted from the state of the previous epoch: they depend on the effective
+	// balances, which the epoch transition may still change.
+	Speculative bool
+}
+
+// Proposer returns the proposer of slot.
+func (d *Duties) Proposer(slot uint64) uint64 {
+	return d.Proposers[slot%uint64(len(d.Proposers))]
+}
+
+// Committee returns the attesters of committee index at slot, nil if there is no such committee.
+func (d *Duties) Committee(slot, index uint64) []uint64 {
+	if index >= d.CommitteesPerSlot {
+		return nil
+	}
+	return d.Committees[(slot%uint64(len(d.Proposers)))*d.CommitteesPerSlot+index]
+}
+
+func (d *Duties) size() int {
+	size := 8*len(d.Proposers) + 24*len(d.Committees)
+	for _, committee := range d.Committees {
+		size += 8 * len(committee)
+	}
+	return size
+}
+
+type dutiesKey struct {
+	epoch         uint64
+	dependentRoot libcommon.Hash
+}
+
+// Lookahead computes the duties of the next epoch as soon as the root they depend on is known, so that duties
+// requests and subnet subscriptions at the epoch boundary find them ready instead of shuffling the validator set.
+type Lookahead struct {
+	beaconCfg *clparams.BeaconChainConfig
+	duties    *lru.Cache // dutiesKey -> *Duties
+
+	mu      sync.Mutex
+	pending map[dutiesKey]chan struct{} // Closed when the computation of the duties completes.
+}
+
+func New(beaconCfg *clparams.BeaconChainConfig) *Lookahead {
+	duties, _ := lru.New(dutiesCacheSize)
+	return &Lookahead{
+		beaconCfg: beaconCfg,
+		duties:    duties,
+		pending:   make(map[dutiesKey]chan struct{}),
+	}
+}
+
+// Prepare computes in the background the duties of the epoch following the snapshot.
+func (l *Lookahead) Prepare(snapshot *state.Snapshot) {
+	go func() {
+		if _, err := l.Duties(snapshot.State(), snapshot.Epoch()+1); err != nil {
+			log.Warn("[Lookahead] Could not compute duties", "epoch", snapshot.Epoch()+1, "err", err)
+		}
+	}()
+}
+
+// Duties returns the duties of epoch on the chain of s, which must be in epoch or the one before and must not be
+// modified meanwhile (use a snapshot). Duties computed for the chain are reused, proposers predicted from the
+// previous epoch are recomputed once s reaches epoch.
+func (l *Lookahead) Duties(s state.ReadOnlyBeaconState, epoch uint64) (*Duties, error) {
+	if epoch != s.Epoch() && epoch != s.Epoch()+1 {
+		return nil, fmt.Errorf("duties of epoch %d cannot be computed from a state at epoch %d", epoch, s.Epoch())
+	}
+	dependentRoot, err := l.dependentRoot(s, epoch)
+	if err != nil {
+		return nil, err
+	}
+	key := dutiesKey{epoch: epoch, dependentRoot: dependentRoot}
+	for {
+		if cached, ok := l.duties.Get(key); ok {
+			duties := cached.(*Duties)
+			if !duties.Speculative || epoch != s.Epoch() {
+				return duties, nil
+			}
+			confirmed := *duties
+			if confirmed.Proposers, err = s.EpochProposers(epoch); err != nil {
+				return nil, err
+			}
+			confirmed.Speculative = false
+			l.add(key, &confirmed)
+			return &confirmed, nil
+		}
+		l.mu.Lock()
+		done, ok := l.pending[key]
+		if !ok {
+			done = make(chan struct{})
+			l.pending[key] = done
+		}
+		l.mu.Unlock()
+		if ok {
+			// Someone else is computing them, wait and look again.
+			<-done
+			continue
+		}
+		duties, err := l.compute(s, epoch, dependentRoot)
+		if err == nil {
+			l.add(key, duties)
+		}
+		l.mu.Lock()
+		delete(l.pending, key)
+		l.mu.Unlock()
+		close(done)
+		return duties, err
+	}
+}
+
+func (l *Lookahead) add(key dutiesKey, duties *Duties) {
+	l.duties.Add(key, duties)
+	l.account()
+}
+
+// Purge drops the cached duties, to free memory: they are computed again when requested.
+func (l *Lookahead) Purge() {
+	l.duties.Purge()
+	l.account()
+}
+
+func (l *Lookahead) account() {
+	size := 0
+	for _, key := range l.duties.Keys() {
+		if cached, ok := l.duties.Peek(key); ok {
+			size += cached.(*Duties).size()
+		}
+	}
+	dutiesMemory.Set(int64(size))
+}
+
+// dependentRoot returns the root of the last block of the epoch two before epoch, whose randao mix seeds its
+// shuffling. Epochs seeded by the genesis mix depend on no block.
+func (l *Lookahead) dependentRoot(s state.ReadOnlyBeaconState, epoch uint64) (libcommon.Hash, error) {
+	if epoch <= l.beaconCfg.MinSeedLookahead {
+		return libcommon.Hash{}, nil
+	}
+	return s.GetBlockRootAtSlot((epoch-l.beaconCfg.MinSeedLookahead)*l.beaconCfg.SlotsPerEpoch - 1)
+}
+
+func (l *Lookahead) compute(s state.ReadOnlyBeaconState, epoch uint64, dependentRoot libcommon.Hash) (*Duties, error) {
+	proposers, err := s.EpochProposers(epoch)
+	if err != nil {
+		return nil, err
+	}
+	committeesPerSlot := s.CommitteeCount(epoch)
+	count := committeesPerSlot * l.beaconCfg.SlotsPerEpoch
+	// The committees partition the shuffled active set: shuffle it once and slice it.
+	shuffled := s.ShuffledActiveIndices(epoch)
+	total := uint64(len(shuffled))
+	committees := make([][]uint64, count)
+	for i := range committees {
+		committees[i] = shuffled[total*uint64(i)/count : total*uint64(i+1)/count]
+	}
+	return &Duties{
+		Epoch:             epoch,
+		DependentRoot:     dependentRoot,
+		CommitteesPerSlot: committeesPerSlot,
+		Proposers:         proposers,
+		Committees:        committees,
+		Speculative:       epoch != s.Epoch(),
+	}, nil
+}
@@ -0,0 +1,81 @@
+package lookahead_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/lookahead"
+	"github.com/ledgerwatch/erigon/common/memlimit"
+)
+
+func getTestState(t *testing.T, epoch uint64) *state.BeaconState {
+	cfg := &clparams.MainnetBeaconConfig
+	validators := make([]*cltypes.Validator, 128)
+	for i := range validators {
+		validators[i] = &cltypes.Validator{
+			ExitEpoch:        cfg.FarFutureEpoch,
+			EffectiveBalance: cfg.MaxEffectiveBalance,
+		}
+	}
+	b := state.GetEmptyBeaconState()
+	b.SetValidators(validators)
+	b.SetSlot(epoch*cfg.SlotsPerEpoch + 3)
+	b.SetBlockRootAt(int(epoch*cfg.SlotsPerEpoch-1), [32]byte{byte(epoch)})
+	return b
+}
+
+func TestDuties(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	b := getTestState(t, 4)
+	l := lookahead.New(cfg)
+
+	next, err := l.Duties(b, 5)
+	require.NoError(t, err)
+	require.True(t, next.Speculative)
+	require.Equal(t, [32]byte{4}, [32]byte(next.DependentRoot))
+	for slot := uint64(5 * cfg.SlotsPerEpoch); slot < 6*cfg.SlotsPerEpoch; slot++ {
+		committee, err := b.GetBeaconCommittee(slot, 0)
+		require.NoError(t, err)
+		require.Equal(t, committee, next.Committee(slot, 0))
+		proposer, err := b.ComputeBeaconProposerIndexAtSlot(slot)
+		require.NoError(t, err)
+		require.Equal(t, proposer, next.Proposer(slot))
+	}
+	require.Nil(t, next.Committee(5*cfg.SlotsPerEpoch, next.CommitteesPerSlot))
+
+	again, err := l.Duties(b, 5)
+	require.NoError(t, err)
+	require.Same(t, next, again)
+
+	// Once the state reaches the epoch, the committees are reused and the proposers confirmed.
+	b.SetSlot(5 * cfg.SlotsPerEpoch)
+	confirmed, err := l.Duties(b, 5)
+	require.NoError(t, err)
+	require.False(t, confirmed.Speculative)
+	require.Equal(t, next.Committees, confirmed.Committees)
+
+	_, err = l.Duties(b, 7)
+	require.Error(t, err)
+}
+
+func TestPurge(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	b := getTestState(t, 4)
+	l := lookahead.New(cfg)
+	account := memlimit.Default.Account("cl_duties")
+
+	next, err := l.Duties(b, 5)
+	require.NoError(t, err)
+	require.Positive(t, account.Bytes())
+
+	l.Purge()
+	require.Zero(t, account.Bytes())
+	again, err := l.Duties(b, 5)
+	require.NoError(t, err)
+	require.NotSame(t, next, again)
+	require.Equal(t, next.Committees, again.Committees)
+}
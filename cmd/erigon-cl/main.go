@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	sentinelrpc "github.com/ledgerwatch/erigon-lib/gointerfaces/sentinel"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
@@ -13,10 +16,12 @@ import (
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cl/rpc"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/beaconapi"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/execution_client"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/lookahead"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/network"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/stages"
 	lcCli "github.com/ledgerwatch/erigon/cmd/sentinel/cli"
@@ -24,6 +29,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/handshake"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/service"
+	"github.com/ledgerwatch/erigon/common/memlimit"
 	sentinelapp "github.com/ledgerwatch/erigon/turbo/app"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli/v2"
@@ -31,6 +37,7 @@ import (
 
 func main() {
 	app := sentinelapp.MakeApp(runConsensusLayerNode, flags.CLDefaultFlags)
+	app.Commands = append(app.Commands, &validatorCommand)
 	if err := app.Run(os.Args); err != nil {
 		_, printErr := fmt.Fprintln(os.Stderr, err)
 		if printErr != nil {
@@ -42,9 +49,13 @@ func main() {
 
 func runConsensusLayerNode(cliCtx *cli.Context) error {
 	ctx := context.Background()
-	cfg, _ := lcCli.SetupConsensusClientCfg(cliCtx)
+	cfg, err := lcCli.SetupConsensusClientCfg(cliCtx)
+	if err != nil {
+		return err
+	}
+	memlimit.Default.SetLimit(cfg.MemorySoftLimit)
+	memlimit.Default.Start(ctx, time.Second)
 	var db kv.RwDB
-	var err error
 	if cfg.Chaindata == "" {
 		db, err = mdbx.NewTemporaryMdbx()
 	} else {
@@ -58,12 +69,27 @@ func runConsensusLayerNode(cliCtx *cli.Context) error {
 		log.Error("Could load beacon data configuration", "err", err)
 		return err
 	}
-	// Fetch the checkpoint state.
-	cpState, err := getCheckpointState(ctx, db, cfg.BeaconCfg, cfg.GenesisCfg, cfg.CheckpointUri)
+	// Resume from the latest persisted state, or fetch the checkpoint state.
+	cpState, err := getPersistedState(ctx, db, cfg.BeaconCfg)
 	if err != nil {
-		log.Error("Could not get checkpoint", "err", err)
+		log.Error("Could not read persisted state", "err", err)
 		return err
 	}
+	if cpState == nil {
+		cpState, err = getCheckpointState(ctx, db, cfg.BeaconCfg, cfg.GenesisCfg, cfg.CheckpointUri, cfg.DepositSnapshotUri)
+		if err != nil {
+			log.Error("Could not get checkpoint", "err", err)
+			return err
+		}
+	}
+	if cfg.BeaconApiAddr != "" {
+		go func() {
+			log.Info("[Beacon API] Serving", "addr", cfg.BeaconApiAddr)
+			if err := http.ListenAndServe(cfg.BeaconApiAddr, beaconapi.NewHandler(db, cfg.BeaconCfg)); err != nil {
+				log.Error("[Beacon API] Stopped", "err", err)
+			}
+		}()
+	}
 	var executionClient *execution_client.ExecutionClient
 	if cfg.ELEnabled {
 		executionClient, err = execution_client.NewExecutionClient(ctx, "127.0.0.1:8989")
@@ -78,7 +104,7 @@ func runConsensusLayerNode(cliCtx *cli.Context) error {
 	// Start the sentinel service
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(cfg.LogLvl), log.StderrHandler))
 	log.Info("[Sentinel] running sentinel with configuration", "cfg", cfg)
-	s, err := startSentinel(cliCtx, *cfg, cpState)
+	s, err := startSentinel(cliCtx, *cfg, db, cpState)
 	if err != nil {
 		log.Error("Could not start sentinel service", "err", err)
 	}
@@ -91,8 +117,13 @@ func runConsensusLayerNode(cliCtx *cli.Context) error {
 
 	gossipManager := network.NewGossipReceiver(ctx, s)
 	gossipManager.AddReceiver(sentinelrpc.GossipType_BeaconBlockGossipType, downloader)
+	gossipManager.AddReceiver(sentinelrpc.GossipType_BeaconBlockGossipType, network.NewClockSkewReceiver(genesisCfg, beaconConfig))
 	go gossipManager.Loop()
-	stageloop, err := stages.NewConsensusStagedSync(ctx, db, downloader, bdownloader, genesisCfg, beaconConfig, cpState, nil, false, tmpdir, executionClient, cfg.BeaconDataCfg)
+	snapshots := state.NewSnapshots()
+	registerStateMetrics(snapshots)
+	duties := lookahead.New(beaconConfig)
+	memlimit.Default.OnPressure("cl_duties", duties.Purge)
+	stageloop, err := stages.NewConsensusStagedSync(ctx, db, downloader, bdownloader, genesisCfg, beaconConfig, cpState, snapshots, duties, nil, false, tmpdir, executionClient, cfg.BeaconDataCfg)
 	if err != nil {
 		return err
 	}
@@ -110,7 +141,23 @@ Loop:
 	return nil
 }
 
-func startSentinel(cliCtx *cli.Context, cfg lcCli.ConsensusClientCliCfg, beaconState *state.BeaconState) (sentinelrpc.SentinelClient, error) {
+// registerStateMetrics exports the snapshot of the head state, so that scrapes never wait for block processing
+func registerStateMetrics(snapshots *state.Snapshots) {
+	gauge := func(name string, value func(s state.ReadOnlyBeaconState) uint64) {
+		metrics.GetOrCreateGauge(name, func() float64 {
+			snapshot := snapshots.Head()
+			if snapshot == nil {
+				return 0
+			}
+			return float64(value(snapshot.State()))
+		})
+	}
+	gauge("beacon_state_slot", func(s state.ReadOnlyBeaconState) uint64 { return s.Slot() })
+	gauge("beacon_state_validators", func(s state.ReadOnlyBeaconState) uint64 { return uint64(len(s.Validators())) })
+	gauge("beacon_state_finalized_epoch", func(s state.ReadOnlyBeaconState) uint64 { return s.FinalizedCheckpoint().Epoch })
+}
+
+func startSentinel(cliCtx *cli.Context, cfg lcCli.ConsensusClientCliCfg, db kv.RoDB, beaconState *state.BeaconState) (sentinelrpc.SentinelClient, error) {
 	forkDigest, err := fork.ComputeForkDigest(cfg.BeaconCfg, cfg.GenesisCfg)
 	if err != nil {
 		return nil, err
@@ -123,7 +170,7 @@ func startSentinel(cliCtx *cli.Context, cfg lcCli.ConsensusClientCliCfg, beaconS
 		NetworkConfig: cfg.NetworkCfg,
 		BeaconConfig:  cfg.BeaconCfg,
 		NoDiscovery:   cfg.NoDiscovery,
-	}, nil, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
+	}, db, &service.ServerConfig{Network: cfg.ServerProtocol, Addr: cfg.ServerAddr}, nil, &cltypes.Status{
 		ForkDigest:     forkDigest,
 		FinalizedRoot:  beaconState.FinalizedCheckpoint().Root,
 		FinalizedEpoch: beaconState.FinalizedCheckpoint().Epoch,
@@ -138,7 +185,24 @@ func startSentinel(cliCtx *cli.Context, cfg lcCli.ConsensusClientCliCfg, beaconS
 	return s, nil
 }
 
-func getCheckpointState(ctx context.Context, db kv.RwDB, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, uri string) (*state.BeaconState, error) {
+// getPersistedState reads the state persisted by a previous run, nil if there is none.
+func getPersistedState(ctx context.Context, db kv.RoDB, beaconConfig *clparams.BeaconChainConfig) (*state.BeaconState, error) {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	persisted, err := rawdb.ReadLatestBeaconState(tx, beaconConfig)
+	if err != nil {
+		return nil, err
+	}
+	if persisted != nil {
+		log.Info("Resuming from persisted state", "slot", persisted.Slot())
+	}
+	return persisted, nil
+}
+
+func getCheckpointState(ctx context.Context, db kv.RwDB, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, uri, depositSnapshotUri string) (*state.BeaconState, error) {
 	state, err := core.RetrieveBeaconState(ctx, beaconConfig, genesisConfig, uri)
 	if err != nil {
 		log.Error("[Checkpoint Sync] Failed", "reason", err)
@@ -155,6 +219,22 @@ func getCheckpointState(ctx context.Context, db kv.RwDB, beaconConfig *clparams.
 		log.Error("[DB] Failed", "reason", err)
 		return nil, err
 	}
+	// Light clients can bootstrap from this node at the checkpoint.
+	if blockRoot, bootstrap, err := state.LightClientBootstrap(); err != nil {
+		log.Debug("[Checkpoint Sync] No light client bootstrap", "reason", err)
+	} else if err := rawdb.WriteLightClientBootstrap(tx, blockRoot, bootstrap, state.Version()); err != nil {
+		log.Error("[DB] Failed", "reason", err)
+		return nil, err
+	}
+	// Deposits are processed from the snapshot on, instead of from the logs of all the deposits.
+	if depositSnapshotUri != "" {
+		if snapshot, err := core.RetrieveDepositSnapshot(ctx, depositSnapshotUri, state); err != nil {
+			log.Warn("[Checkpoint Sync] No deposit snapshot", "reason", err)
+		} else if err := rawdb.WriteDepositSnapshot(tx, snapshot); err != nil {
+			log.Error("[DB] Failed", "reason", err)
+			return nil, err
+		}
+	}
 	log.Info("Checkpoint sync successful: hurray!")
 	return state, tx.Commit()
 }
@@ -0,0 +1,35 @@
+package network
+
+import (
+	"time"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
+	"github.com/ledgerwatch/erigon/common/clockskew"
+)
+
+// ClockSkewReceiver estimates the skew of the system clock from the arrival of gossiped blocks, which are published at
+// the start of their slot. Propagation delays blocks by a second or so, so the tolerance is a third of a slot: blocks
+// arriving past the attestation deadline, or before their slot, point at the local clock.
+type ClockSkewReceiver struct {
+	monitor        *clockskew.Monitor
+	genesisTime    uint64
+	secondsPerSlot uint64
+}
+
+func NewClockSkewReceiver(genesisCfg *clparams.GenesisConfig, beaconCfg *clparams.BeaconChainConfig) *ClockSkewReceiver {
+	return &ClockSkewReceiver{
+		monitor:        clockskew.New("beacon_blocks", time.Duration(beaconCfg.SecondsPerSlot)*time.Second/3),
+		genesisTime:    genesisCfg.GenesisTime,
+		secondsPerSlot: beaconCfg.SecondsPerSlot,
+	}
+}
+
+func (c *ClockSkewReceiver) ReceiveGossip(obj ssz_utils.Unmarshaler) {
+	signedBlock, ok := obj.(*cltypes.SignedBeaconBlock)
+	if !ok {
+		return
+	}
+	c.monitor.Observe(time.Unix(int64(c.genesisTime+signedBlock.Block.Slot*c.secondsPerSlot), 0))
+}
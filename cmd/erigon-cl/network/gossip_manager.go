@@ -7,10 +7,20 @@ import (
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
+	"github.com/ledgerwatch/erigon/cl/utils"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/seen"
 	"github.com/ledgerwatch/log/v3"
 )
 
+const (
+	// seenGossipCapacity is about the number of gossip messages received in a few epochs
+	seenGossipCapacity = 1 << 16
+	// seenAttestationsCapacity is about the number of aggregates received in a few epochs
+	seenAttestationsCapacity = 1 << 16
+	seenFalsePositiveRate    = 0.0001
+)
+
 type GossipReceiver interface {
 	ReceiveGossip(ssz_utils.Unmarshaler)
 }
@@ -20,13 +30,19 @@ type GossipManager struct {
 
 	receivers map[sentinel.GossipType][]GossipReceiver
 	sentinel  sentinel.SentinelClient
+
+	// seenGossip and seenAttestations drop the messages, and the aggregates, which were already received
+	seenGossip       *seen.Filter
+	seenAttestations *seen.Filter
 }
 
 func NewGossipReceiver(ctx context.Context, s sentinel.SentinelClient) *GossipManager {
 	return &GossipManager{
-		sentinel:  s,
-		receivers: make(map[sentinel.GossipType][]GossipReceiver),
-		ctx:       ctx,
+		sentinel:         s,
+		receivers:        make(map[sentinel.GossipType][]GossipReceiver),
+		ctx:              ctx,
+		seenGossip:       seen.Shared("beacon_gossip", seenGossipCapacity, seenFalsePositiveRate),
+		seenAttestations: seen.Shared("beacon_attestations", seenAttestationsCapacity, seenFalsePositiveRate),
 	}
 }
 
@@ -49,6 +65,11 @@ func (g *GossipManager) Loop() {
 			log.Warn("[Beacon Gossip] Failure in receiving", "err", err)
 			continue
 		}
+		// The ID of a message is the hash of its content, so the same message relayed by several peers is handled once
+		id := utils.Keccak256([]byte{byte(data.Type)}, data.Data)
+		if g.seenGossip.Seen(id[:]) {
+			continue
+		}
 		// Depending on the type of the received data, we create an instance of a specific type that implements the ObjectSSZ interface,
 		// then attempts to deserialize the received data into it.
 		//If the deserialization fails, an error is logged and the loop continues to the next iteration.
@@ -86,6 +107,15 @@ func (g *GossipManager) Loop() {
 				log.Warn("[Beacon Gossip] Failure in decoding proof", "err", err)
 				continue
 			}
+			// Aggregators of the same committee often gossip the same aggregate
+			root, err := object.(*cltypes.SignedAggregateAndProof).Message.Aggregate.HashSSZ()
+			if err != nil {
+				log.Warn("[Beacon Gossip] Failure in hashing aggregate", "err", err)
+				continue
+			}
+			if g.seenAttestations.Seen(root[:]) {
+				continue
+			}
 		}
 		// If we received a valid object give it to our receiver
 		if object != nil {
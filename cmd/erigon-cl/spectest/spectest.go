@@ -0,0 +1,193 @@
+// Package spectest loads the vectors of the consensus-spec-tests (https://github.com/ethereum/consensus-spec-tests),
+// which `make cl-spec-tests` downloads next to this file, for the tests of the consensus layer.
+package spectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// Forks are the state versions of the fork directories of the vectors.
+var Forks = map[string]clparams.StateVersion{
+	"phase0":    clparams.Phase0Version,
+	"altair":    clparams.AltairVersion,
+	"bellatrix": clparams.BellatrixVersion,
+	"capella":   clparams.CapellaVersion,
+}
+
+// BLS settings of meta.yaml: whether the signatures of a case are valid and must be checked.
+const (
+	BLSOptional = 0
+	BLSRequired = 1
+	BLSIgnored  = 2
+)
+
+// Meta is the meta.yaml of a case, absent for most of them.
+type Meta struct {
+	BLSSetting  int `yaml:"bls_setting"`
+	BlocksCount int `yaml:"blocks_count"`
+}
+
+// Case is a test case, at tests/<preset>/<fork>/<runner>/<handler>/<suite>/<name>.
+type Case struct {
+	Preset  string
+	Fork    string
+	Runner  string
+	Handler string
+	Suite   string
+	Name    string
+	Path    string
+}
+
+// Dir is where the vectors are extracted.
+func Dir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "consensus-spec-tests")
+}
+
+// Cases returns the cases of runner for every preset and fork, os.ErrNotExist when the vectors weren't downloaded.
+func Cases(runner string) ([]Case, error) {
+	root := filepath.Join(Dir(), "tests")
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	var cases []Case
+	presets, err := subdirs(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range presets {
+		forks, err := subdirs(filepath.Join(root, preset))
+		if err != nil {
+			return nil, err
+		}
+		for _, fork := range forks {
+			runnerDir := filepath.Join(root, preset, fork, runner)
+			if _, err := os.Stat(runnerDir); os.IsNotExist(err) {
+				continue
+			}
+			handlers, err := subdirs(runnerDir)
+			if err != nil {
+				return nil, err
+			}
+			for _, handler := range handlers {
+				suites, err := subdirs(filepath.Join(runnerDir, handler))
+				if err != nil {
+					return nil, err
+				}
+				for _, suite := range suites {
+					names, err := subdirs(filepath.Join(runnerDir, handler, suite))
+					if err != nil {
+						return nil, err
+					}
+					for _, name := range names {
+						cases = append(cases, Case{
+							Preset:  preset,
+							Fork:    fork,
+							Runner:  runner,
+							Handler: handler,
+							Suite:   suite,
+							Name:    name,
+							Path:    filepath.Join(runnerDir, handler, suite, name),
+						})
+					}
+				}
+			}
+		}
+	}
+	return cases, nil
+}
+
+func subdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (c Case) String() string {
+	return filepath.Join(c.Preset, c.Fork, c.Runner, c.Handler, c.Suite, c.Name)
+}
+
+// Version is the state version of the fork of the case, false for the forks which aren't supported.
+func (c Case) Version() (clparams.StateVersion, bool) {
+	version, ok := Forks[c.Fork]
+	return version, ok
+}
+
+// Exists returns whether the case has the SSZ object name, the post state is absent when the case must fail.
+func (c Case) Exists(name string) bool {
+	_, err := os.Stat(filepath.Join(c.Path, name+".ssz_snappy"))
+	return err == nil
+}
+
+// ReadSSZ returns the SSZ encoding of the object name.
+func (c Case) ReadSSZ(name string) ([]byte, error) {
+	compressed, err := os.ReadFile(filepath.Join(c.Path, name+".ssz_snappy"))
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecompressSnappy(compressed)
+}
+
+// DecodeSSZ decodes the object name into obj.
+func (c Case) DecodeSSZ(name string, obj interface{ DecodeSSZ([]byte) error }) error {
+	buf, err := c.ReadSSZ(name)
+	if err != nil {
+		return err
+	}
+	if err := obj.DecodeSSZ(buf); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// ReadYAML decodes the name.yaml file into v.
+func (c Case) ReadYAML(name string, v interface{}) error {
+	buf, err := os.ReadFile(filepath.Join(c.Path, name+".yaml"))
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(buf, v)
+}
+
+// Meta returns the meta.yaml of the case, the defaults when there's none.
+func (c Case) Meta() (Meta, error) {
+	var meta Meta
+	if err := c.ReadYAML("meta", &meta); err != nil && !os.IsNotExist(err) {
+		return meta, err
+	}
+	return meta, nil
+}
+
+// ReadState decodes the state name, pre or post, at the version of the fork of the case.
+func (c Case) ReadState(name string, cfg *clparams.BeaconChainConfig) (*state.BeaconState, error) {
+	version, ok := c.Version()
+	if !ok {
+		return nil, fmt.Errorf("unsupported fork %s", c.Fork)
+	}
+	buf, err := c.ReadSSZ(name)
+	if err != nil {
+		return nil, err
+	}
+	b := state.New(cfg)
+	if err := b.DecodeSSZWithVersion(buf, int(version)); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return b, nil
+}
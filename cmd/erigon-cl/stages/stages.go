@@ -8,6 +8,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/execution_client"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/lookahead"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/network"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
@@ -76,6 +77,8 @@ func NewConsensusStagedSync(ctx context.Context,
 	genesisCfg *clparams.GenesisConfig,
 	beaconCfg *clparams.BeaconChainConfig,
 	state *state.BeaconState,
+	snapshots *state.Snapshots,
+	lookahead *lookahead.Lookahead,
 	triggerExecution triggerExecutionFunc,
 	clearEth1Data bool,
 	tmpdir string,
@@ -87,7 +90,7 @@ func NewConsensusStagedSync(ctx context.Context,
 			ctx,
 			StageHistoryReconstruction(db, backwardDownloader, genesisCfg, beaconCfg, beaconDBCfg, state, tmpdir, executionClient),
 			StageBeaconsBlock(db, forwardDownloader, genesisCfg, beaconCfg, state, executionClient),
-			StageBeaconState(db, genesisCfg, beaconCfg, state, triggerExecution, clearEth1Data, executionClient),
+			StageBeaconState(db, genesisCfg, beaconCfg, state, snapshots, lookahead, triggerExecution, clearEth1Data, executionClient),
 			StageBeaconIndexes(db, tmpdir),
 		),
 		ConsensusUnwindOrder,
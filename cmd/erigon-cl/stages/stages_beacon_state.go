@@ -10,6 +10,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/execution_client"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/lookahead"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/log/v3"
@@ -23,21 +24,30 @@ type StageBeaconStateCfg struct {
 	genesisCfg       *clparams.GenesisConfig
 	beaconCfg        *clparams.BeaconChainConfig
 	state            *state.BeaconState
+	snapshots        *state.Snapshots
+	lookahead        *lookahead.Lookahead
 	clearEth1Data    bool // Whether we want to discard eth1 data.
 	triggerExecution triggerExecutionFunc
 	executionClient  *execution_client.ExecutionClient
+	stateWriter      *rawdb.BeaconStateWriter
 }
 
+// beaconStateAnchorEpochs is how often the head state is persisted in full, it is a diff to the previous one otherwise.
+const beaconStateAnchorEpochs = 8
+
 func StageBeaconState(db kv.RwDB, genesisCfg *clparams.GenesisConfig,
-	beaconCfg *clparams.BeaconChainConfig, state *state.BeaconState, triggerExecution triggerExecutionFunc, clearEth1Data bool, executionClient *execution_client.ExecutionClient) StageBeaconStateCfg {
+	beaconCfg *clparams.BeaconChainConfig, state *state.BeaconState, snapshots *state.Snapshots, lookahead *lookahead.Lookahead, triggerExecution triggerExecutionFunc, clearEth1Data bool, executionClient *execution_client.ExecutionClient) StageBeaconStateCfg {
 	return StageBeaconStateCfg{
 		db:               db,
 		genesisCfg:       genesisCfg,
 		beaconCfg:        beaconCfg,
 		state:            state,
+		snapshots:        snapshots,
+		lookahead:        lookahead,
 		clearEth1Data:    clearEth1Data,
 		triggerExecution: triggerExecution,
 		executionClient:  executionClient,
+		stateWriter:      rawdb.NewBeaconStateWriter(beaconStateAnchorEpochs * beaconCfg.SlotsPerEpoch),
 	}
 }
 
@@ -101,6 +111,24 @@ func SpawnStageBeaconState(cfg StageBeaconStateCfg, s *stagedsync.StageState, tx
 	}
 	latestBlockHeader.Slot = endSlot
 	cfg.state.SetLatestBlockHeader(latestBlockHeader)
+	if endSlot > fromSlot {
+		// The node resumes from the head state after a restart, the states before the finalized one aren't needed.
+		if err := cfg.stateWriter.Write(tx, cfg.state); err != nil {
+			return err
+		}
+		if err := rawdb.PruneBeaconStates(tx, cfg.state.FinalizedCheckpoint().Epoch*cfg.beaconCfg.SlotsPerEpoch); err != nil {
+			return err
+		}
+	}
+	if cfg.snapshots != nil {
+		snapshot, err := cfg.snapshots.Publish(cfg.state)
+		if err != nil {
+			return err
+		}
+		if cfg.lookahead != nil {
+			cfg.lookahead.Prepare(snapshot)
+		}
+	}
 
 	log.Info(fmt.Sprintf("[%s] Finished transitioning state", s.LogPrefix()), "from", fromSlot, "to", endSlot)
 	if !useExternalTx {
@@ -0,0 +1,62 @@
+package stages_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+	clstages "github.com/ledgerwatch/erigon/cmd/erigon-cl/stages"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+func runBeaconState(t *testing.T, db kv.RwDB, beaconState *state.BeaconState) {
+	cfg := clstages.StageBeaconState(db, nil, &clparams.MainnetBeaconConfig, beaconState, nil, nil, nil, false, nil)
+	sync := stagedsync.New([]*stagedsync.Stage{{
+		ID: stages.BeaconState,
+		Forward: func(firstCycle bool, badBlockUnwind bool, s *stagedsync.StageState, u stagedsync.Unwinder, tx kv.RwTx, quiet bool) error {
+			return clstages.SpawnStageBeaconState(cfg, s, tx, context.Background())
+		},
+	}}, nil, nil)
+	require.NoError(t, sync.Run(db, nil, true, true))
+}
+
+func TestBeaconStateRestart(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	slotsPerEpoch := clparams.MainnetBeaconConfig.SlotsPerEpoch
+
+	// an older state, before the finalized checkpoint of the checkpoint state
+	old := state.GetEmptyBeaconState()
+	old.SetSlot(slotsPerEpoch)
+	checkpoint := state.GetEmptyBeaconState()
+	checkpoint.SetSlot(4 * slotsPerEpoch)
+	checkpoint.SetLatestBlockHeader(&cltypes.BeaconBlockHeader{Slot: 4 * slotsPerEpoch})
+	checkpoint.SetFinalizedCheckpoint(&cltypes.Checkpoint{Epoch: 2})
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, rawdb.WriteBeaconState(tx, old))
+	require.NoError(t, rawdb.WriteBeaconState(tx, checkpoint))
+	// the blocks were downloaded past the checkpoint
+	require.NoError(t, stages.SaveStageProgress(tx, stages.BeaconBlocks, 4*slotsPerEpoch+3))
+	require.NoError(t, tx.Commit())
+
+	runBeaconState(t, db, checkpoint)
+
+	// after a restart the node resumes from the state the stage left, not from the checkpoint
+	tx, err = db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	resumed, err := rawdb.ReadLatestBeaconState(tx, &clparams.MainnetBeaconConfig)
+	require.NoError(t, err)
+	require.Equal(t, 4*slotsPerEpoch+3, resumed.LatestBlockHeader().Slot)
+	pruned, err := rawdb.ReadBeaconState(tx, &clparams.MainnetBeaconConfig, slotsPerEpoch)
+	require.NoError(t, err)
+	require.Nil(t, pruned)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cl/keystore"
+	"github.com/ledgerwatch/erigon/cl/utils"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/validator"
+	lcCli "github.com/ledgerwatch/erigon/cmd/sentinel/cli"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/common/secret"
+)
+
+var (
+	validatorPubkeyFlag = cli.StringFlag{
+		Name:     "pubkey",
+		Usage:    "Hex public key of the validator",
+		Required: true,
+	}
+	validatorKeystoreFlag = cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Path of the EIP-2335 keystore of the validator",
+	}
+	validatorKeystorePasswordFlag = cli.StringFlag{
+		Name:  "keystore.password",
+		Usage: "Password of the keystore: path of a file, env:NAME or cmd:program args",
+	}
+	validatorRemoteSignerFlag = cli.StringFlag{
+		Name:  "remote-signer",
+		Usage: "URL of a Web3Signer compatible remote signer holding the key of the validator, instead of a keystore",
+	}
+	validatorExitEpochFlag = cli.Uint64Flag{
+		Name:  "exit.epoch",
+		Usage: "Epoch of the exit, defaults to the current epoch",
+	}
+	validatorBroadcastFlag = cli.StringFlag{
+		Name:  "broadcast",
+		Usage: "URL of the beacon API of a beacon node to submit the signed exit to",
+	}
+	validatorFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format of the signed exit: json or ssz (hex)",
+		Value: "json",
+	}
+)
+
+var validatorCommand = cli.Command{
+	Name:  "validator",
+	Usage: "Validator operations which don't need a validator client",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "exit",
+			Usage:  "Sign a voluntary exit of a validator, print it and optionally broadcast it",
+			Action: doValidatorExit,
+			Flags: []cli.Flag{
+				&validatorPubkeyFlag,
+				&validatorKeystoreFlag,
+				&validatorKeystorePasswordFlag,
+				&validatorRemoteSignerFlag,
+				&validatorExitEpochFlag,
+				&validatorBroadcastFlag,
+				&validatorFormatFlag,
+			},
+		},
+	},
+}
+
+func doValidatorExit(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	cfg, err := lcCli.SetupConsensusClientCfg(cliCtx)
+	if err != nil {
+		return err
+	}
+	b, err := hexutil.Decode(cliCtx.String(validatorPubkeyFlag.Name))
+	if err != nil || len(b) != 48 {
+		return fmt.Errorf("--%s: invalid public key", validatorPubkeyFlag.Name)
+	}
+	var pubkey [48]byte
+	copy(pubkey[:], b)
+	format := cliCtx.String(validatorFormatFlag.Name)
+	if format != "json" && format != "ssz" {
+		return fmt.Errorf("--%s: unknown format %s", validatorFormatFlag.Name, format)
+	}
+
+	var signer validator.Signer
+	switch {
+	case cliCtx.IsSet(validatorRemoteSignerFlag.Name):
+		signer = validator.NewRemoteSigner(cliCtx.String(validatorRemoteSignerFlag.Name))
+	case cliCtx.IsSet(validatorKeystoreFlag.Name):
+		localSigner, err := localSignerFromKeystore(cliCtx.String(validatorKeystoreFlag.Name), cliCtx.String(validatorKeystorePasswordFlag.Name))
+		if err != nil {
+			return err
+		}
+		defer localSigner.Close()
+		if localSigner.PublicKey() != pubkey {
+			return fmt.Errorf("the keystore holds the key of %x", localSigner.PublicKey())
+		}
+		signer = localSigner
+	default:
+		return fmt.Errorf("one of --%s and --%s is required", validatorKeystoreFlag.Name, validatorRemoteSignerFlag.Name)
+	}
+
+	epoch := utils.GetCurrentEpoch(cfg.GenesisCfg.GenesisTime, cfg.BeaconCfg.SecondsPerSlot, cfg.BeaconCfg.SlotsPerEpoch)
+	if cliCtx.IsSet(validatorExitEpochFlag.Name) {
+		epoch = cliCtx.Uint64(validatorExitEpochFlag.Name)
+	}
+	beaconState, err := core.RetrieveBeaconState(ctx, cfg.BeaconCfg, cfg.GenesisCfg, cfg.CheckpointUri)
+	if err != nil {
+		return err
+	}
+	exit, err := validator.NewVoluntaryExit(beaconState, pubkey, epoch)
+	if err != nil {
+		return err
+	}
+	signed, err := validator.SignVoluntaryExit(ctx, beaconState, signer, pubkey, exit)
+	if err != nil {
+		return err
+	}
+
+	if format == "ssz" {
+		fmt.Println(hexutility.Encode(signed.EncodeSSZ(nil)))
+	} else {
+		out, err := validator.MarshalJSON(signed)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	}
+	if url := cliCtx.String(validatorBroadcastFlag.Name); url != "" {
+		if err := validator.Broadcast(ctx, url, signed); err != nil {
+			return err
+		}
+		log.Info("Voluntary exit submitted", "validator", exit.ValidatorIndex, "epoch", exit.Epoch)
+	}
+	return nil
+}
+
+func localSignerFromKeystore(path, passwordSpec string) (*validator.LocalSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := keystore.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	password, err := secret.Load(passwordSpec)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", validatorKeystorePasswordFlag.Name, err)
+	}
+	defer password.Destroy()
+	secretKey, err := k.Decrypt(password.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer secretKey.Destroy()
+	return validator.NewLocalSigner(secretKey.Bytes())
+}
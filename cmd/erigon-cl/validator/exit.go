@@ -0,0 +1,97 @@
+// Package validator implements the operations of validators which don't need a validator client, such as exits.
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+// NewVoluntaryExit returns the exit at epoch of the validator with pubkey, checking that s would accept it.
+func NewVoluntaryExit(s *state.BeaconState, pubkey [48]byte, epoch uint64) (*cltypes.VoluntaryExit, error) {
+	index, ok := s.ValidatorIndexByPubkey(pubkey)
+	if !ok {
+		return nil, fmt.Errorf("no validator with public key %x", pubkey)
+	}
+	validator := s.ValidatorAt(int(index))
+	beaconConfig := s.BeaconConfig()
+	if !validator.Active(epoch) {
+		return nil, fmt.Errorf("validator %d is not active at epoch %d", index, epoch)
+	}
+	if validator.ExitEpoch != beaconConfig.FarFutureEpoch {
+		return nil, fmt.Errorf("validator %d is already exiting at epoch %d", index, validator.ExitEpoch)
+	}
+	if epoch < validator.ActivationEpoch+beaconConfig.ShardCommitteePeriod {
+		return nil, fmt.Errorf("validator %d can't exit before epoch %d", index, validator.ActivationEpoch+beaconConfig.ShardCommitteePeriod)
+	}
+	return &cltypes.VoluntaryExit{Epoch: epoch, ValidatorIndex: index}, nil
+}
+
+// SignVoluntaryExit has signer sign exit by pubkey, in the domain of the fork of s at the epoch of the exit.
+func SignVoluntaryExit(ctx context.Context, s *state.BeaconState, signer Signer, pubkey [48]byte, exit *cltypes.VoluntaryExit) (*cltypes.SignedVoluntaryExit, error) {
+	signingRoot, err := s.ComputeSigningRoot(exit, s.BeaconConfig().DomainVoluntaryExit, exit.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signer.SignVoluntaryExit(ctx, pubkey, exit, s.Fork(), s.GenesisValidatorsRoot(), signingRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &cltypes.SignedVoluntaryExit{VolunaryExit: exit, Signature: signature}, nil
+}
+
+// voluntaryExitJSON is the encoding of exits of the beacon API and remote signers.
+type voluntaryExitJSON struct {
+	Epoch          string `json:"epoch"`
+	ValidatorIndex string `json:"validator_index"`
+}
+
+func newVoluntaryExitJSON(exit *cltypes.VoluntaryExit) *voluntaryExitJSON {
+	return &voluntaryExitJSON{Epoch: strconv.FormatUint(exit.Epoch, 10), ValidatorIndex: strconv.FormatUint(exit.ValidatorIndex, 10)}
+}
+
+type signedVoluntaryExitJSON struct {
+	Message   *voluntaryExitJSON `json:"message"`
+	Signature string             `json:"signature"`
+}
+
+// MarshalJSON encodes signed as the beacon API does.
+func MarshalJSON(signed *cltypes.SignedVoluntaryExit) ([]byte, error) {
+	return json.Marshal(signedVoluntaryExitJSON{
+		Message:   newVoluntaryExitJSON(signed.VolunaryExit),
+		Signature: hexutility.Encode(signed.Signature[:]),
+	})
+}
+
+// Broadcast submits signed to the exit pool of the beacon node serving the beacon API at url, which gossips it.
+func Broadcast(ctx context.Context, url string, signed *cltypes.SignedVoluntaryExit) error {
+	body, err := MarshalJSON(signed)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(url, "/")+"/eth/v1/beacon/pool/voluntary_exits", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("broadcast: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
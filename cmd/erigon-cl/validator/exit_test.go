@@ -0,0 +1,75 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/state"
+)
+
+var testPubkey = [48]byte{1}
+
+func getTestState(v *cltypes.Validator) *state.BeaconState {
+	b := state.GetEmptyBeaconState()
+	b.SetFork(&cltypes.Fork{PreviousVersion: [4]byte{0, 1, 2, 3}, CurrentVersion: [4]byte{3, 2, 1, 0}, Epoch: 10})
+	v.PublicKey = testPubkey
+	b.AddValidator(v)
+	return b
+}
+
+func TestNewVoluntaryExit(t *testing.T) {
+	cfg := &clparams.MainnetBeaconConfig
+	b := getTestState(&cltypes.Validator{ActivationEpoch: 10, ExitEpoch: cfg.FarFutureEpoch})
+
+	_, err := NewVoluntaryExit(b, [48]byte{2}, 1000)
+	require.Error(t, err)
+	// too early
+	_, err = NewVoluntaryExit(b, testPubkey, 10+cfg.ShardCommitteePeriod-1)
+	require.Error(t, err)
+	exit, err := NewVoluntaryExit(b, testPubkey, 10+cfg.ShardCommitteePeriod)
+	require.NoError(t, err)
+	require.Equal(t, &cltypes.VoluntaryExit{Epoch: 10 + cfg.ShardCommitteePeriod, ValidatorIndex: 0}, exit)
+
+	exiting := getTestState(&cltypes.Validator{ActivationEpoch: 10, ExitEpoch: 2000})
+	_, err = NewVoluntaryExit(exiting, testPubkey, 1000)
+	require.Error(t, err)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	var request remoteSignRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/eth2/sign/0x010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &request))
+		signature := [96]byte{0xaa}
+		w.Write([]byte(`{"signature": "` + hexutility.Encode(signature[:]) + `"}`))
+	}))
+	defer server.Close()
+
+	b := getTestState(&cltypes.Validator{ExitEpoch: clparams.MainnetBeaconConfig.FarFutureEpoch})
+	exit := &cltypes.VoluntaryExit{Epoch: 300, ValidatorIndex: 0}
+	signed, err := SignVoluntaryExit(context.Background(), b, NewRemoteSigner(server.URL+"/"), testPubkey, exit)
+	require.NoError(t, err)
+	require.Equal(t, byte(0xaa), signed.Signature[0])
+
+	require.Equal(t, "VOLUNTARY_EXIT", request.Type)
+	require.Equal(t, "300", request.VoluntaryExit.Epoch)
+	require.Equal(t, "0x03020100", request.ForkInfo.Fork.CurrentVersion)
+	require.Equal(t, "10", request.ForkInfo.Fork.Epoch)
+	require.Equal(t, libcommon.Hash{}.Hex(), request.ForkInfo.GenesisValidatorsRoot)
+
+	out, err := MarshalJSON(signed)
+	require.NoError(t, err)
+	require.Contains(t, string(out), `"message":{"epoch":"300","validator_index":"0"}`)
+}
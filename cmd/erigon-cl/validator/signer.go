@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// signatureDST is the domain separation tag of the signatures of the consensus layer.
+var signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// Signer signs the messages of validators without giving out their secret keys.
+type Signer interface {
+	// SignVoluntaryExit returns the signature of the exit by pubkey. signingRoot is the root of the exit in the
+	// domain of fork and genesisValidatorsRoot, remote signers check it against the exit.
+	SignVoluntaryExit(ctx context.Context, pubkey [48]byte, exit *cltypes.VoluntaryExit, fork *cltypes.Fork, genesisValidatorsRoot libcommon.Hash, signingRoot [32]byte) ([96]byte, error)
+}
+
+// LocalSigner signs with a secret key held in memory, usually decrypted from a keystore.
+type LocalSigner struct {
+	secretKey *blst.SecretKey
+	pubkey    [48]byte
+}
+
+func NewLocalSigner(secretKey []byte) (*LocalSigner, error) {
+	sk := new(blst.SecretKey).Deserialize(secretKey)
+	if sk == nil || !sk.Valid() {
+		return nil, fmt.Errorf("invalid secret key")
+	}
+	s := &LocalSigner{secretKey: sk}
+	copy(s.pubkey[:], new(blst.P1Affine).From(sk).Compress())
+	return s, nil
+}
+
+func (s *LocalSigner) PublicKey() [48]byte {
+	return s.pubkey
+}
+
+func (s *LocalSigner) SignVoluntaryExit(_ context.Context, pubkey [48]byte, _ *cltypes.VoluntaryExit, _ *cltypes.Fork, _ libcommon.Hash, signingRoot [32]byte) (signature [96]byte, err error) {
+	if pubkey != s.pubkey {
+		return signature, fmt.Errorf("no secret key for %x", pubkey)
+	}
+	copy(signature[:], new(blst.P2Affine).Sign(s.secretKey, signingRoot[:], signatureDST).Compress())
+	return signature, nil
+}
+
+// Close zeroes the secret key.
+func (s *LocalSigner) Close() {
+	s.secretKey.Zeroize()
+}
+
+// RemoteSigner signs with a remote signer implementing the Web3Signer API.
+type RemoteSigner struct {
+	url    string
+	client *http.Client
+}
+
+func NewRemoteSigner(url string) *RemoteSigner {
+	return &RemoteSigner{url: strings.TrimSuffix(url, "/"), client: http.DefaultClient}
+}
+
+type remoteForkInfo struct {
+	Fork struct {
+		PreviousVersion string `json:"previous_version"`
+		CurrentVersion  string `json:"current_version"`
+		Epoch           string `json:"epoch"`
+	} `json:"fork"`
+	GenesisValidatorsRoot string `json:"genesis_validators_root"`
+}
+
+type remoteSignRequest struct {
+	Type          string             `json:"type"`
+	ForkInfo      remoteForkInfo     `json:"fork_info"`
+	SigningRoot   string             `json:"signingRoot"`
+	VoluntaryExit *voluntaryExitJSON `json:"voluntary_exit,omitempty"`
+}
+
+func (s *RemoteSigner) SignVoluntaryExit(ctx context.Context, pubkey [48]byte, exit *cltypes.VoluntaryExit, fork *cltypes.Fork, genesisValidatorsRoot libcommon.Hash, signingRoot [32]byte) (signature [96]byte, err error) {
+	request := remoteSignRequest{
+		Type:          "VOLUNTARY_EXIT",
+		SigningRoot:   hexutility.Encode(signingRoot[:]),
+		VoluntaryExit: newVoluntaryExitJSON(exit),
+	}
+	request.ForkInfo.Fork.PreviousVersion = hexutility.Encode(fork.PreviousVersion[:])
+	request.ForkInfo.Fork.CurrentVersion = hexutility.Encode(fork.CurrentVersion[:])
+	request.ForkInfo.Fork.Epoch = fmt.Sprint(fork.Epoch)
+	request.ForkInfo.GenesisValidatorsRoot = hexutility.Encode(genesisValidatorsRoot[:])
+	body, err := json.Marshal(request)
+	if err != nil {
+		return signature, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/v1/eth2/sign/0x%x", s.url, pubkey), bytes.NewReader(body))
+	if err != nil {
+		return signature, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return signature, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return signature, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return signature, fmt.Errorf("remote signer: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	// Older signers answer with the bare hex signature
+	encoded := strings.TrimSpace(string(respBody))
+	if strings.HasPrefix(encoded, "{") {
+		var decoded struct {
+			Signature string `json:"signature"`
+		}
+		if err := json.Unmarshal(respBody, &decoded); err != nil {
+			return signature, fmt.Errorf("remote signer: %w", err)
+		}
+		encoded = decoded.Signature
+	}
+	b, err := hexutil.Decode(encoded)
+	if err != nil || len(b) != len(signature) {
+		return signature, fmt.Errorf("remote signer: invalid signature %q", encoded)
+	}
+	copy(signature[:], b)
+	return signature, nil
+}
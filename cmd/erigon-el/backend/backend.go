@@ -26,7 +26,6 @@ import (
 	"github.com/ledgerwatch/erigon-lib/downloader/downloadergrpc"
 	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/execution"
-	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
@@ -39,6 +38,7 @@ import (
 	txpool2 "github.com/ledgerwatch/erigon-lib/txpool"
 	"github.com/ledgerwatch/erigon-lib/txpool/txpooluitl"
 	types2 "github.com/ledgerwatch/erigon-lib/types"
+	"github.com/ledgerwatch/erigon/common/secret"
 	"github.com/ledgerwatch/erigon/core/systemcontracts"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/exp/slices"
@@ -66,6 +66,7 @@ import (
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state/temporal"
+	"github.com/ledgerwatch/erigon/core/txcond"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
@@ -134,6 +135,7 @@ type Ethereum struct {
 	sentryServers  []*sentry.GrpcServer
 
 	stagedSync *stagedsync.Sync
+	execution  *eth1.Eth1Execution
 
 	downloaderClient proto_downloader.DownloaderClient
 
@@ -150,6 +152,7 @@ type Ethereum struct {
 	txPool2Send             *txpool2.Send
 	txPool2GrpcServer       txpool_proto.TxpoolServer
 	notifyMiningAboutNewTxs chan struct{}
+	txConditions            *txcond.Store
 	forkValidator           *engineapi.ForkValidator
 	downloader              *downloader3.Downloader
 	blockReader             services.FullBlockReader
@@ -244,6 +247,11 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 		return nil, err
 	}
 
+	txConditions, err := txcond.NewStore(filepath.Join(config.Dirs.DataDir, "txconditions.json"))
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	// kv_remote architecture does blocks on stream.Send - means current architecture require unlimited amount of txs to provide good throughput
@@ -260,9 +268,11 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 		waitForStageLoopStop: make(chan struct{}),
 		waitForMiningStop:    make(chan struct{}),
 		notifications: &shards.Notifications{
-			Events:      shards.NewEvents(),
-			Accumulator: shards.NewAccumulator(),
+			Events:            shards.NewEvents(),
+			Accumulator:       shards.NewAccumulator(),
+			SnapshotsDownload: &shards.SnapshotsDownload{},
 		},
+		txConditions: txConditions,
 	}
 	var (
 		allSnapshots *snapshotsync.RoSnapshots
@@ -466,7 +476,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	mining := stagedsync.New(
 		stagedsync.MiningStages(backend.sentryCtx,
 			stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miner, *backend.chainConfig, backend.engine, backend.txPool2, backend.txPool2DB, nil, tmpdir),
-			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool2, backend.txPool2DB),
+			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool2, backend.txPool2DB, config.MinerTxFilter, backend.txConditions),
 			stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3, backend.agg),
 			stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, backend.blockReader, nil, config.HistoryV3, backend.agg),
 			stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miner, backend.miningSealingQuit),
@@ -484,7 +494,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 		proposingSync := stagedsync.New(
 			stagedsync.MiningStages(backend.sentryCtx,
 				stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miningStatePos, *backend.chainConfig, backend.engine, backend.txPool2, backend.txPool2DB, param, tmpdir),
-				stagedsync.StageMiningExecCfg(backend.chainDB, miningStatePos, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, interrupt, param.PayloadId, backend.txPool2, backend.txPool2DB),
+				stagedsync.StageMiningExecCfg(backend.chainDB, miningStatePos, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, interrupt, param.PayloadId, backend.txPool2, backend.txPool2DB, config.MinerTxFilter, backend.txConditions),
 				stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3, backend.agg),
 				stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, backend.blockReader, nil, config.HistoryV3, backend.agg),
 				stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miningStatePos, backend.miningSealingQuit),
@@ -505,13 +515,16 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	var creds credentials.TransportCredentials
 	if stack.Config().PrivateApiAddr != "" {
 		if stack.Config().TLSConnection {
-			creds, err = grpcutil.TLS(stack.Config().TLSCACert, stack.Config().TLSCertFile, stack.Config().TLSKeyFile)
+			creds, err = secret.TLS(stack.Config().TLSCACert, stack.Config().TLSCertFile, stack.Config().TLSKeyFile)
 			if err != nil {
 				return nil, err
 			}
 		}
 		backend.privateAPI, err = privateapi.StartGrpc(
-			kvRPC,
+			privateapi.NewAuditedKV(privateapi.NewLimitedKV(privateapi.NewMeteredKV(kvRPC), privateapi.KvLimits{
+				OpsPerSecond:   stack.Config().PrivateApiKvOpsLimit,
+				BytesPerSecond: stack.Config().PrivateApiKvBytesLimit,
+			}), stack.Config().PrivateApiAuditRate),
 			ethBackendRPC,
 			backend.txPool2GrpcServer,
 			miningRPC,
@@ -605,6 +618,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	if err != nil {
 		return nil, err
 	}
+	backend.execution = eth1.NewEth1Execution(backend.chainDB, backend.blockReader, backend.stagedSync)
 
 	backend.sentriesClient.Hd.StartPoSDownloader(backend.sentryCtx, backend.sentriesClient.SendHeaderRequest, backend.sentriesClient.Penalize)
 
@@ -649,7 +663,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	if casted, ok := backend.engine.(*bor.Bor); ok {
 		borDb = casted.DB
 	}
-	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine)
+	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine, backend.notifications.SnapshotsDownload, backend.txConditions)
 	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine)
 	go func() {
 		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
@@ -932,7 +946,7 @@ func (s *Ethereum) Start() error {
 		}
 		maxReceiveSize := 500 * datasize.MB
 		server := grpc.NewServer(grpc.MaxRecvMsgSize(int(maxReceiveSize)))
-		execution.RegisterExecutionServer(server, eth1.NewEth1Execution(s.chainDB, s.blockReader, s.stagedSync))
+		execution.RegisterExecutionServer(server, s.execution)
 		log.Info("Execution Module Server started!")
 		if err := server.Serve(lis); err != nil {
 			panic(err)
@@ -996,6 +1010,12 @@ func (s *Ethereum) StagedSync() *stagedsync.Sync {
 	return s.stagedSync
 }
 
+// Execution is the execution module served to erigon-cl, for a consensus layer running in the same process to call
+// directly, see execution_client.NewExecutionClientDirect
+func (s *Ethereum) Execution() *eth1.Eth1Execution {
+	return s.execution
+}
+
 func (s *Ethereum) Notifications() *shards.Notifications {
 	return s.notifications
 }
@@ -42,33 +42,37 @@ func NewEth1Execution(db kv.RwDB, blockReader services.FullBlockReader, executio
 }
 
 func (e *Eth1Execution) InsertHeaders(ctx context.Context, req *execution.InsertHeadersRequest) (*execution.EmptyMessage, error) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-	tx, err := e.db.BeginRw(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
+	headers := make([]*types.Header, 0, len(req.Headers))
 	for _, header := range req.Headers {
 		h, err := HeaderRpcToHeader(header)
 		if err != nil {
 			return nil, err
 		}
-		rawdb.WriteHeader(tx, h)
+		headers = append(headers, h)
 	}
-	return &execution.EmptyMessage{}, tx.Commit()
+	return &execution.EmptyMessage{}, e.WriteHeaders(ctx, headers)
 }
 
-func (e *Eth1Execution) InsertBodies(ctx context.Context, req *execution.InsertBodiesRequest) (*execution.EmptyMessage, error) {
+// WriteHeaders is InsertHeaders without the RPC types, for callers in the same process
+func (e *Eth1Execution) WriteHeaders(ctx context.Context, headers []*types.Header) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	tx, err := e.db.BeginRw(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer tx.Rollback()
 
+	for _, h := range headers {
+		rawdb.WriteHeader(tx, h)
+	}
+	return tx.Commit()
+}
+
+func (e *Eth1Execution) InsertBodies(ctx context.Context, req *execution.InsertBodiesRequest) (*execution.EmptyMessage, error) {
+	bodies := make([]*types.RawBody, 0, len(req.Bodies))
+	blockHashes := make([]libcommon.Hash, 0, len(req.Bodies))
+	blockNumbers := make([]uint64, 0, len(req.Bodies))
 	for _, body := range req.Bodies {
 		uncles := make([]*types.Header, 0, len(body.Uncles))
 		for _, uncle := range body.Uncles {
@@ -89,16 +93,36 @@ func (e *Eth1Execution) InsertBodies(ctx context.Context, req *execution.InsertB
 			})
 		}
 
-		if _, _, err := rawdb.WriteRawBodyIfNotExists(tx, gointerfaces.ConvertH256ToHash(body.BlockHash),
-			body.BlockNumber, &types.RawBody{
-				Transactions: body.Transactions,
-				Uncles:       uncles,
-				Withdrawals:  withdrawals,
-			}); err != nil {
-			return nil, err
+		bodies = append(bodies, &types.RawBody{
+			Transactions: body.Transactions,
+			Uncles:       uncles,
+			Withdrawals:  withdrawals,
+		})
+		blockHashes = append(blockHashes, gointerfaces.ConvertH256ToHash(body.BlockHash))
+		blockNumbers = append(blockNumbers, body.BlockNumber)
+	}
+	return &execution.EmptyMessage{}, e.WriteBodies(ctx, bodies, blockHashes, blockNumbers)
+}
+
+// WriteBodies is InsertBodies without the RPC types, for callers in the same process
+func (e *Eth1Execution) WriteBodies(ctx context.Context, bodies []*types.RawBody, blockHashes []libcommon.Hash, blockNumbers []uint64) error {
+	if len(bodies) != len(blockHashes) || len(bodies) != len(blockNumbers) {
+		return fmt.Errorf("unbalanced inputs")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	tx, err := e.db.BeginRw(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, body := range bodies {
+		if _, _, err := rawdb.WriteRawBodyIfNotExists(tx, blockHashes[i], blockNumbers[i], body); err != nil {
+			return err
 		}
 	}
-	return &execution.EmptyMessage{}, tx.Commit()
+	return tx.Commit()
 }
 
 type canonicalEntry struct {
@@ -67,6 +67,7 @@ func NewStagedSync(ctx context.Context,
 				snapDownloader,
 				blockReader,
 				notifications.Events,
+				notifications.SnapshotsDownload,
 				engine,
 				cfg.HistoryV3,
 				agg,
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/evm/internal/t8ntool"
+	"github.com/ledgerwatch/erigon/tests"
+)
+
+var blockTestCommand = cli.Command{
+	Action:    blockTestCmd,
+	Name:      "blocktest",
+	Usage:     "executes the given blockchain tests, through the block processing of erigon",
+	ArgsUsage: "<file or directory>...",
+}
+
+// BlocktestResult contains the execution status after running a blockchain test, in the format of the results of
+// statetest.
+type BlocktestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Error string `json:"error,omitempty"`
+}
+
+func blockTestCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	// the sync stages of every test are noisy, only their errors are of interest
+	lvl := log.LvlError
+	if ctx.Bool(DebugFlag.Name) {
+		lvl = log.LvlDebug
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(lvl, log.StderrHandler))
+
+	files, err := testFiles(ctx.Args().Slice())
+	if err != nil {
+		return err
+	}
+	results := []BlocktestResult{}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var blockTests map[string]*tests.BlockTest
+		if err = json.Unmarshal(src, &blockTests); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, name := range sortedTestNames(blockTests) {
+			test := blockTests[name]
+			result := BlocktestResult{Name: name, Fork: test.Network(), Pass: true}
+			if err := test.RunStandalone(); err != nil {
+				result.Pass, result.Error = false, err.Error()
+			}
+			results = append(results, result)
+		}
+	}
+
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	for _, result := range results {
+		if !result.Pass {
+			return t8ntool.NewError(t8ntool.ErrorEVM, errors.New("some tests failed"))
+		}
+	}
+	return nil
+}
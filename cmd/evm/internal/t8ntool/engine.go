@@ -0,0 +1,39 @@
+package t8ntool
+
+import (
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// rewardEngine is ethash with the block reward given by --state.reward instead of the one of the fork, like the
+// transition tools of the other clients: -1 pays no reward.
+type rewardEngine struct {
+	consensus.Engine
+	reward int64
+}
+
+func (e *rewardEngine) Finalize(config *chain.Config, header *types.Header, ibs *state.IntraBlockState,
+	txs types.Transactions, uncles []*types.Header, r types.Receipts, withdrawals []*types.Withdrawal,
+	epoch consensus.EpochReader, chain consensus.ChainHeaderReader, syscall consensus.SystemCall,
+) (types.Transactions, types.Receipts, error) {
+	if e.reward < 0 {
+		return txs, r, nil
+	}
+	blockReward := uint256.NewInt(uint64(e.reward))
+	minerReward := new(uint256.Int).Set(blockReward)
+	perOmmer := new(uint256.Int).Div(blockReward, uint256.NewInt(32))
+	for _, uncle := range uncles {
+		// (uncle number + 8 - block number) * reward / 8
+		ommerReward := uint256.NewInt(uncle.Number.Uint64() + 8 - header.Number.Uint64())
+		ommerReward.Mul(ommerReward, blockReward)
+		ommerReward.Div(ommerReward, uint256.NewInt(8))
+		ibs.AddBalance(uncle.Coinbase, ommerReward)
+		minerReward.Add(minerReward, perOmmer)
+	}
+	ibs.AddBalance(header.Coinbase, minerReward)
+	return txs, r, nil
+}
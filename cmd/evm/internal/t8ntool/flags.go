@@ -89,6 +89,10 @@ var (
 		Usage: "ChainID to use",
 		Value: 1,
 	}
+	RewardFlag = cli.Int64Flag{
+		Name:  "state.reward",
+		Usage: "Mining reward in wei, -1 pays none. The reward of the fork when not set",
+	}
 	ForknameFlag = cli.StringFlag{
 		Name: "state.fork",
 		Usage: fmt.Sprintf("Name of ruleset to use."+
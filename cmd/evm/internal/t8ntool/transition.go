@@ -26,6 +26,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
@@ -41,7 +42,9 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
@@ -156,7 +159,9 @@ func Main(ctx *cli.Context) error {
 	// Figure out the prestate alloc
 	if allocStr == stdinSelector || envStr == stdinSelector || txStr == stdinSelector {
 		decoder := json.NewDecoder(os.Stdin)
-		decoder.Decode(inputData) //nolint:errcheck
+		if err = decoder.Decode(inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling stdin: %v", err))
+		}
 	}
 	if allocStr != stdinSelector {
 		inFile, err1 := os.Open(allocStr)
@@ -212,7 +217,16 @@ func Main(ctx *cli.Context) error {
 		}
 		defer inFile.Close()
 		decoder := json.NewDecoder(inFile)
-		if err = decoder.Decode(&txsWithKeys); err != nil {
+		if strings.HasSuffix(txStr, ".rlp") {
+			// a hex string of the rlp list of the signed transactions, as the fuzzers write them
+			var body hexutil.Bytes
+			if err = decoder.Decode(&body); err != nil {
+				return NewError(ErrorJson, fmt.Errorf("failed unmarshaling txs-file: %v", err))
+			}
+			if txsWithKeys, err = decodeRlpTxs(body); err != nil {
+				return NewError(ErrorJson, fmt.Errorf("failed decoding rlp txs: %v", err))
+			}
+		} else if err = decoder.Decode(&txsWithKeys); err != nil {
 			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling txs-file: %v", err))
 		}
 	} else {
@@ -242,7 +256,17 @@ func Main(ctx *cli.Context) error {
 		return NewError(ErrorVMConfig, errors.New("Shanghai config but missing 'withdrawals' in env section"))
 	}
 
-	if env := prestate.Env; env.Difficulty == nil {
+	// after the merge, difficulty is zero and the randomness of the beacon chain takes its place
+	isMerged := chainConfig.TerminalTotalDifficulty != nil && chainConfig.TerminalTotalDifficulty.BitLen() == 0
+	if env := prestate.Env; isMerged {
+		if env.Random == nil {
+			return NewError(ErrorVMConfig, errors.New("post-merge requires currentRandom to be defined in env"))
+		}
+		if env.Difficulty != nil && env.Difficulty.BitLen() != 0 {
+			return NewError(ErrorVMConfig, errors.New("post-merge difficulty must be zero (or omitted) in env"))
+		}
+		prestate.Env.Difficulty = new(big.Int)
+	} else if env.Difficulty == nil {
 		// If difficulty was not provided by caller, we need to calculate it.
 		switch {
 		case env.ParentDifficulty == nil:
@@ -290,12 +314,18 @@ func Main(ctx *cli.Context) error {
 	defer tx.Rollback()
 
 	reader, writer := MakePreState(chainConfig.Rules(0, 0), tx, prestate.Pre)
-	engine := ethash.NewFaker()
+	var engine consensus.Engine = ethash.NewFaker()
+	if ctx.IsSet(RewardFlag.Name) {
+		engine = &rewardEngine{Engine: engine, reward: ctx.Int64(RewardFlag.Name)}
+	}
+	if chainConfig.TerminalTotalDifficulty != nil {
+		engine = serenity.New(engine) // the Merge
+	}
 
 	result, err := core.ExecuteBlockEphemerally(chainConfig, &vmConfig, getHash, engine, block, reader, writer, nil, nil, getTracer)
 
 	if hashError != nil {
-		return NewError(ErrorMissingBlockhash, fmt.Errorf("blockhash error: %v", err))
+		return NewError(ErrorMissingBlockhash, fmt.Errorf("blockhash error: %v", hashError))
 	}
 
 	if err != nil {
@@ -450,10 +480,31 @@ func getTransaction(txJson commands.RPCTransaction) (types.Transaction, error) {
 		return &dynamicFeeTx, nil
 
 	default:
-		return nil, nil
+		return nil, fmt.Errorf("unsupported transaction type %d", txJson.Type)
 	}
 }
 
+// decodeRlpTxs decodes the rlp list of signed transactions body.
+func decodeRlpTxs(body []byte) ([]*txWithKey, error) {
+	var encoded []rlp.RawValue
+	if err := rlp.DecodeBytes(body, &encoded); err != nil {
+		return nil, err
+	}
+	raw := make([][]byte, len(encoded))
+	for i, enc := range encoded {
+		raw[i] = enc
+	}
+	txs, err := types.DecodeTransactions(raw)
+	if err != nil {
+		return nil, err
+	}
+	txsWithKeys := make([]*txWithKey, len(txs))
+	for i, tx := range txs {
+		txsWithKeys[i] = &txWithKey{tx: tx}
+	}
+	return txsWithKeys, nil
+}
+
 // signUnsignedTransactions converts the input txs to canonical transactions.
 //
 // The transactions can have two forms, either
@@ -578,6 +629,9 @@ func NewHeader(env stEnv) *types.Header {
 	header.GasLimit = env.GasLimit
 	header.Time = env.Timestamp
 	header.BaseFee = env.BaseFee
+	if env.Random != nil {
+		header.MixDigest = libcommon.BigToHash(env.Random)
+	}
 
 	return &header
 }
@@ -150,6 +150,7 @@ var stateTransitionCommand = cli.Command{
 		&t8ntool.InputTxsFlag,
 		&t8ntool.ForknameFlag,
 		&t8ntool.ChainIDFlag,
+		&t8ntool.RewardFlag,
 		&t8ntool.VerbosityFlag,
 	},
 }
@@ -184,6 +185,7 @@ func init() {
 		&compileCommand,
 		&disasmCommand,
 		&runCommand,
+		&blockTestCommand,
 		&stateTestCommand,
 		&stateTransitionCommand,
 	}
@@ -21,13 +21,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/urfave/cli/v2"
 
+	"github.com/ledgerwatch/erigon/cmd/evm/internal/t8ntool"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
@@ -39,7 +44,7 @@ var stateTestCommand = cli.Command{
 	Action:    stateTestCmd,
 	Name:      "statetest",
 	Usage:     "executes the given state tests",
-	ArgsUsage: "<file>",
+	ArgsUsage: "<file or directory>...",
 }
 
 // StatetestResult contains the execution status after running a state test, any
@@ -82,27 +87,70 @@ func stateTestCmd(ctx *cli.Context) error {
 	default:
 		debugger = logger.NewStructLogger(config)
 	}
-	// Load the test content from the input file
-	src, err := os.ReadFile(ctx.Args().First())
+	// Load the test content from the input files
+	files, err := testFiles(ctx.Args().Slice())
 	if err != nil {
 		return err
 	}
-	var stateTests map[string]tests.StateTest
-	if err = json.Unmarshal(src, &stateTests); err != nil {
-		return err
-	}
+	results := []StatetestResult{}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var stateTests map[string]tests.StateTest
+		if err = json.Unmarshal(src, &stateTests); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
 
-	// Iterate over all the stateTests, run them and aggregate the results
-	results, err := aggregateResultsFromStateTests(ctx, stateTests, tracer, debugger)
-	if err != nil {
-		return err
+		// Iterate over all the stateTests, run them and aggregate the results
+		fileResults, err := aggregateResultsFromStateTests(ctx, stateTests, tracer, debugger)
+		if err != nil {
+			return err
+		}
+		results = append(results, fileResults...)
 	}
 
 	out, _ := json.MarshalIndent(results, "", "  ")
 	fmt.Println(string(out))
+	for _, result := range results {
+		if !result.Pass {
+			return t8ntool.NewError(t8ntool.ErrorEVM, errors.New("some tests failed"))
+		}
+	}
 	return nil
 }
 
+// testFiles returns the json files in paths, the directories are walked.
+func testFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (file == path || strings.HasSuffix(file, ".json")) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// sortedTestNames returns the names of the tests of a file, so that the results are in a stable order.
+func sortedTestNames[T any](tests map[string]T) []string {
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func aggregateResultsFromStateTests(
 	ctx *cli.Context,
 	stateTests map[string]tests.StateTest,
@@ -117,14 +165,10 @@ func aggregateResultsFromStateTests(
 	db := memdb.New()
 	defer db.Close()
 
-	tx, txErr := db.BeginRw(context.Background())
-	if txErr != nil {
-		return nil, txErr
-	}
-	defer tx.Rollback()
 	results := make([]StatetestResult, 0, len(stateTests))
 
-	for key, test := range stateTests {
+	for _, key := range sortedTestNames(stateTests) {
+		test := stateTests[key]
 		for _, st := range test.Subtests() {
 			// Run the test and aggregate the result
 			result := &StatetestResult{Name: key, Fork: st.Fork, Pass: true}
@@ -132,9 +176,15 @@ func aggregateResultsFromStateTests(
 			var root libcommon.Hash
 			var calcRootErr error
 
+			// every subtest starts from the pre state of the test
+			tx, txErr := db.BeginRw(context.Background())
+			if txErr != nil {
+				return nil, txErr
+			}
 			statedb, err := test.Run(tx, st, cfg)
 			// print state root for evmlab tracing
 			root, calcRootErr = trie.CalcRoot("", tx)
+			tx.Rollback()
 			if err == nil && calcRootErr != nil {
 				err = calcRootErr
 			}
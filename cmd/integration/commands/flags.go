@@ -19,6 +19,7 @@ var (
 	bucket                         string
 	datadirCli, toChaindata        string
 	migration                      string
+	tableVersion                   uint32
 	integrityFast, integritySlow   bool
 	file                           string
 	HeimdallgRPCAddress            string
@@ -125,6 +126,10 @@ func withMigration(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&migration, "migration", "", "action to apply to given migration")
 }
 
+func withTableVersion(cmd *cobra.Command) {
+	cmd.Flags().Uint32Var(&tableVersion, "version", 0, "schema version of the table to revert to")
+}
+
 func withTxTrace(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&txtrace, "txtrace", false, "enable tracing of transactions")
 }
@@ -262,6 +262,19 @@ var cmdRunMigrations = &cobra.Command{
 	},
 }
 
+var cmdRevertMigrations = &cobra.Command{
+	Use:   "revert_migrations",
+	Short: "Revert the migrations of the given table down to the given schema version, before switching to an older Erigon",
+	Run: func(cmd *cobra.Command, args []string) {
+		db := openDB(dbCfg(kv.ChainDB, chaindata).Exclusive(), false)
+		defer db.Close()
+		if err := migrations.NewMigrator(kv.ChainDB).Revert(db, datadirCli, bucket, tableVersion); err != nil {
+			log.Error("Error", "err", err)
+			return
+		}
+	},
+}
+
 var cmdSetPrune = &cobra.Command{
 	Use:   "force_set_prune",
 	Short: "Override existing --prune flag value (if you know what you are doing)",
@@ -433,6 +446,12 @@ func init() {
 	withHeimdall(cmdRunMigrations)
 	rootCmd.AddCommand(cmdRunMigrations)
 
+	withDataDir(cmdRevertMigrations)
+	withBucket(cmdRevertMigrations)
+	withTableVersion(cmdRevertMigrations)
+	must(cmdRevertMigrations.MarkFlagRequired("bucket"))
+	rootCmd.AddCommand(cmdRevertMigrations)
+
 	withDataDir2(cmdSetSnap)
 	withChain(cmdSetSnap)
 	rootCmd.AddCommand(cmdSetSnap)
@@ -1256,7 +1275,7 @@ func newSync(ctx context.Context, db kv.RwDB, miningConfig *params.MiningConfig)
 	miningSync := stagedsync.New(
 		stagedsync.MiningStages(ctx,
 			stagedsync.StageMiningCreateBlockCfg(db, miner, *chainConfig, engine, nil, nil, nil, dirs.Tmp),
-			stagedsync.StageMiningExecCfg(db, miner, events, *chainConfig, engine, &vm.Config{}, dirs.Tmp, nil, 0, nil, nil),
+			stagedsync.StageMiningExecCfg(db, miner, events, *chainConfig, engine, &vm.Config{}, dirs.Tmp, nil, 0, nil, nil, nil, nil),
 			stagedsync.StageHashStateCfg(db, dirs, historyV3, agg),
 			stagedsync.StageTrieCfg(db, false, true, false, dirs.Tmp, br, nil, historyV3, agg),
 			stagedsync.StageMiningFinishCfg(db, *chainConfig, engine, miner, miningCancel),
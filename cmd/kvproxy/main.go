@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/common/secret"
+	"github.com/ledgerwatch/erigon/ethdb/kvproxy"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	logging2 "github.com/ledgerwatch/erigon/turbo/logging"
+)
+
+var (
+	backendAddrs []string // Addresses of the private api of erigon nodes <host>:<port>
+	listenAddr   string
+	healthCheck  = kvproxy.DefaultHealthCheckInterval
+
+	TLSCertfile string
+	TLSCACert   string
+	TLSKeyFile  string
+)
+
+func init() {
+	utils.CobraFlags(rootCmd, debug.Flags, utils.MetricFlags, logging2.Flags)
+	rootCmd.Flags().StringSliceVar(&backendAddrs, "backend.addr", []string{"localhost:9090"}, "comma separated private api addresses of erigon nodes '<host>:<port>,<host>:<port>'")
+	rootCmd.Flags().StringVar(&listenAddr, "private.api.addr", "localhost:9089", "proxy will serve KV service on this <host>:<port>")
+	rootCmd.Flags().DurationVar(&healthCheck, "healthcheck.interval", kvproxy.DefaultHealthCheckInterval, "how often backends are checked")
+	rootCmd.PersistentFlags().StringVar(&TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
+	rootCmd.PersistentFlags().StringVar(&TLSKeyFile, "tls.key", "", "key file for client side TLS handshake, or env:NAME or cmd:program")
+	rootCmd.PersistentFlags().StringVar(&TLSCACert, "tls.cacert", "", "CA certificate for client side TLS handshake")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "kvproxy",
+	Short: "Read replica proxy: serves remote KV (as used by rpcdaemon --private.api.addr) from several erigon nodes with health checks and failover",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return debug.SetupCobra(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		debug.Exit()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_ = logging2.GetLoggerCmd("kvproxy", cmd)
+		ctx := cmd.Context()
+		creds, err := secret.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("could not connect to remoteKv: %w", err)
+		}
+		backends := make(map[string]remote.KVClient, len(backendAddrs))
+		for _, addr := range backendAddrs {
+			conn, err := grpcutil.Connect(creds, addr)
+			if err != nil {
+				return fmt.Errorf("could not connect to remoteKv: %w, addr=%s", err, addr)
+			}
+			backends[addr] = remote.NewKVClient(conn)
+		}
+		proxy := kvproxy.New(backends)
+		go proxy.Run(ctx, healthCheck)
+
+		lis, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return fmt.Errorf("could not create listener: %w, addr=%s", err, listenAddr)
+		}
+		grpcServer := grpcutil.NewServer(0, nil)
+		remote.RegisterKVServer(grpcServer, proxy)
+		go func() {
+			<-ctx.Done()
+			grpcServer.GracefulStop()
+		}()
+		log.Info("KV proxy started", "on", listenAddr, "backends", backendAddrs)
+		return grpcServer.Serve(lis)
+	},
+}
+
+func main() {
+	ctx, cancel := common.RootContext()
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
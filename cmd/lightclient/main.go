@@ -27,6 +27,7 @@ import (
 	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/lightclient/lightclient"
 	lcCli "github.com/ledgerwatch/erigon/cmd/sentinel/cli"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/cli/flags"
@@ -70,6 +71,14 @@ func runLightClientNode(cliCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	// Other light clients can bootstrap from this one at the checkpoint.
+	if blockRoot, bootstrap, err := state.LightClientBootstrap(); err != nil {
+		log.Debug("[Checkpoint Sync] No light client bootstrap", "reason", err)
+	} else if err := db.Update(ctx, func(tx kv.RwTx) error {
+		return rawdb.WriteLightClientBootstrap(tx, blockRoot, bootstrap, state.Version())
+	}); err != nil {
+		return err
+	}
 
 	forkDigest, err := fork.ComputeForkDigest(cfg.BeaconCfg, cfg.GenesisCfg)
 	if err != nil {
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+const usage = `  buckets                        list the buckets
+  get <bucket> <key>             print the value of key
+  seek <bucket> <key> [limit]    print the entries from key onwards, 10 by default
+  scan <bucket> <prefix> [limit] print the entries with prefix, all by default
+  stats [bucket...]              print the number of entries of the buckets, of all by default
+  help                           print this list
+  exit
+Keys are 0x-prefixed hex, or taken as they are otherwise.`
+
+const defaultSeekLimit = 10
+
+// run executes one command in a read transaction of db
+func run(ctx context.Context, db kv.RoDB, w io.Writer, args []string) error {
+	switch args[0] {
+	case "help":
+		fmt.Fprintln(w, usage)
+		return nil
+	case "buckets":
+		buckets := append([]string{}, kv.ChaindataTables...)
+		sort.Strings(buckets)
+		for _, name := range buckets {
+			if kv.ChaindataTablesCfg[name].Flags&kv.DupSort != 0 {
+				fmt.Fprintln(w, name, "(dupsort)")
+			} else {
+				fmt.Fprintln(w, name)
+			}
+		}
+		return nil
+	}
+	return db.View(ctx, func(tx kv.Tx) error {
+		switch args[0] {
+		case "get":
+			if len(args) != 3 {
+				return fmt.Errorf("usage: get <bucket> <key>")
+			}
+			bucket, key, err := bucketAndKey(args)
+			if err != nil {
+				return err
+			}
+			v, err := tx.GetOne(bucket, key)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				fmt.Fprintln(w, "not found")
+				return nil
+			}
+			fmt.Fprintf(w, "%x\n", v)
+			return nil
+		case "seek", "scan":
+			if len(args) != 3 && len(args) != 4 {
+				if args[0] == "scan" {
+					return fmt.Errorf("usage: scan <bucket> <prefix> [limit]")
+				}
+				return fmt.Errorf("usage: seek <bucket> <key> [limit]")
+			}
+			bucket, key, err := bucketAndKey(args)
+			if err != nil {
+				return err
+			}
+			limit := -1
+			if args[0] == "seek" {
+				limit = defaultSeekLimit
+			}
+			if len(args) == 4 {
+				if limit, err = strconv.Atoi(args[3]); err != nil || limit <= 0 {
+					return fmt.Errorf("limit must be a positive number, got %q", args[3])
+				}
+			}
+			return walk(tx, w, bucket, key, args[0] == "scan", limit)
+		case "stats":
+			buckets := args[1:]
+			if len(buckets) == 0 {
+				buckets = append([]string{}, kv.ChaindataTables...)
+				sort.Strings(buckets)
+			}
+			for _, bucket := range buckets {
+				if err := checkBucket(bucket); err != nil {
+					return err
+				}
+				c, err := tx.Cursor(bucket)
+				if err != nil {
+					return err
+				}
+				count, err := c.Count()
+				c.Close()
+				if err != nil {
+					return fmt.Errorf("%s: %w", bucket, err)
+				}
+				fmt.Fprintf(w, "%s %d\n", bucket, count)
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown command %q, see help", args[0])
+		}
+	})
+}
+
+func walk(tx kv.Tx, w io.Writer, bucket string, from []byte, prefixOnly bool, limit int) error {
+	c, err := tx.Cursor(bucket)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, v, err := c.Seek(from); limit != 0; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		if k == nil || prefixOnly && !bytes.HasPrefix(k, from) {
+			break
+		}
+		fmt.Fprintf(w, "%x %x\n", k, v)
+		limit--
+	}
+	return nil
+}
+
+func bucketAndKey(args []string) (string, []byte, error) {
+	if err := checkBucket(args[1]); err != nil {
+		return "", nil, err
+	}
+	key, err := parseKey(args[2])
+	return args[1], key, err
+}
+
+func checkBucket(name string) error {
+	if _, ok := kv.ChaindataTablesCfg[name]; !ok {
+		return fmt.Errorf("unknown bucket %q, see buckets", name)
+	}
+	return nil
+}
+
+func parseKey(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return []byte(s), nil
+	}
+	key, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", s, err)
+	}
+	return key, nil
+}
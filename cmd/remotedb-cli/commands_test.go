@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for _, k := range []string{"a1", "a2", "a3", "b1"} {
+			if err := tx.Put(kv.DatabaseInfo, []byte(k), []byte{k[1]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	exec := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := run(ctx, db, &out, args)
+		return out.String(), err
+	}
+
+	out, err := exec("get", kv.DatabaseInfo, "0x6132")
+	require.NoError(t, err)
+	require.Equal(t, "32\n", out)
+	out, err = exec("get", kv.DatabaseInfo, "c")
+	require.NoError(t, err)
+	require.Equal(t, "not found\n", out)
+
+	out, err = exec("seek", kv.DatabaseInfo, "a2", "2")
+	require.NoError(t, err)
+	require.Equal(t, "6132 32\n6133 33\n", out)
+	out, err = exec("seek", kv.DatabaseInfo, "a3")
+	require.NoError(t, err)
+	require.Equal(t, "6133 33\n6231 31\n", out)
+
+	out, err = exec("scan", kv.DatabaseInfo, "a")
+	require.NoError(t, err)
+	require.Equal(t, "6131 31\n6132 32\n6133 33\n", out)
+
+	out, err = exec("stats", kv.DatabaseInfo)
+	require.NoError(t, err)
+	require.Equal(t, kv.DatabaseInfo+" 4\n", out)
+
+	out, err = exec("buckets")
+	require.NoError(t, err)
+	require.Contains(t, out, kv.PlainState+" (dupsort)\n")
+
+	_, err = exec("get", "NoSuchBucket", "a")
+	require.ErrorContains(t, err, "unknown bucket")
+	_, err = exec("scan", kv.DatabaseInfo, "a", "0")
+	require.ErrorContains(t, err, "limit")
+	_, err = exec("nope")
+	require.ErrorContains(t, err, "unknown command")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/ledgerwatch/erigon/common/secret"
+)
+
+var (
+	privateApiAddr string // Address of the private api of an erigon node <host>:<port>
+
+	TLSCertfile string
+	TLSCACert   string
+	TLSKeyFile  string
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&privateApiAddr, "private.api.addr", "localhost:9090", "private api address of an erigon node (or of kvproxy) <host>:<port>")
+	rootCmd.Flags().StringVar(&TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
+	rootCmd.Flags().StringVar(&TLSKeyFile, "tls.key", "", "key file for client side TLS handshake, or env:NAME or cmd:program")
+	rootCmd.Flags().StringVar(&TLSCACert, "tls.cacert", "", "CA certificate for client side TLS handshake")
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "remotedb-cli [command]",
+	Short: "Inspects the database of an erigon node over its remote KV service: runs the command given as arguments, or reads commands from stdin",
+	Long:  "Commands:\n" + usage,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		creds, err := secret.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
+		if err != nil {
+			return fmt.Errorf("could not connect to remoteKv: %w", err)
+		}
+		conn, err := grpcutil.Connect(creds, privateApiAddr)
+		if err != nil {
+			return fmt.Errorf("could not connect to remoteKv: %w, addr=%s", err, privateApiAddr)
+		}
+		db, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), log.New(), remote.NewKVClient(conn)).Open()
+		if err != nil {
+			return fmt.Errorf("could not connect to remoteKv: %w", err)
+		}
+		defer db.Close()
+
+		out := bufio.NewWriter(os.Stdout)
+		defer out.Flush()
+		if len(args) > 0 {
+			return run(ctx, db, out, args)
+		}
+
+		interactive := isTerminal(os.Stdin)
+		in := bufio.NewScanner(os.Stdin)
+		for {
+			if interactive {
+				fmt.Fprint(out, "> ")
+			}
+			out.Flush()
+			if !in.Scan() {
+				return in.Err()
+			}
+			fields := strings.Fields(in.Text())
+			if len(fields) == 0 {
+				continue
+			}
+			if fields[0] == "exit" || fields[0] == "quit" {
+				return nil
+			}
+			if err := run(ctx, db, out, fields); err != nil {
+				if !interactive {
+					return err
+				}
+				fmt.Fprintln(out, "error:", err)
+			}
+		}
+	},
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func main() {
+	ctx, cancel := common.RootContext()
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
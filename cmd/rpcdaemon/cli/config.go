@@ -20,11 +20,14 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
 	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
 	libstate "github.com/ledgerwatch/erigon-lib/state"
+	"github.com/ledgerwatch/erigon/common/secret"
 	"github.com/ledgerwatch/erigon/core/state/historyv2read"
 	"github.com/ledgerwatch/erigon/core/state/temporal"
 	"github.com/ledgerwatch/erigon/core/systemcontracts"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/ethdb/notify"
+	"github.com/ledgerwatch/erigon/ethdb/secondary"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 	"github.com/ledgerwatch/erigon/turbo/debug"
 	"github.com/ledgerwatch/erigon/turbo/logging"
@@ -44,6 +47,7 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	grpcHealth "google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
@@ -52,6 +56,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcservices"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/memlimit"
 	"github.com/ledgerwatch/erigon/common/paths"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/node"
@@ -72,7 +77,8 @@ var rootCmd = &cobra.Command{
 }
 
 var (
-	stateCacheStr string
+	stateCacheStr      string
+	memorySoftLimitStr string
 )
 
 func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
@@ -81,9 +87,10 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	cfg := &httpcfg.HttpCfg{Enabled: true, StateCache: kvcache.DefaultCoherentConfig}
 	rootCmd.PersistentFlags().StringVar(&cfg.PrivateApiAddr, "private.api.addr", "127.0.0.1:9090", "private api network address, for example: 127.0.0.1:9090")
 	rootCmd.PersistentFlags().StringVar(&cfg.DataDir, "datadir", "", "path to Erigon working directory")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Secondary, "datadir.secondary", false, "Serve from --datadir alone, without connecting to the private api of Erigon: new blocks are followed through the head file Erigon writes in its datadir. The txpool, mining, engine and p2p methods are not available")
 	rootCmd.PersistentFlags().StringVar(&cfg.HttpListenAddress, "http.addr", nodecfg.DefaultHTTPHost, "HTTP-RPC server listening interface")
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
-	rootCmd.PersistentFlags().StringVar(&cfg.TLSKeyFile, "tls.key", "", "key file for client side TLS handshake")
+	rootCmd.PersistentFlags().StringVar(&cfg.TLSKeyFile, "tls.key", "", "key file for client side TLS handshake, or env:NAME or cmd:program")
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSCACert, "tls.cacert", "", "CA certificate for client side TLS handshake")
 	rootCmd.PersistentFlags().IntVar(&cfg.HttpPort, "http.port", nodecfg.DefaultHTTPPort, "HTTP-RPC server listening port")
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpCORSDomain, "http.corsdomain", []string{}, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
@@ -97,6 +104,8 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, utils.RpcAccessListFlag.Name, "", "Specify granular (method-by-method) API allowlist")
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, utils.RpcBatchConcurrencyFlag.Name, 2, utils.RpcBatchConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStreamingDisable, utils.RpcStreamingDisableFlag.Name, false, utils.RpcStreamingDisableFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStrictLatest, utils.RpcStrictLatestFlag.Name, false, utils.RpcStrictLatestFlag.Usage)
+	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStrictSyncing, utils.RpcStrictSyncingFlag.Name, false, utils.RpcStrictSyncingFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.DBReadConcurrency, utils.DBReadConcurrencyFlag.Name, utils.DBReadConcurrencyFlag.Value, utils.DBReadConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.TraceCompatibility, "trace.compat", false, "Bug for bug compatibility with OE for trace_ routines")
 	rootCmd.PersistentFlags().StringVar(&cfg.TxPoolApiAddr, "txpool.api.addr", "", "txpool api network address, for example: 127.0.0.1:9090 (default: use value of --private.api.addr)")
@@ -118,6 +127,7 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evmtimeout", rpccfg.DefaultEvmCallTimeout, "Maximum amount of time to wait for the answer from EVM call.")
 	rootCmd.PersistentFlags().IntVar(&cfg.BatchLimit, utils.RpcBatchLimit.Name, utils.RpcBatchLimit.Value, utils.RpcBatchLimit.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
+	rootCmd.PersistentFlags().StringVar(&memorySoftLimitStr, utils.MemorySoftLimitFlag.Name, "", utils.MemorySoftLimitFlag.Usage)
 
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
@@ -141,6 +151,12 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 			return fmt.Errorf("state.cache value of %v is not valid", stateCacheStr)
 		}
 
+		if memorySoftLimitStr != "" {
+			if err = cfg.MemorySoftLimit.UnmarshalText([]byte(memorySoftLimitStr)); err != nil {
+				return fmt.Errorf("%s value of %v is not valid", utils.MemorySoftLimitFlag.Name, memorySoftLimitStr)
+			}
+		}
+
 		cfg.WithDatadir = cfg.DataDir != ""
 		if cfg.WithDatadir {
 			if cfg.DataDir == "" {
@@ -279,40 +295,53 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	if !cfg.WithDatadir && cfg.PrivateApiAddr == "" {
 		return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("either remote db or local db must be specified")
 	}
-	creds, err := grpcutil.TLS(cfg.TLSCACert, cfg.TLSCertfile, cfg.TLSKeyFile)
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("open tls cert: %w", err)
-	}
-	conn, err := grpcutil.Connect(creds, cfg.PrivateApiAddr)
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("could not connect to execution service privateApi: %w", err)
-	}
+	if cfg.Secondary && !cfg.WithDatadir {
+		return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("--datadir.secondary requires --datadir")
+	}
+	var creds credentials.TransportCredentials
+	var conn *grpc.ClientConn
+	var remoteBackendClient remote.ETHBACKENDClient
+	var remoteKvClient remote.KVClient
+	var remoteKv *remotedb.RemoteKV
+	// in secondary mode, everything is read from the datadir
+	if !cfg.Secondary {
+		creds, err = secret.TLS(cfg.TLSCACert, cfg.TLSCertfile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("open tls cert: %w", err)
+		}
+		conn, err = grpcutil.Connect(creds, cfg.PrivateApiAddr)
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("could not connect to execution service privateApi: %w", err)
+		}
 
-	remoteBackendClient := remote.NewETHBACKENDClient(conn)
-	remoteKvClient := remote.NewKVClient(conn)
-	remoteKv, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), logger, remoteKvClient).Open()
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("could not connect to remoteKv: %w", err)
+		remoteBackendClient = remote.NewETHBACKENDClient(conn)
+		remoteKvClient = newMeteredKVClient(remote.NewKVClient(conn))
+		remoteKv, err = remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), logger, remoteKvClient).Open()
+		if err != nil {
+			return nil, nil, nil, nil, nil, nil, nil, ff, nil, fmt.Errorf("could not connect to remoteKv: %w", err)
+		}
 	}
 
 	// Configure DB first
 	var allSnapshots *snapshotsync.RoSnapshots
+	var localDb kv.RoDB
+	var localSnapDir string
+	var cc *chain.Config
 	onNewSnapshot := func() {}
 	if cfg.WithDatadir {
 		var rwKv kv.RwDB
 		dir.MustExist(cfg.Dirs.SnapHistory)
 		log.Trace("Creating chain db", "path", cfg.Dirs.Chaindata)
 		limiter := semaphore.NewWeighted(int64(cfg.DBReadConcurrency))
-		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Readonly().Open()
+		rwKv, err = kv2.NewMDBX(logger).RoTxsLimiter(limiter).Path(cfg.Dirs.Chaindata).Readonly().WithTableCfg(secondary.ReadonlyTablesCfg).Open()
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 		}
 		if compatErr := checkDbCompatibility(ctx, rwKv); compatErr != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, compatErr
 		}
-		db = rwKv
+		db, localDb = rwKv, rwKv
 
-		var cc *chain.Config
 		if err := db.View(context.Background(), func(tx kv.Tx) error {
 			genesisBlock, err := rawdb.ReadBlockByNumber(tx, 0)
 			if err != nil {
@@ -344,6 +373,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		// Configure sapshots
 		if cfg.Snap.Enabled {
 			allSnapshots = snapshotsync.NewRoSnapshots(cfg.Snap, cfg.Dirs.Snap)
+			localSnapDir = cfg.Dirs.Snap
 			// To povide good UX - immediatly can read snapshots after RPCDaemon start, even if Erigon is down
 			// Erigon does store list of snapshots in db: means RPCDaemon can read this list now, but read by `remoteKvClient.Snapshots` after establish grpc connection
 			allSnapshots.OptimisticReopenWithDB(db)
@@ -363,12 +393,17 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 			})
 			onNewSnapshot = func() {
 				go func() { // don't block events processing by network communication
-					reply, err := remoteKvClient.Snapshots(ctx, &remote.SnapshotsRequest{}, grpc.WaitForReady(true))
-					if err != nil {
+					if cfg.Secondary {
+						// Erigon stores the list of its snapshots in the db
+						if err := allSnapshots.ReopenWithDB(rwKv); err != nil {
+							log.Error("[Snapshots] reopen", "err", err)
+						} else {
+							allSnapshots.LogStat()
+						}
+					} else if reply, err := remoteKvClient.Snapshots(ctx, &remote.SnapshotsRequest{}, grpc.WaitForReady(true)); err != nil {
 						log.Warn("[Snapshots] reopen", "err", err)
 						return
-					}
-					if err := allSnapshots.ReopenList(reply.Files, true); err != nil {
+					} else if err := allSnapshots.ReopenList(reply.Files, true); err != nil {
 						log.Error("[Snapshots] reopen", "err", err)
 					} else {
 						allSnapshots.LogStat()
@@ -440,6 +475,13 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		log.Info("if you run RPCDaemon on same machine with Erigon add --datadir option")
 	}
 
+	if cfg.Secondary {
+		secondaryEth := rpcservices.NewSecondaryBackend(db, blockReader, cc, cfg.DataDir)
+		ff = rpchelper.New(ctx, secondaryEth, nil, nil, onNewSnapshot)
+		log.Info("Serving from the datadir of Erigon, following its head file", "path", notify.Path(cfg.DataDir))
+		return db, borDb, secondaryEth, nil, nil, stateCache, secondaryEth, ff, agg, nil
+	}
+
 	subscribeToStateChangesLoop(ctx, remoteKvClient, stateCache)
 
 	txpoolConn := conn
@@ -475,6 +517,9 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		if !txPoolService.EnsureVersionCompatibility() {
 			rootCancel()
 		}
+		if !ensureRemoteConsistency(ctx, localDb, remoteKv, remoteKvClient, localSnapDir) {
+			rootCancel()
+		}
 	}()
 
 	ff = rpchelper.New(ctx, eth, txPool, mining, onNewSnapshot)
@@ -482,6 +527,10 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 }
 
 func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, authAPI []rpc.API) error {
+	if cfg.MemorySoftLimit > 0 {
+		memlimit.Default.SetLimit(cfg.MemorySoftLimit)
+	}
+	memlimit.Default.Start(ctx, time.Second)
 	if len(authAPI) > 0 {
 		engineInfo, err := startAuthenticatedRpcServer(cfg, authAPI)
 		if err != nil {
@@ -653,7 +702,8 @@ func isWebsocket(r *http.Request) bool {
 
 // obtainJWTSecret loads the jwt-secret, either from the provided config,
 // or from the default location. If neither of those are present, it generates
-// a new secret and stores to the default location.
+// a new secret and stores to the default location. The config may also point to
+// another source of secrets, see the secret package.
 func obtainJWTSecret(cfg httpcfg.HttpCfg) ([]byte, error) {
 	// try reading from file
 	log.Info("Reading JWT secret", "path", cfg.JWTSecretPath)
@@ -661,11 +711,18 @@ func obtainJWTSecret(cfg httpcfg.HttpCfg) ([]byte, error) {
 	if len(cfg.JWTSecretPath) == 0 {
 		cfg.JWTSecretPath = "jwt.hex"
 	}
-	if data, err := os.ReadFile(cfg.JWTSecretPath); err == nil {
-		jwtSecret := common.FromHex(strings.TrimSpace(string(data)))
+	path, isFile := secret.FilePath(cfg.JWTSecretPath)
+	if _, err := os.Stat(path); err == nil || !isFile {
+		data, err := secret.Load(cfg.JWTSecretPath)
+		if err != nil {
+			return nil, err
+		}
+		defer data.Destroy()
+		jwtSecret := common.FromHex(string(data.Bytes()))
 		if len(jwtSecret) == 32 {
 			return jwtSecret, nil
 		}
+		secret.Zero(jwtSecret)
 		log.Error("Invalid JWT secret", "path", cfg.JWTSecretPath, "length", len(jwtSecret))
 		return nil, errors.New("invalid JWT secret")
 	}
@@ -673,10 +730,10 @@ func obtainJWTSecret(cfg httpcfg.HttpCfg) ([]byte, error) {
 	jwtSecret := make([]byte, 32)
 	rand.Read(jwtSecret)
 
-	if err := os.WriteFile(cfg.JWTSecretPath, []byte(hexutility.Encode(jwtSecret)), 0600); err != nil {
+	if err := os.WriteFile(path, []byte(hexutility.Encode(jwtSecret)), 0600); err != nil {
 		return nil, err
 	}
-	log.Info("Generated JWT secret", "path", cfg.JWTSecretPath)
+	log.Info("Generated JWT secret", "path", path)
 	return jwtSecret, nil
 }
 
@@ -3,6 +3,8 @@ package httpcfg
 import (
 	"time"
 
+	"github.com/c2h5oh/datasize"
+
 	"github.com/ledgerwatch/erigon-lib/common/datadir"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
@@ -14,6 +16,7 @@ type HttpCfg struct {
 	PrivateApiAddr           string
 	WithDatadir              bool // Erigon's database can be read by separated processes on same machine - in read-only mode - with full support of transactions. It will share same "OS PageCache" with Erigon process.
 	DataDir                  string
+	Secondary                bool // only read the datadir, without connecting to the private api of Erigon
 	Dirs                     datadir.Dirs
 	HttpListenAddress        string
 	AuthRpcHTTPListenAddress string
@@ -34,6 +37,8 @@ type HttpCfg struct {
 	RpcAllowListFilePath     string
 	RpcBatchConcurrency      uint
 	RpcStreamingDisable      bool
+	RpcStrictLatest          bool // "latest" only resolves to blocks marked VALID, forkchoice head is "optimistic"
+	RpcStrictSyncing         bool // eth_syncing returns only the fields defined by the spec
 	DBReadConcurrency        int
 	TraceCompatibility       bool // Bug for bug compatibility for trace_ routines with OpenEthereum
 	TxPoolApiAddr            string
@@ -63,4 +68,6 @@ type HttpCfg struct {
 
 	BatchLimit      int // Maximum number of requests in a batch
 	ReturnDataLimit int // Maximum number of bytes retutned from calls (like eth_call)
+
+	MemorySoftLimit datasize.ByteSize // Memory at which new calls are rejected, 0 - no limit
 }
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metrics2 "github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/common/metrics"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"google.golang.org/grpc"
+)
+
+// newMeteredKVClient times the remote KV calls and cursor operations as rpcdaemon sees them, network included, in
+// kv_client_seconds{op="..."} summaries. The node exports the time it took to serve them as kv_server_seconds.
+func newMeteredKVClient(kv remote.KVClient) remote.KVClient {
+	if !metrics.Enabled {
+		return kv
+	}
+	return &meteredKVClient{KVClient: kv}
+}
+
+type meteredKVClient struct {
+	remote.KVClient
+}
+
+func kvClientTimer(op string) *metrics2.Summary {
+	return metrics2.GetOrCreateSummary(fmt.Sprintf(`kv_client_seconds{op="%s"}`, op))
+}
+
+func meteredCall[T any](op string, f func() (T, error)) (T, error) {
+	start := time.Now()
+	reply, err := f()
+	kvClientTimer(op).UpdateDuration(start)
+	return reply, err
+}
+
+func (c *meteredKVClient) Tx(ctx context.Context, opts ...grpc.CallOption) (remote.KV_TxClient, error) {
+	stream, err := c.KVClient.Tx(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredTxClient{KV_TxClient: stream}, nil
+}
+
+func (c *meteredKVClient) Snapshots(ctx context.Context, in *remote.SnapshotsRequest, opts ...grpc.CallOption) (*remote.SnapshotsReply, error) {
+	return meteredCall("Snapshots", func() (*remote.SnapshotsReply, error) { return c.KVClient.Snapshots(ctx, in, opts...) })
+}
+
+func (c *meteredKVClient) Range(ctx context.Context, in *remote.RangeReq, opts ...grpc.CallOption) (*remote.Pairs, error) {
+	return meteredCall("Range", func() (*remote.Pairs, error) { return c.KVClient.Range(ctx, in, opts...) })
+}
+
+func (c *meteredKVClient) DomainGet(ctx context.Context, in *remote.DomainGetReq, opts ...grpc.CallOption) (*remote.DomainGetReply, error) {
+	return meteredCall("DomainGet", func() (*remote.DomainGetReply, error) { return c.KVClient.DomainGet(ctx, in, opts...) })
+}
+
+func (c *meteredKVClient) HistoryGet(ctx context.Context, in *remote.HistoryGetReq, opts ...grpc.CallOption) (*remote.HistoryGetReply, error) {
+	return meteredCall("HistoryGet", func() (*remote.HistoryGetReply, error) { return c.KVClient.HistoryGet(ctx, in, opts...) })
+}
+
+func (c *meteredKVClient) IndexRange(ctx context.Context, in *remote.IndexRangeReq, opts ...grpc.CallOption) (*remote.IndexRangeReply, error) {
+	return meteredCall("IndexRange", func() (*remote.IndexRangeReply, error) { return c.KVClient.IndexRange(ctx, in, opts...) })
+}
+
+// meteredTxClient times each cursor operation from its message to its reply. Every operation gets one reply before
+// the next one is sent, the message announcing the transaction id has no operation.
+type meteredTxClient struct {
+	remote.KV_TxClient
+	op    remote.Op
+	start time.Time
+}
+
+func (s *meteredTxClient) Send(m *remote.Cursor) error {
+	s.op, s.start = m.Op, time.Now()
+	return s.KV_TxClient.Send(m)
+}
+
+func (s *meteredTxClient) Recv() (*remote.Pair, error) {
+	m, err := s.KV_TxClient.Recv()
+	if !s.start.IsZero() {
+		kvClientTimer(s.op.String()).UpdateDuration(s.start)
+		s.start = time.Time{}
+	}
+	return m, err
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+const (
+	// remoteProgressTolerance is how far apart the progress of the local and remote databases can be when they are
+	// the same database: reads are not atomic across both, and Erigon may unwind in between.
+	remoteProgressTolerance = 128
+	remoteProgressAttempts  = 3
+)
+
+// remoteMismatchError means the Erigon behind --private.api.addr does not serve the database found in --datadir.
+type remoteMismatchError struct{ msg string }
+
+func (e *remoteMismatchError) Error() string {
+	return e.msg + ". --datadir of rpcdaemon must be the datadir of the Erigon behind --private.api.addr"
+}
+
+func mismatch(format string, args ...interface{}) error {
+	return &remoteMismatchError{msg: fmt.Sprintf(format, args...)}
+}
+
+type dbIdentity struct {
+	schema   []byte
+	genesis  libcommon.Hash
+	progress uint64
+}
+
+func readDbIdentity(ctx context.Context, db kv.RoDB) (id dbIdentity, err error) {
+	err = db.View(ctx, func(tx kv.Tx) error {
+		if id.schema, err = tx.GetOne(kv.DatabaseInfo, kv.DBSchemaVersionKey); err != nil {
+			return err
+		}
+		id.schema = libcommon.Copy(id.schema)
+		if id.genesis, err = rawdb.ReadCanonicalHash(tx, 0); err != nil {
+			return err
+		}
+		id.progress, err = stages.GetStageProgress(tx, stages.Finish)
+		return err
+	})
+	return id, err
+}
+
+func withinTolerance(progress, from, to uint64) bool {
+	if from > to {
+		from, to = to, from
+	}
+	return progress+remoteProgressTolerance >= from && progress <= to+remoteProgressTolerance
+}
+
+// checkRemoteConsistency compares the local database of --datadir with the remote one: schema version, genesis,
+// sync progress and the snapshot files the remote has open (remoteFiles), which must all be found in snapDir.
+// Mismatches are returned as remoteMismatchError, other errors are failures to read either database.
+func checkRemoteConsistency(ctx context.Context, local, remote kv.RoDB, remoteFiles []string, snapDir string) error {
+	var progressErr error
+	for attempt := 0; attempt < remoteProgressAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Second)
+		}
+		// the local progress read in between two remote reads is within their range, when both are the same database
+		before, err := readDbIdentity(ctx, remote)
+		if err != nil {
+			return fmt.Errorf("read remote db: %w", err)
+		}
+		localID, err := readDbIdentity(ctx, local)
+		if err != nil {
+			return fmt.Errorf("read local db: %w", err)
+		}
+		after, err := readDbIdentity(ctx, remote)
+		if err != nil {
+			return fmt.Errorf("read remote db: %w", err)
+		}
+		if !bytes.Equal(localID.schema, after.schema) {
+			return mismatch("DB schema versions differ: datadir %x, remote %x", localID.schema, after.schema)
+		}
+		if localID.genesis != after.genesis {
+			return mismatch("genesis differs: datadir %x, remote %x", localID.genesis, after.genesis)
+		}
+		if withinTolerance(localID.progress, before.progress, after.progress) {
+			progressErr = nil
+			break
+		}
+		progressErr = mismatch("sync progress differs: datadir at block %d, remote at block %d", localID.progress, after.progress)
+	}
+	if progressErr != nil {
+		return progressErr
+	}
+
+	var missing []string
+	for _, name := range remoteFiles {
+		if _, err := os.Stat(filepath.Join(snapDir, name)); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return mismatch("snapshots are stale: %d files of remote are missing from %s, first %s", len(missing), snapDir, missing[0])
+	}
+	return nil
+}
+
+// ensureRemoteConsistency waits for the remote Erigon and returns false when it can't be used: its DB schema is not
+// supported or, with --datadir (local != nil), it doesn't serve the local database.
+func ensureRemoteConsistency(ctx context.Context, local, remoteDB kv.RoDB, remoteKvClient remote.KVClient, snapDir string) bool {
+	reply, err := remoteKvClient.Snapshots(ctx, &remote.SnapshotsRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		log.Warn("[rpc] remote consistency check skipped", "err", err)
+		return true
+	}
+	if local == nil {
+		if err := checkDbCompatibility(ctx, remoteDB); err != nil {
+			log.Error("[rpc] remote db", "err", err)
+			return false
+		}
+		return true
+	}
+	var remoteFiles []string
+	if snapDir != "" {
+		remoteFiles = reply.Files
+	}
+	err = checkRemoteConsistency(ctx, local, remoteDB, remoteFiles, snapDir)
+	var mismatchErr *remoteMismatchError
+	switch {
+	case errors.As(err, &mismatchErr):
+		log.Error("[rpc] remote db does not match datadir", "err", err)
+		return false
+	case err != nil:
+		log.Warn("[rpc] remote consistency check skipped", "err", err)
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+func newIdentityDB(t *testing.T, genesis libcommon.Hash, progress uint64) kv.RwDB {
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		if err := tx.Put(kv.DatabaseInfo, kv.DBSchemaVersionKey, []byte{0, 0, 0, 6, 0, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+			return err
+		}
+		if err := rawdb.WriteCanonicalHash(tx, genesis, 0); err != nil {
+			return err
+		}
+		return stages.SaveStageProgress(tx, stages.Finish, progress)
+	}))
+	return db
+}
+
+func TestCheckRemoteConsistency(t *testing.T) {
+	ctx := context.Background()
+	snapDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(snapDir, "v1-000000-000500-headers.seg"), nil, 0644))
+
+	local := newIdentityDB(t, libcommon.HexToHash("0x01"), 1000)
+	isMismatch := func(err error) bool {
+		var mismatchErr *remoteMismatchError
+		return errors.As(err, &mismatchErr)
+	}
+
+	require.NoError(t, checkRemoteConsistency(ctx, local, newIdentityDB(t, libcommon.HexToHash("0x01"), 1010), []string{"v1-000000-000500-headers.seg"}, snapDir))
+
+	err := checkRemoteConsistency(ctx, local, newIdentityDB(t, libcommon.HexToHash("0x02"), 1000), nil, snapDir)
+	require.True(t, isMismatch(err), err)
+	require.Contains(t, err.Error(), "genesis")
+
+	err = checkRemoteConsistency(ctx, local, newIdentityDB(t, libcommon.HexToHash("0x01"), 1000), []string{"v1-000500-001000-headers.seg"}, snapDir)
+	require.True(t, isMismatch(err), err)
+	require.Contains(t, err.Error(), "snapshots are stale")
+}
+
+func TestWithinTolerance(t *testing.T) {
+	require.True(t, withinTolerance(100, 100, 100))
+	require.True(t, withinTolerance(150, 200, 100))
+	require.True(t, withinTolerance(100+remoteProgressTolerance, 100, 100))
+	require.False(t, withinTolerance(101+remoteProgressTolerance, 100, 100))
+	require.False(t, withinTolerance(100, 101+remoteProgressTolerance, 200+remoteProgressTolerance))
+}
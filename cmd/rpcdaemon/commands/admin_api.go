@@ -5,6 +5,12 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+	"go.uber.org/atomic"
+
+	"github.com/ledgerwatch/erigon/common/memlimit"
+	"github.com/ledgerwatch/erigon/ethdb/backup"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
@@ -17,17 +23,28 @@ type AdminAPI interface {
 	// Peers returns information about the connected remote nodes.
 	// https://geth.ethereum.org/docs/rpc/ns-admin#admin_peers
 	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// BackupDatabase starts copying chaindata into the empty directory dir, on the host of the rpcdaemon, while the
+	// node keeps running. rate caps the read rate (like "64mb", "0" is unlimited), the progress is logged.
+	BackupDatabase(ctx context.Context, dir string, rate *string) error
+
+	// MemoryUsage returns the memory of the process serving it - the node, or the standalone rpcdaemon - with the
+	// part accounted by each component, and the soft limit.
+	MemoryUsage(ctx context.Context) (memlimit.Stats, error)
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
 type AdminAPIImpl struct {
 	ethBackend rpchelper.ApiBackend
+	db         kv.RoDB
+	backingUp  atomic.Bool
 }
 
 // NewAdminAPI returns AdminAPIImpl instance.
-func NewAdminAPI(eth rpchelper.ApiBackend) *AdminAPIImpl {
+func NewAdminAPI(eth rpchelper.ApiBackend, db kv.RoDB) *AdminAPIImpl {
 	return &AdminAPIImpl{
 		ethBackend: eth,
+		db:         db,
 	}
 }
 
@@ -47,3 +64,27 @@ func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
 func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 	return api.ethBackend.Peers(ctx)
 }
+
+func (api *AdminAPIImpl) BackupDatabase(ctx context.Context, dir string, rate *string) error {
+	cfg := backup.DefaultCfg
+	if rate != nil {
+		if err := cfg.BytesPerSecond.UnmarshalText([]byte(*rate)); err != nil {
+			return fmt.Errorf("invalid rate: %w", err)
+		}
+	}
+	if !api.backingUp.CAS(false, true) {
+		return errors.New("a backup is already running")
+	}
+	go func() {
+		defer api.backingUp.Store(false)
+		// Not ctx: the backup outlives the request
+		if err := backup.ToDir(context.Background(), "admin_backupDatabase", api.db, dir, cfg); err != nil {
+			log.Error("[admin_backupDatabase] backup failed", "to", dir, "err", err)
+		}
+	}()
+	return nil
+}
+
+func (api *AdminAPIImpl) MemoryUsage(ctx context.Context) (memlimit.Stats, error) {
+	return memlimit.Default.Stats(), nil
+}
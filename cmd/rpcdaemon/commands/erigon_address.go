@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+var errNoAddressActivity = errors.New("the address activity index is not built by this node, it needs the CallTraces stage and an Erigon version maintaining " + rawdb.AddressActivity)
+
+// AddressSummary is the activity of an address, as indexed by the CallTraces stage up to IndexedBlock
+type AddressSummary struct {
+	Address libcommon.Address `json:"address"`
+	// CreationBlock is the first block the address appears in, as sender, recipient or created contract, nil if it never did
+	CreationBlock *hexutil.Uint64 `json:"creationBlock"`
+	// LastActiveBlock is the last block the address appears in, nil if it never did
+	LastActiveBlock *hexutil.Uint64 `json:"lastActiveBlock"`
+	// TxCountEstimate is a lower bound of the number of transactions involving the address: the largest of its nonce and
+	// of the number of blocks it appears in
+	TxCountEstimate hexutil.Uint64 `json:"txCountEstimate"`
+	HasCode         bool           `json:"hasCode"`
+	IndexedBlock    hexutil.Uint64 `json:"indexedBlock"`
+}
+
+// GetAddressSummary implements erigon_getAddressSummary. Returns when an address was first and last active, roughly
+// how many transactions it was involved in and whether it holds code, without walking its history
+func (api *ErigonImpl) GetAddressSummary(ctx context.Context, address libcommon.Address) (*AddressSummary, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if api.historyV3(tx) {
+		return nil, errNoAddressActivity
+	}
+	if migrator, ok := tx.(kv.BucketMigrator); ok {
+		if exists, err := migrator.ExistsBucket(rawdb.AddressActivity); err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, errNoAddressActivity
+		}
+	}
+	indexed, err := stages.GetStageProgress(tx, stages.CallTraces)
+	if err != nil {
+		return nil, err
+	}
+	summary := &AddressSummary{Address: address, IndexedBlock: hexutil.Uint64(indexed)}
+
+	activity, err := rawdb.ReadAddressActivity(tx, address)
+	if err != nil {
+		return nil, err
+	}
+	if activity != nil {
+		first, last := hexutil.Uint64(activity.FirstBlock), hexutil.Uint64(activity.LastBlock)
+		summary.CreationBlock, summary.LastActiveBlock = &first, &last
+		summary.TxCountEstimate = hexutil.Uint64(activity.Blocks)
+	}
+
+	reader, err := rpchelper.CreateStateReader(ctx, tx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, api.filters, api.stateCache, false, "", api.strictLatest)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if acc != nil {
+		summary.HasCode = !acc.IsEmptyCodeHash()
+		if nonce := hexutil.Uint64(acc.Nonce); nonce > summary.TxCountEstimate {
+			summary.TxCountEstimate = nonce
+		}
+	}
+	return summary, nil
+}
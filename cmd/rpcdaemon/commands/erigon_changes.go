@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+const (
+	// ChainChangeBlock adds a block on top of the chain of the indexer
+	ChainChangeBlock = "block"
+	// ChainChangeRollback removes the blocks of the indexer above the common ancestor of its chain and the canonical one
+	ChainChangeRollback = "rollback"
+
+	defaultChainChangesLimit = 1024
+)
+
+// ChangesCursor is the position of an indexer in the changes of the canonical chain. It's all the node needs to
+// continue from there: an indexer which stores it with the data of the changes it has processed, and passes it back on
+// the next call, gets every change at least once and in order, across the restarts of the node and its own.
+type ChangesCursor struct {
+	Seq    hexutil.Uint64 `json:"seq"`    // number of changes up to this position, it increases with every change
+	Number hexutil.Uint64 `json:"number"` // the last block of the indexer
+	Hash   common.Hash    `json:"hash"`
+}
+
+// ChainChange is a block added to the chain of the indexer, or a rollback of its chain to the common ancestor with the
+// canonical chain, whose number and hash are the ones of the change.
+type ChainChange struct {
+	Type       string         `json:"type"`
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash,omitempty"`
+	Cursor     ChangesCursor  `json:"cursor"` // the position after this change
+}
+
+// ChainChanges are the changes after a cursor, Cursor is the position after the last one.
+type ChainChanges struct {
+	Changes []ChainChange `json:"changes"`
+	Cursor  ChangesCursor `json:"cursor"`
+}
+
+// GetChainChanges implements erigon_getChainChanges. Returns up to limit changes which bring the chain of an indexer at
+// cursor to the canonical chain up to the latest block, from the genesis block when cursor is nil. When the block of the
+// cursor isn't canonical anymore, the first change is a rollback to the common ancestor.
+func (api *ErigonImpl) GetChainChanges(ctx context.Context, cursor *ChangesCursor, limit *hexutil.Uint64) (*ChainChanges, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	max := uint64(defaultChainChangesLimit)
+	if limit != nil && uint64(*limit) < max {
+		max = uint64(*limit)
+	}
+	if max == 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	latest, err := rpchelper.GetLatestBlockNumber(tx, api.strictLatest)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChainChanges{Changes: []ChainChange{}}
+	next := uint64(0)
+	if cursor != nil {
+		result.Cursor = *cursor
+		// walk back the chain of the cursor to a canonical block, not above the latest one
+		number, hash := uint64(cursor.Number), cursor.Hash
+		for {
+			if number <= latest {
+				canonical, err := api._blockReader.CanonicalHash(ctx, tx, number)
+				if err != nil {
+					return nil, err
+				}
+				if canonical == hash {
+					break
+				}
+			}
+			if number == 0 {
+				return nil, fmt.Errorf("cursor on another chain, genesis %x", hash)
+			}
+			header, err := api._blockReader.Header(ctx, tx, hash, number)
+			if err != nil {
+				return nil, err
+			}
+			if header == nil {
+				return nil, fmt.Errorf("block %d %x of the cursor not found", number, hash)
+			}
+			number, hash = number-1, header.ParentHash
+		}
+		if number != uint64(cursor.Number) {
+			result.add(ChainChange{Type: ChainChangeRollback, Number: hexutil.Uint64(number), Hash: hash})
+		}
+		next = number + 1
+	}
+
+	for ; next <= latest && uint64(len(result.Changes)) < max; next++ {
+		header, err := api._blockReader.HeaderByNumber(ctx, tx, next)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("block header not found: %d", next)
+		}
+		result.add(ChainChange{Type: ChainChangeBlock, Number: hexutil.Uint64(next), Hash: header.Hash(), ParentHash: header.ParentHash})
+	}
+	return result, nil
+}
+
+// add appends change, positioned after the previous ones.
+func (c *ChainChanges) add(change ChainChange) {
+	c.Cursor.Seq++
+	c.Cursor.Number, c.Cursor.Hash = change.Number, change.Hash
+	change.Cursor = c.Cursor
+	c.Changes = append(c.Changes, change)
+}
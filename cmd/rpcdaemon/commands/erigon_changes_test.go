@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+func TestGetChainChanges(t *testing.T) {
+	m := stages.Mock(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{1})
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	// a longer fork after the first block
+	fork, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 4, func(i int, b *core.BlockGen) {
+		if i == 0 {
+			b.SetCoinbase(libcommon.Address{1})
+		} else {
+			b.SetCoinbase(libcommon.Address{2})
+		}
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	br := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), br, m.HistoryV3Components(), false, rpccfg.DefaultEvmCallTimeout, m.Engine), m.DB, nil)
+
+	// from the genesis, in two pages
+	limit := hexutil.Uint64(2)
+	first, err := api.GetChainChanges(m.Ctx, nil, &limit)
+	require.NoError(t, err)
+	require.Len(t, first.Changes, 2)
+	require.Equal(t, ChainChange{Type: ChainChangeBlock, Number: 0, Hash: m.Genesis.Hash(), Cursor: ChangesCursor{Seq: 1, Number: 0, Hash: m.Genesis.Hash()}}, first.Changes[0])
+	second, err := api.GetChainChanges(m.Ctx, &first.Cursor, nil)
+	require.NoError(t, err)
+	require.Len(t, second.Changes, 2)
+	require.Equal(t, ChangesCursor{Seq: 4, Number: 3, Hash: chain.TopBlock.Hash()}, second.Cursor)
+	for i, change := range second.Changes {
+		require.Equal(t, ChainChangeBlock, change.Type)
+		require.Equal(t, chain.Blocks[i+1].Hash(), change.Hash)
+		require.Equal(t, chain.Blocks[i].Hash(), change.ParentHash)
+	}
+
+	// nothing new
+	same, err := api.GetChainChanges(m.Ctx, &second.Cursor, nil)
+	require.NoError(t, err)
+	require.Empty(t, same.Changes)
+	require.Equal(t, second.Cursor, same.Cursor)
+
+	// the fork replaces the blocks after the first one
+	require.NoError(t, m.InsertChain(fork))
+	reorg, err := api.GetChainChanges(m.Ctx, &second.Cursor, nil)
+	require.NoError(t, err)
+	require.Len(t, reorg.Changes, 4)
+	require.Equal(t, ChainChange{Type: ChainChangeRollback, Number: 1, Hash: fork.Blocks[0].Hash(), Cursor: ChangesCursor{Seq: 5, Number: 1, Hash: fork.Blocks[0].Hash()}}, reorg.Changes[0])
+	for i, change := range reorg.Changes[1:] {
+		require.Equal(t, ChainChangeBlock, change.Type)
+		require.Equal(t, hexutil.Uint64(i+2), change.Number)
+		require.Equal(t, fork.Blocks[i+1].Hash(), change.Hash)
+	}
+	require.Equal(t, ChangesCursor{Seq: 8, Number: 4, Hash: fork.TopBlock.Hash()}, reorg.Cursor)
+
+	_, err = api.GetChainChanges(m.Ctx, &ChangesCursor{Number: 2, Hash: libcommon.Hash{1}}, nil)
+	require.Error(t, err)
+}
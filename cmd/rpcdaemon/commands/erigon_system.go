@@ -54,7 +54,12 @@ func (api *ErigonImpl) BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.Bloc
 	var blockNum uint64
 	switch rpcBlockNum {
 	case rpc.LatestBlockNumber:
-		blockNum, err = rpchelper.GetLatestBlockNumber(tx)
+		blockNum, err = rpchelper.GetLatestBlockNumber(tx, api.strictLatest)
+		if err != nil {
+			return 0, err
+		}
+	case rpc.OptimisticBlockNumber:
+		blockNum, err = rpchelper.GetOptimisticBlockNumber(tx)
 		if err != nil {
 			return 0, err
 		}
@@ -79,3 +84,34 @@ func (api *ErigonImpl) BlockNumber(ctx context.Context, rpcBlockNumPtr *rpc.Bloc
 
 	return hexutil.Uint64(blockNum), nil
 }
+
+// IndexingStatus is a data type to report which blocks historical queries can be served for
+type IndexingStatus struct {
+	ExecutedBlock hexutil.Uint64          `json:"executedBlock"`
+	Indexes       []rpchelper.IndexStatus `json:"indexes"`
+}
+
+// IndexingStatus implements erigon_indexingStatus. Returns the block ranges covered by each historical index,
+// queries outside of them fail with a "not indexed" error carrying the covered range
+func (api *ErigonImpl) IndexingStatus(ctx context.Context) (IndexingStatus, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return IndexingStatus{}, err
+	}
+	defer tx.Rollback()
+
+	executed, err := rpchelper.GetLatestExecutedBlockNumber(tx)
+	if err != nil {
+		return IndexingStatus{}, err
+	}
+	historyV3 := api.historyV3(tx)
+	status := IndexingStatus{ExecutedBlock: hexutil.Uint64(executed)}
+	for _, stage := range rpchelper.IndexStages {
+		index, err := rpchelper.GetIndexStatus(tx, stage, historyV3)
+		if err != nil {
+			return IndexingStatus{}, err
+		}
+		status.Indexes = append(status.Indexes, index)
+	}
+	return status, nil
+}
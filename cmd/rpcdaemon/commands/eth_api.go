@@ -3,6 +3,7 @@ package commands
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"math/big"
 	"sync"
 	"time"
@@ -24,13 +25,18 @@ import (
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/txcond"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
 	ethFilters "github.com/ledgerwatch/erigon/eth/filters"
 	"github.com/ledgerwatch/erigon/rpc"
 	ethapi2 "github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/shards"
 )
 
 // EthAPI is a collection of functions that are exposed in the
@@ -83,8 +89,9 @@ type EthAPI interface {
 
 	// Sending related (see ./eth_call.go)
 	Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi2.StateOverrides) (hexutil.Bytes, error)
-	EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error)
+	EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, optionsOrNil *EstimateGasOptions) (hexutil.Uint64, error)
 	SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error)
+	SendRawTransactionConditional(ctx context.Context, encodedTx hexutil.Bytes, options txcond.Options) (common.Hash, error)
 	SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
 	Sign(ctx context.Context, _ common.Address, _ hexutil.Bytes) (hexutil.Bytes, error)
 	SignTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
@@ -117,6 +124,7 @@ type BaseAPI struct {
 	_engine      consensus.EngineReader
 
 	evmCallTimeout time.Duration
+	strictLatest   bool // "latest" resolves to the last VALID block instead of the forkchoice head (--rpc.latest.strict)
 }
 
 func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader services.FullBlockReader, agg *libstate.AggregatorV3, singleNodeMode bool, evmCallTimeout time.Duration, engine consensus.EngineReader) *BaseAPI {
@@ -129,7 +137,11 @@ func NewBaseApi(f *rpchelper.Filters, stateCache kvcache.Cache, blockReader serv
 		panic(err)
 	}
 
-	return &BaseAPI{filters: f, stateCache: stateCache, blocksLRU: blocksLRU, _blockReader: blockReader, _txnReader: blockReader, _agg: agg, evmCallTimeout: evmCallTimeout, _engine: engine}
+	api := &BaseAPI{filters: f, stateCache: stateCache, blocksLRU: blocksLRU, _blockReader: blockReader, _txnReader: blockReader, _agg: agg, evmCallTimeout: evmCallTimeout, _engine: engine}
+	if f != nil {
+		f.SetPendingStateBuilder(api.buildPendingState)
+	}
+	return api
 }
 
 func (api *BaseAPI) chainConfig(tx kv.Tx) (*chain.Config, error) {
@@ -174,6 +186,9 @@ func (api *BaseAPI) blockByHashWithSenders(tx kv.Tx, hash common.Hash) (*types.B
 }
 
 func (api *BaseAPI) blockWithSenders(tx kv.Tx, hash common.Hash, number uint64) (*types.Block, error) {
+	if pending := api.pendingBlock(); pending != nil && pending.Hash() == hash {
+		return pending, nil
+	}
 	if api.blocksLRU != nil {
 		if it, ok := api.blocksLRU.Get(hash); ok && it != nil {
 			return it.(*types.Block), nil
@@ -247,11 +262,45 @@ func (api *BaseAPI) chainConfigWithGenesis(tx kv.Tx) (*chain.Config, *types.Bloc
 }
 
 func (api *BaseAPI) pendingBlock() *types.Block {
+	if api.filters == nil {
+		return nil
+	}
 	return api.filters.LastPendingBlock()
 }
 
+// buildPendingState executes the transactions of the pending block on top of the state of its parent, and returns the
+// writes of the block, see rpchelper.CreatePendingStateReader.
+func (api *BaseAPI) buildPendingState(ctx context.Context, tx kv.Tx, block *types.Block, parent state.StateReader) (*shards.StateCache, error) {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	header := block.HeaderNoCopy()
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		h, _ := api._blockReader.Header(ctx, tx, hash, number)
+		return h
+	}
+	getHash := core.GetHashFn(header, getHeader)
+	rules := chainConfig.Rules(header.Number.Uint64(), header.Time)
+
+	writes := shards.NewStateCache(32, 0)
+	ibs := state.New(state.NewCachedReader(parent, shards.NewStateCache(32, 0)))
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	var usedGas uint64
+	for i, txn := range block.Transactions() {
+		ibs.Prepare(txn.Hash(), block.Hash(), i)
+		if _, _, err := core.ApplyTransaction(chainConfig, getHash, api.engine(), &header.Coinbase, gp, ibs, state.NewNoopWriter(), header, txn, &usedGas, vm.Config{}); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	if err := ibs.CommitBlock(rules, state.NewCachedWriter(state.NewNoopWriter(), writes)); err != nil {
+		return nil, err
+	}
+	return writes, nil
+}
+
 func (api *BaseAPI) blockByRPCNumber(number rpc.BlockNumber, tx kv.Tx) (*types.Block, error) {
-	n, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters)
+	n, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters, api.strictLatest)
 	if err != nil {
 		return nil, err
 	}
@@ -261,7 +310,7 @@ func (api *BaseAPI) blockByRPCNumber(number rpc.BlockNumber, tx kv.Tx) (*types.B
 }
 
 func (api *BaseAPI) headerByRPCNumber(number rpc.BlockNumber, tx kv.Tx) (*types.Header, error) {
-	n, h, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters)
+	n, h, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(number), tx, api.filters, api.strictLatest)
 	if err != nil {
 		return nil, err
 	}
@@ -278,6 +327,10 @@ type APIImpl struct {
 	db              kv.RoDB
 	GasCap          uint64
 	ReturnDataLimit int
+	strictSyncing   bool // eth_syncing only returns the standard fields (--rpc.syncing.strict)
+	syncProgress    *syncProgressTracker
+	download        *shards.SnapshotsDownload // nil in a standalone rpcdaemon
+	txConditions    *txcond.Store             // nil in a standalone rpcdaemon, which doesn't build blocks
 }
 
 // NewEthAPI returns APIImpl instance
@@ -295,6 +348,7 @@ func NewEthAPI(base *BaseAPI, db kv.RoDB, eth rpchelper.ApiBackend, txPool txpoo
 		gasCache:        NewGasPriceCache(),
 		GasCap:          gascap,
 		ReturnDataLimit: returnDataLimit,
+		syncProgress:    newSyncProgressTracker(),
 	}
 }
 
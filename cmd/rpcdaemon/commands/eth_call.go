@@ -49,7 +49,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 		args.Gas = (*hexutil.Uint64)(&api.GasCap)
 	}
 
-	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters, api.strictLatest) // DoCall cannot be executed on non-canonical blocks
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +61,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 		return nil, nil
 	}
 
-	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName)
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, 0, api.filters, api.stateCache, api.historyV3(tx), chainConfig.ChainName, api.strictLatest)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +85,7 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi2.CallArgs, blockNrOrHa
 
 // headerByNumberOrHash - intent to read recent headers only, tries from the lru cache before reading from the db
 func headerByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash, api *APIImpl) (*types.Header, error) {
-	_, bNrOrHashHash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
+	_, bNrOrHashHash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters, api.strictLatest)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +94,7 @@ func headerByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 		return block.Header(), nil
 	}
 
-	blockNum, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	blockNum, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters, api.strictLatest)
 	if err != nil {
 		return nil, err
 	}
@@ -106,13 +106,32 @@ func headerByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 	return header, nil
 }
 
+// EstimateGasOptions are the optional parameters of eth_estimateGas which trade the accuracy of the estimate for the
+// number of executions of the call.
+type EstimateGasOptions struct {
+	// ErrorRatio stops the binary search once the range of the gas left to search is within this fraction of the
+	// estimate, the estimate being its upper end, so that it's never too low. Zero searches down to the exact gas.
+	ErrorRatio float64 `json:"errorRatio"`
+	// LowerBound is the gas the call is known to need at least, the search doesn't try below it.
+	LowerBound *hexutil.Uint64 `json:"lowerBound"`
+	// UpperBound caps the gas, on top of the gas of the call and the gas cap of the node.
+	UpperBound *hexutil.Uint64 `json:"upperBound"`
+}
+
 // EstimateGas implements eth_estimateGas. Returns an estimate of how much gas is necessary to allow the transaction to complete. The transaction will not be added to the blockchain.
-func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, optionsOrNil *EstimateGasOptions) (hexutil.Uint64, error) {
 	var args ethapi2.CallArgs
 	// if we actually get CallArgs here, we use them
 	if argsOrNil != nil {
 		args = *argsOrNil
 	}
+	var options EstimateGasOptions
+	if optionsOrNil != nil {
+		options = *optionsOrNil
+	}
+	if options.ErrorRatio < 0 || options.ErrorRatio >= 1 {
+		return 0, fmt.Errorf("error ratio %v out of range [0, 1)", options.ErrorRatio)
+	}
 
 	dbtx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -212,6 +231,15 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", api.GasCap)
 		hi = api.GasCap
 	}
+	if options.UpperBound != nil && uint64(*options.UpperBound) < hi {
+		hi = uint64(*options.UpperBound)
+	}
+	if options.LowerBound != nil && uint64(*options.LowerBound) > lo+1 {
+		lo = uint64(*options.LowerBound) - 1
+	}
+	if lo >= hi {
+		return 0, fmt.Errorf("gas lower bound %d above the allowance (%d)", lo+1, hi)
+	}
 	cap = hi
 
 	chainConfig, err := api.chainConfig(dbtx)
@@ -220,7 +248,7 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 	}
 	engine := api.engine()
 
-	latestCanBlockNumber, latestCanHash, isLatest, err := rpchelper.GetCanonicalBlockNumber(latestNumOrHash, dbtx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	latestCanBlockNumber, latestCanHash, isLatest, err := rpchelper.GetCanonicalBlockNumber(latestNumOrHash, dbtx, api.filters, api.strictLatest) // DoCall cannot be executed on non-canonical blocks
 	if err != nil {
 		return 0, err
 	}
@@ -263,8 +291,56 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 		return result.Failed(), result, nil
 	}
 
+	// Reject the transaction as invalid if it fails at the highest allowance, with the same error as eth_call
+	failed, result, err := executable(hi)
+	if err != nil {
+		return 0, err
+	}
+	if failed {
+		if result != nil && !errors.Is(result.Err, vm.ErrOutOfGas) {
+			if len(result.Revert()) > 0 {
+				return 0, ethapi2.NewRevertError(result)
+			}
+			return 0, result.Err
+		}
+		// Otherwise, the specified gas cap is too low
+		return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+	}
+	// The call can't need less than the gas it used, refunds included
+	if result.UsedGas > lo+1 {
+		lo = result.UsedGas - 1
+	}
+
+	// Plain transfers and most calls without refunds run with their intrinsic gas, skip the search for them
+	rules := chainConfig.Rules(header.Number.Uint64(), header.Time)
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	var accessList types2.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+	intrinsic, err := core.IntrinsicGas(data, accessList, args.To == nil, rules.IsHomestead, rules.IsIstanbul, (&vm.Config{}).HasEip3860(rules))
+	if err != nil {
+		return 0, err
+	}
+	if intrinsic > lo && intrinsic < hi {
+		failed, _, err := executable(intrinsic)
+		if err != nil {
+			return 0, err
+		}
+		if !failed {
+			return hexutil.Uint64(intrinsic), nil
+		}
+		lo = intrinsic
+	}
+
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
+		if options.ErrorRatio > 0 && float64(hi-lo)/float64(hi) < options.ErrorRatio {
+			break
+		}
 		mid := (hi + lo) / 2
 		failed, _, err := executable(mid)
 		// If the error is not nil(consensus error), it means the provided message
@@ -279,24 +355,6 @@ func (api *APIImpl) EstimateGas(ctx context.Context, argsOrNil *ethapi2.CallArgs
 			hi = mid
 		}
 	}
-
-	// Reject the transaction as invalid if it still fails at the highest allowance
-	if hi == cap {
-		failed, result, err := executable(hi)
-		if err != nil {
-			return 0, err
-		}
-		if failed {
-			if result != nil && !errors.Is(result.Err, vm.ErrOutOfGas) {
-				if len(result.Revert()) > 0 {
-					return 0, ethapi2.NewRevertError(result)
-				}
-				return 0, result.Err
-			}
-			// Otherwise, the specified gas cap is too low
-			return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
-		}
-	}
 	return hexutil.Uint64(hi), nil
 }
 
@@ -307,6 +365,9 @@ func (api *APIImpl) GetProof(ctx context.Context, address libcommon.Address, sto
 }
 
 func (api *APIImpl) tryBlockFromLru(hash libcommon.Hash) *types.Block {
+	if pending := api.pendingBlock(); pending != nil && pending.Hash() == hash {
+		return pending
+	}
 	var block *types.Block
 	if api.blocksLRU != nil {
 		if it, ok := api.blocksLRU.Get(hash); ok && it != nil {
@@ -346,7 +407,7 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi2.CallArgs,
 	}
 	engine := api.engine()
 
-	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(bNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(bNrOrHash, tx, api.filters, api.strictLatest) // DoCall cannot be executed on non-canonical blocks
 	if err != nil {
 		return nil, err
 	}
@@ -48,9 +48,26 @@ func TestEstimateGas(t *testing.T) {
 	if _, err := api.EstimateGas(context.Background(), &ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, nil); err != nil {
+	}, nil, nil); err != nil {
 		t.Errorf("calling EstimateGas: %v", err)
 	}
+	// a transfer runs with its intrinsic gas
+	gas, err := api.EstimateGas(context.Background(), &ethapi.CallArgs{
+		From: &from,
+		To:   &to,
+	}, nil, &EstimateGasOptions{ErrorRatio: 0.01})
+	if err != nil {
+		t.Errorf("calling EstimateGas: %v", err)
+	} else if uint64(gas) != params.TxGas {
+		t.Errorf("wrong estimate: %d", gas)
+	}
+	upper := hexutil.Uint64(params.TxGas - 1)
+	if _, err := api.EstimateGas(context.Background(), &ethapi.CallArgs{
+		From: &from,
+		To:   &to,
+	}, nil, &EstimateGasOptions{UpperBound: &upper}); err == nil {
+		t.Errorf("expected an error below the intrinsic gas")
+	}
 }
 
 func TestEthCallNonCanonical(t *testing.T) {
@@ -3,6 +3,8 @@ package commands
 import (
 	"context"
 	"math/big"
+	"sync"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -25,7 +27,7 @@ func (api *APIImpl) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
 		return 0, err
 	}
 	defer tx.Rollback()
-	blockNum, err := rpchelper.GetLatestBlockNumber(tx)
+	blockNum, err := rpchelper.GetLatestBlockNumber(tx, api.strictLatest)
 	if err != nil {
 		return 0, err
 	}
@@ -33,6 +35,8 @@ func (api *APIImpl) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
 }
 
 // Syncing implements eth_syncing. Returns a data object detailing the status of the sync process or false if not syncing.
+// Unless --rpc.syncing.strict is set, the object also contains erigon-specific details: per-stage progress and target,
+// snapshots download status (only when the rpcdaemon runs inside erigon) and time estimates.
 func (api *APIImpl) Syncing(ctx context.Context) (interface{}, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -50,13 +54,27 @@ func (api *APIImpl) Syncing(ctx context.Context) (interface{}, error) {
 	}
 
 	if currentBlock > 0 && currentBlock >= highestBlock { // Return not syncing if the synchronisation already completed
+		// the next catch-up starts its own estimates
+		api.syncProgress.reset()
 		return false, nil
 	}
 
+	now := time.Now()
+	startingBlock := api.syncProgress.startingBlock(currentBlock, now)
+	if api.strictSyncing {
+		return map[string]interface{}{
+			"startingBlock": hexutil.Uint64(startingBlock),
+			"currentBlock":  hexutil.Uint64(currentBlock),
+			"highestBlock":  hexutil.Uint64(highestBlock),
+		}, nil
+	}
+
 	// Otherwise gather the block sync stats
 	type S struct {
-		StageName   string         `json:"stage_name"`
-		BlockNumber hexutil.Uint64 `json:"block_number"`
+		StageName   string          `json:"stage_name"`
+		BlockNumber hexutil.Uint64  `json:"block_number"`
+		TargetBlock hexutil.Uint64  `json:"target_block"`
+		SecondsLeft *hexutil.Uint64 `json:"estimated_seconds_left,omitempty"`
 	}
 	stagesMap := make([]S, len(stages.AllStages))
 	for i, stage := range stages.AllStages {
@@ -66,13 +84,89 @@ func (api *APIImpl) Syncing(ctx context.Context) (interface{}, error) {
 		}
 		stagesMap[i].StageName = string(stage)
 		stagesMap[i].BlockNumber = hexutil.Uint64(progress)
+		stagesMap[i].TargetBlock = hexutil.Uint64(highestBlock)
+		if left, ok := api.syncProgress.timeLeft(stage, progress, highestBlock, now); ok {
+			seconds := hexutil.Uint64(left / time.Second)
+			stagesMap[i].SecondsLeft = &seconds
+		}
+	}
+
+	res := map[string]interface{}{
+		"startingBlock": hexutil.Uint64(startingBlock),
+		"currentBlock":  hexutil.Uint64(currentBlock),
+		"highestBlock":  hexutil.Uint64(highestBlock),
+		"stages":        stagesMap,
+	}
+	if left, ok := api.syncProgress.timeLeft(stages.Finish, currentBlock, highestBlock, now); ok {
+		res["estimatedSecondsLeft"] = hexutil.Uint64(left / time.Second)
+	}
+	if download, ok := api.download.Last(); ok {
+		snapshots := map[string]interface{}{
+			"completed":      download.Completed,
+			"progress":       download.Percent,
+			"bytesCompleted": hexutil.Uint64(download.BytesCompleted),
+			"bytesTotal":     hexutil.Uint64(download.BytesTotal),
+		}
+		if left := download.TimeLeft(); left > 0 {
+			snapshots["estimatedSecondsLeft"] = hexutil.Uint64(left / time.Second)
+		}
+		res["snapshots"] = snapshots
+	}
+	return res, nil
+}
+
+// syncProgressTracker remembers the first progress of each stage observed by eth_syncing,
+// and estimates remaining time from the average speed since then.
+type syncProgressTracker struct {
+	lock  sync.Mutex
+	first map[stages.SyncStage]syncProgressSample
+}
+
+type syncProgressSample struct {
+	block uint64
+	at    time.Time
+}
+
+func newSyncProgressTracker() *syncProgressTracker {
+	return &syncProgressTracker{first: map[stages.SyncStage]syncProgressSample{}}
+}
+
+// startingBlock returns the block at which this node was first observed syncing.
+func (t *syncProgressTracker) startingBlock(current uint64, now time.Time) uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if s, ok := t.first[stages.Finish]; ok && s.block <= current {
+		return s.block
 	}
+	t.first[stages.Finish] = syncProgressSample{block: current, at: now}
+	return current
+}
+
+// reset forgets the observed progress, once the node caught up with the head.
+func (t *syncProgressTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.first = map[stages.SyncStage]syncProgressSample{}
+}
 
-	return map[string]interface{}{
-		"currentBlock": hexutil.Uint64(currentBlock),
-		"highestBlock": hexutil.Uint64(highestBlock),
-		"stages":       stagesMap,
-	}, nil
+// timeLeft returns the estimated time for stage to get from current to target. The second result is false
+// while no estimate is possible: on the first observation, after an unwind, or when the stage did not move.
+func (t *syncProgressTracker) timeLeft(stage stages.SyncStage, current, target uint64, now time.Time) (time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	s, ok := t.first[stage]
+	if !ok || current < s.block {
+		t.first[stage] = syncProgressSample{block: current, at: now}
+		return 0, false
+	}
+	if current >= target {
+		return 0, true
+	}
+	elapsed := now.Sub(s.at)
+	if current == s.block || elapsed <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) * float64(target-current) / float64(current-s.block)), true
 }
 
 // ChainId implements eth_chainId. Returns the current ethereum chainId.
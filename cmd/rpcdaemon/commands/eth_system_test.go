@@ -5,17 +5,24 @@ import (
 	"math"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
+	stagedsyncstages "github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/stages"
 )
@@ -92,3 +99,66 @@ func createGasPriceTestKV(t *testing.T, chainSize int) *stages.MockSentry {
 
 	return m
 }
+
+func TestSyncing(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.NoError(t, stagedsyncstages.SaveStageProgress(tx, stagedsyncstages.Headers, 100))
+	require.NoError(t, stagedsyncstages.SaveStageProgress(tx, stagedsyncstages.Finish, 10))
+	require.NoError(t, tx.Commit())
+
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	base := NewBaseApi(nil, stateCache, snapshotsync.NewBlockReader(), nil, false, rpccfg.DefaultEvmCallTimeout, nil)
+
+	strict := NewEthAPI(base, db, nil, nil, nil, 5000000, 100_000)
+	strict.strictSyncing = true
+	res, err := strict.Syncing(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"startingBlock": hexutil.Uint64(10),
+		"currentBlock":  hexutil.Uint64(10),
+		"highestBlock":  hexutil.Uint64(100),
+	}, res)
+
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000, 100_000)
+	api.download = &shards.SnapshotsDownload{}
+	res, err = api.Syncing(context.Background())
+	require.NoError(t, err)
+	extended := res.(map[string]interface{})
+	require.Equal(t, hexutil.Uint64(10), extended["currentBlock"])
+	require.Contains(t, extended, "stages")
+	require.NotContains(t, extended, "snapshots")
+
+	// the progress the Snapshots stage recorded
+	api.download.Update(&proto_downloader.StatsReply{Progress: 50, BytesCompleted: 100, BytesTotal: 200, DownloadRate: 10})
+	res, err = api.Syncing(context.Background())
+	require.NoError(t, err)
+	snapshots := res.(map[string]interface{})["snapshots"].(map[string]interface{})
+	require.Equal(t, hexutil.Uint64(10), snapshots["estimatedSecondsLeft"])
+}
+
+func TestSyncProgressTimeLeft(t *testing.T) {
+	tracker := newSyncProgressTracker()
+	start := time.Now()
+	_, ok := tracker.timeLeft(stagedsyncstages.Execution, 100, 1100, start)
+	require.False(t, ok)
+	_, ok = tracker.timeLeft(stagedsyncstages.Execution, 100, 1100, start.Add(time.Minute))
+	require.False(t, ok) // did not move
+
+	left, ok := tracker.timeLeft(stagedsyncstages.Execution, 200, 1100, start.Add(10*time.Second))
+	require.True(t, ok)
+	require.Equal(t, 90*time.Second, left)
+
+	_, ok = tracker.timeLeft(stagedsyncstages.Execution, 50, 1100, start.Add(20*time.Second))
+	require.False(t, ok) // unwind resets the estimate
+
+	// once synced, the next catch-up starts from where it is observed again
+	require.Equal(t, uint64(100), tracker.startingBlock(100, start))
+	require.Equal(t, uint64(100), tracker.startingBlock(1100, start.Add(time.Minute)))
+	tracker.reset()
+	require.Equal(t, uint64(1100), tracker.startingBlock(1100, start.Add(time.Hour)))
+	_, ok = tracker.timeLeft(stagedsyncstages.Execution, 1100, 2100, start.Add(time.Hour))
+	require.False(t, ok)
+}
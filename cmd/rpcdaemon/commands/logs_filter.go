@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/ethdb/cbor"
+)
+
+// logsFilter is the address and topics criteria of a logs query, prepared once for the whole query. The indices
+// narrow the query to the blocks (or transactions) which may hold a matching log, the filter then selects the logs.
+type logsFilter struct {
+	addresses map[common.Address]struct{}
+	topics    []map[common.Hash]struct{} // by position, nil matches any topic
+}
+
+func newLogsFilter(crit filters.FilterCriteria) *logsFilter {
+	f := &logsFilter{
+		addresses: make(map[common.Address]struct{}, len(crit.Addresses)),
+		topics:    make([]map[common.Hash]struct{}, len(crit.Topics)),
+	}
+	for _, addr := range crit.Addresses {
+		f.addresses[addr] = struct{}{}
+	}
+	for i, sub := range crit.Topics {
+		if len(sub) == 0 {
+			continue
+		}
+		f.topics[i] = make(map[common.Hash]struct{}, len(sub))
+		for _, topic := range sub {
+			f.topics[i][topic] = struct{}{}
+		}
+	}
+	return f
+}
+
+// match reports whether log matches the criteria, like types.Logs.Filter.
+func (f *logsFilter) match(log *types.Log) bool {
+	if len(f.addresses) > 0 {
+		if _, ok := f.addresses[log.Address]; !ok {
+			return false
+		}
+	}
+	if len(f.topics) > len(log.Topics) {
+		return false
+	}
+	for i, set := range f.topics {
+		if set == nil {
+			continue
+		}
+		if _, ok := set[log.Topics[i]]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *logsFilter) filter(logs types.Logs) types.Logs {
+	filtered := make(types.Logs, 0, len(logs))
+	for _, log := range logs {
+		if f.match(log) {
+			filtered = append(filtered, log)
+		}
+	}
+	return filtered
+}
+
+// mayMatch reports whether the encoded logs of a transaction may hold a matching log, without decoding them. The
+// addresses and topics are encoded as plain byte strings, so the encoding of a matching log holds one of the
+// addresses and a topic of each position of the criteria.
+func (f *logsFilter) mayMatch(encoded []byte) bool {
+	if len(f.addresses) > 0 {
+		found := false
+		for addr := range f.addresses {
+			if bytes.Contains(encoded, addr[:]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, set := range f.topics {
+		if set == nil {
+			continue
+		}
+		found := false
+		for topic := range set {
+			if bytes.Contains(encoded, topic[:]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// getBlockLogs returns the logs of the canonical block blockNumber which match the filter, with their index in the
+// block and the index of their transaction. Only the logs of the transactions which may match are decoded, the
+// others are just counted for the log indices.
+func getBlockLogs(tx kv.Tx, blockNumber uint64, filter *logsFilter) ([]*types.Log, error) {
+	var logIndex uint
+	var blockLogs []*types.Log
+	it, err := tx.Prefix(kv.Log, hexutility.EncodeTs(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	for it.HasNext() {
+		k, v, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !filter.mayMatch(v) {
+			count, err := cbor.ArrayLen(v)
+			if err != nil {
+				return nil, fmt.Errorf("receipt unmarshal failed:  %w", err)
+			}
+			logIndex += uint(count)
+			continue
+		}
+
+		var logs types.Logs
+		if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
+			return nil, fmt.Errorf("receipt unmarshal failed:  %w", err)
+		}
+		txIndex := uint(binary.BigEndian.Uint32(k[8:]))
+		for _, log := range logs {
+			log.Index = logIndex
+			logIndex++
+			if filter.match(log) {
+				log.TxIndex = txIndex
+				blockLogs = append(blockLogs, log)
+			}
+		}
+	}
+	return blockLogs, nil
+}
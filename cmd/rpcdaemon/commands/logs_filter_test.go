@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+)
+
+func TestGetBlockLogs(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	addrA, addrB := libcommon.Address{0xa}, libcommon.Address{0xb}
+	topic1, topic2 := libcommon.Hash{1}, libcommon.Hash{2}
+	receipts := types.Receipts{
+		{Logs: types.Logs{{Address: addrA, Topics: []libcommon.Hash{topic1}}, {Address: addrA}}},
+		{},
+		{Logs: types.Logs{{Address: addrB, Topics: []libcommon.Hash{topic2, topic1}}}},
+		{Logs: types.Logs{{Address: addrB, Topics: []libcommon.Hash{topic1}}, {Address: addrA, Topics: []libcommon.Hash{topic2}}}},
+	}
+	require.NoError(t, rawdb.WriteReceipts(tx, 7, receipts))
+
+	type match struct{ index, txIndex uint }
+	for _, tt := range []struct {
+		name string
+		crit filters.FilterCriteria
+		want []match
+	}{
+		{"all", filters.FilterCriteria{}, []match{{0, 0}, {1, 0}, {2, 2}, {3, 3}, {4, 3}}},
+		{"address", filters.FilterCriteria{Addresses: []libcommon.Address{addrB}}, []match{{2, 2}, {3, 3}}},
+		{"topic", filters.FilterCriteria{Topics: [][]libcommon.Hash{{topic2}}}, []match{{2, 2}, {4, 3}}},
+		{"wildcard topic", filters.FilterCriteria{Topics: [][]libcommon.Hash{{}, {topic1}}}, []match{{2, 2}}},
+		{"address and topic", filters.FilterCriteria{Addresses: []libcommon.Address{addrA}, Topics: [][]libcommon.Hash{{topic2}}}, []match{{4, 3}}},
+		{"no match", filters.FilterCriteria{Addresses: []libcommon.Address{{0xc}}}, nil},
+	} {
+		logs, err := getBlockLogs(tx, 7, newLogsFilter(tt.crit))
+		require.NoError(t, err, tt.name)
+		var got []match
+		for _, log := range logs {
+			got = append(got, match{log.Index, log.TxIndex})
+		}
+		require.Equal(t, tt.want, got, tt.name)
+	}
+}
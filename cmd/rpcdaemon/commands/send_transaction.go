@@ -6,22 +6,74 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	txPoolProto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
 	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/txcond"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 // SendRawTransaction implements eth_sendRawTransaction. Creates new message call transaction or a contract creation for previously-signed transactions.
 func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error) {
+	return api.sendRawTransaction(ctx, encodedTx, nil)
+}
+
+// SendRawTransactionConditional implements eth_sendRawTransactionConditional. Like eth_sendRawTransaction, but the transaction
+// is only accepted, and included in blocks built by this node, while the block number, timestamp and storage conditions hold.
+// Conditions are checked against the next block on submission, and then by the block builder, which only shares them
+// with rpcdaemon running inside the erigon process: a standalone rpcdaemon refuses conditional transactions. The
+// conditions are kept until the transaction leaves the pool, new ones are refused while too many are pending.
+func (api *APIImpl) SendRawTransactionConditional(ctx context.Context, encodedTx hexutil.Bytes, options txcond.Options) (common.Hash, error) {
+	if api.txConditions == nil {
+		return common.Hash{}, fmt.Errorf("conditional transactions are not supported by a standalone rpcdaemon, their conditions can't be enforced")
+	}
+	if err := options.Validate(); err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer tx.Rollback()
+	latest := rawdb.ReadCurrentHeader(tx)
+	if latest == nil {
+		return common.Hash{}, fmt.Errorf("no current header")
+	}
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), 0, api.filters, api.stateCache, api.historyV3(tx), cc.ChainName, api.strictLatest)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// the timestamp the block builder gives the next block, see SpawnMiningCreateBlockStage
+	timestamp := uint64(time.Now().Unix())
+	if latest.Time >= timestamp {
+		timestamp = latest.Time + 1
+	}
+	next := &types.Header{Number: new(big.Int).Add(latest.Number, big.NewInt(1)), Time: timestamp}
+	if err := options.Check(next, stateReader); err != nil {
+		return common.Hash{}, err
+	}
+	tx.Rollback()
+	return api.sendRawTransaction(ctx, encodedTx, &options)
+}
+
+func (api *APIImpl) sendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes, options *txcond.Options) (common.Hash, error) {
 	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
 	if err != nil {
 		return common.Hash{}, err
@@ -36,12 +88,26 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
 	hash := txn.Hash()
+	// registered before the pool can hand the transaction to the block builder, and only forgotten below if it was
+	// registered by this call: a resubmission keeps the conditions of the first submission
+	var registered bool
+	if options != nil {
+		if registered, err = api.registerTxConditions(ctx, hash, options); err != nil {
+			return common.Hash{}, err
+		}
+	}
 	res, err := api.txPool.Add(ctx, &txPoolProto.AddRequest{RlpTxs: [][]byte{encodedTx}})
 	if err != nil {
+		if registered {
+			api.forgetTxConditions(hash)
+		}
 		return common.Hash{}, err
 	}
 
 	if res.Imported[0] != txPoolProto.ImportResult_SUCCESS {
+		if registered {
+			api.forgetTxConditions(hash)
+		}
 		return hash, fmt.Errorf("%s: %s", txPoolProto.ImportResult_name[int32(res.Imported[0])], res.Errors[0])
 	}
 
@@ -84,6 +150,40 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 	return txn.Hash(), nil
 }
 
+// registerTxConditions records the conditions of a transaction. When the store is full, the conditions of the
+// transactions which left the pool are forgotten first.
+func (api *APIImpl) registerTxConditions(ctx context.Context, hash common.Hash, options *txcond.Options) (bool, error) {
+	registered, err := api.txConditions.Register(hash, options)
+	if !errors.Is(err, txcond.ErrStoreFull) {
+		return registered, err
+	}
+	hashes := api.txConditions.Hashes()
+	request := &txPoolProto.TransactionsRequest{Hashes: make([]*types2.H256, len(hashes))}
+	for i, h := range hashes {
+		request.Hashes[i] = gointerfaces.ConvertHashToH256(h)
+	}
+	reply, err := api.txPool.Transactions(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	var gone []common.Hash
+	for i, rlpTx := range reply.RlpTxs {
+		if len(rlpTx) == 0 {
+			gone = append(gone, hashes[i])
+		}
+	}
+	if err := api.txConditions.Forget(gone...); err != nil {
+		return false, err
+	}
+	return api.txConditions.Register(hash, options)
+}
+
+func (api *APIImpl) forgetTxConditions(hash common.Hash) {
+	if err := api.txConditions.Forget(hash); err != nil {
+		log.Warn("Could not save the transaction conditions", "err", err)
+	}
+}
+
 // SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
 func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
 	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
@@ -2,6 +2,7 @@ package commands_test
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"math/big"
 	"testing"
@@ -17,6 +18,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common/u256"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/txcond"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/params"
@@ -101,6 +103,13 @@ func TestSendRawTransaction(t *testing.T) {
 	//require.Equal(eth.ToProto[m.MultiClient.Protocol()][eth.NewPooledTransactionHashesMsg], sent.Id)
 }
 
+func TestSendRawTransactionConditionalStandalone(t *testing.T) {
+	// without the block builder in the process, the conditions can't be enforced
+	api := commands.NewEthAPI(commands.NewBaseApi(nil, nil, nil, nil, false, rpccfg.DefaultEvmCallTimeout, nil), nil, nil, nil, nil, 5000000, 100_000)
+	_, err := api.SendRawTransactionConditional(context.Background(), nil, txcond.Options{})
+	require.ErrorContains(t, err, "standalone rpcdaemon")
+}
+
 func transaction(nonce uint64, gaslimit uint64, key *ecdsa.PrivateKey) types.Transaction {
 	return pricedTransaction(nonce, gaslimit, u256.Num1, key)
 }
@@ -0,0 +1,139 @@
+package rpcservices
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/notify"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// ErrSecondary is returned by the methods which need the private api of Erigon, when RPCDaemon only reads its datadir.
+var ErrSecondary = errors.New("not available with --datadir.secondary")
+
+// SecondaryBackend serves the backend of RPCDaemon from the datadir of Erigon alone: blocks are read from the
+// database, and the new heads and snapshots are followed through the head file that Erigon publishes in its datadir.
+type SecondaryBackend struct {
+	services.FullBlockReader
+	db          kv.RoDB
+	chainConfig *chain.Config
+	dataDir     string
+	interval    time.Duration
+}
+
+func NewSecondaryBackend(db kv.RoDB, blockReader services.FullBlockReader, chainConfig *chain.Config, dataDir string) *SecondaryBackend {
+	return &SecondaryBackend{
+		FullBlockReader: blockReader,
+		db:              db,
+		chainConfig:     chainConfig,
+		dataDir:         dataDir,
+		interval:        notify.DefaultInterval,
+	}
+}
+
+func (back *SecondaryBackend) Etherbase(ctx context.Context) (libcommon.Address, error) {
+	return libcommon.Address{}, ErrSecondary
+}
+
+func (back *SecondaryBackend) NetVersion(ctx context.Context) (uint64, error) {
+	return back.chainConfig.ChainID.Uint64(), nil
+}
+
+func (back *SecondaryBackend) NetPeerCount(ctx context.Context) (uint64, error) {
+	return 0, ErrSecondary
+}
+
+func (back *SecondaryBackend) ProtocolVersion(ctx context.Context) (uint64, error) {
+	return 66, nil
+}
+
+func (back *SecondaryBackend) ClientVersion(ctx context.Context) (string, error) {
+	return common.MakeName("erigon", params.Version), nil
+}
+
+// Subscribe sends a HEADER event for every head published by Erigon, and a NEW_SNAPSHOT event when it opened new
+// snapshot files, until ctx is done.
+func (back *SecondaryBackend) Subscribe(ctx context.Context, onNewEvent func(*remote.SubscribeReply)) error {
+	var last notify.Head
+	for head := range notify.Watch(ctx, back.dataDir, back.interval) {
+		if head.Snapshots != last.Snapshots {
+			onNewEvent(&remote.SubscribeReply{Type: remote.Event_NEW_SNAPSHOT})
+		}
+		if head.Hash != last.Hash && head.Hash != (libcommon.Hash{}) {
+			headerRlp, err := back.headerRlp(ctx, head)
+			if err != nil {
+				log.Warn("[rpc] reading new head", "number", head.Number, "err", err)
+			} else if headerRlp != nil {
+				onNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: headerRlp})
+			}
+		}
+		last = head
+	}
+	return nil
+}
+
+func (back *SecondaryBackend) headerRlp(ctx context.Context, head notify.Head) ([]byte, error) {
+	tx, err := back.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	header, err := back.Header(ctx, tx, head.Hash, head.Number)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(header)
+}
+
+// SubscribeLogs blocks until ctx is done: the logs are only streamed by Erigon over the private api.
+func (back *SecondaryBackend) SubscribeLogs(ctx context.Context, onNewLogs func(reply *remote.SubscribeLogsReply), requestor *atomic.Value) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (back *SecondaryBackend) EngineNewPayload(ctx context.Context, payload *types2.ExecutionPayload) (*remote.EnginePayloadStatus, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) EngineForkchoiceUpdated(ctx context.Context, request *remote.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) EngineGetPayload(ctx context.Context, payloadId uint64) (*remote.EngineGetPayloadResponse, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) EngineGetPayloadBodiesByHashV1(ctx context.Context, request *remote.EngineGetPayloadBodiesByHashV1Request) (*remote.EngineGetPayloadBodiesV1Response, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) EngineGetPayloadBodiesByRangeV1(ctx context.Context, request *remote.EngineGetPayloadBodiesByRangeV1Request) (*remote.EngineGetPayloadBodiesV1Response, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error) {
+	return nil, ErrSecondary
+}
+
+func (back *SecondaryBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	return nil, ErrSecondary
+}
+
+// PendingBlock returns nil, the pending block only lives in the memory of Erigon.
+func (back *SecondaryBackend) PendingBlock(ctx context.Context) (*types.Block, error) {
+	return nil, nil
+}
@@ -4,28 +4,33 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/urfave/cli/v2"
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/cli/flags"
 )
 
 type ConsensusClientCliCfg struct {
-	GenesisCfg     *clparams.GenesisConfig     `json:"genesisCfg"`
-	BeaconCfg      *clparams.BeaconChainConfig `json:"beaconCfg"`
-	NetworkCfg     *clparams.NetworkConfig     `json:"networkCfg"`
-	BeaconDataCfg  *rawdb.BeaconDataConfig     `json:"beaconDataConfig"`
-	Port           uint                        `json:"port"`
-	Addr           string                      `json:"address"`
-	ServerAddr     string                      `json:"serverAddr"`
-	ServerProtocol string                      `json:"serverProtocol"`
-	ServerTcpPort  uint                        `json:"serverTcpPort"`
-	LogLvl         uint                        `json:"logLevel"`
-	NoDiscovery    bool                        `json:"noDiscovery"`
-	CheckpointUri  string                      `json:"checkpointUri"`
-	Chaindata      string                      `json:"chaindata"`
-	ELEnabled      bool                        `json:"elEnabled"`
+	GenesisCfg         *clparams.GenesisConfig     `json:"genesisCfg"`
+	BeaconCfg          *clparams.BeaconChainConfig `json:"beaconCfg"`
+	NetworkCfg         *clparams.NetworkConfig     `json:"networkCfg"`
+	BeaconDataCfg      *rawdb.BeaconDataConfig     `json:"beaconDataConfig"`
+	Port               uint                        `json:"port"`
+	Addr               string                      `json:"address"`
+	ServerAddr         string                      `json:"serverAddr"`
+	ServerProtocol     string                      `json:"serverProtocol"`
+	ServerTcpPort      uint                        `json:"serverTcpPort"`
+	LogLvl             uint                        `json:"logLevel"`
+	NoDiscovery        bool                        `json:"noDiscovery"`
+	CheckpointUri      string                      `json:"checkpointUri"`
+	DepositSnapshotUri string                      `json:"depositSnapshotUri"`
+	BeaconApiAddr      string                      `json:"beaconApiAddr"`
+	Chaindata          string                      `json:"chaindata"`
+	ELEnabled          bool                        `json:"elEnabled"`
+	MemorySoftLimit    datasize.ByteSize           `json:"memorySoftLimit"`
 }
 
 func SetupConsensusClientCfg(ctx *cli.Context) (*ConsensusClientCliCfg, error) {
@@ -64,9 +69,22 @@ func SetupConsensusClientCfg(ctx *cli.Context) (*ConsensusClientCliCfg, error) {
 	} else {
 		cfg.CheckpointUri = clparams.GetCheckpointSyncEndpoint(network)
 	}
+	if ctx.String(flags.DepositSnapshotUrlFlag.Name) != "" {
+		cfg.DepositSnapshotUri = ctx.String(flags.DepositSnapshotUrlFlag.Name)
+	} else if cfg.CheckpointUri != "" {
+		if cfg.DepositSnapshotUri, err = core.DepositSnapshotEndpoint(cfg.CheckpointUri); err != nil {
+			return nil, err
+		}
+	}
+	cfg.BeaconApiAddr = ctx.String(flags.BeaconApiAddrFlag.Name)
 	cfg.Chaindata = ctx.String(flags.ChaindataFlag.Name)
 	cfg.ELEnabled = ctx.Bool(flags.ELEnabledFlag.Name)
 	cfg.BeaconDataCfg = rawdb.BeaconDataConfigurations[ctx.String(flags.BeaconDBModeFlag.Name)]
+	if limit := ctx.String(flags.MemorySoftLimitFlag.Name); limit != "" {
+		if err := cfg.MemorySoftLimit.UnmarshalText([]byte(limit)); err != nil {
+			return nil, fmt.Errorf("option %q: %w", flags.MemorySoftLimitFlag.Name, err)
+		}
+	}
 	// Process bootnodes
 	if ctx.String(flags.BootnodesFlag.Name) != "" {
 		cfg.NetworkCfg.BootNodes = strings.Split(ctx.String(flags.BootnodesFlag.Name), ",")
@@ -18,4 +18,7 @@ var CLDefaultFlags = []cli.Flag{
 	&BeaconConfigFlag,
 	&GenesisSSZFlag,
 	&CheckpointSyncUrlFlag,
+	&DepositSnapshotUrlFlag,
+	&BeaconApiAddrFlag,
+	&MemorySoftLimitFlag,
 }
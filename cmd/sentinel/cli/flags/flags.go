@@ -78,4 +78,19 @@ var (
 		Usage: "checkpoint sync endpoint",
 		Value: "",
 	}
+	DepositSnapshotUrlFlag = cli.StringFlag{
+		Name:  "deposit-snapshot-url",
+		Usage: "deposit tree snapshot (EIP-4881) endpoint, by default the one of the checkpoint sync node",
+		Value: "",
+	}
+	BeaconApiAddrFlag = cli.StringFlag{
+		Name:  "beacon.api.addr",
+		Usage: "address to serve the Beacon API on, disabled when empty",
+		Value: "",
+	}
+	MemorySoftLimitFlag = cli.StringFlag{
+		Name:  "memory.soft-limit",
+		Usage: "memory of the process at which the caches of duties are dropped, no limit when empty",
+		Value: "",
+	}
 )
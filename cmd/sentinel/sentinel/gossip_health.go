@@ -0,0 +1,224 @@
+/*
+   Copyright 2022 Erigon-Lightclient contributors
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+       http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package sentinel
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/log/v3"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const (
+	// how often topic meshes are checked
+	gossipHealthCheckInterval = time.Minute
+	// maximum number of peers dropped per check, to make room for discovery to find peers subscribed to weak topics
+	gossipRotatePeers = 2
+)
+
+var gossipIWantCounter = metrics.GetOrCreateCounter(`sentinel_gossip_iwant_total`)
+
+// topicHealth keeps the gossipsub mesh of one topic and counters of the messages seen on it.
+type topicHealth struct {
+	mesh map[peer.ID]struct{}
+
+	meshPeers  *metrics.Counter
+	delivered  *metrics.Counter
+	duplicates *metrics.Counter
+	ihave      *metrics.Counter
+}
+
+func newTopicHealth(topic string) *topicHealth {
+	label := topicLabel(topic)
+	return &topicHealth{
+		mesh:       map[peer.ID]struct{}{},
+		meshPeers:  metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_mesh_peers{topic="%s"}`, label)),
+		delivered:  metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_delivered_total{topic="%s"}`, label)),
+		duplicates: metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_duplicates_total{topic="%s"}`, label)),
+		ihave:      metrics.GetOrCreateCounter(fmt.Sprintf(`sentinel_gossip_ihave_total{topic="%s"}`, label)),
+	}
+}
+
+// topicLabel strips the fork digest and codec off a topic path: /eth2/<digest>/beacon_block/ssz_snappy => beacon_block.
+func topicLabel(topic string) string {
+	parts := strings.Split(strings.TrimPrefix(topic, gossipTopicPrefix), "/")
+	if len(parts) < 2 {
+		return topic
+	}
+	return parts[1]
+}
+
+// gossipTracer is a pubsub.RawTracer tracking the mesh of every joined topic, exported as metrics.
+type gossipTracer struct {
+	mu     sync.Mutex
+	topics map[string]*topicHealth
+}
+
+var _ pubsub.RawTracer = &gossipTracer{}
+
+func newGossipTracer() *gossipTracer {
+	return &gossipTracer{topics: map[string]*topicHealth{}}
+}
+
+// topic must be called with the lock held.
+func (g *gossipTracer) topic(topic string) *topicHealth {
+	t, ok := g.topics[topic]
+	if !ok {
+		t = newTopicHealth(topic)
+		g.topics[topic] = t
+	}
+	return t
+}
+
+// meshSizes returns the number of mesh peers per joined topic.
+func (g *gossipTracer) meshSizes() map[string]int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	sizes := make(map[string]int, len(g.topics))
+	for topic, t := range g.topics {
+		sizes[topic] = len(t.mesh)
+	}
+	return sizes
+}
+
+func (g *gossipTracer) Join(topic string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.topic(topic)
+}
+
+func (g *gossipTracer) Leave(topic string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if t, ok := g.topics[topic]; ok {
+		t.meshPeers.Set(0)
+		delete(g.topics, topic)
+	}
+}
+
+func (g *gossipTracer) Graft(p peer.ID, topic string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t := g.topic(topic)
+	t.mesh[p] = struct{}{}
+	t.meshPeers.Set(uint64(len(t.mesh)))
+}
+
+func (g *gossipTracer) Prune(p peer.ID, topic string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t := g.topic(topic)
+	delete(t.mesh, p)
+	t.meshPeers.Set(uint64(len(t.mesh)))
+}
+
+func (g *gossipTracer) RemovePeer(p peer.ID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, t := range g.topics {
+		delete(t.mesh, p)
+		t.meshPeers.Set(uint64(len(t.mesh)))
+	}
+}
+
+func (g *gossipTracer) DeliverMessage(msg *pubsub.Message) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.topic(msg.GetTopic()).delivered.Inc()
+}
+
+func (g *gossipTracer) DuplicateMessage(msg *pubsub.Message) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.topic(msg.GetTopic()).duplicates.Inc()
+}
+
+func (g *gossipTracer) RecvRPC(rpc *pubsub.RPC) {
+	control := rpc.GetControl()
+	if control == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, ihave := range control.GetIhave() {
+		if t, ok := g.topics[ihave.GetTopicID()]; ok {
+			t.ihave.Add(len(ihave.GetMessageIDs()))
+		}
+	}
+	for _, iwant := range control.GetIwant() {
+		gossipIWantCounter.Add(len(iwant.GetMessageIDs()))
+	}
+}
+
+func (g *gossipTracer) AddPeer(peer.ID, protocol.ID)             {}
+func (g *gossipTracer) ValidateMessage(*pubsub.Message)          {}
+func (g *gossipTracer) RejectMessage(*pubsub.Message, string)    {}
+func (g *gossipTracer) ThrottlePeer(peer.ID)                     {}
+func (g *gossipTracer) SendRPC(*pubsub.RPC, peer.ID)             {}
+func (g *gossipTracer) DropRPC(*pubsub.RPC, peer.ID)             {}
+func (g *gossipTracer) UndeliverableMessage(msg *pubsub.Message) {}
+
+// rotationCandidates picks up to max connected peers which are not subscribed to a topic.
+func rotationCandidates(connected, topicPeers []peer.ID, max int) []peer.ID {
+	subscribed := make(map[peer.ID]struct{}, len(topicPeers))
+	for _, p := range topicPeers {
+		subscribed[p] = struct{}{}
+	}
+	var candidates []peer.ID
+	for _, p := range connected {
+		if len(candidates) >= max {
+			break
+		}
+		if _, ok := subscribed[p]; !ok {
+			candidates = append(candidates, p)
+		}
+	}
+	return candidates
+}
+
+// monitorGossipHealth checks the mesh of every topic: when a mesh is below the low watermark and we are at
+// the peer limit (so discovery is paused), a few peers not subscribed to the topic are dropped to make room.
+func (s *Sentinel) monitorGossipHealth() {
+	ticker := time.NewTicker(gossipHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for topic, size := range s.gossipTracer.meshSizes() {
+			if size >= gossipSubDlo {
+				continue
+			}
+			sub, ok := s.subManager.GetSubscription(topic)
+			if !ok || sub.topic == nil {
+				continue
+			}
+			log.Debug("[Gossip] Unhealthy topic mesh", "topic", topicLabel(topic), "mesh", size, "subscribed", len(sub.topic.ListPeers()))
+			if !s.HasTooManyPeers() {
+				continue
+			}
+			for _, p := range rotationCandidates(s.host.Network().Peers(), sub.topic.ListPeers(), gossipRotatePeers) {
+				s.peers.DisconnectPeer(p)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sentinel
+
+import (
+	"testing"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGossipTracer(t *testing.T) {
+	const topic = "/eth2/4a26c58b/beacon_block/ssz_snappy"
+	require.Equal(t, "beacon_block", topicLabel(topic))
+
+	g := newGossipTracer()
+	g.Join(topic)
+	g.Graft("peer1", topic)
+	g.Graft("peer2", topic)
+	g.Graft("peer3", topic)
+	g.Prune("peer2", topic)
+	g.RemovePeer("peer3")
+	require.Equal(t, map[string]int{topic: 1}, g.meshSizes())
+	require.Equal(t, uint64(1), g.topics[topic].meshPeers.Get())
+
+	topicName := topic
+	delivered := g.topics[topic].delivered.Get()
+	duplicates := g.topics[topic].duplicates.Get()
+	g.DeliverMessage(&pubsub.Message{Message: &pubsub_pb.Message{Topic: &topicName}})
+	g.DuplicateMessage(&pubsub.Message{Message: &pubsub_pb.Message{Topic: &topicName}})
+	g.DuplicateMessage(&pubsub.Message{Message: &pubsub_pb.Message{Topic: &topicName}})
+	require.Equal(t, delivered+1, g.topics[topic].delivered.Get())
+	require.Equal(t, duplicates+2, g.topics[topic].duplicates.Get())
+
+	ihave := g.topics[topic].ihave.Get()
+	g.RecvRPC(&pubsub.RPC{RPC: pubsub_pb.RPC{Control: &pubsub_pb.ControlMessage{
+		Ihave: []*pubsub_pb.ControlIHave{{TopicID: &topicName, MessageIDs: []string{"a", "b"}}},
+	}}})
+	require.Equal(t, ihave+2, g.topics[topic].ihave.Get())
+
+	g.Leave(topic)
+	require.Empty(t, g.meshSizes())
+}
+
+func TestRotationCandidates(t *testing.T) {
+	connected := []peer.ID{"a", "b", "c", "d"}
+	require.Equal(t, []peer.ID{"b", "d"}, rotationCandidates(connected, []peer.ID{"a", "c"}, 2))
+	require.Equal(t, []peer.ID{"a"}, rotationCandidates(connected, nil, 1))
+	require.Empty(t, rotationCandidates(connected, connected, 2))
+}
@@ -14,58 +14,87 @@
 package handlers
 
 import (
+	"github.com/ledgerwatch/erigon/cl/clparams"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/cl/cltypes/ssz_utils"
 	"github.com/ledgerwatch/erigon/cl/fork"
 	"github.com/ledgerwatch/erigon/cmd/erigon-cl/core/rawdb"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/communication/ssz_snappy"
-	"github.com/ledgerwatch/log/v3"
 	"github.com/libp2p/go-libp2p/core/network"
 )
 
-func (c *ConsensusHandlers) lightClientFinalityUpdateHandler(stream network.Stream) {
-	if c.db == nil {
-		stream.Write([]byte{ResourceUnavaiablePrefix})
-		return
-	}
+// maxRequestLightClientUpdates is MAX_REQUEST_LIGHT_CLIENT_UPDATES of the light client networking spec.
+const maxRequestLightClientUpdates = 128
 
+// writeLightClientResponse writes one response chunk: the result code, the fork digest as context and the payload.
+func (c *ConsensusHandlers) writeLightClientResponse(stream network.Stream, val ssz_utils.Marshaler) error {
 	forkDigest, err := fork.ComputeForkDigest(c.beaconConfig, c.genesisConfig)
 	if err != nil {
-		stream.Close()
+		return err
+	}
+	if _, err := stream.Write(append([]byte{SuccessfulResponsePrefix}, forkDigest[:]...)); err != nil {
+		return err
+	}
+	return ssz_snappy.EncodeAndWrite(stream, val)
+}
+
+func (c *ConsensusHandlers) lightClientBootstrapHandler(stream network.Stream) {
+	if c.db == nil {
+		stream.Write([]byte{ResourceUnavaiablePrefix})
 		return
 	}
-	if _, err := stream.Write(forkDigest[:]); err != nil {
+	req := &cltypes.SingleRoot{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.Phase0Version); err != nil {
 		stream.Close()
 		return
 	}
-	// Read latest lightclient update
 	tx, err := c.db.BeginRo(c.ctx)
 	if err != nil {
 		stream.Close()
 		return
 	}
 	defer tx.Rollback()
-	update, err := rawdb.ReadLightClientFinalityUpdate(tx)
+	bootstrap, err := rawdb.ReadLightClientBootstrap(tx, req.Root)
 	if err != nil {
 		stream.Close()
 		return
 	}
-
-	ssz_snappy.EncodeAndWrite(stream, update, SuccessfulResponsePrefix)
+	if bootstrap == nil {
+		stream.Write([]byte{ResourceUnavaiablePrefix})
+		return
+	}
+	c.writeLightClientResponse(stream, bootstrap)
 }
 
-func (c *ConsensusHandlers) lightClientOptimisticUpdateHandler(stream network.Stream) {
+func (c *ConsensusHandlers) lightClientFinalityUpdateHandler(stream network.Stream) {
 	if c.db == nil {
 		stream.Write([]byte{ResourceUnavaiablePrefix})
 		return
 	}
-	forkDigest, err := fork.ComputeForkDigest(c.beaconConfig, c.genesisConfig)
+	// Read latest lightclient update
+	tx, err := c.db.BeginRo(c.ctx)
 	if err != nil {
 		stream.Close()
 		return
 	}
-	if _, err := stream.Write(forkDigest[:]); err != nil {
+	defer tx.Rollback()
+	update, err := rawdb.ReadLightClientFinalityUpdate(tx)
+	if err != nil {
 		stream.Close()
 		return
 	}
+	if update == nil {
+		stream.Write([]byte{ResourceUnavaiablePrefix})
+		return
+	}
+	c.writeLightClientResponse(stream, update)
+}
+
+func (c *ConsensusHandlers) lightClientOptimisticUpdateHandler(stream network.Stream) {
+	if c.db == nil {
+		stream.Write([]byte{ResourceUnavaiablePrefix})
+		return
+	}
 	// Read latest lightclient update
 	tx, err := c.db.BeginRo(c.ctx)
 	if err != nil {
@@ -78,15 +107,50 @@ func (c *ConsensusHandlers) lightClientOptimisticUpdateHandler(stream network.St
 		stream.Close()
 		return
 	}
-
-	ssz_snappy.EncodeAndWrite(stream, update, SuccessfulResponsePrefix)
+	if update == nil {
+		stream.Write([]byte{ResourceUnavaiablePrefix})
+		return
+	}
+	c.writeLightClientResponse(stream, update)
 }
 
-func (c *ConsensusHandlers) lightClientUpdatesByRange(stream network.Stream) {
+// lightClientUpdatesByRangeHandler responds with the best updates of the consecutive sync committee periods requested,
+// stopping at the first period it has no update for.
+func (c *ConsensusHandlers) lightClientUpdatesByRangeHandler(stream network.Stream) {
 	if c.db == nil {
 		stream.Write([]byte{ResourceUnavaiablePrefix})
 		return
 	}
-	log.Info("Got lightClientUpdatesByRange handler call")
-	// TODO: ADD Proper logic
+	req := &cltypes.LightClientUpdatesByRangeRequest{}
+	if err := ssz_snappy.DecodeAndReadNoForkDigest(stream, req, clparams.Phase0Version); err != nil {
+		stream.Close()
+		return
+	}
+	count := req.Count
+	if count > maxRequestLightClientUpdates {
+		count = maxRequestLightClientUpdates
+	}
+	tx, err := c.db.BeginRo(c.ctx)
+	if err != nil {
+		stream.Close()
+		return
+	}
+	defer tx.Rollback()
+	for i := uint64(0); i < count; i++ {
+		update, err := rawdb.ReadLightClientUpdate(tx, uint32(req.Period+i))
+		if err != nil {
+			stream.Close()
+			return
+		}
+		if update == nil {
+			if i == 0 {
+				stream.Write([]byte{ResourceUnavaiablePrefix})
+			}
+			return
+		}
+		if err := c.writeLightClientResponse(stream, update); err != nil {
+			stream.Close()
+			return
+		}
+	}
 }
@@ -56,6 +56,7 @@ type Sentinel struct {
 	pubsub         *pubsub.PubSub
 	subManager     *GossipManager
 	gossipTopics   []GossipTopic
+	gossipTracer   *gossipTracer
 }
 
 func (s *Sentinel) createLocalNode(
@@ -162,6 +163,7 @@ func (s *Sentinel) pubsubOptions() []pubsub.Option {
 		pubsub.WithMaxMessageSize(int(s.cfg.NetworkConfig.GossipMaxSize)),
 		pubsub.WithValidateQueueSize(pubsubQueueSize),
 		pubsub.WithGossipSubParams(gsp),
+		pubsub.WithRawTracer(s.gossipTracer),
 	}
 	return psOpts
 }
@@ -174,9 +176,10 @@ func New(
 	rule handshake.RuleFunc,
 ) (*Sentinel, error) {
 	s := &Sentinel{
-		ctx: ctx,
-		cfg: cfg,
-		db:  db,
+		ctx:          ctx,
+		cfg:          cfg,
+		db:           db,
+		gossipTracer: newGossipTracer(),
 	}
 
 	// Setup discovery
@@ -250,6 +253,7 @@ func (s *Sentinel) Start() error {
 		go s.listenForPeers()
 	}
 	s.subManager = NewGossipManager(s.ctx)
+	go s.monitorGossipHealth()
 	return nil
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
 
+	"github.com/ledgerwatch/erigon/common/seen"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/p2p"
@@ -29,8 +30,13 @@ const (
 	// This is the target size for the packs of transactions or announcements. A
 	// pack can get larger than this if a single transactions exceeds this size.
 	maxTxPacketSize = 100 * 1024
+	// seenTxsCapacity is about the number of remote transactions announced in a few minutes
+	seenTxsCapacity = 1 << 18
 )
 
+// seenRemoteTxs drops the remote transactions which were already announced
+var seenRemoteTxs = seen.Shared("remote_txs", seenTxsCapacity, 0.0001)
+
 func (cs *MultiClient) PropagateNewBlockHashes(ctx context.Context, announces []headerdownload.Announce) {
 	cs.lock.RLock()
 	defer cs.lock.RUnlock()
@@ -191,6 +197,13 @@ func (cs *MultiClient) BroadcastLocalPooledTxs(ctx context.Context, txs []libcom
 }
 
 func (cs *MultiClient) BroadcastRemotePooledTxs(ctx context.Context, txs []libcommon.Hash) {
+	fresh := txs[:0:0]
+	for i := range txs {
+		if !seenRemoteTxs.Seen(txs[i][:]) {
+			fresh = append(fresh, txs[i])
+		}
+	}
+	txs = fresh
 	if len(txs) == 0 {
 		return
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common/paths"
+	"github.com/ledgerwatch/erigon/common/secret"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/turbo/debug"
 	logging2 "github.com/ledgerwatch/erigon/turbo/logging"
@@ -60,7 +61,7 @@ func init() {
 		panic(err)
 	}
 	rootCmd.PersistentFlags().StringVar(&TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
-	rootCmd.PersistentFlags().StringVar(&TLSKeyFile, "tls.key", "", "key file for client side TLS handshake")
+	rootCmd.PersistentFlags().StringVar(&TLSKeyFile, "tls.key", "", "key file for client side TLS handshake, or env:NAME or cmd:program")
 	rootCmd.PersistentFlags().StringVar(&TLSCACert, "tls.cacert", "", "CA certificate for client side TLS handshake")
 
 	rootCmd.PersistentFlags().IntVar(&pendingPoolLimit, "txpool.globalslots", txpool.DefaultConfig.PendingSubPoolLimit, "Maximum number of executable transaction slots for all accounts")
@@ -84,7 +85,7 @@ var rootCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		_ = logging2.GetLoggerCmd("txpool", cmd)
 		ctx := cmd.Context()
-		creds, err := grpcutil.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
+		creds, err := secret.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
 		if err != nil {
 			return fmt.Errorf("could not connect to remoteKv: %w", err)
 		}
@@ -103,7 +104,7 @@ var rootCmd = &cobra.Command{
 
 		sentryClients := make([]direct.SentryClient, len(sentryAddr))
 		for i := range sentryAddr {
-			creds, err := grpcutil.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
+			creds, err := secret.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
 			if err != nil {
 				return fmt.Errorf("could not connect to sentry: %w", err)
 			}
@@ -34,7 +34,6 @@ import (
 	"github.com/ledgerwatch/erigon-lib/common/datadir"
 	"github.com/ledgerwatch/erigon-lib/common/metrics"
 	downloadercfg2 "github.com/ledgerwatch/erigon-lib/downloader/downloadercfg"
-	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/txpool"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
@@ -43,13 +42,18 @@ import (
 
 	"github.com/ledgerwatch/erigon/cl/clparams"
 	"github.com/ledgerwatch/erigon/cmd/downloader/downloadernat"
+	"github.com/ledgerwatch/erigon/common/budget"
+	"github.com/ledgerwatch/erigon/common/memlimit"
 	"github.com/ledgerwatch/erigon/common/paths"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/txfilter"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/crypto/atrest"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/ethdb/cbor"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/enode"
@@ -136,6 +140,10 @@ var (
 		Name:  "externalcl",
 		Usage: "enables external consensus",
 	}
+	L2Flag = cli.StringFlag{
+		Name:  "l2",
+		Usage: "Run as the execution layer of the registered L2 stack of this name: its transaction types, fee hooks and block source replace the ones of L1",
+	}
 	// Transaction pool settings
 	TxPoolDisableFlag = cli.BoolFlag{
 		Name:  "txpool.disable",
@@ -240,6 +248,10 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerTxFilterFileFlag = cli.StringFlag{
+		Name:  "miner.txfilter",
+		Usage: "File with addresses (one per line) whose transactions are excluded from locally built blocks. Block validation is not affected",
+	}
 	VMEnableDebugFlag = cli.BoolFlag{
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
@@ -305,7 +317,7 @@ var (
 
 	JWTSecretPath = cli.StringFlag{
 		Name:  "authrpc.jwtsecret",
-		Usage: "Path to the token that ensures safe connection between CL and EL",
+		Usage: "Path to the token that ensures safe connection between CL and EL, or env:NAME or cmd:program to read it from an environment variable or the output of a program",
 		Value: "",
 	}
 
@@ -346,6 +358,14 @@ var (
 		Name:  "rpc.streaming.disable",
 		Usage: "Erigon has enalbed json streaming for some heavy endpoints (like trace_*). It's treadoff: greatly reduce amount of RAM (in some cases from 30GB to 30mb), but it produce invalid json format if error happened in the middle of streaming (because json is not streaming-friendly format)",
 	}
+	RpcStrictLatestFlag = cli.BoolFlag{
+		Name:  "rpc.latest.strict",
+		Usage: "Resolve the \"latest\" block tag to the last block validated by execution instead of the forkchoice head. The forkchoice head stays available as \"optimistic\"",
+	}
+	RpcStrictSyncingFlag = cli.BoolFlag{
+		Name:  "rpc.syncing.strict",
+		Usage: "Return only the standard fields from eth_syncing (no per-stage progress, snapshots download status and time estimates)",
+	}
 	RpcBatchLimit = cli.IntFlag{
 		Name:  "rpc.batch.limit",
 		Usage: "Maximum number of requests in a batch",
@@ -407,7 +427,7 @@ var (
 	}
 	TLSKeyFlag = cli.StringFlag{
 		Name:  "tls.key",
-		Usage: "Specify key file",
+		Usage: "Specify key file, or env:NAME or cmd:program to read the key from an environment variable or the output of a program",
 		Value: "",
 	}
 	TLSCACertFlag = cli.StringFlag{
@@ -523,6 +543,10 @@ var (
 		Name:  "nodekeyhex",
 		Usage: "P2P node key as hex (for testing)",
 	}
+	NodeKeyEncryptionFlag = cli.StringFlag{
+		Name:  "nodekey.encryption",
+		Usage: "Keep the P2P node key file encrypted with the key from this provider: file:<path of hex key> (or a scheme registered by a plugin)",
+	}
 	NATFlag = cli.StringFlag{
 		Name: "nat",
 		Usage: `NAT port mapping mechanism (any|none|upnp|pmp|stun|extip:<IP>)
@@ -545,6 +569,10 @@ var (
 		Name:  "v5disc",
 		Usage: "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 	}
+	NTPServerFlag = cli.StringFlag{
+		Name:  "ntp.server",
+		Usage: "NTP server to compare the system clock with, at startup and hourly (disabled if empty)",
+	}
 	NetrestrictFlag = cli.StringFlag{
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
@@ -632,6 +660,10 @@ var (
 		Name:  ethconfig.FlagSnapStop,
 		Usage: "Workaround to stop producing new snapshots, if you meet some snapshots-related critical bug. It will stop move historical data from DB to new immutable snapshots. DB will grow and may slightly slow-down - and removing this flag in future will not fix this effect (db size will not greatly reduce).",
 	}
+	SnapHistoryWindowFlag = cli.Uint64Flag{
+		Name:  ethconfig.FlagSnapHistoryWindow,
+		Usage: "Remove snapshots of bodies and transactions of blocks older than this many blocks, once executed, keeping headers. RPC returns an error for such blocks. 0 - keep all history",
+	}
 	TorrentVerbosityFlag = cli.IntFlag{
 		Name:  "torrent.verbosity",
 		Value: 2,
@@ -688,8 +720,28 @@ var (
 	}
 	DbPageSizeFlag = cli.StringFlag{
 		Name:  "db.pagesize",
-		Usage: "set mdbx pagesize on db creation: must be power of 2 and '256b <= pagesize <= 64kb'. default: equal to OperationSystem's pageSize",
-		Value: datasize.ByteSize(kv.DefaultPageSize()).String(),
+		Usage: "set mdbx pagesize on db creation: must be power of 2 and '256b <= pagesize <= 64kb'. default: tuned to the filesystem of --datadir, at least OperationSystem's pageSize",
+	}
+	DbGrowthStepFlag = cli.StringFlag{
+		Name:  "db.growth.step",
+		Usage: "set mdbx growth step of chaindata: the size by which the db file grows when full. default: tuned to the free space of the disk of --datadir, up to 2GB",
+	}
+	DbCborPoolSizeFlag = cli.IntFlag{
+		Name:  "db.cbor.pool.size",
+		Usage: "Number of idle CBOR encoders and decoders kept for reuse, 0 - unbounded (sync.Pool, reclaimed by GC when idle)",
+		Value: cbor.DefaultPoolSize,
+	}
+	BackgroundTasksFlag = cli.IntFlag{
+		Name:  "background.tasks",
+		Usage: "Max number of background tasks (snapshot merges, building of indices) running at once. default: all CPUs but one",
+	}
+	BackgroundMemoryFlag = cli.StringFlag{
+		Name:  "background.memory",
+		Usage: "Max memory used by background tasks (snapshot merges, building of indices) at once, as estimated by them. default: half of RAM",
+	}
+	MemorySoftLimitFlag = cli.StringFlag{
+		Name:  "memory.soft-limit",
+		Usage: "Memory of the process at which it sheds load: new RPC calls are rejected and caches dropped, until it goes back under 90% of it. default: no limit",
 	}
 
 	HealthCheckFlag = cli.BoolFlag{
@@ -758,6 +810,13 @@ func setNodeKey(ctx *cli.Context, cfg *p2p.Config, datadir string) {
 	hex := ctx.String(NodeKeyHexFlag.Name)
 
 	config := p2p.NodeKeyConfig{}
+	if uri := ctx.String(NodeKeyEncryptionFlag.Name); uri != "" {
+		provider, err := atrest.NewKeyProvider(uri)
+		if err != nil {
+			Fatalf("%v", err)
+		}
+		config.Encryption = provider
+	}
 	key, err := config.LoadOrParseOrGenerateAndSave(file, hex, datadir)
 	if err != nil {
 		Fatalf("%v", err)
@@ -1068,6 +1127,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config, nodeName, datadir string) {
 	if ctx.IsSet(DiscoveryV5Flag.Name) {
 		cfg.DiscoveryV5 = ctx.Bool(DiscoveryV5Flag.Name)
 	}
+	cfg.NTPServer = ctx.String(NTPServerFlag.Name)
 
 	ethPeers := cfg.MaxPeers
 	cfg.Name = nodeName
@@ -1168,15 +1228,47 @@ func setDataDir(ctx *cli.Context, cfg *nodecfg.Config) {
 	}
 	cfg.Dirs = datadir.New(cfg.Dirs.DataDir)
 
-	if err := cfg.MdbxPageSize.UnmarshalText([]byte(ctx.String(DbPageSizeFlag.Name))); err != nil {
-		panic(err)
+	if ctx.IsSet(DbPageSizeFlag.Name) {
+		if err := cfg.MdbxPageSize.UnmarshalText([]byte(ctx.String(DbPageSizeFlag.Name))); err != nil {
+			panic(err)
+		}
+		sz := cfg.MdbxPageSize.Bytes()
+		if !isPowerOfTwo(sz) || sz < 256 || sz > 64*1024 {
+			panic(fmt.Errorf("invalid --db.pagesize: %s=%d, see: %s", ctx.String(DbPageSizeFlag.Name), sz, DbPageSizeFlag.Usage))
+		}
 	}
-	sz := cfg.MdbxPageSize.Bytes()
-	if !isPowerOfTwo(sz) || sz < 256 || sz > 64*1024 {
-		panic(fmt.Errorf("invalid --db.pagesize: %s=%d, see: %s", ctx.String(DbPageSizeFlag.Name), sz, DbPageSizeFlag.Usage))
+	if ctx.IsSet(DbGrowthStepFlag.Name) {
+		if err := cfg.MdbxGrowthStep.UnmarshalText([]byte(ctx.String(DbGrowthStepFlag.Name))); err != nil {
+			panic(err)
+		}
 	}
 }
 
+func setBackgroundBudget(ctx *cli.Context) {
+	var maxMem datasize.ByteSize
+	if ctx.IsSet(BackgroundMemoryFlag.Name) {
+		if err := maxMem.UnmarshalText([]byte(ctx.String(BackgroundMemoryFlag.Name))); err != nil {
+			Fatalf("Option %q: %v", BackgroundMemoryFlag.Name, err)
+		}
+	}
+	maxTasks := ctx.Int(BackgroundTasksFlag.Name)
+	if maxTasks < 0 {
+		Fatalf("Option %q: must not be negative, got %d", BackgroundTasksFlag.Name, maxTasks)
+	}
+	budget.Default.SetLimits(maxTasks, maxMem)
+}
+
+func setMemoryLimit(ctx *cli.Context) {
+	if !ctx.IsSet(MemorySoftLimitFlag.Name) {
+		return
+	}
+	var limit datasize.ByteSize
+	if err := limit.UnmarshalText([]byte(ctx.String(MemorySoftLimitFlag.Name))); err != nil {
+		Fatalf("Option %q: %v", MemorySoftLimitFlag.Name, err)
+	}
+	memlimit.Default.SetLimit(limit)
+}
+
 func isPowerOfTwo(n uint64) bool {
 	if n == 0 { //corner case: if n is zero it will also consider as power 2
 		return true
@@ -1403,6 +1495,18 @@ func setMiner(ctx *cli.Context, cfg *params.MiningConfig) {
 	}
 }
 
+func setMinerTxFilter(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.IsSet(MinerTxFilterFileFlag.Name) {
+		return
+	}
+	list, err := txfilter.LoadAddressList(ctx.String(MinerTxFilterFileFlag.Name))
+	if err != nil {
+		Fatalf("Failed to load transaction filter: %v", err)
+	}
+	log.Info("Filtering transactions of locally built blocks", "addresses", list.Len())
+	cfg.MinerTxFilter = list
+}
+
 func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	whitelist := ctx.String(WhitelistFlag.Name)
 	if whitelist == "" {
@@ -1475,6 +1579,17 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.SentinelAddr = ctx.String(SentinelAddrFlag.Name)
 	cfg.SentinelPort = ctx.Uint64(SentinelPortFlag.Name)
 
+	if ctx.IsSet(DbCborPoolSizeFlag.Name) {
+		if size := ctx.Int(DbCborPoolSizeFlag.Name); size >= 0 {
+			cbor.SetPoolSize(size)
+		} else {
+			Fatalf("Option %q: must not be negative, got %d", DbCborPoolSizeFlag.Name, size)
+		}
+	}
+
+	setBackgroundBudget(ctx)
+	setMemoryLimit(ctx)
+
 	cfg.Sync.UseSnapshots = ethconfig.UseSnapshotsByChainName(ctx.String(ChainFlag.Name))
 	if ctx.IsSet(SnapshotFlag.Name) { //force override default by cli
 		cfg.Sync.UseSnapshots = ctx.Bool(SnapshotFlag.Name)
@@ -1483,6 +1598,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	cfg.Dirs = nodeConfig.Dirs
 	cfg.Snapshot.KeepBlocks = ctx.Bool(SnapKeepBlocksFlag.Name)
 	cfg.Snapshot.Produce = !ctx.Bool(SnapStopFlag.Name)
+	cfg.Snapshot.HistoryWindow = ctx.Uint64(SnapHistoryWindowFlag.Name)
 	cfg.Snapshot.NoDownloader = ctx.Bool(NoDownloaderFlag.Name)
 	cfg.Snapshot.Verify = ctx.Bool(DownloaderVerifyFlag.Name)
 	cfg.Snapshot.DownloaderAddr = strings.TrimSpace(ctx.String(DownloaderAddrFlag.Name))
@@ -1527,6 +1643,7 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 	setAuRa(ctx, &cfg.Aura, nodeConfig.Dirs.DataDir)
 	setParlia(ctx, &cfg.Parlia, nodeConfig.Dirs.DataDir)
 	setMiner(ctx, &cfg.Miner)
+	setMinerTxFilter(ctx, cfg)
 	setWhitelist(ctx, cfg)
 	setBorConfig(ctx, cfg)
 
@@ -1603,6 +1720,9 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		cfg.TxPool.OverrideShanghaiTime = cfg.OverrideShanghaiTime
 	}
 
+	if ctx.IsSet(L2Flag.Name) {
+		cfg.L2 = ctx.String(L2Flag.Name)
+	}
 	if ctx.IsSet(ExternalConsensusFlag.Name) {
 		cfg.ExternalCL = ctx.Bool(ExternalConsensusFlag.Name)
 	} else {
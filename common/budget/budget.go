@@ -0,0 +1,160 @@
+// Package budget shares the goroutines and memory left over by the sync between background tasks - snapshot merges,
+// index building, pruning, backfill - so that running several of them at once doesn't OOM nodes sized for
+// steady-state: tasks register with a Manager before they start and wait while its ceilings are reached.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/pbnjay/memory"
+
+	"github.com/ledgerwatch/erigon/common/prque"
+	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
+)
+
+// Priority of a task: waiting tasks start by priority, then in order of arrival
+type Priority int
+
+const (
+	Low    Priority = iota // can wait: merges, pruning of old data
+	Normal                 // backfill
+	High                   // the sync waits for it: building of missed indices
+)
+
+// Manager enforces a ceiling on the number of running tasks and on the sum of their memory estimates
+type Manager struct {
+	mu       sync.Mutex
+	maxTasks int
+	maxMem   datasize.ByteSize
+	tasks    int
+	mem      datasize.ByteSize
+	waiting  *prque.Prque
+	seq      int64
+}
+
+type waiter struct {
+	name  string
+	mem   datasize.ByteSize
+	ready chan struct{}
+	index int
+}
+
+// New creates a Manager running at most maxTasks tasks, with at most maxMem of memory estimates in total
+func New(maxTasks int, maxMem datasize.ByteSize) *Manager {
+	m := &Manager{}
+	m.waiting = prque.New(func(data interface{}, index int) { data.(*waiter).index = index })
+	m.SetLimits(maxTasks, maxMem)
+	return m
+}
+
+// Default is the Manager of the background tasks of the node: all-but-one CPUs and half of the RAM, see estimate
+var Default = New(estimate.AlmostAllCPUs(), datasize.ByteSize(memory.TotalMemory()/2))
+
+func init() {
+	metrics.GetOrCreateGauge(`budget_tasks_running`, func() float64 { tasks, _, _ := Default.Stats(); return float64(tasks) })
+	metrics.GetOrCreateGauge(`budget_memory_bytes`, func() float64 { _, mem, _ := Default.Stats(); return float64(mem) })
+	metrics.GetOrCreateGauge(`budget_tasks_waiting`, func() float64 { _, _, waiting := Default.Stats(); return float64(waiting) })
+}
+
+// SetLimits changes the ceilings, 0 keeps the current one. Running tasks are not interrupted.
+func (m *Manager) SetLimits(maxTasks int, maxMem datasize.ByteSize) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if maxTasks > 0 {
+		m.maxTasks = maxTasks
+	}
+	if maxMem > 0 {
+		m.maxMem = maxMem
+	}
+	m.schedule()
+}
+
+// Stats returns the number of running tasks, the sum of their memory estimates and the number of waiting tasks
+func (m *Manager) Stats() (tasks int, mem datasize.ByteSize, waiting int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tasks, m.mem, m.waiting.Size()
+}
+
+// Acquire waits until the task fits in the budget and returns the func to call once it's done. A task estimated to
+// need more memory than the ceiling runs once no other task holds memory.
+func (m *Manager) Acquire(ctx context.Context, name string, priority Priority, mem datasize.ByteSize) (release func(), err error) {
+	m.mu.Lock()
+	if mem > m.maxMem {
+		mem = m.maxMem
+	}
+	w := &waiter{name: name, mem: mem, ready: make(chan struct{})}
+	m.seq++
+	// int64: priority in the high bits, first come first served in the low ones
+	m.waiting.Push(w, int64(priority)<<40-m.seq)
+	m.schedule()
+	m.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	default:
+		log.Debug("[budget] task waits", "task", name, "priority", priority, "mem", mem)
+		select {
+		case <-w.ready:
+		case <-ctx.Done():
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			select {
+			case <-w.ready: // started meanwhile
+				m.release(w)
+			default:
+				m.waiting.Remove(w.index)
+				m.schedule() // the tasks behind may fit now
+			}
+			return nil, ctx.Err()
+		}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.release(w)
+		})
+	}, nil
+}
+
+func (m *Manager) release(w *waiter) {
+	m.tasks--
+	m.mem -= w.mem
+	m.schedule()
+}
+
+// schedule starts the waiting tasks which fit, in order: one which doesn't fit makes the ones behind it wait too,
+// otherwise a large task could wait forever for small ones
+func (m *Manager) schedule() {
+	for !m.waiting.Empty() {
+		data, _ := m.waiting.Peek()
+		w := data.(*waiter)
+		if m.tasks >= m.maxTasks || m.mem+w.mem > m.maxMem {
+			return
+		}
+		m.waiting.Pop()
+		m.tasks++
+		m.mem += w.mem
+		close(w.ready)
+	}
+}
+
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+}
@@ -0,0 +1,100 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+)
+
+func started(release func(), err error) func() {
+	if err != nil {
+		panic(err)
+	}
+	return release
+}
+
+func TestCeilings(t *testing.T) {
+	ctx := context.Background()
+	m := New(2, 100*datasize.MB)
+
+	r1 := started(m.Acquire(ctx, "a", Normal, 10*datasize.MB))
+	r2 := started(m.Acquire(ctx, "b", Normal, 10*datasize.MB))
+	tasks, mem, _ := m.Stats()
+	require.Equal(t, 2, tasks)
+	require.Equal(t, 20*datasize.MB, mem)
+
+	// too many tasks
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		started(m.Acquire(ctx, "c", Normal, 10*datasize.MB))()
+	}()
+	require.Eventually(t, func() bool { _, _, waiting := m.Stats(); return waiting == 1 }, time.Second, time.Millisecond)
+	r1()
+	r1() // idempotent
+	<-done
+	r2()
+
+	// too much memory: a larger task than the ceiling runs alone
+	r1 = started(m.Acquire(ctx, "a", Normal, 10*datasize.MB))
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		started(m.Acquire(ctx, "huge", Normal, datasize.GB))()
+	}()
+	require.Eventually(t, func() bool { _, _, waiting := m.Stats(); return waiting == 1 }, time.Second, time.Millisecond)
+	r1()
+	<-done
+	tasks, mem, waiting := m.Stats()
+	require.Zero(t, tasks)
+	require.Zero(t, mem)
+	require.Zero(t, waiting)
+}
+
+func TestPriority(t *testing.T) {
+	ctx := context.Background()
+	m := New(1, datasize.GB)
+	release := started(m.Acquire(ctx, "running", Normal, 0))
+
+	order := make(chan string, 3)
+	for i, w := range []struct {
+		name     string
+		priority Priority
+	}{{"low", Low}, {"high1", High}, {"high2", High}} {
+		w := w
+		go func() {
+			release := started(m.Acquire(ctx, w.name, w.priority, 0))
+			order <- w.name
+			release()
+		}()
+		i := i
+		require.Eventually(t, func() bool { _, _, waiting := m.Stats(); return waiting == i+1 }, time.Second, time.Millisecond)
+	}
+	release()
+	require.Equal(t, "high1", <-order)
+	require.Equal(t, "high2", <-order)
+	require.Equal(t, "low", <-order)
+}
+
+func TestCancel(t *testing.T) {
+	m := New(1, datasize.GB)
+	release := started(m.Acquire(context.Background(), "running", Normal, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		_, err := m.Acquire(ctx, "cancelled", High, 0)
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool { _, _, waiting := m.Stats(); return waiting == 1 }, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-errCh, context.Canceled)
+	_, _, waiting := m.Stats()
+	require.Zero(t, waiting)
+
+	release()
+	started(m.Acquire(context.Background(), "next", Normal, 0))()
+}
@@ -0,0 +1,88 @@
+// Package clockskew estimates the offset of the system clock from timestamps observed on the network,
+// and optionally from an NTP server. A skewed clock shows up as rejected discovery packets, missed
+// attestations or rejected gossip, so it is better to say so.
+package clockskew
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// window is the number of most recent samples the skew is estimated from
+	window = 64
+	// minSamples are needed before the estimate is trusted
+	minSamples = 8
+	// warnInterval rate-limits the warnings of a Monitor
+	warnInterval = 10 * time.Minute
+)
+
+// Monitor estimates the skew of the system clock from remote timestamps of one source, as the median of
+// the last samples: positive when the local clock is ahead of the network.
+type Monitor struct {
+	source    string
+	tolerance time.Duration
+
+	mu       sync.Mutex
+	samples  [window]time.Duration
+	count    int
+	next     int
+	skew     time.Duration
+	lastWarn time.Time
+
+	now func() time.Time
+}
+
+// New creates a Monitor for source warning when the skew exceeds tolerance, and exports the skew as the
+// clock_skew_seconds{source="<source>"} metric.
+func New(source string, tolerance time.Duration) *Monitor {
+	m := &Monitor{source: source, tolerance: tolerance, now: time.Now}
+	metrics.GetOrCreateGauge(fmt.Sprintf(`clock_skew_seconds{source="%s"}`, source), func() float64 {
+		skew, _ := m.Skew()
+		return skew.Seconds()
+	})
+	return m
+}
+
+// Observe records a timestamp which should be the current time on the network (minus latency).
+func (m *Monitor) Observe(remote time.Time) {
+	offset := m.now().Sub(remote)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samples[m.next] = offset
+	m.next = (m.next + 1) % window
+	if m.count < window {
+		m.count++
+	}
+	if m.count < minSamples {
+		return
+	}
+	sorted := make([]time.Duration, m.count)
+	copy(sorted, m.samples[:m.count])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	m.skew = sorted[m.count/2]
+	if abs(m.skew) > m.tolerance && m.now().Sub(m.lastWarn) > warnInterval {
+		m.lastWarn = m.now()
+		log.Warn("System clock seems off, check NTP synchronisation", "source", m.source, "skew", m.skew, "tolerance", m.tolerance)
+	}
+}
+
+// Skew returns the estimated skew, ok is false until enough samples were observed.
+func (m *Monitor) Skew() (skew time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.skew, m.count >= minSamples
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
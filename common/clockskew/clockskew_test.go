@@ -0,0 +1,35 @@
+package clockskew
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitorSkew(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	m := New("test", time.Second)
+	m.now = func() time.Time { return now }
+
+	// the remote clock is 3s behind, with a few outliers
+	for i := 0; i < minSamples-1; i++ {
+		m.Observe(now.Add(-3 * time.Second))
+	}
+	_, ok := m.Skew()
+	require.False(t, ok)
+	m.Observe(now.Add(time.Hour))
+	m.Observe(now.Add(-time.Hour))
+	m.Observe(now.Add(-3 * time.Second))
+
+	skew, ok := m.Skew()
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, skew)
+
+	// old samples leave the window
+	for i := 0; i < window; i++ {
+		m.Observe(now)
+	}
+	skew, _ = m.Skew()
+	require.Zero(t, skew)
+}
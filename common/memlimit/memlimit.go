@@ -0,0 +1,206 @@
+// Package memlimit accounts the memory held by the major components of the node - RPC requests in flight, CL caches,
+// and whatever else registers an Account - and sheds load once the memory of the process reaches a soft limit, so
+// that the node degrades (rejects new RPC work, shrinks its caches) before the kernel OOM killer stops it.
+package memlimit
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	metrics2 "github.com/VictoriaMetrics/metrics"
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Account is the memory held by a component, kept up to date by the component itself
+type Account struct {
+	name  string
+	bytes int64 // atomic
+}
+
+// Add accounts n more bytes, or releases them when n is negative
+func (a *Account) Add(n int64) { atomic.AddInt64(&a.bytes, n) }
+
+// Set replaces the accounted bytes, for components which know their size
+func (a *Account) Set(n int64) { atomic.StoreInt64(&a.bytes, n) }
+
+func (a *Account) Bytes() int64 { return atomic.LoadInt64(&a.bytes) }
+
+func (a *Account) Name() string { return a.name }
+
+type shedder struct {
+	name string
+	shed func()
+}
+
+// Tracker holds the accounts of the components and the soft limit. The memory of the process is sampled by Check:
+// once it reaches the limit the tracker is overloaded, until the memory goes back under 90% of the limit.
+type Tracker struct {
+	limit      uint64 // atomic, 0 - no limit
+	overloaded int32  // atomic
+	memory     uint64 // atomic, at the last Check
+	running    int32  // atomic, whether Start was called
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+	shedders []shedder
+	metrics  bool
+
+	sample func() uint64 // replaced in tests
+}
+
+// New creates a Tracker with the soft limit, 0 - no limit
+func New(limit datasize.ByteSize) *Tracker {
+	return &Tracker{limit: uint64(limit), accounts: map[string]*Account{}, sample: processMemory}
+}
+
+// Default is the Tracker of the node, its accounts are exported as metrics
+var Default = New(0)
+
+func init() {
+	Default.metrics = true
+	metrics2.GetOrCreateGauge(`memory_soft_limit_bytes`, func() float64 { return float64(Default.Limit()) })
+	metrics2.GetOrCreateGauge(`memory_process_bytes`, func() float64 { return float64(atomic.LoadUint64(&Default.memory)) })
+	metrics2.GetOrCreateGauge(`memory_overloaded`, func() float64 {
+		if Default.Overloaded() {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Account returns the account of the component name, created on first use
+func (t *Tracker) Account(name string) *Account {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if a, ok := t.accounts[name]; ok {
+		return a
+	}
+	a := &Account{name: name}
+	t.accounts[name] = a
+	if t.metrics {
+		metrics2.GetOrCreateGauge(fmt.Sprintf(`memory_accounted_bytes{component="%s"}`, name), func() float64 { return float64(a.Bytes()) })
+	}
+	return a
+}
+
+// OnPressure registers shed to be called when the tracker gets overloaded, and at each Check while it is. It must
+// be quick: drop a cache, shrink a buffer.
+func (t *Tracker) OnPressure(name string, shed func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.shedders = append(t.shedders, shedder{name: name, shed: shed})
+}
+
+// SetLimit changes the soft limit, 0 - no limit
+func (t *Tracker) SetLimit(limit datasize.ByteSize) {
+	atomic.StoreUint64(&t.limit, uint64(limit))
+	if limit == 0 {
+		atomic.StoreInt32(&t.overloaded, 0)
+	}
+}
+
+func (t *Tracker) Limit() datasize.ByteSize { return datasize.ByteSize(atomic.LoadUint64(&t.limit)) }
+
+// Overloaded reports whether the memory of the process reached the soft limit: new work should be rejected
+func (t *Tracker) Overloaded() bool { return atomic.LoadInt32(&t.overloaded) == 1 }
+
+// Check samples the memory of the process, updates the overloaded state and sheds load while overloaded
+func (t *Tracker) Check() {
+	limit := atomic.LoadUint64(&t.limit)
+	memory := t.sample()
+	atomic.StoreUint64(&t.memory, memory)
+	if limit == 0 {
+		return
+	}
+	switch {
+	case !t.Overloaded() && memory >= limit:
+		atomic.StoreInt32(&t.overloaded, 1)
+		stats := t.Stats()
+		log.Warn("[memlimit] Soft memory limit reached, shedding load", "memory", datasize.ByteSize(memory), "limit", datasize.ByteSize(limit), "accounted", stats.Components)
+		t.shed()
+		// give the memory of the dropped caches back to the OS now, it's what the OOM killer looks at
+		debug.FreeOSMemory()
+	case t.Overloaded() && memory < limit/10*9:
+		atomic.StoreInt32(&t.overloaded, 0)
+		log.Info("[memlimit] Memory back under the soft limit", "memory", datasize.ByteSize(memory), "limit", datasize.ByteSize(limit))
+	case t.Overloaded():
+		t.shed()
+	}
+}
+
+func (t *Tracker) shed() {
+	t.mu.Lock()
+	shedders := append([]shedder{}, t.shedders...)
+	t.mu.Unlock()
+	for _, s := range shedders {
+		log.Debug("[memlimit] Shedding", "component", s.name)
+		s.shed()
+	}
+}
+
+// Start calls Check every interval until ctx is done, in the background. Only the first call starts the loop: the
+// node and the embedded services may all call it.
+func (t *Tracker) Start(ctx context.Context, interval time.Duration) {
+	if !atomic.CompareAndSwapInt32(&t.running, 0, 1) {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			t.Check()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ComponentStats is the memory accounted by a component
+type ComponentStats struct {
+	Name  string `json:"name"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Stats is the memory breakdown of the process
+type Stats struct {
+	Limit      uint64           `json:"limit"`  // 0 - no limit
+	Memory     uint64           `json:"memory"` // of the process, at the last Check
+	Overloaded bool             `json:"overloaded"`
+	Components []ComponentStats `json:"components"` // by name
+}
+
+func (t *Tracker) Stats() Stats {
+	t.mu.Lock()
+	components := make([]ComponentStats, 0, len(t.accounts))
+	for name, a := range t.accounts {
+		components = append(components, ComponentStats{Name: name, Bytes: a.Bytes()})
+	}
+	t.mu.Unlock()
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+	return Stats{
+		Limit:      atomic.LoadUint64(&t.limit),
+		Memory:     atomic.LoadUint64(&t.memory),
+		Overloaded: t.Overloaded(),
+		Components: components,
+	}
+}
+
+// processMemory returns the memory the Go runtime holds from the OS and hasn't released
+func processMemory() uint64 {
+	samples := []metrics.Sample{{Name: "/memory/classes/total:bytes"}, {Name: "/memory/classes/heap/released:bytes"}}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
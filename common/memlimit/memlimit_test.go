@@ -0,0 +1,55 @@
+package memlimit
+
+import (
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverloaded(t *testing.T) {
+	tr := New(100 * datasize.MB)
+	memory := uint64(50 * datasize.MB)
+	tr.sample = func() uint64 { return memory }
+	sheds := 0
+	tr.OnPressure("cache", func() { sheds++ })
+
+	tr.Check()
+	require.False(t, tr.Overloaded())
+	require.Zero(t, sheds)
+
+	memory = uint64(100 * datasize.MB)
+	tr.Check()
+	require.True(t, tr.Overloaded())
+	require.Equal(t, 1, sheds)
+	// still shedding while overloaded, down to 90% of the limit
+	memory = uint64(95 * datasize.MB)
+	tr.Check()
+	require.True(t, tr.Overloaded())
+	require.Equal(t, 2, sheds)
+	memory = uint64(89 * datasize.MB)
+	tr.Check()
+	require.False(t, tr.Overloaded())
+	require.Equal(t, 2, sheds)
+
+	// no limit
+	memory = uint64(200 * datasize.MB)
+	tr.SetLimit(0)
+	tr.Check()
+	require.False(t, tr.Overloaded())
+	require.Equal(t, memory, tr.Stats().Memory)
+}
+
+func TestAccounts(t *testing.T) {
+	tr := New(0)
+	rpc := tr.Account("rpc")
+	require.Same(t, rpc, tr.Account("rpc"))
+	rpc.Add(10)
+	rpc.Add(-4)
+	tr.Account("cl").Set(100)
+	require.Equal(t, []ComponentStats{{Name: "cl", Bytes: 100}, {Name: "rpc", Bytes: 6}}, tr.Stats().Components)
+}
+
+func TestProcessMemory(t *testing.T) {
+	require.NotZero(t, processMemory())
+}
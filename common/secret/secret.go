@@ -0,0 +1,132 @@
+// Package secret loads the secrets of the components - JWT secret of the Engine API, TLS private keys - from one of
+// several sources, so that they don't have to sit in plaintext files next to the datadir:
+//
+//	/path/to/file, file:/path/to/file  - content of the file
+//	env:NAME                           - value of the environment variable NAME
+//	cmd:program arg1 arg2              - standard output of the program (password managers, vaults)
+//
+// Leading and trailing whitespace is trimmed. Callers call Destroy once they are done with the secret, which zeroes it.
+package secret
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix = "file:"
+	envPrefix  = "env:"
+	cmdPrefix  = "cmd:"
+
+	// cmdTimeout bounds the run of the program of a cmd: source
+	cmdTimeout = 30 * time.Second
+)
+
+var ErrEmpty = errors.New("secret is empty")
+
+// Secret holds the bytes of a secret. Don't copy them out: they won't be zeroed by Destroy.
+type Secret struct {
+	b []byte
+}
+
+// New takes ownership of b
+func New(b []byte) *Secret {
+	return &Secret{b: b}
+}
+
+func (s *Secret) Bytes() []byte { return s.b }
+
+// Destroy zeroes the secret
+func (s *Secret) Destroy() {
+	Zero(s.b)
+	s.b = nil
+}
+
+// String never prints the secret, in case one ends up in a log
+func (s *Secret) String() string { return "<secret>" }
+
+// Zero overwrites b with zeroes
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// FilePath returns the path of spec when it's a file source
+func FilePath(spec string) (path string, ok bool) {
+	switch {
+	case strings.HasPrefix(spec, envPrefix), strings.HasPrefix(spec, cmdPrefix):
+		return "", false
+	case strings.HasPrefix(spec, filePrefix):
+		return strings.TrimPrefix(spec, filePrefix), true
+	default:
+		return spec, true
+	}
+}
+
+// Load reads the secret from the source described by spec, see the package doc. Errors never contain the secret.
+func Load(spec string) (*Secret, error) {
+	var b []byte
+	switch {
+	case strings.HasPrefix(spec, envPrefix):
+		name := strings.TrimPrefix(spec, envPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("secret: environment variable %s is not set", name)
+		}
+		b = []byte(v)
+	case strings.HasPrefix(spec, cmdPrefix):
+		args := strings.Fields(strings.TrimPrefix(spec, cmdPrefix))
+		if len(args) == 0 {
+			return nil, fmt.Errorf("secret: no program in %q", spec)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			Zero(stdout.Bytes())
+			return nil, fmt.Errorf("secret: running %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		b = stdout.Bytes()
+	default:
+		path, _ := FilePath(spec)
+		var err error
+		if b, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("secret: %w", err)
+		}
+	}
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) == 0 {
+		Zero(b)
+		return nil, fmt.Errorf("secret: %s: %w", describe(spec), ErrEmpty)
+	}
+	// keep only the trimmed bytes, zero the rest
+	s := make([]byte, len(trimmed))
+	copy(s, trimmed)
+	Zero(b)
+	return New(s), nil
+}
+
+// describe names the source of spec without revealing anything else about it
+func describe(spec string) string {
+	switch {
+	case strings.HasPrefix(spec, envPrefix):
+		return spec
+	case strings.HasPrefix(spec, cmdPrefix):
+		if args := strings.Fields(strings.TrimPrefix(spec, cmdPrefix)); len(args) > 0 {
+			return cmdPrefix + args[0]
+		}
+		return cmdPrefix
+	default:
+		path, _ := FilePath(spec)
+		return path
+	}
+}
@@ -0,0 +1,57 @@
+package secret
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(" from-file\n"), 0600))
+	t.Setenv("SECRET_TEST", "from-env\n")
+
+	for spec, want := range map[string]string{
+		path:                      "from-file",
+		"file:" + path:            "from-file",
+		"env:SECRET_TEST":         "from-env",
+		"cmd:echo  from-cmd   ok": "from-cmd ok",
+	} {
+		s, err := Load(spec)
+		require.NoError(t, err, spec)
+		require.Equal(t, want, string(s.Bytes()), spec)
+	}
+
+	_, err := Load("env:SECRET_TEST_UNSET")
+	require.ErrorContains(t, err, "SECRET_TEST_UNSET is not set")
+	_, err = Load("cmd:false")
+	require.Error(t, err)
+	t.Setenv("SECRET_TEST_EMPTY", " ")
+	_, err = Load("env:SECRET_TEST_EMPTY")
+	require.True(t, errors.Is(err, ErrEmpty))
+}
+
+func TestDestroy(t *testing.T) {
+	b := []byte("secret")
+	s := New(b)
+	require.Equal(t, "<secret>", s.String())
+	s.Destroy()
+	require.Equal(t, make([]byte, 6), b)
+	require.Nil(t, s.Bytes())
+}
+
+func TestFilePath(t *testing.T) {
+	path, ok := FilePath("/a/b")
+	require.True(t, ok)
+	require.Equal(t, "/a/b", path)
+	path, ok = FilePath("file:/a/b")
+	require.True(t, ok)
+	require.Equal(t, "/a/b", path)
+	_, ok = FilePath("env:A")
+	require.False(t, ok)
+	_, ok = FilePath("cmd:a")
+	require.False(t, ok)
+}
@@ -0,0 +1,48 @@
+package secret
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
+	"google.golang.org/grpc/credentials"
+)
+
+// TLS is grpcutil.TLS with the private key loaded from any source, see Load
+func TLS(tlsCACert, tlsCertFile, tlsKey string) (credentials.TransportCredentials, error) {
+	if _, ok := FilePath(tlsKey); ok || tlsKey == "" {
+		return grpcutil.TLS(tlsCACert, tlsCertFile, tlsKey)
+	}
+	certPEM, err := os.ReadFile(tlsCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("read cert file error:%w", err)
+	}
+	key, err := Load(tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Destroy()
+	peerCert, err := tls.X509KeyPair(certPEM, key.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("load peer cert/key error:%w", err)
+	}
+	if tlsCACert == "" {
+		return credentials.NewServerTLSFromCert(&peerCert), nil
+	}
+	caCert, err := os.ReadFile(tlsCACert)
+	if err != nil {
+		return nil, fmt.Errorf("read ca cert file error:%w", err)
+	}
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(caCert)
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{peerCert},
+		ClientCAs:    caCertPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		//nolint:gosec
+		InsecureSkipVerify: true, // same as grpcutil.TLS: Common Name may not match
+	}), nil
+}
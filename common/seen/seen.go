@@ -0,0 +1,165 @@
+// Package seen provides bounded caches of the keys recently seen by the node, such as gossip message IDs,
+// attestation roots or transaction hashes, to drop duplicates before doing any work on them. The caches are
+// sliding bloom filters: their memory is fixed however long the node runs, and the price is a small rate of
+// false positives, keys reported as seen when they were not.
+package seen
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// Filter remembers at least the last capacity keys added to it, and at most twice as many. Keys are added to
+// the current generation of the filter; when it holds capacity keys it becomes the previous generation, and
+// the previous one is dropped.
+type Filter struct {
+	mu       sync.Mutex
+	hash     maphash.Hash
+	current  *bloom
+	previous *bloom
+	capacity int
+	count    int
+
+	checks     *metrics.Counter
+	duplicates *metrics.Counter
+}
+
+// New returns a filter named name, which remembers the last capacity keys with the falsePositiveRate.
+func New(name string, capacity int, falsePositiveRate float64) *Filter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	f := &Filter{
+		capacity:   capacity,
+		checks:     metrics.GetOrCreateCounter(fmt.Sprintf(`seen_checks_total{cache="%s"}`, name)),
+		duplicates: metrics.GetOrCreateCounter(fmt.Sprintf(`seen_duplicates_total{cache="%s"}`, name)),
+	}
+	// a key may be in both generations, so each gets half of the rate
+	bits, hashes := bloomSize(capacity, falsePositiveRate/2)
+	f.current, f.previous = newBloom(bits, hashes), newBloom(bits, hashes)
+	return f
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[string]*Filter{}
+)
+
+// Shared returns the filter named name of the process, creating it on first use, so that every component
+// handling the same keys drops the duplicates of the others.
+func Shared(name string, capacity int, falsePositiveRate float64) *Filter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	f, ok := shared[name]
+	if !ok {
+		f = New(name, capacity, falsePositiveRate)
+		shared[name] = f
+	}
+	return f
+}
+
+// Seen adds key to the filter and reports whether it was already there.
+func (f *Filter) Seen(key []byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checks.Inc()
+	h := f.sum(key)
+	if f.current.contains(h) {
+		f.duplicates.Inc()
+		return true
+	}
+	// keys of the previous generation are added again, so that the ones seen all the time are never forgotten
+	duplicate := f.previous.contains(h)
+	f.current.add(h)
+	f.count++
+	if f.count >= f.capacity {
+		f.current, f.previous = f.previous, f.current
+		f.current.reset()
+		f.count = 0
+	}
+	if duplicate {
+		f.duplicates.Inc()
+	}
+	return duplicate
+}
+
+// Contains reports whether key was added to the filter, without adding it.
+func (f *Filter) Contains(key []byte) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.sum(key)
+	return f.current.contains(h) || f.previous.contains(h)
+}
+
+// Reset forgets all the keys.
+func (f *Filter) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current.reset()
+	f.previous.reset()
+	f.count = 0
+}
+
+// sum must be called with the lock held.
+func (f *Filter) sum(key []byte) uint64 {
+	f.hash.Reset()
+	f.hash.Write(key)
+	return f.hash.Sum64()
+}
+
+// bloomSize returns the optimal number of bits and of hash functions of a bloom filter of n keys.
+func bloomSize(n int, p float64) (bits uint64, hashes int) {
+	if p <= 0 || p >= 1 {
+		p = 0.001
+	}
+	bits = uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if bits < 64 {
+		bits = 64
+	}
+	hashes = int(math.Round(float64(bits) / float64(n) * math.Ln2))
+	if hashes < 1 {
+		hashes = 1
+	}
+	return bits, hashes
+}
+
+type bloom struct {
+	words  []uint64
+	bits   uint64
+	hashes int
+}
+
+func newBloom(bits uint64, hashes int) *bloom {
+	words := (bits + 63) / 64
+	return &bloom{words: make([]uint64, words), bits: words * 64, hashes: hashes}
+}
+
+// positions of the key are derived from the two halves of its hash, as in Kirsch and Mitzenmacher.
+func (b *bloom) add(h uint64) {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := 0; i < b.hashes; i++ {
+		pos := (h1 + uint64(i)*h2) % b.bits
+		b.words[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+func (b *bloom) contains(h uint64) bool {
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := 0; i < b.hashes; i++ {
+		pos := (h1 + uint64(i)*h2) % b.bits
+		if b.words[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloom) reset() {
+	for i := range b.words {
+		b.words[i] = 0
+	}
+}
@@ -0,0 +1,70 @@
+package seen
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func key(i int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(i))
+	return b
+}
+
+func TestFilterSeen(t *testing.T) {
+	f := New("test", 1000, 0.001)
+	// the hash seed is random, so a few new keys may be false positives
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if f.Seen(key(i)) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 10)
+	// the last capacity keys are remembered across the rotation
+	for i := 0; i < 1000; i++ {
+		require.True(t, f.Seen(key(i)), i)
+		require.True(t, f.Contains(key(i)), i)
+	}
+
+	f.Reset()
+	require.False(t, f.Contains(key(1)))
+}
+
+func TestFilterSliding(t *testing.T) {
+	f := New("test_sliding", 100, 0.001)
+	for i := 0; i < 300; i++ {
+		f.Seen(key(i))
+	}
+	// two generations later the first keys are forgotten
+	forgotten := 0
+	for i := 0; i < 100; i++ {
+		if !f.Contains(key(i)) {
+			forgotten++
+		}
+	}
+	require.Greater(t, forgotten, 95)
+	for i := 200; i < 300; i++ {
+		require.True(t, f.Contains(key(i)), i)
+	}
+}
+
+func TestFilterFalsePositives(t *testing.T) {
+	f := New("test_fp", 10_000, 0.01)
+	for i := 0; i < 10_000; i++ {
+		f.Seen(key(i))
+	}
+	falsePositives := 0
+	for i := 10_000; i < 20_000; i++ {
+		if f.Contains(key(i)) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 200)
+}
+
+func TestShared(t *testing.T) {
+	require.Same(t, Shared("test_shared", 10, 0.01), Shared("test_shared", 100, 0.01))
+}
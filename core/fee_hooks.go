@@ -0,0 +1,23 @@
+package core
+
+import (
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+)
+
+// FeeHooks let an L2 stack change how the fees of a transaction are charged and paid (see turbo/l2). The messages
+// of the transaction types of the stack carry their data in types.Message.L2Data.
+type FeeHooks interface {
+	// BuyGas is called before the gas of msg is bought from its sender. When it returns true, the default purchase is
+	// skipped, and Settle is called after the execution instead of the refund of the unused gas and of the payment
+	// of the block producer. It may also charge additional fees and return false.
+	BuyGas(ibs evmtypes.IntraBlockState, msg Message, block evmtypes.BlockContext) (bool, error)
+	// Settle is called after the execution of a message for which BuyGas returned true.
+	Settle(ibs evmtypes.IntraBlockState, msg Message, block evmtypes.BlockContext, gasUsed uint64) error
+}
+
+var feeHooks FeeHooks
+
+// SetFeeHooks installs the fee hooks of the active L2 stack, before any execution. nil restores the defaults.
+func SetFeeHooks(hooks FeeHooks) {
+	feeHooks = hooks
+}
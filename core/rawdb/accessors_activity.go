@@ -0,0 +1,56 @@
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/ethdb/secondary"
+)
+
+// AddressActivity indexes the first and the last block in which an address appears in the call traces, as sender,
+// recipient or created contract, and in how many blocks it does. It is maintained by the CallTraces stage and isn't
+// pruned with the call traces.
+// address -> first block (8 bytes) + last block (8 bytes) + number of blocks (8 bytes)
+const AddressActivity = "AddressActivity"
+
+func init() {
+	secondary.RegisterTable(AddressActivity, kv.TableCfgItem{})
+}
+
+// Activity is an entry of the AddressActivity table.
+type Activity struct {
+	FirstBlock uint64
+	LastBlock  uint64
+	Blocks     uint64
+}
+
+// ReadAddressActivity returns the activity of addr, nil if it was never seen.
+func ReadAddressActivity(db kv.Getter, addr libcommon.Address) (*Activity, error) {
+	v, err := db.GetOne(AddressActivity, addr[:])
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if len(v) != 24 {
+		return nil, fmt.Errorf("wrong size of value in %s: %x (size %d)", AddressActivity, v, len(v))
+	}
+	return &Activity{
+		FirstBlock: binary.BigEndian.Uint64(v),
+		LastBlock:  binary.BigEndian.Uint64(v[8:]),
+		Blocks:     binary.BigEndian.Uint64(v[16:]),
+	}, nil
+}
+
+func WriteAddressActivity(db kv.Putter, addr libcommon.Address, activity Activity) error {
+	var v [24]byte
+	binary.BigEndian.PutUint64(v[:], activity.FirstBlock)
+	binary.BigEndian.PutUint64(v[8:], activity.LastBlock)
+	binary.BigEndian.PutUint64(v[16:], activity.Blocks)
+	return db.Put(AddressActivity, addr[:], v[:])
+}
+
+func DeleteAddressActivity(db kv.Deleter, addr libcommon.Address) error {
+	return db.Delete(AddressActivity, addr[:])
+}
@@ -187,7 +187,7 @@ func ResetTxLookup(tx kv.RwTx) error {
 var Tables = map[stages.SyncStage][]string{
 	stages.HashState:           {kv.HashedAccounts, kv.HashedStorage, kv.ContractCode},
 	stages.IntermediateHashes:  {kv.TrieOfAccounts, kv.TrieOfStorage},
-	stages.CallTraces:          {kv.CallFromIndex, kv.CallToIndex},
+	stages.CallTraces:          {kv.CallFromIndex, kv.CallToIndex, rawdb.AddressActivity},
 	stages.LogIndex:            {kv.LogAddressIndex, kv.LogTopicIndex},
 	stages.AccountHistoryIndex: {kv.AccountsHistory},
 	stages.StorageHistoryIndex: {kv.StorageHistory},
@@ -343,6 +343,12 @@ func clearTable(ctx context.Context, db kv.RoDB, tx kv.RwTx, table string) error
 		cancel()
 		clean()
 	}()
+	if migrator, ok := tx.(kv.BucketMigrator); ok {
+		// tables outside of erigon-lib only exist when the db was opened with them
+		if exists, err := migrator.ExistsBucket(table); err != nil || !exists {
+			return err
+		}
+	}
 	log.Info("Clear", "table", table)
 	return tx.ClearBucket(table)
 }
@@ -75,6 +75,8 @@ type StateTransition struct {
 
 	isParlia bool
 	isBor    bool
+	// l2Fees is true when the fees of the message are handled by the fee hooks
+	l2Fees bool
 }
 
 // Message represents a message sent to a contract.
@@ -195,6 +197,21 @@ func (st *StateTransition) to() libcommon.Address {
 }
 
 func (st *StateTransition) buyGas(gasBailout bool) error {
+	if feeHooks != nil {
+		handled, err := feeHooks.BuyGas(st.state, st.msg, st.evm.Context())
+		if err != nil {
+			return err
+		}
+		if handled {
+			st.l2Fees = true
+			if err := st.gp.SubGas(st.msg.Gas()); err != nil && !gasBailout {
+				return err
+			}
+			st.gas += st.msg.Gas()
+			st.initialGas = st.msg.Gas()
+			return nil
+		}
+	}
 	mgval := st.sharedBuyGas
 	mgval.SetUint64(st.msg.Gas())
 	mgval, overflow := mgval.MulOverflow(mgval, st.gasPrice)
@@ -407,6 +424,16 @@ func (st *StateTransition) TransitionDb(refunds bool, gasBailout bool) (*Executi
 			st.refundGas(params.RefundQuotient)
 		}
 	}
+	if st.l2Fees {
+		if err := feeHooks.Settle(st.state, msg, st.evm.Context(), st.gasUsed()); err != nil {
+			return nil, err
+		}
+		return &ExecutionResult{
+			UsedGas:    st.gasUsed(),
+			Err:        vmerr,
+			ReturnData: ret,
+		}, nil
+	}
 	effectiveTip := st.gasPrice
 	if rules.IsLondon {
 		if st.gasFeeCap.Gt(st.evm.Context().BaseFee) {
@@ -462,8 +489,10 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 	st.gas += refund
 
 	// Return ETH for remaining gas, exchanged at the original rate.
-	remaining := new(uint256.Int).Mul(new(uint256.Int).SetUint64(st.gas), st.gasPrice)
-	st.state.AddBalance(st.msg.From(), remaining)
+	if !st.l2Fees {
+		remaining := new(uint256.Int).Mul(new(uint256.Int).SetUint64(st.gas), st.gasPrice)
+		st.state.AddBalance(st.msg.From(), remaining)
+	}
 
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
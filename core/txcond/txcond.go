@@ -0,0 +1,216 @@
+// Package txcond implements the conditions of eth_sendRawTransactionConditional: a transaction is only
+// included while the block number, timestamp and some storage slots match what the sender expects.
+package txcond
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// KnownAccount is the expected storage of an account: either its storage root or the values of some slots.
+type KnownAccount struct {
+	StorageRoot  *libcommon.Hash
+	StorageSlots map[libcommon.Hash]libcommon.Hash
+}
+
+func (a *KnownAccount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		a.StorageRoot = new(libcommon.Hash)
+		return json.Unmarshal(data, a.StorageRoot)
+	}
+	return json.Unmarshal(data, &a.StorageSlots)
+}
+
+func (a KnownAccount) MarshalJSON() ([]byte, error) {
+	if a.StorageRoot != nil {
+		return json.Marshal(a.StorageRoot)
+	}
+	return json.Marshal(a.StorageSlots)
+}
+
+// Options are the conditions a transaction is submitted with, all of them are optional.
+type Options struct {
+	KnownAccounts  map[libcommon.Address]KnownAccount `json:"knownAccounts,omitempty"`
+	BlockNumberMin *hexutil.Uint64                    `json:"blockNumberMin,omitempty"`
+	BlockNumberMax *hexutil.Uint64                    `json:"blockNumberMax,omitempty"`
+	TimestampMin   *hexutil.Uint64                    `json:"timestampMin,omitempty"`
+	TimestampMax   *hexutil.Uint64                    `json:"timestampMax,omitempty"`
+}
+
+// RejectedError is returned when a condition is not met, Reason names the failed condition.
+type RejectedError struct {
+	Reason  string
+	Message string
+}
+
+func (e *RejectedError) ErrorCode() int { return -32003 }
+
+func (e *RejectedError) Error() string { return e.Message }
+
+func (e *RejectedError) ErrorData() interface{} { return map[string]string{"reason": e.Reason} }
+
+func rejected(reason, format string, args ...interface{}) *RejectedError {
+	return &RejectedError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate rejects options which can't be checked. Storage roots are not tracked per account in
+// the plain state, so only storage slot conditions are supported.
+func (o *Options) Validate() error {
+	for addr, account := range o.KnownAccounts {
+		if account.StorageRoot != nil {
+			return fmt.Errorf("storage root condition for %x is not supported, use storage slots", addr)
+		}
+	}
+	return nil
+}
+
+// Check returns RejectedError if the transaction can't be included in the block of header, on top of the state of r.
+func (o *Options) Check(header *types.Header, r state.StateReader) error {
+	number := header.Number.Uint64()
+	if o.BlockNumberMin != nil && number < uint64(*o.BlockNumberMin) {
+		return rejected("blockNumberMin", "block number %d is below minimum %d", number, *o.BlockNumberMin)
+	}
+	if o.BlockNumberMax != nil && number > uint64(*o.BlockNumberMax) {
+		return rejected("blockNumberMax", "block number %d is above maximum %d", number, *o.BlockNumberMax)
+	}
+	if o.TimestampMin != nil && header.Time < uint64(*o.TimestampMin) {
+		return rejected("timestampMin", "timestamp %d is below minimum %d", header.Time, *o.TimestampMin)
+	}
+	if o.TimestampMax != nil && header.Time > uint64(*o.TimestampMax) {
+		return rejected("timestampMax", "timestamp %d is above maximum %d", header.Time, *o.TimestampMax)
+	}
+	for addr, account := range o.KnownAccounts {
+		if account.StorageRoot != nil {
+			return rejected("knownAccounts", "storage root condition for %x is not supported", addr)
+		}
+		acc, err := r.ReadAccountData(addr)
+		if err != nil {
+			return err
+		}
+		var incarnation uint64
+		if acc != nil {
+			incarnation = acc.Incarnation
+		}
+		for slot, expected := range account.StorageSlots {
+			slot := slot
+			value, err := r.ReadAccountStorage(addr, incarnation, &slot)
+			if err != nil {
+				return err
+			}
+			if actual := libcommon.BytesToHash(value); actual != expected {
+				return rejected("knownAccounts", "storage slot %x of %x is %x, expected %x", slot, addr, actual, expected)
+			}
+		}
+	}
+	return nil
+}
+
+// maxPending bounds the conditions a Store keeps.
+const maxPending = 10_000
+
+// ErrStoreFull is returned by Register while maxPending conditions are kept.
+var ErrStoreFull = errors.New("too many pending conditional transactions")
+
+// Store keeps the conditions of submitted transactions, so that the blocks built by the node only include them while
+// the conditions hold. It is shared by the RPC handlers and the block builder of the same process. Conditions are never
+// evicted, as their transaction would then be included unconditionally: they are forgotten once their transaction left
+// the pool, and saved to a file so that they outlive a restart like the pool does.
+type Store struct {
+	lock    sync.Mutex
+	path    string // empty for a store which isn't saved
+	pending map[libcommon.Hash]*Options
+}
+
+// NewStore returns the store saved at path, empty if there is no such file yet. An empty path keeps the store in memory.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, pending: map[libcommon.Hash]*Options{}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.pending); err != nil {
+		return nil, fmt.Errorf("transaction conditions %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// save writes the conditions to path, replacing the previous file only once they are written.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.pending)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Register records the conditions of the transaction with the given hash. It returns false, and keeps the recorded
+// conditions, if the transaction was registered before.
+func (s *Store) Register(txHash libcommon.Hash, o *Options) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.pending[txHash]; ok {
+		return false, nil
+	}
+	if len(s.pending) >= maxPending {
+		return false, ErrStoreFull
+	}
+	s.pending[txHash] = o
+	if err := s.save(); err != nil {
+		delete(s.pending, txHash)
+		return false, err
+	}
+	return true, nil
+}
+
+// Forget drops the conditions of transactions, after they were refused by the pool or left it.
+func (s *Store) Forget(txHashes ...libcommon.Hash) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, txHash := range txHashes {
+		delete(s.pending, txHash)
+	}
+	return s.save()
+}
+
+// Hashes returns the transactions with conditions.
+func (s *Store) Hashes() []libcommon.Hash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	hashes := make([]libcommon.Hash, 0, len(s.pending))
+	for txHash := range s.pending {
+		hashes = append(hashes, txHash)
+	}
+	return hashes
+}
+
+// Lookup returns the conditions of a transaction, or nil if it was submitted without. A nil Store has no conditions.
+func (s *Store) Lookup(txHash libcommon.Hash) *Options {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pending[txHash]
+}
@@ -0,0 +1,114 @@
+package txcond
+
+import (
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+)
+
+type storageReader map[libcommon.Hash]libcommon.Hash
+
+func (r storageReader) ReadAccountData(libcommon.Address) (*accounts.Account, error) {
+	return &accounts.Account{Incarnation: 1}, nil
+}
+func (r storageReader) ReadAccountStorage(_ libcommon.Address, _ uint64, key *libcommon.Hash) ([]byte, error) {
+	v, ok := r[*key]
+	if !ok {
+		return nil, nil
+	}
+	return v.Bytes(), nil
+}
+func (r storageReader) ReadAccountCode(libcommon.Address, uint64, libcommon.Hash) ([]byte, error) {
+	return nil, nil
+}
+func (r storageReader) ReadAccountCodeSize(libcommon.Address, uint64, libcommon.Hash) (int, error) {
+	return 0, nil
+}
+func (r storageReader) ReadAccountIncarnation(libcommon.Address) (uint64, error) { return 1, nil }
+
+func TestCheck(t *testing.T) {
+	var options Options
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"knownAccounts": {"0x000000000000000000000000000000000000aaaa": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000005"}},
+		"blockNumberMin": "0xa",
+		"blockNumberMax": "0x14",
+		"timestampMax": "0x64"
+	}`), &options))
+	require.NoError(t, options.Validate())
+
+	slot, value := libcommon.HexToHash("0x01"), libcommon.HexToHash("0x05")
+	state := storageReader{slot: value}
+	header := func(number, time uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), Time: time}
+	}
+	require.NoError(t, options.Check(header(10, 100), state))
+
+	var rejectedErr *RejectedError
+	require.ErrorAs(t, options.Check(header(9, 100), state), &rejectedErr)
+	require.Equal(t, "blockNumberMin", rejectedErr.Reason)
+	require.ErrorAs(t, options.Check(header(21, 100), state), &rejectedErr)
+	require.Equal(t, "blockNumberMax", rejectedErr.Reason)
+	require.ErrorAs(t, options.Check(header(15, 101), state), &rejectedErr)
+	require.Equal(t, "timestampMax", rejectedErr.Reason)
+	require.ErrorAs(t, options.Check(header(15, 50), storageReader{}), &rejectedErr)
+	require.Equal(t, "knownAccounts", rejectedErr.Reason)
+
+	var withRoot Options
+	require.NoError(t, json.Unmarshal([]byte(`{"knownAccounts": {"0x000000000000000000000000000000000000aaaa": "0x0000000000000000000000000000000000000000000000000000000000000001"}}`), &withRoot))
+	require.Error(t, withRoot.Validate())
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conditions.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	hash := libcommon.Hash{1}
+	require.Nil(t, store.Lookup(hash))
+	min := hexutil.Uint64(5)
+	options := &Options{BlockNumberMin: &min}
+	registered, err := store.Register(hash, options)
+	require.NoError(t, err)
+	require.True(t, registered)
+	require.True(t, store.Lookup(hash) == options)
+
+	// a resubmission keeps the first conditions
+	registered, err = store.Register(hash, &Options{})
+	require.NoError(t, err)
+	require.False(t, registered)
+	require.True(t, store.Lookup(hash) == options)
+
+	// the conditions outlive a restart
+	reopened, err := NewStore(path)
+	require.NoError(t, err)
+	require.Equal(t, options, reopened.Lookup(hash))
+
+	require.NoError(t, store.Forget(hash))
+	require.Nil(t, store.Lookup(hash))
+	reopened, err = NewStore(path)
+	require.NoError(t, err)
+	require.Empty(t, reopened.Hashes())
+
+	var none *Store
+	require.Nil(t, none.Lookup(hash))
+}
+
+func TestStoreFull(t *testing.T) {
+	store, err := NewStore("")
+	require.NoError(t, err)
+	for i := 0; i < maxPending; i++ {
+		_, err := store.Register(libcommon.Hash{byte(i), byte(i >> 8)}, &Options{})
+		require.NoError(t, err)
+	}
+	// conditions are refused rather than evicted
+	_, err = store.Register(libcommon.Hash{0xff, 0xff}, &Options{})
+	require.ErrorIs(t, err, ErrStoreFull)
+	require.NotNil(t, store.Lookup(libcommon.Hash{}))
+}
@@ -0,0 +1,75 @@
+// Package txfilter lets operators exclude transactions from the blocks they build.
+//
+// Filters are consulted only when building blocks locally (mining or engine API payloads),
+// never when validating blocks built by others.
+package txfilter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// Filter decides whether a transaction may be included in a locally built block.
+type Filter interface {
+	// Allow reports whether txn sent by sender may be included. Reason is a short, low cardinality
+	// explanation of a refusal, used in logs and metrics.
+	Allow(txn types.Transaction, sender libcommon.Address) (ok bool, reason string)
+}
+
+// AddressList refuses transactions sent by or sent to any of the listed addresses.
+type AddressList struct {
+	addresses map[libcommon.Address]struct{}
+}
+
+func NewAddressList(addresses []libcommon.Address) *AddressList {
+	l := &AddressList{addresses: make(map[libcommon.Address]struct{}, len(addresses))}
+	for _, addr := range addresses {
+		l.addresses[addr] = struct{}{}
+	}
+	return l
+}
+
+// LoadAddressList reads one hex address per line, empty lines and lines starting with # are skipped.
+func LoadAddressList(path string) (*AddressList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var addresses []libcommon.Address
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !libcommon.IsHexAddress(text) {
+			return nil, fmt.Errorf("%s:%d: invalid address %q", path, line, text)
+		}
+		addresses = append(addresses, libcommon.HexToAddress(text))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewAddressList(addresses), nil
+}
+
+func (l *AddressList) Len() int { return len(l.addresses) }
+
+func (l *AddressList) Allow(txn types.Transaction, sender libcommon.Address) (bool, string) {
+	if _, ok := l.addresses[sender]; ok {
+		return false, "sender"
+	}
+	if to := txn.GetTo(); to != nil {
+		if _, ok := l.addresses[*to]; ok {
+			return false, "recipient"
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,37 @@
+package txfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+func TestAddressList(t *testing.T) {
+	listed := libcommon.HexToAddress("0x8589427373D6D84E98730D7795D8f6f8731FDA16")
+	other := libcommon.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	path := filepath.Join(t.TempDir(), "list")
+	require.NoError(t, os.WriteFile(path, []byte("# sanctioned\n\n"+listed.Hex()+"\n"), 0600))
+	list, err := LoadAddressList(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, list.Len())
+
+	ok, reason := list.Allow(types.NewTransaction(0, other, uint256.NewInt(0), 21000, uint256.NewInt(1), nil), listed)
+	require.False(t, ok)
+	require.Equal(t, "sender", reason)
+	ok, reason = list.Allow(types.NewTransaction(0, listed, uint256.NewInt(0), 21000, uint256.NewInt(1), nil), other)
+	require.False(t, ok)
+	require.Equal(t, "recipient", reason)
+	ok, _ = list.Allow(types.NewContractCreation(0, uint256.NewInt(0), 21000, uint256.NewInt(1), nil), other)
+	require.True(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte("not an address\n"), 0600))
+	_, err = LoadAddressList(path)
+	require.Error(t, err)
+}
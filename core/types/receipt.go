@@ -251,7 +251,12 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 				return err
 			}
 		default:
-			return ErrTxTypeNotSupported
+			if extraTxDecoder(r.Type) == nil {
+				return ErrTxTypeNotSupported
+			}
+			if err := r.decodePayload(s); err != nil {
+				return err
+			}
 		}
 		if err = s.ListEnd(); err != nil {
 			return err
@@ -443,6 +448,12 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 			panic(err)
 		}
 	default:
+		if extraTxDecoder(r.Type) != nil {
+			w.WriteByte(r.Type)
+			if err := rlp.Encode(w, data); err != nil {
+				panic(err)
+			}
+		}
 		// For unsupported types, write nothing. Since this is for
 		// DeriveSha, the error will be caught matching the derived hash
 		// to the block.
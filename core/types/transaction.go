@@ -157,7 +157,13 @@ func DecodeTransaction(s *rlp.Stream) (Transaction, error) {
 		}
 		tx = t
 	default:
-		return nil, fmt.Errorf("%w, got: %d", rlp.ErrUnknownTxTypePrefix, b[0])
+		decode := extraTxDecoder(b[0])
+		if decode == nil {
+			return nil, fmt.Errorf("%w, got: %d", rlp.ErrUnknownTxTypePrefix, b[0])
+		}
+		if tx, err = decode(s); err != nil {
+			return nil, err
+		}
 	}
 	if kind == rlp.String {
 		if err = s.ListEnd(); err != nil {
@@ -465,6 +471,7 @@ type Message struct {
 	accessList types2.AccessList
 	checkNonce bool
 	isFree     bool
+	l2Data     interface{}
 }
 
 func NewMessage(from libcommon.Address, to *libcommon.Address, nonce uint64, amount *uint256.Int, gasLimit uint64, gasPrice *uint256.Int, feeCap, tip *uint256.Int, data []byte, accessList types2.AccessList, checkNonce bool, isFree bool) Message {
@@ -506,6 +513,12 @@ func (m *Message) SetCheckNonce(checkNonce bool) {
 	m.checkNonce = checkNonce
 }
 func (m Message) IsFree() bool { return m.isFree }
+
+// L2Data returns what the transaction of an L2 type attached to its message for the fee hooks of its stack.
+func (m Message) L2Data() interface{} { return m.l2Data }
+func (m *Message) SetL2Data(data interface{}) {
+	m.l2Data = data
+}
 func (m *Message) SetIsFree(isFree bool) {
 	m.isFree = isFree
 }
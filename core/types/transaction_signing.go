@@ -187,6 +187,9 @@ func (sg Signer) Sender(tx Transaction) (libcommon.Address, error) {
 
 // SenderWithContext returns the sender address of the transaction.
 func (sg Signer) SenderWithContext(context *secp256k1.Context, tx Transaction) (libcommon.Address, error) {
+	if t, ok := tx.(ExplicitSenderTx); ok {
+		return t.ExplicitSender(), nil
+	}
 	var V uint256.Int
 	var R, S *uint256.Int
 	signChainID := sg.chainID.ToBig() // This is reset to nil if tx is unprotected
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// TxDecoder decodes the payload of a transaction of a registered type, which follows the type byte.
+type TxDecoder func(s *rlp.Stream) (Transaction, error)
+
+var (
+	extraTxTypesLock sync.RWMutex
+	extraTxTypes     = map[byte]TxDecoder{}
+)
+
+// RegisterTxType makes the transactions and the receipts of an additional EIP-2718 type decodable, for the L2
+// stacks which define their own (see turbo/l2). It panics for the types of Ethereum and for types registered twice.
+func RegisterTxType(txType byte, decode TxDecoder) {
+	if txType <= DynamicFeeTxType || txType >= 0x80 {
+		panic(fmt.Sprintf("transaction type %d can't be registered", txType))
+	}
+	extraTxTypesLock.Lock()
+	defer extraTxTypesLock.Unlock()
+	if _, ok := extraTxTypes[txType]; ok {
+		panic(fmt.Sprintf("transaction type %d registered twice", txType))
+	}
+	extraTxTypes[txType] = decode
+}
+
+func extraTxDecoder(txType byte) TxDecoder {
+	extraTxTypesLock.RLock()
+	defer extraTxTypesLock.RUnlock()
+	return extraTxTypes[txType]
+}
+
+// ExplicitSenderTx is implemented by the registered transaction types which carry their sender instead of a
+// signature, like the deposits of op-stack. Signer returns it as is.
+type ExplicitSenderTx interface {
+	Transaction
+	ExplicitSender() libcommon.Address
+}
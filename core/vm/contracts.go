@@ -189,8 +189,17 @@ func init() {
 	}
 }
 
-// ActivePrecompiles returns the precompiles enabled with the current configuration.
+// ActivePrecompiles returns the precompiles enabled with the current configuration, the ones registered for the
+// chain included.
 func ActivePrecompiles(rules *chain.Rules) []libcommon.Address {
+	active := activeBuiltinPrecompiles(rules)
+	if custom := customPrecompilesOf(rules); custom != nil {
+		return append(append(make([]libcommon.Address, 0, len(active)+len(custom.addresses)), active...), custom.addresses...)
+	}
+	return active
+}
+
+func activeBuiltinPrecompiles(rules *chain.Rules) []libcommon.Address {
 	switch {
 	case rules.IsMoran:
 		return PrecompiledAddressesMoran
@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
+
+// CustomPrecompile is a precompiled contract added by a chain on top of the ones of its forks, for the crypto
+// primitives of a private chain which the EVM doesn't have.
+type CustomPrecompile struct {
+	Address libcommon.Address
+	Gas     func(input []byte) uint64
+	Run     func(input []byte) ([]byte, error)
+}
+
+// customPrecompiled adapts a CustomPrecompile, whose fields take the names of the methods, to PrecompiledContract.
+type customPrecompiled struct{ p *CustomPrecompile }
+
+func (c customPrecompiled) RequiredGas(input []byte) uint64  { return c.p.Gas(input) }
+func (c customPrecompiled) Run(input []byte) ([]byte, error) { return c.p.Run(input) }
+
+// customPrecompiles is the set of each chain id, the maps are replaced on registration and never modified so that
+// the EVM reads them without locking.
+type customPrecompiles map[uint64]*customSet
+
+type customSet struct {
+	contracts map[libcommon.Address]PrecompiledContract
+	addresses []libcommon.Address
+}
+
+var (
+	customLock sync.Mutex
+	customs    atomic.Value // customPrecompiles
+)
+
+// RegisterPrecompile adds p to the precompiles of the chain chainID, from its genesis. It panics when the address is
+// taken by a precompile of the EVM or registered twice: it's meant to be called from init functions.
+func RegisterPrecompile(chainID *big.Int, p CustomPrecompile) {
+	if p.Gas == nil || p.Run == nil {
+		panic(fmt.Sprintf("precompile %x of chain %d without gas or run function", p.Address, chainID))
+	}
+	if !chainID.IsUint64() {
+		panic(fmt.Sprintf("chain id %d out of range", chainID))
+	}
+	for _, builtin := range []map[libcommon.Address]PrecompiledContract{
+		PrecompiledContractsHomestead, PrecompiledContractsByzantium, PrecompiledContractsIstanbul,
+		PrecompiledContractsIstanbulForBSC, PrecompiledContractsNano, PrecompiledContractsIsMoran,
+		PrecompiledContractsBerlin, PrecompiledContractsBLS,
+	} {
+		if _, ok := builtin[p.Address]; ok {
+			panic(fmt.Sprintf("precompile %x of chain %d overrides a precompile of the EVM", p.Address, chainID))
+		}
+	}
+
+	customLock.Lock()
+	defer customLock.Unlock()
+	prev, _ := customs.Load().(customPrecompiles)
+	next := make(customPrecompiles, len(prev)+1)
+	for id, set := range prev {
+		next[id] = set
+	}
+	id := chainID.Uint64()
+	set := &customSet{contracts: map[libcommon.Address]PrecompiledContract{}}
+	if old, ok := prev[id]; ok {
+		if _, ok := old.contracts[p.Address]; ok {
+			panic(fmt.Sprintf("precompile %x of chain %d registered twice", p.Address, chainID))
+		}
+		for addr, c := range old.contracts {
+			set.contracts[addr] = c
+		}
+		set.addresses = append(set.addresses, old.addresses...)
+	}
+	set.contracts[p.Address] = customPrecompiled{&p}
+	set.addresses = append(set.addresses, p.Address)
+	next[id] = set
+	customs.Store(next)
+}
+
+// customPrecompilesOf returns the precompiles registered for the chain of rules, nil when there are none.
+func customPrecompilesOf(rules *chain.Rules) *customSet {
+	sets, _ := customs.Load().(customPrecompiles)
+	if len(sets) == 0 || rules.ChainID == nil || !rules.ChainID.IsUint64() {
+		return nil
+	}
+	return sets[rules.ChainID.Uint64()]
+}
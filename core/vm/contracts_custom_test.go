@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestRegisterPrecompile(t *testing.T) {
+	chainID := big.NewInt(424242)
+	addr := libcommon.BytesToAddress([]byte{0x01, 0x00})
+	RegisterPrecompile(chainID, CustomPrecompile{
+		Address: addr,
+		Gas:     func(input []byte) uint64 { return uint64(len(input)) },
+		Run:     func(input []byte) ([]byte, error) { return append([]byte{0xff}, input...), nil },
+	})
+	require.Panics(t, func() {
+		RegisterPrecompile(chainID, CustomPrecompile{Address: addr, Gas: func([]byte) uint64 { return 0 }, Run: func([]byte) ([]byte, error) { return nil, nil }})
+	})
+	require.Panics(t, func() {
+		RegisterPrecompile(chainID, CustomPrecompile{Address: libcommon.BytesToAddress([]byte{1}), Gas: func([]byte) uint64 { return 0 }, Run: func([]byte) ([]byte, error) { return nil, nil }})
+	})
+
+	custom := *params.TestChainConfig
+	custom.ChainID = chainID
+	for _, tt := range []struct {
+		config *chain.Config
+		active bool
+	}{{&custom, true}, {params.TestChainConfig, false}} {
+		evm := NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, &dummyStatedb{}, tt.config, Config{})
+		p, ok := evm.precompile(addr)
+		require.Equal(t, tt.active, ok)
+		require.Equal(t, tt.active, contains(ActivePrecompiles(evm.chainRules), addr))
+		if !tt.active {
+			continue
+		}
+		out, gas, err := RunPrecompiledContract(p, []byte{1, 2}, 10)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xff, 1, 2}, out)
+		require.Equal(t, uint64(8), gas)
+
+		// the precompiles of the forks are still there
+		_, ok = evm.precompile(libcommon.BytesToAddress([]byte{1}))
+		require.True(t, ok)
+		require.Equal(t, len(activeBuiltinPrecompiles(evm.chainRules))+1, len(ActivePrecompiles(evm.chainRules)))
+	}
+}
+
+func contains(addrs []libcommon.Address, addr libcommon.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
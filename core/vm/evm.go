@@ -54,8 +54,14 @@ func (evm *EVM) precompile(addr libcommon.Address) (PrecompiledContract, bool) {
 	default:
 		precompiles = PrecompiledContractsHomestead
 	}
-	p, ok := precompiles[addr]
-	return p, ok
+	if p, ok := precompiles[addr]; ok {
+		return p, true
+	}
+	if custom := customPrecompilesOf(evm.chainRules); custom != nil {
+		p, ok := custom.contracts[addr]
+		return p, ok
+	}
+	return nil, false
 }
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
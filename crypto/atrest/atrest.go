@@ -0,0 +1,132 @@
+// Package atrest encrypts sensitive files kept in the datadir (such as the node key) with key material
+// supplied by an operator-chosen KeyProvider.
+//
+// Only small files are covered: MDBX pages are not encrypted, use an encrypted filesystem for the database.
+package atrest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeySize is the size of keys returned by a KeyProvider (AES-256).
+const KeySize = 32
+
+// magic prefixes encrypted data, so plaintext files written before encryption was enabled can be recognised.
+var magic = []byte("erigon-atrest-v1:")
+
+var ErrNotEncrypted = errors.New("atrest: data is not encrypted")
+
+// KeyProvider supplies the key used to encrypt and decrypt data at rest.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// FileKeyProvider reads a hex-encoded key from a file, which is expected to live outside of the datadir
+// (mounted secret, removable media, ...).
+type FileKeyProvider string
+
+func (p FileKeyProvider) Key() ([]byte, error) {
+	data, err := os.ReadFile(string(p))
+	if err != nil {
+		return nil, fmt.Errorf("atrest: reading key file: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("atrest: key file %s: %w", p, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("atrest: key file %s: want %d bytes key, got %d", p, KeySize, len(key))
+	}
+	return key, nil
+}
+
+var (
+	providersLock sync.RWMutex
+	providers     = map[string]func(arg string) (KeyProvider, error){
+		"file": func(arg string) (KeyProvider, error) { return FileKeyProvider(arg), nil },
+	}
+)
+
+// Register makes a KeyProvider available under scheme (for example a KMS client), to be selected by
+// NewKeyProvider with "<scheme>:<arg>".
+func Register(scheme string, newProvider func(arg string) (KeyProvider, error)) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providers[scheme] = newProvider
+}
+
+// NewKeyProvider parses "<scheme>:<arg>", a path without scheme is a key file.
+func NewKeyProvider(uri string) (KeyProvider, error) {
+	scheme, arg := "file", uri
+	if i := strings.Index(uri, ":"); i > 0 {
+		scheme, arg = uri[:i], uri[i+1:]
+	}
+	providersLock.RLock()
+	newProvider, ok := providers[scheme]
+	providersLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("atrest: unknown key provider %q", scheme)
+	}
+	return newProvider(arg)
+}
+
+func newAEAD(p KeyProvider) (cipher.AEAD, error) {
+	key, err := p.Key()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsEncrypted reports whether data was produced by Encrypt.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Encrypt seals plaintext with AES-GCM, output is magic | nonce | ciphertext.
+func Encrypt(p KeyProvider, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(p)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(magic)+aead.NonceSize(), len(magic)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, magic)
+	nonce := out[len(magic):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Decrypt opens data produced by Encrypt.
+func Decrypt(p KeyProvider, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	aead, err := newAEAD(p)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(magic):]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("atrest: encrypted data too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], magic)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: decrypting: %w", err)
+	}
+	return plaintext, nil
+}
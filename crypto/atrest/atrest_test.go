@@ -0,0 +1,46 @@
+package atrest
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type staticKey []byte
+
+func (k staticKey) Key() ([]byte, error) { return k, nil }
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	key := make([]byte, KeySize)
+	key[0] = 1
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0600))
+	provider, err := NewKeyProvider("file:" + keyFile)
+	require.NoError(t, err)
+
+	data, err := Encrypt(provider, []byte("secret"))
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(data))
+	require.NotContains(t, string(data), "secret")
+	plaintext, err := Decrypt(provider, data)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(plaintext))
+
+	_, err = Decrypt(staticKey(make([]byte, KeySize)), data)
+	require.Error(t, err)
+	_, err = Decrypt(provider, []byte("secret"))
+	require.ErrorIs(t, err, ErrNotEncrypted)
+
+	Register("static", func(arg string) (KeyProvider, error) { return staticKey(key), nil })
+	static, err := NewKeyProvider("static:")
+	require.NoError(t, err)
+	plaintext, err = Decrypt(static, data)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(plaintext))
+
+	_, err = NewKeyProvider("kms:key-id")
+	require.Error(t, err)
+}
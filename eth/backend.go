@@ -41,7 +41,6 @@ import (
 	"github.com/ledgerwatch/erigon-lib/downloader/downloadercfg"
 	"github.com/ledgerwatch/erigon-lib/downloader/downloadergrpc"
 	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
-	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
 	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
@@ -55,6 +54,7 @@ import (
 	txpool2 "github.com/ledgerwatch/erigon-lib/txpool"
 	"github.com/ledgerwatch/erigon-lib/txpool/txpooluitl"
 	types2 "github.com/ledgerwatch/erigon-lib/types"
+	"github.com/ledgerwatch/erigon/common/secret"
 	"github.com/ledgerwatch/erigon/core/systemcontracts"
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/exp/slices"
@@ -75,7 +75,9 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/handshake"
 	"github.com/ledgerwatch/erigon/cmd/sentinel/sentinel/service"
 	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
+	"github.com/ledgerwatch/erigon/common/clockskew"
 	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/memlimit"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/bor"
 	"github.com/ledgerwatch/erigon/consensus/clique"
@@ -84,6 +86,7 @@ import (
 	"github.com/ledgerwatch/erigon/consensus/serenity"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/txcond"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
@@ -93,14 +96,17 @@ import (
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/notify"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/ethstats"
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
+	"github.com/ledgerwatch/erigon/turbo/l2"
 	"github.com/ledgerwatch/erigon/turbo/services"
 	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
@@ -168,6 +174,7 @@ type Ethereum struct {
 	txPool2Send             *txpool2.Send
 	txPool2GrpcServer       txpool_proto.TxpoolServer
 	notifyMiningAboutNewTxs chan struct{}
+	txConditions            *txcond.Store
 	forkValidator           *engineapi.ForkValidator
 	downloader              *downloader3.Downloader
 
@@ -207,6 +214,15 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		return nil, err
 	}
 
+	// the transaction types and fee hooks of an L2 must be installed before anything is decoded or executed
+	var l2Plugin *l2.Plugin
+	if config.L2 != "" {
+		if l2Plugin, err = l2.Activate(config.L2); err != nil {
+			return nil, err
+		}
+		log.Info("Running as the execution layer of an L2", "plugin", l2Plugin.Name)
+	}
+
 	var currentBlock *types.Block
 
 	// Check if we have an already initialized chain and fall back to
@@ -275,6 +291,11 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		return nil, err
 	}
 
+	txConditions, err := txcond.NewStore(filepath.Join(config.Dirs.DataDir, "txconditions.json"))
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	// kv_remote architecture does blocks on stream.Send - means current architecture require unlimited amount of txs to provide good throughput
@@ -292,9 +313,11 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		waitForStageLoopStop: make(chan struct{}),
 		waitForMiningStop:    make(chan struct{}),
 		notifications: &shards.Notifications{
-			Events:      shards.NewEvents(),
-			Accumulator: shards.NewAccumulator(),
+			Events:            shards.NewEvents(),
+			Accumulator:       shards.NewAccumulator(),
+			SnapshotsDownload: &shards.SnapshotsDownload{},
 		},
+		txConditions: txConditions,
 	}
 	blockReader, allSnapshots, agg, err := backend.setUpBlockReader(ctx, config.Dirs, config.Snapshot, config.Downloader)
 	if err != nil {
@@ -316,6 +339,11 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 
 	backend.gasPrice, _ = uint256.FromBig(config.Miner.GasPrice)
 
+	if ntpServer := stack.Config().P2P.NTPServer; ntpServer != "" {
+		// a second is well within a slot, but already shows in the timing of attestations
+		go clockskew.WatchNTP(backend.sentryCtx, ntpServer, time.Second)
+	}
+
 	var sentries []direct.SentryClient
 	if len(stack.Config().P2P.SentryAddr) > 0 {
 		for _, addr := range stack.Config().P2P.SentryAddr {
@@ -491,7 +519,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	mining := stagedsync.New(
 		stagedsync.MiningStages(backend.sentryCtx,
 			stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miner, *backend.chainConfig, backend.engine, backend.txPool2, backend.txPool2DB, nil, tmpdir),
-			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool2, backend.txPool2DB),
+			stagedsync.StageMiningExecCfg(backend.chainDB, miner, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, nil, 0, backend.txPool2, backend.txPool2DB, config.MinerTxFilter, backend.txConditions),
 			stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3, backend.agg),
 			stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, blockReader, nil, config.HistoryV3, backend.agg),
 			stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miner, backend.miningSealingQuit),
@@ -509,7 +537,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		proposingSync := stagedsync.New(
 			stagedsync.MiningStages(backend.sentryCtx,
 				stagedsync.StageMiningCreateBlockCfg(backend.chainDB, miningStatePos, *backend.chainConfig, backend.engine, backend.txPool2, backend.txPool2DB, param, tmpdir),
-				stagedsync.StageMiningExecCfg(backend.chainDB, miningStatePos, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, interrupt, param.PayloadId, backend.txPool2, backend.txPool2DB),
+				stagedsync.StageMiningExecCfg(backend.chainDB, miningStatePos, backend.notifications.Events, *backend.chainConfig, backend.engine, &vm.Config{}, tmpdir, interrupt, param.PayloadId, backend.txPool2, backend.txPool2DB, config.MinerTxFilter, backend.txConditions),
 				stagedsync.StageHashStateCfg(backend.chainDB, dirs, config.HistoryV3, backend.agg),
 				stagedsync.StageTrieCfg(backend.chainDB, false, true, true, tmpdir, blockReader, nil, config.HistoryV3, backend.agg),
 				stagedsync.StageMiningFinishCfg(backend.chainDB, *backend.chainConfig, backend.engine, miningStatePos, backend.miningSealingQuit),
@@ -530,13 +558,16 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 	var creds credentials.TransportCredentials
 	if stack.Config().PrivateApiAddr != "" {
 		if stack.Config().TLSConnection {
-			creds, err = grpcutil.TLS(stack.Config().TLSCACert, stack.Config().TLSCertFile, stack.Config().TLSKeyFile)
+			creds, err = secret.TLS(stack.Config().TLSCACert, stack.Config().TLSCertFile, stack.Config().TLSKeyFile)
 			if err != nil {
 				return nil, err
 			}
 		}
 		backend.privateAPI, err = privateapi.StartGrpc(
-			kvRPC,
+			privateapi.NewAuditedKV(privateapi.NewLimitedKV(privateapi.NewMeteredKV(kvRPC), privateapi.KvLimits{
+				OpsPerSecond:   stack.Config().PrivateApiKvOpsLimit,
+				BytesPerSecond: stack.Config().PrivateApiKvBytesLimit,
+			}), stack.Config().PrivateApiAuditRate),
 			ethBackendRPC,
 			backend.txPool2GrpcServer,
 			miningRPC,
@@ -549,8 +580,15 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 		}
 	}
 
-	// If we choose not to run a consensus layer, run our embedded.
-	if !config.ExternalCL && clparams.EmbeddedSupported(config.NetworkID) {
+	if l2Plugin != nil && l2Plugin.BlockSource != nil {
+		go func() {
+			defer debug.LogPanic()
+			if err := l2Plugin.BlockSource.Start(ctx, ethBackendRPC); err != nil && !errors.Is(err, context.Canceled) {
+				log.Error("L2 block source stopped", "plugin", l2Plugin.Name, "err", err)
+			}
+		}()
+	} else if !config.ExternalCL && clparams.EmbeddedSupported(config.NetworkID) {
+		// If we choose not to run a consensus layer, run our embedded.
 		genesisCfg, networkCfg, beaconCfg := clparams.GetConfigsByNetwork(clparams.NetworkType(config.NetworkID))
 		if err != nil {
 			return nil, err
@@ -722,7 +760,7 @@ func (backend *Ethereum) Init(stack *node.Node, config *ethconfig.Config) error
 	if casted, ok := backend.engine.(*bor.Bor); ok {
 		borDb = casted.DB
 	}
-	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine)
+	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine, backend.notifications.SnapshotsDownload, backend.txConditions)
 	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, blockReader, backend.agg, httpRpcCfg, backend.engine)
 	go func() {
 		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
@@ -1030,14 +1068,49 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 // Start implements node.Lifecycle, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *Ethereum) Start() error {
+	memlimit.Default.Start(s.sentryCtx, time.Second)
 	s.sentriesClient.StartStreamLoops(s.sentryCtx)
 	time.Sleep(10 * time.Millisecond) // just to reduce logs order confusion
 
 	go stages2.StageLoop(s.sentryCtx, s.chainConfig, s.chainDB, s.stagedSync, s.sentriesClient.Hd, s.notifications, s.sentriesClient.UpdateHead, s.waitForStageLoopStop, s.config.Sync.LoopThrottle)
+	go s.publishHeads()
 
 	return nil
 }
 
+// publishHeads announces the committed heads and snapshots in the datadir, for the processes which read it
+// without the private api (see `rpcdaemon --datadir.secondary`).
+func (s *Ethereum) publishHeads() {
+	defer debug.LogPanic()
+	w := notify.NewWriter(s.config.Dirs.DataDir)
+	headCh, closeHeadCh := s.notifications.Events.AddHeaderSubscription()
+	defer closeHeadCh()
+	snapshotCh, closeSnapshotCh := s.notifications.Events.AddNewSnapshotSubscription()
+	defer closeSnapshotCh()
+	for {
+		var err error
+		select {
+		case headersRlp := <-headCh:
+			if len(headersRlp) == 0 {
+				continue
+			}
+			var header types.Header
+			if err = rlp.DecodeBytes(headersRlp[len(headersRlp)-1], &header); err != nil {
+				log.Warn("[notify] decoding new head", "err", err)
+				continue
+			}
+			err = w.OnNewHeader(header.Number.Uint64(), header.Hash())
+		case <-snapshotCh:
+			err = w.OnNewSnapshot()
+		case <-s.sentryCtx.Done():
+			return
+		}
+		if err != nil {
+			log.Warn("[notify] publishing new head", "err", err)
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
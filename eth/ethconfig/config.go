@@ -18,6 +18,7 @@
 package ethconfig
 
 import (
+	"fmt"
 	"math/big"
 	"os"
 	"os/user"
@@ -35,6 +36,7 @@ import (
 
 	"github.com/ledgerwatch/erigon/consensus/ethash"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/txfilter"
 	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
 	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
@@ -75,6 +77,7 @@ var Defaults = Config{
 		ReconWorkerCount:           estimate.ReconstituteState.Workers(),
 		BodyCacheLimit:             256 * 1024 * 1024,
 		BodyDownloadTimeoutSeconds: 30,
+		BatchMemoryLimit:           2 * datasize.GB,
 	},
 	Ethash: ethash.Config{
 		CachesInMem:      2,
@@ -136,6 +139,7 @@ type Snapshot struct {
 	NoDownloader   bool // possible to use snapshots without calling Downloader
 	Verify         bool // verify snapshots on startup
 	DownloaderAddr string
+	HistoryWindow  uint64 // remove snapshots of bodies and transactions of blocks older than this many blocks, keeping headers. 0 - keep all
 }
 
 func (s Snapshot) String() string {
@@ -149,12 +153,16 @@ func (s Snapshot) String() string {
 	if !s.Produce {
 		out = append(out, "--"+FlagSnapStop+"=true")
 	}
+	if s.HistoryWindow > 0 {
+		out = append(out, fmt.Sprintf("--%s=%d", FlagSnapHistoryWindow, s.HistoryWindow))
+	}
 	return strings.Join(out, " ")
 }
 
 var (
-	FlagSnapKeepBlocks = "snap.keepblocks"
-	FlagSnapStop       = "snap.stop"
+	FlagSnapKeepBlocks    = "snap.keepblocks"
+	FlagSnapStop          = "snap.stop"
+	FlagSnapHistoryWindow = "snap.history.window"
 )
 
 func NewSnapCfg(enabled, keepBlocks, produce bool) Snapshot {
@@ -200,6 +208,10 @@ type Config struct {
 	// Mining options
 	Miner params.MiningConfig
 
+	// MinerTxFilter excludes transactions from the blocks built locally (--miner.txfilter), blocks of other
+	// producers are validated regardless of it
+	MinerTxFilter txfilter.Filter `toml:"-"`
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -244,6 +256,8 @@ type Config struct {
 	LightClientDiscoveryTCPPort uint64
 	SentinelAddr                string
 	SentinelPort                uint64
+	// L2 is the name of the registered l2 plugin to run as, empty on L1
+	L2 string
 
 	OverrideShanghaiTime *big.Int `toml:",omitempty"`
 }
@@ -257,6 +271,9 @@ type Sync struct {
 
 	BodyCacheLimit             datasize.ByteSize
 	BodyDownloadTimeoutSeconds int // TODO: change to duration
+
+	// BatchMemoryLimit is the memory over which the writes of the execution stage are spilled to disk until commit, 0 for no limit
+	BatchMemoryLimit datasize.ByteSize
 }
 
 // Chains where snapshots are enabled by default
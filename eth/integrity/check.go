@@ -0,0 +1,216 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+// Report is the machine-readable result of Check: the number of findings of every check which ran, 0 when it
+// passed, and the findings themselves.
+type Report struct {
+	Checks   map[string]int `json:"checks"`
+	Findings []Finding      `json:"findings"`
+}
+
+// OK reports whether no check found anything
+func (r *Report) OK() bool { return len(r.Findings) == 0 }
+
+type namedCheck struct {
+	name  string
+	check func(context.Context, kv.Tx, DoctorCfg) ([]Finding, error)
+}
+
+// Check walks the critical tables and cross-validates them: the checks of Doctor, plus bodies, senders and the
+// encoding of the plain state. Unlike Doctor it doesn't plan repairs, it tells what is corrupted.
+func Check(ctx context.Context, tx kv.Tx, cfg DoctorCfg) (*Report, error) {
+	if cfg.SampleEvery == 0 {
+		cfg.SampleEvery = 1
+	}
+	report := &Report{Checks: map[string]int{}, Findings: []Finding{}}
+	for _, c := range []namedCheck{
+		{"canonical", CanonicalChain},
+		{"snapshots", SnapshotsBoundary},
+		{"bodies", Bodies},
+		{"senders", Senders},
+		{"plainstate", PlainStateEncoding},
+		{"txlookup", TxLookupSample},
+		{"history", HistorySample},
+	} {
+		log.Info("[check] running", "check", c.name)
+		findings, err := c.check(ctx, tx, cfg)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", c.name, err)
+		}
+		report.Checks[c.name] = len(findings)
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+// Bodies checks that every canonical block up to the Bodies stage has a body matching the transactions, uncles and
+// withdrawals roots of its header.
+func Bodies(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "bodies"
+	to, err := stages.GetStageProgress(tx, stages.Bodies)
+	if err != nil {
+		return nil, err
+	}
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	var findings []Finding
+	for n := uint64(0); n <= to && len(findings) < maxFindings; n++ {
+		select {
+		case <-ctx.Done():
+			return findings, ctx.Err()
+		case <-logEvery.C:
+			log.Info("[check] bodies", "block", n, "of", to)
+		default:
+		}
+		hash, header, err := canonicalHeader(ctx, tx, cfg, n)
+		if err != nil {
+			return findings, err
+		}
+		if header == nil {
+			continue // reported by the canonical check
+		}
+		body, err := cfg.BlockReader.BodyWithTransactions(ctx, tx, hash, n)
+		if err != nil {
+			findings = append(findings, Finding{Check: check, Block: n, Problem: fmt.Sprintf("unreadable body: %v", err), Repair: unwindBodiesTo(tx, n-1)})
+			continue
+		}
+		if body == nil {
+			findings = append(findings, Finding{Check: check, Block: n, Problem: "no body", Repair: unwindBodiesTo(tx, n-1)})
+			continue
+		}
+		if problem := compareBody(header, body); problem != "" {
+			findings = append(findings, Finding{Check: check, Block: n, Problem: problem, Repair: unwindBodiesTo(tx, n-1)})
+		}
+	}
+	return findings, nil
+}
+
+func compareBody(header *types.Header, body *types.Body) string {
+	if root := types.DeriveSha(types.Transactions(body.Transactions)); root != header.TxHash {
+		return fmt.Sprintf("transactions root %x, header has %x", root, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(body.Uncles); hash != header.UncleHash {
+		return fmt.Sprintf("uncles hash %x, header has %x", hash, header.UncleHash)
+	}
+	if header.WithdrawalsHash != nil {
+		if root := types.DeriveSha(types.Withdrawals(body.Withdrawals)); root != *header.WithdrawalsHash {
+			return fmt.Sprintf("withdrawals root %x, header has %x", root, *header.WithdrawalsHash)
+		}
+	}
+	return ""
+}
+
+// Senders checks that every canonical block up to the Senders stage has one sender per transaction.
+func Senders(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "senders"
+	to, err := stages.GetStageProgress(tx, stages.Senders)
+	if err != nil {
+		return nil, err
+	}
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	var findings []Finding
+	for n := uint64(0); n <= to && len(findings) < maxFindings; n++ {
+		select {
+		case <-ctx.Done():
+			return findings, ctx.Err()
+		case <-logEvery.C:
+			log.Info("[check] senders", "block", n, "of", to)
+		default:
+		}
+		hash, err := cfg.BlockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return findings, err
+		}
+		if hash == (libcommon.Hash{}) {
+			continue
+		}
+		block, senders, err := cfg.BlockReader.BlockWithSenders(ctx, tx, hash, n)
+		if err != nil {
+			return findings, err
+		}
+		if block == nil {
+			continue // reported by the bodies check
+		}
+		if len(senders) != len(block.Transactions()) {
+			findings = append(findings, Finding{Check: check, Block: n,
+				Problem: fmt.Sprintf("%d senders for %d transactions", len(senders), len(block.Transactions())),
+				Repair:  fmt.Sprintf("recover the senders again: integration stage_senders --unwind=%d", to-n+1)})
+		}
+	}
+	return findings, nil
+}
+
+// PlainStateEncoding checks that every entry of the plain state is an account or a storage slot which decodes.
+func PlainStateEncoding(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "plainstate"
+	progress, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, err
+	}
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+	repair := "re-execute: integration stage_exec --reset, then restart erigon"
+
+	var findings []Finding
+	var account accounts.Account
+	err = tx.ForEach(kv.PlainState, nil, func(k, v []byte) error {
+		if len(findings) >= maxFindings {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-logEvery.C:
+			log.Info("[check] plain state", "key", fmt.Sprintf("%x", k))
+		default:
+		}
+		switch len(k) {
+		case length.Addr:
+			if err := account.DecodeForStorage(v); err != nil {
+				findings = append(findings, Finding{Check: check, Block: progress, Problem: fmt.Sprintf("account %x: %v", k, err), Repair: repair})
+			}
+		case length.Addr + length.Incarnation + length.Hash:
+			if len(v) == 0 || len(v) > length.Hash {
+				findings = append(findings, Finding{Check: check, Block: progress, Problem: fmt.Sprintf("storage %x: value of %d bytes", k, len(v)), Repair: repair})
+			}
+		default:
+			findings = append(findings, Finding{Check: check, Block: progress, Problem: fmt.Sprintf("key %x of %d bytes", k, len(k)), Repair: repair})
+		}
+		return nil
+	})
+	return findings, err
+}
+
+func canonicalHeader(ctx context.Context, tx kv.Tx, cfg DoctorCfg, n uint64) (libcommon.Hash, *types.Header, error) {
+	hash, err := cfg.BlockReader.CanonicalHash(ctx, tx, n)
+	if err != nil || hash == (libcommon.Hash{}) {
+		return hash, nil, err
+	}
+	header, err := cfg.BlockReader.Header(ctx, tx, hash, n)
+	return hash, header, err
+}
+
+func unwindBodiesTo(tx kv.Tx, block uint64) string {
+	progress, _ := stages.GetStageProgress(tx, stages.Bodies)
+	if progress <= block {
+		return "none needed, block is above the Bodies stage"
+	}
+	return fmt.Sprintf("unwind to block %d: integration stage_bodies --unwind=%d", block, progress-block)
+}
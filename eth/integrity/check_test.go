@@ -0,0 +1,53 @@
+package integrity
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+func TestCheck(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	ctx := context.Background()
+	cfg := DoctorCfg{BlockReader: snapshotsync.NewBlockReader(), SampleEvery: 1}
+
+	var parent *types.Header
+	for n := int64(0); n <= 3; n++ {
+		h := &types.Header{Number: big.NewInt(n), Difficulty: big.NewInt(1), TxHash: types.EmptyRootHash, UncleHash: types.EmptyUncleHash}
+		if parent != nil {
+			h.ParentHash = parent.Hash()
+		}
+		require.NoError(t, rawdb.WriteBlock(tx, types.NewBlockWithHeader(h)))
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, h.Hash(), h.Number.Uint64()))
+		parent = h
+	}
+	for _, stage := range []stages.SyncStage{stages.Headers, stages.Bodies, stages.Senders} {
+		require.NoError(t, stages.SaveStageProgress(tx, stage, 3))
+	}
+
+	report, err := Check(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.True(t, report.OK(), report.Findings)
+	require.Len(t, report.Checks, 7)
+
+	// a body which doesn't match its header, and a key which is neither an account nor a storage slot
+	hash2, err := rawdb.ReadCanonicalHash(tx, 2)
+	require.NoError(t, err)
+	require.NoError(t, rawdb.WriteBody(tx, hash2, 2, &types.Body{Uncles: []*types.Header{parent}}))
+	require.NoError(t, tx.Put(kv.PlainState, []byte{1, 2, 3}, []byte{1}))
+
+	report, err = Check(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, 1, report.Checks["bodies"], report.Findings)
+	require.Equal(t, 1, report.Checks["plainstate"], report.Findings)
+}
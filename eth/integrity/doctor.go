@@ -0,0 +1,325 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/cmp"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/erigon/turbo/services"
+)
+
+// maxFindings stops a check after this many problems: past that point the database needs an unwind, not a list.
+const maxFindings = 100
+
+// Finding is an inconsistency found by one of the doctor checks.
+type Finding struct {
+	Check   string `json:"check"`
+	Block   uint64 `json:"block"`
+	Problem string `json:"problem"`
+	// Repair is the plan to fix the problem: applied by Repair when fix is set, otherwise a command to run by hand.
+	Repair string `json:"repair"`
+	fix    func(tx kv.RwTx) error
+}
+
+// Safe reports whether the finding can be repaired without unwinding any stage.
+func (f *Finding) Safe() bool { return f.fix != nil }
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("[%s] block %d: %s, repair: %s", f.Check, f.Block, f.Problem, f.Repair)
+}
+
+// DoctorCfg configures the checks: SampleEvery is the distance between sampled blocks of the txlookup and
+// history checks, SnapshotBlocks the last block available in snapshots (0 without snapshots).
+type DoctorCfg struct {
+	BlockReader    services.FullBlockReader
+	SnapshotBlocks uint64
+	SampleEvery    uint64
+	HistoryV3      bool
+}
+
+// Doctor runs all the consistency checks and returns what they found.
+func Doctor(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	if cfg.SampleEvery == 0 {
+		cfg.SampleEvery = 1
+	}
+	var findings []Finding
+	for _, check := range []func(context.Context, kv.Tx, DoctorCfg) ([]Finding, error){
+		CanonicalChain, SnapshotsBoundary, TxLookupSample, HistorySample,
+	} {
+		f, err := check(ctx, tx, cfg)
+		if err != nil {
+			return findings, err
+		}
+		findings = append(findings, f...)
+	}
+	return findings, nil
+}
+
+// Repair applies the safe repairs of findings, others are left to the operator.
+func Repair(tx kv.RwTx, findings []Finding) (repaired int, err error) {
+	for i := range findings {
+		if !findings[i].Safe() {
+			continue
+		}
+		if err := findings[i].fix(tx); err != nil {
+			return repaired, fmt.Errorf("%s: %w", findings[i].String(), err)
+		}
+		repaired++
+	}
+	return repaired, nil
+}
+
+func unwindHeadersTo(tx kv.Tx, block uint64) string {
+	progress, _ := stages.GetStageProgress(tx, stages.Headers)
+	if progress <= block {
+		return "none needed, block is above the Headers stage"
+	}
+	return fmt.Sprintf("unwind to block %d: integration stage_headers --unwind=%d", block, progress-block)
+}
+
+// CanonicalChain checks that every block up to the Headers stage has a canonical hash and a header,
+// and that each header points to the canonical hash of the previous block.
+func CanonicalChain(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "canonical"
+	to, err := stages.GetStageProgress(tx, stages.Headers)
+	if err != nil {
+		return nil, err
+	}
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	var findings []Finding
+	prevHash, err := cfg.BlockReader.CanonicalHash(ctx, tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for n := uint64(1); n <= to && len(findings) < maxFindings; n++ {
+		select {
+		case <-ctx.Done():
+			return findings, ctx.Err()
+		case <-logEvery.C:
+			log.Info("[doctor] canonical chain", "block", n, "of", to)
+		default:
+		}
+		hash, err := cfg.BlockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return findings, err
+		}
+		if hash == (libcommon.Hash{}) {
+			f := Finding{Check: check, Block: n, Problem: "no canonical hash", Repair: unwindHeadersTo(tx, n-1)}
+			// a single stored header on top of the previous canonical one can only be the canonical one
+			if headers, err := rawdb.ReadHeadersByNumber(tx, n); err == nil && len(headers) == 1 && headers[0].ParentHash == prevHash {
+				header, n := headers[0], n
+				f.Repair, f.fix = fmt.Sprintf("mark %x canonical", header.Hash()), func(tx kv.RwTx) error {
+					return rawdb.WriteCanonicalHash(tx, header.Hash(), n)
+				}
+				hash = header.Hash()
+			}
+			findings = append(findings, f)
+			prevHash = hash
+			continue
+		}
+		header, err := cfg.BlockReader.Header(ctx, tx, hash, n)
+		if err != nil {
+			return findings, err
+		}
+		switch {
+		case header == nil:
+			findings = append(findings, Finding{Check: check, Block: n, Problem: fmt.Sprintf("no header for canonical hash %x", hash), Repair: unwindHeadersTo(tx, n-1)})
+		case header.ParentHash != prevHash:
+			findings = append(findings, Finding{Check: check, Block: n, Problem: fmt.Sprintf("parent hash %x, canonical hash of previous block is %x", header.ParentHash, prevHash), Repair: unwindHeadersTo(tx, n-1)})
+		}
+		prevHash = hash
+	}
+	return findings, nil
+}
+
+// SnapshotsBoundary checks that the blocks stored in the database continue the chain of the snapshots.
+func SnapshotsBoundary(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "snapshots"
+	last := cfg.SnapshotBlocks
+	if last == 0 {
+		return nil, nil
+	}
+	var findings []Finding
+	for _, stage := range []stages.SyncStage{stages.Headers, stages.Bodies} {
+		progress, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		if progress < last {
+			findings = append(findings, Finding{Check: check, Block: progress,
+				Problem: fmt.Sprintf("%s stage is behind snapshots (block %d)", stage, last),
+				Repair:  "restart erigon, it moves the stages to the end of snapshots"})
+		}
+	}
+	snapHash, err := cfg.BlockReader.CanonicalHash(ctx, tx, last)
+	if err != nil {
+		return nil, err
+	}
+	if snapHash == (libcommon.Hash{}) {
+		findings = append(findings, Finding{Check: check, Block: last, Problem: "last block of snapshots has no header", Repair: "rebuild the indices of snapshots: erigon snapshots index"})
+		return findings, nil
+	}
+	dbHash, err := rawdb.ReadCanonicalHash(tx, last)
+	if err != nil {
+		return nil, err
+	}
+	if dbHash != (libcommon.Hash{}) && dbHash != snapHash {
+		f := Finding{Check: check, Block: last, Problem: fmt.Sprintf("canonical hash %x in database, %x in snapshots", dbHash, snapHash)}
+		f.Repair, f.fix = "take the hash of snapshots", func(tx kv.RwTx) error {
+			return rawdb.WriteCanonicalHash(tx, snapHash, last)
+		}
+		findings = append(findings, f)
+	}
+	next := rawdb.ReadHeaderByNumber(tx, last+1)
+	if next != nil && next.ParentHash != snapHash {
+		findings = append(findings, Finding{Check: check, Block: last + 1,
+			Problem: fmt.Sprintf("parent hash %x, last block of snapshots is %x", next.ParentHash, snapHash),
+			Repair:  unwindHeadersTo(tx, last)})
+	}
+	return findings, nil
+}
+
+// TxLookupSample checks that the transactions of every SampleEvery-th block indexed by the TxLookup stage
+// can be found by hash.
+func TxLookupSample(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "txlookup"
+	to, err := stages.GetStageProgress(tx, stages.TxLookup)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := prune.Get(tx)
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(0)
+	if pm.TxIndex.Enabled() {
+		from = pm.TxIndex.PruneTo(to)
+	}
+	var findings []Finding
+	for n := from; n <= to && len(findings) < maxFindings; n += cfg.SampleEvery {
+		if err := ctx.Err(); err != nil {
+			return findings, err
+		}
+		hash, err := cfg.BlockReader.CanonicalHash(ctx, tx, n)
+		if err != nil {
+			return findings, err
+		}
+		block, _, err := cfg.BlockReader.BlockWithSenders(ctx, tx, hash, n)
+		if err != nil {
+			return findings, err
+		}
+		if block == nil {
+			continue // reported by the canonical check
+		}
+		for _, txn := range block.Transactions() {
+			found, ok, err := cfg.BlockReader.TxnLookup(ctx, tx, txn.Hash())
+			if err != nil {
+				return findings, err
+			}
+			if ok && found == n {
+				continue
+			}
+			problem := fmt.Sprintf("transaction %x not indexed", txn.Hash())
+			if ok {
+				problem = fmt.Sprintf("transaction %x indexed at block %d", txn.Hash(), found)
+			}
+			findings = append(findings, Finding{Check: check, Block: n, Problem: problem,
+				Repair: "re-index the transactions of the block", fix: writeTxLookup(block)})
+			break
+		}
+	}
+	return findings, nil
+}
+
+func writeTxLookup(block *types.Block) func(tx kv.RwTx) error {
+	return func(tx kv.RwTx) error {
+		rawdb.WriteTxLookupEntries(tx, block)
+		return nil
+	}
+}
+
+// HistorySample checks, for every SampleEvery-th block covered by the account history index, that the state
+// read from history at the block matches the values recorded in its account changeset.
+func HistorySample(ctx context.Context, tx kv.Tx, cfg DoctorCfg) ([]Finding, error) {
+	const check = "history"
+	if cfg.HistoryV3 {
+		return nil, nil
+	}
+	to, err := stages.GetStageProgress(tx, stages.AccountHistoryIndex)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := prune.Get(tx)
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(1)
+	if pm.History.Enabled() {
+		from = cmp.Max(from, pm.History.PruneTo(to))
+	}
+	var findings []Finding
+	for n := from; n <= to && len(findings) < maxFindings; n += cfg.SampleEvery {
+		if err := ctx.Err(); err != nil {
+			return findings, err
+		}
+		reader := state.NewPlainState(tx, n, nil)
+		if err := changeset.ForRange(tx, kv.AccountChangeSet, n, n+1, func(_ uint64, k, v []byte) error {
+			if len(findings) >= maxFindings {
+				return nil
+			}
+			addr := libcommon.BytesToAddress(k)
+			var expected *accounts.Account
+			if len(v) > 0 {
+				expected = new(accounts.Account)
+				if err := expected.DecodeForStorage(v); err != nil {
+					return err
+				}
+			}
+			actual, err := reader.ReadAccountData(addr)
+			if err != nil {
+				return err
+			}
+			if problem := compareAccounts(expected, actual); problem != "" {
+				findings = append(findings, Finding{Check: check, Block: n,
+					Problem: fmt.Sprintf("account %x: %s", addr, problem),
+					Repair:  "rebuild the index: integration stage_history --reset, then restart erigon"})
+			}
+			return nil
+		}); err != nil {
+			return findings, err
+		}
+	}
+	return findings, nil
+}
+
+func compareAccounts(expected, actual *accounts.Account) string {
+	switch {
+	case expected == nil && actual == nil:
+		return ""
+	case expected == nil:
+		return "exists in history, absent from changeset"
+	case actual == nil:
+		return "absent from history, exists in changeset"
+	case expected.Nonce != actual.Nonce:
+		return fmt.Sprintf("nonce %d in history, %d in changeset", actual.Nonce, expected.Nonce)
+	case !expected.Balance.Eq(&actual.Balance):
+		return fmt.Sprintf("balance %d in history, %d in changeset", &actual.Balance, &expected.Balance)
+	case expected.Incarnation != actual.Incarnation:
+		return fmt.Sprintf("incarnation %d in history, %d in changeset", actual.Incarnation, expected.Incarnation)
+	}
+	return ""
+}
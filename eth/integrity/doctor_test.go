@@ -0,0 +1,65 @@
+package integrity
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+func TestCanonicalChain(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	ctx := context.Background()
+	cfg := DoctorCfg{BlockReader: snapshotsync.NewBlockReader(), SampleEvery: 1}
+
+	var parent *types.Header
+	for n := int64(0); n <= 4; n++ {
+		h := &types.Header{Number: big.NewInt(n), Difficulty: big.NewInt(1)}
+		if parent != nil {
+			h.ParentHash = parent.Hash()
+		}
+		rawdb.WriteHeader(tx, h)
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, h.Hash(), h.Number.Uint64()))
+		parent = h
+	}
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Headers, 4))
+
+	findings, err := CanonicalChain(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.Empty(t, findings)
+
+	// a lost canonical hash is repaired from the only header stored at that height
+	require.NoError(t, rawdb.TruncateCanonicalHash(tx, 2, false))
+	lost, err := rawdb.ReadCanonicalHash(tx, 3)
+	require.NoError(t, err)
+	require.Zero(t, lost)
+	findings, err = CanonicalChain(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.Len(t, findings, 3)
+	for _, f := range findings {
+		require.True(t, f.Safe(), f.String())
+	}
+	repaired, err := Repair(tx, findings)
+	require.NoError(t, err)
+	require.Equal(t, 3, repaired)
+	findings, err = CanonicalChain(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.Empty(t, findings)
+
+	// a fork header at the same height can't be told apart, it needs an unwind
+	fork := &types.Header{Number: big.NewInt(4), Difficulty: big.NewInt(2)}
+	rawdb.WriteHeader(tx, fork)
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, fork.Hash(), 4))
+	findings, err = CanonicalChain(ctx, tx, cfg)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	require.Equal(t, uint64(4), findings[0].Block)
+	require.False(t, findings[0].Safe())
+}
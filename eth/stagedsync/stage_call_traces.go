@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"runtime"
+	"sort"
 	"time"
 
 	"github.com/RoaringBitmap/roaring/roaring64"
@@ -21,6 +22,7 @@ import (
 	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/params"
 )
@@ -92,6 +94,7 @@ func promoteCallTraces(logPrefix string, tx kv.RwTx, startBlock, endBlock uint64
 
 	froms := map[string]*roaring64.Bitmap{}
 	tos := map[string]*roaring64.Bitmap{}
+	activity := addressActivity{}
 	collectorFrom := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
 	defer collectorFrom.Close()
 	collectorTo := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
@@ -135,6 +138,7 @@ func promoteCallTraces(logPrefix string, tx kv.RwTx, startBlock, endBlock uint64
 			}
 			m.Add(blockNum)
 		}
+		activity.add(libcommon.BytesToAddress(v[:length.Addr]), blockNum)
 		select {
 		default:
 		case <-logEvery.C:
@@ -163,6 +167,13 @@ func promoteCallTraces(logPrefix string, tx kv.RwTx, startBlock, endBlock uint64
 
 				tos = map[string]*roaring64.Bitmap{}
 			}
+
+			if activity.needFlush(bufLimit) {
+				if err := activity.flush(tx); err != nil {
+					return err
+				}
+				activity = addressActivity{}
+			}
 		}
 	}
 	if err = flushBitmaps64(collectorFrom, froms); err != nil {
@@ -171,6 +182,9 @@ func promoteCallTraces(logPrefix string, tx kv.RwTx, startBlock, endBlock uint64
 	if err = flushBitmaps64(collectorTo, tos); err != nil {
 		return err
 	}
+	if err = activity.flush(tx); err != nil {
+		return err
+	}
 
 	// Clean up before loading call traces to reclaim space
 	var prunedMin uint64 = math.MaxUint64
@@ -309,6 +323,7 @@ func DoUnwindCallTraces(logPrefix string, db kv.RwTx, from, to uint64, ctx conte
 	}
 	defer traceCursor.Close()
 
+	unwound := map[libcommon.Address]uint64{} // address -> number of unwound blocks it appears in
 	var k, v []byte
 	prev := to + 1
 	for k, v, err = traceCursor.Seek(hexutility.EncodeTs(to + 1)); k != nil; k, v, err = traceCursor.Next() {
@@ -323,6 +338,7 @@ func DoUnwindCallTraces(logPrefix string, db kv.RwTx, from, to uint64, ctx conte
 			return fmt.Errorf("wrong size of value in CallTraceSet: %x (size %d)", v, len(v))
 		}
 		mapKey := v[:length.Addr]
+		unwound[libcommon.BytesToAddress(mapKey)]++
 		if v[length.Addr]&1 > 0 {
 			if err = froms.Collect(mapKey, nil); err != nil {
 				return nil
@@ -361,7 +377,7 @@ func DoUnwindCallTraces(logPrefix string, db kv.RwTx, from, to uint64, ctx conte
 	}, etl.TransformArgs{}); err != nil {
 		return fmt.Errorf("TruncateRange: bucket=%s, %w", kv.CallFromIndex, err)
 	}
-	return nil
+	return unwindAddressActivity(db, unwound, to)
 }
 
 func PruneCallTraces(s *PruneState, tx kv.RwTx, cfg CallTracesCfg, ctx context.Context) (err error) {
@@ -510,3 +526,151 @@ func pruneCallTraces(tx kv.RwTx, logPrefix string, pruneTo uint64, ctx context.C
 	}
 	return nil
 }
+
+// addressActivity is the activity of the addresses seen in the call traces of consecutive blocks, not yet written to
+// rawdb.AddressActivity
+type addressActivity map[libcommon.Address]*rawdb.Activity
+
+// activitySize is roughly the memory used by an entry of addressActivity
+const activitySize = 128
+
+// add records that addr appears in block blockNum, blocks are visited in increasing order
+func (a addressActivity) add(addr libcommon.Address, blockNum uint64) {
+	if activity, ok := a[addr]; ok {
+		activity.LastBlock = blockNum
+		activity.Blocks++
+		return
+	}
+	a[addr] = &rawdb.Activity{FirstBlock: blockNum, LastBlock: blockNum, Blocks: 1}
+}
+
+func (a addressActivity) needFlush(limit datasize.ByteSize) bool {
+	return uint64(len(a)*activitySize) > uint64(limit)
+}
+
+// flush merges the accumulated activity into rawdb.AddressActivity
+func (a addressActivity) flush(tx kv.RwTx) error {
+	if len(a) == 0 {
+		return nil
+	}
+	if err := ensureActivityTable(tx); err != nil {
+		return err
+	}
+	addrs := make([]libcommon.Address, 0, len(a))
+	for addr := range a {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	for _, addr := range addrs {
+		activity := *a[addr]
+		existing, err := rawdb.ReadAddressActivity(tx, addr)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			activity.FirstBlock = existing.FirstBlock
+			activity.Blocks += existing.Blocks
+		}
+		if err := rawdb.WriteAddressActivity(tx, addr, activity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unwindAddressActivity removes the blocks after to from the activity of the addresses in unwound, which maps them to
+// the number of unwound blocks they appear in. It runs after the call indexes are truncated, the new last block of an
+// address is the last one left in them.
+func unwindAddressActivity(tx kv.RwTx, unwound map[libcommon.Address]uint64, to uint64) error {
+	if len(unwound) == 0 {
+		return nil
+	}
+	if err := ensureActivityTable(tx); err != nil {
+		return err
+	}
+	for addr, blocks := range unwound {
+		activity, err := rawdb.ReadAddressActivity(tx, addr)
+		if err != nil {
+			return err
+		}
+		if activity == nil {
+			continue
+		}
+		if activity.FirstBlock > to || activity.Blocks <= blocks {
+			if err := rawdb.DeleteAddressActivity(tx, addr); err != nil {
+				return err
+			}
+			continue
+		}
+		activity.Blocks -= blocks
+		last, err := lastCallIndexBlock(tx, addr)
+		if err != nil {
+			return err
+		}
+		if last < activity.FirstBlock || last > to {
+			// the call indexes were pruned past the remaining blocks of the address, only a bound is known
+			last = to
+		}
+		activity.LastBlock = last
+		if err := rawdb.WriteAddressActivity(tx, addr, *activity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastCallIndexBlock returns the last block of addr in CallFromIndex and CallToIndex, 0 if it is in none of them
+func lastCallIndexBlock(tx kv.Tx, addr libcommon.Address) (uint64, error) {
+	var last uint64
+	lastChunkKey := make([]byte, length.Addr+8)
+	copy(lastChunkKey, addr[:])
+	binary.BigEndian.PutUint64(lastChunkKey[length.Addr:], ^uint64(0))
+	for _, table := range []string{kv.CallFromIndex, kv.CallToIndex} {
+		if err := func() error {
+			c, err := tx.Cursor(table)
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			k, v, err := c.Seek(lastChunkKey)
+			if err != nil {
+				return err
+			}
+			if k == nil {
+				k, v, err = c.Last()
+			} else if !bytes.HasPrefix(k, addr[:]) {
+				k, v, err = c.Prev()
+			}
+			if err != nil {
+				return err
+			}
+			if len(k) != length.Addr+8 || !bytes.HasPrefix(k, addr[:]) {
+				return nil
+			}
+			chunk := roaring64.New()
+			if _, err := chunk.ReadFrom(bytes.NewReader(v)); err != nil {
+				return err
+			}
+			if !chunk.IsEmpty() && chunk.Maximum() > last {
+				last = chunk.Maximum()
+			}
+			return nil
+		}(); err != nil {
+			return 0, err
+		}
+	}
+	return last, nil
+}
+
+// ensureActivityTable creates rawdb.AddressActivity when the database was opened without it in its TableCfg
+func ensureActivityTable(tx kv.RwTx) error {
+	migrator, ok := tx.(kv.BucketMigrator)
+	if !ok {
+		return nil
+	}
+	exists, err := migrator.ExistsBucket(rawdb.AddressActivity)
+	if err != nil || exists {
+		return err
+	}
+	return migrator.CreateBucket(rawdb.AddressActivity)
+}
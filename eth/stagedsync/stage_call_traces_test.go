@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/RoaringBitmap/roaring/roaring64"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/bitmapdb"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 )
 
@@ -35,7 +37,7 @@ func TestCallTrace(t *testing.T) {
 	ctx, assert := context.Background(), assert.New(t)
 	_, tx := memdb.NewTestTx(t)
 	genTestCallTraceSet(t, tx, 30)
-	addr := [20]byte{}
+	addr := libcommon.Address{}
 	addr[19] = byte(1)
 	froms := func() *roaring64.Bitmap {
 		b, err := bitmapdb.Get64(tx, kv.CallFromIndex, addr[:], 0, 30)
@@ -47,6 +49,11 @@ func TestCallTrace(t *testing.T) {
 		assert.NoError(err)
 		return b
 	}
+	activity := func() *rawdb.Activity {
+		a, err := rawdb.ReadAddressActivity(tx, addr)
+		assert.NoError(err)
+		return a
+	}
 
 	err := stages.SaveStageProgress(tx, stages.Execution, 30)
 	assert.NoError(err)
@@ -56,18 +63,21 @@ func TestCallTrace(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal([]uint64{6, 16}, froms().ToArray())
 	assert.Equal([]uint64{1, 11}, tos().ToArray())
+	assert.Equal(&rawdb.Activity{FirstBlock: 1, LastBlock: 16, Blocks: 4}, activity())
 
 	// unwind 20->10
 	err = DoUnwindCallTraces("test", tx, 20, 10, ctx, "")
 	assert.NoError(err)
 	assert.Equal([]uint64{6}, froms().ToArray())
 	assert.Equal([]uint64{1}, tos().ToArray())
+	assert.Equal(&rawdb.Activity{FirstBlock: 1, LastBlock: 6, Blocks: 2}, activity())
 
 	// forward 10->30
 	err = promoteCallTraces("test", tx, 10, 30, 0, time.Nanosecond, ctx.Done(), "")
 	assert.NoError(err)
 	assert.Equal([]uint64{6, 16, 26}, froms().ToArray())
 	assert.Equal([]uint64{1, 11, 21}, tos().ToArray())
+	assert.Equal(&rawdb.Activity{FirstBlock: 1, LastBlock: 26, Blocks: 6}, activity())
 
 	// prune 0 -> 10
 	err = pruneCallTraces(tx, "test", 10, ctx, "")
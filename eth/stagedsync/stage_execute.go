@@ -412,12 +412,14 @@ func SpawnExecuteBlocksStage(s *StageState, u Unwinder, tx kv.RwTx, toBlock uint
 	var currentStateGas uint64 // used for batch commits of state
 	// Transform batch_size limit into Ggas
 	gasState := uint64(cfg.batchSize) * uint64(datasize.KB) * 2
+	// Commit earlier if the written tables are deep enough to cause dirty-page spikes
+	batchSize := ethdb.AdaptiveBatchSize(tx, cfg.batchSize, kv.PlainState, kv.AccountChangeSet, kv.StorageChangeSet)
 
 	var stoppedErr error
 
 	var batch ethdb.DbWithPendingMutations
 	// state is stored through ethdb batches
-	batch = olddb.NewHashBatch(tx, quit, cfg.dirs.Tmp)
+	batch = olddb.NewHashBatchWithLimit(tx, quit, cfg.dirs.Tmp, cfg.syncCfg.BatchMemoryLimit)
 	// avoids stacking defers within the loop
 	defer func() {
 		batch.Rollback()
@@ -463,7 +465,7 @@ Loop:
 		}
 		stageProgress = blockNum
 
-		shouldUpdateProgress := batch.BatchSize() >= int(cfg.batchSize)
+		shouldUpdateProgress := batch.BatchSize() >= int(batchSize)
 		if shouldUpdateProgress {
 			log.Info("Committed State", "gas reached", currentStateGas, "gasTarget", gasState)
 			currentStateGas = 0
@@ -484,7 +486,7 @@ Loop:
 				// TODO: This creates stacked up deferrals
 				defer tx.Rollback()
 			}
-			batch = olddb.NewHashBatch(tx, quit, cfg.dirs.Tmp)
+			batch = olddb.NewHashBatchWithLimit(tx, quit, cfg.dirs.Tmp, cfg.syncCfg.BatchMemoryLimit)
 		}
 
 		gas = gas + block.GasUsed()
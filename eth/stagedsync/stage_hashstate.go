@@ -28,6 +28,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
+	"github.com/ledgerwatch/erigon/ethdb"
 )
 
 type HashStateCfg struct {
@@ -191,10 +192,10 @@ func promotePlainState(
 	tmpdir string,
 	ctx context.Context,
 ) error {
-	accCollector := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	accCollector := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(ethdb.AdaptiveBatchSize(tx, etl.BufferOptimalSize, kv.HashedAccounts)))
 	defer accCollector.Close()
 	accCollector.LogLvl(log.LvlTrace)
-	storageCollector := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
+	storageCollector := etl.NewCollector(logPrefix, tmpdir, etl.NewSortableBuffer(ethdb.AdaptiveBatchSize(tx, etl.BufferOptimalSize, kv.HashedStorage)))
 	defer storageCollector.Close()
 	storageCollector.LogLvl(log.LvlTrace)
 
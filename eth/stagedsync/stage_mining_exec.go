@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
@@ -32,6 +33,8 @@ import (
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/systemcontracts"
+	"github.com/ledgerwatch/erigon/core/txcond"
+	"github.com/ledgerwatch/erigon/core/txfilter"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
@@ -52,6 +55,9 @@ type MiningExecCfg struct {
 	payloadId   uint64
 	txPool2     *txpool.TxPool
 	txPool2DB   kv.RoDB
+	txFilter    txfilter.Filter
+	// conditions of the transactions submitted with eth_sendRawTransactionConditional, nil without RPC in the process
+	txConditions *txcond.Store
 }
 
 func StageMiningExecCfg(
@@ -66,20 +72,24 @@ func StageMiningExecCfg(
 	payloadId uint64,
 	txPool2 *txpool.TxPool,
 	txPool2DB kv.RoDB,
+	txFilter txfilter.Filter,
+	txConditions *txcond.Store,
 ) MiningExecCfg {
 	return MiningExecCfg{
-		db:          db,
-		miningState: miningState,
-		notifier:    notifier,
-		chainConfig: chainConfig,
-		engine:      engine,
-		blockReader: snapshotsync.NewBlockReader(),
-		vmConfig:    vmConfig,
-		tmpdir:      tmpdir,
-		interrupt:   interrupt,
-		payloadId:   payloadId,
-		txPool2:     txPool2,
-		txPool2DB:   txPool2DB,
+		db:           db,
+		miningState:  miningState,
+		notifier:     notifier,
+		chainConfig:  chainConfig,
+		engine:       engine,
+		blockReader:  snapshotsync.NewBlockReader(),
+		vmConfig:     vmConfig,
+		tmpdir:       tmpdir,
+		interrupt:    interrupt,
+		payloadId:    payloadId,
+		txPool2:      txPool2,
+		txPool2DB:    txPool2DB,
+		txFilter:     txFilter,
+		txConditions: txConditions,
 	}
 }
 
@@ -233,6 +243,21 @@ func getNextTransactions(
 		var sender libcommon.Address
 		copy(sender[:], txSlots.Senders.At(i))
 
+		if filter := cfg.txFilter; filter != nil {
+			if ok, reason := filter.Allow(transaction, sender); !ok {
+				log.Debug("Transaction excluded by filter", "hash", transaction.Hash(), "sender", sender, "reason", reason)
+				metrics.GetOrCreateCounter(fmt.Sprintf(`mining_filtered_txs{reason="%s"}`, reason)).Inc()
+				continue
+			}
+		}
+
+		if conditions := cfg.txConditions.Lookup(transaction.Hash()); conditions != nil {
+			if err := conditions.Check(header, state.NewPlainStateReader(simulationTx)); err != nil {
+				log.Debug("Conditional transaction excluded", "hash", transaction.Hash(), "err", err)
+				continue
+			}
+		}
+
 		// Check if tx nonce is too low
 		txs = append(txs, transaction)
 		txs[len(txs)-1].SetSender(sender)
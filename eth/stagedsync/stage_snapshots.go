@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -25,6 +26,7 @@ import (
 	"github.com/ledgerwatch/log/v3"
 	"golang.org/x/sync/semaphore"
 
+	"github.com/ledgerwatch/erigon/common/budget"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/parlia"
 	"github.com/ledgerwatch/erigon/core/rawdb"
@@ -32,6 +34,7 @@ import (
 	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/shards"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snapcfg"
 )
@@ -46,6 +49,7 @@ type SnapshotsCfg struct {
 	snapshotDownloader proto_downloader.DownloaderClient
 	blockReader        services.FullBlockReader
 	dbEventNotifier    snapshotsync.DBEventNotifier
+	snapshotsDownload  *shards.SnapshotsDownload
 	engine             consensus.Engine
 
 	historyV3 bool
@@ -61,6 +65,7 @@ func StageSnapshotsCfg(
 	snapshotDownloader proto_downloader.DownloaderClient,
 	blockReader services.FullBlockReader,
 	dbEventNotifier snapshotsync.DBEventNotifier,
+	snapshotsDownload *shards.SnapshotsDownload,
 	engine consensus.Engine,
 	historyV3 bool,
 	agg *state.AggregatorV3,
@@ -74,6 +79,7 @@ func StageSnapshotsCfg(
 		snapshotDownloader: snapshotDownloader,
 		blockReader:        blockReader,
 		dbEventNotifier:    dbEventNotifier,
+		snapshotsDownload:  snapshotsDownload,
 		historyV3:          historyV3,
 		agg:                agg,
 		engine:             engine,
@@ -150,8 +156,15 @@ func DownloadAndIndexSnapshotsIfNeed(s *StageState, ctx context.Context, tx kv.R
 			// wait for Downloader service to download all expected snapshots
 			if cfg.snapshots.IndicesMax() < cfg.snapshots.SegmentsMax() {
 				chainID, _ := uint256.FromBig(cfg.chainConfig.ChainID)
-				sem := semaphore.NewWeighted(int64(estimate.IndexSnapshot.Workers()))
-				if err := snapshotsync.BuildMissedIndices(s.LogPrefix(), ctx, cfg.dirs, *chainID, sem); err != nil {
+				workers := estimate.IndexSnapshot.Workers()
+				release, err := budget.Default.Acquire(ctx, "build missed indices", budget.High, datasize.ByteSize(estimate.IndexSnapshot)*datasize.ByteSize(workers))
+				if err != nil {
+					return err
+				}
+				sem := semaphore.NewWeighted(int64(workers))
+				err = snapshotsync.BuildMissedIndices(s.LogPrefix(), ctx, cfg.dirs, *chainID, sem)
+				release()
+				if err != nil {
 					return fmt.Errorf("BuildMissedIndices: %w", err)
 				}
 			}
@@ -166,8 +179,15 @@ func DownloadAndIndexSnapshotsIfNeed(s *StageState, ctx context.Context, tx kv.R
 	}
 
 	if cfg.historyV3 {
-		sem := semaphore.NewWeighted(int64(estimate.IndexSnapshot.Workers()))
-		if err := cfg.agg.BuildMissedIndices(ctx, sem); err != nil {
+		workers := estimate.IndexSnapshot.Workers()
+		release, err := budget.Default.Acquire(ctx, "build missed indices of history", budget.High, datasize.ByteSize(estimate.IndexSnapshot)*datasize.ByteSize(workers))
+		if err != nil {
+			return err
+		}
+		sem := semaphore.NewWeighted(int64(workers))
+		err = cfg.agg.BuildMissedIndices(ctx, sem)
+		release()
+		if err != nil {
 			return err
 		}
 		if cfg.dbEventNotifier != nil {
@@ -366,9 +386,16 @@ func WaitForDownloader(s *StageState, ctx context.Context, cfg SnapshotsCfg, tx
 		}
 	}
 	existingFilesMap := map[string]struct{}{}
+	var bodiesFrom uint64 // bodies and transactions of blocks below were pruned by --snap.history.window
 	for _, existingFile := range existingFiles {
 		_, fname := filepath.Split(existingFile.Path)
 		existingFilesMap[fname] = struct{}{}
+		if existingFile.T == snaptype.Bodies && (bodiesFrom == 0 || existingFile.From < bodiesFrom) {
+			bodiesFrom = existingFile.From
+		}
+	}
+	if cfg.snapshots.Cfg().HistoryWindow == 0 {
+		bodiesFrom = 0
 	}
 	if len(missingSnapshots) > 0 {
 		log.Warn(fmt.Sprintf("[%s] downloading missing snapshots", s.LogPrefix()))
@@ -380,9 +407,13 @@ func WaitForDownloader(s *StageState, ctx context.Context, cfg SnapshotsCfg, tx
 	// build all download requests
 	// builds preverified snapshots request
 	for _, p := range preverifiedBlockSnapshots {
-		if _, exists := existingFilesMap[p.Name]; !exists { // Not to download existing files "behind the scenes"
-			downloadRequest = append(downloadRequest, snapshotsync.NewDownloadRequest(nil, p.Name, p.Hash))
+		if _, exists := existingFilesMap[p.Name]; exists { // Not to download existing files "behind the scenes"
+			continue
+		}
+		if f, err := snaptype.ParseFileName("", p.Name); err == nil && f.T != snaptype.Headers && f.To <= bodiesFrom {
+			continue // Not to download pruned files again
 		}
+		downloadRequest = append(downloadRequest, snapshotsync.NewDownloadRequest(nil, p.Name, p.Hash))
 	}
 	if cfg.historyV3 {
 		preverifiedHistorySnapshots := snapcfg.KnownCfg(cfg.chainConfig.ChainName, snInDB, snHistInDB).PreverifiedHistory
@@ -417,8 +448,11 @@ func WaitForDownloader(s *StageState, ctx context.Context, cfg SnapshotsCfg, tx
 
 	// Check once without delay, for faster erigon re-start
 	stats, err := cfg.snapshotDownloader.Stats(ctx, &proto_downloader.StatsRequest{})
-	if err == nil && stats.Completed {
-		goto Finish
+	if err == nil {
+		cfg.snapshotsDownload.Update(stats)
+		if stats.Completed {
+			goto Finish
+		}
 	}
 
 	// Print download progress until all segments are available
@@ -428,9 +462,13 @@ Loop:
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-logEvery.C:
-			if stats, err := cfg.snapshotDownloader.Stats(ctx, &proto_downloader.StatsRequest{}); err != nil {
+			stats, err := cfg.snapshotDownloader.Stats(ctx, &proto_downloader.StatsRequest{})
+			if err != nil {
 				log.Warn("Error while waiting for snapshots progress", "err", err)
-			} else if stats.Completed {
+				continue
+			}
+			cfg.snapshotsDownload.Update(stats)
+			if stats.Completed {
 				if !cfg.snapshots.Cfg().Verify { // will verify after loop
 					if _, err := cfg.snapshotDownloader.Verify(ctx, &proto_downloader.VerifyRequest{}); err != nil {
 						return err
@@ -438,27 +476,26 @@ Loop:
 				}
 				log.Info(fmt.Sprintf("[%s] download finished", s.LogPrefix()), "time", time.Since(downloadStartTime).String())
 				break Loop
-			} else {
-				if stats.MetadataReady < stats.FilesTotal {
-					log.Info(fmt.Sprintf("[%s] Waiting for torrents metadata: %d/%d", s.LogPrefix(), stats.MetadataReady, stats.FilesTotal))
-					continue
-				}
-				dbg.ReadMemStats(&m)
-				downloadTimeLeft := calculateTime(stats.BytesTotal-stats.BytesCompleted, stats.DownloadRate)
-				log.Info(fmt.Sprintf("[%s] download", s.LogPrefix()),
-					"progress", fmt.Sprintf("%.2f%% %s/%s", stats.Progress, libcommon.ByteCount(stats.BytesCompleted), libcommon.ByteCount(stats.BytesTotal)),
-					"download-time-left", downloadTimeLeft,
-					"total-download-time", time.Since(downloadStartTime).Round(time.Second).String(),
-					"download", libcommon.ByteCount(stats.DownloadRate)+"/s",
-					"upload", libcommon.ByteCount(stats.UploadRate)+"/s",
-				)
-				log.Info(fmt.Sprintf("[%s] download", s.LogPrefix()),
-					"peers", stats.PeersUnique,
-					"connections", stats.ConnectionsTotal,
-					"files", stats.FilesTotal,
-					"alloc", libcommon.ByteCount(m.Alloc), "sys", libcommon.ByteCount(m.Sys),
-				)
 			}
+			if stats.MetadataReady < stats.FilesTotal {
+				log.Info(fmt.Sprintf("[%s] Waiting for torrents metadata: %d/%d", s.LogPrefix(), stats.MetadataReady, stats.FilesTotal))
+				continue
+			}
+			dbg.ReadMemStats(&m)
+			downloadTimeLeft := calculateTime(stats.BytesTotal-stats.BytesCompleted, stats.DownloadRate)
+			log.Info(fmt.Sprintf("[%s] download", s.LogPrefix()),
+				"progress", fmt.Sprintf("%.2f%% %s/%s", stats.Progress, libcommon.ByteCount(stats.BytesCompleted), libcommon.ByteCount(stats.BytesTotal)),
+				"download-time-left", downloadTimeLeft,
+				"total-download-time", time.Since(downloadStartTime).Round(time.Second).String(),
+				"download", libcommon.ByteCount(stats.DownloadRate)+"/s",
+				"upload", libcommon.ByteCount(stats.UploadRate)+"/s",
+			)
+			log.Info(fmt.Sprintf("[%s] download", s.LogPrefix()),
+				"peers", stats.PeersUnique,
+				"connections", stats.ConnectionsTotal,
+				"files", stats.FilesTotal,
+				"alloc", libcommon.ByteCount(m.Alloc), "sys", libcommon.ByteCount(m.Sys),
+			)
 		}
 	}
 
@@ -530,6 +567,9 @@ func SnapshotsPrune(s *PruneState, cfg SnapshotsCfg, ctx context.Context, tx kv.
 		if err := retireBlocksInSingleBackgroundThread(s, cfg.blockRetire, cfg.agg, ctx, tx); err != nil {
 			return fmt.Errorf("retireBlocksInSingleBackgroundThread: %w", err)
 		}
+		if err := pruneBodiesSnapshots(s, cfg, tx); err != nil {
+			return fmt.Errorf("pruneBodiesSnapshots: %w", err)
+		}
 	}
 
 	if !useExternalTx {
@@ -560,3 +600,36 @@ func retireBlocksInSingleBackgroundThread(s *PruneState, blockRetire *snapshotsy
 
 	return nil
 }
+
+// pruneBodiesSnapshots - removes the snapshots of bodies and transactions of blocks older than --snap.history.window,
+// once they were processed by all stages
+func pruneBodiesSnapshots(s *PruneState, cfg SnapshotsCfg, tx kv.RwTx) error {
+	sn := cfg.blockRetire.Snapshots()
+	window := sn.Cfg().HistoryWindow
+	// retiring reopens snapshots in background
+	if window == 0 || cfg.blockRetire.Working() {
+		return nil
+	}
+	finished, err := stages.GetStageProgress(tx, stages.Finish)
+	if err != nil {
+		return err
+	}
+	if finished <= window {
+		return nil
+	}
+	bodiesFrom := sn.BodiesFrom()
+	if err := sn.PruneBodies(finished - window); err != nil {
+		return err
+	}
+	if sn.BodiesFrom() == bodiesFrom {
+		return nil
+	}
+	log.Info(fmt.Sprintf("[%s] Pruned snapshots of bodies", s.LogPrefix()), "bodies_from", sn.BodiesFrom())
+	if err := rawdb.WriteSnapshots(tx, sn.Files(), cfg.agg.Files()); err != nil {
+		return err
+	}
+	if cfg.dbEventNotifier != nil {
+		cfg.dbEventNotifier.OnNewSnapshot()
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package backup copies a database table by table while it is in use: all tables are read in one read
+// transaction, which MDBX keeps consistent while the writer goes on, so the copy is the database as of the
+// start of the backup.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/time/rate"
+)
+
+type Cfg struct {
+	// BytesPerSecond caps the read rate, 0 is unlimited. The pages the writer frees can't be reused before the
+	// backup ends, so a slower backup spares the sync IO but grows the database more.
+	BytesPerSecond datasize.ByteSize
+	// CommitEvery bounds the size of the write transactions of the copy
+	CommitEvery datasize.ByteSize
+}
+
+var DefaultCfg = Cfg{
+	BytesPerSecond: 64 * datasize.MB,
+	CommitEvery:    256 * datasize.MB,
+}
+
+// Copy copies all tables of src into dst, which should be empty
+func Copy(ctx context.Context, logPrefix string, src kv.RoDB, dst kv.RwDB, cfg Cfg) error {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if cfg.BytesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.BytesPerSecond), int(cfg.BytesPerSecond))
+	}
+	tables := make([]string, 0, len(src.AllBuckets()))
+	for name, tableCfg := range src.AllBuckets() {
+		if !tableCfg.IsDeprecated {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	var copied datasize.ByteSize
+	return src.View(ctx, func(srcTx kv.Tx) error {
+		for _, table := range tables {
+			if err := copyTable(ctx, logPrefix, srcTx, dst, table, limiter, cfg.CommitEvery, &copied, logEvery); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		log.Info(fmt.Sprintf("[%s] backup done", logPrefix), "copied", copied.HR())
+		return nil
+	})
+}
+
+func copyTable(ctx context.Context, logPrefix string, srcTx kv.Tx, dst kv.RwDB, table string, limiter *rate.Limiter, commitEvery datasize.ByteSize, copied *datasize.ByteSize, logEvery *time.Ticker) error {
+	c, err := srcTx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	k, v, err := c.First()
+	if err != nil {
+		return err
+	}
+	// The entries come sorted, so they are appended: one write transaction per commitEvery bytes
+	for k != nil {
+		if err := dst.Update(ctx, func(dstTx kv.RwTx) error {
+			dstC, err := dstTx.RwCursor(table)
+			if err != nil {
+				return err
+			}
+			defer dstC.Close()
+			dupSortC, dupSort := dstC.(kv.RwCursorDupSort)
+			var batch datasize.ByteSize
+			for ; k != nil && (commitEvery == 0 || batch < commitEvery); k, v, err = c.Next() {
+				if err != nil {
+					return err
+				}
+				size := len(k) + len(v)
+				if burst := limiter.Burst(); size > burst && limiter.Limit() != rate.Inf {
+					size = burst
+				}
+				if err := limiter.WaitN(ctx, size); err != nil {
+					return err
+				}
+				if dupSort {
+					err = dupSortC.AppendDup(k, v)
+				} else {
+					err = dstC.Append(k, v)
+				}
+				if err != nil {
+					return err
+				}
+				batch += datasize.ByteSize(len(k) + len(v))
+
+				select {
+				case <-logEvery.C:
+					log.Info(fmt.Sprintf("[%s] backup", logPrefix), "table", table, "key", fmt.Sprintf("%x", k), "copied", (*copied + batch).HR())
+				default:
+				}
+			}
+			*copied += batch
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ToDir copies src into a new MDBX database in dir, which must not exist or be empty
+func ToDir(ctx context.Context, logPrefix string, src kv.RoDB, dir string, cfg Cfg) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("backup directory is not empty: %s", dir)
+	}
+	dst, err := mdbx.NewMDBX(log.New()).Path(dir).Open()
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	log.Info(fmt.Sprintf("[%s] backup started", logPrefix), "to", dir, "rate", cfg.BytesPerSecond.HR())
+	return Copy(ctx, logPrefix, src, dst, cfg)
+}
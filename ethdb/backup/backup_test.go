@@ -0,0 +1,61 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	src := memdb.NewTestDB(t)
+	require.NoError(t, src.Update(ctx, func(tx kv.RwTx) error {
+		for i := 0; i < 100; i++ {
+			if err := tx.Put(kv.Headers, []byte(fmt.Sprintf("%03d", i)), []byte("header")); err != nil {
+				return err
+			}
+			// dupsort
+			for j := 0; j < 3; j++ {
+				if err := tx.Put(kv.PlainState, []byte(fmt.Sprintf("%03d", i)), []byte(fmt.Sprintf("v%d", j))); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+
+	dst := memdb.NewTestDB(t)
+	// several write transactions per table
+	require.NoError(t, Copy(ctx, "test", src, dst, Cfg{BytesPerSecond: datasize.MB, CommitEvery: 100 * datasize.B}))
+
+	for _, table := range []string{kv.Headers, kv.PlainState} {
+		var want, got [][2][]byte
+		require.NoError(t, src.View(ctx, func(tx kv.Tx) error {
+			return tx.ForEach(table, nil, func(k, v []byte) error {
+				want = append(want, [2][]byte{k, v})
+				return nil
+			})
+		}))
+		require.NoError(t, dst.View(ctx, func(tx kv.Tx) error {
+			return tx.ForEach(table, nil, func(k, v []byte) error {
+				got = append(got, [2][]byte{k, v})
+				return nil
+			})
+		}))
+		require.Equal(t, want, got, table)
+	}
+}
+
+func TestToDirNotEmpty(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mdbx.dat"), nil, 0644))
+	err := ToDir(context.Background(), "test", memdb.NewTestDB(t), dir, DefaultCfg)
+	require.ErrorContains(t, err, "not empty")
+}
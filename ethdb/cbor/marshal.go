@@ -1,7 +1,9 @@
 package cbor
 
 import (
+	"fmt"
 	"io"
+	"math"
 )
 
 func Marshal(dst io.Writer, v interface{}) error {
@@ -31,3 +33,36 @@ func MustUnmarshal(dst interface{}, data io.Reader) {
 		panic(err)
 	}
 }
+
+// ArrayLen returns the number of elements of the array encoded in data, read from its header without decoding the
+// elements. A nil slice is encoded as null, its length is 0.
+func ArrayLen(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if data[0] == 0xf6 { // null
+		return 0, nil
+	}
+	if major := data[0] >> 5; major != 4 {
+		return 0, fmt.Errorf("cbor: major type %d, expected an array", major)
+	}
+	info := data[0] & 0x1f
+	if info < 24 {
+		return int(info), nil
+	}
+	if info > 27 {
+		return 0, fmt.Errorf("cbor: array of indefinite or invalid length %d", info)
+	}
+	size := 1 << (info - 24)
+	if len(data) < 1+size {
+		return 0, io.ErrUnexpectedEOF
+	}
+	var n uint64
+	for _, b := range data[1 : 1+size] {
+		n = n<<8 | uint64(b)
+	}
+	if n > math.MaxInt32 {
+		return 0, fmt.Errorf("cbor: array of %d elements", n)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,30 @@
+package cbor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayLen(t *testing.T) {
+	var nilSlice []uint64
+	for _, v := range []interface{}{nilSlice, []uint64{}, []uint64{1, 2}, make([]uint64, 23), make([]uint64, 24), make([]uint64, 300), make([]uint64, 70000)} {
+		var buf bytes.Buffer
+		MustMarshal(&buf, v)
+		n, err := ArrayLen(buf.Bytes())
+		require.NoError(t, err)
+		var decoded []uint64
+		MustUnmarshal(&decoded, &buf)
+		require.Equal(t, len(decoded), n)
+	}
+
+	_, err := ArrayLen(nil)
+	require.Error(t, err)
+	_, err = ArrayLen([]byte{0x99, 0x01}) // 2 bytes length, truncated
+	require.Error(t, err)
+	var buf bytes.Buffer
+	MustMarshal(&buf, uint64(3))
+	_, err = ArrayLen(buf.Bytes())
+	require.Error(t, err)
+}
@@ -3,103 +3,151 @@ package cbor
 import (
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/ugorji/go/codec"
 )
 
 var logger = log.New("package", "cbor")
 
+// DefaultPoolSize is the number of idle decoders (and encoders) kept for reuse.
+const DefaultPoolSize = 128
+
+// pool keeps idle codecs: in a bounded channel, or in a sync.Pool when its size is 0.
+type pool[T any] struct {
+	name string
+	ch   atomic.Value // chan T, nil for the sync.Pool
+	sync sync.Pool
+
+	hits, misses, dropped *metrics.Counter
+}
+
+func newPool[T any](name string, size int) *pool[T] {
+	p := &pool[T]{
+		name:    name,
+		hits:    metrics.GetOrCreateCounter(fmt.Sprintf(`cbor_pool_hits{codec="%s"}`, name)),
+		misses:  metrics.GetOrCreateCounter(fmt.Sprintf(`cbor_pool_misses{codec="%s"}`, name)),
+		dropped: metrics.GetOrCreateCounter(fmt.Sprintf(`cbor_pool_dropped{codec="%s"}`, name)),
+	}
+	p.resize(size)
+	metrics.GetOrCreateGauge(fmt.Sprintf(`cbor_pool_idle{codec="%s"}`, name), func() float64 {
+		return float64(len(p.ch.Load().(chan T)))
+	})
+	return p
+}
+
+func (p *pool[T]) resize(size int) {
+	var ch chan T
+	if size > 0 {
+		ch = make(chan T, size)
+	}
+	p.ch.Store(ch)
+}
+
+func (p *pool[T]) get() (v T, ok bool) {
+	if ch := p.ch.Load().(chan T); ch != nil {
+		select {
+		case v = <-ch:
+			p.hits.Inc()
+			return v, true
+		default:
+		}
+	} else if pooled := p.sync.Get(); pooled != nil {
+		p.hits.Inc()
+		return pooled.(T), true
+	}
+	p.misses.Inc()
+	return v, false
+}
+
+func (p *pool[T]) put(v T) {
+	ch := p.ch.Load().(chan T)
+	if ch == nil {
+		p.sync.Put(v)
+		return
+	}
+	select {
+	case ch <- v:
+	default:
+		p.dropped.Inc()
+		logger.Trace("Allowing codec to be garbage collected, pool is full", "codec", p.name)
+	}
+}
+
 // Pool of decoders
-var decoderPool = make(chan *codec.Decoder, 128)
+var decoderPool = newPool[*codec.Decoder]("decoder", DefaultPoolSize)
+
+// Pool of encoders
+var encoderPool = newPool[*codec.Encoder]("encoder", DefaultPoolSize)
+
+// SetPoolSize changes the number of idle decoders and encoders kept for reuse, dropping the ones kept so far.
+// With size 0 they are kept in a sync.Pool instead: as many as the load needs, reclaimed by GC when idle.
+func SetPoolSize(size int) {
+	decoderPool.resize(size)
+	encoderPool.resize(size)
+}
+
+func newDecoderHandle() *codec.CborHandle {
+	var handle codec.CborHandle
+	handle.ReaderBufferSize = 64 * 1024
+	handle.ZeroCopy = true // if you need access to object outside of db transaction - please copy bytes before deserialization
+	return &handle
+}
 
 func Decoder(r io.Reader) *codec.Decoder {
-	var d *codec.Decoder
-	select {
-	case d = <-decoderPool:
+	d, ok := decoderPool.get()
+	if ok {
 		d.Reset(r)
-	default:
-		{
-			var handle codec.CborHandle
-			handle.ReaderBufferSize = 64 * 1024
-			handle.ZeroCopy = true // if you need access to object outside of db transaction - please copy bytes before deserialization
-			d = codec.NewDecoder(r, &handle)
-		}
+		return d
 	}
-	return d
+	return codec.NewDecoder(r, newDecoderHandle())
 }
 
 func DecoderBytes(r []byte) *codec.Decoder {
-	var d *codec.Decoder
-	select {
-	case d = <-decoderPool:
+	d, ok := decoderPool.get()
+	if ok {
 		d.ResetBytes(r)
-	default:
-		{
-			var handle codec.CborHandle
-			handle.ReaderBufferSize = 64 * 1024
-			handle.ZeroCopy = true // if you need access to object outside of db transaction - please copy bytes before deserialization
-			d = codec.NewDecoderBytes(r, &handle)
-		}
+		return d
 	}
-	return d
+	return codec.NewDecoderBytes(r, newDecoderHandle())
 }
 
 func returnDecoderToPool(d *codec.Decoder) {
-	select {
-	case decoderPool <- d:
-	default:
-		logger.Trace("Allowing decoder to be garbage collected, pool is full")
-	}
+	decoderPool.put(d)
 }
 
-// Pool of encoders
-var encoderPool = make(chan *codec.Encoder, 128)
+func newEncoderHandle() *codec.CborHandle {
+	var handle codec.CborHandle
+	handle.WriterBufferSize = 64 * 1024
+	handle.StructToArray = true
+	handle.OptimumSize = true
+	handle.StringToRaw = true
+	return &handle
+}
 
 func Encoder(w io.Writer) *codec.Encoder {
-	var e *codec.Encoder
-	select {
-	case e = <-encoderPool:
+	e, ok := encoderPool.get()
+	if ok {
 		e.Reset(w)
-	default:
-		{
-			var handle codec.CborHandle
-			handle.WriterBufferSize = 64 * 1024
-			handle.StructToArray = true
-			handle.OptimumSize = true
-			handle.StringToRaw = true
-
-			e = codec.NewEncoder(w, &handle)
-		}
+		return e
 	}
-	return e
+	return codec.NewEncoder(w, newEncoderHandle())
 }
 
 func EncoderBytes(w *[]byte) *codec.Encoder {
-	var e *codec.Encoder
-	select {
-	case e = <-encoderPool:
+	e, ok := encoderPool.get()
+	if ok {
 		e.ResetBytes(w)
-	default:
-		{
-			var handle codec.CborHandle
-			handle.WriterBufferSize = 64 * 1024
-			handle.StructToArray = true
-			handle.OptimumSize = true
-			handle.StringToRaw = true
-
-			e = codec.NewEncoderBytes(w, &handle)
-		}
+		return e
 	}
-	return e
+	return codec.NewEncoderBytes(w, newEncoderHandle())
 }
 
 func returnEncoderToPool(e *codec.Encoder) {
-	select {
-	case encoderPool <- e:
-	default:
-		logger.Trace("Allowing encoder to be garbage collected, pool is full")
-	}
+	encoderPool.put(e)
 }
 
 func Return(d interface{}) {
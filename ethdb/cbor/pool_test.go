@@ -0,0 +1,42 @@
+package cbor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestPool(t *testing.T) {
+	defer SetPoolSize(DefaultPoolSize)
+
+	p := newPool[*codec.Decoder]("test", 1)
+	_, ok := p.get()
+	require.False(t, ok)
+	d1, d2 := DecoderBytes(nil), DecoderBytes(nil)
+	p.put(d1)
+	p.put(d2) // dropped, the pool is full
+	require.Equal(t, uint64(1), p.dropped.Get())
+	got, ok := p.get()
+	require.True(t, ok)
+	require.Same(t, d1, got)
+
+	// unbounded
+	p.resize(0)
+	p.put(d1)
+	p.put(d2)
+	require.Equal(t, uint64(1), p.dropped.Get())
+
+	for _, size := range []int{0, 2} {
+		SetPoolSize(size)
+		var buf bytes.Buffer
+		for i := 0; i < 3; i++ {
+			buf.Reset()
+			require.NoError(t, Marshal(&buf, []uint64{1, 2, 3}))
+			var v []uint64
+			require.NoError(t, Unmarshal(&v, &buf))
+			require.Equal(t, []uint64{1, 2, 3}, v)
+		}
+	}
+}
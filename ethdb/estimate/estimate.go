@@ -0,0 +1,96 @@
+// Package estimate approximates the number of entries in a key range of a table without scanning it, so that
+// query planners can choose between scan strategies. It only needs the entry count of the table, which MDBX keeps in
+// its B-tree statistics and the remote KV protocol serves with Op_COUNT, and a few seeks: it works the same on a
+// local and on a remote kv.Tx.
+package estimate
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// exactBelow is the estimated size under which a range is counted exactly, a scan of that many entries being cheap.
+const exactBelow = 256
+
+// Range returns the approximate number of entries of table with from <= key < to, a nil to being the end of the
+// table. The position of a key in the table is interpolated from its value between the first and the last keys,
+// which is accurate for hashed keys and for keys starting with block numbers. Small ranges are counted exactly.
+func Range(tx kv.Tx, table string, from, to []byte) (uint64, error) {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	total, err := c.Count()
+	if err != nil || total == 0 {
+		return 0, err
+	}
+	first, _, err := c.First()
+	if err != nil {
+		return 0, err
+	}
+	last, _, err := c.Last()
+	if err != nil {
+		return 0, err
+	}
+	k, _, err := c.Seek(from)
+	if err != nil {
+		return 0, err
+	}
+	if k == nil || (to != nil && bytes.Compare(k, to) >= 0) {
+		return 0, nil
+	}
+
+	lo := position(first, last, k)
+	hi := 1.0
+	if to != nil {
+		hi = position(first, last, to)
+	}
+	estimate := uint64((hi - lo) * float64(total))
+	if estimate >= exactBelow {
+		if estimate > total {
+			estimate = total
+		}
+		return estimate, nil
+	}
+	var count uint64
+	for ; k != nil && (to == nil || bytes.Compare(k, to) < 0); k, _, err = c.Next() {
+		count++
+	}
+	return count, err
+}
+
+// position returns where key falls between first and last, from 0 to 1.
+func position(first, last, key []byte) float64 {
+	if bytes.Compare(key, first) <= 0 {
+		return 0
+	}
+	if bytes.Compare(key, last) > 0 {
+		return 1
+	}
+	prefix := commonPrefix(first, last)
+	f, l, k := significant(first, prefix), significant(last, prefix), significant(key, prefix)
+	if l <= f {
+		return 0
+	}
+	return float64(k-f) / float64(l-f)
+}
+
+func commonPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// significant returns the 8 bytes of key following prefix as a number, padded with zeros.
+func significant(key []byte, prefix int) uint64 {
+	var buf [8]byte
+	if prefix < len(key) {
+		copy(buf[:], key[prefix:])
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
@@ -0,0 +1,93 @@
+package estimate
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+func blockKey(n uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, n)
+	return k
+}
+
+func fill(t *testing.T, db kv.RwDB) {
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		for i := uint64(1000); i < 11000; i++ {
+			if err := tx.Put(kv.Headers, blockKey(i), []byte{1}); err != nil {
+				return err
+			}
+			if err := tx.Put(kv.HashedAccounts, crypto.Keccak256(blockKey(i)), []byte{1}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func testRange(t *testing.T, tx kv.Tx) {
+	estimate := func(table string, from, to []byte) uint64 {
+		n, err := Range(tx, table, from, to)
+		require.NoError(t, err)
+		return n
+	}
+	require.InDelta(t, 10000, estimate(kv.Headers, nil, nil), 10)
+	require.InDelta(t, 5000, estimate(kv.Headers, blockKey(6000), nil), 10)
+	require.InDelta(t, 2000, estimate(kv.Headers, blockKey(3000), blockKey(5000)), 10)
+	// small ranges are exact
+	require.Equal(t, uint64(100), estimate(kv.Headers, blockKey(3000), blockKey(3100)))
+	require.Equal(t, uint64(0), estimate(kv.Headers, blockKey(20000), nil))
+	require.Equal(t, uint64(0), estimate(kv.Headers, blockKey(0), blockKey(1000)))
+	require.Equal(t, uint64(0), estimate(kv.BlockBody, nil, nil))
+
+	// hashed keys are spread uniformly
+	require.InDelta(t, 5000, estimate(kv.HashedAccounts, []byte{0x80}, nil), 500)
+	require.InDelta(t, 2500, estimate(kv.HashedAccounts, []byte{0x40}, []byte{0x80}), 500)
+}
+
+func TestRange(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	fill(t, db)
+	tx, err := db.BeginRo(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	testRange(t, tx)
+}
+
+func TestRangeRemote(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	fill(t, db)
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	remote.RegisterKVServer(server, remotedbserver.NewKvServer(context.Background(), db, nil, nil))
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Stop()
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	remoteDb, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), log.New(), remote.NewKVClient(conn)).Open()
+	require.NoError(t, err)
+	defer remoteDb.Close()
+	tx, err := remoteDb.BeginRo(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	testRange(t, tx)
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+
+package geometry
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Inspect returns the filesystem of dir.
+func Inspect(dir string) (Disk, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return Disk{}, err
+	}
+	return Disk{
+		FS:        unix.ByteSliceToString(st.Fstypename[:]),
+		BlockSize: uint64(st.Bsize),
+		Free:      st.Bavail * uint64(st.Bsize),
+		Total:     st.Blocks * uint64(st.Bsize),
+	}, nil
+}
@@ -0,0 +1,37 @@
+//go:build linux
+
+package geometry
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// fsNames maps the magic numbers of statfs(2) to filesystem names.
+var fsNames = map[uint32]string{
+	0xEF53:     "ext4", // also ext2 and ext3
+	0x58465342: "xfs",
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0xF2F52010: "f2fs",
+	0x01021994: "tmpfs",
+	0x858458F6: "ramfs",
+	0x794C7630: "overlayfs",
+	0x6969:     "nfs",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x65735546: "fuse",
+}
+
+// Inspect returns the filesystem of dir.
+func Inspect(dir string) (Disk, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return Disk{}, err
+	}
+	return Disk{
+		FS:        fsNames[uint32(st.Type)],
+		BlockSize: uint64(st.Bsize),
+		Free:      st.Bavail * uint64(st.Bsize),
+		Total:     st.Blocks * uint64(st.Bsize),
+	}, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package geometry
+
+import "errors"
+
+// Inspect is not implemented on this platform, Tune falls back to the default geometry.
+func Inspect(dir string) (Disk, error) {
+	return Disk{}, errors.New("not implemented on this platform")
+}
@@ -0,0 +1,134 @@
+// Package geometry picks the MDBX geometry of a database from the disk it lives on, instead of one geometry for
+// all deployments: small disks get a small growth step, copy-on-write filesystems get larger pages.
+package geometry
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	MinGrowthStep = 64 * datasize.MB
+	MaxGrowthStep = 2 * datasize.GB // the default of erigon-lib
+	// growthStepsOfFreeSpace is the number of growth steps the free space of the disk should hold at least
+	growthStepsOfFreeSpace = 64
+	// cowPageSize is the page size used on copy-on-write filesystems, which rewrite whole records on every update
+	cowPageSize = 16 * datasize.KB
+	maxPageSize = 64 * datasize.KB
+)
+
+// Disk describes the filesystem of a database directory.
+type Disk struct {
+	FS        string // name of the filesystem type, empty when unknown
+	BlockSize uint64 // preferred I/O size of the filesystem
+	Free      uint64 // bytes available to unprivileged users
+	Total     uint64
+}
+
+// Geometry is the part of the MDBX geometry which is tuned.
+type Geometry struct {
+	PageSize   datasize.ByteSize // only used when the database is created
+	GrowthStep datasize.ByteSize
+}
+
+// Overrides are the values set by the user, zero values are tuned.
+type Overrides struct {
+	PageSize   datasize.ByteSize
+	GrowthStep datasize.ByteSize
+}
+
+// Tune returns the geometry for a database in dir, which may not exist yet. When the disk can't be inspected, it
+// returns the defaults of erigon-lib.
+func Tune(dir string, overrides Overrides) Geometry {
+	disk, err := Inspect(existingParent(dir))
+	if err != nil {
+		log.Debug("[db] could not inspect disk, using the default geometry", "dir", dir, "err", err)
+		disk = Disk{}
+	}
+	switch disk.FS {
+	case "nfs", "cifs", "smb2", "smbfs", "fuse":
+		log.Warn("[db] MDBX needs a local filesystem, the database may get corrupted on a network filesystem", "dir", dir, "fs", disk.FS)
+	}
+	g := Choose(disk, uint64(os.Getpagesize()))
+	if overrides.PageSize != 0 {
+		g.PageSize = overrides.PageSize
+	}
+	if overrides.GrowthStep != 0 {
+		g.GrowthStep = overrides.GrowthStep
+	}
+	log.Info("[db] geometry", "dir", dir, "fs", disk.FS, "free", datasize.ByteSize(disk.Free).HR(), "pagesize", g.PageSize.HR(), "growthStep", g.GrowthStep.HR())
+	return g
+}
+
+// Choose returns the geometry for disk, on a system with pages of osPageSize. A zero Disk gives the defaults.
+func Choose(disk Disk, osPageSize uint64) Geometry {
+	g := Geometry{PageSize: datasize.ByteSize(kv.DefaultPageSize()), GrowthStep: MaxGrowthStep}
+	if osPageSize != 0 {
+		g.PageSize = datasize.ByteSize(osPageSize)
+	}
+
+	// pages smaller than the blocks of the filesystem turn every page write into a read-modify-write
+	if bs := datasize.ByteSize(disk.BlockSize); isPowerOfTwo(disk.BlockSize) && bs > g.PageSize {
+		g.PageSize = bs
+	}
+	switch disk.FS {
+	case "zfs", "btrfs":
+		if g.PageSize < cowPageSize {
+			g.PageSize = cowPageSize
+		}
+	case "tmpfs", "ramfs":
+		// memory-backed: pages of the OS, and growing often is cheap
+		if osPageSize != 0 {
+			g.PageSize = datasize.ByteSize(osPageSize)
+		}
+		g.GrowthStep = MinGrowthStep
+	}
+	if g.PageSize > maxPageSize {
+		g.PageSize = maxPageSize
+	}
+
+	// keep room for a good number of steps on small disks, a step reserves its space on the disk at once
+	if disk.Free != 0 {
+		step := floorPowerOfTwo(disk.Free / growthStepsOfFreeSpace)
+		if step < uint64(g.GrowthStep) {
+			g.GrowthStep = datasize.ByteSize(step)
+		}
+	}
+	if g.GrowthStep < MinGrowthStep {
+		g.GrowthStep = MinGrowthStep
+	}
+	return g
+}
+
+// existingParent returns dir, or its closest existing parent when the database is not created yet.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func isPowerOfTwo(n uint64) bool {
+	return n != 0 && n&(n-1) == 0
+}
+
+func floorPowerOfTwo(n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+	p := uint64(1)
+	for p <= n/2 {
+		p <<= 1
+	}
+	return p
+}
@@ -0,0 +1,40 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		name string
+		disk Disk
+		want Geometry
+	}{
+		{"unknown disk", Disk{}, Geometry{PageSize: 4 * datasize.KB, GrowthStep: MaxGrowthStep}},
+		{"large ext4", Disk{FS: "ext4", BlockSize: 4096, Free: 2 * uint64(datasize.TB)}, Geometry{PageSize: 4 * datasize.KB, GrowthStep: MaxGrowthStep}},
+		{"small ext4", Disk{FS: "ext4", BlockSize: 4096, Free: 20 * uint64(datasize.GB)}, Geometry{PageSize: 4 * datasize.KB, GrowthStep: 256 * datasize.MB}},
+		{"tiny disk", Disk{FS: "xfs", BlockSize: 4096, Free: uint64(datasize.GB)}, Geometry{PageSize: 4 * datasize.KB, GrowthStep: MinGrowthStep}},
+		{"zfs", Disk{FS: "zfs", BlockSize: 4096, Free: 2 * uint64(datasize.TB)}, Geometry{PageSize: 16 * datasize.KB, GrowthStep: MaxGrowthStep}},
+		{"large blocks", Disk{FS: "xfs", BlockSize: 32 * 1024, Free: 2 * uint64(datasize.TB)}, Geometry{PageSize: 32 * datasize.KB, GrowthStep: MaxGrowthStep}},
+		{"huge blocks", Disk{FS: "zfs", BlockSize: 1024 * 1024, Free: 2 * uint64(datasize.TB)}, Geometry{PageSize: 64 * datasize.KB, GrowthStep: MaxGrowthStep}},
+		{"tmpfs", Disk{FS: "tmpfs", BlockSize: 4096, Free: 2 * uint64(datasize.TB)}, Geometry{PageSize: 4 * datasize.KB, GrowthStep: MinGrowthStep}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Choose(tt.disk, 4096))
+		})
+	}
+}
+
+func TestTuneOverrides(t *testing.T) {
+	dir := t.TempDir()
+	g := Tune(dir+"/chaindata", Overrides{PageSize: 8 * datasize.KB, GrowthStep: 128 * datasize.MB})
+	require.Equal(t, Geometry{PageSize: 8 * datasize.KB, GrowthStep: 128 * datasize.MB}, g)
+
+	g = Tune(dir+"/chaindata", Overrides{})
+	require.NotZero(t, g.PageSize)
+	require.GreaterOrEqual(t, g.GrowthStep, MinGrowthStep)
+}
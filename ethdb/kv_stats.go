@@ -0,0 +1,102 @@
+package ethdb
+
+import (
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/torquem-ch/mdbx-go/mdbx"
+)
+
+// referenceWriteAmplification is the write amplification of a table for which
+// the configured batch sizes were tuned (plain state of a few hundred GB is ~4 levels deep).
+const referenceWriteAmplification = 4
+
+// minBatchDivisor bounds how much AdaptiveBatchSize may shrink a batch.
+const minBatchDivisor = 4
+
+// TableStats is a cheap snapshot of the B-tree shape of a table.
+// It is read from the table header, so it costs O(1) regardless of the table size.
+type TableStats struct {
+	Entries       uint64
+	Depth         uint64
+	BranchPages   uint64
+	LeafPages     uint64
+	OverflowPages uint64
+	PageSize      uint64
+}
+
+type bucketStater interface {
+	BucketStat(name string) (*mdbx.Stat, error)
+}
+
+// ReadTableStats returns the statistics of table. The second result is false
+// when the transaction does not expose page statistics (e.g. remote transactions).
+func ReadTableStats(tx kv.Tx, table string) (TableStats, bool, error) {
+	stater, ok := tx.(bucketStater)
+	if !ok {
+		return TableStats{}, false, nil
+	}
+	st, err := stater.BucketStat(table)
+	if err != nil {
+		return TableStats{}, false, err
+	}
+	return TableStats{
+		Entries:       st.Entries,
+		Depth:         uint64(st.Depth),
+		BranchPages:   st.BranchPages,
+		LeafPages:     st.LeafPages,
+		OverflowPages: st.OverflowPages,
+		PageSize:      uint64(st.PSize),
+	}, true, nil
+}
+
+// Size returns the amount of bytes used by the table pages.
+func (s TableStats) Size() uint64 {
+	return (s.BranchPages + s.LeafPages + s.OverflowPages) * s.PageSize
+}
+
+// BytesPerEntry returns the average amount of page space used by a single entry.
+func (s TableStats) BytesPerEntry() float64 {
+	if s.Entries == 0 {
+		return 0
+	}
+	return float64(s.Size()) / float64(s.Entries)
+}
+
+// WriteAmplification estimates how many pages get dirtied by a single random write:
+// MDBX copies the whole root-to-leaf path on update, plus the overflow pages of large values.
+func (s TableStats) WriteAmplification() float64 {
+	if s.Entries == 0 {
+		return 1
+	}
+	wa := float64(s.Depth) + float64(s.OverflowPages)/float64(s.Entries)
+	if wa < 1 {
+		return 1
+	}
+	return wa
+}
+
+// AdaptiveBatchSize scales base down for tables whose writes dirty more pages than the
+// reference table, so loaders into deep or large-valued tables (history, code) commit
+// before they cause dirty-page spikes. When several tables are written by the same batch,
+// the most write-amplified one decides. The result is never lower than base/4 and never
+// higher than base. Tables without available statistics do not affect the result.
+func AdaptiveBatchSize(tx kv.Tx, base datasize.ByteSize, tables ...string) datasize.ByteSize {
+	adapted := base
+	for _, table := range tables {
+		stats, ok, err := ReadTableStats(tx, table)
+		if err != nil || !ok {
+			continue
+		}
+		wa := stats.WriteAmplification()
+		if wa <= referenceWriteAmplification {
+			continue
+		}
+		if size := datasize.ByteSize(float64(base) * referenceWriteAmplification / wa); size < adapted {
+			adapted = size
+		}
+	}
+	if adapted < base/minBatchDivisor {
+		return base / minBatchDivisor
+	}
+	return adapted
+}
@@ -0,0 +1,45 @@
+package ethdb
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAmplification(t *testing.T) {
+	require.Equal(t, float64(1), TableStats{}.WriteAmplification())
+	require.Equal(t, float64(3), TableStats{Entries: 100, Depth: 3}.WriteAmplification())
+	require.Equal(t, float64(4), TableStats{Entries: 100, Depth: 2, OverflowPages: 200}.WriteAmplification())
+}
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	base := 512 * datasize.MB
+	// Empty and shallow tables keep the configured size.
+	require.Equal(t, base, AdaptiveBatchSize(tx, base, kv.PlainState, kv.Code))
+
+	// Values bigger than a page go to overflow pages, every write touches a lot of pages.
+	big := make([]byte, 64*1024)
+	for i := uint64(0); i < 16; i++ {
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, i)
+		require.NoError(t, tx.Put(kv.Code, k, big))
+	}
+	stats, ok, err := ReadTableStats(tx, kv.Code)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(16), stats.Entries)
+	require.Greater(t, stats.WriteAmplification(), float64(referenceWriteAmplification))
+
+	adapted := AdaptiveBatchSize(tx, base, kv.PlainState, kv.Code)
+	require.Equal(t, base/minBatchDivisor, adapted)
+}
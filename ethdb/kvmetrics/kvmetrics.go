@@ -0,0 +1,343 @@
+// Package kvmetrics counts, per table, the reads and writes going through a kv.RwDB - gets, puts, deletes, cursors
+// and bytes - exported as db_table_*{table="<table>"} metrics, to see which stage or RPC method dominates the I/O.
+// Bytes read and moves through cursors aren't counted: only the cursors opened.
+package kvmetrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/torquem-ch/mdbx-go/mdbx"
+)
+
+type tableMetrics struct {
+	gets, getBytes *metrics.Counter
+	puts, putBytes *metrics.Counter
+	deletes        *metrics.Counter
+	cursors        *metrics.Counter
+}
+
+func newTableMetrics(table string) *tableMetrics {
+	counter := func(name string) *metrics.Counter {
+		return metrics.GetOrCreateCounter(fmt.Sprintf(`db_table_%s{table="%s"}`, name, table))
+	}
+	return &tableMetrics{
+		gets:     counter("gets"),
+		getBytes: counter("get_bytes"),
+		puts:     counter("puts"),
+		putBytes: counter("put_bytes"),
+		deletes:  counter("deletes"),
+		cursors:  counter("cursors"),
+	}
+}
+
+func (m *tableMetrics) read(k, v []byte) {
+	m.gets.Inc()
+	m.getBytes.Add(len(k) + len(v))
+}
+
+func (m *tableMetrics) write(k, v []byte) {
+	m.puts.Inc()
+	m.putBytes.Add(len(k) + len(v))
+}
+
+// DB is a kv.RwDB whose transactions count their operations
+type DB struct {
+	kv.RwDB
+	tables map[string]*tableMetrics // of AllBuckets, read-only
+	others sync.Map                 // tables created later
+}
+
+// Wrap instruments db
+func Wrap(db kv.RwDB) *DB {
+	d := &DB{RwDB: db, tables: map[string]*tableMetrics{}}
+	for table := range db.AllBuckets() {
+		d.tables[table] = newTableMetrics(table)
+	}
+	return d
+}
+
+func (db *DB) table(name string) *tableMetrics {
+	if m, ok := db.tables[name]; ok {
+		return m
+	}
+	m, ok := db.others.Load(name)
+	if !ok {
+		m, _ = db.others.LoadOrStore(name, newTableMetrics(name))
+	}
+	return m.(*tableMetrics)
+}
+
+func (db *DB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	t, err := db.RwDB.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, db: db}, nil
+}
+
+func (db *DB) BeginRw(ctx context.Context) (kv.RwTx, error) {
+	t, err := db.RwDB.BeginRw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rwTx{RwTx: t, db: db}, nil
+}
+
+func (db *DB) BeginRwAsync(ctx context.Context) (kv.RwTx, error) {
+	t, err := db.RwDB.BeginRwAsync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rwTx{RwTx: t, db: db}, nil
+}
+
+func (db *DB) View(ctx context.Context, f func(tx kv.Tx) error) error {
+	return db.RwDB.View(ctx, func(t kv.Tx) error { return f(&tx{Tx: t, db: db}) })
+}
+
+func (db *DB) Update(ctx context.Context, f func(tx kv.RwTx) error) error {
+	return db.RwDB.Update(ctx, func(t kv.RwTx) error { return f(&rwTx{RwTx: t, db: db}) })
+}
+
+func (db *DB) UpdateAsync(ctx context.Context, f func(tx kv.RwTx) error) error {
+	return db.RwDB.UpdateAsync(ctx, func(t kv.RwTx) error { return f(&rwTx{RwTx: t, db: db}) })
+}
+
+// reads are shared by tx and rwTx
+
+func getOne(t kv.Tx, db *DB, table string, key []byte) ([]byte, error) {
+	v, err := t.GetOne(table, key)
+	db.table(table).read(key, v)
+	return v, err
+}
+
+func has(t kv.Tx, db *DB, table string, key []byte) (bool, error) {
+	db.table(table).read(key, nil)
+	return t.Has(table, key)
+}
+
+func counted(db *DB, table string, walker func(k, v []byte) error) func(k, v []byte) error {
+	m := db.table(table)
+	m.cursors.Inc()
+	return func(k, v []byte) error {
+		m.read(k, v)
+		return walker(k, v)
+	}
+}
+
+func cursor(t kv.Tx, db *DB, table string) (kv.Cursor, error) {
+	db.table(table).cursors.Inc()
+	return t.Cursor(table)
+}
+
+func cursorDupSort(t kv.Tx, db *DB, table string) (kv.CursorDupSort, error) {
+	db.table(table).cursors.Inc()
+	return t.CursorDupSort(table)
+}
+
+func bucketStat(t kv.Tx, name string) (*mdbx.Stat, error) {
+	stater, ok := t.(interface {
+		BucketStat(name string) (*mdbx.Stat, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("kvmetrics: %T has no bucket stats", t)
+	}
+	return stater.BucketStat(name)
+}
+
+type tx struct {
+	kv.Tx
+	db *DB
+}
+
+func (t *tx) GetOne(table string, key []byte) ([]byte, error) { return getOne(t.Tx, t.db, table, key) }
+func (t *tx) Has(table string, key []byte) (bool, error)      { return has(t.Tx, t.db, table, key) }
+func (t *tx) ForEach(table string, fromPrefix []byte, walker func(k, v []byte) error) error {
+	return t.Tx.ForEach(table, fromPrefix, counted(t.db, table, walker))
+}
+func (t *tx) ForPrefix(table string, prefix []byte, walker func(k, v []byte) error) error {
+	return t.Tx.ForPrefix(table, prefix, counted(t.db, table, walker))
+}
+func (t *tx) ForAmount(table string, prefix []byte, amount uint32, walker func(k, v []byte) error) error {
+	return t.Tx.ForAmount(table, prefix, amount, counted(t.db, table, walker))
+}
+func (t *tx) Cursor(table string) (kv.Cursor, error) { return cursor(t.Tx, t.db, table) }
+func (t *tx) CursorDupSort(table string) (kv.CursorDupSort, error) {
+	return cursorDupSort(t.Tx, t.db, table)
+}
+
+// BucketStat keeps ethdb.ReadTableStats working
+func (t *tx) BucketStat(name string) (*mdbx.Stat, error) { return bucketStat(t.Tx, name) }
+
+// ListBuckets and the other kv.BucketMigrator methods are implemented by read transactions of mdbx too
+func (t *tx) migrator() (kv.BucketMigrator, error) {
+	m, ok := t.Tx.(kv.BucketMigrator)
+	if !ok {
+		return nil, fmt.Errorf("kvmetrics: %T is not a kv.BucketMigrator", t.Tx)
+	}
+	return m, nil
+}
+func (t *tx) ListBuckets() ([]string, error) {
+	m, err := t.migrator()
+	if err != nil {
+		return nil, err
+	}
+	return m.ListBuckets()
+}
+func (t *tx) ExistsBucket(name string) (bool, error) {
+	m, err := t.migrator()
+	if err != nil {
+		return false, err
+	}
+	return m.ExistsBucket(name)
+}
+func (t *tx) CreateBucket(name string) error {
+	m, err := t.migrator()
+	if err != nil {
+		return err
+	}
+	return m.CreateBucket(name)
+}
+func (t *tx) DropBucket(name string) error {
+	m, err := t.migrator()
+	if err != nil {
+		return err
+	}
+	return m.DropBucket(name)
+}
+func (t *tx) ClearBucket(name string) error {
+	m, err := t.migrator()
+	if err != nil {
+		return err
+	}
+	return m.ClearBucket(name)
+}
+
+type rwTx struct {
+	kv.RwTx
+	db *DB
+}
+
+func (t *rwTx) GetOne(table string, key []byte) ([]byte, error) {
+	return getOne(t.RwTx, t.db, table, key)
+}
+func (t *rwTx) Has(table string, key []byte) (bool, error) { return has(t.RwTx, t.db, table, key) }
+func (t *rwTx) ForEach(table string, fromPrefix []byte, walker func(k, v []byte) error) error {
+	return t.RwTx.ForEach(table, fromPrefix, counted(t.db, table, walker))
+}
+func (t *rwTx) ForPrefix(table string, prefix []byte, walker func(k, v []byte) error) error {
+	return t.RwTx.ForPrefix(table, prefix, counted(t.db, table, walker))
+}
+func (t *rwTx) ForAmount(table string, prefix []byte, amount uint32, walker func(k, v []byte) error) error {
+	return t.RwTx.ForAmount(table, prefix, amount, counted(t.db, table, walker))
+}
+func (t *rwTx) Cursor(table string) (kv.Cursor, error) { return cursor(t.RwTx, t.db, table) }
+func (t *rwTx) CursorDupSort(table string) (kv.CursorDupSort, error) {
+	return cursorDupSort(t.RwTx, t.db, table)
+}
+func (t *rwTx) BucketStat(name string) (*mdbx.Stat, error) { return bucketStat(t.RwTx, name) }
+
+func (t *rwTx) Put(table string, k, v []byte) error {
+	t.db.table(table).write(k, v)
+	return t.RwTx.Put(table, k, v)
+}
+func (t *rwTx) Append(table string, k, v []byte) error {
+	t.db.table(table).write(k, v)
+	return t.RwTx.Append(table, k, v)
+}
+func (t *rwTx) AppendDup(table string, k, v []byte) error {
+	t.db.table(table).write(k, v)
+	return t.RwTx.AppendDup(table, k, v)
+}
+func (t *rwTx) Delete(table string, k []byte) error {
+	t.db.table(table).deletes.Inc()
+	return t.RwTx.Delete(table, k)
+}
+
+// RwCursor keeps the dupsort cursors of dupsort tables assertable to kv.RwCursorDupSort
+func (t *rwTx) RwCursor(table string) (kv.RwCursor, error) {
+	m := t.db.table(table)
+	m.cursors.Inc()
+	c, err := t.RwTx.RwCursor(table)
+	if err != nil {
+		return nil, err
+	}
+	if dc, ok := c.(kv.RwCursorDupSort); ok {
+		return &rwCursorDupSort{RwCursorDupSort: dc, m: m}, nil
+	}
+	return &rwCursor{RwCursor: c, m: m}, nil
+}
+
+func (t *rwTx) RwCursorDupSort(table string) (kv.RwCursorDupSort, error) {
+	m := t.db.table(table)
+	m.cursors.Inc()
+	c, err := t.RwTx.RwCursorDupSort(table)
+	if err != nil {
+		return nil, err
+	}
+	return &rwCursorDupSort{RwCursorDupSort: c, m: m}, nil
+}
+
+type rwCursor struct {
+	kv.RwCursor
+	m *tableMetrics
+}
+
+func (c *rwCursor) Put(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursor.Put(k, v)
+}
+func (c *rwCursor) Append(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursor.Append(k, v)
+}
+func (c *rwCursor) Delete(k []byte) error {
+	c.m.deletes.Inc()
+	return c.RwCursor.Delete(k)
+}
+func (c *rwCursor) DeleteCurrent() error {
+	c.m.deletes.Inc()
+	return c.RwCursor.DeleteCurrent()
+}
+
+type rwCursorDupSort struct {
+	kv.RwCursorDupSort
+	m *tableMetrics
+}
+
+func (c *rwCursorDupSort) Put(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursorDupSort.Put(k, v)
+}
+func (c *rwCursorDupSort) Append(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursorDupSort.Append(k, v)
+}
+func (c *rwCursorDupSort) AppendDup(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursorDupSort.AppendDup(k, v)
+}
+func (c *rwCursorDupSort) PutNoDupData(k, v []byte) error {
+	c.m.write(k, v)
+	return c.RwCursorDupSort.PutNoDupData(k, v)
+}
+func (c *rwCursorDupSort) Delete(k []byte) error {
+	c.m.deletes.Inc()
+	return c.RwCursorDupSort.Delete(k)
+}
+func (c *rwCursorDupSort) DeleteCurrent() error {
+	c.m.deletes.Inc()
+	return c.RwCursorDupSort.DeleteCurrent()
+}
+func (c *rwCursorDupSort) DeleteExact(k1, k2 []byte) error {
+	c.m.deletes.Inc()
+	return c.RwCursorDupSort.DeleteExact(k1, k2)
+}
+func (c *rwCursorDupSort) DeleteCurrentDuplicates() error {
+	c.m.deletes.Inc()
+	return c.RwCursorDupSort.DeleteCurrentDuplicates()
+}
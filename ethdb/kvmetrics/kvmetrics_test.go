@@ -0,0 +1,47 @@
+package kvmetrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func counter(name, table string) uint64 {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`db_table_%s{table="%s"}`, name, table)).Get()
+}
+
+func TestCounts(t *testing.T) {
+	db := Wrap(memdb.NewTestDB(t))
+	ctx := context.Background()
+	getsBefore, putsBefore := counter("gets", kv.Headers), counter("puts", kv.Headers)
+	putBytesBefore, cursorsBefore := counter("put_bytes", kv.AccountChangeSet), counter("cursors", kv.AccountChangeSet)
+
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		if err := tx.Put(kv.Headers, []byte("k"), []byte("vvv")); err != nil {
+			return err
+		}
+		c, err := tx.RwCursor(kv.AccountChangeSet)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		dc, ok := c.(kv.RwCursorDupSort)
+		require.True(t, ok)
+		return dc.AppendDup([]byte("key"), []byte("value"))
+	}))
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		v, err := tx.GetOne(kv.Headers, []byte("k"))
+		require.Equal(t, []byte("vvv"), v)
+		return err
+	}))
+
+	require.Equal(t, uint64(1), counter("gets", kv.Headers)-getsBefore)
+	require.Equal(t, uint64(1), counter("puts", kv.Headers)-putsBefore)
+	require.Equal(t, uint64(8), counter("put_bytes", kv.AccountChangeSet)-putBytesBefore)
+	require.Equal(t, uint64(1), counter("cursors", kv.AccountChangeSet)-cursorsBefore)
+}
@@ -0,0 +1,340 @@
+package kvproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/log/v3"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// DefaultHealthCheckInterval is how often backends are probed with a Version call.
+const DefaultHealthCheckInterval = 5 * time.Second
+
+var ErrNoHealthyBackend = errors.New("kvproxy: no healthy backend")
+
+type backend struct {
+	name     string
+	kv       remote.KVClient
+	healthy  atomic.Bool
+	inflight atomic.Int64
+}
+
+// route is the backend and the backend-local txID of a transaction opened through the proxy.
+type route struct {
+	backend *backend
+	txID    uint64
+}
+
+// Proxy serves the KV gRPC service by spreading read transactions over several erigon nodes.
+// Each Tx stream is pinned to one backend for its whole life (cursors and the DB view live there).
+// TxIDs are re-numbered by the proxy, so unary calls referencing a transaction (Range, DomainGet, ...)
+// also reach the backend which owns it. New transactions go to the healthy backend with the fewest
+// in-flight calls, unhealthy backends are skipped until a health check succeeds again.
+//
+// Backends are not expected to be at the same height: the ViewID of transactions and StateChanges come
+// from different nodes, so clients must not rely on kvcache coherence (run rpcdaemon with --state.cache=0).
+type Proxy struct {
+	remote.UnimplementedKVServer
+
+	backends []*backend
+	lock     sync.RWMutex
+	routes   map[uint64]route
+	nextTxID atomic.Uint64
+}
+
+// New creates a proxy in front of backends, keyed by a name used in logs (usually the dial address).
+// All backends are considered healthy until the first failed call or health check.
+func New(backends map[string]remote.KVClient) *Proxy {
+	p := &Proxy{routes: map[uint64]route{}}
+	for name, kv := range backends {
+		b := &backend{name: name, kv: kv}
+		b.healthy.Store(true)
+		p.backends = append(p.backends, b)
+	}
+	return p
+}
+
+// Run health-checks backends every interval until ctx is cancelled.
+func (p *Proxy) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.checkHealth(ctx, interval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Proxy) checkHealth(ctx context.Context, timeout time.Duration) {
+	var wg sync.WaitGroup
+	for _, b := range p.backends {
+		wg.Add(1)
+		go func(b *backend) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			_, err := b.kv.Version(checkCtx, &emptypb.Empty{})
+			p.setHealthy(b, err == nil, err)
+		}(b)
+	}
+	wg.Wait()
+}
+
+func (p *Proxy) setHealthy(b *backend, healthy bool, err error) {
+	if b.healthy.Swap(healthy) == healthy {
+		return
+	}
+	if healthy {
+		log.Info("[kvproxy] backend is back", "backend", b.name)
+	} else {
+		log.Warn("[kvproxy] backend is down", "backend", b.name, "err", err)
+	}
+}
+
+// pick returns the healthy backend with the fewest in-flight calls, skipping the excluded ones.
+func (p *Proxy) pick(exclude map[*backend]bool) *backend {
+	var best *backend
+	for _, b := range p.backends {
+		if exclude[b] || !b.healthy.Load() {
+			continue
+		}
+		if best == nil || b.inflight.Load() < best.inflight.Load() {
+			best = b
+		}
+	}
+	return best
+}
+
+// isTransportError tells whether err means the backend could not be reached or did not answer in time, as opposed to
+// an error of the call itself (unknown table, invalid argument, ...) that any other backend would return as well.
+func isTransportError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// withFailover runs f on backends until one succeeds. Backends failing with transport errors are marked unhealthy and
+// the next one is tried, other errors are returned unchanged.
+func (p *Proxy) withFailover(ctx context.Context, f func(b *backend) error) error {
+	tried := map[*backend]bool{}
+	for {
+		b := p.pick(tried)
+		if b == nil {
+			return ErrNoHealthyBackend
+		}
+		tried[b] = true
+		b.inflight.Inc()
+		err := f(b)
+		b.inflight.Dec()
+		if err == nil || ctx.Err() != nil || !isTransportError(err) {
+			return err
+		}
+		p.setHealthy(b, false, err)
+	}
+}
+
+func (p *Proxy) addRoute(r route) uint64 {
+	id := p.nextTxID.Inc()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.routes[id] = r
+	return id
+}
+
+func (p *Proxy) removeRoute(id uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.routes, id)
+}
+
+func (p *Proxy) route(id uint64) (route, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	r, ok := p.routes[id]
+	if !ok {
+		return route{}, fmt.Errorf("kvproxy: unknown txID %d", id)
+	}
+	return r, nil
+}
+
+func (p *Proxy) Version(ctx context.Context, _ *emptypb.Empty) (reply *types.VersionReply, err error) {
+	err = p.withFailover(ctx, func(b *backend) error {
+		reply, err = b.kv.Version(ctx, &emptypb.Empty{})
+		return err
+	})
+	return reply, err
+}
+
+func (p *Proxy) Tx(stream remote.KV_TxServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	// open the transaction: until the first message arrives nothing is pinned yet and another backend can be tried
+	var b *backend
+	var upstream remote.KV_TxClient
+	var first *remote.Pair
+	if err := p.withFailover(ctx, func(candidate *backend) (err error) {
+		if upstream, err = candidate.kv.Tx(ctx); err != nil {
+			return err
+		}
+		if first, err = upstream.Recv(); err != nil {
+			return err
+		}
+		b = candidate
+		return nil
+	}); err != nil {
+		return err
+	}
+	b.inflight.Inc()
+	defer b.inflight.Dec()
+
+	id := p.addRoute(route{backend: b, txID: first.TxID})
+	defer p.removeRoute(id)
+	first.TxID = id
+	if err := stream.Send(first); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = upstream.CloseSend()
+				}
+				errCh <- err
+				return
+			}
+			if err := upstream.Send(in); err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	for {
+		out, err := upstream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("kvproxy: backend %s: %w", b.name, err)
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+		select {
+		case err := <-errCh:
+			if err != nil {
+				return err
+			}
+		default:
+		}
+	}
+}
+
+func (p *Proxy) StateChanges(req *remote.StateChangeRequest, stream remote.KV_StateChangesServer) error {
+	ctx := stream.Context()
+	var b *backend
+	var upstream remote.KV_StateChangesClient
+	if err := p.withFailover(ctx, func(candidate *backend) (err error) {
+		if upstream, err = candidate.kv.StateChanges(ctx, req); err != nil {
+			return err
+		}
+		b = candidate
+		return nil
+	}); err != nil {
+		return err
+	}
+	b.inflight.Inc()
+	defer b.inflight.Dec()
+	for {
+		batch, err := upstream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("kvproxy: backend %s: %w", b.name, err)
+		}
+		if err := stream.Send(batch); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Proxy) Snapshots(ctx context.Context, req *remote.SnapshotsRequest) (reply *remote.SnapshotsReply, err error) {
+	err = p.withFailover(ctx, func(b *backend) error {
+		reply, err = b.kv.Snapshots(ctx, req)
+		return err
+	})
+	return reply, err
+}
+
+// withTx runs f on the backend owning proxy transaction id, with the backend-local txID.
+// There is no failover: the transaction (and its DB view) exists only on that backend.
+func (p *Proxy) withTx(id uint64, f func(kv remote.KVClient, txID uint64) error) error {
+	r, err := p.route(id)
+	if err != nil {
+		return err
+	}
+	r.backend.inflight.Inc()
+	defer r.backend.inflight.Dec()
+	return f(r.backend.kv, r.txID)
+}
+
+func (p *Proxy) DomainGet(ctx context.Context, req *remote.DomainGetReq) (reply *remote.DomainGetReply, err error) {
+	err = p.withTx(req.TxId, func(kv remote.KVClient, txID uint64) error {
+		upstreamReq := proto.Clone(req).(*remote.DomainGetReq)
+		upstreamReq.TxId = txID
+		reply, err = kv.DomainGet(ctx, upstreamReq)
+		return err
+	})
+	return reply, err
+}
+
+func (p *Proxy) HistoryGet(ctx context.Context, req *remote.HistoryGetReq) (reply *remote.HistoryGetReply, err error) {
+	err = p.withTx(req.TxId, func(kv remote.KVClient, txID uint64) error {
+		upstreamReq := proto.Clone(req).(*remote.HistoryGetReq)
+		upstreamReq.TxId = txID
+		reply, err = kv.HistoryGet(ctx, upstreamReq)
+		return err
+	})
+	return reply, err
+}
+
+func (p *Proxy) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (reply *remote.IndexRangeReply, err error) {
+	err = p.withTx(req.TxId, func(kv remote.KVClient, txID uint64) error {
+		upstreamReq := proto.Clone(req).(*remote.IndexRangeReq)
+		upstreamReq.TxId = txID
+		reply, err = kv.IndexRange(ctx, upstreamReq)
+		return err
+	})
+	return reply, err
+}
+
+func (p *Proxy) Range(ctx context.Context, req *remote.RangeReq) (reply *remote.Pairs, err error) {
+	err = p.withTx(req.TxId, func(kv remote.KVClient, txID uint64) error {
+		upstreamReq := proto.Clone(req).(*remote.RangeReq)
+		upstreamReq.TxId = txID
+		reply, err = kv.Range(ctx, upstreamReq)
+		return err
+	})
+	return reply, err
+}
@@ -0,0 +1,136 @@
+package kvproxy
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func serve(t *testing.T, register func(s *grpc.Server)) (*grpc.Server, *grpc.ClientConn) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	register(server)
+	go server.Serve(listener) //nolint:errcheck
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+	return server, conn
+}
+
+func newBackend(t *testing.T, value string) (*grpc.Server, remote.KVClient) {
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.Headers, []byte("key"), []byte(value))
+	}))
+	kvServer := remotedbserver.NewKvServer(context.Background(), db, nil, nil)
+	server, conn := serve(t, func(s *grpc.Server) { remote.RegisterKVServer(s, kvServer) })
+	return server, remote.NewKVClient(conn)
+}
+
+func readKey(t *testing.T, tx kv.Tx) string {
+	v, err := tx.GetOne(kv.Headers, []byte("key"))
+	require.NoError(t, err)
+	// Range is a unary call referencing the transaction, it has to reach the same backend
+	it, err := tx.Range(kv.Headers, nil, nil)
+	require.NoError(t, err)
+	require.True(t, it.HasNext())
+	_, rv, err := it.Next()
+	require.NoError(t, err)
+	require.Equal(t, string(v), string(rv))
+	return string(v)
+}
+
+func TestProxy(t *testing.T) {
+	ctx := context.Background()
+	server1, kv1 := newBackend(t, "backend1")
+	_, kv2 := newBackend(t, "backend2")
+	proxy := New(map[string]remote.KVClient{"backend1": kv1, "backend2": kv2})
+	_, conn := serve(t, func(s *grpc.Server) { remote.RegisterKVServer(s, proxy) })
+
+	db, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), log.New(), remote.NewKVClient(conn)).Open()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// concurrent transactions are spread over backends
+	tx1, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	tx2, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	seen := map[string]bool{readKey(t, tx1): true, readKey(t, tx2): true}
+	require.Equal(t, map[string]bool{"backend1": true, "backend2": true}, seen)
+	tx1.Rollback()
+	tx2.Rollback()
+
+	// new transactions fail over to the live backend
+	server1.Stop()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+			require.Equal(t, "backend2", readKey(t, tx))
+			return nil
+		}))
+	}
+
+	proxy.checkHealth(ctx, time.Second)
+	healthy := map[string]bool{}
+	for _, b := range proxy.backends {
+		healthy[b.name] = b.healthy.Load()
+	}
+	require.Equal(t, map[string]bool{"backend1": false, "backend2": true}, healthy)
+}
+
+type snapshotsKV struct {
+	remote.KVClient
+	err   error
+	calls int
+}
+
+func (kv *snapshotsKV) Snapshots(context.Context, *remote.SnapshotsRequest, ...grpc.CallOption) (*remote.SnapshotsReply, error) {
+	kv.calls++
+	if kv.err != nil {
+		return nil, kv.err
+	}
+	return &remote.SnapshotsReply{}, nil
+}
+
+func TestProxyFailover(t *testing.T) {
+	ctx := context.Background()
+	failing := &snapshotsKV{err: status.Error(codes.InvalidArgument, "bad request")}
+	proxy := New(map[string]remote.KVClient{"failing": failing})
+
+	// an error of the call itself is returned as is, the backend stays in use
+	_, err := proxy.Snapshots(ctx, &remote.SnapshotsRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.True(t, proxy.backends[0].healthy.Load())
+
+	// a backend which can't be reached is skipped for the next one
+	down, up := &snapshotsKV{err: status.Error(codes.Unavailable, "connection refused")}, &snapshotsKV{}
+	proxy = New(map[string]remote.KVClient{"down": down, "up": up})
+	for i := 0; i < 2; i++ {
+		_, err = proxy.Snapshots(ctx, &remote.SnapshotsRequest{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, up.calls)
+	require.LessOrEqual(t, down.calls, 1)
+	for _, b := range proxy.backends {
+		require.Equal(t, b.name == "up", b.healthy.Load())
+	}
+}
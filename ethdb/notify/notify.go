@@ -0,0 +1,127 @@
+// Package notify announces the blocks committed by Erigon to the processes which read its datadir without the
+// private api, like `rpcdaemon --datadir.secondary`. Erigon rewrites a small file in the datadir on every new head
+// and on every new snapshot, the readers poll it and refresh their view of the database when it changes.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// FileName is the name of the head file, in the root of the datadir.
+const FileName = "head.json"
+
+// DefaultInterval is how often the readers poll the head file.
+const DefaultInterval = 200 * time.Millisecond
+
+// Head is the content of the head file. Seq increases with every write, so a reader can tell two updates to the
+// same head apart, Snapshots increases when Erigon opens new snapshot files.
+type Head struct {
+	Number    uint64         `json:"number"`
+	Hash      libcommon.Hash `json:"hash"`
+	Seq       uint64         `json:"seq"`
+	Snapshots uint64         `json:"snapshots"`
+	Time      int64          `json:"time"`
+}
+
+// Path returns the path of the head file of dataDir.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, FileName)
+}
+
+// Writer publishes the head of the writing process.
+type Writer struct {
+	lock sync.Mutex
+	path string
+	head Head
+}
+
+func NewWriter(dataDir string) *Writer {
+	return &Writer{path: Path(dataDir)}
+}
+
+func (w *Writer) OnNewHeader(number uint64, hash libcommon.Hash) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.head.Number, w.head.Hash = number, hash
+	return w.write()
+}
+
+func (w *Writer) OnNewSnapshot() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.head.Snapshots++
+	return w.write()
+}
+
+// write replaces the head file with a rename, so that readers never see a partial file.
+func (w *Writer) write() error {
+	w.head.Seq++
+	w.head.Time = time.Now().Unix()
+	data, err := json.Marshal(&w.head)
+	if err != nil {
+		return err
+	}
+	tmp := w.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing head file: %w", err)
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		return fmt.Errorf("writing head file: %w", err)
+	}
+	return nil
+}
+
+// Read returns the head published in dataDir, os.ErrNotExist when Erigon didn't publish one yet.
+func Read(dataDir string) (Head, error) {
+	var head Head
+	data, err := os.ReadFile(Path(dataDir))
+	if err != nil {
+		return head, err
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return head, fmt.Errorf("parsing head file: %w", err)
+	}
+	return head, nil
+}
+
+// Watch sends the head published in dataDir every time it changes, until ctx is done. The first head, if any, is
+// sent right away. Updates in between two polls are coalesced, readers only need the latest one.
+func Watch(ctx context.Context, dataDir string, interval time.Duration) <-chan Head {
+	ch := make(chan Head, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last Head
+		for {
+			head, err := Read(dataDir)
+			switch {
+			case err == nil && head != last:
+				last = head
+				select {
+				case ch <- head:
+				case <-ctx.Done():
+					return
+				}
+			case err != nil && !errors.Is(err, os.ErrNotExist):
+				log.Warn("[notify] reading head file", "err", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterRead(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Read(dir)
+	require.True(t, errors.Is(err, os.ErrNotExist))
+
+	w := NewWriter(dir)
+	require.NoError(t, w.OnNewHeader(10, libcommon.HexToHash("0x0a")))
+	require.NoError(t, w.OnNewSnapshot())
+	head, err := Read(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), head.Number)
+	require.Equal(t, libcommon.HexToHash("0x0a"), head.Hash)
+	require.Equal(t, uint64(2), head.Seq)
+	require.Equal(t, uint64(1), head.Snapshots)
+
+	// no temporary file is left behind
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, dir, time.Millisecond)
+
+	w := NewWriter(dir)
+	next := func() Head {
+		select {
+		case head := <-ch:
+			return head
+		case <-time.After(5 * time.Second):
+			t.Fatal("no head")
+		}
+		return Head{}
+	}
+	require.NoError(t, w.OnNewHeader(1, libcommon.HexToHash("0x01")))
+	require.Equal(t, uint64(1), next().Number)
+	require.NoError(t, w.OnNewHeader(2, libcommon.HexToHash("0x02")))
+	require.Equal(t, uint64(2), next().Number)
+	require.NoError(t, w.OnNewSnapshot())
+	head := next()
+	require.Equal(t, uint64(2), head.Number)
+	require.Equal(t, uint64(1), head.Snapshots)
+
+	cancel()
+	for range ch {
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/etl"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
@@ -24,6 +26,11 @@ type mapmutation struct {
 	size   int
 	count  uint64
 	tmpdir string
+
+	// memLimit is the memory over which the puts are spilled to runs in tmpdir, 0 for no limit
+	memLimit int
+	memSize  int
+	runs     map[string][]*spillRun // table -> runs, oldest first
 }
 
 // NewBatch - starts in-mem batch
@@ -35,6 +42,12 @@ type mapmutation struct {
 // ... some calculations on `batch`
 // batch.Commit()
 func NewHashBatch(tx kv.RwTx, quit <-chan struct{}, tmpdir string) *mapmutation {
+	return NewHashBatchWithLimit(tx, quit, tmpdir, 0)
+}
+
+// NewHashBatchWithLimit - starts a batch which spills its writes to sorted files in tmpdir when they take more than
+// memLimit of memory, and merges them at commit. A memLimit of 0 means no limit.
+func NewHashBatchWithLimit(tx kv.RwTx, quit <-chan struct{}, tmpdir string, memLimit datasize.ByteSize) *mapmutation {
 	clean := func() {}
 	if quit == nil {
 		ch := make(chan struct{})
@@ -43,11 +56,13 @@ func NewHashBatch(tx kv.RwTx, quit <-chan struct{}, tmpdir string) *mapmutation
 	}
 
 	return &mapmutation{
-		db:     tx,
-		puts:   make(map[string]map[string][]byte),
-		quit:   quit,
-		clean:  clean,
-		tmpdir: tmpdir,
+		db:       tx,
+		puts:     make(map[string]map[string][]byte),
+		quit:     quit,
+		clean:    clean,
+		tmpdir:   tmpdir,
+		memLimit: int(memLimit),
+		runs:     make(map[string][]*spillRun),
 	}
 }
 
@@ -58,21 +73,26 @@ func (m *mapmutation) RwKV() kv.RwDB {
 	return nil
 }
 
-func (m *mapmutation) getMem(table string, key []byte) ([]byte, bool) {
+func (m *mapmutation) getMem(table string, key []byte) ([]byte, bool, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	if _, ok := m.puts[table]; !ok {
-		return nil, false
-	}
 	if value, ok := m.puts[table][*(*string)(unsafe.Pointer(&key))]; ok {
-		return value, ok
+		return value, ok, nil
 	}
-
-	return nil, false
+	runs := m.runs[table]
+	for i := len(runs) - 1; i >= 0; i-- {
+		if value, ok, err := runs[i].get(key); ok || err != nil {
+			return value, ok, err
+		}
+	}
+	return nil, false, nil
 }
 
 func (m *mapmutation) IncrementSequence(bucket string, amount uint64) (res uint64, err error) {
-	v, ok := m.getMem(kv.Sequence, []byte(bucket))
+	v, ok, err := m.getMem(kv.Sequence, []byte(bucket))
+	if err != nil {
+		return 0, err
+	}
 	if !ok && m.db != nil {
 		v, err = m.db.GetOne(kv.Sequence, []byte(bucket))
 		if err != nil {
@@ -94,7 +114,10 @@ func (m *mapmutation) IncrementSequence(bucket string, amount uint64) (res uint6
 	return currentV, nil
 }
 func (m *mapmutation) ReadSequence(bucket string) (res uint64, err error) {
-	v, ok := m.getMem(kv.Sequence, []byte(bucket))
+	v, ok, err := m.getMem(kv.Sequence, []byte(bucket))
+	if err != nil {
+		return 0, err
+	}
 	if !ok && m.db != nil {
 		v, err = m.db.GetOne(kv.Sequence, []byte(bucket))
 		if err != nil {
@@ -111,8 +134,8 @@ func (m *mapmutation) ReadSequence(bucket string) (res uint64, err error) {
 
 // Can only be called from the worker thread
 func (m *mapmutation) GetOne(table string, key []byte) ([]byte, error) {
-	if value, ok := m.getMem(table, key); ok {
-		return value, nil
+	if value, ok, err := m.getMem(table, key); ok || err != nil {
+		return value, err
 	}
 	if m.db != nil {
 		// TODO: simplify when tx can no longer be parent of mutation
@@ -149,8 +172,8 @@ func (m *mapmutation) Last(table string) ([]byte, []byte, error) {
 }
 
 func (m *mapmutation) Has(table string, key []byte) (bool, error) {
-	if _, ok := m.getMem(table, key); ok {
-		return ok, nil
+	if _, ok, err := m.getMem(table, key); ok || err != nil {
+		return ok, err
 	}
 	if m.db != nil {
 		return m.db.Has(table, key)
@@ -168,19 +191,55 @@ func (m *mapmutation) Put(table string, k, v []byte) error {
 
 	stringKey := string(k)
 
+	if prev, ok := m.puts[table][stringKey]; ok {
+		m.memSize += len(v) - len(prev)
+	} else {
+		m.memSize += len(k) + len(v) + mapEntryOverhead
+	}
+
 	var ok bool
 	if _, ok = m.puts[table][stringKey]; !ok {
 		m.size += len(v) - len(m.puts[table][stringKey])
 		m.puts[table][stringKey] = v
-		return nil
+		return m.spillIfOverLimit()
 	}
 	m.puts[table][stringKey] = v
 	m.size += len(k) + len(v)
 	m.count++
 
+	return m.spillIfOverLimit()
+}
+
+// spillIfOverLimit writes the puts to a sorted run per table when they take more than the memory limit.
+// Must be called with the lock held.
+func (m *mapmutation) spillIfOverLimit() error {
+	if m.memLimit == 0 || m.memSize < m.memLimit {
+		return nil
+	}
+	start := time.Now()
+	spilled := m.memSize
+	for table, bucket := range m.puts {
+		run, err := writeSpillRun(m.tmpdir, bucket)
+		if err != nil {
+			return fmt.Errorf("spilling batch of %s: %w", table, err)
+		}
+		m.runs[table] = append(m.runs[table], run)
+	}
+	m.puts = map[string]map[string][]byte{}
+	m.memSize = 0
+	log.Debug("Spilled batch to disk", "size", common.ByteCount(uint64(spilled)), "took", time.Since(start))
 	return nil
 }
 
+func (m *mapmutation) closeRuns() {
+	for _, runs := range m.runs {
+		for _, run := range runs {
+			run.close()
+		}
+	}
+	m.runs = map[string][]*spillRun{}
+}
+
 func (m *mapmutation) Append(table string, key []byte, value []byte) error {
 	return m.Put(table, key, value)
 }
@@ -219,7 +278,15 @@ func (m *mapmutation) doCommit(tx kv.RwTx) error {
 	defer logEvery.Stop()
 	count := 0
 	total := float64(m.count)
+	for table, runs := range m.runs {
+		if err := mergeRuns(tx, table, runs, m.puts[table], m.quit, logEvery); err != nil {
+			return err
+		}
+	}
 	for table, bucket := range m.puts {
+		if _, ok := m.runs[table]; ok {
+			continue
+		}
 		collector := etl.NewCollector("", m.tmpdir, etl.NewSortableBuffer(etl.BufferOptimalSize))
 		defer collector.Close()
 		for key, value := range bucket {
@@ -253,7 +320,9 @@ func (m *mapmutation) Commit() error {
 	}
 
 	m.puts = map[string]map[string][]byte{}
+	m.closeRuns()
 	m.size = 0
+	m.memSize = 0
 	m.count = 0
 	m.clean()
 	return nil
@@ -263,7 +332,9 @@ func (m *mapmutation) Rollback() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.puts = map[string]map[string][]byte{}
+	m.closeRuns()
 	m.size = 0
+	m.memSize = 0
 	m.count = 0
 	m.size = 0
 	m.clean()
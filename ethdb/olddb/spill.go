@@ -0,0 +1,242 @@
+package olddb
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+)
+
+const (
+	// spillIndexInterval is the number of records of a spill run between two keys of its index
+	spillIndexInterval = 64
+	// mapEntryOverhead is roughly the memory used by a map entry of a batch, besides its key and value
+	mapEntryOverhead = 64
+)
+
+type spillIndexEntry struct {
+	key    []byte
+	offset int64
+}
+
+// spillRun is a temporary file of the sorted pending writes of one table, which a batch over its memory limit
+// spilled to disk. The records are uvarint(len(k)) k uvarint(len(v)) v, an empty value is a delete. Every
+// spillIndexInterval-th key is kept in memory, so that a lookup reads a single block of the file.
+type spillRun struct {
+	file  *os.File
+	size  int64
+	index []spillIndexEntry
+}
+
+func writeSpillRun(tmpdir string, bucket map[string][]byte) (*spillRun, error) {
+	keys := make([]string, 0, len(bucket))
+	for k := range bucket {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	file, err := os.CreateTemp(tmpdir, "erigon-batch-spill-*")
+	if err != nil {
+		return nil, err
+	}
+	r := &spillRun{file: file, index: make([]spillIndexEntry, 0, len(keys)/spillIndexInterval+1)}
+	w := bufio.NewWriterSize(file, 1<<20)
+	var lenBuf [binary.MaxVarintLen64]byte
+	for i, k := range keys {
+		if i%spillIndexInterval == 0 {
+			r.index = append(r.index, spillIndexEntry{key: []byte(k), offset: r.size})
+		}
+		v := bucket[k]
+		for _, b := range [][]byte{[]byte(k), v} {
+			n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+			if _, err := w.Write(lenBuf[:n]); err != nil {
+				r.close()
+				return nil, err
+			}
+			if _, err := w.Write(b); err != nil {
+				r.close()
+				return nil, err
+			}
+			r.size += int64(n + len(b))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		r.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// get returns the value of key in the run, nil for a delete.
+func (r *spillRun) get(key []byte) ([]byte, bool, error) {
+	i := sort.Search(len(r.index), func(i int) bool { return bytes.Compare(r.index[i].key, key) > 0 }) - 1
+	if i < 0 {
+		return nil, false, nil
+	}
+	end := r.size
+	if i+1 < len(r.index) {
+		end = r.index[i+1].offset
+	}
+	it := r.iterator(r.index[i].offset, end)
+	for {
+		k, v, err := it.next()
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch bytes.Compare(k, key) {
+		case 0:
+			if len(v) == 0 {
+				return nil, true, nil
+			}
+			return v, true, nil
+		case 1:
+			return nil, false, nil
+		}
+	}
+}
+
+func (r *spillRun) iterator(from, to int64) *spillIterator {
+	return &spillIterator{r: bufio.NewReader(io.NewSectionReader(r.file, from, to-from))}
+}
+
+func (r *spillRun) close() {
+	r.file.Close()
+	os.Remove(r.file.Name())
+}
+
+type spillIterator struct {
+	r *bufio.Reader
+}
+
+// next returns io.EOF at the end of the run. The returned slices are fresh.
+func (it *spillIterator) next() (k, v []byte, err error) {
+	if k, err = it.read(); err != nil {
+		return nil, nil, err
+	}
+	if v, err = it.read(); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, nil, err
+	}
+	return k, v, nil
+}
+
+func (it *spillIterator) read() ([]byte, error) {
+	n, err := binary.ReadUvarint(it.r)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(it.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// mergeSource is one of the sorted sources of the writes of a table at commit: a spill run, or the writes
+// still in memory. Sources with a higher priority are newer and win over the others on the same key.
+type mergeSource struct {
+	priority int
+	k, v     []byte
+	next     func() ([]byte, []byte, error)
+}
+
+type mergeHeap []*mergeSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if c := bytes.Compare(h[i].k, h[j].k); c != 0 {
+		return c < 0
+	}
+	return h[i].priority > h[j].priority
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// mergeRuns writes the newest value of every key of the runs and of bucket, in key order, to table.
+func mergeRuns(tx kv.RwTx, table string, runs []*spillRun, bucket map[string][]byte, quit <-chan struct{}, logEvery *time.Ticker) error {
+	h := make(mergeHeap, 0, len(runs)+1)
+	push := func(s *mergeSource) error {
+		k, v, err := s.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.k, s.v = k, v
+		heap.Push(&h, s)
+		return nil
+	}
+	for i, r := range runs {
+		if err := push(&mergeSource{priority: i, next: r.iterator(0, r.size).next}); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(bucket))
+	for k := range bucket {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if err := push(&mergeSource{priority: len(runs), next: func() ([]byte, []byte, error) {
+		if len(keys) == 0 {
+			return nil, nil, io.EOF
+		}
+		k := keys[0]
+		keys = keys[1:]
+		return []byte(k), bucket[k], nil
+	}}); err != nil {
+		return err
+	}
+
+	var last []byte
+	count := 0
+	for h.Len() > 0 {
+		s := heap.Pop(&h).(*mergeSource)
+		// the newest value of a key comes first
+		if count == 0 || !bytes.Equal(s.k, last) {
+			last = s.k
+			if len(s.v) == 0 {
+				if err := tx.Delete(table, s.k); err != nil {
+					return err
+				}
+			} else if err := tx.Put(table, s.k, s.v); err != nil {
+				return err
+			}
+			count++
+		}
+		if err := push(s); err != nil {
+			return err
+		}
+		if err := libcommon.Stopped(quit); err != nil {
+			return err
+		}
+		select {
+		case <-logEvery.C:
+			log.Info("Write to db", "progress", fmt.Sprintf("%.1fM", float64(count)/1_000_000), "current table", table, "spill runs", len(runs))
+			tx.CollectMetrics()
+		default:
+		}
+	}
+	return nil
+}
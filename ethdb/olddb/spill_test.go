@@ -0,0 +1,79 @@
+//go:build !js
+
+package olddb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashBatchSpill(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	tmpdir := t.TempDir()
+	require.NoError(t, tx.Put(kv.HashedAccounts, []byte("key-deleted"), []byte("old")))
+	require.NoError(t, tx.Put(kv.HashedAccounts, []byte("key-kept"), []byte("old")))
+
+	// small enough to spill every few hundred puts
+	batch := NewHashBatchWithLimit(tx, nil, tmpdir, 16*1024)
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, batch.Put(kv.HashedAccounts, []byte(fmt.Sprintf("key-%04d", i)), []byte(fmt.Sprintf("v1-%d", i))))
+		require.NoError(t, batch.Put(kv.HashedStorage, []byte(fmt.Sprintf("key-%04d", i)), []byte("storage")))
+	}
+	// overwrites land in later runs, or in memory
+	for i := 0; i < 2000; i += 3 {
+		require.NoError(t, batch.Put(kv.HashedAccounts, []byte(fmt.Sprintf("key-%04d", i)), []byte(fmt.Sprintf("v2-%d", i))))
+	}
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("key-0001")))
+	require.NoError(t, batch.Delete(kv.HashedAccounts, []byte("key-deleted")))
+	require.Greater(t, len(batch.runs[kv.HashedAccounts]), 1)
+
+	// reads see the newest writes, spilled or not
+	v, err := batch.GetOne(kv.HashedAccounts, []byte("key-0003"))
+	require.NoError(t, err)
+	require.Equal(t, "v2-3", string(v))
+	v, err = batch.GetOne(kv.HashedAccounts, []byte("key-0004"))
+	require.NoError(t, err)
+	require.Equal(t, "v1-4", string(v))
+	v, err = batch.GetOne(kv.HashedAccounts, []byte("key-0001"))
+	require.NoError(t, err)
+	require.Nil(t, v)
+	v, err = batch.GetOne(kv.HashedAccounts, []byte("key-kept"))
+	require.NoError(t, err)
+	require.Equal(t, "old", string(v))
+
+	require.NoError(t, batch.Commit())
+	for i := 0; i < 2000; i++ {
+		v, err := tx.GetOne(kv.HashedAccounts, []byte(fmt.Sprintf("key-%04d", i)))
+		require.NoError(t, err)
+		switch {
+		case i == 1:
+			require.Nil(t, v)
+		case i%3 == 0:
+			require.Equal(t, fmt.Sprintf("v2-%d", i), string(v))
+		default:
+			require.Equal(t, fmt.Sprintf("v1-%d", i), string(v))
+		}
+	}
+	v, err = tx.GetOne(kv.HashedAccounts, []byte("key-deleted"))
+	require.NoError(t, err)
+	require.Nil(t, v)
+	v, err = tx.GetOne(kv.HashedAccounts, []byte("key-kept"))
+	require.NoError(t, err)
+	require.Equal(t, "old", string(v))
+	c, err := tx.Cursor(kv.HashedStorage)
+	require.NoError(t, err)
+	defer c.Close()
+	count, err := c.Count()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2000), count)
+
+	// the runs are removed
+	files, err := os.ReadDir(tmpdir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,121 @@
+package privateapi
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewAuditedKV logs who uses kv: for a sampled rate (0..1] of the transactions (Tx streams) and unary calls, the
+// remote address of the client, the tables read, the number of reads and of keys and bytes replied, and the
+// duration. Operators of nodes shared by several clients can attribute the load with it. StateChanges subscriptions
+// are not logged.
+func NewAuditedKV(kv remote.KVServer, rate float64) remote.KVServer {
+	if rate <= 0 {
+		return kv
+	}
+	return &auditedKV{KVServer: kv, rate: rate}
+}
+
+type auditedKV struct {
+	remote.KVServer
+	rate float64
+}
+
+func (s *auditedKV) sampled() bool {
+	return s.rate >= 1 || rand.Float64() < s.rate //nolint:gosec
+}
+
+// audited runs the unary call f, logging it when sampled.
+func audited[T proto.Message](ctx context.Context, s *auditedKV, method, table string, f func() (T, error)) (T, error) {
+	if !s.sampled() {
+		return f()
+	}
+	start := time.Now()
+	reply, err := f()
+	var keys, size int
+	if err == nil {
+		if pairs, ok := any(reply).(*remote.Pairs); ok {
+			keys = len(pairs.Keys)
+		}
+		size = proto.Size(reply)
+	}
+	log.Info("[kv audit] call", "remote", remoteAddr(ctx), "method", method, "table", table, "keys", keys, "bytes", size,
+		"duration", time.Since(start), "err", err)
+	return reply, err
+}
+
+func (s *auditedKV) Tx(stream remote.KV_TxServer) error {
+	if !s.sampled() {
+		return s.KVServer.Tx(stream)
+	}
+	auditedStream := &auditedTxStream{KV_TxServer: stream, tables: map[string]struct{}{}}
+	start := time.Now()
+	err := s.KVServer.Tx(auditedStream)
+	tables := make([]string, 0, len(auditedStream.tables))
+	for table := range auditedStream.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	log.Info("[kv audit] tx", "remote", remoteAddr(stream.Context()), "tables", tables, "reads", auditedStream.reads,
+		"keys", auditedStream.keys, "bytes", auditedStream.bytes, "duration", time.Since(start), "err", err)
+	return err
+}
+
+func (s *auditedKV) Snapshots(ctx context.Context, req *remote.SnapshotsRequest) (*remote.SnapshotsReply, error) {
+	return audited(ctx, s, "Snapshots", "", func() (*remote.SnapshotsReply, error) { return s.KVServer.Snapshots(ctx, req) })
+}
+
+func (s *auditedKV) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pairs, error) {
+	return audited(ctx, s, "Range", req.Table, func() (*remote.Pairs, error) { return s.KVServer.Range(ctx, req) })
+}
+
+func (s *auditedKV) DomainGet(ctx context.Context, req *remote.DomainGetReq) (*remote.DomainGetReply, error) {
+	return audited(ctx, s, "DomainGet", req.Table, func() (*remote.DomainGetReply, error) { return s.KVServer.DomainGet(ctx, req) })
+}
+
+func (s *auditedKV) HistoryGet(ctx context.Context, req *remote.HistoryGetReq) (*remote.HistoryGetReply, error) {
+	return audited(ctx, s, "HistoryGet", req.Table, func() (*remote.HistoryGetReply, error) { return s.KVServer.HistoryGet(ctx, req) })
+}
+
+func (s *auditedKV) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*remote.IndexRangeReply, error) {
+	return audited(ctx, s, "IndexRange", req.Table, func() (*remote.IndexRangeReply, error) { return s.KVServer.IndexRange(ctx, req) })
+}
+
+// auditedTxStream counts the cursor operations of a transaction and what they replied. The server handles the
+// messages of a stream one by one, no locking is needed.
+type auditedTxStream struct {
+	remote.KV_TxServer
+	tables map[string]struct{}
+	reads  int
+	keys   int
+	bytes  int
+}
+
+func (s *auditedTxStream) Recv() (*remote.Cursor, error) {
+	in, err := s.KV_TxServer.Recv()
+	if err != nil {
+		return nil, err
+	}
+	switch in.Op {
+	case remote.Op_OPEN, remote.Op_OPEN_DUP_SORT:
+		s.tables[in.BucketName] = struct{}{}
+	case remote.Op_CLOSE:
+	default:
+		s.reads++
+	}
+	return in, nil
+}
+
+func (s *auditedTxStream) Send(m *remote.Pair) error {
+	if m.K != nil {
+		s.keys++
+	}
+	s.bytes += proto.Size(m)
+	return s.KV_TxServer.Send(m)
+}
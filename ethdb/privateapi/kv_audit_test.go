@@ -0,0 +1,64 @@
+package privateapi
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testTxStream struct {
+	in  []*remote.Cursor
+	out []*remote.Pair
+	grpc.ServerStream
+}
+
+func (s *testTxStream) Recv() (*remote.Cursor, error) {
+	if len(s.in) == 0 {
+		return nil, io.EOF
+	}
+	in := s.in[0]
+	s.in = s.in[1:]
+	return in, nil
+}
+
+func (s *testTxStream) Send(m *remote.Pair) error {
+	s.out = append(s.out, m)
+	return nil
+}
+
+func TestAuditedTxStream(t *testing.T) {
+	stream := &auditedTxStream{
+		KV_TxServer: &testTxStream{in: []*remote.Cursor{
+			{Op: remote.Op_OPEN, BucketName: "Header"},
+			{Op: remote.Op_SEEK, Cursor: 1, K: []byte{1}},
+			{Op: remote.Op_NEXT, Cursor: 1},
+			{Op: remote.Op_CLOSE, Cursor: 1},
+			{Op: remote.Op_OPEN_DUP_SORT, BucketName: "PlainState"},
+			{Op: remote.Op_SEEK_BOTH, Cursor: 2, K: []byte{2}, V: []byte{3}},
+		}},
+		tables: map[string]struct{}{},
+	}
+	// the replies of the server: cursor ids, two keys, the end of a table and the close
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch in.Op {
+		case remote.Op_OPEN, remote.Op_OPEN_DUP_SORT:
+			require.NoError(t, stream.Send(&remote.Pair{CursorID: 1}))
+		case remote.Op_NEXT, remote.Op_CLOSE:
+			require.NoError(t, stream.Send(&remote.Pair{}))
+		default:
+			require.NoError(t, stream.Send(&remote.Pair{K: []byte{1}, V: []byte{2}}))
+		}
+	}
+	require.Equal(t, map[string]struct{}{"Header": {}, "PlainState": {}}, stream.tables)
+	require.Equal(t, 3, stream.reads)
+	require.Equal(t, 2, stream.keys)
+	require.Positive(t, stream.bytes)
+}
@@ -0,0 +1,198 @@
+package privateapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// idleLimiterTimeout is how long the limiter of a connection without calls is kept.
+const idleLimiterTimeout = time.Minute
+
+var errKvThrottled = status.Error(codes.ResourceExhausted, "remote KV: connection over its rate limit")
+
+// KvLimits bound the load each client connection of the remote KV service puts on the node, zero means unlimited.
+type KvLimits struct {
+	OpsPerSecond   float64           // unary calls and operations of Tx streams
+	BytesPerSecond datasize.ByteSize // replies
+}
+
+// NewLimitedKV serves kv within limits, applied to each client connection separately: a client over its limits gets
+// ResourceExhausted errors for its unary calls, and its Tx streams are slowed down, while the others are served as
+// usual. Version and StateChanges are not limited.
+func NewLimitedKV(kv remote.KVServer, limits KvLimits) remote.KVServer {
+	if limits.OpsPerSecond <= 0 && limits.BytesPerSecond == 0 {
+		return kv
+	}
+	return &limitedKV{KVServer: kv, limits: limits, conns: map[string]*connLimiter{}}
+}
+
+type limitedKV struct {
+	remote.KVServer
+	limits KvLimits
+
+	lock  sync.Mutex
+	conns map[string]*connLimiter // remote address -> limiter
+}
+
+// connLimiter are token buckets holding one second of the limits of a connection.
+type connLimiter struct {
+	ops      *rate.Limiter
+	bytes    *rate.Limiter
+	lastUsed time.Time // guarded by limitedKV.lock
+	streams  int       // open Tx streams, guarded by limitedKV.lock
+}
+
+// remoteAddr identifies the client connection of a call.
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func (s *limitedKV) limiter(ctx context.Context) *connLimiter {
+	addr, now := remoteAddr(ctx), time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	l, ok := s.conns[addr]
+	if !ok {
+		for a, idle := range s.conns {
+			if idle.streams == 0 && now.Sub(idle.lastUsed) > idleLimiterTimeout {
+				delete(s.conns, a)
+			}
+		}
+		l = &connLimiter{}
+		if s.limits.OpsPerSecond > 0 {
+			l.ops = rate.NewLimiter(rate.Limit(s.limits.OpsPerSecond), int(s.limits.OpsPerSecond)+1)
+		}
+		if s.limits.BytesPerSecond > 0 {
+			l.bytes = rate.NewLimiter(rate.Limit(s.limits.BytesPerSecond), int(s.limits.BytesPerSecond))
+		}
+		s.conns[addr] = l
+	}
+	l.lastUsed = now
+	return l
+}
+
+// allow takes the token of an operation. The bytes are paid after the reply is known, so a connection in debt is
+// refused until the bucket fills up again.
+func (l *connLimiter) allow() bool {
+	if l.bytes != nil && l.bytes.Tokens() < 0 {
+		return false
+	}
+	return l.ops == nil || l.ops.Allow()
+}
+
+// wait delays an operation of a Tx stream until the connection is within its limits again.
+func (l *connLimiter) wait(ctx context.Context) error {
+	if l.bytes != nil {
+		if debt := -l.bytes.Tokens(); debt > 0 {
+			timer := time.NewTimer(time.Duration(debt / float64(l.bytes.Limit()) * float64(time.Second)))
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	if l.ops == nil {
+		return nil
+	}
+	return l.ops.Wait(ctx)
+}
+
+func (l *connLimiter) sent(m proto.Message) {
+	if l.bytes == nil {
+		return
+	}
+	// a reservation can't exceed the burst, a reply larger than one second of bandwidth takes several
+	now, burst := time.Now(), l.bytes.Burst()
+	for n := proto.Size(m); n > 0; n -= burst {
+		if n < burst {
+			l.bytes.ReserveN(now, n)
+		} else {
+			l.bytes.ReserveN(now, burst)
+		}
+	}
+}
+
+// limited runs the unary call f within the limits of the connection of ctx.
+func limited[T proto.Message](ctx context.Context, s *limitedKV, f func() (T, error)) (T, error) {
+	l := s.limiter(ctx)
+	if !l.allow() {
+		var empty T
+		return empty, errKvThrottled
+	}
+	reply, err := f()
+	if err == nil {
+		l.sent(reply)
+	}
+	return reply, err
+}
+
+func (s *limitedKV) Tx(stream remote.KV_TxServer) error {
+	l := s.limiter(stream.Context())
+	s.lock.Lock()
+	l.streams++
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		l.streams--
+		l.lastUsed = time.Now()
+		s.lock.Unlock()
+	}()
+	return s.KVServer.Tx(&limitedTxStream{KV_TxServer: stream, limiter: l})
+}
+
+func (s *limitedKV) Snapshots(ctx context.Context, req *remote.SnapshotsRequest) (*remote.SnapshotsReply, error) {
+	return limited(ctx, s, func() (*remote.SnapshotsReply, error) { return s.KVServer.Snapshots(ctx, req) })
+}
+
+func (s *limitedKV) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pairs, error) {
+	return limited(ctx, s, func() (*remote.Pairs, error) { return s.KVServer.Range(ctx, req) })
+}
+
+func (s *limitedKV) DomainGet(ctx context.Context, req *remote.DomainGetReq) (*remote.DomainGetReply, error) {
+	return limited(ctx, s, func() (*remote.DomainGetReply, error) { return s.KVServer.DomainGet(ctx, req) })
+}
+
+func (s *limitedKV) HistoryGet(ctx context.Context, req *remote.HistoryGetReq) (*remote.HistoryGetReply, error) {
+	return limited(ctx, s, func() (*remote.HistoryGetReply, error) { return s.KVServer.HistoryGet(ctx, req) })
+}
+
+func (s *limitedKV) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*remote.IndexRangeReply, error) {
+	return limited(ctx, s, func() (*remote.IndexRangeReply, error) { return s.KVServer.IndexRange(ctx, req) })
+}
+
+// limitedTxStream delays the operations of a connection over its limits, rather than failing the transaction of the
+// client in the middle of its request.
+type limitedTxStream struct {
+	remote.KV_TxServer
+	limiter *connLimiter
+}
+
+func (s *limitedTxStream) Recv() (*remote.Cursor, error) {
+	in, err := s.KV_TxServer.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.limiter.wait(s.Context()); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+func (s *limitedTxStream) Send(m *remote.Pair) error {
+	s.limiter.sent(m)
+	return s.KV_TxServer.Send(m)
+}
@@ -0,0 +1,101 @@
+package privateapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type rangeKV struct {
+	remote.UnimplementedKVServer
+	reply *remote.Pairs
+	ops   int // operations received by Tx
+}
+
+func (kv *rangeKV) Tx(stream remote.KV_TxServer) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		kv.ops++
+	}
+}
+
+// cursorStream is the server side of a Tx stream of a client sending n operations.
+type cursorStream struct {
+	remote.KV_TxServer
+	ctx context.Context
+	n   int
+}
+
+func (s *cursorStream) Context() context.Context { return s.ctx }
+
+func (s *cursorStream) Recv() (*remote.Cursor, error) {
+	if s.n == 0 {
+		return nil, io.EOF
+	}
+	s.n--
+	return &remote.Cursor{}, nil
+}
+
+func (kv *rangeKV) Range(context.Context, *remote.RangeReq) (*remote.Pairs, error) {
+	return kv.reply, nil
+}
+
+func connCtx(port int) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}})
+}
+
+func TestLimitedKV(t *testing.T) {
+	backend := &rangeKV{reply: &remote.Pairs{Keys: [][]byte{make([]byte, 100)}}}
+	require.Same(t, backend, NewLimitedKV(backend, KvLimits{}))
+
+	// a burst of one second of operations, then the connection is throttled while another one is served
+	kv := NewLimitedKV(backend, KvLimits{OpsPerSecond: 2})
+	for i := 0; i < 3; i++ {
+		_, err := kv.Range(connCtx(1), &remote.RangeReq{})
+		require.NoError(t, err)
+	}
+	_, err := kv.Range(connCtx(1), &remote.RangeReq{})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = kv.Range(connCtx(2), &remote.RangeReq{})
+	require.NoError(t, err)
+
+	// the reply over the bandwidth goes through, the next call waits for the debt to be paid
+	kv = NewLimitedKV(backend, KvLimits{BytesPerSecond: 50})
+	_, err = kv.Range(connCtx(1), &remote.RangeReq{})
+	require.NoError(t, err)
+	_, err = kv.Range(connCtx(1), &remote.RangeReq{})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestLimitedKVTx(t *testing.T) {
+	backend := &rangeKV{}
+	kv := NewLimitedKV(backend, KvLimits{OpsPerSecond: 20}).(*limitedKV)
+
+	// the operations over the burst are delayed, the transaction isn't ended
+	start := time.Now()
+	require.NoError(t, kv.Tx(&cursorStream{ctx: connCtx(1), n: 26}))
+	require.Equal(t, 26, backend.ops)
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	// the limiter of a connection with an open Tx stream is not swept as idle
+	l := kv.limiter(connCtx(1))
+	kv.lock.Lock()
+	l.streams++
+	l.lastUsed = time.Now().Add(-2 * idleLimiterTimeout)
+	kv.lock.Unlock()
+	kv.limiter(connCtx(2))
+	require.Same(t, l, kv.limiter(connCtx(1)))
+}
@@ -0,0 +1,86 @@
+package privateapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metrics2 "github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/common/metrics"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+)
+
+// NewMeteredKV times how long the node takes to serve each unary call and each cursor operation of the Tx streams,
+// in kv_server_seconds{op="..."} summaries of the metrics endpoint. Compared to the kv_client_seconds summaries of
+// rpcdaemon, they tell the time spent in the database from the time spent on the network.
+func NewMeteredKV(kv remote.KVServer) remote.KVServer {
+	if !metrics.Enabled {
+		return kv
+	}
+	return &meteredKV{KVServer: kv}
+}
+
+type meteredKV struct {
+	remote.KVServer
+}
+
+func kvServerTimer(op string) *metrics2.Summary {
+	return metrics2.GetOrCreateSummary(fmt.Sprintf(`kv_server_seconds{op="%s"}`, op))
+}
+
+// metered runs the unary call f, timing it under op.
+func metered[T any](op string, f func() (T, error)) (T, error) {
+	start := time.Now()
+	reply, err := f()
+	kvServerTimer(op).UpdateDuration(start)
+	return reply, err
+}
+
+func (s *meteredKV) Tx(stream remote.KV_TxServer) error {
+	return s.KVServer.Tx(&meteredTxStream{KV_TxServer: stream})
+}
+
+func (s *meteredKV) Snapshots(ctx context.Context, req *remote.SnapshotsRequest) (*remote.SnapshotsReply, error) {
+	return metered("Snapshots", func() (*remote.SnapshotsReply, error) { return s.KVServer.Snapshots(ctx, req) })
+}
+
+func (s *meteredKV) Range(ctx context.Context, req *remote.RangeReq) (*remote.Pairs, error) {
+	return metered("Range", func() (*remote.Pairs, error) { return s.KVServer.Range(ctx, req) })
+}
+
+func (s *meteredKV) DomainGet(ctx context.Context, req *remote.DomainGetReq) (*remote.DomainGetReply, error) {
+	return metered("DomainGet", func() (*remote.DomainGetReply, error) { return s.KVServer.DomainGet(ctx, req) })
+}
+
+func (s *meteredKV) HistoryGet(ctx context.Context, req *remote.HistoryGetReq) (*remote.HistoryGetReply, error) {
+	return metered("HistoryGet", func() (*remote.HistoryGetReply, error) { return s.KVServer.HistoryGet(ctx, req) })
+}
+
+func (s *meteredKV) IndexRange(ctx context.Context, req *remote.IndexRangeReq) (*remote.IndexRangeReply, error) {
+	return metered("IndexRange", func() (*remote.IndexRangeReply, error) { return s.KVServer.IndexRange(ctx, req) })
+}
+
+// meteredTxStream times each operation from its message to its reply. The server replies to every operation before
+// reading the next one.
+type meteredTxStream struct {
+	remote.KV_TxServer
+	op    remote.Op
+	start time.Time
+}
+
+func (s *meteredTxStream) Recv() (*remote.Cursor, error) {
+	in, err := s.KV_TxServer.Recv()
+	if err != nil {
+		return nil, err
+	}
+	s.op, s.start = in.Op, time.Now()
+	return in, nil
+}
+
+func (s *meteredTxStream) Send(m *remote.Pair) error {
+	if !s.start.IsZero() {
+		kvServerTimer(s.op.String()).UpdateDuration(s.start)
+		s.start = time.Time{}
+	}
+	return s.KV_TxServer.Send(m)
+}
@@ -0,0 +1,33 @@
+package privateapi
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	metrics2 "github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeteredTxStream(t *testing.T) {
+	stream := &meteredTxStream{KV_TxServer: &testTxStream{in: []*remote.Cursor{
+		{Op: remote.Op_OPEN, BucketName: "Header"},
+		{Op: remote.Op_SEEK_EXACT, Cursor: 1, K: []byte{1}},
+	}}}
+	// the transaction id is sent before any operation
+	require.NoError(t, stream.Send(&remote.Pair{TxID: 1}))
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&remote.Pair{}))
+	}
+	var out bytes.Buffer
+	metrics2.WritePrometheus(&out, false)
+	require.Contains(t, out.String(), `kv_server_seconds_count{op="OPEN"} 1`)
+	require.Contains(t, out.String(), `kv_server_seconds_count{op="SEEK_EXACT"} 1`)
+	require.NotContains(t, out.String(), `kv_server_seconds_count{op="FIRST"}`)
+}
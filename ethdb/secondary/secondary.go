@@ -0,0 +1,252 @@
+// Package secondary maintains index tables derived from a primary table: writes go through a Table, which updates
+// the indexes in the same transaction, and existing data is indexed with Backfill or Rebuild.
+package secondary
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// KeyFunc returns the secondary keys of a primary entry, an entry may have none or several.
+type KeyFunc func(k, v []byte) ([][]byte, error)
+
+// Index is a DupSort table mapping the secondary keys of the entries of Primary to their primary keys.
+type Index struct {
+	Name    string
+	Primary string
+	Keys    KeyFunc
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]*Index{}
+	tables       = map[string]kv.TableCfgItem{}
+)
+
+// Register makes index known to TablesCfg and to the `erigon db index` commands, usually from an init function.
+func Register(index *Index) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[index.Name]; ok {
+		panic(fmt.Sprintf("secondary index %s registered twice", index.Name))
+	}
+	registry[index.Name] = index
+}
+
+// RegisterTable makes a derived table which isn't a secondary index known to TablesCfg, like the indexes that stages
+// maintain outside of the tables of erigon-lib.
+func RegisterTable(name string, item kv.TableCfgItem) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := tables[name]; ok {
+		panic(fmt.Sprintf("table %s registered twice", name))
+	}
+	tables[name] = item
+}
+
+// Get returns the registered index called name, nil if there is none.
+func Get(name string) *Index {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return registry[name]
+}
+
+// Registered returns the registered indexes, sorted by name.
+func Registered() []*Index {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	indexes := make([]*Index, 0, len(registry))
+	for _, index := range registry {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes
+}
+
+// TablesCfg adds the tables of the registered indexes and the registered tables to defaultBuckets, to be used with
+// mdbx WithTableCfg.
+func TablesCfg(defaultBuckets kv.TableCfg) kv.TableCfg {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	if len(registry) == 0 && len(tables) == 0 {
+		return defaultBuckets
+	}
+	cfg := make(kv.TableCfg, len(defaultBuckets)+len(registry)+len(tables))
+	for name, item := range defaultBuckets {
+		cfg[name] = item
+	}
+	for name, item := range tables {
+		cfg[name] = item
+	}
+	for name := range registry {
+		cfg[name] = kv.TableCfgItem{Flags: kv.DupSort}
+	}
+	return cfg
+}
+
+// ReadonlyTablesCfg is TablesCfg for a database opened read-only, which can't create tables: the registered tables
+// are only opened if they exist, like the deprecated tables of erigon-lib, and kv.BucketMigrator.ExistsBucket
+// reports whether they do.
+func ReadonlyTablesCfg(defaultBuckets kv.TableCfg) kv.TableCfg {
+	cfg := TablesCfg(defaultBuckets)
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for name := range registry {
+		item := cfg[name]
+		item.IsDeprecated = true
+		cfg[name] = item
+	}
+	for name := range tables {
+		item := cfg[name]
+		item.IsDeprecated = true
+		cfg[name] = item
+	}
+	return cfg
+}
+
+// Lookup calls walker with the primary keys of the entries indexed under key.
+func (i *Index) Lookup(tx kv.Tx, key []byte, walker func(primaryKey []byte) error) error {
+	c, err := tx.CursorDupSort(i.Name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for k, v, err := c.SeekExact(key); k != nil; k, v, err = c.NextDup() {
+		if err != nil {
+			return err
+		}
+		if err := walker(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill indexes the entries of the primary table from key from on. Entries already indexed are left as is.
+func (i *Index) Backfill(ctx context.Context, logPrefix string, tx kv.RwTx, from []byte) error {
+	logEvery := time.NewTicker(30 * time.Second)
+	defer logEvery.Stop()
+	var indexed uint64
+	if err := tx.ForEach(i.Primary, from, func(k, v []byte) error {
+		select {
+		case <-ctx.Done():
+			return libcommon.ErrStopped
+		case <-logEvery.C:
+			log.Info(fmt.Sprintf("[%s] Backfilling %s", logPrefix, i.Name), "key", fmt.Sprintf("%x", k), "entries", indexed)
+		default:
+		}
+		indexed++
+		return i.add(tx, k, v)
+	}); err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("[%s] Backfilled %s", logPrefix, i.Name), "entries", indexed)
+	return nil
+}
+
+// Rebuild empties the index and indexes the whole primary table again.
+func (i *Index) Rebuild(ctx context.Context, logPrefix string, tx kv.RwTx) error {
+	if err := tx.ClearBucket(i.Name); err != nil {
+		return err
+	}
+	return i.Backfill(ctx, logPrefix, tx, nil)
+}
+
+func (i *Index) add(tx kv.RwTx, k, v []byte) error {
+	keys, err := i.Keys(k, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Name, err)
+	}
+	for _, key := range keys {
+		if err := tx.Put(i.Name, key, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Index) remove(tx kv.RwTx, k, v []byte) error {
+	keys, err := i.Keys(k, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Name, err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	c, err := tx.RwCursorDupSort(i.Name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for _, key := range keys {
+		if err := c.DeleteExact(key, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Table writes to a primary table and keeps its indexes up to date within the same transaction.
+type Table struct {
+	name    string
+	indexes []*Index
+}
+
+func NewTable(name string, indexes ...*Index) *Table {
+	for _, index := range indexes {
+		if index.Primary != name {
+			panic(fmt.Sprintf("secondary index %s is over %s, not %s", index.Name, index.Primary, name))
+		}
+	}
+	return &Table{name: name, indexes: indexes}
+}
+
+// Name returns the name of the primary table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Put writes k and replaces the index entries of its previous value with those of v.
+func (t *Table) Put(tx kv.RwTx, k, v []byte) error {
+	if err := t.unindex(tx, k); err != nil {
+		return err
+	}
+	if err := tx.Put(t.name, k, v); err != nil {
+		return err
+	}
+	for _, index := range t.indexes {
+		if err := index.add(tx, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes k and its index entries.
+func (t *Table) Delete(tx kv.RwTx, k []byte) error {
+	if err := t.unindex(tx, k); err != nil {
+		return err
+	}
+	return tx.Delete(t.name, k)
+}
+
+func (t *Table) unindex(tx kv.RwTx, k []byte) error {
+	old, err := tx.GetOne(t.name, k)
+	if err != nil || old == nil {
+		return err
+	}
+	old = libcommon.Copy(old) // removing the index entries may move the pages of the transaction
+	for _, index := range t.indexes {
+		if err := index.remove(tx, k, old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
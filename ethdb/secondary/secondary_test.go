@@ -0,0 +1,128 @@
+package secondary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPrimary = "TestPrimary"
+	testIndex   = "TestByValue"
+)
+
+// byValue indexes the entries under each byte of their value
+var byValue = &Index{Name: testIndex, Primary: testPrimary, Keys: func(k, v []byte) ([][]byte, error) {
+	keys := make([][]byte, len(v))
+	for i := range v {
+		keys[i] = v[i : i+1]
+	}
+	return keys, nil
+}}
+
+func newTestTx(t *testing.T) kv.RwTx {
+	db := mdbx.NewMDBX(log.New()).InMem(t.TempDir()).WithTableCfg(func(kv.TableCfg) kv.TableCfg {
+		return kv.TableCfg{testPrimary: {}, testIndex: {Flags: kv.DupSort}}
+	}).MustOpen()
+	t.Cleanup(db.Close)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(tx.Rollback)
+	return tx
+}
+
+func lookup(t *testing.T, tx kv.Tx, key string) (primaryKeys []string) {
+	require.NoError(t, byValue.Lookup(tx, []byte(key), func(primaryKey []byte) error {
+		primaryKeys = append(primaryKeys, string(primaryKey))
+		return nil
+	}))
+	return
+}
+
+func TestTable(t *testing.T) {
+	tx := newTestTx(t)
+	table := NewTable(testPrimary, byValue)
+
+	require.NoError(t, table.Put(tx, []byte("k1"), []byte("ab")))
+	require.NoError(t, table.Put(tx, []byte("k2"), []byte("bc")))
+	require.Equal(t, []string{"k1"}, lookup(t, tx, "a"))
+	require.Equal(t, []string{"k1", "k2"}, lookup(t, tx, "b"))
+
+	// the index entries of the previous value go away
+	require.NoError(t, table.Put(tx, []byte("k1"), []byte("c")))
+	require.Nil(t, lookup(t, tx, "a"))
+	require.Equal(t, []string{"k2"}, lookup(t, tx, "b"))
+	require.Equal(t, []string{"k1", "k2"}, lookup(t, tx, "c"))
+
+	require.NoError(t, table.Delete(tx, []byte("k2")))
+	require.Nil(t, lookup(t, tx, "b"))
+	require.Equal(t, []string{"k1"}, lookup(t, tx, "c"))
+}
+
+func TestBackfillAndRebuild(t *testing.T) {
+	tx := newTestTx(t)
+	require.NoError(t, tx.Put(testPrimary, []byte("k1"), []byte("a")))
+	require.NoError(t, tx.Put(testPrimary, []byte("k2"), []byte("ab")))
+	require.NoError(t, tx.Put(testPrimary, []byte("k3"), []byte("b")))
+
+	require.NoError(t, byValue.Backfill(context.Background(), "test", tx, []byte("k2")))
+	require.Equal(t, []string{"k2"}, lookup(t, tx, "a"))
+	require.Equal(t, []string{"k2", "k3"}, lookup(t, tx, "b"))
+
+	// indexing twice is harmless
+	require.NoError(t, byValue.Backfill(context.Background(), "test", tx, nil))
+	require.Equal(t, []string{"k1", "k2"}, lookup(t, tx, "a"))
+	require.Equal(t, []string{"k2", "k3"}, lookup(t, tx, "b"))
+
+	require.NoError(t, tx.Put(testIndex, []byte("z"), []byte("stale")))
+	require.NoError(t, byValue.Rebuild(context.Background(), "test", tx))
+	require.Nil(t, lookup(t, tx, "z"))
+	require.Equal(t, []string{"k1", "k2"}, lookup(t, tx, "a"))
+}
+
+func TestRegistry(t *testing.T) {
+	Register(byValue)
+	defer func() {
+		registryLock.Lock()
+		delete(registry, testIndex)
+		registryLock.Unlock()
+	}()
+	require.Same(t, byValue, Get(testIndex))
+	require.Equal(t, []*Index{byValue}, Registered())
+	require.Panics(t, func() { Register(byValue) })
+
+	cfg := TablesCfg(kv.TableCfg{testPrimary: {}})
+	require.Equal(t, kv.TableCfg{testPrimary: {}, testIndex: {Flags: kv.DupSort}}, cfg)
+}
+
+func TestReadonlyTablesCfg(t *testing.T) {
+	const testTable = "TestDerived"
+	RegisterTable(testTable, kv.TableCfgItem{})
+	defer func() {
+		registryLock.Lock()
+		delete(tables, testTable)
+		registryLock.Unlock()
+	}()
+	require.Panics(t, func() { RegisterTable(testTable, kv.TableCfgItem{}) })
+	require.Equal(t, kv.TableCfg{testPrimary: {}, testTable: {}}, TablesCfg(kv.TableCfg{testPrimary: {}}))
+
+	// a database created before the table was registered can still be opened read-only
+	dir := t.TempDir()
+	mdbx.NewMDBX(log.New()).Path(dir).WithTableCfg(func(kv.TableCfg) kv.TableCfg {
+		return kv.TableCfg{testPrimary: {}}
+	}).MustOpen().Close()
+	db := mdbx.NewMDBX(log.New()).Path(dir).Readonly().WithTableCfg(func(kv.TableCfg) kv.TableCfg {
+		return ReadonlyTablesCfg(kv.TableCfg{testPrimary: {}})
+	}).MustOpen()
+	defer db.Close()
+	require.NoError(t, db.View(context.Background(), func(tx kv.Tx) error {
+		exists, err := tx.(kv.BucketMigrator).ExistsBucket(testTable)
+		require.NoError(t, err)
+		require.False(t, exists)
+		return nil
+	}))
+}
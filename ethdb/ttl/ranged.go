@@ -0,0 +1,136 @@
+package ttl
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// RangedTable is a table whose keys start with their 8 bytes big-endian unix-nano expiration, so that the
+// expired entries are always a range at the start of the table. Sweep deletes this range and stops at the first
+// live key, without reading the rest of the table like Table.Sweep does. It suits ephemeral data which is written
+// once and read in bulk (recent peer data, temporary caches); a key can't be looked up without its expiration.
+type RangedTable struct {
+	name string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewRanged creates an expiring view over table. The table must be part of the database TableCfg.
+func NewRanged(table string, ttl time.Duration) *RangedTable {
+	return &RangedTable{
+		name: table,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Name returns the name of the underlying table.
+func (t *RangedTable) Name() string {
+	return t.name
+}
+
+// Put writes k with the default time-to-live, and returns the key it is stored under.
+func (t *RangedTable) Put(tx kv.Putter, k, v []byte) ([]byte, error) {
+	return t.PutWithTTL(tx, k, v, t.ttl)
+}
+
+// PutWithTTL writes k so that it expires after ttl, and returns the key it is stored under.
+func (t *RangedTable) PutWithTTL(tx kv.Putter, k, v []byte, ttl time.Duration) ([]byte, error) {
+	key := encodeValue(t.now().Add(ttl), k)
+	return key, tx.Put(t.name, key, v)
+}
+
+// ForEach iterates over the non-expired entries, in order of expiration.
+func (t *RangedTable) ForEach(tx kv.Tx, walker func(k, v []byte, expiry time.Time) error) error {
+	c, err := tx.Cursor(t.name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	for key, v, err := c.Seek(t.nowKey()); key != nil; key, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		expiry, k, ok := decodeValue(key)
+		if !ok {
+			continue
+		}
+		if err := walker(k, v, expiry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep deletes the expired entries and returns how many were removed.
+func (t *RangedTable) Sweep(tx kv.RwTx) (int, error) {
+	c, err := tx.RwCursor(t.name)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	now := t.nowKey()
+	deleted := 0
+	for k, _, err := c.First(); k != nil; k, _, err = c.Next() {
+		if err != nil {
+			return deleted, err
+		}
+		if bytes.Compare(k, now) >= 0 {
+			break
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// nowKey is the smallest key which is not expired.
+func (t *RangedTable) nowKey() []byte {
+	var k [expiryLength]byte
+	binary.BigEndian.PutUint64(k[:], uint64(t.now().UnixNano())+1)
+	return k[:]
+}
+
+// Sweeper is a table of which the expired entries are deleted by Sweep, Table and RangedTable are sweepers.
+type Sweeper interface {
+	Name() string
+	Sweep(tx kv.RwTx) (int, error)
+}
+
+// RunJanitor sweeps the designated tables every interval, in one transaction, until ctx is cancelled.
+func RunJanitor(ctx context.Context, db kv.RwDB, interval time.Duration, tables ...Sweeper) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted := make([]int, len(tables))
+			if err := db.Update(ctx, func(tx kv.RwTx) error {
+				for i, table := range tables {
+					var err error
+					if deleted[i], err = table.Sweep(tx); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				log.Warn("[ttl] sweep failed", "err", err)
+				continue
+			}
+			for i, table := range tables {
+				if deleted[i] > 0 {
+					log.Trace("[ttl] swept expired entries", "table", table.Name(), "count", deleted[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ttl
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// expiryLength is the size of the expiration timestamp prepended to each value.
+const expiryLength = 8
+
+// DefaultSweepInterval is how often the background sweeper purges expired entries.
+const DefaultSweepInterval = 10 * time.Minute
+
+// Table wraps a regular kv table so that every entry expires after a time-to-live.
+// Values are stored with an 8 bytes big-endian unix-nano expiration prefix.
+// Expired entries are hidden on read (lazy expiry) and physically removed by Sweep,
+// which can be run periodically with Run. This saves components keeping ephemeral
+// data (seen gossip ids, bad block markers, response caches) from writing their own cleanup loops.
+type Table struct {
+	name string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// New creates a TTL view over table. The table must be part of the database TableCfg.
+func New(table string, ttl time.Duration) *Table {
+	return &Table{
+		name: table,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Name returns the name of the underlying table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// TTL returns the default time-to-live of entries.
+func (t *Table) TTL() time.Duration {
+	return t.ttl
+}
+
+// Put writes k with the default time-to-live.
+func (t *Table) Put(tx kv.Putter, k, v []byte) error {
+	return t.PutWithTTL(tx, k, v, t.ttl)
+}
+
+// PutWithTTL writes k so that it expires after ttl.
+func (t *Table) PutWithTTL(tx kv.Putter, k, v []byte, ttl time.Duration) error {
+	return tx.Put(t.name, k, encodeValue(t.now().Add(ttl), v))
+}
+
+// Get returns the value of k, or nil if it is missing or expired.
+func (t *Table) Get(tx kv.Getter, k []byte) ([]byte, error) {
+	enc, err := tx.GetOne(t.name, k)
+	if err != nil {
+		return nil, err
+	}
+	expiry, v, ok := decodeValue(enc)
+	if !ok || t.expired(expiry) {
+		return nil, nil
+	}
+	return v, nil
+}
+
+// Has returns whether a non-expired entry exists for k.
+func (t *Table) Has(tx kv.Getter, k []byte) (bool, error) {
+	enc, err := tx.GetOne(t.name, k)
+	if err != nil {
+		return false, err
+	}
+	expiry, _, ok := decodeValue(enc)
+	return ok && !t.expired(expiry), nil
+}
+
+// Delete removes k regardless of its expiration.
+func (t *Table) Delete(tx kv.Deleter, k []byte) error {
+	return tx.Delete(t.name, k)
+}
+
+// ForEach iterates over all non-expired entries starting from fromPrefix.
+func (t *Table) ForEach(tx kv.Getter, fromPrefix []byte, walker func(k, v []byte) error) error {
+	return tx.ForEach(t.name, fromPrefix, func(k, enc []byte) error {
+		expiry, v, ok := decodeValue(enc)
+		if !ok || t.expired(expiry) {
+			return nil
+		}
+		return walker(k, v)
+	})
+}
+
+// Sweep deletes all expired (or malformed) entries and returns how many were removed.
+func (t *Table) Sweep(tx kv.RwTx) (int, error) {
+	c, err := tx.RwCursor(t.name)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	deleted := 0
+	for k, enc, err := c.First(); k != nil; k, enc, err = c.Next() {
+		if err != nil {
+			return deleted, err
+		}
+		expiry, _, ok := decodeValue(enc)
+		if ok && !t.expired(expiry) {
+			continue
+		}
+		if err := c.DeleteCurrent(); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Run sweeps the table every interval until ctx is cancelled.
+func (t *Table) Run(ctx context.Context, db kv.RwDB, interval time.Duration) {
+	RunJanitor(ctx, db, interval, t)
+}
+
+func (t *Table) expired(expiry time.Time) bool {
+	return !t.now().Before(expiry)
+}
+
+func encodeValue(expiry time.Time, v []byte) []byte {
+	enc := make([]byte, expiryLength+len(v))
+	binary.BigEndian.PutUint64(enc, uint64(expiry.UnixNano()))
+	copy(enc[expiryLength:], v)
+	return enc
+}
+
+func decodeValue(enc []byte) (time.Time, []byte, bool) {
+	if len(enc) < expiryLength {
+		return time.Time{}, nil, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(enc))), enc[expiryLength:], true
+}
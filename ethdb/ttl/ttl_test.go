@@ -0,0 +1,152 @@
+package ttl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const testTable = "TestTTL"
+
+func newTestDB(t *testing.T) kv.RwDB {
+	db := mdbx.NewMDBX(log.New()).InMem(t.TempDir()).WithTableCfg(func(kv.TableCfg) kv.TableCfg {
+		return kv.TableCfg{testTable: {}}
+	}).MustOpen()
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestLazyExpiry(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Unix(1000, 0)
+	table := New(testTable, time.Minute)
+	table.now = func() time.Time { return now }
+
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	require.NoError(t, table.Put(tx, []byte("a"), []byte("1")))
+	require.NoError(t, table.PutWithTTL(tx, []byte("b"), []byte("2"), time.Hour))
+
+	v, err := table.Get(tx, []byte("a"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), v)
+
+	now = now.Add(2 * time.Minute)
+	v, err = table.Get(tx, []byte("a"))
+	require.NoError(t, err)
+	require.Nil(t, v)
+	has, err := table.Has(tx, []byte("a"))
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = table.Has(tx, []byte("b"))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	var keys []string
+	require.NoError(t, table.ForEach(tx, nil, func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	}))
+	require.Equal(t, []string{"b"}, keys)
+}
+
+func TestSweep(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Unix(1000, 0)
+	table := New(testTable, time.Minute)
+	table.now = func() time.Time { return now }
+
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for _, k := range []string{"a", "b", "c"} {
+		require.NoError(t, table.Put(tx, []byte(k), []byte(k)))
+	}
+	require.NoError(t, table.PutWithTTL(tx, []byte("d"), []byte("d"), time.Hour))
+	// Malformed values are garbage collected too.
+	require.NoError(t, tx.Put(testTable, []byte("e"), []byte{1}))
+
+	now = now.Add(2 * time.Minute)
+	deleted, err := table.Sweep(tx)
+	require.NoError(t, err)
+	require.Equal(t, 4, deleted)
+
+	var left []string
+	require.NoError(t, tx.ForEach(testTable, nil, func(k, _ []byte) error {
+		left = append(left, string(k))
+		return nil
+	}))
+	require.Equal(t, []string{"d"}, left)
+}
+
+func TestRangedSweep(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Unix(1000, 0)
+	table := NewRanged(testTable, time.Minute)
+	table.now = func() time.Time { return now }
+
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = table.PutWithTTL(tx, []byte("late"), []byte("1"), time.Hour)
+	require.NoError(t, err)
+	for _, k := range []string{"a", "b"} {
+		_, err = table.Put(tx, []byte(k), []byte(k))
+		require.NoError(t, err)
+	}
+	now = now.Add(30 * time.Second)
+	_, err = table.Put(tx, []byte("c"), []byte("c"))
+	require.NoError(t, err)
+
+	now = now.Add(40 * time.Second)
+	var keys []string
+	require.NoError(t, table.ForEach(tx, func(k, v []byte, expiry time.Time) error {
+		keys = append(keys, string(k))
+		return nil
+	}))
+	require.Equal(t, []string{"c", "late"}, keys)
+
+	deleted, err := table.Sweep(tx)
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	require.Equal(t, 2, countEntries(t, tx))
+}
+
+func TestRunJanitor(t *testing.T) {
+	db := newTestDB(t)
+	table := NewRanged(testTable, time.Millisecond)
+	require.NoError(t, db.Update(context.Background(), func(tx kv.RwTx) error {
+		_, err := table.Put(tx, []byte("a"), []byte("a"))
+		return err
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunJanitor(ctx, db, time.Millisecond, table)
+	require.Eventually(t, func() bool {
+		var count int
+		require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+			count = countEntries(t, tx)
+			return nil
+		}))
+		return count == 0
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func countEntries(t *testing.T, tx kv.Tx) int {
+	count := 0
+	require.NoError(t, tx.ForEach(testTable, nil, func(_, _ []byte) error {
+		count++
+		return nil
+	}))
+	return count
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common/datadir"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -30,6 +31,11 @@ import (
 //   - in the end:drop old bucket (not in defer!).
 //   - if you need migrate multiple buckets - create separate migration for each bucket
 //   - write test - and check that it's safe to apply same migration twice
+//
+// A migration changing the format of a table in place can set Table and Version - the DB then records the version of
+// the table, and Erigon versions whose migrations stop at a lower version of it refuse to open the DB - and a Down,
+// which `integration revert_migrations` runs. Releases from before table versions don't check them: a format they must
+// not read also needs a bump of the DB schema version.
 var migrations = map[kv.Label][]Migration{
 	kv.ChainDB: {
 		dbSchemaVersion5,
@@ -44,14 +50,27 @@ type Callback func(tx kv.RwTx, progress []byte, isDone bool) error
 type Migration struct {
 	Name string
 	Up   func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) error
+
+	// Table and Version, when set, version the format of one table: applying the migration records Version as the
+	// schema version of Table, see ReadTableVersion. Versions of a table must increase in the order of migrations.
+	Table   string
+	Version uint32
+	// Down reverts Up, leaving the table in the format of the previous Version - run by Migrator.Revert before
+	// switching to an Erigon version which doesn't know this migration. Same contract as Up.
+	Down func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) error
 }
 
 var (
 	ErrMigrationNonUniqueName   = fmt.Errorf("please provide unique migration name")
 	ErrMigrationCommitNotCalled = fmt.Errorf("migration before-commit function was not called")
 	ErrMigrationETLFilesDeleted = fmt.Errorf("db migration progress was interrupted after extraction step and ETL files was deleted, please contact development team for help or re-sync from scratch")
+	ErrMigrationTableVersion    = fmt.Errorf("table versions must increase in the order of migrations")
+	ErrMigrationNoDown          = fmt.Errorf("migration can't be reverted")
 )
 
+// progressLogInterval rate-limits the logs of saved progress of a running migration
+const progressLogInterval = 30 * time.Second
+
 func NewMigrator(label kv.Label) *Migrator {
 	return &Migrator{
 		Migrations: migrations[label],
@@ -145,7 +164,7 @@ func (m *Migrator) VerifyVersion(db kv.RwDB) error {
 				}
 			}
 		}
-		return nil
+		return m.verifyTableVersions(tx)
 	}); err != nil {
 		return fmt.Errorf("migrator.VerifyVersion: %w", err)
 	}
@@ -174,14 +193,8 @@ func (m *Migrator) Apply(db kv.RwDB, dataDir string) error {
 		return fmt.Errorf("migrator.Apply: %w", err)
 	}
 
-	// migration names must be unique, protection against people's mistake
-	uniqueNameCheck := map[string]bool{}
-	for i := range m.Migrations {
-		_, ok := uniqueNameCheck[m.Migrations[i].Name]
-		if ok {
-			return fmt.Errorf("%w, duplicate: %s", ErrMigrationNonUniqueName, m.Migrations[i].Name)
-		}
-		uniqueNameCheck[m.Migrations[i].Name] = true
+	if err := m.validate(); err != nil {
+		return err
 	}
 
 	for i := range m.Migrations {
@@ -190,29 +203,8 @@ func (m *Migrator) Apply(db kv.RwDB, dataDir string) error {
 			continue
 		}
 
-		callbackCalled := false // commit function must be called if no error, protection against people's mistake
-
-		log.Info("Apply migration", "name", v.Name)
-		var progress []byte
-		if err := db.View(context.Background(), func(tx kv.Tx) (err error) {
-			progress, err = tx.GetOne(kv.Migrations, []byte("_progress_"+v.Name))
-			return err
-		}); err != nil {
-			return fmt.Errorf("migrator.Apply: %w", err)
-		}
-
-		dirs.Tmp = filepath.Join(dirs.DataDir, "migrations", v.Name)
-		if err := v.Up(db, dirs, progress, func(tx kv.RwTx, key []byte, isDone bool) error {
-			if !isDone {
-				if key != nil {
-					if err := tx.Put(kv.Migrations, []byte("_progress_"+v.Name), key); err != nil {
-						return err
-					}
-				}
-				return nil
-			}
-			callbackCalled = true
-
+		log.Info("Apply migration", "name", v.Name, "table", v.Table, "version", v.Version)
+		callbackCalled, err := run(db, dirs, v.Name, v.Up, progressKey(v.Name), func(tx kv.RwTx) error {
 			stagesProgress, err := MarshalMigrationPayload(tx)
 			if err != nil {
 				return err
@@ -221,17 +213,14 @@ func (m *Migrator) Apply(db kv.RwDB, dataDir string) error {
 			if err != nil {
 				return err
 			}
-
-			err = tx.Delete(kv.Migrations, []byte("_progress_"+v.Name))
-			if err != nil {
-				return err
+			if v.Table != "" {
+				return WriteTableVersion(tx, v.Table, v.Version)
 			}
-
 			return nil
-		}); err != nil {
+		})
+		if err != nil {
 			return fmt.Errorf("migrator.Apply.Up: %s, %w", v.Name, err)
 		}
-
 		if !callbackCalled {
 			return fmt.Errorf("%w: %s", ErrMigrationCommitNotCalled, v.Name)
 		}
@@ -254,6 +243,157 @@ func (m *Migrator) Apply(db kv.RwDB, dataDir string) error {
 	return nil
 }
 
+// Revert runs, newest first, the Down of the applied migrations of table with a Version above version, so that an
+// Erigon version which knows the table only up to version can open the DB.
+func (m *Migrator) Revert(db kv.RwDB, dataDir string, table string, version uint32) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	dirs := datadir.New(dataDir)
+
+	var applied map[string][]byte
+	if err := db.View(context.Background(), func(tx kv.Tx) (err error) {
+		applied, err = AppliedMigrations(tx, false)
+		return err
+	}); err != nil {
+		return fmt.Errorf("migrator.Revert: %w", err)
+	}
+
+	for i := len(m.Migrations) - 1; i >= 0; i-- {
+		v := m.Migrations[i]
+		if v.Table != table || v.Version <= version {
+			continue
+		}
+		if _, ok := applied[v.Name]; !ok {
+			continue
+		}
+		if v.Down == nil {
+			return fmt.Errorf("migrator.Revert: %w: %s", ErrMigrationNoDown, v.Name)
+		}
+		prev := m.previousVersion(i)
+
+		log.Info("Revert migration", "name", v.Name, "table", v.Table, "version", prev)
+		callbackCalled, err := run(db, dirs, v.Name, v.Down, progressKey("down_"+v.Name), func(tx kv.RwTx) error {
+			if err := tx.Delete(kv.Migrations, []byte(v.Name)); err != nil {
+				return err
+			}
+			if prev == 0 {
+				return tx.Delete(kv.DatabaseInfo, tableVersionKey(v.Table))
+			}
+			return WriteTableVersion(tx, v.Table, prev)
+		})
+		if err != nil {
+			return fmt.Errorf("migrator.Revert.Down: %s, %w", v.Name, err)
+		}
+		if !callbackCalled {
+			return fmt.Errorf("%w: %s", ErrMigrationCommitNotCalled, v.Name)
+		}
+		log.Info("Reverted migration", "name", v.Name)
+	}
+	return nil
+}
+
+// validate protects against people's mistakes: migration names must be unique, table versions increasing
+func (m *Migrator) validate() error {
+	uniqueNameCheck := map[string]bool{}
+	versions := map[string]uint32{}
+	for i := range m.Migrations {
+		v := m.Migrations[i]
+		if _, ok := uniqueNameCheck[v.Name]; ok {
+			return fmt.Errorf("%w, duplicate: %s", ErrMigrationNonUniqueName, v.Name)
+		}
+		uniqueNameCheck[v.Name] = true
+		if v.Table == "" {
+			continue
+		}
+		if v.Version <= versions[v.Table] {
+			return fmt.Errorf("%w: %s sets %s to version %d after version %d", ErrMigrationTableVersion, v.Name, v.Table, v.Version, versions[v.Table])
+		}
+		versions[v.Table] = v.Version
+	}
+	return nil
+}
+
+// previousVersion is the version the table of the i-th migration has before it
+func (m *Migrator) previousVersion(i int) uint32 {
+	for j := i - 1; j >= 0; j-- {
+		if m.Migrations[j].Table == m.Migrations[i].Table {
+			return m.Migrations[j].Version
+		}
+	}
+	return 0
+}
+
+// TableVersions returns the latest version of each table versioned by the migrations
+func (m *Migrator) TableVersions() map[string]uint32 {
+	versions := map[string]uint32{}
+	for i := range m.Migrations {
+		if v := m.Migrations[i]; v.Table != "" && v.Version > versions[v.Table] {
+			versions[v.Table] = v.Version
+		}
+	}
+	return versions
+}
+
+// verifyTableVersions refuses a DB with tables written in a format newer than the migrations know about
+func (m *Migrator) verifyTableVersions(tx kv.Tx) error {
+	known := m.TableVersions()
+	return tx.ForPrefix(kv.DatabaseInfo, []byte(tableVersionPrefix), func(k, v []byte) error {
+		table := string(k[len(tableVersionPrefix):])
+		if len(v) != 4 {
+			return fmt.Errorf("incorrect length of schema version of table %s: %d", table, len(v))
+		}
+		if version := binary.BigEndian.Uint32(v); version > known[table] {
+			return fmt.Errorf("cannot downgrade table %s from version %d to %d, revert its migrations with the Erigon version which applied them (integration revert_migrations)", table, version, known[table])
+		}
+		return nil
+	})
+}
+
+// run runs one direction of a migration: resumes it from the saved progress, saves the progress it reports and
+// calls onDone in the transaction it commits with
+func run(db kv.RwDB, dirs datadir.Dirs, name string, f func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) error, progressKey []byte, onDone func(tx kv.RwTx) error) (callbackCalled bool, err error) {
+	var progress []byte
+	if err := db.View(context.Background(), func(tx kv.Tx) (err error) {
+		progress, err = tx.GetOne(kv.Migrations, progressKey)
+		progress = common.CopyBytes(progress)
+		return err
+	}); err != nil {
+		return false, err
+	}
+	if progress != nil {
+		log.Info("Resume migration", "name", name)
+	}
+
+	var lastLog time.Time
+	dirs.Tmp = filepath.Join(dirs.DataDir, "migrations", name)
+	err = f(db, dirs, progress, func(tx kv.RwTx, key []byte, isDone bool) error {
+		if !isDone {
+			if key != nil {
+				if err := tx.Put(kv.Migrations, progressKey, key); err != nil {
+					return err
+				}
+				if time.Since(lastLog) > progressLogInterval {
+					lastLog = time.Now()
+					log.Info("Migration progress saved", "name", name, "key", fmt.Sprintf("%x", key))
+				}
+			}
+			return nil
+		}
+		callbackCalled = true // commit function must be called if no error, protection against people's mistake
+
+		if err := onDone(tx); err != nil {
+			return err
+		}
+		return tx.Delete(kv.Migrations, progressKey)
+	})
+	return callbackCalled, err
+}
+
+func progressKey(name string) []byte {
+	return []byte("_progress_" + name)
+}
+
 func MarshalMigrationPayload(db kv.Getter) ([]byte, error) {
 	s := map[string][]byte{}
 
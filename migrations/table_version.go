@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+)
+
+// tableVersionPrefix prefixes, in kv.DatabaseInfo, the keys of the schema versions of tables
+const tableVersionPrefix = "tableVersion_"
+
+func tableVersionKey(table string) []byte {
+	return []byte(tableVersionPrefix + table)
+}
+
+// ReadTableVersion returns the schema version of table recorded by the migrations, 0 if none was applied
+func ReadTableVersion(tx kv.Getter, table string) (uint32, error) {
+	v, err := tx.GetOne(kv.DatabaseInfo, tableVersionKey(table))
+	if err != nil {
+		return 0, err
+	}
+	if len(v) == 0 {
+		return 0, nil
+	}
+	if len(v) != 4 {
+		return 0, fmt.Errorf("incorrect length of schema version of table %s: %d", table, len(v))
+	}
+	return binary.BigEndian.Uint32(v), nil
+}
+
+func WriteTableVersion(tx kv.Putter, table string, version uint32) error {
+	var v [4]byte
+	binary.BigEndian.PutUint32(v[:], version)
+	return tx.Put(kv.DatabaseInfo, tableVersionKey(table), v[:])
+}
@@ -0,0 +1,135 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+// formatMigration stores the format of kv.Code under the key "format", as its versions would
+func formatMigration(version uint32) Migration {
+	write := func(format uint32) func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) error {
+		return func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) error {
+			tx, err := db.BeginRw(context.Background())
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			if err := tx.Put(kv.Code, []byte("format"), []byte(fmt.Sprintf("v%d", format))); err != nil {
+				return err
+			}
+			if err := BeforeCommit(tx, nil, true); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}
+	}
+	return Migration{
+		Name:    fmt.Sprintf("code_v%d", version),
+		Table:   kv.Code,
+		Version: version,
+		Up:      write(version),
+		Down:    write(version - 1),
+	}
+}
+
+func requireTable(t *testing.T, db kv.RwDB, version uint32, format string) {
+	require.NoError(t, db.View(context.Background(), func(tx kv.Tx) error {
+		v, err := ReadTableVersion(tx, kv.Code)
+		require.NoError(t, err)
+		require.Equal(t, version, v)
+		f, err := tx.GetOne(kv.Code, []byte("format"))
+		require.NoError(t, err)
+		require.Equal(t, format, string(f))
+		return nil
+	}))
+}
+
+func TestTableVersion(t *testing.T) {
+	require, db := require.New(t), memdb.NewTestDB(t)
+	migrator := NewMigrator(kv.ChainDB)
+	migrator.Migrations = []Migration{formatMigration(1), formatMigration(2)}
+	require.NoError(migrator.Apply(db, ""))
+	requireTable(t, db, 2, "v2")
+	require.Equal(map[string]uint32{kv.Code: 2}, migrator.TableVersions())
+
+	// a binary knowing only version 1 refuses the DB
+	older := NewMigrator(kv.ChainDB)
+	older.Migrations = migrator.Migrations[:1]
+	require.ErrorContains(older.VerifyVersion(db), "cannot downgrade table")
+
+	require.NoError(migrator.Revert(db, "", kv.Code, 1))
+	requireTable(t, db, 1, "v1")
+	require.NoError(older.VerifyVersion(db))
+
+	require.NoError(migrator.Revert(db, "", kv.Code, 0))
+	requireTable(t, db, 0, "v0")
+
+	// and they apply again
+	require.NoError(migrator.Apply(db, ""))
+	requireTable(t, db, 2, "v2")
+
+	noDown := NewMigrator(kv.ChainDB)
+	noDown.Migrations = []Migration{formatMigration(1), formatMigration(2)}
+	noDown.Migrations[1].Down = nil
+	require.True(errors.Is(noDown.Revert(db, "", kv.Code, 1), ErrMigrationNoDown))
+	requireTable(t, db, 2, "v2")
+}
+
+func TestTableVersionValidation(t *testing.T) {
+	require, db := require.New(t), memdb.NewTestDB(t)
+	migrator := NewMigrator(kv.ChainDB)
+	migrator.Migrations = []Migration{formatMigration(2), formatMigration(1)}
+	require.True(errors.Is(migrator.Apply(db, ""), ErrMigrationTableVersion))
+	requireTable(t, db, 0, "")
+}
+
+func TestResume(t *testing.T) {
+	require, db := require.New(t), memdb.NewTestDB(t)
+	interrupted := errors.New("interrupted")
+	var resumedFrom []byte
+	m := Migration{
+		Name: "resumable",
+		Up: func(db kv.RwDB, dirs datadir.Dirs, progress []byte, BeforeCommit Callback) (err error) {
+			tx, err := db.BeginRw(context.Background())
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+
+			if progress == nil {
+				if err := BeforeCommit(tx, []byte{1}, false); err != nil {
+					return err
+				}
+				if err := tx.Commit(); err != nil {
+					return err
+				}
+				return interrupted
+			}
+			resumedFrom = progress
+			if err := BeforeCommit(tx, nil, true); err != nil {
+				return err
+			}
+			return tx.Commit()
+		},
+	}
+	migrator := NewMigrator(kv.ChainDB)
+	migrator.Migrations = []Migration{m}
+	require.True(errors.Is(migrator.Apply(db, ""), interrupted))
+	require.NoError(migrator.Apply(db, ""))
+	require.Equal([]byte{1}, resumedFrom)
+
+	require.NoError(db.View(context.Background(), func(tx kv.Tx) error {
+		progress, err := tx.GetOne(kv.Migrations, progressKey(m.Name))
+		require.NoError(err)
+		require.Nil(progress)
+		return nil
+	}))
+}
@@ -36,6 +36,9 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/ethdb/geometry"
+	"github.com/ledgerwatch/erigon/ethdb/kvmetrics"
+	"github.com/ledgerwatch/erigon/ethdb/secondary"
 	"github.com/ledgerwatch/erigon/migrations"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/log/v3"
@@ -319,6 +322,10 @@ func OpenDatabase(config *nodecfg.Config, logger log.Logger, label kv.Label) (kv
 	dbPath := filepath.Join(config.Dirs.DataDir, name)
 	var openFunc func(exclusive bool) (kv.RwDB, error)
 	log.Info("Opening Database", "label", name, "path", dbPath)
+	var g geometry.Geometry
+	if label == kv.ChainDB {
+		g = geometry.Tune(dbPath, geometry.Overrides{PageSize: config.MdbxPageSize, GrowthStep: config.MdbxGrowthStep})
+	}
 	openFunc = func(exclusive bool) (kv.RwDB, error) {
 		roTxLimit := int64(32)
 		if config.Http.DBReadConcurrency > 0 {
@@ -332,7 +339,7 @@ func OpenDatabase(config *nodecfg.Config, logger log.Logger, label kv.Label) (kv
 			opts = opts.Exclusive()
 		}
 		if label == kv.ChainDB {
-			opts = opts.PageSize(config.MdbxPageSize.Bytes()).MapSize(8 * datasize.TB)
+			opts = opts.PageSize(g.PageSize.Bytes()).GrowthStep(g.GrowthStep).MapSize(8 * datasize.TB).WithTableCfg(secondary.TablesCfg)
 		} else {
 			opts = opts.GrowthStep(16 * datasize.MB)
 		}
@@ -374,6 +381,9 @@ func OpenDatabase(config *nodecfg.Config, logger log.Logger, label kv.Label) (kv
 	}); err != nil {
 		return nil, err
 	}
+	if config.DatabaseMetrics && label == kv.ChainDB {
+		db = kvmetrics.Wrap(db)
+	}
 
 	return db, nil
 }
@@ -142,11 +142,18 @@ type Config struct {
 	Log log.Logger `toml:",omitempty"`
 
 	DatabaseVerbosity kv.DBVerbosityLvl
+	// DatabaseMetrics counts the operations on the tables of chaindata, see kvmetrics
+	DatabaseMetrics bool
 
 	// Address to listen to when launchig listener for remote database access
 	// empty string means not to start the listener
 	PrivateApiAddr      string
 	PrivateApiRateLimit uint32
+	// Limits of each client connection of the remote database, zero means unlimited
+	PrivateApiKvOpsLimit   float64
+	PrivateApiKvBytesLimit datasize.ByteSize
+	// Fraction of the remote database transactions and calls logged with their client, zero disables it
+	PrivateApiAuditRate float64
 
 	staticNodesWarning  bool
 	trustedNodesWarning bool
@@ -160,7 +167,9 @@ type Config struct {
 	TLSKeyFile          string
 	TLSCACert           string
 
-	MdbxPageSize datasize.ByteSize
+	// MdbxPageSize and MdbxGrowthStep override the geometry of chaindata tuned to its disk, 0 for auto
+	MdbxPageSize   datasize.ByteSize
+	MdbxGrowthStep datasize.ByteSize
 
 	// HealthCheck enables standard grpc health check
 	HealthCheck bool
@@ -679,6 +679,7 @@ func (t *UDPv4) verifyPing(h *packetHandlerV4, from *net.UDPAddr, fromID enode.I
 	if err != nil {
 		return err
 	}
+	observeExpiration(req.Expiration)
 	if v4wire.Expired(req.Expiration) {
 		return errExpired
 	}
@@ -2,14 +2,22 @@ package p2p
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path"
+	"strings"
+
+	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/crypto/atrest"
 )
 
 type NodeKeyConfig struct {
+	// Encryption, if set, keeps the node key file encrypted. Plaintext key files are still loaded
+	// and get encrypted when they live in the datadir.
+	Encryption atrest.KeyProvider
 }
 
 func (config NodeKeyConfig) DefaultPath(datadir string) string {
@@ -33,17 +41,48 @@ func (config NodeKeyConfig) parseHex(hex string) (*ecdsa.PrivateKey, error) {
 }
 
 func (config NodeKeyConfig) load(keyfile string) (*ecdsa.PrivateKey, error) {
-	key, err := crypto.LoadECDSA(keyfile)
+	key, _, err := config.loadEncrypted(keyfile)
+	return key, err
+}
+
+// loadEncrypted loads the key at keyfile, also reporting whether the file was encrypted.
+func (config NodeKeyConfig) loadEncrypted(keyfile string) (*ecdsa.PrivateKey, bool, error) {
+	data, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load node key from %s: %w", keyfile, err)
+	}
+	if !atrest.IsEncrypted(data) {
+		key, err := crypto.LoadECDSA(keyfile)
+		if err != nil {
+			err = fmt.Errorf("failed to load node key from %s: %w", keyfile, err)
+		}
+		return key, false, err
+	}
+	if config.Encryption == nil {
+		return nil, true, fmt.Errorf("node key %s is encrypted, but no encryption key is configured", keyfile)
+	}
+	data, err = atrest.Decrypt(config.Encryption, data)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decrypt node key from %s: %w", keyfile, err)
+	}
+	key, err := crypto.HexToECDSA(strings.TrimSpace(string(data)))
 	if err != nil {
 		err = fmt.Errorf("failed to load node key from %s: %w", keyfile, err)
 	}
-	return key, err
+	return key, true, err
 }
 
 func (config NodeKeyConfig) save(keyfile string, key *ecdsa.PrivateKey) error {
 	err := os.MkdirAll(path.Dir(keyfile), 0755)
 	if err == nil {
-		err = crypto.SaveECDSA(keyfile, key)
+		if config.Encryption == nil {
+			err = crypto.SaveECDSA(keyfile, key)
+		} else {
+			var data []byte
+			if data, err = atrest.Encrypt(config.Encryption, []byte(hex.EncodeToString(crypto.FromECDSA(key)))); err == nil {
+				err = os.WriteFile(keyfile, data, 0600)
+			}
+		}
 	}
 	if err != nil {
 		return fmt.Errorf("failed to save node key to %s: %w", keyfile, err)
@@ -54,7 +93,15 @@ func (config NodeKeyConfig) save(keyfile string, key *ecdsa.PrivateKey) error {
 func (config NodeKeyConfig) LoadOrGenerateAndSave(keyfile string) (*ecdsa.PrivateKey, error) {
 	// If file exists, try to load it.
 	if _, err := os.Stat(keyfile); err == nil {
-		return config.load(keyfile)
+		key, encrypted, err := config.loadEncrypted(keyfile)
+		if err != nil || encrypted || config.Encryption == nil {
+			return key, err
+		}
+		log.Info("Encrypting node key", "file", keyfile)
+		if err := config.save(keyfile, key); err != nil {
+			return nil, err
+		}
+		return key, nil
 	}
 
 	// No persistent key found, generate and store a new one.
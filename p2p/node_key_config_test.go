@@ -0,0 +1,39 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/crypto/atrest"
+)
+
+func TestNodeKeyEncryption(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte(hex.EncodeToString(make([]byte, atrest.KeySize))), 0600))
+
+	// a plaintext key written before encryption was enabled gets encrypted on load
+	plain := NodeKeyConfig{}
+	key, err := plain.LoadOrGenerateAndSave(plain.DefaultPath(dir))
+	require.NoError(t, err)
+
+	encrypted := NodeKeyConfig{Encryption: atrest.FileKeyProvider(secret)}
+	loaded, err := encrypted.LoadOrParseOrGenerateAndSave("", "", dir)
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(loaded))
+
+	data, err := os.ReadFile(encrypted.DefaultPath(dir))
+	require.NoError(t, err)
+	require.True(t, atrest.IsEncrypted(data))
+
+	loaded, err = encrypted.LoadOrParseOrGenerateAndSave("", "", dir)
+	require.NoError(t, err)
+	require.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(loaded))
+	_, err = plain.LoadOrParseOrGenerateAndSave("", "", dir)
+	require.Error(t, err)
+}
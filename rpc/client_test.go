@@ -32,6 +32,8 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ledgerwatch/erigon-lib/common/dbg"
 	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/memlimit"
 )
 
 func TestClientRequest(t *testing.T) {
@@ -67,6 +69,29 @@ func TestClientResponseType(t *testing.T) {
 }
 
 // This test checks that server-returned errors with code and data come out of Client.Call.
+func TestClientOverloaded(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	// any process is over a limit of a byte
+	memlimit.Default.SetLimit(1)
+	memlimit.Default.Check()
+	var resp echoResult
+	err := client.Call(&resp, "test_echo", "hello", 10, &echoArgs{"world"})
+	if e, ok := err.(Error); !ok {
+		t.Fatalf("client did not return rpc.Error, got %#v", err)
+	} else if e.ErrorCode() != (&overloadedError{}).ErrorCode() {
+		t.Fatalf("wrong error code %d, want %d", e.ErrorCode(), (&overloadedError{}).ErrorCode())
+	}
+
+	memlimit.Default.SetLimit(0)
+	if err := client.Call(&resp, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestClientErrorData(t *testing.T) {
 	server := newTestServer()
 	defer server.Stop()
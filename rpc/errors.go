@@ -25,6 +25,7 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(overloadedError)
 	_ Error = new(CustomError)
 )
 
@@ -74,6 +75,13 @@ func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
 
+// the node is short of memory and sheds load, see memlimit
+type overloadedError struct{}
+
+func (e *overloadedError) ErrorCode() int { return -32005 }
+
+func (e *overloadedError) Error() string { return "server is overloaded, try again later" }
+
 type CustomError struct {
 	Code    int
 	Message string
@@ -29,6 +29,8 @@ import (
 
 	jsoniter "github.com/json-iterator/go"
 	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/memlimit"
 )
 
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
@@ -194,11 +196,17 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		}
 		wg.Wait()
 		answers := make([]interface{}, 0, len(msgs))
+		answersSize := int64(0)
 		for _, answer := range answersWithNils {
 			if answer != nil {
 				answers = append(answers, answer)
 			}
+			if raw, ok := answer.(json.RawMessage); ok {
+				answersSize += int64(len(raw))
+			}
 		}
+		inflightMemory.Add(answersSize)
+		defer inflightMemory.Add(-answersSize)
 		h.addSubscriptions(cp.notifiers)
 		if len(answers) > 0 {
 			h.conn.writeJSON(cp.ctx, answers)
@@ -436,10 +444,16 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if callb == nil {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	if callb != h.unsubscribeCb && memlimit.Default.Overloaded() {
+		return msg.errorResponse(&overloadedError{})
+	}
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
+	inflight := int64(len(msg.Params))
+	inflightMemory.Add(inflight)
+	defer inflightMemory.Add(-inflight)
 	start := time.Now()
 	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
 
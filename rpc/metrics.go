@@ -20,11 +20,16 @@ import (
 	"fmt"
 
 	"github.com/VictoriaMetrics/metrics"
+
+	"github.com/ledgerwatch/erigon/common/memlimit"
 )
 
 var (
 	rpcRequestGauge    = metrics.GetOrCreateCounter("rpc_total")
 	failedReqeustGauge = metrics.GetOrCreateCounter("rpc_failure")
+
+	// params of the calls running and answers not yet written
+	inflightMemory = memlimit.Default.Account("rpc_inflight")
 )
 
 func newRPCServingTimerMS(method string, valid bool) *metrics.Summary {
@@ -72,6 +72,7 @@ type BlockNumber int64
 type Timestamp uint64
 
 const (
+	OptimisticBlockNumber     = BlockNumber(-6)
 	LatestExecutedBlockNumber = BlockNumber(-5)
 	FinalizedBlockNumber      = BlockNumber(-4)
 	SafeBlockNumber           = BlockNumber(-3)
@@ -81,7 +82,7 @@ const (
 )
 
 // UnmarshalJSON parses the given JSON fragment into a BlockNumber. It supports:
-// - "latest", "earliest", "pending", "safe", or "finalized" as string arguments
+// - "latest", "earliest", "pending", "safe", "finalized" or "optimistic" as string arguments
 // - the block number
 // Returned errors:
 // - an invalid block number error when the given argument isn't a known strings
@@ -111,6 +112,9 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 	case "latestExecuted":
 		*bn = LatestExecutedBlockNumber
 		return nil
+	case "optimistic":
+		*bn = OptimisticBlockNumber
+		return nil
 	case "null":
 		*bn = LatestBlockNumber
 		return nil
@@ -192,6 +196,10 @@ func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
 		bn := FinalizedBlockNumber
 		bnh.BlockNumber = &bn
 		return nil
+	case "optimistic":
+		bn := OptimisticBlockNumber
+		bnh.BlockNumber = &bn
+		return nil
 	default:
 		if len(input) == 66 {
 			hash := libcommon.Hash{}
@@ -48,6 +48,7 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		14: {`someString`, true, BlockNumber(0)},
 		15: {`""`, true, BlockNumber(0)},
 		16: {``, true, BlockNumber(0)},
+		17: {`"optimistic"`, false, OptimisticBlockNumber},
 	}
 
 	for i, test := range tests {
@@ -101,6 +102,8 @@ func TestBlockNumberOrHash_UnmarshalJSON(t *testing.T) {
 		25: {`{"blockNumber":"0x1", "blockHash":"0x0000000000000000000000000000000000000000000000000000000000000000"}`, true, BlockNumberOrHash{}},
 		26: {`{}`, true, BlockNumberOrHash{}},
 		27: {`{"jsonrpc":"2.0","result":{"code":418,"message":"blabla"},"id":""}]`, true, BlockNumberOrHash{}},
+		28: {`"optimistic"`, false, BlockNumberOrHashWithNumber(OptimisticBlockNumber)},
+		29: {`{"blockNumber":"optimistic"}`, false, BlockNumberOrHashWithNumber(OptimisticBlockNumber)},
 	}
 
 	for i, test := range tests {
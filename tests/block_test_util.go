@@ -105,9 +105,33 @@ type btHeaderMarshaling struct {
 }
 
 func (t *BlockTest) Run(tst *testing.T, _ bool) error {
+	config, engine, err := t.setup()
+	if err != nil {
+		return err
+	}
+	return t.run(stages.MockWithGenesisEngine(tst, t.genesis(config), engine, false))
+}
+
+// RunStandalone runs the test outside of go test, like evm blocktest does.
+func (t *BlockTest) RunStandalone() error {
+	config, engine, err := t.setup()
+	if err != nil {
+		return err
+	}
+	m := stages.MockWithGenesisEngine(nil, t.genesis(config), engine, false)
+	defer m.Close()
+	return t.run(m)
+}
+
+// Network returns the name of the fork the test runs on.
+func (t *BlockTest) Network() string {
+	return t.json.Network
+}
+
+func (t *BlockTest) setup() (*chain.Config, consensus.Engine, error) {
 	config, ok := Forks[t.json.Network]
 	if !ok {
-		return UnsupportedForkError{t.json.Network}
+		return nil, nil, UnsupportedForkError{t.json.Network}
 	}
 	var engine consensus.Engine
 	if t.json.SealEngine == "NoProof" {
@@ -118,8 +142,10 @@ func (t *BlockTest) Run(tst *testing.T, _ bool) error {
 	if config.TerminalTotalDifficulty != nil {
 		engine = serenity.New(engine) // the Merge
 	}
-	m := stages.MockWithGenesisEngine(tst, t.genesis(config), engine, false)
+	return config, engine, nil
+}
 
+func (t *BlockTest) run(m *stages.MockSentry) error {
 	// import pre accounts & construct test genesis block & state root
 	if m.Genesis.Hash() != t.json.Genesis.Hash {
 		return fmt.Errorf("genesis block hash doesn't match test: computed=%x, test=%x", m.Genesis.Hash().Bytes()[:6], t.json.Genesis.Hash[:6])
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	mdbx2 "github.com/torquem-ch/mdbx-go/mdbx"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/integrity"
+	"github.com/ledgerwatch/erigon/ethdb/backup"
+	"github.com/ledgerwatch/erigon/ethdb/secondary"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	"github.com/ledgerwatch/erigon/turbo/logging"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+var dbCommand = cli.Command{
+	Name:        "db",
+	Description: `Checking the database`,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "doctor",
+			Action: doDoctor,
+			Usage:  "Check the consistency of chaindata (canonical chain, txlookup, history, snapshots boundary) and print a repair plan",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&DoctorFixFlag,
+				&DoctorSampleEveryFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:   "check",
+			Action: doCheck,
+			Usage:  "Walk headers, bodies, senders, state and history indices, cross-validate them and print a JSON report of the corruption found",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&CheckSampleEveryFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:   "backup",
+			Action: doBackup,
+			Usage:  "Copy chaindata into an empty directory, consistently, while erigon keeps running",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&BackupToFlag,
+				&BackupRateFlag,
+				&BackupCommitEveryFlag,
+			}, debug.Flags, logging.Flags),
+		},
+		{
+			Name:  "index",
+			Usage: "Maintain the secondary indexes of chaindata, erigon must be stopped",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "backfill",
+					Action: doIndexBackfill,
+					Usage:  "Index the entries of the primary table of an index, from a key on",
+					Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+					Flags: joinFlags([]cli.Flag{
+						&utils.DataDirFlag,
+						&IndexNameFlag,
+						&IndexFromFlag,
+					}, debug.Flags, logging.Flags),
+				},
+				{
+					Name:   "rebuild",
+					Action: doIndexRebuild,
+					Usage:  "Empty an index and index its whole primary table again",
+					Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+					Flags: joinFlags([]cli.Flag{
+						&utils.DataDirFlag,
+						&IndexNameFlag,
+					}, debug.Flags, logging.Flags),
+				},
+			},
+		},
+	},
+}
+
+var (
+	DoctorFixFlag = cli.BoolFlag{
+		Name:  "fix",
+		Usage: "Apply the safe repairs (which don't need an unwind), erigon must be stopped",
+	}
+	DoctorSampleEveryFlag = cli.Uint64Flag{
+		Name:  "sample.every",
+		Usage: "Check txlookup and history of every N-th block",
+		Value: 10_000,
+	}
+	CheckSampleEveryFlag = cli.Uint64Flag{
+		Name:  "sample.every",
+		Usage: "Check txlookup and history of every N-th block, 1 checks them all",
+		Value: 1,
+	}
+	BackupToFlag = cli.StringFlag{
+		Name:     "to",
+		Usage:    "Directory of the copy, must not exist or be empty",
+		Required: true,
+	}
+	BackupRateFlag = cli.StringFlag{
+		Name:  "backup.rate",
+		Usage: "Max read rate per second, 0 is unlimited: a slower backup leaves more IO to the sync, but the database grows until it ends",
+		Value: backup.DefaultCfg.BytesPerSecond.String(),
+	}
+	BackupCommitEveryFlag = cli.StringFlag{
+		Name:  "backup.commit.every",
+		Usage: "Size of the write transactions of the copy",
+		Value: backup.DefaultCfg.CommitEvery.String(),
+	}
+	IndexNameFlag = cli.StringFlag{
+		Name:     "index",
+		Usage:    "Name of the index table",
+		Required: true,
+	}
+	IndexFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Hex primary key to start from, empty starts from the first entry",
+	}
+)
+
+func doDoctor(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	fix := cliCtx.Bool(DoctorFixFlag.Name)
+
+	opts := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata)
+	if !fix {
+		opts = opts.Readonly()
+	}
+	chainDB := opts.MustOpen()
+	defer chainDB.Close()
+
+	snapshots := snapshotsync.NewRoSnapshots(ethconfig.NewSnapCfg(true, true, false), dirs.Snap)
+	if err := snapshots.ReopenFolder(); err != nil {
+		return err
+	}
+	defer snapshots.Close()
+
+	var findings []integrity.Finding
+	if err := chainDB.View(ctx, func(tx kv.Tx) error {
+		historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+		if err != nil {
+			return err
+		}
+		findings, err = integrity.Doctor(ctx, tx, integrity.DoctorCfg{
+			BlockReader:    snapshotsync.NewBlockReaderWithSnapshots(snapshots),
+			SnapshotBlocks: snapshots.BlocksAvailable(),
+			SampleEvery:    cliCtx.Uint64(DoctorSampleEveryFlag.Name),
+			HistoryV3:      historyV3,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	var safe int
+	for i := range findings {
+		if findings[i].Safe() {
+			safe++
+		}
+		fmt.Println(findings[i].String())
+	}
+	log.Info("[doctor] done", "findings", len(findings), "safe repairs", safe)
+	if !fix || safe == 0 {
+		return nil
+	}
+	var repaired int
+	if err := chainDB.Update(ctx, func(tx kv.RwTx) error {
+		var err error
+		repaired, err = integrity.Repair(tx, findings)
+		return err
+	}); err != nil {
+		return err
+	}
+	log.Info("[doctor] repaired", "findings", repaired)
+	return nil
+}
+
+func doCheck(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+
+	// Accede: erigon may be running
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).Readonly().Flags(func(f uint) uint { return f | mdbx2.Accede }).MustOpen()
+	defer chainDB.Close()
+
+	snapshots := snapshotsync.NewRoSnapshots(ethconfig.NewSnapCfg(true, true, false), dirs.Snap)
+	if err := snapshots.ReopenFolder(); err != nil {
+		return err
+	}
+	defer snapshots.Close()
+
+	var report *integrity.Report
+	if err := chainDB.View(ctx, func(tx kv.Tx) error {
+		historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+		if err != nil {
+			return err
+		}
+		report, err = integrity.Check(ctx, tx, integrity.DoctorCfg{
+			BlockReader:    snapshotsync.NewBlockReaderWithSnapshots(snapshots),
+			SnapshotBlocks: snapshots.BlocksAvailable(),
+			SampleEvery:    cliCtx.Uint64(CheckSampleEveryFlag.Name),
+			HistoryV3:      historyV3,
+		})
+		return err
+	}); err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	if !report.OK() {
+		return fmt.Errorf("found %d problems, see `erigon db doctor` for the repairs", len(report.Findings))
+	}
+	return nil
+}
+
+func doBackup(cliCtx *cli.Context) error {
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	var cfg backup.Cfg
+	if err := cfg.BytesPerSecond.UnmarshalText([]byte(cliCtx.String(BackupRateFlag.Name))); err != nil {
+		return fmt.Errorf("--%s: %w", BackupRateFlag.Name, err)
+	}
+	if err := cfg.CommitEvery.UnmarshalText([]byte(cliCtx.String(BackupCommitEveryFlag.Name))); err != nil {
+		return fmt.Errorf("--%s: %w", BackupCommitEveryFlag.Name, err)
+	}
+
+	// Accede: take the geometry of the database from the running erigon
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).Readonly().Flags(func(f uint) uint { return f | mdbx2.Accede }).MustOpen()
+	defer chainDB.Close()
+	return backup.ToDir(cliCtx.Context, "backup", chainDB, cliCtx.String(BackupToFlag.Name), cfg)
+}
+
+func indexFromFlag(cliCtx *cli.Context) (*secondary.Index, error) {
+	name := cliCtx.String(IndexNameFlag.Name)
+	index := secondary.Get(name)
+	if index == nil {
+		var known []string
+		for _, index := range secondary.Registered() {
+			known = append(known, index.Name)
+		}
+		return nil, fmt.Errorf("unknown index %q, known indexes: [%s]", name, strings.Join(known, ", "))
+	}
+	return index, nil
+}
+
+func doIndexBackfill(cliCtx *cli.Context) error {
+	index, err := indexFromFlag(cliCtx)
+	if err != nil {
+		return err
+	}
+	from, err := hex.DecodeString(strings.TrimPrefix(cliCtx.String(IndexFromFlag.Name), "0x"))
+	if err != nil {
+		return fmt.Errorf("--%s: %w", IndexFromFlag.Name, err)
+	}
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).WithTableCfg(secondary.TablesCfg).MustOpen()
+	defer chainDB.Close()
+	return chainDB.Update(cliCtx.Context, func(tx kv.RwTx) error {
+		return index.Backfill(cliCtx.Context, "index", tx, from)
+	})
+}
+
+func doIndexRebuild(cliCtx *cli.Context) error {
+	index, err := indexFromFlag(cliCtx)
+	if err != nil {
+		return err
+	}
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainDB := mdbx.NewMDBX(log.New()).Path(dirs.Chaindata).WithTableCfg(secondary.TablesCfg).MustOpen()
+	defer chainDB.Close()
+	return chainDB.Update(cliCtx.Context, func(tx kv.RwTx) error {
+		return index.Rebuild(cliCtx.Context, "index", tx)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/c2h5oh/datasize"
@@ -34,6 +35,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/debug"
 	"github.com/ledgerwatch/erigon/turbo/logging"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
@@ -73,6 +75,18 @@ var snapshotCommand = cli.Command{
 				&SnapshotEveryFlag,
 			}, debug.Flags, logging.Flags),
 		},
+		{
+			Name:      "import",
+			Action:    doImportCommand,
+			Usage:     "Write historical blocks directly into snapshots: erigon snapshots import --chain=mainnet mainnet-00000.era1 mainnet-00001.era1 ...",
+			ArgsUsage: "<filename> (<filename 2> ... <filename N>)",
+			Before:    func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&utils.ChainFlag,
+				&SnapshotSegmentSizeFlag,
+			}, debug.Flags, logging.Flags),
+		},
 		{
 			Name:   "uncompress",
 			Action: doUncompress,
@@ -329,6 +343,50 @@ func doCompress(cliCtx *cli.Context) error {
 
 	return nil
 }
+
+// doImportCommand bootstraps snapshots of an empty datadir from era1 files (or RLP-encoded blocks, as produced by
+// `export`) without writing blocks into the database. Files must be passed in order, starting from genesis.
+func doImportCommand(cliCtx *cli.Context) error {
+	ctx := cliCtx.Context
+	if cliCtx.NArg() < 1 {
+		return fmt.Errorf("expecting at least one era1 or rlp file")
+	}
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	chainName := cliCtx.String(utils.ChainFlag.Name)
+	chainConfig := params.ChainConfigByChainName(chainName)
+	if chainConfig == nil {
+		return fmt.Errorf("unknown chain: %s", chainName)
+	}
+	segmentSize := cliCtx.Uint64(SnapshotSegmentSizeFlag.Name)
+	if segmentSize < snaptype.Erigon2MinSegmentSize || segmentSize%snaptype.Erigon2MinSegmentSize != 0 {
+		return fmt.Errorf("segment size must be a multiple of %d", snaptype.Erigon2MinSegmentSize)
+	}
+	dir.MustExist(dirs.Snap)
+	dir.MustExist(dirs.Tmp)
+
+	sources := make([]snapshotsync.BlockSource, 0, cliCtx.NArg())
+	for _, fName := range cliCtx.Args().Slice() {
+		f, err := os.Open(fName)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if strings.HasSuffix(fName, ".era1") {
+			sources = append(sources, snapshotsync.NewEra1BlockSource(f))
+		} else {
+			sources = append(sources, snapshotsync.NewRLPBlockSource(bufio.NewReaderSize(f, 4*1024*1024)))
+		}
+	}
+
+	next, err := snapshotsync.ImportBlocks(ctx, snapshotsync.MultiBlockSource(sources...), chainConfig, dirs.Snap, dirs.Tmp, segmentSize, estimate.CompressSnapshot.Workers(), log.LvlInfo)
+	if err != nil {
+		return err
+	}
+	log.Info("[snapshots] Import done, start erigon with the same datadir to sync the rest of the chain", "imported_blocks", next)
+	return nil
+}
+
 func doRetireCommand(cliCtx *cli.Context) error {
 	defer log.Info("Retire Done")
 	ctx := cliCtx.Context
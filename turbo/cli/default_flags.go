@@ -13,6 +13,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.EthashDatasetDirFlag,
 	&utils.SnapshotFlag,
 	&utils.ExternalConsensusFlag,
+	&utils.L2Flag,
 	&utils.TxPoolDisableFlag,
 	&utils.TxPoolLocalsFlag,
 	&utils.TxPoolNoLocalsFlag,
@@ -35,10 +36,15 @@ var DefaultFlags = []cli.Flag{
 	&PruneTxIndexBeforeFlag,
 	&PruneCallTracesBeforeFlag,
 	&BatchSizeFlag,
+	&BatchMemoryLimitFlag,
 	&BodyCacheLimitFlag,
 	&DatabaseVerbosityFlag,
+	&DatabaseMetricsFlag,
 	&PrivateApiAddr,
 	&PrivateApiRateLimit,
+	&PrivateApiKvOpsLimit,
+	&PrivateApiKvBandwidth,
+	&PrivateApiAuditRate,
 	&EtlBufferSizeFlag,
 	&TLSFlag,
 	&TLSCertFlag,
@@ -65,6 +71,8 @@ var DefaultFlags = []cli.Flag{
 	&utils.StateCacheFlag,
 	&utils.RpcBatchConcurrencyFlag,
 	&utils.RpcStreamingDisableFlag,
+	&utils.RpcStrictLatestFlag,
+	&utils.RpcStrictSyncingFlag,
 	&utils.DBReadConcurrencyFlag,
 	&utils.RpcAccessListFlag,
 	&utils.RpcTraceCompatFlag,
@@ -82,8 +90,14 @@ var DefaultFlags = []cli.Flag{
 	&EvmCallTimeoutFlag,
 
 	&utils.SnapKeepBlocksFlag,
+	&utils.SnapHistoryWindowFlag,
 	&utils.SnapStopFlag,
 	&utils.DbPageSizeFlag,
+	&utils.DbGrowthStepFlag,
+	&utils.DbCborPoolSizeFlag,
+	&utils.BackgroundTasksFlag,
+	&utils.BackgroundMemoryFlag,
+	&utils.MemorySoftLimitFlag,
 	&utils.TorrentPortFlag,
 	&utils.TorrentMaxPeersFlag,
 	&utils.TorrentConnsPerFileFlag,
@@ -97,9 +111,11 @@ var DefaultFlags = []cli.Flag{
 	&utils.NATFlag,
 	&utils.NoDiscoverFlag,
 	&utils.DiscoveryV5Flag,
+	&utils.NTPServerFlag,
 	&utils.NetrestrictFlag,
 	&utils.NodeKeyFileFlag,
 	&utils.NodeKeyHexFlag,
+	&utils.NodeKeyEncryptionFlag,
 	&utils.DNSDiscoveryFlag,
 	&utils.BootnodesFlag,
 	&utils.StaticPeersFlag,
@@ -129,6 +145,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.MinerEtherbaseFlag,
 	&utils.MinerExtraDataFlag,
 	&utils.MinerNoVerfiyFlag,
+	&utils.MinerTxFilterFileFlag,
 	&utils.MinerSigningKeyFileFlag,
 	&utils.SentryAddrFlag,
 	&utils.SentryLogPeerInfoFlag,
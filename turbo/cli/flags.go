@@ -31,11 +31,20 @@ var (
 		Usage: "Enabling internal db logs. Very high verbosity levels may require recompile db. Default: 2, means warning.",
 		Value: 2,
 	}
+	DatabaseMetricsFlag = cli.BoolFlag{
+		Name:  "database.metrics",
+		Usage: "Count gets, puts, deletes, cursors and bytes per table of chaindata, exported as db_table_* metrics. Costs a little CPU on every operation.",
+	}
 	BatchSizeFlag = cli.StringFlag{
 		Name:  "batchSize",
 		Usage: "Batch size for the execution stage",
 		Value: "256M",
 	}
+	BatchMemoryLimitFlag = cli.StringFlag{
+		Name:  "batchMemoryLimit",
+		Usage: "Memory over which the pending writes of the execution stage are spilled to sorted files in the tmp dir until the batch is committed. 0 for no limit",
+		Value: ethconfig.Defaults.Sync.BatchMemoryLimit.String(),
+	}
 	EtlBufferSizeFlag = cli.StringFlag{
 		Name:  "etl.bufferSize",
 		Usage: "Buffer size for ETL operations.",
@@ -59,6 +68,22 @@ var (
 		Value: kv.ReadersLimit - 128,
 	}
 
+	PrivateApiKvOpsLimit = cli.Float64Flag{
+		Name:  "private.api.kv.ops",
+		Usage: "Max remote database calls and cursor operations per second of each client connection, 0 means unlimited. Over it, calls fail with ResourceExhausted",
+	}
+
+	PrivateApiKvBandwidth = cli.StringFlag{
+		Name:  "private.api.kv.bandwidth",
+		Usage: "Max bytes per second of remote database replies to each client connection (e.g. 10MB), 0 means unlimited. Over it, calls fail with ResourceExhausted",
+		Value: "0",
+	}
+
+	PrivateApiAuditRate = cli.Float64Flag{
+		Name:  "private.api.audit",
+		Usage: "Fraction (0..1) of the remote database transactions and calls logged with their client address, tables, reads, bytes and duration. 0 disables the audit log",
+	}
+
 	PruneFlag = cli.StringFlag{
 		Name: "prune",
 		Usage: `Choose which ancient data delete from DB:
@@ -221,6 +246,13 @@ func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config) {
 		}
 	}
 
+	if ctx.String(BatchMemoryLimitFlag.Name) != "" {
+		err := cfg.Sync.BatchMemoryLimit.UnmarshalText([]byte(ctx.String(BatchMemoryLimitFlag.Name)))
+		if err != nil {
+			utils.Fatalf("Invalid batchMemoryLimit provided: %v", err)
+		}
+	}
+
 	if ctx.String(EtlBufferSizeFlag.Name) != "" {
 		sizeVal := datasize.ByteSize(0)
 		size := &sizeVal
@@ -337,6 +369,7 @@ func ApplyFlagsForNodeConfig(ctx *cli.Context, cfg *nodecfg.Config) {
 	setPrivateApi(ctx, cfg)
 	setEmbeddedRpcDaemon(ctx, cfg)
 	cfg.DatabaseVerbosity = kv.DBVerbosityLvl(ctx.Int(DatabaseVerbosityFlag.Name))
+	cfg.DatabaseMetrics = ctx.Bool(DatabaseMetricsFlag.Name)
 }
 
 func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
@@ -381,6 +414,8 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 		WebsocketEnabled:     ctx.IsSet(utils.WSEnabledFlag.Name),
 		RpcBatchConcurrency:  ctx.Uint(utils.RpcBatchConcurrencyFlag.Name),
 		RpcStreamingDisable:  ctx.Bool(utils.RpcStreamingDisableFlag.Name),
+		RpcStrictLatest:      ctx.Bool(utils.RpcStrictLatestFlag.Name),
+		RpcStrictSyncing:     ctx.Bool(utils.RpcStrictSyncingFlag.Name),
 		DBReadConcurrency:    ctx.Int(utils.DBReadConcurrencyFlag.Name),
 		RpcAllowListFilePath: ctx.String(utils.RpcAccessListFlag.Name),
 		Gascap:               ctx.Uint64(utils.RpcGasCapFlag.Name),
@@ -433,6 +468,11 @@ func setPrivateApi(ctx *cli.Context, cfg *nodecfg.Config) {
 		log.Warn("private.api.ratelimit is too big", "force", maxRateLimit)
 		cfg.PrivateApiRateLimit = maxRateLimit
 	}
+	cfg.PrivateApiKvOpsLimit = ctx.Float64(PrivateApiKvOpsLimit.Name)
+	if err := cfg.PrivateApiKvBytesLimit.UnmarshalText([]byte(ctx.String(PrivateApiKvBandwidth.Name))); err != nil {
+		utils.Fatalf("Invalid private.api.kv.bandwidth provided: %v", err)
+	}
+	cfg.PrivateApiAuditRate = ctx.Float64(PrivateApiAuditRate.Name)
 	if ctx.Bool(TLSFlag.Name) {
 		certFile := ctx.String(TLSCertFlag.Name)
 		keyFile := ctx.String(TLSKeyFlag.Name)
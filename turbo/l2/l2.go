@@ -0,0 +1,100 @@
+// Package l2 is the registry of the L2 stacks which reuse the execution and the RPC layers of Erigon. A stack
+// registers a Plugin, usually from an init function of a package linked into its build of Erigon, and is selected
+// at startup with --l2=<name>:
+//
+//   - its transaction types, like the deposits of op-stack, are decoded with the others (types.RegisterTxType);
+//   - its fee hooks replace how fees are charged and paid (core.FeeHooks);
+//   - its block source feeds the blocks, derived from L1 or received from a sequencer, through the same engine
+//     api a consensus layer uses, instead of the embedded light client.
+package l2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+// BlockSource produces the blocks of the L2 and drives the execution with EngineNewPayload and
+// EngineForkChoiceUpdated, like the embedded light client does on L1.
+type BlockSource interface {
+	// Start runs until ctx is done.
+	Start(ctx context.Context, execution remote.ETHBACKENDServer) error
+}
+
+// Plugin is what an L2 stack plugs into Erigon, every part is optional.
+type Plugin struct {
+	Name        string
+	TxTypes     map[byte]types.TxDecoder
+	Fees        core.FeeHooks
+	BlockSource BlockSource
+}
+
+var (
+	lock     sync.Mutex
+	registry = map[string]*Plugin{}
+	active   *Plugin
+)
+
+// Register makes plugin selectable with --l2, it panics when a plugin of the same name is already registered.
+func Register(plugin *Plugin) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := registry[plugin.Name]; ok {
+		panic(fmt.Sprintf("l2 plugin %s registered twice", plugin.Name))
+	}
+	registry[plugin.Name] = plugin
+}
+
+// Names returns the names of the registered plugins, sorted.
+func Names() []string {
+	lock.Lock()
+	defer lock.Unlock()
+	return namesLocked()
+}
+
+// Activate installs the transaction types and the fee hooks of the plugin called name, for the whole process.
+// Only one plugin can be active, activating it again returns it.
+func Activate(name string) (*Plugin, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	plugin, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown l2 plugin %q, registered: %v", name, namesLocked())
+	}
+	if active != nil {
+		if active == plugin {
+			return plugin, nil
+		}
+		return nil, fmt.Errorf("l2 plugin %s is already active", active.Name)
+	}
+	for txType, decode := range plugin.TxTypes {
+		types.RegisterTxType(txType, decode)
+	}
+	if plugin.Fees != nil {
+		core.SetFeeHooks(plugin.Fees)
+	}
+	active = plugin
+	return plugin, nil
+}
+
+// Active returns the active plugin, nil on L1.
+func Active() *Plugin {
+	lock.Lock()
+	defer lock.Unlock()
+	return active
+}
+
+func namesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package l2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+const testDepositTxType = 0x7e
+
+// testDeposit is a transaction of the L2 carrying its sender, its payload is the one of a legacy transaction
+type testDeposit struct {
+	*types.LegacyTx
+	from libcommon.Address
+}
+
+func (tx *testDeposit) Type() byte                        { return testDepositTxType }
+func (tx *testDeposit) ExplicitSender() libcommon.Address { return tx.from }
+
+func decodeTestDeposit(s *rlp.Stream) (types.Transaction, error) {
+	_, size, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+	tx := &types.LegacyTx{}
+	if err := tx.DecodeRLP(s, size); err != nil {
+		return nil, err
+	}
+	return &testDeposit{LegacyTx: tx, from: libcommon.HexToAddress("0xdeadbeef")}, nil
+}
+
+func TestActivate(t *testing.T) {
+	Register(&Plugin{Name: "test", TxTypes: map[byte]types.TxDecoder{testDepositTxType: decodeTestDeposit}})
+	require.Panics(t, func() { Register(&Plugin{Name: "test"}) })
+	require.Equal(t, []string{"test"}, Names())
+	require.Nil(t, Active())
+
+	_, err := Activate("unknown")
+	require.Error(t, err)
+
+	var payload bytes.Buffer
+	payload.WriteByte(testDepositTxType)
+	require.NoError(t, types.NewTransaction(1, libcommon.HexToAddress("0x01"), uint256.NewInt(2), 21000, uint256.NewInt(0), nil).EncodeRLP(&payload))
+
+	// L1 doesn't know the type
+	_, err = types.UnmarshalTransactionFromBinary(payload.Bytes())
+	require.Error(t, err)
+
+	plugin, err := Activate("test")
+	require.NoError(t, err)
+	require.Equal(t, plugin, Active())
+	again, err := Activate("test")
+	require.NoError(t, err)
+	require.Equal(t, plugin, again)
+
+	tx, err := types.UnmarshalTransactionFromBinary(payload.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, byte(testDepositTxType), tx.Type())
+	require.Equal(t, uint64(1), tx.GetNonce())
+	sender, err := types.LatestSignerForChainID(big.NewInt(1)).Sender(tx)
+	require.NoError(t, err)
+	require.Equal(t, libcommon.HexToAddress("0xdeadbeef"), sender)
+
+	// and its receipts
+	receipt := &types.Receipt{Type: testDepositTxType, Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{}}
+	enc, err := rlp.EncodeToBytes(receipt)
+	require.NoError(t, err)
+	var decoded types.Receipt
+	require.NoError(t, rlp.DecodeBytes(enc, &decoded))
+	require.Equal(t, byte(testDepositTxType), decoded.Type)
+	require.Equal(t, uint64(21000), decoded.CumulativeGasUsed)
+}
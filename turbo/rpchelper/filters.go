@@ -31,6 +31,10 @@ type Filters struct {
 
 	pendingBlock *types.Block
 
+	pendingStateMu      sync.Mutex
+	pendingState        *pendingState
+	pendingStateBuilder PendingStateBuilder
+
 	headsSubs        *SyncMap[HeadsSubID, Sub[*types.Header]]
 	pendingLogsSubs  *SyncMap[PendingLogsSubID, Sub[types.Logs]]
 	pendingBlockSubs *SyncMap[PendingBlockSubID, Sub[*types.Block]]
@@ -24,15 +24,18 @@ func (e nonCanonocalHashError) Error() string {
 	return fmt.Sprintf("hash %x is not currently canonical", e.hash)
 }
 
-func GetBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters) (uint64, libcommon.Hash, bool, error) {
-	return _GetBlockNumber(blockNrOrHash.RequireCanonical, blockNrOrHash, tx, filters)
+func GetBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters, strictLatest bool) (uint64, libcommon.Hash, bool, error) {
+	return _GetBlockNumber(blockNrOrHash.RequireCanonical, blockNrOrHash, tx, filters, strictLatest)
 }
 
-func GetCanonicalBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters) (uint64, libcommon.Hash, bool, error) {
-	return _GetBlockNumber(true, blockNrOrHash, tx, filters)
+func GetCanonicalBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters, strictLatest bool) (uint64, libcommon.Hash, bool, error) {
+	return _GetBlockNumber(true, blockNrOrHash, tx, filters, strictLatest)
 }
 
-func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters) (blockNumber uint64, hash libcommon.Hash, latest bool, err error) {
+// _GetBlockNumber resolves blockNrOrHash, latest tells whether the block is the one of the plain state. The
+// "optimistic" tag is the forkchoice head in both modes of "latest": while the head is ahead of execution latest is
+// false and its state can't be read yet (see CreateStateReader), its header and body can.
+func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *Filters, strictLatest bool) (blockNumber uint64, hash libcommon.Hash, latest bool, err error) {
 	// Due to changed semantics of `lastest` block in RPC request, it is now distinct
 	// from the block block number corresponding to the plain state
 	var plainStateBlockNumber uint64
@@ -45,7 +48,11 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 		number := *blockNrOrHash.BlockNumber
 		switch number {
 		case rpc.LatestBlockNumber:
-			if blockNumber, err = GetLatestBlockNumber(tx); err != nil {
+			if blockNumber, err = GetLatestBlockNumber(tx, strictLatest); err != nil {
+				return 0, libcommon.Hash{}, false, err
+			}
+		case rpc.OptimisticBlockNumber:
+			if blockNumber, err = GetOptimisticBlockNumber(tx); err != nil {
 				return 0, libcommon.Hash{}, false, err
 			}
 		case rpc.EarliestBlockNumber:
@@ -94,11 +101,28 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 	return blockNumber, hash, blockNumber == plainStateBlockNumber, nil
 }
 
-func CreateStateReader(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash, txnIndex int, filters *Filters, stateCache kvcache.Cache, historyV3 bool, chainName string) (state.StateReader, error) {
-	blockNumber, _, latest, err := _GetBlockNumber(true, blockNrOrHash, tx, filters)
+func CreateStateReader(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash, txnIndex int, filters *Filters, stateCache kvcache.Cache, historyV3 bool, chainName string, strictLatest bool) (state.StateReader, error) {
+	if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+		reader, err := CreatePendingStateReader(ctx, tx, filters, stateCache, historyV3, chainName)
+		if err != nil || reader != nil {
+			return reader, err
+		}
+		// No pending block to resolve against, read the latest state
+		blockNrOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber)
+	}
+	blockNumber, _, latest, err := _GetBlockNumber(true, blockNrOrHash, tx, filters, strictLatest)
 	if err != nil {
 		return nil, err
 	}
+	if !latest && blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.OptimisticBlockNumber {
+		executed, err := stages.GetStageProgress(tx, stages.Execution)
+		if err != nil {
+			return nil, err
+		}
+		if blockNumber > executed {
+			return nil, fmt.Errorf("state of the optimistic head %d is not available, blocks are executed up to %d", blockNumber, executed)
+		}
+	}
 	return CreateStateReaderFromBlockNumber(ctx, tx, blockNumber, latest, txnIndex, stateCache, historyV3, chainName)
 }
 
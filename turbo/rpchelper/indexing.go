@@ -0,0 +1,97 @@
+package rpchelper
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+)
+
+// IndexStages are the stages building the historical indexes RPC relies on. They run after Execution
+// and can lag behind it (for example during archive backfill).
+var IndexStages = []stages.SyncStage{
+	stages.AccountHistoryIndex,
+	stages.StorageHistoryIndex,
+	stages.LogIndex,
+	stages.CallTraces,
+	stages.TxLookup,
+}
+
+// IndexStatus is the inclusive block range [FromBlock, ToBlock] covered by the index built by Stage.
+// Nothing is indexed if ToBlock < FromBlock.
+type IndexStatus struct {
+	Stage     stages.SyncStage `json:"stage"`
+	FromBlock uint64           `json:"fromBlock"`
+	ToBlock   uint64           `json:"toBlock"`
+}
+
+// GetIndexStatus returns which blocks the index built by stage covers: up to the stage progress,
+// starting from where pruning (as configured in the db) stops.
+// With historyV3 indexes are written by Execution itself, so they follow its progress.
+func GetIndexStatus(tx kv.Tx, stage stages.SyncStage, historyV3 bool) (IndexStatus, error) {
+	progressStage := stage
+	if historyV3 {
+		progressStage = stages.Execution
+	}
+	progress, err := stages.GetStageProgress(tx, progressStage)
+	if err != nil {
+		return IndexStatus{}, err
+	}
+	pruneMode, err := prune.Get(tx)
+	if err != nil {
+		return IndexStatus{}, err
+	}
+	var amount prune.BlockAmount
+	switch stage {
+	case stages.AccountHistoryIndex, stages.StorageHistoryIndex:
+		amount = pruneMode.History
+	case stages.LogIndex:
+		amount = pruneMode.Receipts
+	case stages.CallTraces:
+		amount = pruneMode.CallTraces
+	case stages.TxLookup:
+		amount = pruneMode.TxIndex
+	default:
+		return IndexStatus{}, fmt.Errorf("stage %s does not build an index", stage)
+	}
+	status := IndexStatus{Stage: stage, ToBlock: progress}
+	if amount.Enabled() {
+		status.FromBlock = amount.PruneTo(progress)
+	}
+	return status, nil
+}
+
+// NotIndexedError is returned when a query needs blocks the index no longer covers.
+// The covered range is returned as error data, so clients can retry later or narrow the query.
+type NotIndexedError struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Indexed   IndexStatus
+}
+
+func (e *NotIndexedError) ErrorCode() int { return -32000 }
+
+func (e *NotIndexedError) Error() string {
+	return fmt.Sprintf("blocks %d-%d are not indexed by %s yet, indexed range is %d-%d", e.FromBlock, e.ToBlock, e.Indexed.Stage, e.Indexed.FromBlock, e.Indexed.ToBlock)
+}
+
+func (e *NotIndexedError) ErrorData() interface{} { return e.Indexed }
+
+// IndexedRange clamps to to the last block indexed by stage: the index can lag behind the head the range was
+// resolved against, its blocks are not searched yet. The range is empty if the result is below from. It returns
+// NotIndexedError if the blocks from from on are no longer indexed (pruned).
+func IndexedRange(tx kv.Tx, stage stages.SyncStage, from, to uint64) (uint64, error) {
+	status, err := GetIndexStatus(tx, stage, false)
+	if err != nil {
+		return 0, err
+	}
+	if from < status.FromBlock {
+		return 0, &NotIndexedError{FromBlock: from, ToBlock: to, Indexed: status}
+	}
+	if to > status.ToBlock {
+		to = status.ToBlock
+	}
+	return to, nil
+}
@@ -0,0 +1,47 @@
+package rpchelper
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+)
+
+func TestIndexedRange(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	// Execution is at 100, log index backfill got to 60, history indexes are pruned below 90
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 100))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.LogIndex, 60))
+	require.NoError(t, stages.SaveStageProgress(tx, stages.AccountHistoryIndex, 100))
+	mode := prune.DefaultMode
+	mode.History = prune.Distance(10)
+	require.NoError(t, prune.Override(tx, mode))
+
+	// the blocks the index didn't reach yet are left out
+	to, err := IndexedRange(tx, stages.LogIndex, 0, 60)
+	require.NoError(t, err)
+	require.Equal(t, uint64(60), to)
+	to, err = IndexedRange(tx, stages.LogIndex, 50, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(60), to)
+	to, err = IndexedRange(tx, stages.LogIndex, 80, 100)
+	require.NoError(t, err)
+	require.Less(t, to, uint64(80))
+
+	// pruned blocks are an error
+	to, err = IndexedRange(tx, stages.AccountHistoryIndex, 90, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), to)
+	_, err = IndexedRange(tx, stages.AccountHistoryIndex, 89, 100)
+	var notIndexed *NotIndexedError
+	require.ErrorAs(t, err, &notIndexed)
+	require.Equal(t, IndexStatus{Stage: stages.AccountHistoryIndex, FromBlock: 90, ToBlock: 100}, notIndexed.ErrorData())
+
+	status, err := GetIndexStatus(tx, stages.LogIndex, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), status.ToBlock)
+}
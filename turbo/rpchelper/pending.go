@@ -0,0 +1,85 @@
+package rpchelper
+
+import (
+	"context"
+	"fmt"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/shards"
+)
+
+// PendingStateBuilder executes the transactions of the pending block on top of parent, the state of its parent
+// block, and returns their writes. It needs the chain config and the consensus engine, so it is provided by the
+// API serving the requests.
+type PendingStateBuilder func(ctx context.Context, tx kv.Tx, block *types.Block, parent state.StateReader) (*shards.StateCache, error)
+
+// pendingState is the writes of the last pending block, which are built once and shared by the requests.
+type pendingState struct {
+	hash   libcommon.Hash
+	writes *shards.StateCache
+}
+
+func (ff *Filters) SetPendingStateBuilder(builder PendingStateBuilder) {
+	ff.pendingStateMu.Lock()
+	defer ff.pendingStateMu.Unlock()
+	ff.pendingStateBuilder = builder
+}
+
+// pendingWrites returns a copy of the writes of block, which the caller may modify.
+func (ff *Filters) pendingWrites(ctx context.Context, tx kv.Tx, block *types.Block, parent state.StateReader) (*shards.StateCache, error) {
+	ff.pendingStateMu.Lock()
+	defer ff.pendingStateMu.Unlock()
+	if ff.pendingStateBuilder == nil {
+		return nil, nil
+	}
+	if ff.pendingState == nil || ff.pendingState.hash != block.Hash() {
+		writes, err := ff.pendingStateBuilder(ctx, tx, block, parent)
+		if err != nil {
+			return nil, fmt.Errorf("executing pending block %d: %w", block.NumberU64(), err)
+		}
+		ff.pendingState = &pendingState{hash: block.Hash(), writes: writes}
+	}
+	return ff.pendingState.writes.Clone(), nil
+}
+
+// CreatePendingStateReader returns a reader of the state after the transactions of the pending block, built by the
+// local payload builder. It returns nil when there is no pending block on top of the latest executed block, for
+// instance when the node isn't building payloads: the callers then use the latest state, which the txpool may refine.
+func CreatePendingStateReader(ctx context.Context, tx kv.Tx, filters *Filters, stateCache kvcache.Cache, historyV3 bool, chainName string) (state.StateReader, error) {
+	if filters == nil {
+		return nil, nil
+	}
+	block := filters.LastPendingBlock()
+	if block == nil {
+		return nil, nil
+	}
+	latest, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, fmt.Errorf("getting plain state block number: %w", err)
+	}
+	latestHash, err := rawdb.ReadCanonicalHash(tx, latest)
+	if err != nil {
+		return nil, err
+	}
+	if block.NumberU64() != latest+1 || block.ParentHash() != latestHash {
+		log.Debug("[rpc] Pending block is not on top of the latest block, using the latest state", "pending", block.NumberU64(), "latest", latest)
+		return nil, nil
+	}
+	parent, err := CreateStateReaderFromBlockNumber(ctx, tx, latest, true, 0, stateCache, historyV3, chainName)
+	if err != nil {
+		return nil, err
+	}
+	writes, err := filters.pendingWrites(ctx, tx, block, parent)
+	if err != nil || writes == nil {
+		return nil, err
+	}
+	return state.NewCachedReader(parent, writes), nil
+}
@@ -16,7 +16,18 @@ var UnknownBlockError = &rpc.CustomError{
 	Message: "Unknown block",
 }
 
-func GetLatestBlockNumber(tx kv.Tx) (uint64, error) {
+// GetLatestBlockNumber resolves the "latest" tag. With strictLatest (--rpc.latest.strict) it is the last block marked
+// VALID (executed on the canonical chain), otherwise the forkchoice head, which may still get invalidated and is
+// also reachable through the "optimistic" tag.
+func GetLatestBlockNumber(tx kv.Tx, strictLatest bool) (uint64, error) {
+	if strictLatest {
+		return GetLatestValidBlockNumber(tx)
+	}
+	return GetOptimisticBlockNumber(tx)
+}
+
+// GetOptimisticBlockNumber returns the head of the last forkchoice update, which might not be validated yet.
+func GetOptimisticBlockNumber(tx kv.Tx) (uint64, error) {
 	forkchoiceHeadHash := rawdb.ReadForkchoiceHead(tx)
 	if forkchoiceHeadHash != (libcommon.Hash{}) {
 		forkchoiceHeadNum := rawdb.ReadHeaderNumber(tx, forkchoiceHeadHash)
@@ -33,6 +44,23 @@ func GetLatestBlockNumber(tx kv.Tx) (uint64, error) {
 	return blockNum, nil
 }
 
+// GetLatestValidBlockNumber returns the highest canonical block that has been executed,
+// never going past the forkchoice head.
+func GetLatestValidBlockNumber(tx kv.Tx) (uint64, error) {
+	headNum, err := GetOptimisticBlockNumber(tx)
+	if err != nil {
+		return 0, err
+	}
+	executedNum, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return 0, fmt.Errorf("getting latest executed block number: %w", err)
+	}
+	if headNum < executedNum {
+		return headNum, nil
+	}
+	return executedNum, nil
+}
+
 func GetFinalizedBlockNumber(tx kv.Tx) (uint64, error) {
 	forkchoiceFinalizedHash := rawdb.ReadForkchoiceFinalized(tx)
 	if forkchoiceFinalizedHash != (libcommon.Hash{}) {
@@ -0,0 +1,54 @@
+package rpchelper
+
+import (
+	"context"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestStrictLatest(t *testing.T) {
+	db := memdb.NewTestDB(t)
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	// Forkchoice head is at 10, but only 8 blocks got executed so far.
+	head := libcommon.Hash{10}
+	require.NoError(t, rawdb.WriteHeaderNumber(tx, head, 10))
+	rawdb.WriteForkchoiceHead(tx, head)
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 8))
+
+	latest, err := GetLatestBlockNumber(tx, false)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), latest)
+
+	latest, err = GetLatestBlockNumber(tx, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), latest)
+	optimistic, err := GetOptimisticBlockNumber(tx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), optimistic)
+
+	// The optimistic head resolves to its block, its state isn't there before it is executed.
+	require.NoError(t, rawdb.WriteCanonicalHash(tx, head, 10))
+	n, hash, isLatest, err := GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.OptimisticBlockNumber), tx, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), n)
+	require.Equal(t, head, hash)
+	require.False(t, isLatest)
+	_, err = CreateStateReader(context.Background(), tx, rpc.BlockNumberOrHashWithNumber(rpc.OptimisticBlockNumber), 0, nil, nil, false, "", true)
+	require.ErrorContains(t, err, "not available")
+
+	// Once the head is executed both tags agree.
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Execution, 12))
+	latest, err = GetLatestBlockNumber(tx, true)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), latest)
+}
@@ -142,4 +142,5 @@ type Notifications struct {
 	Events               *Events
 	Accumulator          *Accumulator
 	StateChangesConsumer StateChangeConsumer
+	SnapshotsDownload    *SnapshotsDownload
 }
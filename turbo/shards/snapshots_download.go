@@ -0,0 +1,62 @@
+package shards
+
+import (
+	"sync"
+	"time"
+
+	proto_downloader "github.com/ledgerwatch/erigon-lib/gointerfaces/downloader"
+)
+
+// DownloadProgress is a snapshots download status observed by the Snapshots stage.
+type DownloadProgress struct {
+	Percent        float32
+	BytesCompleted uint64
+	BytesTotal     uint64
+	DownloadRate   uint64 // bytes/sec
+	Completed      bool
+	Updated        time.Time
+}
+
+// TimeLeft estimates the remaining download time, zero if it is unknown.
+func (p DownloadProgress) TimeLeft() time.Duration {
+	if p.Completed || p.DownloadRate == 0 || p.BytesTotal < p.BytesCompleted {
+		return 0
+	}
+	return time.Duration((p.BytesTotal-p.BytesCompleted)/p.DownloadRate) * time.Second
+}
+
+// SnapshotsDownload hands the download status seen by the Snapshots stage to the consumers of the same process, e.g.
+// eth_syncing of the embedded rpcdaemon. A nil *SnapshotsDownload records nothing.
+type SnapshotsDownload struct {
+	lock     sync.RWMutex
+	progress DownloadProgress
+	ok       bool
+}
+
+func (d *SnapshotsDownload) Update(stats *proto_downloader.StatsReply) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.progress = DownloadProgress{
+		Percent:        stats.Progress,
+		BytesCompleted: stats.BytesCompleted,
+		BytesTotal:     stats.BytesTotal,
+		DownloadRate:   stats.DownloadRate,
+		Completed:      stats.Completed,
+		Updated:        time.Now(),
+	}
+	d.ok = true
+}
+
+// Last returns the last status recorded by Update. The second result is false if nothing was recorded (snapshots
+// are disabled, or the stage hasn't asked the downloader yet).
+func (d *SnapshotsDownload) Last() (DownloadProgress, bool) {
+	if d == nil {
+		return DownloadProgress{}, false
+	}
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.progress, d.ok
+}
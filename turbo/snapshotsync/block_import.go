@@ -0,0 +1,381 @@
+package snapshotsync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+	common2 "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/background"
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
+	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// BlockSource yields blocks in ascending order. Next returns io.EOF when there are no more blocks.
+type BlockSource interface {
+	Next() (*types.Block, error)
+}
+
+type rlpBlockSource struct {
+	stream *rlp.Stream
+}
+
+// NewRLPBlockSource reads blocks from a stream of RLP-encoded blocks (the `import`/`export` format).
+func NewRLPBlockSource(r io.Reader) BlockSource {
+	return &rlpBlockSource{stream: rlp.NewStream(r, 0)}
+}
+
+func (s *rlpBlockSource) Next() (*types.Block, error) {
+	b := &types.Block{}
+	if err := s.stream.Decode(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// e2store entry types used by era1 files, see https://github.com/ethereum/go-ethereum/tree/master/internal/era
+const (
+	era1TypeVersion            = 0x3265
+	era1TypeCompressedHeader   = 0x03
+	era1TypeCompressedBody     = 0x04
+	era1TypeCompressedReceipts = 0x05
+	era1TypeTotalDifficulty    = 0x06
+	era1TypeAccumulator        = 0x07
+	era1TypeBlockIndex         = 0x3266
+
+	era1EntryHeaderLength = 8
+)
+
+type era1BlockSource struct {
+	r      *bufio.Reader
+	header [era1EntryHeaderLength]byte
+}
+
+// NewEra1BlockSource reads blocks from an era1 file: a sequence of e2store entries
+// `Version | (CompressedHeader | CompressedBody | CompressedReceipts | TotalDifficulty)* | Accumulator | BlockIndex`,
+// where compressed values are snappy-framed RLP. Receipts and total difficulty are not needed to build block snapshots and are skipped.
+func NewEra1BlockSource(r io.Reader) BlockSource {
+	return &era1BlockSource{r: bufio.NewReaderSize(r, 4*1024*1024)}
+}
+
+func (s *era1BlockSource) readEntry() (typ uint16, value []byte, err error) {
+	if _, err = io.ReadFull(s.r, s.header[:]); err != nil {
+		return 0, nil, err
+	}
+	typ = binary.LittleEndian.Uint16(s.header[0:2])
+	length := binary.LittleEndian.Uint32(s.header[2:6])
+	if reserved := binary.LittleEndian.Uint16(s.header[6:8]); reserved != 0 {
+		return 0, nil, fmt.Errorf("era1: reserved bytes of entry type %#x must be zero, got %d", typ, reserved)
+	}
+	value = make([]byte, length)
+	if _, err = io.ReadFull(s.r, value); err != nil {
+		return 0, nil, fmt.Errorf("era1: entry type %#x: %w", typ, err)
+	}
+	return typ, value, nil
+}
+
+func (s *era1BlockSource) Next() (*types.Block, error) {
+	var header *types.Header
+	for {
+		typ, value, err := s.readEntry()
+		if err != nil {
+			if errors.Is(err, io.EOF) && header != nil {
+				return nil, fmt.Errorf("era1: body of block %d is missing", header.Number.Uint64())
+			}
+			return nil, err
+		}
+		switch typ {
+		case era1TypeVersion, era1TypeCompressedReceipts, era1TypeTotalDifficulty:
+			continue
+		case era1TypeAccumulator, era1TypeBlockIndex:
+			if header != nil {
+				return nil, fmt.Errorf("era1: body of block %d is missing", header.Number.Uint64())
+			}
+			// block tuples are over, the rest of the file is only the accumulator and the block index
+			if _, err := io.Copy(io.Discard, s.r); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		case era1TypeCompressedHeader:
+			if header != nil {
+				return nil, fmt.Errorf("era1: body of block %d is missing", header.Number.Uint64())
+			}
+			header = &types.Header{}
+			if err := rlp.Decode(snappy.NewReader(bytes.NewReader(value)), header); err != nil {
+				return nil, fmt.Errorf("era1: decode header: %w", err)
+			}
+		case era1TypeCompressedBody:
+			if header == nil {
+				return nil, fmt.Errorf("era1: body without header")
+			}
+			body := &types.Body{}
+			if err := rlp.Decode(snappy.NewReader(bytes.NewReader(value)), body); err != nil {
+				return nil, fmt.Errorf("era1: decode body of block %d: %w", header.Number.Uint64(), err)
+			}
+			return types.NewBlockFromStorage(header.Hash(), header, body.Transactions, body.Uncles, body.Withdrawals), nil
+		default:
+			return nil, fmt.Errorf("era1: unexpected entry type %#x", typ)
+		}
+	}
+}
+
+type multiBlockSource struct {
+	sources []BlockSource
+}
+
+// MultiBlockSource reads the sources one after another, e.g. consecutive era1 files.
+func MultiBlockSource(sources ...BlockSource) BlockSource {
+	return &multiBlockSource{sources: sources}
+}
+
+func (s *multiBlockSource) Next() (*types.Block, error) {
+	for len(s.sources) > 0 {
+		b, err := s.sources[0].Next()
+		if errors.Is(err, io.EOF) {
+			s.sources = s.sources[1:]
+			continue
+		}
+		return b, err
+	}
+	return nil, io.EOF
+}
+
+// importSegment holds the compressors of the 3 block segments currently being written.
+type importSegment struct {
+	from, to uint64 // to is the nominal end, the last segment may end earlier
+	headers  *compress.Compressor
+	bodies   *compress.Compressor
+	txs      *compress.Compressor
+}
+
+func (s *importSegment) close() {
+	s.headers.Close()
+	s.bodies.Close()
+	s.txs.Close()
+}
+
+// ImportBlocks writes blocks of src directly into headers/bodies/transactions segments of blocksPerFile
+// blocks each, and builds indices of every segment as soon as it is compressed. The mutable database is
+// not involved at all, so archive nodes can be bootstrapped from historical chain data (e.g. era1 files)
+// without writing terabytes twice. src must start from genesis, because txn ids in the segments are
+// counted from it. Blocks are accepted in batches of Erigon2MinSegmentSize, a trailing incomplete batch is
+// left out of the snapshots: it returns the number of the first block which was not imported, such blocks
+// have to go through the regular sync.
+func ImportBlocks(ctx context.Context, src BlockSource, chainConfig *chain.Config, snapDir, tmpDir string, blocksPerFile uint64, workers int, lvl log.Lvl) (uint64, error) {
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+
+	chainID, _ := uint256.FromBig(chainConfig.ChainID)
+	batch := make([]*types.Block, 0, snaptype.Erigon2MinSegmentSize)
+	var seg *importSegment
+	defer func() {
+		if seg != nil {
+			seg.close()
+		}
+	}()
+	var blockNum, nextTxID uint64
+	for {
+		b, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return blockNum, fmt.Errorf("at block %d: %w", blockNum, err)
+		}
+		if b.NumberU64() != blockNum+uint64(len(batch)) {
+			return blockNum, fmt.Errorf("blocks must be consecutive: expected %d, got %d", blockNum+uint64(len(batch)), b.NumberU64())
+		}
+		batch = append(batch, b)
+		if len(batch) < snaptype.Erigon2MinSegmentSize {
+			continue
+		}
+
+		if seg == nil {
+			if seg, err = newImportSegment(ctx, snapDir, tmpDir, blockNum, blockNum+blocksPerFile, workers, lvl); err != nil {
+				return blockNum, err
+			}
+		}
+		if nextTxID, err = addImportBatch(ctx, seg, batch, chainConfig, nextTxID, workers); err != nil {
+			return blockNum, err
+		}
+		blockNum += uint64(len(batch))
+		batch = batch[:0]
+		if blockNum == seg.to {
+			err = finishImportSegment(ctx, seg, blockNum, *chainID, snapDir, tmpDir, lvl)
+			seg.close()
+			seg = nil
+			if err != nil {
+				return blockNum, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return blockNum, ctx.Err()
+		case <-logEvery.C:
+			log.Log(lvl, "[snapshots] Importing blocks", "block num", blockNum, "txs", nextTxID)
+		default:
+		}
+	}
+	if len(batch) > 0 {
+		log.Warn("[snapshots] Import: trailing blocks are not aligned to segment boundary, they won't be part of snapshots",
+			"from", blockNum, "to", blockNum+uint64(len(batch)))
+	}
+	if seg != nil {
+		err := finishImportSegment(ctx, seg, blockNum, *chainID, snapDir, tmpDir, lvl)
+		seg.close()
+		seg = nil
+		if err != nil {
+			return blockNum, err
+		}
+	}
+	return blockNum, nil
+}
+
+func newImportSegment(ctx context.Context, snapDir, tmpDir string, from, to uint64, workers int, lvl log.Lvl) (*importSegment, error) {
+	seg := &importSegment{from: from, to: to}
+	var err error
+	if seg.headers, err = compress.NewCompressor(ctx, "Snapshot Headers", filepath.Join(snapDir, snaptype.SegmentFileName(from, to, snaptype.Headers)), tmpDir, compress.MinPatternScore, workers, lvl); err != nil {
+		return nil, err
+	}
+	if seg.bodies, err = compress.NewCompressor(ctx, "Snapshot Bodies", filepath.Join(snapDir, snaptype.SegmentFileName(from, to, snaptype.Bodies)), tmpDir, compress.MinPatternScore, workers, lvl); err != nil {
+		seg.headers.Close()
+		return nil, err
+	}
+	if seg.txs, err = compress.NewCompressor(ctx, "Snapshot Txs", filepath.Join(snapDir, snaptype.SegmentFileName(from, to, snaptype.Transactions)), tmpDir, compress.MinPatternScore, workers, lvl); err != nil {
+		seg.headers.Close()
+		seg.bodies.Close()
+		return nil, err
+	}
+	return seg, nil
+}
+
+// addImportBatch appends blocks to the segment in the same format as DumpHeaders/DumpBodies/DumpTxs produce,
+// and returns the id of the next txn. Senders are recovered in parallel.
+func addImportBatch(ctx context.Context, seg *importSegment, blocks []*types.Block, chainConfig *chain.Config, nextTxID uint64, workers int) (uint64, error) {
+	senders := make([][]common2.Address, len(blocks))
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(workers)
+	for i := range blocks {
+		i := i
+		g.Go(func() error {
+			b := blocks[i]
+			signer := types.MakeSigner(chainConfig, b.NumberU64())
+			senders[i] = make([]common2.Address, len(b.Transactions()))
+			for j, txn := range b.Transactions() {
+				sender, err := signer.Sender(txn)
+				if err != nil {
+					return fmt.Errorf("block %d, txn %d: %w", b.NumberU64(), j, err)
+				}
+				senders[i][j] = sender
+			}
+			select {
+			case <-gCtx.Done():
+				return gCtx.Err()
+			default:
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nextTxID, err
+	}
+
+	var valueBuf []byte
+	for i, b := range blocks {
+		headerRLP, err := rlp.EncodeToBytes(b.Header())
+		if err != nil {
+			return nextTxID, err
+		}
+		valueBuf = append(valueBuf[:0], b.Hash()[0]) // first_byte_of_header_hash + header_rlp
+		valueBuf = append(valueBuf, headerRLP...)
+		if err := seg.headers.AddWord(valueBuf); err != nil {
+			return nextTxID, err
+		}
+
+		txs := b.Transactions()
+		bodyRLP, err := rlp.EncodeToBytes(&types.BodyForStorage{
+			BaseTxId:    nextTxID,
+			TxAmount:    uint32(len(txs)) + 2, // 2 system txs: at the beginning and at the end of each block
+			Uncles:      b.Uncles(),
+			Withdrawals: b.Withdrawals(),
+		})
+		if err != nil {
+			return nextTxID, err
+		}
+		if err := seg.bodies.AddWord(bodyRLP); err != nil {
+			return nextTxID, err
+		}
+
+		if err := seg.txs.AddWord(nil); err != nil {
+			return nextTxID, err
+		}
+		for j, txn := range txs {
+			txnRLP, err := rlp.EncodeToBytes(txn)
+			if err != nil {
+				return nextTxID, fmt.Errorf("block %d, txn %d: %w", b.NumberU64(), j, err)
+			}
+			// first tx byte => sender adress => tx rlp
+			valueBuf = append(valueBuf[:0], txn.Hash()[0])
+			valueBuf = append(valueBuf, senders[i][j][:]...)
+			valueBuf = append(valueBuf, txnRLP...)
+			if err := seg.txs.AddWord(valueBuf); err != nil {
+				return nextTxID, err
+			}
+		}
+		if err := seg.txs.AddWord(nil); err != nil {
+			return nextTxID, err
+		}
+		nextTxID += uint64(len(txs)) + 2
+	}
+	return nextTxID, nil
+}
+
+// finishImportSegment compresses the segment files and builds their indices. If the segment ended before its
+// nominal end, files are renamed to reflect the actual range.
+func finishImportSegment(ctx context.Context, seg *importSegment, to uint64, chainID uint256.Int, snapDir, tmpDir string, lvl log.Lvl) error {
+	for _, t := range snaptype.AllSnapshotTypes {
+		var c *compress.Compressor
+		switch t {
+		case snaptype.Headers:
+			c = seg.headers
+		case snaptype.Bodies:
+			c = seg.bodies
+		case snaptype.Transactions:
+			c = seg.txs
+		}
+		if err := c.Compress(); err != nil {
+			return fmt.Errorf("compress: %w", err)
+		}
+		if to != seg.to {
+			if err := os.Rename(filepath.Join(snapDir, snaptype.SegmentFileName(seg.from, seg.to, t)), filepath.Join(snapDir, snaptype.SegmentFileName(seg.from, to, t))); err != nil {
+				return err
+			}
+		}
+	}
+	// transactions index reads bodies segment, so indices are built after all segments of the range are compressed
+	p := &background.Progress{}
+	for _, t := range snaptype.AllSnapshotTypes {
+		sn := snaptype.FileInfo{Path: filepath.Join(snapDir, snaptype.SegmentFileName(seg.from, to, t)), From: seg.from, To: to, T: t}
+		if err := buildIdx(ctx, sn, chainID, tmpDir, p, lvl); err != nil {
+			return err
+		}
+	}
+	log.Log(lvl, "[snapshots] Imported segment", "from", seg.from, "to", to)
+	return nil
+}
@@ -0,0 +1,137 @@
+package snapshotsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+func generateImportBlocks(t *testing.T, n int) ([]*types.Block, libcommon.Address) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.LatestSignerForChainID(params.TestChainConfig.ChainID)
+	blocks := make([]*types.Block, n)
+	parent := libcommon.Hash{}
+	nonce := uint64(0)
+	for i := 0; i < n; i++ {
+		var txs []types.Transaction
+		if i%100 == 1 {
+			for j := 0; j < 3; j++ {
+				txn, err := types.SignTx(types.NewTransaction(nonce, libcommon.Address{1}, uint256.NewInt(1), 21000, uint256.NewInt(1), nil), *signer, key)
+				require.NoError(t, err)
+				txs = append(txs, txn)
+				nonce++
+			}
+		}
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), GasLimit: 1_000_000}
+		blocks[i] = types.NewBlock(header, txs, nil, nil, nil)
+		parent = blocks[i].Hash()
+	}
+	return blocks, sender
+}
+
+type sliceBlockSource struct {
+	blocks []*types.Block
+}
+
+func (s *sliceBlockSource) Next() (*types.Block, error) {
+	if len(s.blocks) == 0 {
+		return nil, io.EOF
+	}
+	b := s.blocks[0]
+	s.blocks = s.blocks[1:]
+	return b, nil
+}
+
+func writeEra1Entry(t *testing.T, w *bytes.Buffer, typ uint16, v interface{}) {
+	var value bytes.Buffer
+	if v != nil {
+		sw := snappy.NewBufferedWriter(&value)
+		require.NoError(t, rlp.Encode(sw, v))
+		require.NoError(t, sw.Close())
+	}
+	var header [era1EntryHeaderLength]byte
+	binary.LittleEndian.PutUint16(header[0:2], typ)
+	binary.LittleEndian.PutUint32(header[2:6], uint32(value.Len()))
+	w.Write(header[:])
+	w.Write(value.Bytes())
+}
+
+func TestEra1BlockSource(t *testing.T) {
+	blocks, _ := generateImportBlocks(t, 3)
+	var buf bytes.Buffer
+	writeEra1Entry(t, &buf, era1TypeVersion, nil)
+	for _, b := range blocks {
+		writeEra1Entry(t, &buf, era1TypeCompressedHeader, b.Header())
+		writeEra1Entry(t, &buf, era1TypeCompressedBody, b.Body())
+		writeEra1Entry(t, &buf, era1TypeCompressedReceipts, types.Receipts{})
+		writeEra1Entry(t, &buf, era1TypeTotalDifficulty, nil)
+	}
+	writeEra1Entry(t, &buf, era1TypeAccumulator, nil)
+	writeEra1Entry(t, &buf, era1TypeBlockIndex, nil)
+
+	src := NewEra1BlockSource(&buf)
+	for _, expect := range blocks {
+		b, err := src.Next()
+		require.NoError(t, err)
+		require.Equal(t, expect.Hash(), b.Hash())
+		require.Equal(t, len(expect.Transactions()), len(b.Transactions()))
+	}
+	_, err := src.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestImportBlocks(t *testing.T) {
+	dir, require := t.TempDir(), require.New(t)
+	blocks, sender := generateImportBlocks(t, 2_500)
+
+	next, err := ImportBlocks(context.Background(), &sliceBlockSource{blocks: blocks}, params.TestChainConfig, dir, dir, 3_000, 2, log.LvlDebug)
+	require.NoError(err)
+	require.Equal(uint64(2_000), next)
+
+	s := NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, dir)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	require.Equal(uint64(1_999), s.BlocksAvailable())
+
+	_, tx := memdb.NewTestTx(t)
+	br := NewBlockReaderWithSnapshots(s)
+	ctx := context.Background()
+	for _, i := range []uint64{0, 1, 1_001, 1_999} {
+		h, err := br.HeaderByNumber(ctx, tx, i)
+		require.NoError(err)
+		require.Equal(blocks[i].Hash(), h.Hash())
+
+		body, err := br.BodyWithTransactions(ctx, tx, blocks[i].Hash(), i)
+		require.NoError(err)
+		require.Equal(len(blocks[i].Transactions()), len(body.Transactions))
+		for j, txn := range body.Transactions {
+			require.Equal(blocks[i].Transactions()[j].Hash(), txn.Hash())
+			from, ok := txn.GetSender()
+			require.True(ok)
+			require.Equal(sender, from)
+		}
+	}
+
+	txnHash := blocks[1_001].Transactions()[2].Hash()
+	blockNum, ok, err := br.TxnLookup(ctx, tx, txnHash)
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(uint64(1_001), blockNum)
+}
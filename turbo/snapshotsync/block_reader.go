@@ -391,6 +391,9 @@ func (back *BlockReaderWithSnapshots) BodyWithTransactions(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	if body == nil {
+		return nil, back.sn.checkBodyPruned(blockHeight)
+	}
 	return body, nil
 }
 
@@ -421,6 +424,9 @@ func (back *BlockReaderWithSnapshots) Body(ctx context.Context, tx kv.Getter, ha
 		return body, txAmount, nil
 	}
 	body, _, txAmount = rawdb.ReadBody(tx, hash, blockHeight)
+	if body == nil {
+		return nil, 0, back.sn.checkBodyPruned(blockHeight)
+	}
 	return body, txAmount, nil
 }
 
@@ -491,6 +497,9 @@ func (back *BlockReaderWithSnapshots) BlockWithSenders(ctx context.Context, tx k
 		if err != nil {
 			return nil, nil, err
 		}
+		if block == nil && h != nil {
+			return nil, nil, back.sn.checkBodyPruned(blockHeight)
+		}
 		return block, senders, nil
 	}
 	return rawdb.NonCanonicalBlockWithSenders(tx, hash, blockHeight)
@@ -760,7 +769,7 @@ func (back *BlockReaderWithSnapshots) TxnByIdxInBlock(ctx context.Context, tx kv
 		return nil, err
 	}
 	if b == nil {
-		return nil, nil
+		return nil, back.sn.checkBodyPruned(blockNum)
 	}
 
 	txn, err = rawdb.CanonicalTxnByID(tx, b.BaseTxId+1+uint64(i))
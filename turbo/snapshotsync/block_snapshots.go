@@ -16,6 +16,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/c2h5oh/datasize"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
 	common2 "github.com/ledgerwatch/erigon-lib/common"
@@ -40,11 +41,13 @@ import (
 	"golang.org/x/sync/semaphore"
 
 	"github.com/ledgerwatch/erigon/cmd/hack/tool/fromdb"
+	"github.com/ledgerwatch/erigon/common/budget"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/crypto/cryptopool"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/ethconfig/estimate"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
@@ -368,6 +371,7 @@ type RoSnapshots struct {
 	dir         string
 	segmentsMax atomic.Uint64 // all types of .seg files are available - up to this number
 	idxMax      atomic.Uint64 // all types of .idx files are available - up to this number
+	bodiesFrom  atomic.Uint64 // bodies and transactions .seg files are available - from this number, see PruneBodies
 	cfg         ethconfig.Snapshot
 }
 
@@ -387,6 +391,7 @@ func (s *RoSnapshots) IndicesReady() bool      { return s.indicesReady.Load() }
 func (s *RoSnapshots) IndicesMax() uint64      { return s.idxMax.Load() }
 func (s *RoSnapshots) SegmentsMax() uint64     { return s.segmentsMax.Load() }
 func (s *RoSnapshots) BlocksAvailable() uint64 { return cmp.Min(s.segmentsMax.Load(), s.idxMax.Load()) }
+func (s *RoSnapshots) BodiesFrom() uint64      { return s.bodiesFrom.Load() }
 func (s *RoSnapshots) LogStat() {
 	var m runtime.MemStats
 	dbg.ReadMemStats(&m)
@@ -692,6 +697,11 @@ Loop:
 	if segmentsMaxSet {
 		s.segmentsMax.Store(segmentsMax)
 	}
+	var bodiesFrom uint64
+	if len(s.Bodies.segments) > 0 {
+		bodiesFrom = s.Bodies.segments[0].ranges.from
+	}
+	s.bodiesFrom.Store(bodiesFrom)
 	s.segmentsReady.Store(true)
 	s.idxMax.Store(s.idxAvailability())
 	s.indicesReady.Store(true)
@@ -699,6 +709,50 @@ Loop:
 	return nil
 }
 
+// PruneBodies - removes the bodies and transactions segments of blocks below `to`, keeping the headers segments.
+// Only segments of full size are removed: smaller ones may still be merged.
+func (s *RoSnapshots) PruneBodies(to uint64) error {
+	var keep, toDel []string
+	for _, fName := range s.Files() {
+		f, err := snaptype.ParseFileName(s.dir, fName)
+		if err != nil {
+			return err
+		}
+		if f.T != snaptype.Headers && f.To <= to && f.To-f.From == snaptype.Erigon2SegmentSize {
+			toDel = append(toDel, f.Path)
+			continue
+		}
+		keep = append(keep, fName)
+	}
+	if len(toDel) == 0 {
+		return nil
+	}
+	if err := s.ReopenList(keep, false); err != nil {
+		return err
+	}
+	for _, f := range toDel {
+		withoutExt := strings.TrimSuffix(f, filepath.Ext(f))
+		_ = os.Remove(f)
+		_ = os.Remove(f + ".torrent") // stop seeding it after restart of downloader
+		_ = os.Remove(withoutExt + ".idx")
+		if strings.HasSuffix(withoutExt, snaptype.Transactions.String()) {
+			_ = os.Remove(withoutExt + "-to-block.idx")
+		}
+	}
+	return nil
+}
+
+// ErrHistoryPruned - body, transactions and receipts of the block are not available: their snapshots were pruned
+var ErrHistoryPruned = errors.New("block history was pruned")
+
+// checkBodyPruned - after a body was not found, tells why if it's because of PruneBodies
+func (s *RoSnapshots) checkBodyPruned(blockNum uint64) error {
+	if from := s.BodiesFrom(); blockNum < from {
+		return fmt.Errorf("%w: block %d, bodies are available from block %d", ErrHistoryPruned, blockNum, from)
+	}
+	return nil
+}
+
 func (s *RoSnapshots) Ranges() (ranges []Range) {
 	_ = s.Headers.View(func(segments []*HeaderSegment) error {
 		for _, sn := range segments {
@@ -939,8 +993,8 @@ func BuildMissedIndices(logPrefix string, ctx context.Context, dirs datadir.Dirs
 	}
 }
 
-func noGaps(in []snaptype.FileInfo) (out []snaptype.FileInfo, missingSnapshots []Range) {
-	var prevTo uint64
+func noGaps(in []snaptype.FileInfo, from uint64) (out []snaptype.FileInfo, missingSnapshots []Range) {
+	prevTo := from
 	for _, f := range in {
 		if f.To <= prevTo {
 			continue
@@ -997,21 +1051,35 @@ func noOverlaps(in []snaptype.FileInfo) (res []snaptype.FileInfo) {
 	return res
 }
 
+// Segments - returns the segments which make block ranges available: with all types of segments, or with headers only
+// below the first range having all types - when bodies and transactions of these blocks were pruned, see RoSnapshots.PruneBodies.
 func Segments(dir string) (res []snaptype.FileInfo, missingSnapshots []Range, err error) {
 	list, err := snaptype.Segments(dir)
 	if err != nil {
 		return nil, missingSnapshots, err
 	}
+	var bodiesFrom uint64
 	{
 		var l []snaptype.FileInfo
-		var m []Range
 		for _, f := range list {
 			if f.T != snaptype.Headers {
 				continue
 			}
 			l = append(l, f)
 		}
-		l, m = noGaps(noOverlaps(allTypeOfSegmentsMustExist(dir, l)))
+		complete := noOverlaps(allTypeOfSegmentsMustExist(dir, l))
+		if len(complete) > 0 {
+			bodiesFrom = complete[0].From
+		}
+		var headersOnly []snaptype.FileInfo
+		for _, f := range l {
+			if f.To <= bodiesFrom && !dir2.FileExist(filepath.Join(dir, snaptype.SegmentFileName(f.From, f.To, snaptype.Bodies))) &&
+				!dir2.FileExist(filepath.Join(dir, snaptype.SegmentFileName(f.From, f.To, snaptype.Transactions))) {
+				headersOnly = append(headersOnly, f)
+			}
+		}
+		var m []Range
+		l, m = noGaps(append(noOverlaps(headersOnly), complete...), 0)
 		res = append(res, l...)
 		missingSnapshots = append(missingSnapshots, m...)
 	}
@@ -1023,7 +1091,7 @@ func Segments(dir string) (res []snaptype.FileInfo, missingSnapshots []Range, er
 			}
 			l = append(l, f)
 		}
-		l, _ = noGaps(noOverlaps(allTypeOfSegmentsMustExist(dir, l)))
+		l, _ = noGaps(noOverlaps(allTypeOfSegmentsMustExist(dir, l)), bodiesFrom)
 		res = append(res, l...)
 	}
 	{
@@ -1034,7 +1102,7 @@ func Segments(dir string) (res []snaptype.FileInfo, missingSnapshots []Range, er
 			}
 			l = append(l, f)
 		}
-		l, _ = noGaps(noOverlaps(allTypeOfSegmentsMustExist(dir, l)))
+		l, _ = noGaps(noOverlaps(allTypeOfSegmentsMustExist(dir, l)), bodiesFrom)
 		res = append(res, l...)
 	}
 
@@ -1155,7 +1223,13 @@ func (br *BlockRetire) RetireBlocksInBackground(ctx context.Context, forwardProg
 			return
 		}
 
-		err := br.RetireBlocks(ctx, blockFrom, blockTo, lvl)
+		release, err := budget.Default.Acquire(ctx, "retire blocks", budget.Low, datasize.ByteSize(estimate.CompressSnapshot)*datasize.ByteSize(br.workers))
+		if err != nil {
+			return
+		}
+		defer release()
+
+		err = br.RetireBlocks(ctx, blockFrom, blockTo, lvl)
 		if err != nil {
 			br.BackgroundResult.Set(fmt.Errorf("retire blocks error: %w, fromBlock=%d, toBlock=%d", err, blockFrom, blockTo))
 		} else {
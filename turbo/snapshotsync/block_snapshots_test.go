@@ -7,6 +7,7 @@ import (
 	"testing/fstest"
 
 	"github.com/holiman/uint256"
+	dir2 "github.com/ledgerwatch/erigon-lib/common/dir"
 	"github.com/ledgerwatch/erigon-lib/compress"
 	"github.com/ledgerwatch/erigon-lib/downloader/snaptype"
 	"github.com/ledgerwatch/erigon-lib/recsplit"
@@ -200,6 +201,50 @@ func TestOpenAllSnapshot(t *testing.T) {
 	require.NoError(err)
 }
 
+func TestPruneBodies(t *testing.T) {
+	dir, require := t.TempDir(), require.New(t)
+	createFile := func(from, to uint64) {
+		for _, snT := range snaptype.AllSnapshotTypes {
+			createTestSegmentFile(t, from, to, snT, dir)
+		}
+	}
+	createFile(0, 500_000)
+	createFile(500_000, 1_000_000)
+	createFile(1_000_000, 1_100_000)
+
+	cfg := ethconfig.Snapshot{Enabled: true}
+	s := NewRoSnapshots(cfg, dir)
+	defer s.Close()
+	require.NoError(s.ReopenFolder())
+	require.NoError(s.PruneBodies(900_000))
+	require.Equal(500_000, int(s.BodiesFrom()))
+	require.False(dir2.FileExist(filepath.Join(dir, snaptype.SegmentFileName(0, 500_000, snaptype.Bodies))))
+	require.False(dir2.FileExist(filepath.Join(dir, snaptype.IdxFileName(0, 500_000, snaptype.Transactions2Block.String()))))
+	require.True(dir2.FileExist(filepath.Join(dir, snaptype.SegmentFileName(0, 500_000, snaptype.Headers))))
+	require.True(dir2.FileExist(filepath.Join(dir, snaptype.SegmentFileName(1_000_000, 1_100_000, snaptype.Bodies))))
+
+	ok, err := s.ViewHeaders(10, func(sn *HeaderSegment) error { return nil })
+	require.NoError(err)
+	require.True(ok)
+	ok, err = s.ViewBodies(10, func(sn *BodySegment) error { return nil })
+	require.NoError(err)
+	require.False(ok)
+	require.ErrorIs(s.checkBodyPruned(10), ErrHistoryPruned)
+	require.NoError(s.checkBodyPruned(500_000))
+
+	// headers of pruned blocks are still available after restart
+	s2 := NewRoSnapshots(cfg, dir)
+	defer s2.Close()
+	require.NoError(s2.ReopenFolder())
+	require.Equal(3, len(s2.Headers.segments))
+	require.Equal(2, len(s2.Bodies.segments))
+	require.Equal(500_000, int(s2.BodiesFrom()))
+	require.Equal(1_100_000-1, int(s2.BlocksAvailable()))
+	_, missing, err := Segments(dir)
+	require.NoError(err)
+	require.Empty(missing)
+}
+
 func TestParseCompressedFileName(t *testing.T) {
 	require := require.New(t)
 	fs := fstest.MapFS{
@@ -379,6 +379,7 @@ func NewDefaultStages(ctx context.Context,
 			snapDownloader,
 			blockReader,
 			notifications.Events,
+			notifications.SnapshotsDownload,
 			engine,
 			cfg.HistoryV3,
 			agg,
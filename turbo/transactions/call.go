@@ -38,14 +38,7 @@ func DoCall(
 	headerReader services.HeaderReader,
 	callTimeout time.Duration,
 ) (*core.ExecutionResult, error) {
-	// todo: Pending state is only known by the miner
-	/*
-		if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
-			block, state, _ := b.eth.miner.Pending()
-			return state, block.Header(), nil
-		}
-	*/
-
+	// stateReader reads the pending state for the pending block, see rpchelper.CreatePendingStateReader
 	state := state.New(stateReader)
 
 	// Override the fields of specified contracts before execution.